@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/kube-vip/kube-vip/pkg/k8s"
+	"github.com/kube-vip/kube-vip/pkg/lint"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// lintFiles are manifests to lint instead of the services in the cluster
+var lintFiles []string
+
+// lintStrict will fail on warnings as well as errors
+var lintStrict bool
+
+func init() {
+	kubeVipLint.Flags().StringSliceVarP(&lintFiles, "filename", "f", []string{}, "Manifests (files or directories) to lint instead of the services in the cluster")
+	kubeVipLint.Flags().BoolVar(&lintStrict, "strict", false, "Fail if there are any warnings as well as errors")
+	kubeVipLint.Flags().BoolVar(&inCluster, "inCluster", false, "Use the incluster token to authenticate to Kubernetes")
+}
+
+var kubeVipLint = &cobra.Command{
+	Use:   "lint",
+	Short: "Check services for malformed or conflicting kube-vip annotations",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Set the logging level for all subsequent functions
+		log.SetLevel(log.Level(logLevel))
+
+		var services []v1.Service
+		if len(lintFiles) != 0 {
+			var err error
+			services, err = lint.LoadServices(lintFiles)
+			if err != nil {
+				log.Fatalln(err)
+			}
+		} else {
+			clientSet, err := k8s.NewClientset(initConfig.K8sConfigFile, inCluster, "")
+			if err != nil {
+				log.Fatalf("unable to create Kubernetes client: %v", err)
+			}
+			list, err := clientSet.CoreV1().Services(v1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				log.Fatalf("unable to list services: %v", err)
+			}
+			services = list.Items
+		}
+
+		var errors, warnings int
+		for _, finding := range lint.Services(services) {
+			fmt.Println(finding)
+			if finding.Severity == lint.Error {
+				errors++
+			} else {
+				warnings++
+			}
+		}
+		fmt.Printf("checked %d service(s), %d error(s), %d warning(s)\n", len(services), errors, warnings)
+
+		if errors != 0 || (lintStrict && warnings != 0) {
+			os.Exit(1)
+		}
+	},
+}
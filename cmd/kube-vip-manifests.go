@@ -3,29 +3,92 @@ package cmd
 import (
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/kube-vip/kube-vip/pkg/ipam"
 	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/servicepolicy"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 )
 
 // manifests will eventually deprecate the kubeadm set of subcommands
 // manifests will be used to generate:
 // - Pod spec manifest, mainly used for a static pod (kubeadm)
 // - Daemonset manifest, mainly used to run kube-vip as a deamonset within Kubernetes (k3s/rke)
+// - Kustomize base + overlays, for layering environment specific patches on top of the above
 
 // var inCluster bool
 var taint bool
 
+// manifestExtrasPath is the path to a file of additional containers, volumes and environment for the manifest
+var manifestExtrasPath string
+
+// manifestExtraEnv is additional KEY=VALUE environment for the kube-vip container
+var manifestExtraEnv []string
+
+// Scheduling options for the generated daemonset
+var (
+	dsTolerations       []string
+	dsNodeSelector      map[string]string
+	dsAffinityPath      string
+	dsPriorityClassName string
+	dsRequests          map[string]string
+	dsLimits            map[string]string
+)
+
+// rbacEgress adds the permissions needed by services with egress enabled to the RBAC manifest
+var rbacEgress bool
+
+// Addresses and format for the generated firewall rules
+var (
+	firewallFormat     string
+	firewallAPIServers []string
+	firewallPrometheus []string
+)
+
+// securityProfileFormat is the format of the generated security profile, seccomp or apparmor
+var securityProfileFormat string
+
+// redistributionFormat is the routing daemon that the redistribution configuration is generated for, frr or bird
+var redistributionFormat string
+
+// kustomizeOutput is the directory that the kustomize base and overlays are written to
+var kustomizeOutput string
+
 func init() {
 	kubeManifest.PersistentFlags().BoolVar(&inCluster, "inCluster", false, "Use the incluster token to authenticate to Kubernetes")
+	kubeManifest.PersistentFlags().StringVar(&manifestExtrasPath, "extras", "", "Path to a yaml file of additional containers, volumes, volumeMounts and env to add to the manifest")
+	kubeManifest.PersistentFlags().StringSliceVar(&manifestExtraEnv, "extraEnv", []string{}, "Additional environment for the kube-vip container, format: KEY=VALUE")
 	kubeManifestDaemon.PersistentFlags().BoolVar(&taint, "taint", false, "Taint the manifest for only running on control planes")
+	kubeManifestDaemon.PersistentFlags().StringSliceVar(&dsTolerations, "tolerations", []string{}, "Tolerations for the daemonset, format: key[=value]:Effect (leave out the key or effect to tolerate all)")
+	kubeManifestDaemon.PersistentFlags().StringToStringVar(&dsNodeSelector, "nodeSelector", map[string]string{}, "Node selector for the daemonset, format: key=value")
+	kubeManifestDaemon.PersistentFlags().StringVar(&dsAffinityPath, "affinity", "", "Path to a yaml file of the affinity for the daemonset (replaces the affinity from --taint)")
+	kubeManifestDaemon.PersistentFlags().StringVar(&dsPriorityClassName, "priorityClassName", "", "Priority class of the daemonset pods, e.g. system-node-critical")
+	kubeManifestDaemon.PersistentFlags().StringToStringVar(&dsRequests, "requests", map[string]string{}, "Resource requests for kube-vip, format: cpu=100m,memory=64Mi")
+	kubeManifestDaemon.PersistentFlags().StringToStringVar(&dsLimits, "limits", map[string]string{}, "Resource limits for kube-vip, format: cpu=200m,memory=128Mi")
+	kubeManifestRbac.PersistentFlags().BoolVar(&rbacEgress, "egress", false, "Include the permissions needed by services with egress enabled")
+	kubeManifestFirewall.PersistentFlags().StringVar(&firewallFormat, "format", "networkpolicy", "Format of the firewall rules, networkpolicy or nftables")
+	kubeManifestFirewall.PersistentFlags().StringSliceVar(&firewallAPIServers, "apiServerCIDR", []string{}, "CIDRs of the Kubernetes API servers (defaults to any address)")
+	kubeManifestFirewall.PersistentFlags().StringSliceVar(&firewallPrometheus, "prometheusCIDR", []string{}, "CIDRs allowed to scrape the kube-vip metrics (defaults to any address)")
+	kubeManifest.PersistentFlags().BoolVar(&initConfig.ManifestSecurityProfiles, "securityProfiles", false, "Set the seccomp and AppArmor profiles from \"manifest securityprofiles\", they have to be installed on every node")
+	kubeManifestSecurityProfiles.PersistentFlags().StringVar(&securityProfileFormat, "format", "seccomp", "Format of the security profile, seccomp or apparmor")
+	kubeManifestRedistribution.PersistentFlags().StringVar(&redistributionFormat, "format", "frr", "Routing daemon of the configuration, frr or bird")
+	kubeManifestKustomize.PersistentFlags().BoolVar(&taint, "taint", false, "Taint the daemonset overlay for only running on control planes")
+	kubeManifestKustomize.PersistentFlags().StringVarP(&kustomizeOutput, "output", "o", "", "Directory to write the kustomize base and overlays to (defaults to stdout)")
 
 	kubeManifest.AddCommand(kubeManifestPod)
 	kubeManifest.AddCommand(kubeManifestDaemon)
 	kubeManifest.AddCommand(kubeManifestRbac)
+	kubeManifest.AddCommand(kubeManifestKustomize)
+	kubeManifest.AddCommand(kubeManifestHelmValues)
+	kubeManifest.AddCommand(kubeManifestCRD)
+	kubeManifest.AddCommand(kubeManifestFirewall)
+	kubeManifest.AddCommand(kubeManifestSecurityProfiles)
+	kubeManifest.AddCommand(kubeManifestRedistribution)
 }
 
 var kubeManifest = &cobra.Command{
@@ -65,6 +128,10 @@ var kubeManifestPod = &cobra.Command{
 			}
 		}
 
+		if err := loadManifestExtras(&initConfig); err != nil {
+			log.Fatalln(err)
+		}
+
 		cfg := kubevip.GeneratePodManifestFromConfig(&initConfig, Release.Version, inCluster)
 		fmt.Println(cfg) // output manifest to stdout
 	},
@@ -100,6 +167,14 @@ var kubeManifestDaemon = &cobra.Command{
 			}
 		}
 
+		if err := loadManifestExtras(&initConfig); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := loadManifestScheduling(&initConfig); err != nil {
+			log.Fatalln(err)
+		}
+
 		cfg := kubevip.GenerateDaemonsetManifestFromConfig(&initConfig, Release.Version, inCluster, taint)
 		fmt.Println(cfg) // output manifest to stdout
 	},
@@ -108,6 +183,7 @@ var kubeManifestDaemon = &cobra.Command{
 var kubeManifestRbac = &cobra.Command{
 	Use:   "rbac",
 	Short: "Generate an RBAC Manifest",
+	Long:  "Generate the ServiceAccount, ClusterRole and Roles with only the permissions needed by the enabled features",
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
 
@@ -133,12 +209,238 @@ var kubeManifestRbac = &cobra.Command{
 			}
 		}
 
-		cfg := kubevip.GenerateSA()
-		b, _ := yaml.Marshal(cfg)
-		fmt.Println(string(b)) // output manifest to stdout
+		cfg := kubevip.GenerateRBACManifestFromConfig(&initConfig, rbacEgress)
+		fmt.Print(cfg) // output manifest to stdout
+	},
+}
+
+var kubeManifestCRD = &cobra.Command{
+	Use:   "crd",
+	Short: "Generate the KubeVipServicePolicy and KubeVipIPPool CustomResourceDefinitions",
+	Long: `Generate the KubeVipServicePolicy and KubeVipIPPool CustomResourceDefinitions, policies are only used when the
+CRDConfig feature gate is enabled and pools when ipam is enabled`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// output manifests to stdout
+		fmt.Print(servicepolicy.CRD)
+		fmt.Println("---")
+		fmt.Print(ipam.CRD)
+	},
+}
+
+var kubeManifestFirewall = &cobra.Command{
+	Use:   "firewall",
+	Short: "Generate a NetworkPolicy or host firewall rules that only allow the flows kube-vip needs",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Set the logging level for all subsequent functions
+		log.SetLevel(log.Level(logLevel))
+		if err := kubevip.ParseEnvironment(&initConfig); err != nil {
+			log.Fatalf("Error parsing environment from config: %v", err)
+		}
+
+		flows, err := kubevip.GenerateFlows(&initConfig, kubevip.FirewallOptions{
+			APIServers: firewallAPIServers,
+			Prometheus: firewallPrometheus,
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		switch firewallFormat {
+		case "networkpolicy":
+			fmt.Print(kubevip.GenerateNetworkPolicy(&initConfig, flows)) // output manifest to stdout
+		case "nftables":
+			fmt.Print(kubevip.GenerateNftablesRules(flows))
+		default:
+			log.Fatalf("unknown format [%s], expected networkpolicy or nftables", firewallFormat)
+		}
+	},
+}
+
+var kubeManifestSecurityProfiles = &cobra.Command{
+	Use:   "securityprofiles",
+	Short: "Generate a seccomp or AppArmor profile that only allows what kube-vip needs",
+	Long: `Generate a seccomp or AppArmor profile that only allows what kube-vip needs for the enabled features.
+
+The seccomp profile is installed in the kubelet seccomp directory of every node:
+
+  kube-vip manifest securityprofiles --format seccomp --arp --services > /var/lib/kubelet/seccomp/` + kubevip.SeccompProfileName + `
+
+The AppArmor profile is loaded on every node:
+
+  kube-vip manifest securityprofiles --format apparmor --arp --services | apparmor_parser -r
+
+Generate the pod or daemonset with --securityProfiles to use them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Set the logging level for all subsequent functions
+		log.SetLevel(log.Level(logLevel))
+		if err := kubevip.ParseEnvironment(&initConfig); err != nil {
+			log.Fatalf("Error parsing environment from config: %v", err)
+		}
+
+		switch securityProfileFormat {
+		case "seccomp":
+			profile, err := kubevip.GenerateSeccompProfile(&initConfig)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			fmt.Print(profile)
+		case "apparmor":
+			fmt.Print(kubevip.GenerateAppArmorProfile(&initConfig))
+		default:
+			log.Fatalf("unknown format [%s], expected seccomp or apparmor", securityProfileFormat)
+		}
+	},
+}
+
+var kubeManifestKustomize = &cobra.Command{
+	Use:   "kustomize",
+	Short: "Generate a kustomize base and overlays (pod, daemonset, rbac)",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Set the logging level for all subsequent functions
+		log.SetLevel(log.Level(logLevel))
+		initConfig.LoadBalancers = append(initConfig.LoadBalancers, initLoadBalancer)
+		if err := kubevip.ParseEnvironment(&initConfig); err != nil {
+			log.Fatalf("Error parsing environment from config: %v", err)
+		}
+
+		// The control plane has a requirement for a VIP being specified
+		if initConfig.EnableControlPlane && (initConfig.VIP == "" && initConfig.Address == "" && !initConfig.DDNS) {
+			_ = cmd.Help()
+			log.Fatalln("No address is specified for kube-vip to expose services on")
+		}
+
+		if initConfig.VIPCIDR == "" {
+			initConfig.VIPCIDR, err = generateCidrRange(initConfig.Address)
+
+			if err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		if err := loadManifestExtras(&initConfig); err != nil {
+			log.Fatalln(err)
+		}
+
+		files := kubevip.GenerateKustomizeFromConfig(&initConfig, Release.Version, inCluster, taint)
+		if err := writeManifestFiles(kustomizeOutput, files); err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+var kubeManifestHelmValues = &cobra.Command{
+	Use:   "helm-values",
+	Short: "Generate a values.yaml for the kube-vip helm chart",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		// Set the logging level for all subsequent functions
+		log.SetLevel(log.Level(logLevel))
+		initConfig.LoadBalancers = append(initConfig.LoadBalancers, initLoadBalancer)
+		if err := kubevip.ParseEnvironment(&initConfig); err != nil {
+			log.Fatalf("Error parsing environment from config: %v", err)
+		}
+
+		// The control plane has a requirement for a VIP being specified
+		if initConfig.EnableControlPlane && (initConfig.VIP == "" && initConfig.Address == "" && !initConfig.DDNS) {
+			_ = cmd.Help()
+			log.Fatalln("No address is specified for kube-vip to expose services on")
+		}
+
+		if initConfig.VIPCIDR == "" {
+			initConfig.VIPCIDR, err = generateCidrRange(initConfig.Address)
+
+			if err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		if err := loadManifestExtras(&initConfig); err != nil {
+			log.Fatalln(err)
+		}
+
+		cfg := kubevip.GenerateHelmValuesFromConfig(&initConfig, Release.Version, inCluster)
+		fmt.Println(cfg) // output values to stdout
 	},
 }
 
+// writeManifestFiles will write a set of manifests (keyed by relative path) to a directory, or to stdout if no
+// directory is specified
+func writeManifestFiles(dir string, files map[string]string) error {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for i, p := range paths {
+		if dir == "" {
+			if i != 0 {
+				fmt.Println("---")
+			}
+			fmt.Printf("# %s\n%s", p, files[p]) // output manifest to stdout
+			continue
+		}
+		fullPath := filepath.Join(dir, p)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return fmt.Errorf("unable to create directory for [%s]: %v", fullPath, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(files[p]), 0600); err != nil {
+			return fmt.Errorf("unable to write [%s]: %v", fullPath, err)
+		}
+		log.Infof("written [%s]", fullPath)
+	}
+	return nil
+}
+
+// loadManifestExtras will populate the configuration with any extras that should be added to the manifest
+func loadManifestExtras(c *kubevip.Config) error {
+	extras := &kubevip.ManifestExtras{}
+	if manifestExtrasPath != "" {
+		var err error
+		extras, err = kubevip.LoadManifestExtras(manifestExtrasPath)
+		if err != nil {
+			return err
+		}
+	}
+	env, err := kubevip.ParseExtraEnv(manifestExtraEnv)
+	if err != nil {
+		return err
+	}
+	extras.Env = append(extras.Env, env...)
+	c.ManifestExtras = extras
+	return nil
+}
+
+// loadManifestScheduling will parse the daemonset scheduling flags into the configuration
+func loadManifestScheduling(c *kubevip.Config) error {
+	tolerations, err := kubevip.ParseTolerations(dsTolerations)
+	if err != nil {
+		return err
+	}
+	scheduling := &kubevip.ManifestScheduling{
+		Tolerations:       tolerations,
+		NodeSelector:      dsNodeSelector,
+		PriorityClassName: dsPriorityClassName,
+	}
+	if dsAffinityPath != "" {
+		scheduling.Affinity, err = kubevip.LoadAffinity(dsAffinityPath)
+		if err != nil {
+			return err
+		}
+	}
+	if scheduling.Resources.Requests, err = kubevip.ParseResourceList(dsRequests); err != nil {
+		return err
+	}
+	if scheduling.Resources.Limits, err = kubevip.ParseResourceList(dsLimits); err != nil {
+		return err
+	}
+	c.ManifestScheduling = scheduling
+	return nil
+}
+
 func generateCidrRange(address string) (string, error) {
 	var cidrs []string
 
@@ -159,3 +461,41 @@ func generateCidrRange(address string) (string, error) {
 
 	return strings.Join(cidrs, ","), nil
 }
+
+var kubeManifestRedistribution = &cobra.Command{
+	Use:   "redistribution",
+	Short: "Generate the FRR or BIRD configuration that redistributes the routes of routing table mode",
+	Long: `Generate the FRR or BIRD configuration that redistributes the routes of routing table mode, so that an existing
+routing daemon advertises the VIPs without kube-vip speaking BGP.
+
+The routes are host routes of the VIPs in table ` + fmt.Sprint(kubevip.DefaultRoutingTableID) + ` with protocol ` + fmt.Sprint(kubevip.DefaultRoutingProtocol) + ` (--tableID and --routingProtocol), these
+are kept stable between releases. FRR imports the whole table into the BGP instance of --localAS, so the table
+shouldn't be shared with other routes:
+
+  kube-vip manifest redistribution --format frr --localAS 65000 >> /etc/frr/frr.conf
+
+BIRD learns the routes of the table by their protocol, and pipes them into master4 and master6 for the BGP protocols
+to export:
+
+  kube-vip manifest redistribution --format bird > /etc/bird/kube-vip.conf`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Set the logging level for all subsequent functions
+		log.SetLevel(log.Level(logLevel))
+		if err := kubevip.ParseEnvironment(&initConfig); err != nil {
+			log.Fatalf("Error parsing environment from config: %v", err)
+		}
+
+		switch redistributionFormat {
+		case "frr":
+			config, err := kubevip.GenerateFRRConfig(&initConfig)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			fmt.Print(config)
+		case "bird":
+			fmt.Print(kubevip.GenerateBIRDConfig(&initConfig))
+		default:
+			log.Fatalf("unknown format [%s], expected frr or bird", redistributionFormat)
+		}
+	},
+}
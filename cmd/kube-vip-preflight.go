@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/preflight"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	kubeVipPreflight.Flags().BoolVar(&inCluster, "inCluster", false, "Use the incluster token to check the Kubernetes API")
+}
+
+var kubeVipPreflight = &cobra.Command{
+	Use:   "preflight",
+	Short: "Check that this node meets the prerequisites for kube-vip",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Set the logging level for all subsequent functions
+		log.SetLevel(log.Level(logLevel))
+		if err := kubevip.ParseEnvironment(&initConfig); err != nil {
+			log.Fatalf("Error parsing environment from config: %v", err)
+		}
+
+		failed := 0
+		for _, result := range preflight.Run(preflight.Checks(&initConfig, initConfig.K8sConfigFile, inCluster)) {
+			if result.Passed() {
+				fmt.Printf("[PASS] %s\n", result.Name)
+				continue
+			}
+			failed++
+			fmt.Printf("[FAIL] %s: %v\n", result.Name, result.Err)
+			fmt.Printf("       hint: %s\n", result.Remediation)
+		}
+
+		if failed != 0 {
+			fmt.Printf("%d preflight check(s) failed\n", failed)
+			os.Exit(1)
+		}
+		fmt.Println("all preflight checks passed")
+	},
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/servicepolicy"
+	"github.com/kube-vip/kube-vip/pkg/signature"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// configSignature is the detached signature of the configuration file
+var configSignature string
+
+// signatureFile is the file that is signed or verified
+var signatureFile string
+
+func init() {
+	kubeVipSignaturePayload.Flags().StringVarP(&signatureFile, "filename", "f", "", "A file of KubeVipServicePolicy resources")
+	kubeVipSignatureVerify.Flags().StringVarP(&signatureFile, "filename", "f", "", "The file that has been signed")
+
+	kubeVipSignature.AddCommand(kubeVipSignaturePayload)
+	kubeVipSignature.AddCommand(kubeVipSignatureVerify)
+}
+
+// loadConfigFile will load the configuration file, if a public key has been set then the file has to be signed by it
+func loadConfigFile() error {
+	// The key can't come from the configuration file that it verifies
+	key := initConfig.SignatureKey
+	if key == "" {
+		key = os.Getenv("signature_key")
+	}
+	if key != "" {
+		verifier, err := signature.LoadVerifier(key)
+		if err != nil {
+			return err
+		}
+		if err = verifier.VerifyFile(configFile, signaturePath()); err != nil {
+			return err
+		}
+		log.Infof("Configuration file [%s] is signed by [%s]", configFile, key)
+	}
+	return kubevip.LoadConfigFile(configFile, &initConfig)
+}
+
+// loadInlineConfig will load a configuration document from the vip_config environment variable, so that hosts without
+// a writable /etc (e.g. Talos) can inject the configuration. It can't be signed, so it is refused if a key is set.
+func loadInlineConfig() error {
+	inline := os.Getenv("vip_config")
+	if inline == "" {
+		return nil
+	}
+	if initConfig.SignatureKey != "" || os.Getenv("signature_key") != "" {
+		return fmt.Errorf("the inline configuration can't be verified, use a signed configuration file")
+	}
+	return kubevip.LoadConfig([]byte(inline), &initConfig)
+}
+
+// signaturePath returns the path of the configuration file signature
+func signaturePath() string {
+	if configSignature != "" {
+		return configSignature
+	}
+	return configFile + ".sig"
+}
+
+var kubeVipSignature = &cobra.Command{
+	Use:   "signature",
+	Short: "Sign and verify kube-vip configuration",
+	Long: `kube-vip verifies detached signatures over its configuration file and KubeVipServicePolicy resources when
+it is started with --signatureKey. The signatures are created with cosign, e.g.
+
+  cosign sign-blob --key cosign.key --output-signature config.yaml.sig config.yaml
+
+A service policy is signed over its payload and the signature is set in the kube-vip.io/signature annotation, e.g.
+
+  kube-vip signature payload -f policy.yaml > policy.payload
+  cosign sign-blob --key cosign.key --output-signature policy.sig policy.payload`,
+}
+
+var kubeVipSignaturePayload = &cobra.Command{
+	Use:   "payload",
+	Short: "Print the content of a service policy that is signed",
+	Run: func(cmd *cobra.Command, args []string) {
+		if signatureFile == "" {
+			_ = cmd.Help()
+			log.Fatalln("No file has been passed with -f")
+		}
+		b, err := os.ReadFile(signatureFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, doc := range bytes.Split(b, []byte("\n---")) {
+			if len(bytes.TrimSpace(doc)) == 0 {
+				continue
+			}
+			var policy servicepolicy.KubeVipServicePolicy
+			if err := yaml.Unmarshal(doc, &policy); err != nil {
+				log.Fatalf("unable to parse [%s]: %v", signatureFile, err)
+			}
+			if policy.Kind != "KubeVipServicePolicy" {
+				continue
+			}
+			payload, err := servicepolicy.SignaturePayload(&policy)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			fmt.Println(string(payload))
+		}
+	},
+}
+
+var kubeVipSignatureVerify = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the detached signature of a file with the --signatureKey",
+	Run: func(cmd *cobra.Command, args []string) {
+		if signatureFile == "" || initConfig.SignatureKey == "" {
+			_ = cmd.Help()
+			log.Fatalln("A file (-f) and a public key (--signatureKey) are required")
+		}
+		verifier, err := signature.LoadVerifier(initConfig.SignatureKey)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		path := configSignature
+		if path == "" {
+			path = signatureFile + ".sig"
+		}
+		if err := verifier.VerifyFile(signatureFile, path); err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("Verified [%s] with [%s]\n", signatureFile, path)
+	},
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kube-vip/kube-vip/pkg/k8s"
+	"github.com/kube-vip/kube-vip/pkg/simulate"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// simulateFailover contains the flags for the failover simulation
+var simulateFailover struct {
+	service  string
+	lease    string
+	port     int32
+	interval time.Duration
+	timeout  time.Duration
+	bgp      bool
+	hold     time.Duration
+}
+
+func init() {
+	kubeVipSimulateFailover.Flags().StringVar(&simulateFailover.service, "service", "", "The service to fail over, format: namespace/name")
+	kubeVipSimulateFailover.Flags().StringVar(&simulateFailover.lease, "lease", "", "The lease to hand off (in --namespace), defaults to the per service lease used with servicesElection")
+	kubeVipSimulateFailover.Flags().Int32Var(&simulateFailover.port, "port", 0, "The port to probe, defaults to the first port of the service")
+	kubeVipSimulateFailover.Flags().DurationVar(&simulateFailover.interval, "interval", 100*time.Millisecond, "How often the service is probed")
+	kubeVipSimulateFailover.Flags().DurationVar(&simulateFailover.timeout, "timeout", 2*time.Minute, "How long to wait for the failover to complete")
+	kubeVipSimulateFailover.Flags().BoolVar(&simulateFailover.bgp, "bgp", false, "Withdraw the routes of the service and announce them again from a new leader, instead of leaving the lease to expire")
+	kubeVipSimulateFailover.Flags().DurationVar(&simulateFailover.hold, "hold", 5*time.Second, "How long the routes are kept withdrawn with --bgp")
+
+	kubeVipSimulate.AddCommand(kubeVipSimulateFailover)
+}
+
+var kubeVipSimulate = &cobra.Command{
+	Use:   "simulate",
+	Short: "Simulate events to validate the kube-vip configuration",
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
+
+var kubeVipSimulateFailover = &cobra.Command{
+	Use:   "failover",
+	Short: "Force a leadership handoff for a service and measure the downtime",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Set the logging level for all subsequent functions
+		log.SetLevel(log.Level(logLevel))
+
+		namespace, name, found := strings.Cut(simulateFailover.service, "/")
+		if !found || namespace == "" || name == "" {
+			_ = cmd.Help()
+			log.Fatalln("--service should be in the format namespace/name")
+		}
+
+		clientSet, err := k8s.NewClientset(initConfig.K8sConfigFile, false, "")
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		ctx := context.Background()
+		svc, err := clientSet.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if len(svc.Status.LoadBalancer.Ingress) == 0 {
+			log.Fatalf("service [%s] has no load balancer address", simulateFailover.service)
+		}
+
+		port := simulateFailover.port
+		if port == 0 {
+			for x := range svc.Spec.Ports {
+				if svc.Spec.Ports[x].Protocol == v1.ProtocolTCP {
+					port = svc.Spec.Ports[x].Port
+					break
+				}
+			}
+			if port == 0 {
+				log.Fatalf("service [%s] has no TCP ports to probe, specify one with --port", simulateFailover.service)
+			}
+		}
+
+		config := &simulate.FailoverConfig{
+			LeaseName:      fmt.Sprintf("kubevip-%s", svc.Name),
+			LeaseNamespace: svc.Namespace,
+			Address:        net.JoinHostPort(svc.Status.LoadBalancer.Ingress[0].IP, strconv.Itoa(int(port))),
+			ProbeInterval:  simulateFailover.interval,
+			Timeout:        simulateFailover.timeout,
+			BGP:            simulateFailover.bgp,
+			Hold:           simulateFailover.hold,
+		}
+		if simulateFailover.lease != "" {
+			config.LeaseName = simulateFailover.lease
+			config.LeaseNamespace = initConfig.Namespace
+		}
+
+		result, err := simulate.Failover(ctx, clientSet, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		fmt.Printf("Service:         %s (%s)\n", simulateFailover.service, config.Address)
+		fmt.Printf("Lease:           %s/%s\n", config.LeaseNamespace, config.LeaseName)
+		fmt.Printf("Previous leader: %s\n", result.PreviousLeader)
+		fmt.Printf("New leader:      %s\n", result.NewLeader)
+		fmt.Printf("Leader elected:  %s\n", result.LeaderElected)
+		if config.BGP {
+			fmt.Printf("Withdrawn:       %s\n", result.Withdrawn)
+			fmt.Printf("Announced:       %s\n", result.Announced)
+		}
+		fmt.Printf("Downtime:        %s\n", result.Downtime)
+		fmt.Printf("Failed probes:   %d/%d\n", result.FailedProbes, result.TotalProbes)
+	},
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// systemdUnitPath is where the systemd unit is written, "-" will write it to stdout
+var systemdUnitPath string
+
+// systemdBinary is the path to the kube-vip binary that systemd will run
+var systemdBinary string
+
+// systemdUser is the user that systemd will run kube-vip as
+var systemdUser string
+
+func init() {
+	kubeVipServiceInstall.Flags().StringVar(&systemdUnitPath, "unitFile", "/etc/systemd/system/kube-vip.service", "Path to write the systemd unit to, use \"-\" for stdout")
+	kubeVipServiceInstall.Flags().StringVar(&systemdBinary, "binary", "", "Path to the kube-vip binary (defaults to this binary)")
+	kubeVipServiceInstall.Flags().StringVar(&systemdUser, "user", "kube-vip", "User to run kube-vip as, it only has the NET_ADMIN and NET_RAW capabilities and must be able to read the configuration file")
+
+	kubeVipService.AddCommand(kubeVipServiceInstall)
+}
+
+var kubeVipServiceInstall = &cobra.Command{
+	Use:   "install",
+	Short: "Install a systemd unit that runs kube-vip in standalone mode",
+	Long: `Install a systemd unit that runs kube-vip in standalone mode, without a static pod or kubelet.
+The configuration is read from the file passed with --configFile, and kube-vip runs as the user passed with --user, e.g.
+
+  useradd --system --no-create-home --shell /usr/sbin/nologin kube-vip
+  kube-vip service install --configFile /etc/kube-vip/config.yaml
+  systemctl daemon-reload && systemctl enable --now kube-vip`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if configFile == "" {
+			_ = cmd.Help()
+			log.Fatalln("a configuration file is required in standalone mode (--configFile)")
+		}
+
+		// systemd requires absolute paths
+		configPath, err := filepath.Abs(configFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		// Ensure that the configuration can actually run standalone before installing anything
+		if err := kubevip.LoadConfigFile(configPath, &initConfig); err != nil {
+			log.Fatalln(err)
+		}
+		initConfig.Standalone = true
+		if err := initConfig.CheckStandalone(); err != nil {
+			log.Fatalln(err)
+		}
+
+		binary := systemdBinary
+		if binary == "" {
+			binary, err = os.Executable()
+			if err != nil {
+				log.Fatalf("unable to find the kube-vip binary, use --binary: %v", err)
+			}
+		}
+		binary, err = filepath.Abs(binary)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if systemdUser == "" {
+			_ = cmd.Help()
+			log.Fatalln("a user to run kube-vip as is required (--user)")
+		}
+
+		unit := kubevip.GenerateSystemdUnit(binary, configPath, systemdUser)
+		if systemdUnitPath == "-" {
+			fmt.Print(unit)
+			return
+		}
+
+		if err := os.WriteFile(systemdUnitPath, []byte(unit), 0o644); err != nil {
+			log.Fatalf("unable to write systemd unit: %v", err)
+		}
+		fmt.Printf("Installed systemd unit [%s], start it with:\n", systemdUnitPath)
+		fmt.Printf("  systemctl daemon-reload && systemctl enable --now %s\n", filepath.Base(systemdUnitPath))
+	},
+}
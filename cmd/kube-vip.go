@@ -14,12 +14,23 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/vishvananda/netlink"
 
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+	"github.com/kube-vip/kube-vip/pkg/capabilities"
 	"github.com/kube-vip/kube-vip/pkg/equinixmetal"
+	"github.com/kube-vip/kube-vip/pkg/features"
+	"github.com/kube-vip/kube-vip/pkg/fips"
+	"github.com/kube-vip/kube-vip/pkg/httptls"
 	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/loadbalancer"
 	"github.com/kube-vip/kube-vip/pkg/manager"
+	"github.com/kube-vip/kube-vip/pkg/version"
 	"github.com/kube-vip/kube-vip/pkg/vip"
+	"github.com/kube-vip/kube-vip/pkg/wireguard"
 )
 
+// Preset of flag defaults
+var preset string
+
 // Is kube-vip running within cluster
 var inCluster bool
 
@@ -35,6 +46,9 @@ var providerConfig string
 // Points to a kubernetes configuration file
 var kubeConfigPath string
 
+// Points to a kube-vip configuration file
+var configFile string
+
 // Release - this struct contains the release information populated when building kube-vip
 var Release struct {
 	Version string
@@ -50,14 +64,20 @@ var (
 var kubeVipCmd = &cobra.Command{
 	Use:   "kube-vip",
 	Short: "This is a server for providing a Virtual IP and load-balancer for the Kubernetes control-plane",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return applyPreset(cmd)
+	},
 }
 
 func init() {
 	// Basic flags
+	kubeVipCmd.PersistentFlags().StringVar(&preset, "preset", "", presetUsage())
+	kubeVipCmd.PersistentFlags().StringVar(&configFile, "configFile", "", "Path to a kube-vip configuration file, older apiVersions are migrated automatically")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.FeatureGates, "feature-gates", "", "A set of key=value pairs that describe feature gates for experimental features. Options are:\n"+strings.Join(features.DefaultFeatureGate.KnownFeatures(), "\n"))
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.Interface, "interface", "", "Name of the interface to bind to")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.ServicesInterface, "serviceInterface", "", "Name of the interface to bind to (for services)")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.VIP, "vip", "", "The Virtual IP address")
-	kubeVipCmd.PersistentFlags().StringVar(&initConfig.VIPSubnet, "vipSubnet", "", "The Virtual IP address subnet e.g. /32 /24 /8 etc..")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.VIPSubnet, "vipSubnet", "", "The Virtual IP address subnet e.g. /32 /24 /8 etc.., or the IPv4 and IPv6 subnets e.g. /32,/128 for dual-stack")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.NodeName, "nodeName", "", "Name to be used for lease holder. Must be unique for each node/instance")
 
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.VIPCIDR, "cidr", "", "The CIDR range for the virtual IP address. Default to 32 for IPv4 and 128 for IPv6") // todo: deprecate
@@ -65,14 +85,48 @@ func init() {
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.Address, "address", "", "an address (IP or DNS name) to use as a VIP")
 	kubeVipCmd.PersistentFlags().IntVar(&initConfig.Port, "port", 6443, "Port for the VIP")
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableARP, "arp", false, "Enable Arp for VIP changes")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.ArpRepeat, "arpRepeat", 0, "Gratuitous ARP/NDP updates that are sent when a VIP is acquired, before they're sent at the broadcast rate")
+	kubeVipCmd.PersistentFlags().Int64Var(&initConfig.ArpRepeatInterval, "arpRepeatInterval", 0, "Milliseconds between the gratuitous ARP/NDP updates that are sent when a VIP is acquired (defaults to 200)")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.ArpBackoff, "arpBackoff", false, "Double the time between gratuitous ARP/NDP updates after the repeats, until it reaches the broadcast rate")
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableWireguard, "wireguard", false, "Enable Wireguard for services VIPs")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.WireguardSecret, "wireguardSecret", "wireguard", "Name of the secret holding the Wireguard keys and peer configuration")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.WireguardHandshakeTimeout, "wireguardHandshakeTimeout", 0, "Seconds since the last Wireguard handshake before the tunnel is unhealthy and the node gives up the leadership, 0 disables it")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.WireguardProbeAddress, "wireguardProbeAddress", "", "Address that is pinged through the Wireguard tunnel, the node gives up the leadership when it stops replying")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.WireguardMesh, "wireguardMesh", false, "Peer every kube-vip node with the others over Wireguard, the nodes are discovered from their annotations")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.WireguardMeshSecret, "wireguardMeshSecret", "", "Name of the secret holding the preshared keys of the Wireguard mesh, the mesh doesn't use preshared keys without it")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.WireguardPort, "wireguardPort", wireguard.DefaultPort, "UDP port of the Wireguard tunnel")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.WireguardMeshPort, "wireguardMeshPort", wireguard.DefaultMeshPort, "UDP port of the Wireguard mesh between kube-vip nodes")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.WireguardMTU, "wireguardMTU", 0, "MTU of the Wireguard interfaces, 0 leaves the default MTU")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.WireguardFirewallMark, "wireguardFirewallMark", 0, "Firewall mark (fwmark) of the encrypted Wireguard packets, 0 doesn't mark them")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.WireguardGenerateKeys, "wireguardGenerateKeys", false, "Generate the Wireguard private key if the secret doesn't have one, and publish the public key in the secret")
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableRoutingTable, "table", false, "Enable Routing Table for services VIPs")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.SignatureKey, "signatureKey", "", "Path to a public key, the configuration file and service policies are only applied if they are signed by it")
+	kubeVipCmd.PersistentFlags().StringVar(&configSignature, "configSignature", "", "Path to the detached signature of the configuration file (default is the configuration file with .sig appended)")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.SecurityWebhook, "securityWebhook", "", "A URL that security events are posted to as JSON, e.g. for a SIEM")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.FIPS, "fips", false, "Only use FIPS approved cryptography, requires a kube-vip binary built with BoringCrypto")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.Standalone, "standalone", false, "Run without a Kubernetes cluster (e.g. under systemd), only the control plane VIP without leader election or with etcd leader election is supported")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.ControlPlaneEngine, "controlPlaneEngine", "", "When several engines are enabled, the engine (arp, bgp, wireguard, table) used for the control plane, the first of bgp, arp, wireguard and table if not set")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.ServicesEngine, "servicesEngine", "", "When several engines are enabled, the engine (arp, bgp, wireguard, table) used for services, the first of bgp, arp, wireguard and table if not set")
 
 	// LoadBalancer flags
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableLoadBalancer, "enableLoadBalancer", false, "enable loadbalancing on the VIP with IPVS")
 	kubeVipCmd.PersistentFlags().IntVar(&initConfig.LoadBalancerPort, "lbPort", 6443, "loadbalancer port for the VIP")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.LoadBalancerForwardingMethod, "lbForwardingMethod", "local", "loadbalancer forwarding method")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.LoadBalancerDataplane, "lbDataplane", kubevip.LoadBalancerIPVS, "loadbalancer data plane, ipvs or xdp (balances the connections with an eBPF program on the interface, needs the EBPFDataplane and DirectServerReturn feature gates)")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.LoadBalancerScheduler, "lbScheduler", loadbalancer.ROUNDROBIN, "loadbalancer scheduler of IPVS, rr (round robin), wrr (weighted round robin), lc (least connection) or sh (source hashing)")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.LoadBalancerPersistenceTimeout, "lbPersistenceTimeout", 0, "Seconds that the connections of a client stay with the same backend of the IPVS loadbalancer, 0 disables persistence")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.LoadBalancerBackends, "lbBackends", kubevip.LoadBalancerBackendsNodes, "loadbalancer backends, the control plane nodes (nodes) or the endpoints of the default/kubernetes service (endpoints)")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.FirewallBackend, "firewallBackend", "", "What manages the egress, service security and masquerade rules, iptables or nftables (natively, without the iptables binaries), if not set nftables unless only the legacy iptables tables have rules")
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.DDNS, "ddns", false, "use Dynamic DNS + DHCP to allocate VIP for address")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.DDNSProvider, "ddnsProvider", "dhcp", "The provider that publishes the records of Dynamic DNS (dhcp, cloudflare, route53, rfc2136)")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.DDNSSecret, "ddnsSecret", "", "The Secret with the credentials of the Dynamic DNS provider")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.DDNSTTL, "ddnsTTL", 60, "The TTL (in seconds) of the records that the Dynamic DNS provider publishes")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableUPNP, "enableUPNP", false, "Forward the ports of the services from the gateway to their VIPs with UPNP")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.UPNPLeaseDuration, "upnpLeaseDuration", 3600, "The lease (in seconds) of the UPNP port mappings, they are renewed half way through it (0 is a permanent mapping)")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.UPNPProtocol, "upnpProtocol", "auto", "The protocol that the ports are mapped with on the gateway (auto, upnp, natpmp, pcp)")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.UPNPGatewayURL, "upnpGatewayURL", "", "The description URL of the UPNP gateway (e.g. http://192.168.0.1:5000/rootDesc.xml), for when more than one gateway answers")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.UPNPInterface, "upnpInterface", "", "The interface that the gateway is searched for, or routed to, through")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.UPNPOptIn, "upnpOptIn", false, "Only forward the ports of the services with the kube-vip.io/upnp annotation set to true")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.MirrorDestInterface, "mirrorDestInterface", "", "network interface where all traffic that traverses the service interface will be mirrored to. Source interface will use default interface is servicesInterface is not set.")
 
 	// Clustering type (leaderElection)
@@ -88,6 +142,29 @@ func init() {
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.MetalAPIKey, "metalKey", "", "The API token for authenticating with the Equinix Metal API")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.MetalProject, "metalProject", "", "The name of project already created within Equinix Metal")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.MetalProjectID, "metalProjectID", "", "The ID of project already created within Equinix Metal")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.MetalEIPTag, "metalEIPTag", "", "The tag of the Equinix Metal EIP that is used as the VIP when no VIP is set")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.MetalEIPSyncInterval, "metalEIPSyncInterval", 30, "How often (in seconds) the leader checks that the Equinix Metal EIP is still assigned to it, 0 disables the check")
+	// AWS flags
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableAWS, "aws", false, "This will use the EC2 API to assign the VIP to the ENI of the leader")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.AWSRegion, "awsRegion", "", "The region of the EC2 API, read from the instance metadata when it isn't set")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.AWSEIPAllocationID, "awsEIPAllocationID", "", "The allocation ID of the EIP that is associated with the VIP on the leader")
+	// GCP flags
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableGCP, "gcp", false, "This will use the Compute Engine API to route the VIP to the leader (the instances need IP forwarding enabled)")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.GCPRouteName, "gcpRouteName", "", "The name of the VPC route of the VIP, derived from the VIP when it isn't set")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.GCPNetwork, "gcpNetwork", "", "The VPC network of the route of the VIP, the network of the first interface of the instance when it isn't set")
+	// Azure flags
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableAzure, "azure", false, "This will use the Azure API to move the IP configuration of the VIP to the NIC of the leader")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.AzureIPConfigName, "azureIPConfigName", "", "The name of the IP configuration of the VIP, derived from the VIP when it isn't set")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.AzurePublicIPID, "azurePublicIPID", "", "The resource ID of the public IP that is associated with the VIP on the leader")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.AzureClientID, "azureClientID", "", "The client ID of the user-assigned managed identity, the system-assigned identity is used when it isn't set")
+	// OpenStack flags
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableOpenStack, "openstack", false, "This will use the OpenStack API (with the OS_* variables) to add the VIP and the pools to the allowed address pairs of the port of the node")
+	kubeVipCmd.PersistentFlags().StringSliceVar(&initConfig.OpenStackPools, "openstackPools", nil, "The CIDRs of the pools that are allowed on the port of the node alongside the VIP")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.OpenStackPoolsConfigMap, "openstackPoolsConfigMap", "", "The configmap of the kube-vip cloud provider whose pools are allowed on the port of the node whenever they change")
+	// NSX flags
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableNSX, "nsx", false, "This will use the NSX API (with NSX_USERNAME and NSX_PASSWORD) to bind the VIP to the logical port of the leader")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.NSXManager, "nsxManager", "", "The URL of the NSX manager (https://<address>)")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.NSXInsecure, "nsxInsecure", false, "Skip the verification of the certificate of the NSX manager")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.ProviderConfig, "provider-config", "", "The path to a provider configuration")
 
 	// BGP flags
@@ -98,12 +175,15 @@ func init() {
 	kubeVipCmd.PersistentFlags().Uint32Var(&initConfig.BGPConfig.AS, "localAS", 65000, "The local AS number for the bgp server")
 	kubeVipCmd.PersistentFlags().Uint64Var(&initConfig.BGPConfig.HoldTime, "bgpHoldTimer", 30, "The hold timer for all bgp peers (it defines the time a session is held)")
 	kubeVipCmd.PersistentFlags().Uint64Var(&initConfig.BGPConfig.KeepaliveInterval, "bgpKeepAliveInterval", 10, "The keepalive interval for all bgp peers (it defines the heartbeat of keepalive messages)")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.BGPConfig.GracefulRestart, "bgpGracefulRestart", false, "Negotiate graceful restart with all bgp peers, so that they keep the routes while kube-vip restarts (the routes of elected VIPs are still withdrawn when the leadership is lost)")
+	kubeVipCmd.PersistentFlags().Uint32Var(&initConfig.BGPConfig.RestartTime, "bgpRestartTime", bgp.DefaultRestartTime, "How long (in seconds, up to 4095) the bgp peers keep the routes while kube-vip restarts, unless a peer sets its own")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.BGPPeerConfig.Address, "peerAddress", "", "The address of a BGP peer")
 	kubeVipCmd.PersistentFlags().Uint32Var(&initConfig.BGPPeerConfig.AS, "peerAS", 65000, "The AS number for a BGP peer")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.BGPPeerConfig.Password, "peerPass", "", "The md5 password for a BGP peer")
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.BGPPeerConfig.MultiHop, "multihop", false, "This will enable BGP multihop support")
-	kubeVipCmd.PersistentFlags().StringSliceVar(&initConfig.BGPPeers, "bgppeers", []string{}, "Comma separated BGP Peer, format: address:as:password:multihop")
+	kubeVipCmd.PersistentFlags().StringSliceVar(&initConfig.BGPPeers, "bgppeers", []string{}, "Comma separated BGP Peer, format: address:as:password:multihop:gracefulRestart:restartTime")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.Annotations, "annotations", "", "Set Node annotations prefix for parsing")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.BGPPeerSecret, "bgpPeerSecret", "", "Name of a secret holding the BGP peer password(s), overrides any passwords passed as config")
 
 	// Namespace for kube-vip
 	kubeVipCmd.PersistentFlags().StringVarP(&initConfig.Namespace, "namespace", "n", "kube-system", "The namespace for the configmap defined within the cluster")
@@ -115,45 +195,105 @@ func init() {
 	kubeVipService.Flags().StringVarP(&configMap, "configMap", "c", "plndr", "The configuration map defined within the cluster")
 
 	// Routing Table flags
-	kubeVipCmd.PersistentFlags().IntVar(&initConfig.RoutingTableID, "tableID", 198, "The routing table used for all table entries")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.RoutingTableID, "tableID", kubevip.DefaultRoutingTableID, "The routing table used for all table entries")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.RoutingTableVRF, "tableVRF", "", "The VRF whose routing table is used for all table entries (instead of tableID), the VIPs are bound inside it")
 	kubeVipCmd.PersistentFlags().IntVar(&initConfig.RoutingTableType, "tableType", 0, "The type of route that will be added to the routing table")
-	kubeVipCmd.PersistentFlags().IntVar(&initConfig.RoutingProtocol, "routingProtocol", 248, "The routing protocol value used to create routes")
-	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.CleanRoutingTable, "cleanRoutingTable", false, "Clean routing table of redundant routes on start")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.RoutingProtocol, "routingProtocol", kubevip.DefaultRoutingProtocol, "The routing protocol value used to create routes")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.RoutingMetric, "routingMetric", 0, "The metric (priority) of the routes, lower metrics are preferred")
+	kubeVipCmd.PersistentFlags().StringSliceVar(&initConfig.RoutingNextHops, "routingNextHops", nil, "Comma separated next hops (<gateway>[@<interface>]) of multipath routes, the routes are balanced across them")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.RoutingScope, "routingScope", "", "The scope of the routes (global, link or host), derived from the route type if it is empty")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.RoutingOnLink, "routingOnLink", false, "Add the routes with the onlink flag, the gateways are treated as directly connected")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.RoutingSource, "routingSource", "", "The preferred source of the routes, \"vip\" for the VIP itself or an address")
+	kubeVipCmd.PersistentFlags().StringSliceVar(&initConfig.RoutingTableNamespaces, "routingTableNamespaces", nil, "Comma separated <namespace>=<table> routing tables for the services of a namespace (instead of tableID)")
+	kubeVipCmd.PersistentFlags().StringSliceVar(&initConfig.BlackholePools, "blackholePools", nil, "Comma separated CIDRs of the service VIP pools, the addresses that aren't allocated to a service are blackholed")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.CleanRoutingTable, "cleanRoutingTable", true, "Clean routing table of redundant routes (left by a crash) on start")
 
 	// Behaviour flags
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableControlPlane, "controlplane", false, "Enable HA for control plane")
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.DetectControlPlane, "autodetectcp", false, "Determine working address for control plane (from loopback)")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.ControlPlaneDNS, "controlPlaneDNS", "", "The hostname whose records list the healthy control plane nodes, published through the DDNS provider")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.ControlPlaneDNSMode, "controlPlaneDNSMode", kubevip.ControlPlaneDNSNodes, "What the records of the control plane hostname list, every healthy control plane node (nodes) or only the leader (leader, for networks without ARP or BGP)")
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableServices, "services", false, "Enable Kubernetes services")
 
 	// Extended behaviour flags
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableServicesElection, "servicesElection", false, "Enable leader election per kubernetes service")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.ServicesElectionShards, "servicesElectionShards", 0, "Elect the services in this many shards with one lease each, instead of one lease per service")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableIPAM, "ipam", false, "Allocate the addresses of LoadBalancer services without one from the KubeVipIPPool resources")
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.LoadBalancerClassOnly, "lbClassOnly", false, "Enable load balancing only for services with LoadBalancerClass \"kube-vip.io/kube-vip-class\"")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.LoadBalancerClassName, "lbClassName", "kube-vip.io/kube-vip-class", "Name of load balancer class for kube-VIP, defaults to \"kube-vip.io/kube-vip-class\"")
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableServiceSecurity, "onlyAllowTrafficServicePorts", false, "Only allow traffic to service ports, others will be dropped, defaults to false")
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableNodeLabeling, "enableNodeLabeling", false, "Enable leader node labeling with \"kube-vip.io/has-ip=<VIP address>\", defaults to false")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.ServicesLeaseName, "servicesLeaseName", "plndr-svcs-lock", "Name of the lease that is used for leader election for services (in arp mode)")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.ServicesWorkers, "servicesWorkers", 4, "Number of services that are synchronised at the same time")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.ServicesSelector, "servicesSelector", "", "Only watch the services (and their endpoints) that match this label selector, the API server filters them")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.DNSMode, "dnsMode", "first", "Name of the mode that DNS lookup will be performed (first, ipv4, ipv6, dual)")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.DNSRefreshInterval, "dnsRefreshInterval", 3, "How often (in seconds) the DNS name of a VIP is resolved again")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.DNSHealthCheck, "dnsHealthCheck", false, "Health check the addresses that the DNS name of the VIP resolves to, and keep the VIP on one whose API server answers")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.ExternalDNSTemplate, "externalDNSTemplate", "", "The template of the hostname published for the VIP of a service for external-dns, e.g. {service}.{namespace}.lb.example.com")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.DNSServerZone, "dnsServerZone", "", "The zone that kube-vip answers the queries of with the VIPs of the services, named {service}.{namespace}.{zone}")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.DNSServerAddress, "dnsServerAddress", "", "The address that the DNS server is bound to, the VIP of the control plane by default")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.DNSServerPort, "dnsServerPort", 53, "The port that the DNS server listens on")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.ExternalDNSTarget, "externalDNSTarget", "annotation", "Where the hostname of a service is published, the external-dns annotation or the status")
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.DisableServiceUpdates, "disableServiceUpdates", false, "If true, kube-vip will process services as usual, but will not update service's Status.LoadBalancer.Ingress slice")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableServiceEvents, "serviceEvents", false, "Record events on the services when their VIPs are advertised or withdrawn")
 	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableEndpointSlices, "enableEndpointSlices", false, "If enabled, kube-vip will only advertise services, but will use EndpointSlices instead of endpoints to get IPs of Pods")
 
 	// Prometheus HTTP Server
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.PrometheusHTTPServer, "prometheusHTTPServer", ":2112", "Host and port used to expose Prometheus metrics via an HTTP server")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.HTTPTLS.CertFile, "httpTLSCert", "", "Serve the HTTP endpoints over TLS using this certificate file (reloaded when it changes)")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.HTTPTLS.KeyFile, "httpTLSKey", "", "Key file for the HTTP endpoint certificate")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.HTTPTLS.ClientCAFile, "httpTLSClientCA", "", "Require HTTP clients to present a certificate signed by this CA (mTLS)")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.Coordination.Port, "coordinationPort", 0, "Port of the mTLS channel between kube-vip nodes, 0 disables it")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.Coordination.Secret, "coordinationSecret", "", "Name of the secret with the CA (ca.crt and ca.key, or only ca.crt with --coordinationSignerName) of the channel between nodes")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.Coordination.SignerName, "coordinationSignerName", "", "Request the node certificates from this signer with a CertificateSigningRequest, instead of issuing them with the CA key")
+
+	// SPIFFE workload identities
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.SPIFFE.Socket, "spiffeSocket", "", "SPIFFE Workload API socket that identities are fetched from, e.g. unix:///run/spire/sockets/agent.sock")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.SPIFFE.Kubernetes, "spiffeKubernetes", false, "Authenticate to the Kubernetes API server with the SPIFFE SVID")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.SPIFFE.NodeIDTemplate, "spiffeNodeIDTemplate", "", "SPIFFE ID of the nodes (e.g. spiffe://example.org/kube-vip/{node}), the channel between nodes uses SVIDs when it is set")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.HTTPTLS.Secret, "httpTLSSecret", "", "Name of a TLS secret (tls.crt, tls.key and optionally ca.crt for mTLS) for the HTTP endpoints, reloaded when it is rotated")
 
 	// Etcd
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.Etcd.CAFile, "etcdCACert", "", "Verify certificates of TLS-enabled secure servers using this CA bundle file")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.Etcd.ClientCertFile, "etcdCert", "", "Identify secure client using this TLS certificate file")
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.Etcd.ClientKeyFile, "etcdKey", "", "Identify secure client using this TLS key file")
 	kubeVipCmd.PersistentFlags().StringSliceVar(&initConfig.Etcd.Endpoints, "etcdEndpoints", nil, "Etcd member endpoints")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.Etcd.ClientSecret, "etcdClientSecret", "", "Name of a TLS secret (ca.crt, tls.crt, tls.key) holding the etcd client certificates")
+
+	// Cluster API bootstrap
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.Bootstrap, "bootstrap", "", "Elect the control plane VIP with etcd or static peers until the API server is healthy, then move to Kubernetes leader election (etcd or static)")
+	kubeVipCmd.PersistentFlags().StringSliceVar(&initConfig.BootstrapPeers, "bootstrapPeers", nil, "The control plane nodes of the static bootstrap, the first of them holds the VIP until the API server is healthy")
+
+	// Upgrades
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.Handover, "handover", false, "Keep the control plane VIP, the VIPs of the services in ARP mode and their leases when kube-vip shuts down, for the kube-vip that replaces it on the node (the VIPs fail over once the leases expire if there is no replacement, routes and BGP advertisements are withdrawn)")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.HandoverPath, "handoverPath", "/var/run/kube-vip/handover.json", "The file on the host that the control plane VIP is handed over in, the VIPs of the services are handed over next to it")
+
+	// VRRP
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.EnableVRRP, "vrrp", false, "Elect the control plane VIP with VRRP instead of leader election, to share it with keepalived or VRRP routers (requires arp)")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.VRRP.RouterID, "vrrpRouterID", 0, "The ID of the virtual router (1-255), the same on every node and router of the VIP")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.VRRP.Priority, "vrrpPriority", kubevip.DefaultVRRPPriority, "The priority of this node (1-254), the node with the highest priority holds the VIP")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.VRRP.AdvertInterval, "vrrpAdvertInterval", kubevip.DefaultVRRPAdvertInterval, "The interval between the advertisements in milliseconds (whole seconds with version 2)")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.VRRP.Preempt, "vrrpPreempt", true, "Take the VIP over from a node with a lower priority")
+	kubeVipCmd.PersistentFlags().IntVar(&initConfig.VRRP.Version, "vrrpVersion", 3, "The version of VRRP, 3 or 2 for the routers that don't speak version 3")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.VRRP.Password, "vrrpPassword", "", "The simple text authentication of VRRPv2 (up to 8 characters)")
 
 	// Kubernetes client specific flags
 
 	kubeVipCmd.PersistentFlags().StringVar(&initConfig.K8sConfigFile, "k8sConfigPath", "/etc/kubernetes/admin.conf", "Path to the configuration file used with the Kubernetes client")
+	kubeVipCmd.PersistentFlags().BoolVar(&initConfig.ImmutableOS, "immutableOS", false, "Never read a kubeconfig from the host, for immutable operating systems such as Talos or Flatcar")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.APIServer, "apiServer", "", "The URL of the API server, used with the apiToken instead of a kubeconfig")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.APIToken, "apiToken", "", "The bearer token (e.g. a bootstrap token) that authenticates to the API server")
+	kubeVipCmd.PersistentFlags().StringVar(&initConfig.APICA, "apiCA", "", "The PEM encoded CA that the API server is verified with")
 
 	kubeVipCmd.AddCommand(kubeKubeadm)
 	kubeVipCmd.AddCommand(kubeManifest)
+	kubeVipCmd.AddCommand(kubeVipLint)
 	kubeVipCmd.AddCommand(kubeVipManager)
+	kubeVipCmd.AddCommand(kubeVipPreflight)
 	kubeVipCmd.AddCommand(kubeVipSample)
 	kubeVipCmd.AddCommand(kubeVipService)
+	kubeVipCmd.AddCommand(kubeVipSignature)
+	kubeVipCmd.AddCommand(kubeVipSimulate)
 	kubeVipCmd.AddCommand(kubeVipVersion)
 }
 
@@ -165,13 +305,56 @@ func Execute() {
 	}
 }
 
+// applyPreset will set any flags from the preset that haven't been set explicitly
+func applyPreset(cmd *cobra.Command) error {
+	if preset == "" {
+		return nil
+	}
+	p, err := kubevip.GetPreset(preset)
+	if err != nil {
+		return err
+	}
+	for _, name := range p.SortedFlags() {
+		f := cmd.Flags().Lookup(name)
+		if f == nil || f.Changed {
+			continue
+		}
+		if err := cmd.Flags().Set(name, p.Flags[name]); err != nil {
+			return fmt.Errorf("preset [%s] unable to set [%s]: %v", p.Name, name, err)
+		}
+		log.Debugf("preset [%s] set [--%s=%s]", p.Name, name, p.Flags[name])
+	}
+	return nil
+}
+
+// presetUsage describes each of the presets for the flag help
+func presetUsage() string {
+	usage := "A preset of defaults, flags that are set explicitly override the preset. Options are:"
+	for _, p := range kubevip.Presets {
+		usage += fmt.Sprintf("\n%s - %s", p.Name, p.Description)
+	}
+	return usage
+}
+
 var kubeVipVersion = &cobra.Command{
 	Use:   "version",
 	Short: "Version and Release information about the Kubernetes Virtual IP Server",
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("Kube-VIP Release Information\n")
-		fmt.Printf("Version:  %s\n", Release.Version)
-		fmt.Printf("Build:    %s\n", Release.Build)
+		fmt.Print(version.Get(Release.Version, Release.Build))
+
+		// The features reflect any --feature-gates or feature_gates that have been set
+		if err := kubevip.ParseEnvironment(&initConfig); err != nil {
+			log.Fatalln(err)
+		}
+		if err := features.DefaultFeatureGate.Set(initConfig.FeatureGates); err != nil {
+			log.Fatalln(err)
+		}
+		enabled := features.DefaultFeatureGate.EnabledFeatures()
+		if len(enabled) == 0 {
+			enabled = []string{"none"}
+		}
+		fmt.Printf("Features: %s\n", strings.Join(enabled, ","))
 	},
 }
 
@@ -190,12 +373,28 @@ var kubeVipService = &cobra.Command{
 		// Set the logging level for all subsequent functions
 		log.SetLevel(log.Level(logLevel))
 
+		// load the configuration file, this will overwrite any flags
+		if configFile != "" {
+			if err := loadConfigFile(); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		// load an inline configuration, for hosts that don't have a writable /etc to put a file in
+		if err := loadInlineConfig(); err != nil {
+			log.Fatalln(err)
+		}
+
 		// parse environment variables, these will overwrite anything loaded or flags
 		err := kubevip.ParseEnvironment(&initConfig)
 		if err != nil {
 			log.Fatalln(err)
 		}
 
+		if err := features.DefaultFeatureGate.Set(initConfig.FeatureGates); err != nil {
+			log.Fatalln(err)
+		}
+
 		if err := initConfig.CheckInterface(); err != nil {
 			log.Fatalln(err)
 		}
@@ -224,52 +423,233 @@ var kubeVipManager = &cobra.Command{
 	Use:   "manager",
 	Short: "Start the kube-vip manager",
 	Run: func(cmd *cobra.Command, args []string) {
+		// load the configuration file, this will overwrite any flags
+		if configFile != "" {
+			if err := loadConfigFile(); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		// load an inline configuration, for hosts that don't have a writable /etc to put a file in
+		if err := loadInlineConfig(); err != nil {
+			log.Fatalln(err)
+		}
+
 		// parse environment variables, these will overwrite anything loaded or flags
 		err := kubevip.ParseEnvironment(&initConfig)
 		if err != nil {
 			log.Fatalln(err)
 		}
 
+		if err := features.DefaultFeatureGate.Set(initConfig.FeatureGates); err != nil {
+			log.Fatalln(err)
+		}
+
+		// resolve any ${ENV_VAR} or file:// references now that the configuration is loaded
+		err = kubevip.ResolveReferences(&initConfig)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
 		// Set the logging level for all subsequent functions
 		log.SetLevel(log.Level(initConfig.Logging))
 
+		// FIPS mode has to be enabled before anything uses TLS
+		if initConfig.FIPS {
+			if err := initConfig.CheckFIPS(); err != nil {
+				log.Fatalln(err)
+			}
+			if err := fips.Enable(); err != nil {
+				log.Fatalln(err)
+			}
+			log.Info("FIPS mode enabled, only FIPS approved cryptography will be used")
+		}
+
 		// Welome messages
 		log.Infof("Starting kube-vip.io [%s]", Release.Version)
 		log.Debugf("Build kube-vip.io [%s]", Release.Build)
 
+		// load the certificates for the HTTP endpoints, these are reloaded whenever they're rotated
+		var httpCertificates *httptls.Certificates
+		if initConfig.HTTPTLS.Enabled() {
+			if err := initConfig.HTTPTLS.Validate(); err != nil {
+				log.Fatalln(err)
+			}
+			httpCertificates = &httptls.Certificates{}
+			if initConfig.HTTPTLS.CertFile != "" {
+				if err := httpCertificates.LoadFiles(initConfig.HTTPTLS.CertFile, initConfig.HTTPTLS.KeyFile, initConfig.HTTPTLS.ClientCAFile); err != nil {
+					log.Fatalf("unable to load HTTP certificates: %v", err)
+				}
+				go httpCertificates.WatchFiles(cmd.Context(), initConfig.HTTPTLS.CertFile, initConfig.HTTPTLS.KeyFile, initConfig.HTTPTLS.ClientCAFile, 10*time.Second)
+			}
+		}
+
 		// start prometheus server
+		status := http.NewServeMux()
 		if initConfig.PrometheusHTTPServer != "" {
 			go servePrometheusHTTPServer(cmd.Context(), PrometheusHTTPServerConfig{
-				Addr: initConfig.PrometheusHTTPServer,
+				Addr:   initConfig.PrometheusHTTPServer,
+				TLS:    httpCertificates,
+				Status: status,
 			})
 		}
 
-		// Determine the kube-vip mode
-		var mode string
+		// Determine the kube-vip mode(s)
+		var modes []string
 		if initConfig.EnableARP {
-			mode = "ARP"
+			modes = append(modes, "ARP")
 		}
 
 		if initConfig.EnableBGP {
-			mode = "BGP"
+			modes = append(modes, "BGP")
 		}
 
 		if initConfig.EnableWireguard {
-			mode = "Wireguard"
+			modes = append(modes, "Wireguard")
 		}
 
 		if initConfig.EnableRoutingTable {
-			mode = "Routing Table"
+			modes = append(modes, "Routing Table")
+		}
+
+		if initConfig.EnableAWS {
+			modes = append(modes, "AWS")
+		}
+
+		if initConfig.EnableGCP {
+			modes = append(modes, "GCP")
+		}
+
+		if initConfig.EnableAzure {
+			modes = append(modes, "Azure")
+		}
+
+		if initConfig.EnableNSX {
+			modes = append(modes, "NSX")
 		}
 
 		// Provide configuration to output/logging
-		log.Infof("namespace [%s], Mode: [%s], Features(s): Control Plane:[%t], Services:[%t]", initConfig.Namespace, mode, initConfig.EnableControlPlane, initConfig.EnableServices)
+		log.Infof("namespace [%s], Mode: [%s], Features(s): Control Plane:[%t], Services:[%t]", initConfig.Namespace, strings.Join(modes, ","), initConfig.EnableControlPlane, initConfig.EnableServices)
 
 		// End if nothing is enabled
-		if !initConfig.EnableServices && !initConfig.EnableControlPlane {
+		if !initConfig.EnableServices && !initConfig.EnableControlPlane && len(initConfig.VIPGroups) == 0 && initConfig.ControlPlaneDNS == "" {
 			log.Fatalln("no features are enabled")
 		}
 
+		// Ensure that nothing requires Kubernetes when running standalone
+		if err := initConfig.CheckStandalone(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckCoordination(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckSPIFFE(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckWireguard(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckRoutingTable(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckBlackholePools(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckEgress(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckExternalDNS(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckDDNS(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckDNSServer(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckUPNP(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckMetal(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckAWS(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckGCP(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckAzure(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckOpenStack(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckNSX(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckBootstrap(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckImmutableOS(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckHandover(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckVRRP(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckIPAM(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckServicesElection(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckARP(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckLoadBalancerDataplane(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckLoadBalancerScheduler(); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := initConfig.CheckFirewallBackend(); err != nil {
+			log.Fatalln(err)
+		}
+
+		// Fail now with a clear message, rather than when the first address or route is added
+		if err := capabilities.Check(initConfig.RequiredCapabilities()); err != nil {
+			log.Fatalln(err)
+		}
+
 		// If we're using wireguard then all traffic goes through the wg0 interface
 		if initConfig.EnableWireguard {
 			if initConfig.Interface == "" {
@@ -292,6 +672,9 @@ var kubeVipManager = &cobra.Command{
 					}
 				}
 			}
+			if err = initConfig.WireguardOptions().SetMTU(initConfig.Interface); err != nil {
+				log.Fatalln(err)
+			}
 			err = netlink.LinkSetUp(l)
 			if err != nil {
 				log.Fatalln(err)
@@ -337,6 +720,17 @@ var kubeVipManager = &cobra.Command{
 				initConfig.MetalAPIKey = providerAPI
 				initConfig.MetalProject = providerProject
 			}
+
+			// The VIP is the EIP with the tag when none is set
+			if initConfig.MetalEIPTag != "" {
+				metalClient, err := equinixmetal.NewClient(&initConfig)
+				if err != nil {
+					log.Fatalf("%v", err)
+				}
+				if err := equinixmetal.LookupEIP(metalClient, &initConfig); err != nil {
+					log.Fatalf("%v", err)
+				}
+			}
 		}
 
 		// Define the new service manager
@@ -345,7 +739,12 @@ var kubeVipManager = &cobra.Command{
 			log.Fatalf("configuring new Manager error -> %v", err)
 		}
 
+		// The certificates from a secret are loaded when the manager starts
+		mgr.SetHTTPCertificates(httpCertificates)
+
 		prometheus.MustRegister(mgr.PrometheusCollector()...)
+		status.Handle("/status/egress", mgr.EgressStatusHandler())
+		prometheus.MustRegister(version.Get(Release.Version, Release.Build).BuildInfoCollector(features.DefaultFeatureGate.EnabledFeatures()))
 
 		// Start the service manager, this will watch the config Map and construct kube-vip services for it
 		err = mgr.Start()
@@ -359,18 +758,28 @@ var kubeVipManager = &cobra.Command{
 type PrometheusHTTPServerConfig struct {
 	// Addr sets the http server address used to expose the metric endpoint
 	Addr string
+
+	// TLS will serve the endpoints over TLS (with client certificates if a CA is loaded) when set
+	TLS *httptls.Certificates
+
+	// Status serves the state of the manager under /status/, its handlers are added once the manager is created
+	Status *http.ServeMux
 }
 
 func servePrometheusHTTPServer(ctx context.Context, config PrometheusHTTPServerConfig) {
 	var err error
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
+	if config.Status != nil {
+		mux.Handle("/status/", config.Status)
+	}
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte(`<html>
 			<head><title>kube-vip</title></head>
 			<body>
 			<h1>kube-vip Metrics</h1>
 			<p><a href="` + "/metrics" + `">Metrics</a></p>
+			<p><a href="` + "/status/egress" + `">Egress</a></p>
 			</body>
 			</html>`))
 	})
@@ -382,7 +791,14 @@ func servePrometheusHTTPServer(ctx context.Context, config PrometheusHTTPServerC
 	}
 
 	go func() {
-		if err = srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if config.TLS != nil {
+			// The certificates come from the TLS configuration, so that they can be rotated
+			srv.TLSConfig = config.TLS.TLSConfig()
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("listen:%+s\n", err)
 		}
 	}()
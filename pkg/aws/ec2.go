@@ -0,0 +1,171 @@
+package aws
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// ec2APIVersion is the version of the EC2 Query API that the requests are made with
+const ec2APIVersion = "2016-11-15"
+
+// ec2Endpoint returns the endpoint of the EC2 API in the region
+var ec2Endpoint = func(region string) string {
+	return fmt.Sprintf("https://ec2.%s.amazonaws.com/", region)
+}
+
+// Client moves the VIP to the ENI of this instance with the EC2 API. The VPC only delivers the traffic of an address to
+// the ENI that it is assigned to, so a VIP that is only announced with ARP (or BGP) never reaches the leader.
+type Client struct {
+	endpoint string
+	region   string
+	// eni is the network interface of the instance that the VIP interface is attached to
+	eni string
+	// allocationID is the EIP that is associated with the VIP, it is empty when there is no EIP
+	allocationID string
+
+	client   *http.Client
+	metadata *metadata
+
+	mu    sync.Mutex
+	creds Credentials
+}
+
+// NewClient returns the client of the ENI that the VIP interface is attached to, the region and the ENI are read from
+// the instance metadata
+func NewClient(k *kubevip.Config) (*Client, error) {
+	var mac string
+	if ifi, err := net.InterfaceByName(k.Interface); err == nil {
+		mac = ifi.HardwareAddr.String()
+	}
+	return newClient(k, mac)
+}
+
+func newClient(k *kubevip.Config, mac string) (*Client, error) {
+	c := &Client{
+		region:       k.AWSRegion,
+		allocationID: k.AWSEIPAllocationID,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		metadata:     newMetadata(),
+	}
+	var err error
+	if c.region == "" {
+		if c.region, err = c.metadata.get("placement/region"); err != nil {
+			return nil, err
+		}
+	}
+	if mac == "" {
+		// The primary ENI of the instance
+		if mac, err = c.metadata.get("mac"); err != nil {
+			return nil, err
+		}
+	}
+	if c.eni, err = c.metadata.get("network/interfaces/macs/" + mac + "/interface-id"); err != nil {
+		return nil, fmt.Errorf("unable to find the ENI of the interface [%s]: %v", k.Interface, err)
+	}
+	c.endpoint = ec2Endpoint(c.region)
+	return c, nil
+}
+
+// AssignVIP assigns the VIP as a secondary private IP of the ENI of this instance, taking it from the ENI of the
+// previous leader, and associates the EIP with it when one is configured
+func (c *Client) AssignVIP(vip string) error {
+	log.Infof("[AWS] assigning the VIP [%s] to the ENI [%s]", vip, c.eni)
+	err := c.call("AssignPrivateIpAddresses", url.Values{
+		"NetworkInterfaceId": {c.eni},
+		"PrivateIpAddress.1": {vip},
+		"AllowReassignment":  {"true"},
+	})
+	if err != nil {
+		return err
+	}
+	if c.allocationID == "" {
+		return nil
+	}
+	log.Infof("[AWS] associating the EIP [%s] with the VIP [%s]", c.allocationID, vip)
+	return c.call("AssociateAddress", url.Values{
+		"AllocationId":       {c.allocationID},
+		"NetworkInterfaceId": {c.eni},
+		"PrivateIpAddress":   {vip},
+		"AllowReassociation": {"true"},
+	})
+}
+
+// call sends the action with its parameters to the EC2 API
+func (c *Client) call(action string, params url.Values) error {
+	creds, err := c.credentials()
+	if err != nil {
+		return err
+	}
+	params.Set("Action", action)
+	params.Set("Version", ec2APIVersion)
+	body := params.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	Sign(req, []byte(body), creds, c.region, "ec2", time.Now())
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var response struct {
+		Errors []struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		} `xml:"Errors>Error"`
+	}
+	b, _ := io.ReadAll(resp.Body)
+	if err := xml.Unmarshal(b, &response); err != nil || len(response.Errors) == 0 {
+		return fmt.Errorf("%s failed with status [%s]", action, resp.Status)
+	}
+	return fmt.Errorf("%s failed: %s: %s", action, response.Errors[0].Code, response.Errors[0].Message)
+}
+
+// credentials returns the keys of the environment, or those of the IAM role of the instance which are renewed before
+// they expire
+func (c *Client) credentials() (Credentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return Credentials{AccessKeyID: id, SecretAccessKey: secret, Token: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.creds.AccessKeyID != "" && time.Now().Add(5*time.Minute).Before(c.creds.Expiration) {
+		return c.creds, nil
+	}
+	roles, err := c.metadata.get("iam/security-credentials/")
+	if err != nil {
+		return Credentials{}, fmt.Errorf("the instance has no IAM role and no credentials are set: %v", err)
+	}
+	role, _, _ := strings.Cut(roles, "\n")
+	document, err := c.metadata.get("iam/security-credentials/" + role)
+	if err != nil {
+		return Credentials{}, err
+	}
+	var creds Credentials
+	if err := json.Unmarshal([]byte(document), &creds); err != nil {
+		return Credentials{}, fmt.Errorf("unable to read the credentials of the IAM role [%s]: %v", role, err)
+	}
+	c.creds = creds
+	return creds, nil
+}
@@ -0,0 +1,119 @@
+package aws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// fakeAWS answers the instance metadata of an instance with an IAM role, and the actions of the EC2 API
+type fakeAWS struct {
+	mu      sync.Mutex
+	actions []string
+	// fail is the error code that the EC2 API answers with, when it is set
+	fail string
+}
+
+func (f *fakeAWS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if strings.HasPrefix(r.URL.Path, "/latest/") {
+		if r.URL.Path == "/latest/api/token" {
+			_, _ = w.Write([]byte("token"))
+			return
+		}
+		if r.Header.Get("X-aws-ec2-metadata-token") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		metadata := map[string]string{
+			"placement/region": "eu-west-1",
+			"mac":              "0a:00:00:00:00:01",
+			"network/interfaces/macs/0a:00:00:00:00:01/interface-id": "eni-primary",
+			"network/interfaces/macs/0a:00:00:00:00:02/interface-id": "eni-secondary",
+			"iam/security-credentials/":                              "kube-vip",
+			"iam/security-credentials/kube-vip":                      `{"AccessKeyId":"AKID","SecretAccessKey":"secret","Token":"session","Expiration":"` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `"}`,
+		}
+		value, exists := metadata[strings.TrimPrefix(r.URL.Path, "/latest/meta-data/")]
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(value))
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") || r.Header.Get("X-Amz-Security-Token") != "session" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	_ = r.ParseForm()
+	f.actions = append(f.actions, r.Form.Get("Action")+" "+r.Form.Get("NetworkInterfaceId")+" "+r.Form.Get("PrivateIpAddress.1")+r.Form.Get("PrivateIpAddress")+" "+r.Form.Get("AllocationId"))
+	if f.fail != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<Response><Errors><Error><Code>` + f.fail + `</Code><Message>denied</Message></Error></Errors></Response>`))
+		return
+	}
+	_, _ = w.Write([]byte(`<AssignPrivateIpAddressesResponse><return>true</return></AssignPrivateIpAddressesResponse>`))
+}
+
+func newTestClient(t *testing.T, f *fakeAWS, k *kubevip.Config, mac string) *Client {
+	t.Helper()
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	previousMetadata, previousEndpoint := metadataURL, ec2Endpoint
+	metadataURL = server.URL
+	ec2Endpoint = func(string) string { return server.URL + "/" }
+	t.Cleanup(func() { metadataURL, ec2Endpoint = previousMetadata, previousEndpoint })
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	c, err := newClient(k, mac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestAssignVIP(t *testing.T) {
+	f := &fakeAWS{}
+	c := newTestClient(t, f, &kubevip.Config{AWSEIPAllocationID: "eipalloc-1"}, "")
+	if c.region != "eu-west-1" || c.eni != "eni-primary" {
+		t.Errorf("region = %q, ENI = %q, want eu-west-1 and eni-primary", c.region, c.eni)
+	}
+	if err := c.AssignVIP("10.0.1.100"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"AssignPrivateIpAddresses eni-primary 10.0.1.100 ",
+		"AssociateAddress eni-primary 10.0.1.100 eipalloc-1",
+	}
+	if strings.Join(f.actions, "\n") != strings.Join(want, "\n") {
+		t.Errorf("actions = %q, want %q", f.actions, want)
+	}
+}
+
+func TestAssignVIPInterface(t *testing.T) {
+	f := &fakeAWS{}
+	// The ENI of the VIP interface, without an EIP
+	c := newTestClient(t, f, &kubevip.Config{AWSRegion: "us-east-1"}, "0a:00:00:00:00:02")
+	if c.region != "us-east-1" || c.eni != "eni-secondary" {
+		t.Errorf("region = %q, ENI = %q, want us-east-1 and eni-secondary", c.region, c.eni)
+	}
+	if err := c.AssignVIP("10.0.2.100"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.actions) != 1 || f.actions[0] != "AssignPrivateIpAddresses eni-secondary 10.0.2.100 " {
+		t.Errorf("actions = %q, want only the assignment to eni-secondary", f.actions)
+	}
+
+	f.fail = "UnauthorizedOperation"
+	if err := c.AssignVIP("10.0.2.100"); err == nil || !strings.Contains(err.Error(), "UnauthorizedOperation") {
+		t.Errorf("AssignVIP() = %v, want the error of the EC2 API", err)
+	}
+}
@@ -0,0 +1,81 @@
+package aws
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// metadataURL is the instance metadata service (IMDS) of the EC2 instances
+var metadataURL = "http://169.254.169.254"
+
+// metadataTokenTTL is how long the IMDSv2 session token is valid for
+const metadataTokenTTL = 6 * time.Hour
+
+// metadata reads the instance metadata with IMDSv2, which needs a session token for every request
+type metadata struct {
+	client *http.Client
+
+	token   string
+	expires time.Time
+}
+
+func newMetadata() *metadata {
+	return &metadata{client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// get returns the value of the metadata path, relative to /latest/meta-data/
+func (m *metadata) get(path string) (string, error) {
+	token, err := m.sessionToken()
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodGet, metadataURL+"/latest/meta-data/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to read the instance metadata [%s], status [%s]", path, resp.Status)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// sessionToken returns the IMDSv2 session token, a new one is requested before the last one expires
+func (m *metadata) sessionToken() (string, error) {
+	if m.token != "" && time.Now().Before(m.expires) {
+		return m.token, nil
+	}
+	req, err := http.NewRequest(http.MethodPut, metadataURL+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", fmt.Sprint(int(metadataTokenTTL/time.Second)))
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to reach the instance metadata service: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to get an instance metadata token, status [%s]", resp.Status)
+	}
+	m.token = strings.TrimSpace(string(body))
+	// The token is renewed a minute before it expires
+	m.expires = time.Now().Add(metadataTokenTTL - time.Minute)
+	return m.token, nil
+}
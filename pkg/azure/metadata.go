@@ -0,0 +1,97 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// metadataURL is the instance metadata service (IMDS) of the Azure virtual machines
+var metadataURL = "http://169.254.169.254"
+
+// managementResource is the resource that the access tokens of the Azure Resource Manager API are requested for
+const managementResource = "https://management.azure.com/"
+
+// instance is the subset of the instance metadata that finds the virtual machine
+type instance struct {
+	Compute struct {
+		Name              string `json:"name"`
+		ResourceGroupName string `json:"resourceGroupName"`
+		SubscriptionID    string `json:"subscriptionId"`
+	} `json:"compute"`
+}
+
+// metadata reads the metadata of the instance, and the access token of its managed identity
+type metadata struct {
+	client *http.Client
+	// clientID selects the user-assigned managed identity, the system-assigned identity is used when it is empty
+	clientID string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func newMetadata(clientID string) *metadata {
+	return &metadata{client: &http.Client{Timeout: 5 * time.Second}, clientID: clientID}
+}
+
+// get decodes the metadata of the path into the result
+func (m *metadata) get(path string, query url.Values, result any) error {
+	req, err := http.NewRequest(http.MethodGet, metadataURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Metadata", "true")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to reach the instance metadata service: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to read the instance metadata [%s], status [%s]", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// instance returns the metadata of the virtual machine
+func (m *metadata) instance() (*instance, error) {
+	var i instance
+	if err := m.get("/metadata/instance", url.Values{"api-version": {"2021-02-01"}}, &i); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// accessToken returns the access token of the managed identity, a new one is requested before the last one expires
+func (m *metadata) accessToken() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.token != "" && time.Now().Before(m.expires) {
+		return m.token, nil
+	}
+	query := url.Values{"api-version": {"2018-02-01"}, "resource": {managementResource}}
+	if m.clientID != "" {
+		query.Set("client_id", m.clientID)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		// ExpiresIn is a number of seconds in a string
+		ExpiresIn string `json:"expires_in"`
+	}
+	if err := m.get("/metadata/identity/oauth2/token", query, &token); err != nil {
+		return "", fmt.Errorf("unable to get the access token of the managed identity: %v", err)
+	}
+	expiresIn, err := strconv.Atoi(token.ExpiresIn)
+	if err != nil {
+		return "", fmt.Errorf("invalid expiry of the access token [%s]", token.ExpiresIn)
+	}
+	m.token = token.AccessToken
+	// The token is renewed a minute before it expires
+	m.expires = time.Now().Add(time.Duration(expiresIn)*time.Second - time.Minute)
+	return m.token, nil
+}
@@ -0,0 +1,246 @@
+package azure
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// managementURL is the endpoint of the Azure Resource Manager API
+var managementURL = "https://management.azure.com"
+
+const (
+	computeAPIVersion = "2023-03-01"
+	networkAPIVersion = "2023-05-01"
+)
+
+// pollInterval is how often a long-running operation is checked until it is done
+var pollInterval = 2 * time.Second
+
+// Client moves the IP configuration of the VIP to the NIC of this virtual machine with the Azure Resource Manager API.
+// The virtual network only delivers the traffic of an address to the NIC that has it in an IP configuration, and the
+// public IP of the VIP is associated with that IP configuration so it moves with it.
+type Client struct {
+	// resourceGroup is the ID of the resource group of the virtual machine, the NICs that the VIP is moved from are in it
+	resourceGroup string
+	// nic is the ID of the primary NIC of this virtual machine
+	nic string
+	// ipConfigName is the name of the IP configuration of the VIP, it is derived from the VIP when it is empty
+	ipConfigName string
+	// publicIP is the ID of the public IP that is associated with the VIP, it is empty when there is none
+	publicIP string
+
+	client   *http.Client
+	metadata *metadata
+}
+
+// NewClient returns the client of the primary NIC of this virtual machine, which is found from the instance metadata
+func NewClient(k *kubevip.Config) (*Client, error) {
+	c := &Client{
+		ipConfigName: k.AzureIPConfigName,
+		publicIP:     k.AzurePublicIPID,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		metadata:     newMetadata(k.AzureClientID),
+	}
+	i, err := c.metadata.instance()
+	if err != nil {
+		return nil, err
+	}
+	c.resourceGroup = fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", i.Compute.SubscriptionID, i.Compute.ResourceGroupName)
+
+	var vm struct {
+		Properties struct {
+			NetworkProfile struct {
+				NetworkInterfaces []struct {
+					ID         string `json:"id"`
+					Properties struct {
+						Primary bool `json:"primary"`
+					} `json:"properties"`
+				} `json:"networkInterfaces"`
+			} `json:"networkProfile"`
+		} `json:"properties"`
+	}
+	vmID := c.resourceGroup + "/providers/Microsoft.Compute/virtualMachines/" + i.Compute.Name
+	if _, err := c.do(http.MethodGet, managementURL+vmID+"?api-version="+computeAPIVersion, nil, &vm); err != nil {
+		return nil, fmt.Errorf("unable to get the virtual machine [%s]: %v", i.Compute.Name, err)
+	}
+	for _, nic := range vm.Properties.NetworkProfile.NetworkInterfaces {
+		// A virtual machine with a single NIC doesn't always mark it as the primary one
+		if c.nic == "" || nic.Properties.Primary {
+			c.nic = nic.ID
+		}
+	}
+	if c.nic == "" {
+		return nil, fmt.Errorf("the virtual machine [%s] has no NIC", i.Compute.Name)
+	}
+	return c, nil
+}
+
+// AssignVIP moves the IP configuration of the VIP from the NIC of the previous leader to the NIC of this virtual
+// machine, with the public IP when one is configured. An address can only be in one IP configuration of the virtual
+// network, so it is removed from the previous NIC first.
+func (c *Client) AssignVIP(vip string) error {
+	name := c.ipConfigName
+	if name == "" {
+		name = IPConfigName(vip)
+	}
+
+	nics, err := c.listNICs()
+	if err != nil {
+		return err
+	}
+	var own map[string]any
+	for _, nic := range nics {
+		id, _ := nic["id"].(string)
+		if strings.EqualFold(id, c.nic) {
+			own = nic
+			continue
+		}
+		if !removeIPConfig(nic, func(ipConfig map[string]any) bool { return ipConfigAddress(ipConfig) == vip }) {
+			continue
+		}
+		log.Infof("[Azure] removing the VIP [%s] from the NIC [%s]", vip, path.Base(id))
+		if err := c.putNIC(nic); err != nil {
+			return err
+		}
+	}
+	if own == nil {
+		return fmt.Errorf("unable to find the NIC [%s] in the resource group", c.nic)
+	}
+
+	var subnet any
+	for _, ipConfig := range ipConfigurations(own) {
+		if ipConfigAddress(ipConfig) == vip && strings.EqualFold(ipConfigPublicIP(ipConfig), c.publicIP) {
+			log.Infof("[Azure] the VIP [%s] is already on the NIC [%s]", vip, path.Base(c.nic))
+			return nil
+		}
+		if properties, ok := ipConfig["properties"].(map[string]any); ok && (subnet == nil || properties["primary"] == true) {
+			subnet = properties["subnet"]
+		}
+	}
+	// A stale IP configuration of the VIP is replaced
+	removeIPConfig(own, func(ipConfig map[string]any) bool {
+		return ipConfig["name"] == name || ipConfigAddress(ipConfig) == vip
+	})
+
+	properties := map[string]any{
+		"privateIPAllocationMethod": "Static",
+		"privateIPAddress":          vip,
+		"primary":                   false,
+		"subnet":                    subnet,
+	}
+	if c.publicIP != "" {
+		properties["publicIPAddress"] = map[string]any{"id": c.publicIP}
+	}
+	addIPConfig(own, map[string]any{"name": name, "properties": properties})
+	log.Infof("[Azure] adding the VIP [%s] to the NIC [%s]", vip, path.Base(c.nic))
+	return c.putNIC(own)
+}
+
+// IPConfigName returns the name of the IP configuration of the VIP when none is configured
+func IPConfigName(vip string) string {
+	return "kube-vip-" + strings.ReplaceAll(vip, ".", "-")
+}
+
+// listNICs returns the NICs of the resource group
+func (c *Client) listNICs() ([]map[string]any, error) {
+	var nics []map[string]any
+	next := managementURL + c.resourceGroup + "/providers/Microsoft.Network/networkInterfaces?api-version=" + networkAPIVersion
+	for next != "" {
+		var page struct {
+			Value    []map[string]any `json:"value"`
+			NextLink string           `json:"nextLink"`
+		}
+		if _, err := c.do(http.MethodGet, next, nil, &page); err != nil {
+			return nil, fmt.Errorf("unable to list the NICs: %v", err)
+		}
+		nics = append(nics, page.Value...)
+		next = page.NextLink
+	}
+	return nics, nil
+}
+
+// putNIC updates the NIC, and waits for the update to be done
+func (c *Client) putNIC(nic map[string]any) error {
+	id, _ := nic["id"].(string)
+	header, err := c.do(http.MethodPut, managementURL+id+"?api-version="+networkAPIVersion, nic, nil)
+	if err != nil {
+		return fmt.Errorf("unable to update the NIC [%s]: %v", path.Base(id), err)
+	}
+	operation := header.Get("Azure-AsyncOperation")
+	for operation != "" {
+		var status struct {
+			Status string `json:"status"`
+			Error  struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if _, err := c.do(http.MethodGet, operation, nil, &status); err != nil {
+			return err
+		}
+		switch status.Status {
+		case "Succeeded":
+			return nil
+		case "Failed", "Canceled":
+			return fmt.Errorf("the update of the NIC [%s] failed: %s", path.Base(id), status.Error.Message)
+		}
+		time.Sleep(pollInterval)
+	}
+	return nil
+}
+
+// do sends the request with the access token of the managed identity, and decodes its response into the result
+func (c *Client) do(method, url string, body, result any) (http.Header, error) {
+	token, err := c.metadata.accessToken()
+	if err != nil {
+		return nil, err
+	}
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		var apiErr struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Error.Message == "" {
+			return nil, fmt.Errorf("%s failed with status [%s]", method, resp.Status)
+		}
+		return nil, fmt.Errorf("%s failed: %s: %s", method, apiErr.Error.Code, apiErr.Error.Message)
+	}
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return nil, err
+		}
+	}
+	return resp.Header, nil
+}
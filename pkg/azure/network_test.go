@@ -0,0 +1,136 @@
+package azure
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+const (
+	testGroup    = "/subscriptions/0000/resourceGroups/cluster"
+	testNIC      = testGroup + "/providers/Microsoft.Network/networkInterfaces/node1-nic"
+	testOtherNIC = testGroup + "/providers/Microsoft.Network/networkInterfaces/node2-nic"
+	testSubnet   = "/subscriptions/0000/resourceGroups/network/providers/Microsoft.Network/virtualNetworks/vnet/subnets/nodes"
+	testPublicIP = testGroup + "/providers/Microsoft.Network/publicIPAddresses/control-plane"
+)
+
+// fakeAzure answers the instance metadata of the virtual machine node1, and the calls of the Azure Resource Manager API
+// about its NICs
+type fakeAzure struct {
+	url string
+
+	mu    sync.Mutex
+	nics  map[string]map[string]any
+	calls []string
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var body any
+	switch {
+	case r.URL.Path == "/metadata/instance" && r.Header.Get("Metadata") == "true":
+		body = map[string]any{"compute": map[string]any{"name": "node1", "resourceGroupName": "cluster", "subscriptionId": "0000"}}
+	case r.URL.Path == "/metadata/identity/oauth2/token" && r.Header.Get("Metadata") == "true":
+		if r.URL.Query().Get("client_id") != "identity" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body = map[string]any{"access_token": "token", "expires_in": "3600"}
+	case r.Header.Get("Authorization") != "Bearer token":
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	case r.URL.Path == testGroup+"/providers/Microsoft.Compute/virtualMachines/node1":
+		body = map[string]any{"properties": map[string]any{"networkProfile": map[string]any{
+			"networkInterfaces": []any{map[string]any{"id": testNIC, "properties": map[string]any{"primary": true}}},
+		}}}
+	case r.URL.Path == testGroup+"/providers/Microsoft.Network/networkInterfaces":
+		var nics []any
+		for _, id := range []string{testNIC, testOtherNIC} {
+			nics = append(nics, f.nics[id])
+		}
+		body = map[string]any{"value": nics}
+	case r.Method == http.MethodPut && f.nics[r.URL.Path] != nil:
+		f.calls = append(f.calls, "PUT "+r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		var nic map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&nic)
+		f.nics[r.URL.Path] = nic
+		w.Header().Set("Azure-AsyncOperation", f.url+"/operations/1")
+		body = nic
+	case r.URL.Path == "/operations/1":
+		body = map[string]any{"status": "Succeeded"}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// testNICDocument returns the NIC with its primary IP configuration and the extra IP configurations
+func testNICDocument(id, address string, ipConfigs ...any) map[string]any {
+	primary := map[string]any{"name": "ipconfig1", "properties": map[string]any{
+		"primary": true, "privateIPAddress": address, "subnet": map[string]any{"id": testSubnet},
+	}}
+	return map[string]any{
+		"id":         id,
+		"etag":       "W/\"1\"",
+		"properties": map[string]any{"enableIPForwarding": false, "ipConfigurations": append([]any{primary}, ipConfigs...)},
+	}
+}
+
+func TestAssignVIP(t *testing.T) {
+	f := &fakeAzure{nics: map[string]map[string]any{
+		testNIC: testNICDocument(testNIC, "10.0.0.4"),
+		testOtherNIC: testNICDocument(testOtherNIC, "10.0.0.5", map[string]any{"name": "control-plane", "properties": map[string]any{
+			"privateIPAddress": "10.0.0.100", "publicIPAddress": map[string]any{"id": testPublicIP},
+		}}),
+	}}
+	server := httptest.NewServer(f)
+	defer server.Close()
+	f.url = server.URL
+	previousMetadata, previousManagement, previousPoll := metadataURL, managementURL, pollInterval
+	metadataURL, managementURL, pollInterval = server.URL, server.URL, 0
+	defer func() { metadataURL, managementURL, pollInterval = previousMetadata, previousManagement, previousPoll }()
+
+	c, err := NewClient(&kubevip.Config{AzureIPConfigName: "control-plane", AzurePublicIPID: testPublicIP, AzureClientID: "identity"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AssignVIP("10.0.0.100"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"PUT node2-nic", "PUT node1-nic"}; strings.Join(f.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %q, want %q", f.calls, want)
+	}
+	if ipConfigs := ipConfigurations(f.nics[testOtherNIC]); len(ipConfigs) != 1 {
+		t.Errorf("IP configurations of the previous NIC = %v, want only its primary one", ipConfigs)
+	}
+	ipConfigs := ipConfigurations(f.nics[testNIC])
+	if len(ipConfigs) != 2 {
+		t.Fatalf("IP configurations = %v, want the primary one and the VIP", ipConfigs)
+	}
+	vip := ipConfigs[1]
+	properties, _ := vip["properties"].(map[string]any)
+	subnet, _ := properties["subnet"].(map[string]any)
+	if vip["name"] != "control-plane" || ipConfigAddress(vip) != "10.0.0.100" || ipConfigPublicIP(vip) != testPublicIP || subnet["id"] != testSubnet {
+		t.Errorf("IP configuration of the VIP = %v", vip)
+	}
+	// The properties that kube-vip doesn't manage are kept
+	if f.nics[testNIC]["etag"] != "W/\"1\"" {
+		t.Errorf("NIC = %v, want its etag kept", f.nics[testNIC])
+	}
+
+	// The VIP that is already on this NIC isn't moved again
+	f.calls = nil
+	if err := c.AssignVIP("10.0.0.100"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.calls) != 0 {
+		t.Errorf("calls = %q, want none", f.calls)
+	}
+}
@@ -0,0 +1,68 @@
+package azure
+
+// The NICs are kept as the JSON documents of the API, so that an update doesn't drop the properties that kube-vip
+// doesn't know about
+
+// ipConfigurations returns the IP configurations of the NIC
+func ipConfigurations(nic map[string]any) []map[string]any {
+	properties, _ := nic["properties"].(map[string]any)
+	list, _ := properties["ipConfigurations"].([]any)
+	var ipConfigs []map[string]any
+	for _, item := range list {
+		if ipConfig, ok := item.(map[string]any); ok {
+			ipConfigs = append(ipConfigs, ipConfig)
+		}
+	}
+	return ipConfigs
+}
+
+// setIPConfigurations replaces the IP configurations of the NIC
+func setIPConfigurations(nic map[string]any, ipConfigs []map[string]any) {
+	properties, ok := nic["properties"].(map[string]any)
+	if !ok {
+		properties = map[string]any{}
+		nic["properties"] = properties
+	}
+	list := make([]any, 0, len(ipConfigs))
+	for _, ipConfig := range ipConfigs {
+		list = append(list, ipConfig)
+	}
+	properties["ipConfigurations"] = list
+}
+
+// addIPConfig adds the IP configuration to the NIC
+func addIPConfig(nic, ipConfig map[string]any) {
+	setIPConfigurations(nic, append(ipConfigurations(nic), ipConfig))
+}
+
+// removeIPConfig removes the IP configurations that match from the NIC, and returns whether any was removed
+func removeIPConfig(nic map[string]any, match func(map[string]any) bool) bool {
+	var kept []map[string]any
+	removed := false
+	for _, ipConfig := range ipConfigurations(nic) {
+		if match(ipConfig) {
+			removed = true
+			continue
+		}
+		kept = append(kept, ipConfig)
+	}
+	if removed {
+		setIPConfigurations(nic, kept)
+	}
+	return removed
+}
+
+// ipConfigAddress returns the private address of the IP configuration
+func ipConfigAddress(ipConfig map[string]any) string {
+	properties, _ := ipConfig["properties"].(map[string]any)
+	address, _ := properties["privateIPAddress"].(string)
+	return address
+}
+
+// ipConfigPublicIP returns the ID of the public IP that is associated with the IP configuration
+func ipConfigPublicIP(ipConfig map[string]any) string {
+	properties, _ := ipConfig["properties"].(map[string]any)
+	publicIP, _ := properties["publicIPAddress"].(map[string]any)
+	id, _ := publicIP["id"].(string)
+	return id
+}
@@ -0,0 +1,112 @@
+package bgp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/osrg/gobgp/v3/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// md5Counters are the kernel counters of TCP segments that fail MD5 authentication, either the signature is wrong or
+// it is missing
+var md5Counters = []string{"TCPMD5Failure", "TCPMD5NotFound"}
+
+// md5Failures returns the number of TCP MD5 authentication failures from /proc/net/netstat
+func md5Failures(r io.Reader) (uint64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		names := strings.Fields(scanner.Text())
+		if len(names) == 0 || names[0] != "TcpExt:" || !scanner.Scan() {
+			continue
+		}
+		// The names are followed by a line with their values
+		values := strings.Fields(scanner.Text())
+		var failures uint64
+		for x := range names {
+			for _, counter := range md5Counters {
+				if names[x] != counter || x >= len(values) {
+					continue
+				}
+				v, err := strconv.ParseUint(values[x], 10, 64)
+				if err != nil {
+					return 0, fmt.Errorf("unable to parse %s: %v", counter, err)
+				}
+				failures += v
+			}
+		}
+		return failures, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no TcpExt counters found")
+}
+
+func readMD5Failures() (uint64, error) {
+	f, err := os.Open("/proc/net/netstat")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return md5Failures(f)
+}
+
+// unauthenticatedPeers returns the peers that use a password and don't have an established session
+func (b *Server) unauthenticatedPeers(ctx context.Context) ([]string, error) {
+	var peers []string
+	err := b.s.ListPeer(ctx, &api.ListPeerRequest{}, func(p *api.Peer) {
+		if p.GetConf().GetAuthPassword() != "" && p.GetState().GetSessionState() != api.PeerState_ESTABLISHED {
+			peers = append(peers, p.GetConf().GetNeighborAddress())
+		}
+	})
+	return peers, err
+}
+
+// WatchAuthFailures will call failed whenever the kernel rejects TCP segments because of their MD5 signature while
+// peers that use a password aren't established. A wrong password is never reported by the BGP session itself, as the
+// segments are dropped before they reach it.
+func (b *Server) WatchAuthFailures(ctx context.Context, interval time.Duration, failed func(peers []string, failures uint64)) {
+	previous, err := readMD5Failures()
+	if err != nil {
+		log.Warnf("[BGP] unable to read TCP MD5 counters, authentication failures won't be detected: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := readMD5Failures()
+		if err != nil {
+			log.Warnf("[BGP] unable to read TCP MD5 counters: %v", err)
+			continue
+		}
+		if current <= previous {
+			continue
+		}
+		failures := current - previous
+		previous = current
+
+		peers, err := b.unauthenticatedPeers(ctx)
+		if err != nil {
+			log.Warnf("[BGP] unable to list peers: %v", err)
+			continue
+		}
+		// The counters are for the whole host, so they're only a failure of ours if a peer is failing to connect
+		if len(peers) != 0 {
+			failed(peers, failures)
+		}
+	}
+}
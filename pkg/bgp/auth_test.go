@@ -0,0 +1,25 @@
+package bgp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMD5Failures(t *testing.T) {
+	netstat := `TcpExt: SyncookiesSent TCPMD5NotFound TCPMD5Unexpected TCPMD5Failure
+TcpExt: 0 2 7 3
+IpExt: InNoRoutes InTruncatedPkts
+IpExt: 0 0
+`
+	failures, err := md5Failures(strings.NewReader(netstat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failures != 5 {
+		t.Errorf("md5Failures() = %d, want 5", failures)
+	}
+
+	if _, err := md5Failures(strings.NewReader("IpExt: InNoRoutes\nIpExt: 0\n")); err == nil {
+		t.Error("expected an error without TcpExt counters")
+	}
+}
@@ -0,0 +1,63 @@
+package bgp
+
+import (
+	"fmt"
+
+	api "github.com/osrg/gobgp/v3/api"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultRestartTime is how long (in seconds) the peers keep the routes while kube-vip restarts
+	DefaultRestartTime = 120
+
+	// The restart time is a 12 bit field of the graceful restart capability (RFC 4724)
+	maxRestartTime = 4095
+)
+
+// restartTime returns the restart time of a peer, or 0 if graceful restart isn't negotiated with it
+func (b *Server) restartTime(peer Peer) uint32 {
+	if !b.c.GracefulRestart && !peer.GracefulRestart {
+		return 0
+	}
+	if peer.RestartTime != 0 {
+		return peer.RestartTime
+	}
+	if b.c.RestartTime != 0 {
+		return b.c.RestartTime
+	}
+	return DefaultRestartTime
+}
+
+// gracefulRestart returns the graceful restart capability of a peer, it is negotiated for each of the families that
+// are exchanged with the peer
+func (b *Server) gracefulRestart(peer Peer, afiSafis []*api.AfiSafi) (*api.GracefulRestart, error) {
+	restartTime := b.restartTime(peer)
+	if restartTime == 0 {
+		return nil, nil
+	}
+	if restartTime > maxRestartTime {
+		return nil, fmt.Errorf("the restart time of peer [%s] is longer than %d seconds", peer.Address, maxRestartTime)
+	}
+
+	for _, afiSafi := range afiSafis {
+		afiSafi.MpGracefulRestart = &api.MpGracefulRestart{
+			Config: &api.MpGracefulRestartConfig{Enabled: true},
+		}
+	}
+	return &api.GracefulRestart{Enabled: true, RestartTime: restartTime}, nil
+}
+
+// Shutdown stops the server as kube-vip exits. With graceful restart the sessions are left to be closed by the exit
+// (without a notification), so that the peers keep the routes until kube-vip is back or the restart time is up.
+func (b *Server) Shutdown() error {
+	graceful := b.c.GracefulRestart
+	for _, peer := range b.c.Peers {
+		graceful = graceful || peer.GracefulRestart
+	}
+	if graceful {
+		log.Info("[BGP] leaving the sessions for the graceful restart of the peers")
+		return nil
+	}
+	return b.Close()
+}
@@ -0,0 +1,75 @@
+package bgp
+
+import (
+	"testing"
+
+	api "github.com/osrg/gobgp/v3/api"
+)
+
+func TestGracefulRestart(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *Config
+		peer    Peer
+		want    uint32
+		wantErr bool
+	}{
+		{name: "disabled", c: &Config{}, peer: Peer{Address: "192.168.0.1"}},
+		{name: "every peer", c: &Config{GracefulRestart: true}, peer: Peer{Address: "192.168.0.1"}, want: DefaultRestartTime},
+		{name: "restart time of the server", c: &Config{GracefulRestart: true, RestartTime: 300}, peer: Peer{Address: "192.168.0.1"}, want: 300},
+		{name: "restart time of the peer", c: &Config{GracefulRestart: true, RestartTime: 300}, peer: Peer{Address: "fd00::1", RestartTime: 60}, want: 60},
+		{name: "only the peer", c: &Config{}, peer: Peer{Address: "192.168.0.1", GracefulRestart: true}, want: DefaultRestartTime},
+		{name: "too long", c: &Config{GracefulRestart: true, RestartTime: 5000}, peer: Peer{Address: "192.168.0.1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Server{c: tt.c}
+			afiSafis := []*api.AfiSafi{unicastFamily(api.Family_AFI_IP), unicastFamily(api.Family_AFI_IP6)}
+			got, err := b.gracefulRestart(tt.peer, afiSafis)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("gracefulRestart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == 0 {
+				if got != nil || afiSafis[0].MpGracefulRestart != nil || afiSafis[1].MpGracefulRestart != nil {
+					t.Errorf("gracefulRestart() = %v, %v without graceful restart", got, afiSafis)
+				}
+				return
+			}
+			if !got.Enabled || got.RestartTime != tt.want {
+				t.Errorf("gracefulRestart() = %v, want a restart time of %d", got, tt.want)
+			}
+			// Graceful restart is negotiated for both families, whatever the family of the address of the peer
+			for _, afiSafi := range afiSafis {
+				if afiSafi.MpGracefulRestart == nil || !afiSafi.MpGracefulRestart.Config.Enabled {
+					t.Errorf("gracefulRestart() isn't negotiated for %s", afiSafi.Config.Family.Afi)
+				}
+			}
+		})
+	}
+}
+
+func TestParseBGPPeerConfigGracefulRestart(t *testing.T) {
+	peers, err := ParseBGPPeerConfig("192.168.0.1:65000::false:true:300,[fd00::1]:65001:secret,192.168.0.2:65000::false:true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Peer{
+		{Address: "192.168.0.1", AS: 65000, GracefulRestart: true, RestartTime: 300},
+		{Address: "fd00::1", AS: 65001, Password: "secret"},
+		{Address: "192.168.0.2", AS: 65000, GracefulRestart: true},
+	}
+	if len(peers) != len(want) {
+		t.Fatalf("ParseBGPPeerConfig() = %v, want %v", peers, want)
+	}
+	for i := range want {
+		if peers[i] != want[i] {
+			t.Errorf("peer %d = %+v, want %+v", i, peers[i], want[i])
+		}
+	}
+
+	for _, config := range []string{"192.168.0.1:65000::false:maybe", "192.168.0.1:65000::false:true:5000"} {
+		if _, err := ParseBGPPeerConfig(config); err == nil {
+			t.Errorf("ParseBGPPeerConfig(%s) didn't fail", config)
+		}
+	}
+}
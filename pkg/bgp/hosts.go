@@ -8,6 +8,15 @@ import (
 	api "github.com/osrg/gobgp/v3/api"
 )
 
+// HostCIDR is the host route of an address in CIDR notation, /32 for IPv4 and /128 for IPv6. The prefix of the VIP
+// configuration can be a list for dual-stack VIPs, and the route of a VIP is always advertised for the host
+func HostCIDR(address string) string {
+	if ip := net.ParseIP(address); ip != nil && ip.To4() == nil {
+		return fmt.Sprintf("%s/128", address)
+	}
+	return fmt.Sprintf("%s/32", address)
+}
+
 // AddHost will update peers of a host
 func (b *Server) AddHost(addr string) (err error) {
 	ip, _, err := net.ParseCIDR(addr)
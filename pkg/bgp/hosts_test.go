@@ -0,0 +1,19 @@
+package bgp
+
+import "testing"
+
+func TestHostCIDR(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"192.168.0.10", "192.168.0.10/32"},
+		{"fd00::10", "fd00::10/128"},
+		{"::ffff:192.168.0.10", "::ffff:192.168.0.10/32"},
+	}
+	for _, tt := range tests {
+		if got := HostCIDR(tt.address); got != tt.want {
+			t.Errorf("HostCIDR(%s) = %s, want %s", tt.address, got, tt.want)
+		}
+	}
+}
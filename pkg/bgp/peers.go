@@ -12,7 +12,11 @@ import (
 	api "github.com/osrg/gobgp/v3/api"
 )
 
-// AddPeer will add peers to the BGP configuration
+// peerPort is the port that the sessions with the peers are opened to
+var peerPort uint32 = 179
+
+// AddPeer will add peers to the BGP configuration, both the IPv4 and the IPv6 unicast families are exchanged with every
+// peer (whatever the family of its address) so that the VIPs of both families reach it
 func (b *Server) AddPeer(peer Peer) (err error) {
 	p := &api.Peer{
 		Conf: &api.PeerConf{
@@ -38,7 +42,12 @@ func (b *Server) AddPeer(peer Peer) (err error) {
 		Transport: &api.Transport{
 			MtuDiscovery:  true,
 			RemoteAddress: peer.Address,
-			RemotePort:    uint32(179),
+			RemotePort:    peerPort,
+		},
+
+		AfiSafis: []*api.AfiSafi{
+			unicastFamily(api.Family_AFI_IP),
+			unicastFamily(api.Family_AFI_IP6),
 		},
 	}
 
@@ -50,11 +59,45 @@ func (b *Server) AddPeer(peer Peer) (err error) {
 		p.Transport.BindInterface = b.c.SourceIF
 	}
 
+	if p.GracefulRestart, err = b.gracefulRestart(peer, p.AfiSafis); err != nil {
+		return err
+	}
+
 	return b.s.AddPeer(context.Background(), &api.AddPeerRequest{
 		Peer: p,
 	})
 }
 
+// UpdatePeer will replace an existing peer, this resets the session with only that peer so that a changed password
+// is used to authenticate it. The advertised paths are sent to the peer again once the session is re-established.
+func (b *Server) UpdatePeer(peer Peer) error {
+	if err := b.DeletePeer(peer.Address); err != nil {
+		return err
+	}
+	return b.AddPeer(peer)
+}
+
+// DeletePeer will close the session with a peer and remove it from the BGP configuration
+func (b *Server) DeletePeer(address string) error {
+	err := b.s.DeletePeer(context.Background(), &api.DeletePeerRequest{
+		Address: address,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to remove peer [%s]: %v", address, err)
+	}
+	return nil
+}
+
+// unicastFamily returns the unicast family of the AFI, enabled for a peer
+func unicastFamily(afi api.Family_Afi) *api.AfiSafi {
+	return &api.AfiSafi{
+		Config: &api.AfiSafiConfig{
+			Family:  &api.Family{Afi: afi, Safi: api.Family_SAFI_UNICAST},
+			Enabled: true,
+		},
+	}
+}
+
 func (b *Server) getPath(ip net.IP) (path *api.Path) {
 	isV6 := ip.To4() == nil
 
@@ -108,9 +151,88 @@ func (b *Server) getPath(ip net.IP) (path *api.Path) {
 			Pattrs: []*any.Any{originAttr, mpAttr},
 		}
 	}
+
+	path.Pattrs = append(path.Pattrs, b.getHostAttributes(ip)...)
+	return
+}
+
+// getHostAttributes returns any additional path attributes that have been set for a host
+func (b *Server) getHostAttributes(ip net.IP) (attrs []*any.Any) {
+	b.mutex.Lock()
+	h := b.hostAttributes[ip.String()]
+	b.mutex.Unlock()
+	if h == nil {
+		return
+	}
+
+	if len(h.Communities) != 0 {
+		// The communities are validated when they're set
+		communities, _ := parseCommunities(h.Communities)
+		//nolint
+		attr, _ := ptypes.MarshalAny(&api.CommunitiesAttribute{
+			Communities: communities,
+		})
+		attrs = append(attrs, attr)
+	}
+
+	if h.LocalPref != 0 {
+		//nolint
+		attr, _ := ptypes.MarshalAny(&api.LocalPrefAttribute{
+			LocalPref: h.LocalPref,
+		})
+		attrs = append(attrs, attr)
+	}
 	return
 }
 
+// SetHostAttributes will set the additional path attributes advertised with a host, this needs to be set before
+// the host is added. Setting nil attributes will remove them.
+func (b *Server) SetHostAttributes(addr string, attrs *HostAttributes) error {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return fmt.Errorf("invalid address [%s]", addr)
+	}
+
+	if attrs != nil {
+		if _, err := parseCommunities(attrs.Communities); err != nil {
+			return err
+		}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if attrs == nil {
+		delete(b.hostAttributes, ip.String())
+		return nil
+	}
+	if b.hostAttributes == nil {
+		b.hostAttributes = map[string]*HostAttributes{}
+	}
+	b.hostAttributes[ip.String()] = attrs
+	return nil
+}
+
+// parseCommunities will parse communities in the format "65000:100" into their 32bit values
+func parseCommunities(communities []string) ([]uint32, error) {
+	var values []uint32
+	for _, community := range communities {
+		asn, value, found := strings.Cut(community, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid community [%s], expected the format ASN:value", community)
+		}
+		high, err := strconv.ParseUint(asn, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid community [%s]: %v", community, err)
+		}
+		low, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid community [%s]: %v", community, err)
+		}
+		values = append(values, uint32(high<<16|low))
+	}
+	return values, nil
+}
+
 // ParseBGPPeerConfig - take a string and parses it into an array of peers
 func ParseBGPPeerConfig(config string) (bgpPeers []Peer, err error) {
 	peers := strings.Split(config, ",")
@@ -162,11 +284,29 @@ func ParseBGPPeerConfig(config string) (bgpPeers []Peer, err error) {
 			}
 		}
 
+		gracefulRestart := false
+		if len(peer) >= 5 {
+			gracefulRestart, err = strconv.ParseBool(peer[4])
+			if err != nil {
+				return nil, fmt.Errorf("BGP graceful restart format error (true/false) [%s]", peer[4])
+			}
+		}
+
+		var restartTime uint64
+		if len(peer) >= 6 {
+			restartTime, err = strconv.ParseUint(peer[5], 10, 12)
+			if err != nil {
+				return nil, fmt.Errorf("BGP restart time format error (0-%d seconds) [%s]", maxRestartTime, peer[5])
+			}
+		}
+
 		peerConfig := Peer{
-			Address:  address,
-			AS:       uint32(ASNumber),
-			Password: password,
-			MultiHop: multiHop,
+			Address:         address,
+			AS:              uint32(ASNumber),
+			Password:        password,
+			MultiHop:        multiHop,
+			GracefulRestart: gracefulRestart,
+			RestartTime:     uint32(restartTime),
 		}
 
 		bgpPeers = append(bgpPeers, peerConfig)
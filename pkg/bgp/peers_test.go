@@ -0,0 +1,77 @@
+package bgp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	api "github.com/osrg/gobgp/v3/api"
+	gobgp "github.com/osrg/gobgp/v3/pkg/server"
+)
+
+// neighbour starts a BGP server on the address that waits for the session of the peer, with both unicast families
+func neighbour(t *testing.T, address, peer string) (*gobgp.BgpServer, uint32) {
+	l, err := net.Listen("tcp", net.JoinHostPort(address, "0"))
+	if err != nil {
+		t.Skipf("unable to listen on [%s]: %v", address, err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	s := gobgp.NewBgpServer()
+	go s.Serve()
+	t.Cleanup(func() { _ = s.StopBgp(context.Background(), &api.StopBgpRequest{}) })
+	if err := s.StartBgp(context.Background(), &api.StartBgpRequest{
+		Global: &api.Global{Asn: 65001, RouterId: address, ListenPort: int32(port), ListenAddresses: []string{address}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddPeer(context.Background(), &api.AddPeerRequest{Peer: &api.Peer{
+		Conf:      &api.PeerConf{NeighborAddress: peer, PeerAsn: 65000},
+		Transport: &api.Transport{PassiveMode: true},
+		AfiSafis:  []*api.AfiSafi{unicastFamily(api.Family_AFI_IP), unicastFamily(api.Family_AFI_IP6)},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	return s, uint32(port)
+}
+
+func TestAddPeerDualStack(t *testing.T) {
+	n, port := neighbour(t, "127.0.0.2", "127.0.0.1")
+	defer func(port uint32) { peerPort = port }(peerPort)
+	peerPort = port
+
+	b, err := NewBGPServer(&Config{
+		AS:       65000,
+		RouterID: "127.0.0.1",
+		SourceIP: "127.0.0.1",
+		Peers:    []Peer{{Address: "127.0.0.2", AS: 65001}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	// The IPv6 VIP of a dual-stack service reaches the neighbour of the IPv4 session
+	if err := b.AddHost(HostCIDR("fd00::10")); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		var prefixes []string
+		if err := n.ListPath(context.Background(), &api.ListPathRequest{
+			TableType: api.TableType_GLOBAL,
+			Family:    &api.Family{Afi: api.Family_AFI_IP6, Safi: api.Family_SAFI_UNICAST},
+		}, func(d *api.Destination) {
+			prefixes = append(prefixes, d.Prefix)
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if len(prefixes) == 1 && prefixes[0] == "fd00::10/128" {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("the neighbour didn't receive the IPv6 path over the IPv4 session")
+}
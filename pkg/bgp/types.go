@@ -1,6 +1,10 @@
 package bgp
 
-import gobgp "github.com/osrg/gobgp/v3/pkg/server"
+import (
+	"sync"
+
+	gobgp "github.com/osrg/gobgp/v3/pkg/server"
+)
 
 // Peer defines a BGP Peer
 type Peer struct {
@@ -8,6 +12,11 @@ type Peer struct {
 	AS       uint32
 	Password string
 	MultiHop bool
+
+	// GracefulRestart is negotiated with the peer, so that it keeps the routes while kube-vip restarts, for the
+	// RestartTime (in seconds) of the peer or of the server
+	GracefulRestart bool
+	RestartTime     uint32
 }
 
 // Config defines the BGP server configuration
@@ -20,11 +29,27 @@ type Config struct {
 	HoldTime          uint64
 	KeepaliveInterval uint64
 
+	// GracefulRestart is negotiated with every peer, RestartTime (in seconds) applies to the peers that don't set
+	// their own
+	GracefulRestart bool
+	RestartTime     uint32
+
 	Peers []Peer
 }
 
+// HostAttributes are additional path attributes that are advertised with a host
+type HostAttributes struct {
+	// Communities in the format "65000:100"
+	Communities []string
+	LocalPref   uint32
+}
+
 // Server manages a server object
 type Server struct {
 	s *gobgp.BgpServer
 	c *Config
+
+	// Additional path attributes for specific hosts, indexed by address
+	hostAttributes map[string]*HostAttributes
+	mutex          sync.Mutex
 }
@@ -0,0 +1,75 @@
+// Package capabilities checks the Linux capabilities that kube-vip is running with
+package capabilities
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// bits are the capabilities that kube-vip may need, indexed by name (see capabilities(7))
+var bits = map[string]uint{
+	"NET_ADMIN": 12,
+	"NET_RAW":   13,
+	"BPF":       39,
+}
+
+// Effective returns the effective capabilities of kube-vip
+func Effective() (uint64, error) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return parseEffective(f)
+}
+
+// parseEffective will find the effective capabilities (CapEff) in the status of a process
+func parseEffective(r io.Reader) (uint64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), "CapEff:")
+		if !found {
+			continue
+		}
+		return strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no effective capabilities found")
+}
+
+// Missing returns the capabilities that aren't in the effective set
+func Missing(effective uint64, required []string) ([]string, error) {
+	var missing []string
+	for _, name := range required {
+		bit, known := bits[name]
+		if !known {
+			return nil, fmt.Errorf("unknown capability [%s]", name)
+		}
+		if effective&(1<<bit) == 0 {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// Check will ensure that kube-vip is running with all of the required capabilities
+func Check(required []string) error {
+	effective, err := Effective()
+	if err != nil {
+		return fmt.Errorf("unable to read the capabilities of kube-vip: %v", err)
+	}
+	missing, err := Missing(effective, required)
+	if err != nil {
+		return err
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("kube-vip is missing the capabilities %v, add them to the securityContext of the kube-vip container (securityContext.capabilities.add)", missing)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package capabilities
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseEffective(t *testing.T) {
+	status := "Name:\tkube-vip\nCapInh:\t0000000000000000\nCapPrm:\t0000000000003000\nCapEff:\t0000000000003000\n"
+	effective, err := parseEffective(strings.NewReader(status))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if effective != 0x3000 {
+		t.Errorf("parseEffective() = %x, want 3000", effective)
+	}
+
+	if _, err := parseEffective(strings.NewReader("Name:\tkube-vip\n")); err == nil {
+		t.Errorf("parseEffective() expected an error without CapEff")
+	}
+}
+
+func TestMissing(t *testing.T) {
+	tests := []struct {
+		name      string
+		effective uint64
+		want      []string
+		wantErr   bool
+	}{
+		{"all", 0x3000, nil, false},
+		{"no NET_RAW", 0x1000, []string{"NET_RAW"}, false},
+		{"none", 0, []string{"NET_ADMIN", "NET_RAW"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Missing(tt.effective, []string{"NET_ADMIN", "NET_RAW"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Missing() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Missing() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Missing(0, []string{"SYS_ADMIN"}); err == nil {
+		t.Errorf("Missing() expected an error for an unknown capability")
+	}
+}
@@ -0,0 +1,164 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kube-vip/kube-vip/pkg/etcd"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// The status of the bootstrap is published as annotations of the node, for the Cluster API controllers
+const (
+	bootstrapAnnotation          = "kube-vip.io/bootstrap"
+	bootstrapLeaderAnnotation    = "kube-vip.io/bootstrap-leader"
+	bootstrapCompletedAnnotation = "kube-vip.io/bootstrap-completed"
+)
+
+// bootstrapStatusInterval is how often the status is retried, the node may only register after the API server is healthy
+var bootstrapStatusInterval = 5 * time.Second
+
+// runBootstrap elects the control plane VIP with etcd or the static peers until the API server is healthy, the leader
+// serves the VIP in the meantime. It returns if this node is still serving the VIP, so that it can be kept while the
+// Kubernetes lease is taken, and the leader of the bootstrap.
+func (cluster *Cluster) runBootstrap(ctx context.Context, run *runConfig) (bool, string) {
+	bootstrapCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mutex sync.Mutex
+	var leading, handedOver bool
+	var leader string
+
+	started := func(ctx context.Context) {
+		mutex.Lock()
+		if ctx.Err() != nil || handedOver {
+			// The API server became healthy while etcd was waiting for the previous leader to step down
+			mutex.Unlock()
+			return
+		}
+		leading = true
+		mutex.Unlock()
+		run.onStartedLeading(ctx)
+	}
+
+	var wg sync.WaitGroup
+	switch run.config.Bootstrap {
+	case kubevip.BootstrapEtcd:
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for bootstrapCtx.Err() == nil {
+				err := etcd.RunElection(bootstrapCtx, &etcd.LeaderElectionConfig{
+					EtcdConfig:           etcd.ClientConfig{Client: run.sm.EtcdClient},
+					Name:                 run.config.LeaseName,
+					MemberID:             run.leaseID,
+					LeaseDurationSeconds: int64(run.config.LeaseDuration),
+					Callbacks: etcd.LeaderCallbacks{
+						OnStartedLeading: started,
+						OnStoppedLeading: func() {
+							mutex.Lock()
+							done := handedOver
+							mutex.Unlock()
+							// Stepping down for the Kubernetes lease keeps the VIP
+							if !done {
+								run.onStoppedLeading()
+							}
+						},
+						OnNewLeader: func(identity string) {
+							mutex.Lock()
+							leader = identity
+							mutex.Unlock()
+							log.Infof("[bootstrap] node [%s] is assuming leadership of the control plane VIP", identity)
+						},
+					},
+				})
+				if err == nil {
+					return
+				}
+				// etcd is started with the API server, so it may not be up yet either
+				log.Warnf("[bootstrap] unable to join the etcd election: %v", err)
+				select {
+				case <-bootstrapCtx.Done():
+				case <-time.After(time.Duration(run.config.RetryPeriod) * time.Second):
+				}
+			}
+		}()
+	case kubevip.BootstrapStatic:
+		leader = run.config.BootstrapPeers[0]
+		log.Infof("[bootstrap] node [%s] is the first static peer and holds the control plane VIP", leader)
+		if leader == run.leaseID {
+			go started(bootstrapCtx)
+		}
+	}
+
+	if err := waitForAPIServer(ctx, run.sm.KubernetesClient, time.Duration(run.config.RetryPeriod)*time.Second); err != nil {
+		wg.Wait()
+		return false, ""
+	}
+	log.Info("[bootstrap] the API server is healthy, moving to kubernetes leader election")
+
+	mutex.Lock()
+	handedOver = true
+	mutex.Unlock()
+	cancel()
+	wg.Wait()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	return leading, leader
+}
+
+// waitForAPIServer polls the readiness of the API server until it is ready, or the context is cancelled
+func waitForAPIServer(ctx context.Context, client kubernetes.Interface, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_, err := client.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx)
+		if err == nil {
+			return nil
+		}
+		log.Debugf("[bootstrap] the API server isn't ready: %v", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishBootstrapStatus annotates the node with the bootstrap that it took part in, and its leader, once the control
+// plane VIP has moved to kubernetes leader election
+func publishBootstrapStatus(ctx context.Context, client kubernetes.Interface, c *kubevip.Config, leader string) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]string{
+			bootstrapAnnotation:          c.Bootstrap,
+			bootstrapLeaderAnnotation:    leader,
+			bootstrapCompletedAnnotation: time.Now().UTC().Format(time.RFC3339),
+		}},
+	})
+	if err != nil {
+		log.Errorf("[bootstrap] unable to encode the status: %v", err)
+		return
+	}
+	ticker := time.NewTicker(bootstrapStatusInterval)
+	defer ticker.Stop()
+	for {
+		_, err := client.CoreV1().Nodes().Patch(ctx, c.NodeName, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err == nil {
+			return
+		}
+		log.Debugf("[bootstrap] unable to publish the status on node [%s]: %v", c.NodeName, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// fakeAPIServer is not ready for the first probes, and records the annotations that are patched on the node
+type fakeAPIServer struct {
+	probes      atomic.Int32
+	notReady    int32
+	annotations chan map[string]string
+}
+
+func (f *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/readyz":
+		if f.probes.Add(1) <= f.notReady {
+			http.Error(w, "etcd not ready", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	case r.Method == http.MethodPatch && r.URL.Path == "/api/v1/nodes/cp-0":
+		body, _ := io.ReadAll(r.Body)
+		var patch struct {
+			Metadata struct {
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		}
+		_ = json.Unmarshal(body, &patch)
+		f.annotations <- patch.Metadata.Annotations
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind":"Node","apiVersion":"v1","metadata":{"name":"cp-0"}}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newFakeAPIServer(t *testing.T, notReady int32) (*fakeAPIServer, *kubernetes.Clientset) {
+	t.Helper()
+	f := &fakeAPIServer{notReady: notReady, annotations: make(chan map[string]string, 1)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, client
+}
+
+func TestRunBootstrapStatic(t *testing.T) {
+	tests := []struct {
+		name        string
+		node        string
+		wantLeading bool
+	}{
+		{"first peer", "cp-0", true},
+		{"other peer", "cp-1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, client := newFakeAPIServer(t, 1)
+			var started atomic.Bool
+			run := &runConfig{
+				config: &kubevip.Config{
+					Bootstrap:                kubevip.BootstrapStatic,
+					BootstrapPeers:           []string{"cp-0", "cp-1"},
+					KubernetesLeaderElection: kubevip.KubernetesLeaderElection{RetryPeriod: 1},
+				},
+				leaseID:          tt.node,
+				sm:               &Manager{KubernetesClient: client},
+				onStartedLeading: func(context.Context) { started.Store(true) },
+				onStoppedLeading: func() { t.Error("the VIP of the bootstrap was released") },
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			leading, leader := (&Cluster{}).runBootstrap(ctx, run)
+			if leading != tt.wantLeading || leader != "cp-0" {
+				t.Errorf("runBootstrap() = %t, %q, want %t, %q", leading, leader, tt.wantLeading, "cp-0")
+			}
+			if f.probes.Load() != 2 {
+				t.Errorf("the API server was probed %d times, want 2", f.probes.Load())
+			}
+			if leading && !started.Load() {
+				t.Errorf("the VIP wasn't started on the first peer")
+			}
+		})
+	}
+}
+
+func TestRunBootstrapCancelled(t *testing.T) {
+	_, client := newFakeAPIServer(t, 1<<30)
+	run := &runConfig{
+		config: &kubevip.Config{
+			Bootstrap:                kubevip.BootstrapStatic,
+			BootstrapPeers:           []string{"cp-0"},
+			KubernetesLeaderElection: kubevip.KubernetesLeaderElection{RetryPeriod: 1},
+		},
+		leaseID:          "cp-1",
+		sm:               &Manager{KubernetesClient: client},
+		onStartedLeading: func(context.Context) {},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if leading, leader := (&Cluster{}).runBootstrap(ctx, run); leading || leader != "" {
+		t.Errorf("runBootstrap() = %t, %q after the context was cancelled", leading, leader)
+	}
+}
+
+func TestPublishBootstrapStatus(t *testing.T) {
+	f, client := newFakeAPIServer(t, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	publishBootstrapStatus(ctx, client, &kubevip.Config{NodeName: "cp-0", Bootstrap: kubevip.BootstrapEtcd}, "cp-2")
+
+	select {
+	case annotations := <-f.annotations:
+		if annotations[bootstrapAnnotation] != "etcd" || annotations[bootstrapLeaderAnnotation] != "cp-2" {
+			t.Errorf("unexpected annotations %v", annotations)
+		}
+		if _, err := time.Parse(time.RFC3339, annotations[bootstrapCompletedAnnotation]); err != nil {
+			t.Errorf("the completion time isn't RFC3339: %v", err)
+		}
+	default:
+		t.Fatal("the status wasn't published")
+	}
+}
@@ -2,9 +2,11 @@ package cluster
 
 import (
 	"sync"
+	"sync/atomic"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/kube-vip/kube-vip/pkg/handover"
 	"github.com/kube-vip/kube-vip/pkg/kubevip"
 	"github.com/kube-vip/kube-vip/pkg/vip"
 )
@@ -15,6 +17,11 @@ type Cluster struct {
 	completed chan bool
 	once      sync.Once
 	Network   []vip.Network
+
+	// The VIPs that the kube-vip this one replaces handed over
+	handover []handover.VIP
+	// Set once the VIPs of a service are handed over, they are left on their interfaces when it is stopped
+	handingOver atomic.Bool
 }
 
 // InitCluster - Will attempt to initialise all of the required settings for the cluster
@@ -50,10 +57,33 @@ func startNetworking(c *kubevip.Config) ([]vip.Network, error) {
 
 	networks := []vip.Network{}
 	for _, addr := range addresses {
-		network, err := vip.NewConfig(addr, c.Interface, c.VIPSubnet, c.DDNS, c.RoutingTableID, c.RoutingTableType, c.RoutingProtocol, c.DNSMode, c.LoadBalancerForwardingMethod, c.IptablesBackend)
+		network, err := vip.NewConfig(addr, c.Interface, c.VIPSubnet, c.DDNS, c.RoutingTableID, c.RoutingTableType, c.RoutingProtocol, c.RoutingMetric, c.DNSMode, c.LoadBalancerForwardingMethod, c.IptablesBackend, c.FirewallBackend)
 		if err != nil {
 			return nil, err
 		}
+		if c.EnableRoutingTable && len(c.RoutingNextHops) != 0 {
+			hops, err := vip.ParseNextHops(c.RoutingNextHops)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range network {
+				if err := n.SetNextHops(hops); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if c.EnableRoutingTable && c.RouteAttributes() != (vip.RouteAttributes{}) {
+			for _, n := range network {
+				if err := n.SetRouteAttributes(c.RouteAttributes()); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if c.EnableRoutingTable && c.RoutingRuleTable != 0 {
+			for _, n := range network {
+				n.SetRuleTable(c.RoutingRuleTable)
+			}
+		}
 		networks = append(networks, network...)
 	}
 
@@ -3,6 +3,10 @@ package cluster
 import (
 	"context"
 
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kube-vip/kube-vip/pkg/ddns"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
 	"github.com/kube-vip/kube-vip/pkg/vip"
 )
 
@@ -12,9 +16,19 @@ import (
 // during runtime if IP changes, startDDNS don't have to do reconfigure because
 // dnsUpdater already have the functionality to keep trying resolve the IP
 // and update the VIP configuration if it changes
-func (cluster *Cluster) StartDDNS(ctx context.Context) error {
+// The record of the address is published through the DDNS provider, which is the DHCP server itself by default
+func (cluster *Cluster) StartDDNS(ctx context.Context, c *kubevip.Config, sm *Manager) error {
+	var clientSet kubernetes.Interface
+	if sm != nil && sm.KubernetesClient != nil {
+		clientSet = sm.KubernetesClient
+	}
+	provider, err := ddns.NewFromConfig(ctx, c, clientSet)
+	if err != nil {
+		return err
+	}
+
 	for i := range cluster.Network {
-		ddnsMgr := vip.NewDDNSManager(ctx, cluster.Network[i])
+		ddnsMgr := vip.NewDDNSManager(ctx, cluster.Network[i], ddns.Publisher{Provider: provider}, cluster.requestedIP(cluster.Network[i].Interface()))
 		ip, err := ddnsMgr.Start()
 		if err != nil {
 			return err
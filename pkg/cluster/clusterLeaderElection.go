@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/kube-vip/kube-vip/pkg/k8s"
 	"github.com/kube-vip/kube-vip/pkg/kubevip"
 	"github.com/kube-vip/kube-vip/pkg/loadbalancer"
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
 
 	"github.com/packethost/packngo"
 
@@ -105,17 +107,46 @@ func (cluster *Cluster) StartCluster(c *kubevip.Config, sm *Manager, bgpServer *
 	// Add Notification for SIGTERM (sent from Kubernetes)
 	signal.Notify(signalChan, syscall.SIGTERM)
 
+	// Set while this node leads, and once the VIP has been handed over to the kube-vip that replaces this one
+	var leading, handingOver atomic.Bool
+
 	go func() {
 		<-signalChan
 		log.Info("Received termination, signaling cluster shutdown")
+		// The leader hands the VIP over rather than releasing it, for an upgrade
+		if c.Handover && leading.Load() {
+			if err := cluster.writeHandover(c); err != nil {
+				log.Errorf("[handover] unable to hand the VIP over, releasing it: %v", err)
+			} else {
+				log.Infof("[handover] the VIP and the lease are handed over in [%s]", c.HandoverPath)
+				handingOver.Store(true)
+			}
+		}
 		// Cancel the context, which will in turn cancel the leadership
 		cancel()
 		// Cancel the arp context, which will in turn stop any broadcasts
 	}()
 
+	// Set while the VIPs that the previous kube-vip handed over are kept, until the election decides where they go
+	var adopted atomic.Bool
+	if cluster.takeHandover(c) {
+		adopted.Store(true)
+		// The lease that they were handed over with expires by then, so another node may hold them
+		time.AfterFunc(time.Duration(c.LeaseDuration)*time.Second, func() {
+			if adopted.Swap(false) {
+				log.Warn("[handover] this node didn't take the lease in time")
+				cluster.releaseHandover()
+			}
+		})
+	}
+
 	// (attempt to) Remove the virtual IP, in case it already exists
 
 	for i := range cluster.Network {
+		if cluster.handedOver(cluster.Network[i]) {
+			log.Infof("[handover] keeping the VIP [%s] that was handed over", cluster.Network[i].IP())
+			continue
+		}
 		err = cluster.Network[i].DeleteIP()
 		if err != nil {
 			log.Errorf("could not delete virtualIP: %v", err)
@@ -132,18 +163,7 @@ func (cluster *Cluster) StartCluster(c *kubevip.Config, sm *Manager, bgpServer *
 	// If Equinix Metal is enabled then we can begin our preparation work
 	var packetClient *packngo.Client
 	if c.EnableMetal {
-		if c.ProviderConfig != "" {
-			key, project, err := equinixmetal.GetPacketConfig(c.ProviderConfig)
-			if err != nil {
-				log.Error(err)
-			} else {
-				// Set the environment variable with the key for the project
-				os.Setenv("PACKET_AUTH_TOKEN", key)
-				// Update the configuration with the project key
-				c.MetalProjectID = project
-			}
-		}
-		packetClient, err = packngo.NewClient()
+		packetClient, err = equinixmetal.NewClient(c)
 		if err != nil {
 			log.Error(err)
 		}
@@ -164,52 +184,104 @@ func (cluster *Cluster) StartCluster(c *kubevip.Config, sm *Manager, bgpServer *
 		bgpServer, err = bgp.NewBGPServer(&c.BGPConfig, nil)
 		if err != nil {
 			log.Error(err)
+		} else {
+			go bgpServer.WatchAuthFailures(ctx, 10*time.Second, securityevents.BGPAuthFailed)
 		}
 	}
 
-	run := &runConfig{
-		config:  c,
-		leaseID: c.NodeName,
-		sm:      sm,
-		onStartedLeading: func(ctx context.Context) {
-			// As we're leading lets start the vip service
-			err := cluster.vipService(ctxArp, ctxDNS, c, sm, bgpServer, packetClient)
-			if err != nil {
-				log.Errorf("Error starting the VIP service on the leader [%s]", err)
-			}
-		},
-		onStoppedLeading: func() {
-			// we can do cleanup here
-			log.Info("This node is becoming a follower within the cluster")
+	leadership := securityevents.NewLeadership(ctx, c.LeaseName, c.NodeName)
+
+	stopLeading := func() {
+		// we can do cleanup here
+		log.Info("This node is becoming a follower within the cluster")
 
+		// The lease of the DHCP address is kept for the replacement when the VIP is handed over
+		if !handingOver.Load() {
 			// Stop the dns context
 			cancelDNS()
-			// Stop the Arp context if it is running
-			cancelArp()
+		}
+		// Stop the Arp context if it is running
+		cancelArp()
 
-			// Stop the BGP server
-			if bgpServer != nil {
-				err := bgpServer.Close()
-				if err != nil {
-					log.Warnf("%v", err)
-				}
+		// Stop the BGP server
+		if bgpServer != nil {
+			err := bgpServer.Close()
+			if err != nil {
+				log.Warnf("%v", err)
 			}
+		}
 
+		if handingOver.Load() {
+			log.Info("[handover] leaving the VIP for the kube-vip that replaces this one")
+		} else {
 			for i := range cluster.Network {
 				err := cluster.Network[i].DeleteIP()
 				if err != nil {
 					log.Warnf("%v", err)
 				}
 			}
+		}
+
+		securityevents.Flush()
+		log.Fatal("lost leadership, restarting kube-vip")
+	}
 
-			log.Fatal("lost leadership, restarting kube-vip")
+	// Set when this node still serves the VIP that it was elected for by the bootstrap
+	var bootstrapped atomic.Bool
+
+	run := &runConfig{
+		config:  c,
+		leaseID: c.NodeName,
+		sm:      sm,
+		onStartedLeading: func(ctx context.Context) {
+			leadership.Started()
+			leading.Store(true)
+			// The VIPs that were handed over are now served by this kube-vip
+			adopted.Store(false)
+			// The VIP is already up if this node held it during the bootstrap
+			if bootstrapped.Swap(false) {
+				log.Info("Keeping the VIP of the bootstrap now that this node holds the lease")
+				return
+			}
+			// As we're leading lets start the vip service
+			err := cluster.vipService(ctxArp, ctxDNS, c, sm, bgpServer, packetClient)
+			if err != nil {
+				log.Errorf("Error starting the VIP service on the leader [%s]", err)
+			}
 		},
+		onStoppedLeading: stopLeading,
 		onNewLeader: func(identity string) {
+			// Another node took the lease while this node still holds the VIP of the bootstrap, which is handed over
+			if identity != c.NodeName && bootstrapped.Swap(false) {
+				log.Infof("Node [%s] took the lease, handing over the VIP of the bootstrap", identity)
+				stopLeading()
+			}
+			// Another node was elected while this node kept the VIPs that were handed over
+			if identity != c.NodeName && adopted.Swap(false) {
+				log.Infof("Node [%s] took the lease, releasing the VIPs that were handed over", identity)
+				cluster.releaseHandover()
+			}
 			// we're notified when new leader elected
+			leadership.NewLeader(identity)
 			log.Infof("Node [%s] is assuming leadership of the cluster", identity)
 		},
 	}
 
+	// With Cluster API the VIP is elected without the API server until it is healthy
+	if c.Bootstrap != "" {
+		leading, leader := cluster.runBootstrap(ctx, run)
+		if ctx.Err() != nil {
+			return nil
+		}
+		bootstrapped.Store(leading)
+		go publishBootstrapStatus(ctx, sm.KubernetesClient, c, leader)
+	}
+
+	// VRRP takes the place of the leader election, the VIP is shared with the other routers of the virtual router
+	if c.EnableVRRP {
+		return cluster.runVRRP(ctx, run)
+	}
+
 	switch c.LeaderElectionType {
 	case "kubernetes", "":
 		cluster.runKubernetesLeaderElectionOrDie(ctx, run)
@@ -261,7 +333,8 @@ func (cluster *Cluster) runKubernetesLeaderElectionOrDie(ctx context.Context, ru
 		// loop still running and another process could
 		// get elected before your background loop finished, violating
 		// the stated goal of the lease.
-		ReleaseOnCancel: true,
+		// The lease is kept for the kube-vip that replaces this one when the VIP is handed over
+		ReleaseOnCancel: !run.config.Handover,
 		LeaseDuration:   time.Duration(run.config.LeaseDuration) * time.Second,
 		RenewDeadline:   time.Duration(run.config.RenewDeadline) * time.Second,
 		RetryPeriod:     time.Duration(run.config.RetryPeriod) * time.Second,
@@ -287,7 +360,7 @@ func (cluster *Cluster) runEtcdLeaderElectionOrDie(ctx context.Context, run *run
 	})
 }
 
-func (sm *Manager) NodeWatcher(lb *loadbalancer.IPVSLoadBalancer, port int) error {
+func (sm *Manager) NodeWatcher(lb loadbalancer.Backends, port int) error {
 	// Use a restartable watcher, as this should help in the event of etcd or timeout issues
 	log.Infof("Kube-Vip is watching nodes for control-plane labels")
 
@@ -295,9 +368,20 @@ func (sm *Manager) NodeWatcher(lb *loadbalancer.IPVSLoadBalancer, port int) erro
 		LabelSelector: "node-role.kubernetes.io/control-plane",
 	}
 
-	rw, err := watchtools.NewRetryWatcher("1", &cache.ListWatch{
+	// The nodes are listed once, the watch then resumes from the version of the list (and from the last event after a
+	// disconnect) rather than sending every control plane node again
+	nodes, err := sm.KubernetesClient.CoreV1().Nodes().List(context.Background(), listOptions)
+	if err != nil {
+		return fmt.Errorf("unable to list the control plane nodes: %v", err)
+	}
+	for x := range nodes.Items {
+		addNodeBackends(lb, &nodes.Items[x], port)
+	}
+
+	rw, err := watchtools.NewRetryWatcher(nodes.ResourceVersion, &cache.ListWatch{
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-			return sm.KubernetesClient.CoreV1().Nodes().Watch(context.Background(), listOptions)
+			options.LabelSelector = listOptions.LabelSelector
+			return sm.KubernetesClient.CoreV1().Nodes().Watch(context.Background(), options)
 		},
 	})
 	if err != nil {
@@ -322,15 +406,7 @@ func (sm *Manager) NodeWatcher(lb *loadbalancer.IPVSLoadBalancer, port int) erro
 			if !ok {
 				return fmt.Errorf("unable to parse Kubernetes Node from Annotation watcher")
 			}
-			// Find the node IP address (this isn't foolproof)
-			for x := range node.Status.Addresses {
-				if node.Status.Addresses[x].Type == v1.NodeInternalIP {
-					err = lb.AddBackend(node.Status.Addresses[x].Address, port)
-					if err != nil {
-						log.Errorf("add IPVS backend [%v]", err)
-					}
-				}
-			}
+			addNodeBackends(lb, node, port)
 		case watch.Deleted:
 			node, ok := event.Object.(*v1.Node)
 			if !ok {
@@ -342,7 +418,7 @@ func (sm *Manager) NodeWatcher(lb *loadbalancer.IPVSLoadBalancer, port int) erro
 				if node.Status.Addresses[x].Type == v1.NodeInternalIP {
 					err = lb.RemoveBackend(node.Status.Addresses[x].Address, port)
 					if err != nil {
-						log.Errorf("Del IPVS backend [%v]", err)
+						log.Errorf("Del load-balancer backend [%v]", err)
 					}
 				}
 			}
@@ -370,3 +446,15 @@ func (sm *Manager) NodeWatcher(lb *loadbalancer.IPVSLoadBalancer, port int) erro
 	log.Infoln("Exiting Node watcher")
 	return nil
 }
+
+// addNodeBackends adds the address of a control plane node to the backends of the load-balancer
+func addNodeBackends(lb loadbalancer.Backends, node *v1.Node, port int) {
+	// Find the node IP address (this isn't foolproof)
+	for x := range node.Status.Addresses {
+		if node.Status.Addresses[x].Type == v1.NodeInternalIP {
+			if err := lb.AddBackend(node.Status.Addresses[x].Address, port); err != nil {
+				log.Errorf("add load-balancer backend [%v]", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package cluster
+
+import (
+	"context"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+// conflictInterval limits how often a conflict with the same MAC address is reported, as the other host will keep
+// announcing the address
+const conflictInterval = time.Minute
+
+// watchVIPConflicts will report ARP messages from other MAC addresses that claim the VIP while this node holds it
+func watchVIPConflicts(ctx context.Context, address, iface string) {
+	if vip.IsIPv6(address) {
+		return
+	}
+
+	reported := map[string]time.Time{}
+	err := vip.ARPWatchConflicts(ctx, address, iface, func(mac net.HardwareAddr) {
+		if last, exists := reported[mac.String()]; exists && time.Since(last) < conflictInterval {
+			return
+		}
+		reported[mac.String()] = time.Now()
+		securityevents.Emit(securityevents.VIPConflict, "VIP has been claimed by another MAC address", map[string]string{
+			"vip":       address,
+			"interface": iface,
+			"mac":       mac.String(),
+		})
+	})
+	if err != nil {
+		log.Warnf("unable to watch for conflicts with the VIP [%s]: %v", address, err)
+	}
+}
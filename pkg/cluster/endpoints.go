@@ -0,0 +1,119 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/loadbalancer"
+)
+
+// BackendWatcher keeps the backends of the load-balancer in sync with the control plane nodes, or with the endpoints of
+// the kubernetes service
+func (sm *Manager) BackendWatcher(lb loadbalancer.Backends, c *kubevip.Config) error {
+	if c.LoadBalancerBackends == kubevip.LoadBalancerBackendsEndpoints {
+		return sm.EndpointsWatcher(lb, c.Port)
+	}
+	return sm.NodeWatcher(lb, c.Port)
+}
+
+// EndpointsWatcher keeps the backends of the load-balancer in sync with the API servers of the default/kubernetes
+// endpoints, which the API servers add themselves to while they are running
+func (sm *Manager) EndpointsWatcher(lb loadbalancer.Backends, port int) error {
+	log.Infof("Kube-Vip is watching the endpoints of the kubernetes service for API servers")
+
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", "kubernetes").String()
+	endpoints, err := sm.KubernetesClient.CoreV1().Endpoints(metav1.NamespaceDefault).List(context.Background(), metav1.ListOptions{FieldSelector: fieldSelector})
+	if err != nil {
+		return fmt.Errorf("unable to list the endpoints of the kubernetes service: %v", err)
+	}
+	current := map[loadbalancer.Backend]struct{}{}
+	for x := range endpoints.Items {
+		current = syncBackends(lb, current, endpointBackends(&endpoints.Items[x], port))
+	}
+
+	rw, err := watchtools.NewRetryWatcher(endpoints.ResourceVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return sm.KubernetesClient.CoreV1().Endpoints(metav1.NamespaceDefault).Watch(context.Background(), options)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating endpoints watcher: %s", err.Error())
+	}
+
+	go func() {
+		<-sm.SignalChan
+		log.Info("Received termination, signaling shutdown")
+		rw.Stop()
+	}()
+
+	for event := range rw.ResultChan() {
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			ep, ok := event.Object.(*v1.Endpoints)
+			if !ok {
+				return fmt.Errorf("unable to parse Kubernetes Endpoints from watcher")
+			}
+			current = syncBackends(lb, current, endpointBackends(ep, port))
+		case watch.Deleted:
+			// The API servers recreate the endpoints, the backends are kept until then rather than dropping every one
+			log.Warnf("The endpoints of the kubernetes service have been deleted, keeping the [%d] backends", len(current))
+		case watch.Error:
+			log.Errorf("Error attempting to watch the endpoints of the kubernetes service: %v", event.Object)
+		default:
+		}
+	}
+
+	log.Infoln("Exiting Endpoints watcher")
+	return nil
+}
+
+// endpointBackends returns the backends of the ready addresses of the endpoints, on the port of the API server (the
+// https port of the endpoints) or on the port if the endpoints don't have one
+func endpointBackends(ep *v1.Endpoints, port int) map[loadbalancer.Backend]struct{} {
+	backends := map[loadbalancer.Backend]struct{}{}
+	for _, subset := range ep.Subsets {
+		subsetPort := port
+		for _, p := range subset.Ports {
+			if p.Name == "https" || len(subset.Ports) == 1 {
+				subsetPort = int(p.Port)
+			}
+		}
+		for _, address := range subset.Addresses {
+			backends[loadbalancer.Backend{Addr: address.IP, Port: subsetPort}] = struct{}{}
+		}
+	}
+	return backends
+}
+
+// syncBackends adds the backends that are wanted to the load-balancer and removes the others, it returns the backends
+// of the load-balancer
+func syncBackends(lb loadbalancer.Backends, current, wanted map[loadbalancer.Backend]struct{}) map[loadbalancer.Backend]struct{} {
+	for backend := range current {
+		if _, exists := wanted[backend]; exists {
+			continue
+		}
+		log.Infof("API server [%s:%d] has left the endpoints", backend.Addr, backend.Port)
+		if err := lb.RemoveBackend(backend.Addr, backend.Port); err != nil {
+			log.Errorf("Del load-balancer backend [%v]", err)
+		}
+	}
+	for backend := range wanted {
+		if _, exists := current[backend]; exists {
+			continue
+		}
+		if err := lb.AddBackend(backend.Addr, backend.Port); err != nil {
+			log.Errorf("add load-balancer backend [%v]", err)
+		}
+	}
+	return wanted
+}
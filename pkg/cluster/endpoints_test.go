@@ -0,0 +1,67 @@
+package cluster
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/loadbalancer"
+)
+
+// recordingBackends records the backends that are added and removed
+type recordingBackends struct {
+	changes []string
+}
+
+func (r *recordingBackends) AddBackend(address string, port int) error {
+	r.changes = append(r.changes, fmt.Sprintf("add %s:%d", address, port))
+	return nil
+}
+
+func (r *recordingBackends) RemoveBackend(address string, port int) error {
+	r.changes = append(r.changes, fmt.Sprintf("remove %s:%d", address, port))
+	return nil
+}
+
+func TestEndpointBackends(t *testing.T) {
+	ep := &v1.Endpoints{Subsets: []v1.EndpointSubset{{
+		Addresses:         []v1.EndpointAddress{{IP: "192.168.0.10"}, {IP: "192.168.0.11"}},
+		NotReadyAddresses: []v1.EndpointAddress{{IP: "192.168.0.12"}},
+		Ports:             []v1.EndpointPort{{Name: "https", Port: 6444}},
+	}}}
+	backends := endpointBackends(ep, 6443)
+	want := map[loadbalancer.Backend]struct{}{
+		{Addr: "192.168.0.10", Port: 6444}: {},
+		{Addr: "192.168.0.11", Port: 6444}: {},
+	}
+	if len(backends) != len(want) {
+		t.Fatalf("endpointBackends() = %v, want %v", backends, want)
+	}
+	for backend := range want {
+		if _, exists := backends[backend]; !exists {
+			t.Errorf("endpointBackends() = %v, want %v", backends, want)
+		}
+	}
+
+	// Without a port the backends are on the port of the configuration
+	ep.Subsets[0].Ports = nil
+	if _, exists := endpointBackends(ep, 6443)[loadbalancer.Backend{Addr: "192.168.0.10", Port: 6443}]; !exists {
+		t.Errorf("endpointBackends() without a port = %v, want the backends on port 6443", endpointBackends(ep, 6443))
+	}
+}
+
+func TestSyncBackends(t *testing.T) {
+	lb := &recordingBackends{}
+	first, second := loadbalancer.Backend{Addr: "192.168.0.10", Port: 6443}, loadbalancer.Backend{Addr: "192.168.0.11", Port: 6443}
+
+	current := syncBackends(lb, map[loadbalancer.Backend]struct{}{}, map[loadbalancer.Backend]struct{}{first: {}})
+	current = syncBackends(lb, current, map[loadbalancer.Backend]struct{}{first: {}})
+	syncBackends(lb, current, map[loadbalancer.Backend]struct{}{second: {}})
+
+	want := []string{"add 192.168.0.10:6443", "remove 192.168.0.10:6443", "add 192.168.0.11:6443"}
+	if !slices.Equal(lb.changes, want) {
+		t.Errorf("syncBackends() changes = %v, want %v", lb.changes, want)
+	}
+}
@@ -0,0 +1,59 @@
+package cluster
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// gratuitousSchedule is when the gratuitous ARP/NDP updates of a VIP are sent. Some switch fabrics only update their
+// FDB and ARP caches quickly when the updates are repeated as soon as the VIP moves.
+type gratuitousSchedule struct {
+	// repeat updates are sent repeatInterval apart when the VIP is acquired
+	repeat         int
+	repeatInterval time.Duration
+	// interval is the time between the updates after the repeats, which doubles from the repeat interval until it
+	// reaches the interval with backoff
+	interval time.Duration
+	backoff  bool
+}
+
+func newGratuitousSchedule(c *kubevip.Config) gratuitousSchedule {
+	rate := c.ArpBroadcastRate
+	if rate == 0 {
+		rate = 3000
+	} else if rate < 500 {
+		log.Errorf("arp broadcast rate is [%d], this shouldn't be lower that 300ms (defaulting to 3000)", rate)
+		rate = 3000
+	}
+	repeatInterval := c.ArpRepeatInterval
+	if repeatInterval == 0 {
+		repeatInterval = kubevip.DefaultArpRepeatInterval
+	}
+	return gratuitousSchedule{
+		repeat:         c.ArpRepeat,
+		repeatInterval: time.Duration(repeatInterval) * time.Millisecond,
+		interval:       time.Duration(rate) * time.Millisecond,
+		backoff:        c.ArpBackoff,
+	}
+}
+
+// delay returns how long to wait after the nth update (counting from 1) before the next one is sent
+func (s gratuitousSchedule) delay(n int) time.Duration {
+	if n < s.repeat {
+		return s.repeatInterval
+	}
+	if !s.backoff {
+		return s.interval
+	}
+	delay := s.repeatInterval
+	for x := max(s.repeat, 1); x <= n && delay < s.interval; x++ {
+		delay *= 2
+	}
+	if delay > s.interval {
+		return s.interval
+	}
+	return delay
+}
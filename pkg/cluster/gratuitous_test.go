@@ -0,0 +1,47 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+func TestGratuitousSchedule(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name string
+		c    *kubevip.Config
+		want []time.Duration
+	}{
+		{"rate", &kubevip.Config{ArpBroadcastRate: 3000}, []time.Duration{3000 * ms, 3000 * ms, 3000 * ms}},
+		{"default rate", &kubevip.Config{}, []time.Duration{3000 * ms, 3000 * ms}},
+		{"too low rate", &kubevip.Config{ArpBroadcastRate: 100}, []time.Duration{3000 * ms}},
+		{"repeat", &kubevip.Config{ArpBroadcastRate: 3000, ArpRepeat: 3}, []time.Duration{200 * ms, 200 * ms, 3000 * ms, 3000 * ms}},
+		{
+			name: "repeat interval",
+			c:    &kubevip.Config{ArpBroadcastRate: 3000, ArpRepeat: 2, ArpRepeatInterval: 50},
+			want: []time.Duration{50 * ms, 3000 * ms},
+		},
+		{
+			name: "backoff",
+			c:    &kubevip.Config{ArpBroadcastRate: 3000, ArpRepeat: 3, ArpBackoff: true},
+			want: []time.Duration{200 * ms, 200 * ms, 400 * ms, 800 * ms, 1600 * ms, 3000 * ms, 3000 * ms},
+		},
+		{
+			name: "backoff without repeat",
+			c:    &kubevip.Config{ArpBroadcastRate: 1000, ArpBackoff: true},
+			want: []time.Duration{400 * ms, 800 * ms, 1000 * ms, 1000 * ms},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newGratuitousSchedule(tt.c)
+			for x, want := range tt.want {
+				if got := s.delay(x + 1); got != want {
+					t.Errorf("delay(%d) = %s, want %s", x+1, got, want)
+				}
+			}
+		})
+	}
+}
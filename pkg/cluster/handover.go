@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/handover"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+// takeHandover takes the VIPs that the previous kube-vip on the node handed over, it returns if there are any
+func (cluster *Cluster) takeHandover(c *kubevip.Config) bool {
+	if !c.Handover {
+		return false
+	}
+	state, err := handover.Take(c.HandoverPath, c.NodeName, time.Duration(c.LeaseDuration)*time.Second, time.Now())
+	if err != nil {
+		log.Warnf("[handover] ignoring the state of the previous kube-vip: %v", err)
+		return false
+	}
+	if state == nil {
+		return false
+	}
+	log.Infof("[handover] the previous kube-vip handed over %d VIPs and the lease [%s]", len(state.VIPs), state.Lease)
+	cluster.handover = state.VIPs
+	return len(state.VIPs) != 0
+}
+
+// handedOver returns if the address of the network was handed over on its interface
+func (cluster *Cluster) handedOver(network vip.Network) bool {
+	for _, v := range cluster.handover {
+		if v.Address == network.IP() && v.Interface == network.Interface() {
+			return true
+		}
+	}
+	return false
+}
+
+// requestedIP returns the address that the previous kube-vip leased from DHCP on the interface, if any
+func (cluster *Cluster) requestedIP(iface string) string {
+	for _, v := range cluster.handover {
+		if v.DHCP && v.Interface == iface {
+			return v.Address
+		}
+	}
+	return ""
+}
+
+// releaseHandover removes the VIPs that were handed over, once another node has been elected
+func (cluster *Cluster) releaseHandover() {
+	for _, v := range cluster.handover {
+		log.Infof("[handover] removing the VIP [%s] from [%s], it is held by another node", v.Address, v.Interface)
+		if _, err := vip.GarbageCollect(v.Interface, v.Address); err != nil {
+			log.Warnf("[handover] %v", err)
+		}
+	}
+}
+
+// writeHandover hands the VIPs over to the kube-vip that replaces this one, the lease isn't released so that the
+// replacement (with the same identity) renews it straight away
+func (cluster *Cluster) writeHandover(c *kubevip.Config) error {
+	state := &handover.State{
+		Node:    c.NodeName,
+		Lease:   c.LeaseName,
+		Written: time.Now(),
+	}
+	state.VIPs = cluster.HandoverVIPs()
+	return handover.Write(c.HandoverPath, state)
+}
+
+// HandoverVIPs returns the VIPs of the cluster, as they are handed over
+func (cluster *Cluster) HandoverVIPs() []handover.VIP {
+	vips := make([]handover.VIP, 0, len(cluster.Network))
+	for i := range cluster.Network {
+		vips = append(vips, handover.VIP{
+			Address:   cluster.Network[i].IP(),
+			Interface: cluster.Network[i].Interface(),
+			DHCP:      cluster.Network[i].IsDDNS(),
+		})
+	}
+	return vips
+}
+
+// KeepHandedOver keeps the VIPs of a service that the previous kube-vip handed over on their interfaces, rather than
+// removing them before they are added again
+func (cluster *Cluster) KeepHandedOver(vips []handover.VIP) {
+	cluster.handover = vips
+}
+
+// HandOver leaves the VIPs of a service on their interfaces once it is stopped, for the kube-vip that replaces this one.
+// The routes of the routing table are still deleted.
+func (cluster *Cluster) HandOver() {
+	cluster.handingOver.Store(true)
+}
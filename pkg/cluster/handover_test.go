@@ -0,0 +1,46 @@
+package cluster
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+func TestHandover(t *testing.T) {
+	c := &kubevip.Config{
+		NodeName:                 "cp-0",
+		Handover:                 true,
+		HandoverPath:             filepath.Join(t.TempDir(), "handover.json"),
+		KubernetesLeaderElection: kubevip.KubernetesLeaderElection{LeaseName: "plndr-cp-lock", LeaseDuration: 5},
+	}
+	networks, err := vip.NewConfig("192.168.0.40", "lo", "", false, 0, 0, 0, 0, "first", "", "", "")
+	if err != nil {
+		t.Skipf("the loopback interface isn't available: %v", err)
+	}
+	other, err := vip.NewConfig("192.168.0.41", "lo", "", false, 0, 0, 0, 0, "first", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (&Cluster{Network: networks}).writeHandover(c); err != nil {
+		t.Fatal(err)
+	}
+
+	replacement := &Cluster{Network: networks}
+	if !replacement.takeHandover(c) {
+		t.Fatal("the VIP wasn't handed over")
+	}
+	if !replacement.handedOver(networks[0]) || replacement.handedOver(other[0]) {
+		t.Errorf("unexpected VIPs handed over %+v", replacement.handover)
+	}
+	if ip := replacement.requestedIP("lo"); ip != "" {
+		t.Errorf("requestedIP() = %q for an address that isn't from DHCP", ip)
+	}
+
+	// The state is only handed over once
+	if (&Cluster{Network: networks}).takeHandover(c) {
+		t.Error("the VIP was handed over twice")
+	}
+}
@@ -0,0 +1,46 @@
+package cluster
+
+import (
+	"github.com/kube-vip/kube-vip/pkg/aws"
+	"github.com/kube-vip/kube-vip/pkg/azure"
+	"github.com/kube-vip/kube-vip/pkg/gcp"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/nsx"
+)
+
+// vipProvider moves the VIP to this node with the API of the cloud that it runs in, the network of the cloud doesn't
+// learn where the VIP is from ARP (or BGP)
+type vipProvider interface {
+	AssignVIP(vip string) error
+}
+
+// newVIPProvider returns the provider of the enabled cloud, or nil when the VIP isn't moved with the API of a cloud
+func newVIPProvider(c *kubevip.Config) (vipProvider, error) {
+	switch {
+	case c.EnableAWS:
+		client, err := aws.NewClient(c)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case c.EnableGCP:
+		client, err := gcp.NewClient(c)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case c.EnableAzure:
+		client, err := azure.NewClient(c)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case c.EnableNSX:
+		client, err := nsx.NewClient(c)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+	return nil, nil
+}
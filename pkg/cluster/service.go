@@ -2,7 +2,6 @@ package cluster
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,6 +12,7 @@ import (
 	"github.com/kube-vip/kube-vip/pkg/kubevip"
 	"github.com/kube-vip/kube-vip/pkg/loadbalancer"
 	"github.com/kube-vip/kube-vip/pkg/vip"
+	"github.com/kube-vip/kube-vip/pkg/xdplb"
 	"github.com/packethost/packngo"
 	log "github.com/sirupsen/logrus"
 )
@@ -30,10 +30,16 @@ func (cluster *Cluster) vipService(ctxArp, ctxDNS context.Context, c *kubevip.Co
 	// Add Notification for SIGTERM (sent from Kubernetes)
 	signal.Notify(signalChan, syscall.SIGTERM)
 
+	// The network of a cloud only delivers the traffic of the VIP to the node that its API has moved the VIP to
+	provider, err := newVIPProvider(c)
+	if err != nil {
+		log.Errorf("unable to create the client of the cloud API: %v", err)
+	}
+
 	for i := range cluster.Network {
 
 		if cluster.Network[i].IsDDNS() {
-			if err := cluster.StartDDNS(ctxDNS); err != nil {
+			if err := cluster.StartDDNS(ctxDNS, c, sm); err != nil {
 				log.Error(err)
 			}
 		}
@@ -41,7 +47,22 @@ func (cluster *Cluster) vipService(ctxArp, ctxDNS context.Context, c *kubevip.Co
 		// start the dns updater if address is dns
 		if cluster.Network[i].IsDNS() {
 			log.Infof("starting the DNS updater for the address %s", cluster.Network[i].DNSName())
-			ipUpdater := vip.NewIPUpdater(cluster.Network[i])
+			interval := time.Duration(c.DNSRefreshInterval) * time.Second
+			ipUpdater := vip.NewIPUpdater(cluster.Network[i], interval)
+			if c.DNSHealthCheck {
+				// The address that the VIP moves to is advertised over BGP again, the ARP loop announces the current address
+				ipUpdater = vip.NewHealthCheckedIPUpdater(cluster.Network[i], interval, vip.TCPHealthCheck(c.Port), func(previous, current string) {
+					if !c.EnableBGP {
+						return
+					}
+					if err := bgpServer.DelHost(bgp.HostCIDR(previous)); err != nil {
+						log.Errorf("unable to withdraw the previous address [%s] over BGP: %v", previous, err)
+					}
+					if err := bgpServer.AddHost(bgp.HostCIDR(current)); err != nil {
+						log.Errorf("unable to advertise the address [%s] over BGP: %v", current, err)
+					}
+				})
+			}
 			ipUpdater.Run(ctxDNS)
 		}
 
@@ -59,12 +80,23 @@ func (cluster *Cluster) vipService(ctxArp, ctxDNS context.Context, c *kubevip.Co
 				if err != nil {
 					log.Error(err)
 				}
+				// Attach the EIP again whenever it is reassigned while this node is leading
+				if c.MetalEIPSyncInterval > 0 {
+					go equinixmetal.WatchEIP(ctxArp, packetClient, c, time.Duration(c.MetalEIPSyncInterval)*time.Second)
+				}
+			}
+		}
+
+		if provider != nil {
+			err = provider.AssignVIP(cluster.Network[i].IP())
+			if err != nil {
+				log.Error(err)
 			}
 		}
 
 		if c.EnableBGP {
 			// Lets advertise the VIP over BGP, the host needs to be passed using CIDR notation
-			cidrVip := fmt.Sprintf("%s/%s", cluster.Network[i].IP(), c.VIPCIDR)
+			cidrVip := bgp.HostCIDR(cluster.Network[i].IP())
 			log.Debugf("Attempting to advertise the address [%s] over BGP", cidrVip)
 
 			err = bgpServer.AddHost(cidrVip)
@@ -73,19 +105,41 @@ func (cluster *Cluster) vipService(ctxArp, ctxDNS context.Context, c *kubevip.Co
 			}
 		}
 
-		if c.EnableLoadBalancer {
+		if c.EnableLoadBalancer && c.LoadBalancerDataplane == kubevip.LoadBalancerXDP {
+			log.Infof("Starting XDP LoadBalancer")
+
+			// The VIP is still advertised without the load-balancer, the API servers are then reached on the leader
+			lb, err := xdplb.New(c.Interface, cluster.Network[i].IP(), c.LoadBalancerPort, c.BackendHealthCheckInterval)
+			if err != nil {
+				log.Errorf("Error creating XDP LoadBalancer [%s]", err)
+			} else {
+				go func() {
+					err := sm.BackendWatcher(lb, c)
+					if err != nil {
+						log.Errorf("Error watching the load-balancer backends [%s]", err)
+					}
+				}()
+				// Shutdown function that will wait on this signal, unless we call it ourselves
+				go func() {
+					<-signalChan
+					if err := lb.Close(); err != nil {
+						log.Errorf("Error stopping XDP LoadBalancer [%s]", err)
+					}
+				}()
+			}
+		} else if c.EnableLoadBalancer {
 
 			log.Infof("Starting IPVS LoadBalancer")
 
-			lb, err := loadbalancer.NewIPVSLB(cluster.Network[i].IP(), c.LoadBalancerPort, c.LoadBalancerForwardingMethod, c.BackendHealthCheckInterval)
+			lb, err := loadbalancer.NewIPVSLB(cluster.Network[i].IP(), c.LoadBalancerPort, c.LoadBalancerForwardingMethod, c.BackendHealthCheckInterval, c.LoadBalancerScheduler, c.LoadBalancerPersistenceTimeout)
 			if err != nil {
 				log.Errorf("Error creating IPVS LoadBalancer [%s]", err)
 			}
 
 			go func() {
-				err = sm.NodeWatcher(lb, c.Port)
+				err = sm.BackendWatcher(lb, c)
 				if err != nil {
-					log.Errorf("Error watching node labels [%s]", err)
+					log.Errorf("Error watching the load-balancer backends [%s]", err)
 				}
 			}()
 			// Shutdown function that will wait on this signal, unless we call it ourselves
@@ -117,15 +171,17 @@ func (cluster *Cluster) vipService(ctxArp, ctxDNS context.Context, c *kubevip.Co
 				if ndp != nil {
 					defer ndp.Close()
 				}
-				log.Infof("Gratuitous Arp broadcast will repeat every 3 seconds for [%s/%s]", ipString, cluster.Network[i].Interface())
-				for {
+				go watchVIPConflicts(ctx, ipString, cluster.Network[i].Interface())
+				schedule := newGratuitousSchedule(c)
+				log.Infof("Gratuitous Arp broadcast will repeat every %s for [%s/%s]", schedule.interval, ipString, cluster.Network[i].Interface())
+				for n := 1; ; n++ {
 					select {
 					case <-ctx.Done(): // if cancel() execute
 						return
 					default:
 						cluster.ensureIPAndSendGratuitous(cluster.Network[i].Interface(), ndp)
 					}
-					time.Sleep(3 * time.Second)
+					time.Sleep(schedule.delay(n))
 				}
 			}(ctxArp)
 		}
@@ -142,7 +198,7 @@ func (cluster *Cluster) vipService(ctxArp, ctxDNS context.Context, c *kubevip.Co
 }
 
 // StartLoadBalancerService will start a VIP instance and leave it for kube-proxy to handle
-func (cluster *Cluster) StartLoadBalancerService(c *kubevip.Config, bgp *bgp.Server) {
+func (cluster *Cluster) StartLoadBalancerService(c *kubevip.Config, bgpServer *bgp.Server) {
 	// use a Go context so we can tell the arp loop code when we
 	// want to step down
 	//nolint
@@ -154,8 +210,10 @@ func (cluster *Cluster) StartLoadBalancerService(c *kubevip.Config, bgp *bgp.Ser
 	for i := range cluster.Network {
 		network := cluster.Network[i]
 
-		err := network.DeleteIP()
-		if err != nil {
+		var err error
+		if cluster.handedOver(network) {
+			log.Infof("[handover] keeping the VIP [%s] that was handed over", network.IP())
+		} else if err = network.DeleteIP(); err != nil {
 			log.Warnf("Attempted to clean existing VIP => %v", err)
 		}
 		if c.EnableRoutingTable && (c.EnableLeaderElection || c.EnableServicesElection) {
@@ -185,30 +243,28 @@ func (cluster *Cluster) StartLoadBalancerService(c *kubevip.Config, bgp *bgp.Ser
 				if ndp != nil {
 					defer ndp.Close()
 				}
-				log.Debugf("(svcs) broadcasting ARP update for %s via %s, every %dms", ipString, network.Interface(), c.ArpBroadcastRate)
+				go watchVIPConflicts(ctx, ipString, network.Interface())
+				schedule := newGratuitousSchedule(c)
+				log.Debugf("(svcs) broadcasting ARP update for %s via %s, every %s", ipString, network.Interface(), schedule.interval)
 
-				for {
+				for n := 1; ; n++ {
 					select {
 					case <-ctx.Done(): // if cancel() execute
-						log.Debugf("(svcs) ending ARP update for %s via %s, every %dms", ipString, network.Interface(), c.ArpBroadcastRate)
+						log.Debugf("(svcs) ending ARP update for %s via %s, every %s", ipString, network.Interface(), schedule.interval)
 						return
 					default:
 						cluster.ensureIPAndSendGratuitous(network.Interface(), ndp)
 					}
-					if c.ArpBroadcastRate < 500 {
-						log.Errorf("arp broadcast rate is [%d], this shouldn't be lower that 300ms (defaulting to 3000)", c.ArpBroadcastRate)
-						c.ArpBroadcastRate = 3000
-					}
-					time.Sleep(time.Duration(c.ArpBroadcastRate) * time.Millisecond)
+					time.Sleep(schedule.delay(n))
 				}
 			}(ctxArp)
 		}
 
 		if c.EnableBGP && (c.EnableLeaderElection || c.EnableServicesElection) {
 			// Lets advertise the VIP over BGP, the host needs to be passed using CIDR notation
-			cidrVip := fmt.Sprintf("%s/%s", network.IP(), c.VIPCIDR)
+			cidrVip := bgp.HostCIDR(network.IP())
 			log.Debugf("(svcs) attempting to advertise the address [%s] over BGP", cidrVip)
-			err = bgp.AddHost(cidrVip)
+			err = bgpServer.AddHost(cidrVip)
 			if err != nil {
 				log.Error(err)
 			}
@@ -233,6 +289,13 @@ func (cluster *Cluster) StartLoadBalancerService(c *kubevip.Config, bgp *bgp.Ser
 			close(cluster.completed)
 			return
 		}
+		if cluster.handingOver.Load() {
+			for i := range cluster.Network {
+				log.Infof("[handover] leaving the VIP [%s] for the kube-vip that replaces this one", cluster.Network[i].IP())
+			}
+			close(cluster.completed)
+			return
+		}
 		for i := range cluster.Network {
 			log.Infof("[VIP] Releasing the Virtual IP [%s]", cluster.Network[i].IP())
 			if err := cluster.Network[i].DeleteIP(); err != nil {
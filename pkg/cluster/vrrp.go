@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/vip"
+	"github.com/kube-vip/kube-vip/pkg/vrrp"
+)
+
+// vrrpConfig is the virtual router of the VIPs of the cluster
+func vrrpConfig(c *kubevip.Config, networks []vip.Network) (*vrrp.Config, error) {
+	config := &vrrp.Config{
+		Interface: c.Interface,
+		RouterID:  uint8(c.VRRP.RouterID),
+		Priority:  uint8(c.VRRP.Priority),
+		Interval:  time.Duration(c.VRRP.AdvertInterval) * time.Millisecond,
+		Preempt:   c.VRRP.Preempt,
+		Version:   c.VRRP.Version,
+		Password:  c.VRRP.Password,
+	}
+	for _, network := range networks {
+		address := net.ParseIP(network.IP())
+		if address == nil {
+			return nil, fmt.Errorf("vrrp can't advertise the VIP [%s]", network.IP())
+		}
+		config.Addresses = append(config.Addresses, address)
+	}
+	return config, nil
+}
+
+// runVRRP elects the VIP with VRRP instead of a lease, the master of the virtual router is the leader and the
+// address of the master is the identity of the leader
+func (cluster *Cluster) runVRRP(ctx context.Context, run *runConfig) error {
+	config, err := vrrpConfig(run.config, cluster.Network)
+	if err != nil {
+		return err
+	}
+	log.Infof("[vrrp] electing the VIP with virtual router [%d] (version %d) on [%s]", config.RouterID, config.Version, config.Interface)
+	return vrrp.Run(ctx, config, vrrp.Callbacks{
+		OnStartedLeading: run.onStartedLeading,
+		OnStoppedLeading: run.onStoppedLeading,
+		OnNewLeader:      run.onNewLeader,
+	})
+}
@@ -0,0 +1,31 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+func TestVRRPConfig(t *testing.T) {
+	c := &kubevip.Config{
+		Interface: "eth0",
+		VRRP:      kubevip.VRRP{RouterID: 51, Priority: 150, AdvertInterval: 250, Preempt: true, Version: 3},
+	}
+	networks, err := vip.NewConfig("192.168.0.40", "lo", "", false, 0, 0, 0, 0, "first", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := vrrpConfig(c, networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.RouterID != 51 || config.Priority != 150 || config.Interval != 250*time.Millisecond || !config.Preempt || config.Version != 3 {
+		t.Errorf("vrrpConfig() = %+v", config)
+	}
+	if len(config.Addresses) != 1 || config.Addresses[0].String() != "192.168.0.40" {
+		t.Errorf("vrrpConfig() addresses = %v, want [192.168.0.40]", config.Addresses)
+	}
+}
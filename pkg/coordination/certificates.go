@@ -0,0 +1,104 @@
+package coordination
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/certificate/csr"
+)
+
+// CertificateValidity is how long the node certificates are requested for, they are renewed well before they expire
+const CertificateValidity = 7 * 24 * time.Hour
+
+// usages of the node certificates, the same certificate is used by the server and the client
+var usages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+
+// generateKey creates the private key of a node certificate
+func generateKey() (*ecdsa.PrivateKey, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+// Issue will issue a certificate for the node that is signed by the CA, the node name is the common name and the DNS
+// name of the certificate so that peers can check who they're talking to
+func Issue(caCertPEM, caKeyPEM []byte, node string) ([]byte, []byte, error) {
+	ca, err := tls.X509KeyPair(caCertPEM, caKeyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse CA: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse CA: %v", err)
+	}
+
+	key, keyPEM, err := generateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: node},
+		DNSNames:     []string{node},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(CertificateValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  usages,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, ca.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to issue certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyPEM, nil
+}
+
+// Request will request a certificate for the node with a CertificateSigningRequest, the request has to be approved
+// and signed by the signer (e.g. an approver and a cert-manager issuer) before the context expires
+func Request(ctx context.Context, clientSet kubernetes.Interface, signerName, node string) ([]byte, []byte, error) {
+	key, keyPEM, err := generateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	csrPEM, err := cert.MakeCSR(key, &pkix.Name{CommonName: node}, []string{node}, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create certificate request: %v", err)
+	}
+
+	duration := CertificateValidity
+	name, uid, err := csr.RequestCertificate(clientSet, csrPEM, "", signerName, &duration, []certificatesv1.KeyUsage{
+		certificatesv1.UsageDigitalSignature,
+		certificatesv1.UsageServerAuth,
+		certificatesv1.UsageClientAuth,
+	}, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to request certificate from [%s]: %v", signerName, err)
+	}
+	certPEM, err := csr.WaitForCertificate(ctx, clientSet, name, uid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("certificate request [%s] wasn't signed: %v", name, err)
+	}
+	return certPEM, keyPEM, nil
+}
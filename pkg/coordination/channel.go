@@ -0,0 +1,269 @@
+// Package coordination is an mTLS authenticated channel between kube-vip nodes, so that features that need to talk to
+// other nodes can't be spoofed by other hosts on the same network. Every node has a certificate for its node name
+// that is signed by a shared CA, servers only accept clients with a certificate from the CA and clients check that the
+// server is the node that they meant to connect to. With SPIFFE the certificates are the SVIDs of the nodes and the
+// node names are taken from their SPIFFE IDs.
+package coordination
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/httptls"
+	"github.com/kube-vip/kube-vip/pkg/spiffe"
+)
+
+// IdentityPath returns the identity of a node, this is used to check that a peer can be reached
+const IdentityPath = "/v1/identity"
+
+// Identity is returned by the identity endpoint
+type Identity struct {
+	// Node is the node that answered
+	Node string `json:"node"`
+	// Peer is the node that asked, from its client certificate
+	Peer string `json:"peer"`
+}
+
+// LeadingPath returns whether a node is leading a lease, this is used by leaders to find other nodes that still
+// believe that they are leading the same lease (a split brain)
+const LeadingPath = "/v1/leading"
+
+// Leading is returned by the leading endpoint
+type Leading struct {
+	// Node is the node that answered
+	Node string `json:"node"`
+	// Lease is the lease that was asked about
+	Lease string `json:"lease"`
+	// Leading is true if the node is leading the lease
+	Leading bool `json:"leading"`
+}
+
+// Channel is the server and client of the coordination channel on a node
+type Channel struct {
+	node         string
+	port         int
+	certificates *httptls.Certificates
+	mux          *http.ServeMux
+
+	// idTemplate is the SPIFFE ID of the nodes, the common names of the certificates are used when it is empty
+	idTemplate string
+}
+
+// NewChannel creates the channel for the node, it uses the same port on every node
+func NewChannel(node string, port int) *Channel {
+	c := &Channel{
+		node:         node,
+		port:         port,
+		certificates: &httptls.Certificates{},
+		mux:          http.NewServeMux(),
+	}
+	c.Handle(IdentityPath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Identity{Node: c.node, Peer: c.Peer(r)})
+	}))
+	return c
+}
+
+// NewSPIFFEChannel creates the channel for the node that authenticates nodes by their SPIFFE IDs, e.g. with the
+// template spiffe://example.org/kube-vip/{node}. The credentials are set from the SVIDs of the node.
+func NewSPIFFEChannel(node string, port int, idTemplate string) *Channel {
+	c := NewChannel(node, port)
+	c.idTemplate = idTemplate
+	return c
+}
+
+// SetCredentials replaces the certificate of this node and the CA that peers have to be signed by, a CA is required
+func (c *Channel) SetCredentials(certPEM, keyPEM, caPEM []byte) error {
+	if len(caPEM) == 0 {
+		return fmt.Errorf("a CA is required to authenticate peers")
+	}
+	return c.certificates.Load(certPEM, keyPEM, caPEM)
+}
+
+// SetSVID replaces the SVID of this node, peers have to be signed by its trust bundle
+func (c *Channel) SetSVID(svid *spiffe.SVID) error {
+	if c.idTemplate == "" {
+		return fmt.Errorf("the channel doesn't use SPIFFE IDs")
+	}
+	if id := spiffe.NodeID(c.idTemplate, c.node); svid.ID != id {
+		return fmt.Errorf("SVID [%s] isn't the SPIFFE ID of this node [%s]", svid.ID, id)
+	}
+	certPEM, keyPEM, bundlePEM, err := svid.PEM()
+	if err != nil {
+		return err
+	}
+	return c.SetCredentials(certPEM, keyPEM, bundlePEM)
+}
+
+// Handle registers the handler for requests from peers, the peer can be found with Peer
+func (c *Channel) Handle(path string, handler http.Handler) {
+	c.mux.Handle(path, handler)
+}
+
+// HandleLeading answers peers that ask whether this node is leading a lease, from its own leader elections
+func (c *Channel) HandleLeading(leading func(lease string) bool) {
+	c.Handle(LeadingPath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lease := r.URL.Query().Get("lease")
+		if lease == "" {
+			http.Error(w, "a lease is required", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Leading{Node: c.node, Lease: lease, Leading: leading(lease)})
+	}))
+}
+
+// Peer returns the node name of the peer that made a request, from its verified client certificate
+func (c *Channel) Peer(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return c.nodeName(r.TLS.VerifiedChains[0][0])
+}
+
+// nodeName returns the node that a verified certificate belongs to
+func (c *Channel) nodeName(cert *x509.Certificate) string {
+	if c.idTemplate != "" {
+		return spiffe.Node(c.idTemplate, spiffe.ID(cert))
+	}
+	return cert.Subject.CommonName
+}
+
+// Serve will accept connections from peers until the context is cancelled
+func (c *Channel) Serve(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              net.JoinHostPort("", strconv.Itoa(c.port)),
+		Handler:           c.authorize(c.mux),
+		TLSConfig:         c.certificates.TLSConfig(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	log.Infof("(coordination) accepting connections from peers on port [%d]", c.port)
+	if err := srv.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// authorize only passes on requests from peers that are nodes, clients from the same CA or trust domain that aren't
+// nodes are refused
+func (c *Channel) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.Peer(r) == "" {
+			http.Error(w, "client isn't a kube-vip node", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Client returns an HTTP client for a peer, the connection fails unless the peer has a certificate for the node name
+func (c *Channel) Client(node string) (*http.Client, error) {
+	cfg, err := c.certificates.ClientTLSConfig(node)
+	if err != nil {
+		return nil, err
+	}
+	if c.idTemplate != "" {
+		// SVIDs don't have to include DNS names, the chain and the SPIFFE ID are verified instead of the server name
+		roots := cfg.RootCAs
+		id := spiffe.NodeID(c.idTemplate, node)
+		cfg.InsecureSkipVerify = true //nolint
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPeer(cs.PeerCertificates, roots, id)
+		}
+	}
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: cfg},
+	}, nil
+}
+
+// URL returns the URL of a path on the peer at the address
+func (c *Channel) URL(address, path string) string {
+	return fmt.Sprintf("https://%s%s", net.JoinHostPort(address, strconv.Itoa(c.port)), path)
+}
+
+// Ping checks that the node can be reached at the address and that it accepts this node as a peer
+func (c *Channel) Ping(ctx context.Context, node, address string) error {
+	var identity Identity
+	if err := c.get(ctx, node, c.URL(address, IdentityPath), &identity); err != nil {
+		return err
+	}
+	if identity.Node != node || identity.Peer != c.node {
+		return fmt.Errorf("unexpected identity, [%s] answered as [%s] and saw [%s]", node, identity.Node, identity.Peer)
+	}
+	return nil
+}
+
+// Leading asks the node at the address whether it is leading a lease
+func (c *Channel) Leading(ctx context.Context, node, address, lease string) (bool, error) {
+	var leading Leading
+	if err := c.get(ctx, node, c.URL(address, LeadingPath)+"?lease="+url.QueryEscape(lease), &leading); err != nil {
+		return false, err
+	}
+	if leading.Node != node || leading.Lease != lease {
+		return false, fmt.Errorf("unexpected answer, [%s] answered as [%s] for lease [%s]", node, leading.Node, leading.Lease)
+	}
+	return leading.Leading, nil
+}
+
+// get will request a URL from the node and parse the JSON response
+func (c *Channel) get(ctx context.Context, node, target string, v any) error {
+	client, err := c.Client(node)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("[%s] returned [%s]", node, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("unable to parse the response of [%s]: %v", node, err)
+	}
+	return nil
+}
+
+// verifyPeer checks that the certificates of a server are signed by the roots and are for the SPIFFE ID
+func verifyPeer(certs []*x509.Certificate, roots *x509.CertPool, id string) error {
+	if len(certs) == 0 {
+		return fmt.Errorf("no certificate was presented by [%s]", id)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}); err != nil {
+		return err
+	}
+	if got := spiffe.ID(certs[0]); got != id {
+		return fmt.Errorf("unexpected SPIFFE ID [%s], expected [%s]", got, id)
+	}
+	return nil
+}
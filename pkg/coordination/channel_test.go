@@ -0,0 +1,259 @@
+package coordination
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kube-vip/kube-vip/pkg/spiffe"
+)
+
+// newCA creates a self signed CA for the tests
+func newCA(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kube-vip-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// newChannel creates a channel for the node with a certificate from the CA
+func newChannel(t *testing.T, node string, port int, caCert, caKey []byte) *Channel {
+	t.Helper()
+	certPEM, keyPEM, err := Issue(caCert, caKey, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewChannel(node, port)
+	if err := c.SetCredentials(certPEM, keyPEM, caCert); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// freePort returns a port that can be listened on
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestPing(t *testing.T) {
+	caCert, caKey := newCA(t)
+	port := freePort(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := newChannel(t, "node-1", port, caCert, caKey)
+	go func() {
+		_ = server.Serve(ctx)
+	}()
+
+	client := newChannel(t, "node-2", port, caCert, caKey)
+	var err error
+	for attempt := 0; attempt < 50; attempt++ {
+		if err = client.Ping(ctx, "node-1", "127.0.0.1"); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+
+	// The server has to be the node that was asked for
+	if err := client.Ping(ctx, "node-3", "127.0.0.1"); err == nil {
+		t.Error("Ping() expected an error when the server isn't the expected node")
+	}
+
+	// A client from another CA isn't accepted
+	otherCert, otherKey := newCA(t)
+	other := newChannel(t, "node-2", port, otherCert, otherKey)
+	if err := other.Ping(ctx, "node-1", "127.0.0.1"); err == nil {
+		t.Error("Ping() expected an error with a certificate from another CA")
+	}
+}
+
+func TestSetCredentialsRequiresCA(t *testing.T) {
+	caCert, caKey := newCA(t)
+	certPEM, keyPEM, err := Issue(caCert, caKey, "node-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewChannel("node-1", 0).SetCredentials(certPEM, keyPEM, nil); err == nil {
+		t.Error("SetCredentials() expected an error without a CA")
+	}
+}
+
+// newSVID creates an SVID for the SPIFFE ID that is signed by the CA, like the ones that SPIRE issues
+func newSVID(t *testing.T, caCertPEM, caKeyPEM []byte, id string) *spiffe.SVID {
+	t.Helper()
+	ca, err := tls.X509KeyPair(caCertPEM, caKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri, err := url.Parse(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		URIs:         []*url.URL{uri},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  usages,
+	}, caCert, &key.PublicKey, ca.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &spiffe.SVID{ID: id, Certificates: []*x509.Certificate{cert}, PrivateKey: key, Bundle: []*x509.Certificate{caCert}}
+}
+
+func TestSPIFFEPing(t *testing.T) {
+	template := "spiffe://example.org/kube-vip/{node}"
+	caCert, caKey := newCA(t)
+	port := freePort(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := NewSPIFFEChannel("node-1", port, template)
+	if err := server.SetSVID(newSVID(t, caCert, caKey, "spiffe://example.org/kube-vip/node-1")); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = server.Serve(ctx)
+	}()
+
+	client := NewSPIFFEChannel("node-2", port, template)
+	if err := client.SetSVID(newSVID(t, caCert, caKey, "spiffe://example.org/kube-vip/node-2")); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	for attempt := 0; attempt < 50; attempt++ {
+		if err = client.Ping(ctx, "node-1", "127.0.0.1"); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if err := client.Ping(ctx, "node-3", "127.0.0.1"); err == nil {
+		t.Error("Ping() expected an error when the server isn't the expected node")
+	}
+
+	// Other workloads of the trust domain aren't nodes
+	otherSVID := newSVID(t, caCert, caKey, "spiffe://example.org/other/node-2")
+	otherClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		Certificates:       []tls.Certificate{*otherSVID.TLSCertificate()},
+		InsecureSkipVerify: true, //nolint
+	}}}
+	resp, err := otherClient.Get(client.URL("127.0.0.1", IdentityPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("request from a workload that isn't a node returned %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	// The SVID has to be the one of this node
+	if err := client.SetSVID(newSVID(t, caCert, caKey, "spiffe://example.org/kube-vip/node-3")); err == nil {
+		t.Error("SetSVID() expected an error with the SVID of another node")
+	}
+}
+
+func TestLeading(t *testing.T) {
+	caCert, caKey := newCA(t)
+	port := freePort(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := newChannel(t, "node-1", port, caCert, caKey)
+	server.HandleLeading(func(lease string) bool {
+		return lease == "default/kubevip-web"
+	})
+	go func() {
+		_ = server.Serve(ctx)
+	}()
+
+	client := newChannel(t, "node-2", port, caCert, caKey)
+	for attempt := 0; attempt < 50; attempt++ {
+		if err := client.Ping(ctx, "node-1", "127.0.0.1"); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	tests := []struct {
+		name    string
+		node    string
+		lease   string
+		want    bool
+		wantErr bool
+	}{
+		{name: "leading", node: "node-1", lease: "default/kubevip-web", want: true},
+		{name: "not leading", node: "node-1", lease: "default/kubevip-api"},
+		{name: "no lease", node: "node-1", wantErr: true},
+		{name: "another node", node: "node-3", lease: "default/kubevip-web", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.Leading(ctx, tt.node, "127.0.0.1", tt.lease)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Leading() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Leading() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+const cloudflareAPI = "https://api.cloudflare.com/client/v4"
+
+// cloudflare publishes the records in a zone of Cloudflare, the Secret has the API token (with the DNS edit
+// permission of the zones) and the ID of the zone, along with the ID of the reverse zone of the addresses (optional)
+type cloudflare struct {
+	api    string
+	token  string
+	zoneID string
+	// The zone of the PTR records of the addresses, they aren't managed without one
+	reverseZoneID string
+	ttl           int
+	client        *http.Client
+}
+
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+	Proxied bool   `json:"proxied"`
+}
+
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result json.RawMessage `json:"result"`
+}
+
+func newCloudflare(credentials map[string][]byte, ttl int) (*cloudflare, error) {
+	token, err := credential(credentials, kubevip.DDNSProviderCloudflare, "api-token", true)
+	if err != nil {
+		return nil, err
+	}
+	zoneID, err := credential(credentials, kubevip.DDNSProviderCloudflare, "zone-id", true)
+	if err != nil {
+		return nil, err
+	}
+	reverseZoneID, _ := credential(credentials, kubevip.DDNSProviderCloudflare, "reverse-zone-id", false)
+	return &cloudflare{api: cloudflareAPI, token: token, zoneID: zoneID, reverseZoneID: reverseZoneID, ttl: ttl, client: newHTTPClient()}, nil
+}
+
+func (p *cloudflare) Name() string {
+	return kubevip.DDNSProviderCloudflare
+}
+
+func (p *cloudflare) Update(ctx context.Context, hostname, address string) error {
+	rrType, err := recordType(address)
+	if err != nil {
+		return err
+	}
+	return p.upsert(ctx, p.zoneID, rrType, hostname, address)
+}
+
+func (p *cloudflare) Delete(ctx context.Context, hostname, address string) error {
+	rrType, err := recordType(address)
+	if err != nil {
+		return err
+	}
+	return p.remove(ctx, p.zoneID, rrType, hostname, address)
+}
+
+// UpdateSet replaces the records of the hostname with the addresses, the records of the addresses that have gone are
+// removed
+func (p *cloudflare) UpdateSet(ctx context.Context, hostname string, addresses []string) error {
+	sets, err := recordSets(addresses)
+	if err != nil {
+		return err
+	}
+	for _, rrType := range []string{"A", "AAAA"} {
+		existing, err := p.records(ctx, p.zoneID, rrType, hostname)
+		if err != nil {
+			return err
+		}
+		published := map[string]bool{}
+		for _, record := range existing {
+			if slices.Contains(sets[rrType], record.Content) && !published[record.Content] {
+				published[record.Content] = true
+				continue
+			}
+			log.Infof("[ddns] deleting the %s record of [%s] with [%s] in cloudflare", rrType, hostname, record.Content)
+			if err := p.call(ctx, http.MethodDelete, p.zoneID, "/dns_records/"+record.ID, nil, nil); err != nil {
+				return err
+			}
+		}
+		for _, address := range sets[rrType] {
+			if published[address] {
+				continue
+			}
+			log.Infof("[ddns] creating the %s record of [%s] with [%s] in cloudflare", rrType, hostname, address)
+			record := cloudflareRecord{Type: rrType, Name: hostname, Content: address, TTL: p.ttl}
+			if err := p.call(ctx, http.MethodPost, p.zoneID, "/dns_records", record, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// UpdateReverse replaces the PTR record of the address, if a reverse zone is configured
+func (p *cloudflare) UpdateReverse(ctx context.Context, address, hostname string) error {
+	if p.reverseZoneID == "" {
+		return nil
+	}
+	name, err := reverseName(address)
+	if err != nil {
+		return err
+	}
+	return p.upsert(ctx, p.reverseZoneID, "PTR", strings.TrimSuffix(name, "."), hostname)
+}
+
+// DeleteReverse removes the PTR record of the address, if a reverse zone is configured
+func (p *cloudflare) DeleteReverse(ctx context.Context, address, hostname string) error {
+	if p.reverseZoneID == "" {
+		return nil
+	}
+	name, err := reverseName(address)
+	if err != nil {
+		return err
+	}
+	return p.remove(ctx, p.reverseZoneID, "PTR", strings.TrimSuffix(name, "."), hostname)
+}
+
+// upsert creates the record of the name in the zone, or replaces the content of the existing record
+func (p *cloudflare) upsert(ctx context.Context, zoneID, rrType, name, content string) error {
+	record := cloudflareRecord{Type: rrType, Name: name, Content: content, TTL: p.ttl}
+	existing, err := p.records(ctx, zoneID, rrType, name)
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		log.Infof("[ddns] creating the %s record of [%s] with [%s] in cloudflare", rrType, name, content)
+		return p.call(ctx, http.MethodPost, zoneID, "/dns_records", record, nil)
+	}
+	if existing[0].Content == content {
+		return nil
+	}
+	log.Infof("[ddns] updating the %s record of [%s] from [%s] to [%s] in cloudflare", rrType, name, existing[0].Content, content)
+	return p.call(ctx, http.MethodPut, zoneID, "/dns_records/"+existing[0].ID, record, nil)
+}
+
+// remove deletes the records of the name in the zone with the content
+func (p *cloudflare) remove(ctx context.Context, zoneID, rrType, name, content string) error {
+	existing, err := p.records(ctx, zoneID, rrType, name)
+	if err != nil {
+		return err
+	}
+	for _, record := range existing {
+		if record.Content != content {
+			continue
+		}
+		log.Infof("[ddns] deleting the %s record of [%s] with [%s] in cloudflare", rrType, name, content)
+		if err := p.call(ctx, http.MethodDelete, zoneID, "/dns_records/"+record.ID, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// records returns the records of the name in the zone with the type
+func (p *cloudflare) records(ctx context.Context, zoneID, rrType, name string) ([]cloudflareRecord, error) {
+	query := url.Values{"type": {rrType}, "name": {name}}
+	var records []cloudflareRecord
+	if err := p.call(ctx, http.MethodGet, zoneID, "/dns_records?"+query.Encode(), nil, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// call sends a request to the API of the zone, the result of the response is decoded into result
+func (p *cloudflare) call(ctx context.Context, method, zoneID, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/zones/%s%s", p.api, zoneID, path), reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var response cloudflareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("unexpected response [%s] from cloudflare: %v", resp.Status, err)
+	}
+	if !response.Success {
+		if len(response.Errors) != 0 {
+			return fmt.Errorf("cloudflare %s %s failed [%d]: %s", method, path, response.Errors[0].Code, response.Errors[0].Message)
+		}
+		return fmt.Errorf("cloudflare %s %s failed [%s]", method, path, resp.Status)
+	}
+	if result != nil {
+		return json.Unmarshal(response.Result, result)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package ddns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCloudflareUpdate(t *testing.T) {
+	records := map[string]cloudflareRecord{"existing": {ID: "existing", Type: "A", Name: "moved.example.com", Content: "192.168.0.10"}}
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"success":false,"errors":[{"code":9109,"message":"Invalid access token"}]}`))
+			return
+		}
+		var result any
+		switch r.Method {
+		case http.MethodGet:
+			found := []cloudflareRecord{}
+			for _, record := range records {
+				if record.Name == r.URL.Query().Get("name") && record.Type == r.URL.Query().Get("type") {
+					found = append(found, record)
+				}
+			}
+			result = found
+		case http.MethodPost, http.MethodPut:
+			var record cloudflareRecord
+			_ = json.NewDecoder(r.Body).Decode(&record)
+			record.ID = record.Name
+			records[record.ID] = record
+			result = record
+		}
+		b, _ := json.Marshal(result)
+		_, _ = w.Write([]byte(`{"success":true,"errors":[],"result":` + string(b) + `}`))
+	}))
+	defer server.Close()
+
+	p, err := newCloudflare(map[string][]byte{"api-token": []byte("token\n"), "zone-id": []byte("zone")}, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.api, p.client = server.URL, server.Client()
+
+	if err := p.Update(context.Background(), "new.example.com", "192.168.0.20"); err != nil {
+		t.Fatalf("Update() of a new record error = %v", err)
+	}
+	if err := p.Update(context.Background(), "moved.example.com", "192.168.0.11"); err != nil {
+		t.Fatalf("Update() of an existing record error = %v", err)
+	}
+	want := []string{"GET /zones/zone/dns_records", "POST /zones/zone/dns_records", "GET /zones/zone/dns_records", "PUT /zones/zone/dns_records/existing"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for x := range want {
+		if calls[x] != want[x] {
+			t.Errorf("call %d = %s, want %s", x, calls[x], want[x])
+		}
+	}
+	if records["new.example.com"].Content != "192.168.0.20" || records["moved.example.com"].Content != "192.168.0.11" {
+		t.Errorf("records = %v", records)
+	}
+	if records["new.example.com"].TTL != 300 {
+		t.Errorf("TTL = %d, want the configured 300", records["new.example.com"].TTL)
+	}
+
+	p.token = "wrong"
+	if err := p.Update(context.Background(), "new.example.com", "192.168.0.20"); err == nil {
+		t.Error("Update() = nil, want the error of cloudflare")
+	}
+}
+
+func TestNewCloudflare(t *testing.T) {
+	if _, err := newCloudflare(map[string][]byte{"api-token": []byte("token")}, defaultRecordTTL); err == nil {
+		t.Error("newCloudflare() = nil, want an error without the zone-id")
+	}
+}
@@ -0,0 +1,197 @@
+// Package ddns publishes the records of the VIPs that kube-vip allocates with dynamic DNS, through the provider that
+// serves the domain
+package ddns
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kube-vip/kube-vip/pkg/fips"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// defaultRecordTTL is the TTL of the records that are published unless one is configured, short so that a VIP that
+// moves is resolved again quickly
+const defaultRecordTTL = 60
+
+// Provider publishes the record of a hostname with the address of its VIP
+type Provider interface {
+	// Name returns the name of the provider
+	Name() string
+	// Update creates the record of the hostname with the address, or replaces the address of an existing record
+	Update(ctx context.Context, hostname, address string) error
+	// Delete removes the record of the hostname with the address
+	Delete(ctx context.Context, hostname, address string) error
+}
+
+// ReverseProvider is a provider that also manages the reverse (PTR) records of the addresses, when the reverse zone of
+// the addresses has been configured
+type ReverseProvider interface {
+	// UpdateReverse creates the PTR record of the address with the hostname, or replaces its hostname
+	UpdateReverse(ctx context.Context, address, hostname string) error
+	// DeleteReverse removes the PTR record of the address with the hostname
+	DeleteReverse(ctx context.Context, address, hostname string) error
+}
+
+// RecordSetProvider is a provider that can publish a hostname with several addresses, e.g. the nodes of the control
+// plane
+type RecordSetProvider interface {
+	// UpdateSet replaces the A and AAAA records of the hostname with the addresses
+	UpdateSet(ctx context.Context, hostname string, addresses []string) error
+}
+
+// Publisher publishes the records of the addresses through its provider
+type Publisher struct {
+	Provider
+}
+
+// Publish updates the record of the hostname, along with the reverse record of the address if the provider manages them
+func (p Publisher) Publish(ctx context.Context, hostname, address string) error {
+	if err := p.Update(ctx, hostname, address); err != nil {
+		return err
+	}
+	if reverse, ok := p.Provider.(ReverseProvider); ok {
+		return reverse.UpdateReverse(ctx, address, hostname)
+	}
+	return nil
+}
+
+// Unpublish removes the record of the hostname, along with the reverse record of the address if the provider manages
+// them
+func (p Publisher) Unpublish(ctx context.Context, hostname, address string) error {
+	if reverse, ok := p.Provider.(ReverseProvider); ok {
+		if err := reverse.DeleteReverse(ctx, address, hostname); err != nil {
+			return err
+		}
+	}
+	return p.Delete(ctx, hostname, address)
+}
+
+// New creates the provider with its credentials, which are the data of its Secret, the records are published with the
+// TTL (in seconds, the default without one)
+func New(name string, credentials map[string][]byte, ttl int) (Provider, error) {
+	if ttl <= 0 {
+		ttl = defaultRecordTTL
+	}
+	switch name {
+	case "", kubevip.DDNSProviderDHCP:
+		return &dhcpProvider{}, nil
+	case kubevip.DDNSProviderCloudflare:
+		return newCloudflare(credentials, ttl)
+	case kubevip.DDNSProviderRoute53:
+		return newRoute53(credentials, ttl)
+	case kubevip.DDNSProviderRFC2136:
+		return newRFC2136(credentials, ttl)
+	}
+	return nil, fmt.Errorf("unknown ddns provider [%s]", name)
+}
+
+// NewFromConfig creates the provider of the config, the credentials are read from its Secret in the namespace of
+// kube-vip
+func NewFromConfig(ctx context.Context, c *kubevip.Config, clientSet kubernetes.Interface) (Provider, error) {
+	if c.DDNSProvider == "" || c.DDNSProvider == kubevip.DDNSProviderDHCP {
+		return New(c.DDNSProvider, nil, c.DDNSTTL)
+	}
+	if clientSet == nil {
+		return nil, fmt.Errorf("ddns provider [%s] reads its credentials from a Secret, which requires Kubernetes", c.DDNSProvider)
+	}
+	secret, err := clientSet.CoreV1().Secrets(c.Namespace).Get(ctx, c.DDNSSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the credentials of ddns provider [%s] from Secret [%s/%s]: %v", c.DDNSProvider, c.Namespace, c.DDNSSecret, err)
+	}
+	return New(c.DDNSProvider, secret.Data, c.DDNSTTL)
+}
+
+// credential returns the value of a key of the Secret, an error is returned if a required key is missing
+func credential(credentials map[string][]byte, provider, key string, required bool) (string, error) {
+	value := strings.TrimSpace(string(credentials[key]))
+	if value == "" && required {
+		return "", fmt.Errorf("the Secret of ddns provider [%s] is missing [%s]", provider, key)
+	}
+	return value, nil
+}
+
+// recordType returns the type of the record of the address, A or AAAA
+func recordType(address string) (string, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return "", fmt.Errorf("invalid address [%s]", address)
+	}
+	if ip.To4() != nil {
+		return "A", nil
+	}
+	return "AAAA", nil
+}
+
+// recordSets splits the addresses into the A and AAAA record sets, the addresses of each set are sorted
+func recordSets(addresses []string) (map[string][]string, error) {
+	sets := map[string][]string{"A": nil, "AAAA": nil}
+	for _, address := range addresses {
+		rrType, err := recordType(address)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(sets[rrType], address) {
+			sets[rrType] = append(sets[rrType], address)
+		}
+	}
+	for rrType := range sets {
+		sort.Strings(sets[rrType])
+	}
+	return sets, nil
+}
+
+// reverseName returns the name of the PTR record of the address, in in-addr.arpa or ip6.arpa
+func reverseName(address string) (string, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return "", fmt.Errorf("invalid address [%s]", address)
+	}
+	var labels []string
+	if v4 := ip.To4(); v4 != nil {
+		for x := len(v4) - 1; x >= 0; x-- {
+			labels = append(labels, strconv.Itoa(int(v4[x])))
+		}
+		return strings.Join(labels, ".") + ".in-addr.arpa.", nil
+	}
+	const nibbles = "0123456789abcdef"
+	for x := len(ip) - 1; x >= 0; x-- {
+		labels = append(labels, string(nibbles[ip[x]&0xf]), string(nibbles[ip[x]>>4]))
+	}
+	return strings.Join(labels, ".") + ".ip6.arpa.", nil
+}
+
+// newHTTPClient returns the client that the providers call their APIs with
+func newHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	fips.RestrictTLS(transport.TLSClientConfig)
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}
+}
+
+// dhcpProvider leaves the records to the DHCP server, which registers the hostname that is sent with the request of
+// the lease
+type dhcpProvider struct{}
+
+func (p *dhcpProvider) Name() string {
+	return kubevip.DDNSProviderDHCP
+}
+
+func (p *dhcpProvider) Update(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func (p *dhcpProvider) Delete(_ context.Context, _, _ string) error {
+	return nil
+}
@@ -0,0 +1,84 @@
+package ddns
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestReverseName(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"192.168.0.10", "10.0.168.192.in-addr.arpa."},
+		{"2001:db8::567:89ab", "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+	for _, tt := range tests {
+		got, err := reverseName(tt.address)
+		if err != nil || got != tt.want {
+			t.Errorf("reverseName(%s) = %s (%v), want %s", tt.address, got, err, tt.want)
+		}
+	}
+	if _, err := reverseName("api.example.com"); err == nil {
+		t.Error("reverseName() = nil, want an error for a hostname")
+	}
+}
+
+func TestRecordSets(t *testing.T) {
+	sets, err := recordSets([]string{"192.168.0.12", "fd00::11", "192.168.0.11", "192.168.0.12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(sets["A"], []string{"192.168.0.11", "192.168.0.12"}) || !slices.Equal(sets["AAAA"], []string{"fd00::11"}) {
+		t.Errorf("recordSets() = %v, want the sorted addresses of each family", sets)
+	}
+	if _, err := recordSets([]string{"api.example.com"}); err == nil {
+		t.Error("recordSets() = nil, want an error for a hostname")
+	}
+}
+
+// fakeProvider records the changes of the records
+type fakeProvider struct {
+	dhcpProvider
+	records map[string]string
+}
+
+func (p *fakeProvider) Update(_ context.Context, hostname, address string) error {
+	p.records[hostname] = address
+	return nil
+}
+
+func (p *fakeProvider) UpdateReverse(_ context.Context, address, hostname string) error {
+	name, err := reverseName(address)
+	p.records[name] = hostname
+	return err
+}
+
+func (p *fakeProvider) DeleteReverse(_ context.Context, address, _ string) error {
+	name, err := reverseName(address)
+	delete(p.records, name)
+	return err
+}
+
+func TestPublish(t *testing.T) {
+	provider := &fakeProvider{records: map[string]string{}}
+	publisher := Publisher{Provider: provider}
+	if err := publisher.Publish(context.Background(), "api.example.com", "192.168.0.10"); err != nil {
+		t.Fatal(err)
+	}
+	if provider.records["api.example.com"] != "192.168.0.10" || provider.records["10.0.168.192.in-addr.arpa."] != "api.example.com" {
+		t.Errorf("records = %v, want the forward and reverse records", provider.records)
+	}
+	if err := publisher.Unpublish(context.Background(), "api.example.com", "192.168.0.10"); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := provider.records["10.0.168.192.in-addr.arpa."]; exists {
+		t.Errorf("records = %v, want the reverse record removed", provider.records)
+	}
+
+	// The providers without reverse records only publish the forward record
+	if err := (Publisher{Provider: &dhcpProvider{}}).Publish(context.Background(), "api.example.com", "192.168.0.10"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,371 @@
+package ddns
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+const (
+	// opcodeUpdate is the opcode of a dynamic update (RFC2136)
+	opcodeUpdate dnsmessage.OpCode = 5
+	// classNone deletes a record from a record set in the update section
+	classNone dnsmessage.Class = 254
+	// typeTSIG is the type of the signature of a message (RFC8945)
+	typeTSIG dnsmessage.Type = 250
+	// tsigFudge is the number of seconds that the clocks of kube-vip and the server can differ by
+	tsigFudge = 300
+)
+
+// tsigAlgorithms are the HMAC algorithms that updates are signed with
+var tsigAlgorithms = map[string]func() hash.Hash{
+	"hmac-sha256.": sha256.New,
+	"hmac-sha512.": sha512.New,
+}
+
+// rfc2136 publishes the records with dynamic updates to the primary server of the zone, the Secret has the server, the
+// zone and the TSIG key that the server accepts updates with, along with the reverse zone of the addresses (optional)
+type rfc2136 struct {
+	server string
+	zone   string
+	// The zone of the PTR records of the addresses, they aren't managed without one
+	reverseZone string
+	keyName     string
+	secret      []byte
+	algorithm   string
+	ttl         uint32
+}
+
+// rfc2136Change is a record that is added to, or removed from, the zone
+type rfc2136Change struct {
+	hostname string
+	rrType   dnsmessage.Type
+	address  net.IP
+	ptr      string
+	// remove the record (the record set when there's no address or ptr)
+	remove bool
+}
+
+func newRFC2136(credentials map[string][]byte, ttl int) (*rfc2136, error) {
+	p := &rfc2136{ttl: uint32(ttl)}
+	var err error
+	if p.server, err = credential(credentials, kubevip.DDNSProviderRFC2136, "server", true); err != nil {
+		return nil, err
+	}
+	if _, _, err := net.SplitHostPort(p.server); err != nil {
+		p.server = net.JoinHostPort(p.server, "53")
+	}
+	if p.zone, err = credential(credentials, kubevip.DDNSProviderRFC2136, "zone", true); err != nil {
+		return nil, err
+	}
+	p.zone = fqdn(p.zone)
+	if p.reverseZone, _ = credential(credentials, kubevip.DDNSProviderRFC2136, "reverse-zone", false); p.reverseZone != "" {
+		p.reverseZone = fqdn(p.reverseZone)
+	}
+
+	// Updates are only signed when there is a TSIG key
+	keyName, _ := credential(credentials, kubevip.DDNSProviderRFC2136, "tsig-key-name", false)
+	if keyName == "" {
+		return p, nil
+	}
+	p.keyName = strings.ToLower(fqdn(keyName))
+	secret, err := credential(credentials, kubevip.DDNSProviderRFC2136, "tsig-secret", true)
+	if err != nil {
+		return nil, err
+	}
+	if p.secret, err = base64.StdEncoding.DecodeString(secret); err != nil {
+		return nil, fmt.Errorf("the tsig-secret of ddns provider [%s] isn't base64: %v", kubevip.DDNSProviderRFC2136, err)
+	}
+	algorithm, _ := credential(credentials, kubevip.DDNSProviderRFC2136, "tsig-algorithm", false)
+	if algorithm == "" {
+		algorithm = "hmac-sha256"
+	}
+	p.algorithm = strings.ToLower(fqdn(algorithm))
+	if _, known := tsigAlgorithms[p.algorithm]; !known {
+		return nil, fmt.Errorf("unsupported tsig-algorithm [%s] of ddns provider [%s]", algorithm, kubevip.DDNSProviderRFC2136)
+	}
+	return p, nil
+}
+
+func (p *rfc2136) Name() string {
+	return kubevip.DDNSProviderRFC2136
+}
+
+func (p *rfc2136) Update(ctx context.Context, hostname, address string) error {
+	change, err := addressChange(hostname, address)
+	if err != nil {
+		return err
+	}
+	log.Infof("[ddns] updating the record of [%s] with [%s] on [%s]", hostname, address, p.server)
+	// The record set is replaced, so that the previous address of the hostname is removed
+	return p.send(ctx, p.zone, rfc2136Change{hostname: hostname, rrType: change.rrType, remove: true}, change)
+}
+
+func (p *rfc2136) Delete(ctx context.Context, hostname, address string) error {
+	change, err := addressChange(hostname, address)
+	if err != nil {
+		return err
+	}
+	change.remove = true
+	log.Infof("[ddns] deleting the record of [%s] with [%s] on [%s]", hostname, address, p.server)
+	return p.send(ctx, p.zone, change)
+}
+
+// UpdateSet replaces the A and AAAA record sets of the hostname with the addresses, in a single update
+func (p *rfc2136) UpdateSet(ctx context.Context, hostname string, addresses []string) error {
+	sets, err := recordSets(addresses)
+	if err != nil {
+		return err
+	}
+	changes := []rfc2136Change{
+		{hostname: hostname, rrType: dnsmessage.TypeA, remove: true},
+		{hostname: hostname, rrType: dnsmessage.TypeAAAA, remove: true},
+	}
+	for _, rrType := range []string{"A", "AAAA"} {
+		for _, address := range sets[rrType] {
+			change, err := addressChange(hostname, address)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, change)
+		}
+	}
+	log.Infof("[ddns] updating the records of [%s] with %v on [%s]", hostname, addresses, p.server)
+	return p.send(ctx, p.zone, changes...)
+}
+
+// UpdateReverse replaces the PTR record of the address, if a reverse zone is configured
+func (p *rfc2136) UpdateReverse(ctx context.Context, address, hostname string) error {
+	if p.reverseZone == "" {
+		return nil
+	}
+	name, err := reverseName(address)
+	if err != nil {
+		return err
+	}
+	log.Infof("[ddns] updating the PTR record of [%s] with [%s] on [%s]", address, hostname, p.server)
+	return p.send(ctx, p.reverseZone,
+		rfc2136Change{hostname: name, rrType: dnsmessage.TypePTR, remove: true},
+		rfc2136Change{hostname: name, rrType: dnsmessage.TypePTR, ptr: hostname})
+}
+
+// DeleteReverse removes the PTR record of the address, if a reverse zone is configured
+func (p *rfc2136) DeleteReverse(ctx context.Context, address, hostname string) error {
+	if p.reverseZone == "" {
+		return nil
+	}
+	name, err := reverseName(address)
+	if err != nil {
+		return err
+	}
+	log.Infof("[ddns] deleting the PTR record of [%s] with [%s] on [%s]", address, hostname, p.server)
+	return p.send(ctx, p.reverseZone, rfc2136Change{hostname: name, rrType: dnsmessage.TypePTR, ptr: hostname, remove: true})
+}
+
+// addressChange returns the change that adds the A or AAAA record of the address
+func addressChange(hostname, address string) (rfc2136Change, error) {
+	change := rfc2136Change{hostname: hostname, address: net.ParseIP(address)}
+	rrType, err := recordType(address)
+	if err != nil {
+		return change, err
+	}
+	change.rrType = dnsmessage.TypeA
+	if rrType == "AAAA" {
+		change.rrType = dnsmessage.TypeAAAA
+	}
+	return change, nil
+}
+
+// send sends the update of the zone to the server over TCP, and checks that it has been applied
+func (p *rfc2136) send(ctx context.Context, zone string, changes ...rfc2136Change) error {
+	msg, id, err := p.message(zone, time.Now(), changes...)
+	if err != nil {
+		return err
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", p.server)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+	}
+
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(msg)))
+	if _, err := conn.Write(append(framed, msg...)); err != nil {
+		return err
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return err
+	}
+	reply := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+
+	var parser dnsmessage.Parser
+	header, err := parser.Start(reply)
+	if err != nil {
+		return err
+	}
+	if header.ID != id {
+		return fmt.Errorf("unexpected reply from [%s], id %d instead of %d", p.server, header.ID, id)
+	}
+	if header.RCode != dnsmessage.RCodeSuccess {
+		return fmt.Errorf("update of zone [%s] was refused by [%s]: %s", zone, p.server, header.RCode)
+	}
+	return nil
+}
+
+// message builds the update of the zone with the changes, signed with the TSIG key when there is one
+func (p *rfc2136) message(zone string, now time.Time, changes ...rfc2136Change) ([]byte, uint16, error) {
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, 0, err
+	}
+	header := dnsmessage.Header{ID: binary.BigEndian.Uint16(id[:]), OpCode: opcodeUpdate}
+	zoneName, err := dnsmessage.NewName(fqdn(zone))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	b := dnsmessage.NewBuilder(nil, header)
+	// The zone section
+	if err := b.StartQuestions(); err != nil {
+		return nil, 0, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: zoneName, Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, 0, err
+	}
+	// The update section
+	if err := b.StartAuthorities(); err != nil {
+		return nil, 0, err
+	}
+	for _, change := range changes {
+		if err := change.build(&b, p.ttl); err != nil {
+			return nil, 0, err
+		}
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		return nil, 0, err
+	}
+	if p.keyName != "" {
+		msg = p.sign(msg, header.ID, now)
+	}
+	return msg, header.ID, nil
+}
+
+// build adds the change to the update section of the message
+func (c rfc2136Change) build(b *dnsmessage.Builder, ttl uint32) error {
+	name, err := dnsmessage.NewName(fqdn(c.hostname))
+	if err != nil {
+		return err
+	}
+	h := dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: ttl}
+	if c.remove {
+		h.TTL = 0
+		h.Class = classNone
+		if c.address == nil && c.ptr == "" {
+			// Deleting the whole record set has no data
+			h.Class = dnsmessage.ClassANY
+			return b.UnknownResource(h, dnsmessage.UnknownResource{Type: c.rrType})
+		}
+	}
+	switch c.rrType {
+	case dnsmessage.TypeA:
+		r := dnsmessage.AResource{}
+		copy(r.A[:], c.address.To4())
+		return b.AResource(h, r)
+	case dnsmessage.TypeAAAA:
+		r := dnsmessage.AAAAResource{}
+		copy(r.AAAA[:], c.address.To16())
+		return b.AAAAResource(h, r)
+	case dnsmessage.TypePTR:
+		ptr, err := dnsmessage.NewName(fqdn(c.ptr))
+		if err != nil {
+			return err
+		}
+		return b.PTRResource(h, dnsmessage.PTRResource{PTR: ptr})
+	}
+	return fmt.Errorf("unsupported record type %s", c.rrType)
+}
+
+// sign appends the TSIG record (RFC8945) to the message
+func (p *rfc2136) sign(msg []byte, id uint16, now time.Time) []byte {
+	signed := uint64(now.Unix())
+	timers := make([]byte, 0, 8)
+	timers = append(timers, byte(signed>>40), byte(signed>>32), byte(signed>>24), byte(signed>>16), byte(signed>>8), byte(signed))
+	timers = binary.BigEndian.AppendUint16(timers, tsigFudge)
+
+	// The MAC covers the message and the variables of the TSIG record
+	mac := hmac.New(tsigAlgorithms[p.algorithm], p.secret)
+	mac.Write(msg)
+	mac.Write(wireName(p.keyName))
+	mac.Write(binary.BigEndian.AppendUint16(nil, uint16(dnsmessage.ClassANY)))
+	mac.Write([]byte{0, 0, 0, 0}) // TTL
+	mac.Write(wireName(p.algorithm))
+	mac.Write(timers)
+	mac.Write([]byte{0, 0, 0, 0}) // error and other len
+	sum := mac.Sum(nil)
+
+	rdata := wireName(p.algorithm)
+	rdata = append(rdata, timers...)
+	rdata = binary.BigEndian.AppendUint16(rdata, uint16(len(sum)))
+	rdata = append(rdata, sum...)
+	rdata = binary.BigEndian.AppendUint16(rdata, id)
+	rdata = append(rdata, 0, 0, 0, 0) // error and other len
+
+	record := wireName(p.keyName)
+	record = binary.BigEndian.AppendUint16(record, uint16(typeTSIG))
+	record = binary.BigEndian.AppendUint16(record, uint16(dnsmessage.ClassANY))
+	record = append(record, 0, 0, 0, 0) // TTL
+	record = binary.BigEndian.AppendUint16(record, uint16(len(rdata)))
+	record = append(record, rdata...)
+
+	signedMsg := append(append([]byte{}, msg...), record...)
+	// One more record in the additional section
+	binary.BigEndian.PutUint16(signedMsg[10:12], binary.BigEndian.Uint16(msg[10:12])+1)
+	return signedMsg
+}
+
+// wireName returns the uncompressed wire format of a fully qualified name
+func wireName(name string) []byte {
+	var wire []byte
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+		wire = append(wire, byte(len(label)))
+		wire = append(wire, label...)
+	}
+	return append(wire, 0)
+}
+
+// fqdn returns the name with a trailing dot
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
@@ -0,0 +1,160 @@
+package ddns
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestRFC2136Message(t *testing.T) {
+	p, err := newRFC2136(map[string][]byte{
+		"server":        []byte("192.168.0.2"),
+		"zone":          []byte("example.com"),
+		"tsig-key-name": []byte("kube-vip"),
+		"tsig-secret":   []byte("c2VjcmV0"),
+	}, defaultRecordTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.server != "192.168.0.2:53" || p.algorithm != "hmac-sha256." {
+		t.Fatalf("server = %s, algorithm = %s", p.server, p.algorithm)
+	}
+
+	change, err := addressChange("api.example.com", "192.168.0.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, id, err := p.message(p.zone, time.Unix(1700000000, 0), rfc2136Change{hostname: "api.example.com", rrType: change.rrType, remove: true}, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parser dnsmessage.Parser
+	header, err := parser.Start(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.ID != id || header.OpCode != opcodeUpdate {
+		t.Errorf("header = %+v, want an update with id %d", header, id)
+	}
+	zone, err := parser.Question()
+	if err != nil || zone.Name.String() != "example.com." || zone.Type != dnsmessage.TypeSOA {
+		t.Errorf("zone = %+v (%v), want the SOA of example.com.", zone, err)
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.SkipAllAnswers(); err != nil {
+		t.Fatal(err)
+	}
+	updates, err := parser.AllAuthorities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 2 || updates[0].Header.Class != dnsmessage.ClassANY || updates[1].Header.Class != dnsmessage.ClassINET {
+		t.Fatalf("updates = %+v, want the record set deleted and the address added", updates)
+	}
+	if a, ok := updates[1].Body.(*dnsmessage.AResource); !ok || net.IP(a.A[:]).String() != "192.168.0.10" {
+		t.Errorf("added record = %+v, want 192.168.0.10", updates[1].Body)
+	}
+	signatures, err := parser.AllAdditionals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signatures) != 1 || signatures[0].Header.Type != typeTSIG || signatures[0].Header.Name.String() != "kube-vip." {
+		t.Errorf("additionals = %+v, want the TSIG record of kube-vip.", signatures)
+	}
+}
+
+func TestRFC2136ReverseMessage(t *testing.T) {
+	p, err := newRFC2136(map[string][]byte{"server": []byte("192.168.0.2:5353"), "zone": []byte("example.com"), "reverse-zone": []byte("0.168.192.in-addr.arpa")}, defaultRecordTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := reverseName("192.168.0.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, _, err := p.message(p.reverseZone, time.Now(), rfc2136Change{hostname: name, rrType: dnsmessage.TypePTR, ptr: "api.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parser dnsmessage.Parser
+	if _, err := parser.Start(msg); err != nil {
+		t.Fatal(err)
+	}
+	zone, err := parser.Question()
+	if err != nil || zone.Name.String() != "0.168.192.in-addr.arpa." {
+		t.Errorf("zone = %+v (%v), want the reverse zone", zone, err)
+	}
+	_ = parser.SkipAllQuestions()
+	_ = parser.SkipAllAnswers()
+	updates, err := parser.AllAuthorities()
+	if err != nil || len(updates) != 1 {
+		t.Fatalf("updates = %+v (%v), want the PTR record", updates, err)
+	}
+	if ptr, ok := updates[0].Body.(*dnsmessage.PTRResource); !ok || ptr.PTR.String() != "api.example.com." || updates[0].Header.Name.String() != name {
+		t.Errorf("update = %+v, want the PTR of %s to api.example.com.", updates[0], name)
+	}
+}
+
+func TestRFC2136Send(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			var length [2]byte
+			if _, err := io.ReadFull(conn, length[:]); err == nil {
+				msg := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, msg); err == nil {
+					var parser dnsmessage.Parser
+					header, _ := parser.Start(msg)
+					header.Response = true
+					// Refuse the updates of the records of the zone that aren't signed
+					if header.OpCode == opcodeUpdate && binary.BigEndian.Uint16(msg[10:12]) == 0 {
+						header.RCode = dnsmessage.RCodeRefused
+					}
+					b := dnsmessage.NewBuilder(nil, header)
+					reply, _ := b.Finish()
+					_, _ = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(reply))), reply...))
+				}
+			}
+			conn.Close()
+		}
+	}()
+
+	p, err := newRFC2136(map[string][]byte{
+		"server":        []byte(listener.Addr().String()),
+		"zone":          []byte("example.com."),
+		"tsig-key-name": []byte("kube-vip"),
+		"tsig-secret":   []byte("c2VjcmV0"),
+	}, defaultRecordTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Update(context.Background(), "api.example.com", "fd00::10"); err != nil {
+		t.Errorf("Update() error = %v", err)
+	}
+	if err := p.UpdateSet(context.Background(), "api.example.com", []string{"192.168.0.11", "192.168.0.12", "fd00::11"}); err != nil {
+		t.Errorf("UpdateSet() error = %v", err)
+	}
+
+	p.keyName = ""
+	if err := p.Delete(context.Background(), "api.example.com", "fd00::10"); err == nil {
+		t.Error("Delete() = nil, want the refusal of the server")
+	}
+}
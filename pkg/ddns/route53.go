@@ -0,0 +1,187 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/aws"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+const (
+	route53API = "https://route53.amazonaws.com/2013-04-01"
+	// route53Region is the region that the requests to the global endpoint of Route53 are signed for
+	route53Region = "us-east-1"
+)
+
+// route53 publishes the records in a hosted zone of AWS Route53, the Secret has the access key of an identity that is
+// allowed to change the record sets of the zones and the ID of the zone, along with the ID of the reverse zone of the
+// addresses (optional)
+type route53 struct {
+	api    string
+	zoneID string
+	// The zone of the PTR records of the addresses, they aren't managed without one
+	reverseZoneID string
+	accessKeyID   string
+	secretKey     string
+	sessionToken  string
+	ttl           int
+	client        *http.Client
+}
+
+type route53ChangeRequest struct {
+	XMLName xml.Name             `xml:"https://route53.amazonaws.com/doc/2013-04-01/ ChangeResourceRecordSetsRequest"`
+	Changes []route53ChangeEntry `xml:"ChangeBatch>Changes>Change"`
+}
+
+type route53ChangeEntry struct {
+	Action string   `xml:"Action"`
+	Name   string   `xml:"ResourceRecordSet>Name"`
+	Type   string   `xml:"ResourceRecordSet>Type"`
+	TTL    int      `xml:"ResourceRecordSet>TTL"`
+	Values []string `xml:"ResourceRecordSet>ResourceRecords>ResourceRecord>Value"`
+}
+
+type route53Error struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+func newRoute53(credentials map[string][]byte, ttl int) (*route53, error) {
+	p := &route53{api: route53API, ttl: ttl, client: newHTTPClient()}
+	var err error
+	if p.zoneID, err = credential(credentials, kubevip.DDNSProviderRoute53, "hosted-zone-id", true); err != nil {
+		return nil, err
+	}
+	if p.accessKeyID, err = credential(credentials, kubevip.DDNSProviderRoute53, "access-key-id", true); err != nil {
+		return nil, err
+	}
+	if p.secretKey, err = credential(credentials, kubevip.DDNSProviderRoute53, "secret-access-key", true); err != nil {
+		return nil, err
+	}
+	p.sessionToken, _ = credential(credentials, kubevip.DDNSProviderRoute53, "session-token", false)
+	p.reverseZoneID, _ = credential(credentials, kubevip.DDNSProviderRoute53, "reverse-hosted-zone-id", false)
+	p.zoneID = strings.TrimPrefix(p.zoneID, "/hostedzone/")
+	p.reverseZoneID = strings.TrimPrefix(p.reverseZoneID, "/hostedzone/")
+	return p, nil
+}
+
+func (p *route53) Name() string {
+	return kubevip.DDNSProviderRoute53
+}
+
+func (p *route53) Update(ctx context.Context, hostname, address string) error {
+	rrType, err := recordType(address)
+	if err != nil {
+		return err
+	}
+	log.Infof("[ddns] upserting the record of [%s] with [%s] in route53", hostname, address)
+	return p.change(ctx, p.zoneID, "UPSERT", rrType, hostname, address)
+}
+
+func (p *route53) Delete(ctx context.Context, hostname, address string) error {
+	rrType, err := recordType(address)
+	if err != nil {
+		return err
+	}
+	log.Infof("[ddns] deleting the record of [%s] with [%s] in route53", hostname, address)
+	return ignoreNotFound(p.change(ctx, p.zoneID, "DELETE", rrType, hostname, address))
+}
+
+// UpdateSet replaces the record sets of the hostname with the addresses, the record set of a family without addresses
+// is left as it is
+func (p *route53) UpdateSet(ctx context.Context, hostname string, addresses []string) error {
+	sets, err := recordSets(addresses)
+	if err != nil {
+		return err
+	}
+	for _, rrType := range []string{"A", "AAAA"} {
+		if len(sets[rrType]) == 0 {
+			continue
+		}
+		log.Infof("[ddns] upserting the %s records of [%s] with %v in route53", rrType, hostname, sets[rrType])
+		if err := p.change(ctx, p.zoneID, "UPSERT", rrType, hostname, sets[rrType]...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateReverse replaces the PTR record of the address, if a reverse zone is configured
+func (p *route53) UpdateReverse(ctx context.Context, address, hostname string) error {
+	if p.reverseZoneID == "" {
+		return nil
+	}
+	name, err := reverseName(address)
+	if err != nil {
+		return err
+	}
+	log.Infof("[ddns] upserting the PTR record of [%s] with [%s] in route53", address, hostname)
+	return p.change(ctx, p.reverseZoneID, "UPSERT", "PTR", name, fqdn(hostname))
+}
+
+// DeleteReverse removes the PTR record of the address, if a reverse zone is configured
+func (p *route53) DeleteReverse(ctx context.Context, address, hostname string) error {
+	if p.reverseZoneID == "" {
+		return nil
+	}
+	name, err := reverseName(address)
+	if err != nil {
+		return err
+	}
+	log.Infof("[ddns] deleting the PTR record of [%s] with [%s] in route53", address, hostname)
+	return ignoreNotFound(p.change(ctx, p.reverseZoneID, "DELETE", "PTR", name, fqdn(hostname)))
+}
+
+// ignoreNotFound ignores the error of deleting a record that has already gone, or has been replaced with another value
+func ignoreNotFound(err error) error {
+	if err != nil && strings.Contains(err.Error(), "not found") {
+		return nil
+	}
+	return err
+}
+
+// change sends a batch with the change of the record set of the name in the zone
+func (p *route53) change(ctx context.Context, zoneID, action, rrType, name string, values ...string) error {
+	body, err := xml.Marshal(route53ChangeRequest{Changes: []route53ChangeEntry{{
+		Action: action,
+		Name:   name,
+		Type:   rrType,
+		TTL:    p.ttl,
+		Values: values,
+	}}})
+	if err != nil {
+		return err
+	}
+	body = append([]byte(xml.Header), body...)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/hostedzone/%s/rrset", p.api, zoneID), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/xml")
+	aws.Sign(req, body, aws.Credentials{AccessKeyID: p.accessKeyID, SecretAccessKey: p.secretKey, Token: p.sessionToken}, route53Region, "route53", time.Now())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	b, _ := io.ReadAll(resp.Body)
+	var failure route53Error
+	if xml.Unmarshal(b, &failure) == nil && failure.Code != "" {
+		return fmt.Errorf("route53 %s of [%s] failed [%s]: %s", action, name, failure.Code, failure.Message)
+	}
+	return fmt.Errorf("route53 %s of [%s] failed [%s]", action, name, resp.Status)
+}
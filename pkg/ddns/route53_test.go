@@ -0,0 +1,20 @@
+package ddns
+
+import "testing"
+
+func TestNewRoute53(t *testing.T) {
+	p, err := newRoute53(map[string][]byte{
+		"hosted-zone-id":    []byte("/hostedzone/Z123"),
+		"access-key-id":     []byte("AKID"),
+		"secret-access-key": []byte("secret"),
+	}, defaultRecordTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.zoneID != "Z123" {
+		t.Errorf("zoneID = %s, want Z123", p.zoneID)
+	}
+	if _, err := newRoute53(map[string][]byte{"hosted-zone-id": []byte("Z123")}, defaultRecordTTL); err == nil {
+		t.Error("newRoute53() = nil, want an error without the access key")
+	}
+}
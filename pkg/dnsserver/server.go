@@ -0,0 +1,328 @@
+// Package dnsserver answers the A and AAAA queries for the names of the services with their VIPs, as the authoritative
+// server of a zone, for environments without a DNS server that kube-vip could publish the records to
+package dnsserver
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// recordTTL is the TTL of the answers, short so that a VIP that is reallocated is resolved again quickly
+	recordTTL = 30
+	// maxUDPSize is the size of the largest answer that is sent over UDP, larger answers are truncated so that the
+	// query is retried over TCP
+	maxUDPSize = 512
+	// tcpTimeout is how long a TCP connection is kept open without a query
+	tcpTimeout = 10 * time.Second
+)
+
+// Server is the authoritative server of the zone
+type Server struct {
+	zone    string
+	address string
+
+	mu      sync.RWMutex
+	records map[string][]net.IP
+	serial  uint32
+}
+
+// New creates the server of the zone, which will listen on the address (host:port)
+func New(zone, address string) *Server {
+	return &Server{
+		zone:    fqdn(zone),
+		address: address,
+		records: map[string][]net.IP{},
+		serial:  uint32(time.Now().Unix()),
+	}
+}
+
+// Name returns the name of a service in the zone, {service}.{namespace}.{zone}
+func (s *Server) Name(service, namespace string) string {
+	return fqdn(service + "." + namespace + "." + s.zone)
+}
+
+// Set replaces the addresses of the name, a name without any addresses is removed
+func (s *Server) Set(name string, addresses []net.IP) {
+	name = fqdn(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(addresses) == 0 {
+		if _, exists := s.records[name]; !exists {
+			return
+		}
+		delete(s.records, name)
+	} else {
+		s.records[name] = addresses
+	}
+	s.serial++
+}
+
+// Serve answers the queries over UDP and TCP until the context is cancelled. The sockets can be bound to an address
+// that isn't on this node, so that the node that holds the VIP answers for it.
+func (s *Server) Serve(ctx context.Context) error {
+	lc := net.ListenConfig{Control: freebind}
+	udp, err := lc.ListenPacket(ctx, "udp", s.address)
+	if err != nil {
+		return fmt.Errorf("unable to listen for DNS queries on [%s]: %v", s.address, err)
+	}
+	tcp, err := lc.Listen(ctx, "tcp", s.address)
+	if err != nil {
+		udp.Close()
+		return fmt.Errorf("unable to listen for DNS queries on [%s]: %v", s.address, err)
+	}
+	go func() {
+		<-ctx.Done()
+		udp.Close()
+		tcp.Close()
+	}()
+
+	log.Infof("[dns] answering the queries for zone [%s] on [%s]", s.zone, s.address)
+	go s.serveTCP(ctx, tcp)
+	s.serveUDP(udp)
+	return nil
+}
+
+func (s *Server) serveUDP(conn net.PacketConn) {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Errorf("[dns] unable to read a query: %v", err)
+			continue
+		}
+		response, err := s.answer(buf[:n], maxUDPSize)
+		if err != nil {
+			log.Debugf("[dns] ignoring the query from [%s]: %v", addr, err)
+			continue
+		}
+		if _, err := conn.WriteTo(response, addr); err != nil {
+			log.Debugf("[dns] unable to answer [%s]: %v", addr, err)
+		}
+	}
+}
+
+func (s *Server) serveTCP(ctx context.Context, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) || ctx.Err() != nil {
+				return
+			}
+			log.Errorf("[dns] unable to accept a connection: %v", err)
+			continue
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn answers the queries of a TCP connection, each message is preceded by its length
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	for {
+		_ = conn.SetDeadline(time.Now().Add(tcpTimeout))
+		var length uint16
+		if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+			return
+		}
+		query := make([]byte, length)
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+		response, err := s.answer(query, 65535)
+		if err != nil {
+			log.Debugf("[dns] ignoring the query from [%s]: %v", conn.RemoteAddr(), err)
+			return
+		}
+		if _, err := conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(response))), response...)); err != nil {
+			return
+		}
+	}
+}
+
+// answer builds the response to the query, an answer that is larger than the size is truncated
+func (s *Server) answer(query []byte, size int) ([]byte, error) {
+	var p dnsmessage.Parser
+	header, err := p.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	if header.Response {
+		return nil, fmt.Errorf("the message is a response")
+	}
+	response := dnsmessage.Header{ID: header.ID, Response: true, OpCode: header.OpCode, RecursionDesired: header.RecursionDesired}
+
+	question, err := p.Question()
+	if err != nil {
+		response.RCode = dnsmessage.RCodeFormatError
+		return s.build(response, nil, nil, false)
+	}
+	if header.OpCode != 0 {
+		response.RCode = dnsmessage.RCodeNotImplemented
+		return s.build(response, &question, nil, false)
+	}
+	name := strings.ToLower(question.Name.String())
+	if name != s.zone && !strings.HasSuffix(name, "."+s.zone) {
+		// Only the names of the zone are answered, this isn't a recursive server
+		response.RCode = dnsmessage.RCodeRefused
+		return s.build(response, &question, nil, false)
+	}
+	response.Authoritative = true
+
+	s.mu.RLock()
+	addresses, exists := s.records[name]
+	if !exists && name != s.zone && !s.parent(name) {
+		response.RCode = dnsmessage.RCodeNameError
+	}
+	s.mu.RUnlock()
+
+	var answers []net.IP
+	for _, address := range addresses {
+		v4 := address.To4() != nil
+		if (question.Type == dnsmessage.TypeA || question.Type == dnsmessage.TypeALL) && v4 ||
+			(question.Type == dnsmessage.TypeAAAA || question.Type == dnsmessage.TypeALL) && !v4 {
+			answers = append(answers, address)
+		}
+	}
+	soa := name == s.zone && (question.Type == dnsmessage.TypeSOA || question.Type == dnsmessage.TypeALL)
+
+	msg, err := s.build(response, &question, answers, soa)
+	if err != nil || len(msg) <= size {
+		return msg, err
+	}
+	response.Truncated = true
+	return s.build(response, &question, nil, false)
+}
+
+// parent returns true if the name has no records, but is the parent of a name that does (the namespace of a service)
+func (s *Server) parent(name string) bool {
+	for record := range s.records {
+		if strings.HasSuffix(record, "."+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// build builds the response with the answers, an authoritative response without any answers has the SOA record of
+// the zone in its authority section for negative caching
+func (s *Server) build(header dnsmessage.Header, question *dnsmessage.Question, answers []net.IP, soa bool) ([]byte, error) {
+	b := dnsmessage.NewBuilder(make([]byte, 0, maxUDPSize), header)
+	b.EnableCompression()
+	if question == nil {
+		return b.Finish()
+	}
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(*question); err != nil {
+		return nil, err
+	}
+	if !header.Authoritative || header.Truncated {
+		return b.Finish()
+	}
+
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+	h := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: recordTTL}
+	for _, address := range answers {
+		var err error
+		if v4 := address.To4(); v4 != nil {
+			var a dnsmessage.AResource
+			copy(a.A[:], v4)
+			err = b.AResource(h, a)
+		} else {
+			var aaaa dnsmessage.AAAAResource
+			copy(aaaa.AAAA[:], address.To16())
+			err = b.AAAAResource(h, aaaa)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if soa {
+		if err := s.soa(&b); err != nil {
+			return nil, err
+		}
+	}
+	if len(answers) != 0 || soa {
+		return b.Finish()
+	}
+
+	if err := b.StartAuthorities(); err != nil {
+		return nil, err
+	}
+	if err := s.soa(&b); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+// soa adds the SOA record of the zone to the section that is being built
+func (s *Server) soa(b *dnsmessage.Builder) error {
+	zone, err := dnsmessage.NewName(s.zone)
+	if err != nil {
+		return err
+	}
+	ns, err := dnsmessage.NewName("ns." + s.zone)
+	if err != nil {
+		return err
+	}
+	mbox, err := dnsmessage.NewName("hostmaster." + s.zone)
+	if err != nil {
+		return err
+	}
+	s.mu.RLock()
+	serial := s.serial
+	s.mu.RUnlock()
+	return b.SOAResource(dnsmessage.ResourceHeader{Name: zone, Class: dnsmessage.ClassINET, TTL: recordTTL}, dnsmessage.SOAResource{
+		NS:      ns,
+		MBox:    mbox,
+		Serial:  serial,
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  86400,
+		MinTTL:  recordTTL,
+	})
+}
+
+// freebind allows the sockets to be bound to the VIP before it has been added to this node
+func freebind(network, _ string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		if strings.HasSuffix(network, "6") {
+			sockErr = unix.SetsockoptInt(int(fd), unix.SOL_IPV6, unix.IPV6_FREEBIND, 1)
+		} else {
+			sockErr = unix.SetsockoptInt(int(fd), unix.SOL_IP, unix.IP_FREEBIND, 1)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// fqdn returns the lower case name with the trailing dot
+func fqdn(name string) string {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
@@ -0,0 +1,115 @@
+package dnsserver
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// query builds a query of the name and type
+func query(t *testing.T, name string, qtype dnsmessage.Type) []byte {
+	t.Helper()
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 42, RecursionDesired: true})
+	if err := b.StartQuestions(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Question(dnsmessage.Question{Name: dnsmessage.MustNewName(name), Type: qtype, Class: dnsmessage.ClassINET}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestAnswer(t *testing.T) {
+	s := New("lb.example.com", "127.0.0.1:53")
+	s.Set(s.Name("web", "default"), []net.IP{net.ParseIP("192.168.0.10"), net.ParseIP("fd00::10")})
+	s.Set(s.Name("removed", "default"), []net.IP{net.ParseIP("192.168.0.11")})
+	s.Set(s.Name("removed", "default"), nil)
+
+	tests := []struct {
+		name        string
+		qname       string
+		qtype       dnsmessage.Type
+		rcode       dnsmessage.RCode
+		answers     []string
+		authorities int
+	}{
+		{name: "a", qname: "web.default.lb.example.com.", qtype: dnsmessage.TypeA, answers: []string{"192.168.0.10"}},
+		{name: "aaaa", qname: "WEB.default.lb.example.com.", qtype: dnsmessage.TypeAAAA, answers: []string{"fd00::10"}},
+		{name: "no data", qname: "web.default.lb.example.com.", qtype: dnsmessage.TypeMX, authorities: 1},
+		{name: "namespace", qname: "default.lb.example.com.", qtype: dnsmessage.TypeA, authorities: 1},
+		{name: "removed", qname: "removed.default.lb.example.com.", qtype: dnsmessage.TypeA, rcode: dnsmessage.RCodeNameError, authorities: 1},
+		{name: "outside the zone", qname: "web.default.example.org.", qtype: dnsmessage.TypeA, rcode: dnsmessage.RCodeRefused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := s.answer(query(t, tt.qname, tt.qtype), maxUDPSize)
+			if err != nil {
+				t.Fatalf("answer() error = %v", err)
+			}
+			var response dnsmessage.Message
+			if err := response.Unpack(msg); err != nil {
+				t.Fatal(err)
+			}
+			if response.ID != 42 || !response.Response {
+				t.Errorf("answer() header = %+v, want the response to the query", response.Header)
+			}
+			if response.RCode != tt.rcode {
+				t.Errorf("answer() rcode = %v, want %v", response.RCode, tt.rcode)
+			}
+			if response.Authoritative != (tt.rcode != dnsmessage.RCodeRefused) {
+				t.Errorf("answer() authoritative = %t", response.Authoritative)
+			}
+			var answers []string
+			for _, answer := range response.Answers {
+				switch r := answer.Body.(type) {
+				case *dnsmessage.AResource:
+					answers = append(answers, net.IP(r.A[:]).String())
+				case *dnsmessage.AAAAResource:
+					answers = append(answers, net.IP(r.AAAA[:]).String())
+				}
+			}
+			if len(answers) != len(tt.answers) || (len(answers) != 0 && answers[0] != tt.answers[0]) {
+				t.Errorf("answer() answers = %v, want %v", answers, tt.answers)
+			}
+			if len(response.Authorities) != tt.authorities {
+				t.Errorf("answer() authorities = %d, want %d", len(response.Authorities), tt.authorities)
+			}
+		})
+	}
+}
+
+func TestAnswerTruncated(t *testing.T) {
+	s := New("lb.example.com.", "127.0.0.1:53")
+	var addresses []net.IP
+	for x := 1; x <= 64; x++ {
+		addresses = append(addresses, net.IPv4(192, 168, 0, byte(x)))
+	}
+	s.Set("web.default.lb.example.com", addresses)
+
+	msg, err := s.answer(query(t, "web.default.lb.example.com.", dnsmessage.TypeA), maxUDPSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response dnsmessage.Message
+	if err := response.Unpack(msg); err != nil {
+		t.Fatal(err)
+	}
+	if !response.Truncated || len(response.Answers) != 0 {
+		t.Errorf("answer() truncated = %t with %d answers, want a truncated response", response.Truncated, len(response.Answers))
+	}
+
+	if msg, err = s.answer(query(t, "web.default.lb.example.com.", dnsmessage.TypeA), 65535); err != nil {
+		t.Fatal(err)
+	}
+	if err := response.Unpack(msg); err != nil {
+		t.Fatal(err)
+	}
+	if response.Truncated || len(response.Answers) != 64 {
+		t.Errorf("answer() over TCP truncated = %t with %d answers, want all of them", response.Truncated, len(response.Answers))
+	}
+}
@@ -11,18 +11,9 @@ import (
 
 // BGPLookup will use the Equinix Metal API functions to populate the BGP information
 func BGPLookup(c *packngo.Client, k *kubevip.Config) error {
-	var thisDevice *packngo.Device
-	if k.MetalProjectID == "" {
-		proj := findProject(k.MetalProject, c)
-		if proj == nil {
-			return fmt.Errorf("Unable to find Project [%s]", k.MetalProject)
-		}
-		thisDevice = findSelf(c, proj.ID)
-	} else {
-		thisDevice = findSelf(c, k.MetalProjectID)
-	}
-	if thisDevice == nil {
-		return fmt.Errorf("Unable to find local/this device in Equinix Metal API")
+	thisDevice, err := findDevice(c, k)
+	if err != nil {
+		return err
 	}
 
 	log.Infof("Querying BGP settings for [%s]", thisDevice.Hostname)
@@ -48,8 +39,11 @@ func BGPLookup(c *packngo.Client, k *kubevip.Config) error {
 	k.BGPConfig.RouterID = neighbours[0].CustomerIP
 	k.BGPConfig.AS = uint32(neighbours[0].CustomerAs)
 
-	// Add the peer(s)
+	// Add the peer(s), a peer that is already configured keeps its configuration
 	for x := range neighbours[0].PeerIps {
+		if hasPeer(k.BGPConfig.Peers, neighbours[0].PeerIps[x]) {
+			continue
+		}
 		peer := bgp.Peer{
 			Address:  neighbours[0].PeerIps[x],
 			AS:       uint32(neighbours[0].PeerAs),
@@ -61,3 +55,13 @@ func BGPLookup(c *packngo.Client, k *kubevip.Config) error {
 
 	return nil
 }
+
+// hasPeer returns whether a peer with the address is already configured
+func hasPeer(peers []bgp.Peer, address string) bool {
+	for i := range peers {
+		if peers[i].Address == address {
+			return true
+		}
+	}
+	return false
+}
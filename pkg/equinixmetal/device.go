@@ -0,0 +1,70 @@
+package equinixmetal
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/packethost/packngo"
+	"github.com/packethost/packngo/metadata"
+	log "github.com/sirupsen/logrus"
+)
+
+// metadataURL is the metadata service that each device can reach, it tells the device its ID
+var metadataURL = metadata.BaseURL
+
+// NewClient returns the client of the Equinix Metal API, the token and the project ID are read from the provider
+// configuration when one is set
+func NewClient(k *kubevip.Config) (*packngo.Client, error) {
+	if k.ProviderConfig != "" {
+		key, project, err := GetPacketConfig(k.ProviderConfig)
+		if err != nil {
+			return nil, err
+		}
+		// Set the environment variable with the key for the project
+		os.Setenv("PACKET_AUTH_TOKEN", key)
+		// Update the configuration with the project key
+		k.MetalProjectID = project
+	}
+	return packngo.NewClient()
+}
+
+// findDevice returns the device that kube-vip is running on. The metadata service gives the ID of the device, and the
+// project is taken from the device when it isn't configured. When the metadata service can't be reached the device is
+// found by its hostname in the configured project instead.
+func findDevice(c *packngo.Client, k *kubevip.Config) (*packngo.Device, error) {
+	md, err := metadata.GetMetadataFromURL(metadataURL)
+	if err == nil && md != nil && md.ID != "" {
+		device, _, err := c.Devices.Get(md.ID, &packngo.GetOptions{Includes: []string{"project"}})
+		if err != nil {
+			return nil, fmt.Errorf("unable to find device [%s] in Equinix Metal API: %v", md.ID, err)
+		}
+		if k.MetalProjectID == "" && device.Project != nil {
+			k.MetalProjectID = device.Project.ID
+		}
+		return device, nil
+	}
+	log.Debugf("unable to read the metadata of this device, looking it up by hostname: %v", err)
+
+	projID, err := projectID(c, k)
+	if err != nil {
+		return nil, err
+	}
+	device := findSelf(c, projID)
+	if device == nil {
+		return nil, fmt.Errorf("unable to find local/this device in Equinix Metal API")
+	}
+	return device, nil
+}
+
+// projectID returns the ID of the configured project, it is looked up by name when only the name is configured
+func projectID(c *packngo.Client, k *kubevip.Config) (string, error) {
+	if k.MetalProjectID != "" {
+		return k.MetalProjectID, nil
+	}
+	proj := findProject(k.MetalProject, c)
+	if proj == nil {
+		return "", fmt.Errorf("unable to find Project [%s]", k.MetalProject)
+	}
+	return proj.ID, nil
+}
@@ -1,8 +1,11 @@
 package equinixmetal
 
 import (
+	"context"
 	"fmt"
 	"path"
+	"slices"
+	"time"
 
 	"github.com/kube-vip/kube-vip/pkg/kubevip"
 	"github.com/packethost/packngo"
@@ -11,50 +14,40 @@ import (
 
 // AttachEIP will use the Equinix Metal APIs to move an EIP and attach to a host
 func AttachEIP(c *packngo.Client, k *kubevip.Config, _ string) error {
-	// Use MetalProjectID if it is defined
-	projID := k.MetalProjectID
-
-	if projID == "" {
-		// Fallback to attempting to find the project by name
-		proj := findProject(k.MetalProject, c)
-		if proj == nil {
-			return fmt.Errorf("unable to find Project [%s]", k.MetalProject)
-		}
-
-		projID = proj.ID
+	// Lookup this server through the Equinix Metal API
+	thisDevice, err := findDevice(c, k)
+	if err != nil {
+		return err
 	}
-
-	// Prefer Address over VIP
-	vip := k.Address
-	if vip == "" {
-		vip = k.VIP
+	projID, err := projectID(c, k)
+	if err != nil {
+		return err
 	}
 
-	ips, _, _ := c.ProjectIPs.List(projID, &packngo.ListOptions{})
-	for _, ip := range ips {
-		// Find the device id for our EIP
-		if ip.Address == vip {
-			log.Infof("Found EIP ->%s ID -> %s\n", ip.Address, ip.ID)
-			// If attachments already exist then remove them
-			if len(ip.Assignments) != 0 {
-				hrefID := path.Base(ip.Assignments[0].Href)
-				_, err := c.DeviceIPs.Unassign(hrefID)
-				if err != nil {
-					return fmt.Errorf("unable to unassign deviceIP %q: %v", hrefID, err)
-				}
+	vip := eipAddress(k)
+	ip, err := findEIP(c, projID, vip)
+	if err != nil {
+		return err
+	}
+	if ip != nil {
+		log.Infof("Found EIP ->%s ID -> %s\n", ip.Address, ip.ID)
+		if assignedTo(ip, thisDevice.ID) {
+			log.Infof("EIP [%s] is already assigned to -> %s", vip, thisDevice.Hostname)
+			return nil
+		}
+		// If attachments already exist then remove them
+		if len(ip.Assignments) != 0 {
+			hrefID := path.Base(ip.Assignments[0].Href)
+			_, err := c.DeviceIPs.Unassign(hrefID)
+			if err != nil {
+				return fmt.Errorf("unable to unassign deviceIP %q: %v", hrefID, err)
 			}
 		}
 	}
 
-	// Lookup this server through the Equinix Metal API
-	thisDevice := findSelf(c, projID)
-	if thisDevice == nil {
-		return fmt.Errorf("unable to find local/this device in Equinix Metal API")
-	}
-
 	// Assign the EIP to this device
 	log.Infof("Assigning EIP to -> %s\n", thisDevice.Hostname)
-	_, _, err := c.DeviceIPs.Assign(thisDevice.ID, &packngo.AddressStruct{
+	_, _, err = c.DeviceIPs.Assign(thisDevice.ID, &packngo.AddressStruct{
 		Address: vip,
 	})
 	if err != nil {
@@ -63,3 +56,118 @@ func AttachEIP(c *packngo.Client, k *kubevip.Config, _ string) error {
 
 	return nil
 }
+
+// LookupEIP sets the VIP to the public IPv4 EIP of the project that has the configured tag, when neither the VIP nor
+// the address is configured
+func LookupEIP(c *packngo.Client, k *kubevip.Config) error {
+	if k.MetalEIPTag == "" || eipAddress(k) != "" {
+		return nil
+	}
+	// The project is taken from the device when it isn't configured
+	if k.MetalProjectID == "" && k.MetalProject == "" {
+		if _, err := findDevice(c, k); err != nil {
+			return err
+		}
+	}
+	projID, err := projectID(c, k)
+	if err != nil {
+		return err
+	}
+
+	ips, _, err := c.ProjectIPs.List(projID, &packngo.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list the IPs of project [%s]: %v", projID, err)
+	}
+	var found []string
+	for _, ip := range ips {
+		if ip.Public && !ip.Management && ip.AddressFamily == 4 && ip.CIDR == 32 && slices.Contains(ip.Tags, k.MetalEIPTag) {
+			found = append(found, ip.Address)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return fmt.Errorf("no EIP of project [%s] has the tag [%s]", projID, k.MetalEIPTag)
+	case 1:
+		log.Infof("Using the EIP [%s] with the tag [%s] as the VIP", found[0], k.MetalEIPTag)
+		k.VIP = found[0]
+		return nil
+	default:
+		return fmt.Errorf("the EIPs %v of project [%s] all have the tag [%s], only one can be the VIP", found, projID, k.MetalEIPTag)
+	}
+}
+
+// WatchEIP checks that the EIP is still assigned to this device every interval, and attaches it again when it has been
+// reassigned (or unassigned) outside of kube-vip, until the context is cancelled
+func WatchEIP(ctx context.Context, c *packngo.Client, k *kubevip.Config, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		assigned, err := eipAssigned(c, k)
+		if err != nil {
+			log.Warnf("unable to check the assignment of the EIP [%s]: %v", eipAddress(k), err)
+			continue
+		}
+		if assigned {
+			continue
+		}
+		log.Warnf("the EIP [%s] is no longer assigned to this device, attaching it again", eipAddress(k))
+		if err := AttachEIP(c, k, k.NodeName); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+// eipAssigned returns whether the EIP is assigned to this device
+func eipAssigned(c *packngo.Client, k *kubevip.Config) (bool, error) {
+	thisDevice, err := findDevice(c, k)
+	if err != nil {
+		return false, err
+	}
+	projID, err := projectID(c, k)
+	if err != nil {
+		return false, err
+	}
+	ip, err := findEIP(c, projID, eipAddress(k))
+	if err != nil {
+		return false, err
+	}
+	return ip != nil && assignedTo(ip, thisDevice.ID), nil
+}
+
+// findEIP returns the reservation of the EIP in the project, or nil when the project has no such EIP
+func findEIP(c *packngo.Client, projID, vip string) (*packngo.IPAddressReservation, error) {
+	ips, _, err := c.ProjectIPs.List(projID, &packngo.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the IPs of project [%s]: %v", projID, err)
+	}
+	for i := range ips {
+		// Find the device id for our EIP
+		if ips[i].Address == vip {
+			return &ips[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// assignedTo returns whether the EIP is assigned to the device
+func assignedTo(ip *packngo.IPAddressReservation, deviceID string) bool {
+	for _, assignment := range ip.Assignments {
+		if path.Base(assignment.AssignedTo.Href) == deviceID {
+			return true
+		}
+	}
+	return false
+}
+
+// eipAddress returns the EIP of the configuration, the address is preferred over the VIP
+func eipAddress(k *kubevip.Config) string {
+	if k.Address != "" {
+		return k.Address
+	}
+	return k.VIP
+}
@@ -0,0 +1,162 @@
+package equinixmetal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/packethost/packngo"
+)
+
+const (
+	testDevice     = "8a8b7a2c-6d3e-4f37-9c4d-2f4b1c3e5a01"
+	testOther      = "0c9d1e2f-3a4b-4c5d-8e6f-7a8b9c0d1e02"
+	testProject    = "5e1c7b0a-2d4f-4a6b-9c8d-1e2f3a4b5c03"
+	testAssignment = "9f8e7d6c-5b4a-4938-8271-6a5b4c3d2e04"
+)
+
+// fakeMetal answers the metadata of the device and the few calls of the Equinix Metal API that kube-vip makes
+type fakeMetal struct {
+	mu sync.Mutex
+	// assignedTo is the device that the EIP 192.0.2.10 is assigned to
+	assignedTo string
+	assigns    int
+	unassigns  int
+}
+
+func (f *fakeMetal) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var body any
+	switch r.Method + " " + r.URL.Path {
+	case "GET /metadata":
+		body = map[string]any{"id": testDevice, "hostname": "node1"}
+	case "GET /devices/" + testDevice:
+		body = map[string]any{"id": testDevice, "hostname": "node1", "project": map[string]any{"id": testProject}}
+	case "GET /devices/" + testDevice + "/bgp/neighbors":
+		body = map[string]any{"bgp_neighbors": []map[string]any{{
+			"customer_as": 65000, "customer_ip": "10.0.0.2", "md5_password": "secret", "multihop": true,
+			"peer_as": 65530, "peer_ips": []string{"169.254.255.1", "169.254.255.2"},
+		}}}
+	case "GET /projects/" + testProject + "/ips":
+		eip := map[string]any{"id": "eip", "address": "192.0.2.10", "address_family": 4, "cidr": 32, "public": true, "tags": []string{"kube-vip"}}
+		if f.assignedTo != "" {
+			eip["assignments"] = []map[string]any{{"href": "/ips/" + testAssignment, "assigned_to": map[string]any{"href": "/devices/" + f.assignedTo}}}
+		}
+		body = map[string]any{"ip_addresses": []map[string]any{
+			eip,
+			{"id": "other", "address": "192.0.2.11", "address_family": 4, "cidr": 32, "public": true, "tags": []string{"other"}},
+			{"id": "management", "address": "10.0.0.2", "address_family": 4, "cidr": 31, "management": true, "tags": []string{"kube-vip"}},
+		}}
+	case "DELETE /ips/" + testAssignment:
+		f.unassigns++
+		f.assignedTo = ""
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case "POST /devices/" + testDevice + "/ips":
+		f.assigns++
+		f.assignedTo = testDevice
+		body = map[string]any{"id": testAssignment}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// newTestClient returns a client of the fake API, which is also the metadata service for the test
+func newTestClient(t *testing.T, f *fakeMetal) *packngo.Client {
+	t.Helper()
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	previous := metadataURL
+	metadataURL = server.URL
+	t.Cleanup(func() { metadataURL = previous })
+
+	c, err := packngo.NewClient(packngo.WithAuth("kube-vip", "token"), packngo.WithBaseURL(server.URL+"/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestLookupEIP(t *testing.T) {
+	c := newTestClient(t, &fakeMetal{})
+
+	// The project is taken from the device
+	k := &kubevip.Config{MetalEIPTag: "kube-vip"}
+	if err := LookupEIP(c, k); err != nil {
+		t.Fatal(err)
+	}
+	if k.VIP != "192.0.2.10" || k.MetalProjectID != testProject {
+		t.Errorf("VIP = %q, project = %q, want 192.0.2.10 and %s", k.VIP, k.MetalProjectID, testProject)
+	}
+
+	k = &kubevip.Config{MetalEIPTag: "missing", MetalProjectID: testProject}
+	if err := LookupEIP(c, k); err == nil {
+		t.Errorf("LookupEIP() found an EIP for a tag that no EIP has: %q", k.VIP)
+	}
+
+	// A configured VIP is kept
+	k = &kubevip.Config{MetalEIPTag: "kube-vip", VIP: "192.0.2.20"}
+	if err := LookupEIP(c, k); err != nil || k.VIP != "192.0.2.20" {
+		t.Errorf("LookupEIP() = %v, VIP = %q, want the configured VIP", err, k.VIP)
+	}
+}
+
+func TestAttachEIP(t *testing.T) {
+	f := &fakeMetal{assignedTo: testOther}
+	c := newTestClient(t, f)
+	k := &kubevip.Config{VIP: "192.0.2.10"}
+
+	if assigned, err := eipAssigned(c, k); err != nil || assigned {
+		t.Fatalf("eipAssigned() = %t, %v, want the EIP assigned to the other device", assigned, err)
+	}
+	if err := AttachEIP(c, k, "node1"); err != nil {
+		t.Fatal(err)
+	}
+	if f.unassigns != 1 || f.assigns != 1 {
+		t.Errorf("unassigned %d and assigned %d times, want 1 and 1", f.unassigns, f.assigns)
+	}
+	if assigned, err := eipAssigned(c, k); err != nil || !assigned {
+		t.Fatalf("eipAssigned() = %t, %v, want the EIP assigned to this device", assigned, err)
+	}
+
+	// The EIP that is already assigned to this device isn't moved
+	if err := AttachEIP(c, k, "node1"); err != nil {
+		t.Fatal(err)
+	}
+	if f.unassigns != 1 || f.assigns != 1 {
+		t.Errorf("unassigned %d and assigned %d times, want the EIP left assigned", f.unassigns, f.assigns)
+	}
+}
+
+func TestBGPLookup(t *testing.T) {
+	c := newTestClient(t, &fakeMetal{})
+	k := &kubevip.Config{}
+	k.BGPConfig.Peers = []bgp.Peer{{Address: "169.254.255.1", AS: 65530, Password: "configured"}}
+
+	if err := BGPLookup(c, k); err != nil {
+		t.Fatal(err)
+	}
+	if k.BGPConfig.RouterID != "10.0.0.2" || k.BGPConfig.AS != 65000 {
+		t.Errorf("router ID = %q, AS = %d, want 10.0.0.2 and 65000", k.BGPConfig.RouterID, k.BGPConfig.AS)
+	}
+	want := []bgp.Peer{
+		{Address: "169.254.255.1", AS: 65530, Password: "configured"},
+		{Address: "169.254.255.2", AS: 65530, Password: "secret", MultiHop: true},
+	}
+	if len(k.BGPConfig.Peers) != len(want) {
+		t.Fatalf("peers = %+v, want %+v", k.BGPConfig.Peers, want)
+	}
+	for i := range want {
+		if k.BGPConfig.Peers[i] != want[i] {
+			t.Errorf("peer %d = %+v, want %+v", i, k.BGPConfig.Peers[i], want[i])
+		}
+	}
+}
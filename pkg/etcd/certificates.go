@@ -0,0 +1,89 @@
+package etcd
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// ExpiryWarning is how long before the client certificate expires that a warning is logged
+const ExpiryWarning = 7 * 24 * time.Hour
+
+// readCertificate returns the first certificate in a PEM file
+func readCertificate(file string) (*x509.Certificate, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded certificate found in [%s]", file)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// reconnect will drop the connections to the endpoints and dial them again, so that the current client certificate is
+// used rather than the one that the connections were made with. Sessions survive as long as the connections are back
+// before their leases expire.
+func reconnect(client *clientv3.Client) {
+	endpoints := client.Endpoints()
+	client.SetEndpoints()
+	client.SetEndpoints(endpoints...)
+}
+
+// WatchCertificate will check the client certificate every interval until the context is cancelled. The client
+// reconnects when the certificate has been renewed, instead of using the old certificate until a connection fails,
+// and the expiry is set on the gauge so that operators are warned before the election stops working.
+func WatchCertificate(ctx context.Context, client *clientv3.Client, certFile string, interval time.Duration, expiry prometheus.Gauge) {
+	var serial string
+	var warned bool
+
+	check := func() {
+		cert, err := readCertificate(certFile)
+		if err != nil {
+			log.Errorf("(etcd) unable to read client certificate: %v", err)
+			return
+		}
+		expiry.Set(float64(cert.NotAfter.Unix()))
+
+		if serial != "" && serial != cert.SerialNumber.String() {
+			log.Infof("(etcd) client certificate has been renewed, it expires at [%s], reconnecting", cert.NotAfter)
+			reconnect(client)
+			warned = false
+		}
+		serial = cert.SerialNumber.String()
+
+		remaining := time.Until(cert.NotAfter)
+		switch {
+		case remaining <= 0:
+			if !warned {
+				log.Errorf("(etcd) client certificate expired at [%s], leader election will fail once the connection is lost", cert.NotAfter)
+				warned = true
+			}
+		case remaining < ExpiryWarning:
+			if !warned {
+				log.Warnf("(etcd) client certificate expires at [%s], it should be renewed", cert.NotAfter)
+				warned = true
+			}
+		}
+	}
+
+	check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
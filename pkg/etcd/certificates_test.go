@@ -0,0 +1,62 @@
+package etcd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCertificate(t *testing.T, file string, notAfter time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(notAfter.Unix()),
+		Subject:      pkix.Name{CommonName: "kube-vip"},
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadCertificate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "client.crt")
+	notAfter := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	writeCertificate(t, file, notAfter)
+
+	cert, err := readCertificate(file)
+	if err != nil {
+		t.Fatalf("readCertificate() error = %v", err)
+	}
+	if !cert.NotAfter.Equal(notAfter) {
+		t.Errorf("readCertificate() NotAfter = %v, want %v", cert.NotAfter, notAfter)
+	}
+
+	invalid := filepath.Join(dir, "invalid.crt")
+	if err := os.WriteFile(invalid, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCertificate(invalid); err == nil {
+		t.Error("readCertificate() expected an error without a PEM block")
+	}
+	if _, err := readCertificate(filepath.Join(dir, "missing.crt")); err == nil {
+		t.Error("readCertificate() expected an error for a missing file")
+	}
+}
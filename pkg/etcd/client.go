@@ -4,6 +4,7 @@ import (
 	"go.etcd.io/etcd/client/pkg/v3/transport"
 	clientv3 "go.etcd.io/etcd/client/v3"
 
+	"github.com/kube-vip/kube-vip/pkg/fips"
 	"github.com/kube-vip/kube-vip/pkg/kubevip"
 )
 
@@ -14,10 +15,13 @@ func NewClient(c *kubevip.Config) (*clientv3.Client, error) {
 		KeyFile:       c.Etcd.ClientKeyFile,
 	}
 
+	// The client certificate and key are read from disk for every new connection, so rotated certificates are used
+	// when the client reconnects. The CA is only read here.
 	clientTLS, err := tlsInfo.ClientConfig()
 	if err != nil {
 		return nil, err
 	}
+	fips.RestrictTLS(clientTLS)
 
 	return clientv3.New(clientv3.Config{
 		Endpoints: c.Etcd.Endpoints,
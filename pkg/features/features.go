@@ -0,0 +1,169 @@
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Feature is the name of a feature that can be toggled with a feature gate
+type Feature string
+
+// Stage is the maturity of a feature
+type Stage string
+
+const (
+	// Alpha features are disabled by default and may change or be removed
+	Alpha = Stage("ALPHA")
+	// Beta features are enabled by default and are unlikely to be removed
+	Beta = Stage("BETA")
+	// GA features are always enabled, the gate is kept to avoid breaking existing configuration
+	GA = Stage("")
+)
+
+// FeatureSpec defines the default state and maturity of a feature
+type FeatureSpec struct {
+	Default    bool
+	PreRelease Stage
+}
+
+// Features that can be toggled, add new experimental features here
+const (
+	// EBPFDataplane uses an eBPF program for advertising and forwarding
+	EBPFDataplane Feature = "EBPFDataplane"
+
+	// CRDConfig reads configuration from kube-vip custom resources
+	CRDConfig Feature = "CRDConfig"
+
+	// DirectServerReturn allows backends to reply directly to clients, bypassing the load balancer
+	DirectServerReturn Feature = "DirectServerReturn"
+)
+
+// defaultFeatures are all of the known features and their defaults
+var defaultFeatures = map[Feature]FeatureSpec{
+	EBPFDataplane:      {Default: false, PreRelease: Alpha},
+	CRDConfig:          {Default: false, PreRelease: Alpha},
+	DirectServerReturn: {Default: false, PreRelease: Alpha},
+}
+
+// FeatureGate keeps track of which features are enabled, it implements the pflag.Value interface so that it
+// can be used directly as a flag in the format "Feature1=true,Feature2=false"
+type FeatureGate struct {
+	mutex   sync.RWMutex
+	known   map[Feature]FeatureSpec
+	enabled map[Feature]bool
+}
+
+// DefaultFeatureGate is the feature gate used throughout kube-vip
+var DefaultFeatureGate = NewFeatureGate(defaultFeatures)
+
+// NewFeatureGate will create a feature gate for a set of known features
+func NewFeatureGate(known map[Feature]FeatureSpec) *FeatureGate {
+	return &FeatureGate{
+		known:   known,
+		enabled: map[Feature]bool{},
+	}
+}
+
+// Set will parse a comma separated list of Feature=bool pairs and apply them to the feature gate
+func (f *FeatureGate) Set(value string) error {
+	m := map[string]bool{}
+	for _, s := range strings.Split(value, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		k, v, found := strings.Cut(s, "=")
+		if !found {
+			return fmt.Errorf("missing bool value for feature gate [%s]", s)
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid value [%s] for feature gate [%s]: %v", v, k, err)
+		}
+		m[strings.TrimSpace(k)] = b
+	}
+	return f.SetFromMap(m)
+}
+
+// SetFromMap will apply a map of feature names to the feature gate, unknown features are an error
+func (f *FeatureGate) SetFromMap(m map[string]bool) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for k, v := range m {
+		spec, exists := f.known[Feature(k)]
+		if !exists {
+			return fmt.Errorf("unrecognized feature gate [%s], known features are %v", k, f.knownFeatures())
+		}
+		if spec.PreRelease == GA && !v {
+			return fmt.Errorf("feature gate [%s] is GA and can't be disabled", k)
+		}
+		f.enabled[Feature(k)] = v
+	}
+	return nil
+}
+
+// Enabled returns true if a feature has been enabled, or is enabled by default
+func (f *FeatureGate) Enabled(key Feature) bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	if v, exists := f.enabled[key]; exists {
+		return v
+	}
+	return f.known[key].Default
+}
+
+// String returns the feature gates that have been set, in the same format used by Set
+func (f *FeatureGate) String() string {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	var pairs []string
+	for k, v := range f.enabled {
+		pairs = append(pairs, fmt.Sprintf("%s=%t", k, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Type is used by pflag to describe the flag
+func (f *FeatureGate) Type() string {
+	return "mapStringBool"
+}
+
+// KnownFeatures returns a description of each known feature, for use in flag help text
+func (f *FeatureGate) KnownFeatures() []string {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.knownFeatures()
+}
+
+// EnabledFeatures returns the names of all of the features that are enabled
+func (f *FeatureGate) EnabledFeatures() []string {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	var enabled []string
+	for k, v := range f.known {
+		if e, exists := f.enabled[k]; exists && e || !exists && v.Default {
+			enabled = append(enabled, string(k))
+		}
+	}
+	sort.Strings(enabled)
+	return enabled
+}
+
+func (f *FeatureGate) knownFeatures() []string {
+	var known []string
+	for k, v := range f.known {
+		if v.PreRelease == GA {
+			continue
+		}
+		known = append(known, fmt.Sprintf("%s=true|false (%s - default=%t)", k, v.PreRelease, v.Default))
+	}
+	sort.Strings(known)
+	return known
+}
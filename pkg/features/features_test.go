@@ -0,0 +1,54 @@
+package features
+
+import "testing"
+
+func TestFeatureGateSet(t *testing.T) {
+	known := map[Feature]FeatureSpec{
+		"AlphaFeature": {Default: false, PreRelease: Alpha},
+		"BetaFeature":  {Default: true, PreRelease: Beta},
+		"GAFeature":    {Default: true, PreRelease: GA},
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+		want    map[Feature]bool
+	}{
+		{"defaults", "", false, map[Feature]bool{"AlphaFeature": false, "BetaFeature": true, "GAFeature": true}},
+		{"enable alpha", "AlphaFeature=true", false, map[Feature]bool{"AlphaFeature": true, "BetaFeature": true}},
+		{"disable beta", "AlphaFeature=true, BetaFeature=false", false, map[Feature]bool{"AlphaFeature": true, "BetaFeature": false}},
+		{"unknown feature", "Unknown=true", true, nil},
+		{"missing value", "AlphaFeature", true, nil},
+		{"invalid value", "AlphaFeature=yes", true, nil},
+		{"disable GA", "GAFeature=false", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFeatureGate(known)
+			err := f.Set(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for k, v := range tt.want {
+				if f.Enabled(k) != v {
+					t.Errorf("Enabled(%s) = %t, want %t", k, f.Enabled(k), v)
+				}
+			}
+		})
+	}
+}
+
+func TestEnabledFeatures(t *testing.T) {
+	f := NewFeatureGate(map[Feature]FeatureSpec{
+		"AlphaFeature": {Default: false, PreRelease: Alpha},
+		"BetaFeature":  {Default: true, PreRelease: Beta},
+		"OtherFeature": {Default: false, PreRelease: Alpha},
+	})
+	if err := f.Set("OtherFeature=true,BetaFeature=false"); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.EnabledFeatures(); len(got) != 1 || got[0] != "OtherFeature" {
+		t.Errorf("EnabledFeatures() = %v, want [OtherFeature]", got)
+	}
+}
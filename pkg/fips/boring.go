@@ -0,0 +1,10 @@
+//go:build goexperiment.boringcrypto
+
+package fips
+
+import "crypto/boring"
+
+// boringEnabled returns true if BoringCrypto is being used for the crypto primitives
+func boringEnabled() bool {
+	return boring.Enabled()
+}
@@ -0,0 +1,54 @@
+// Package fips restricts the cryptography used by kube-vip to FIPS 140 validated primitives
+package fips
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync/atomic"
+)
+
+// enabled is set once at startup, when FIPS mode has been requested
+var enabled atomic.Bool
+
+// cipherSuites are the FIPS approved TLS 1.2 cipher suites
+var cipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// curves are the FIPS approved curves for key exchange, X25519 is not approved
+var curves = []tls.CurveID{tls.CurveP256, tls.CurveP384}
+
+// Built returns true if kube-vip has been built with a FIPS validated crypto module (BoringCrypto)
+func Built() bool {
+	return boringEnabled()
+}
+
+// Enable will turn on FIPS mode, this is only possible when kube-vip has been built with a FIPS validated crypto module
+func Enable() error {
+	if !Built() {
+		return fmt.Errorf("FIPS mode requires kube-vip to be built with GOEXPERIMENT=boringcrypto (make build-fips)")
+	}
+	enabled.Store(true)
+	return nil
+}
+
+// Enabled returns true if FIPS mode has been turned on
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// RestrictTLS will limit a TLS configuration to the FIPS approved version, cipher suites and curves, it does nothing
+// unless FIPS mode has been turned on
+func RestrictTLS(cfg *tls.Config) {
+	if !Enabled() {
+		return
+	}
+	// The TLS 1.3 cipher suites can't be configured, so TLS 1.2 is used to guarantee an approved cipher suite
+	cfg.MinVersion = tls.VersionTLS12
+	cfg.MaxVersion = tls.VersionTLS12
+	cfg.CipherSuites = cipherSuites
+	cfg.CurvePreferences = curves
+}
@@ -0,0 +1,32 @@
+package fips
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestEnable(t *testing.T) {
+	err := Enable()
+	if Built() && err != nil {
+		t.Errorf("Enable() error = %v, kube-vip has been built with BoringCrypto", err)
+	}
+	if !Built() && err == nil {
+		t.Errorf("Enable() expected an error, kube-vip hasn't been built with BoringCrypto")
+	}
+}
+
+func TestRestrictTLS(t *testing.T) {
+	enabled.Store(false)
+	cfg := &tls.Config{MinVersion: tls.VersionTLS13}
+	RestrictTLS(cfg)
+	if cfg.MinVersion != tls.VersionTLS13 || cfg.CipherSuites != nil {
+		t.Errorf("RestrictTLS() changed the configuration when FIPS mode is disabled")
+	}
+
+	enabled.Store(true)
+	defer enabled.Store(false)
+	RestrictTLS(cfg)
+	if cfg.MaxVersion != tls.VersionTLS12 || len(cfg.CipherSuites) != len(cipherSuites) || len(cfg.CurvePreferences) != len(curves) {
+		t.Errorf("RestrictTLS() didn't restrict the configuration when FIPS mode is enabled")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !goexperiment.boringcrypto
+
+package fips
+
+// boringEnabled always returns false, kube-vip hasn't been built with BoringCrypto
+func boringEnabled() bool {
+	return false
+}
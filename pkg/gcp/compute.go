@@ -0,0 +1,195 @@
+package gcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// computeURL is the endpoint of the Compute Engine API
+var computeURL = "https://compute.googleapis.com/compute/v1"
+
+// routePriority is the priority of the route of the VIP, the default priority of the routes of a VPC
+const routePriority = 1000
+
+// Client points the route of the VIP in the VPC at this instance with the Compute Engine API. GCP ignores gratuitous
+// ARP and has no floating IPs, the VPC only delivers the traffic of the VIP to the instance that its route points at
+// (which needs IP forwarding enabled to accept it).
+type Client struct {
+	project  string
+	network  string
+	instance string
+	// routeName is the name of the route of the VIP, it is derived from the VIP when it is empty
+	routeName string
+
+	client   *http.Client
+	metadata *metadata
+}
+
+// route is the subset of a VPC route that kube-vip manages
+type route struct {
+	Name            string `json:"name"`
+	Network         string `json:"network"`
+	DestRange       string `json:"destRange"`
+	NextHopInstance string `json:"nextHopInstance"`
+	Priority        int    `json:"priority"`
+	Description     string `json:"description,omitempty"`
+}
+
+// operation is the long-running operation that changes a route
+type operation struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  *struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"error"`
+}
+
+// NewClient returns the client of this instance, the project, zone, instance and network are read from the metadata
+// server
+func NewClient(k *kubevip.Config) (*Client, error) {
+	c := &Client{
+		routeName: k.GCPRouteName,
+		client:    &http.Client{Timeout: 2 * time.Minute},
+		metadata:  newMetadata(),
+	}
+	var err error
+	if c.project, err = c.metadata.get("project/project-id"); err != nil {
+		return nil, err
+	}
+	zone, err := c.metadata.last("instance/zone")
+	if err != nil {
+		return nil, err
+	}
+	name, err := c.metadata.get("instance/name")
+	if err != nil {
+		return nil, err
+	}
+	c.instance = fmt.Sprintf("projects/%s/zones/%s/instances/%s", c.project, zone, name)
+	network := k.GCPNetwork
+	if network == "" {
+		if network, err = c.metadata.last("instance/network-interfaces/0/network"); err != nil {
+			return nil, err
+		}
+	}
+	c.network = fmt.Sprintf("projects/%s/global/networks/%s", c.project, network)
+	return c, nil
+}
+
+// AssignVIP points the route of the VIP at this instance. The routes can't be changed, the route that points at the
+// previous leader is deleted and created again.
+func (c *Client) AssignVIP(vip string) error {
+	name := c.routeName
+	if name == "" {
+		name = RouteName(vip)
+	}
+	var existing route
+	found, err := c.do(http.MethodGet, "global/routes/"+name, nil, &existing)
+	if err != nil {
+		return err
+	}
+	if found {
+		if strings.HasSuffix(existing.NextHopInstance, c.instance) && existing.DestRange == vip+"/32" {
+			log.Infof("[GCP] the route [%s] of the VIP [%s] already points at this instance", name, vip)
+			return nil
+		}
+		log.Infof("[GCP] deleting the route [%s] that points at [%s]", name, path.Base(existing.NextHopInstance))
+		if err := c.wait(http.MethodDelete, "global/routes/"+name, nil); err != nil {
+			return err
+		}
+	}
+
+	log.Infof("[GCP] creating the route [%s] of the VIP [%s] to this instance", name, vip)
+	return c.wait(http.MethodPost, "global/routes", &route{
+		Name:            name,
+		Network:         c.network,
+		DestRange:       vip + "/32",
+		NextHopInstance: c.instance,
+		Priority:        routePriority,
+		Description:     "The VIP of kube-vip, it points at the leader",
+	})
+}
+
+// RouteName returns the name of the route of the VIP when none is configured
+func RouteName(vip string) string {
+	return "kube-vip-" + strings.ReplaceAll(vip, ".", "-")
+}
+
+// wait sends the request that starts an operation, and waits for the operation to be done
+func (c *Client) wait(method, resource string, body any) error {
+	var op operation
+	found, err := c.do(method, resource, body, &op)
+	if err != nil {
+		return err
+	}
+	if !found {
+		// The route has already been deleted
+		return nil
+	}
+	for op.Status != "DONE" {
+		if _, err := c.do(http.MethodPost, "global/operations/"+op.Name+"/wait", nil, &op); err != nil {
+			return err
+		}
+	}
+	if op.Error != nil && len(op.Error.Errors) != 0 {
+		return fmt.Errorf("%s %s failed: %s: %s", method, resource, op.Error.Errors[0].Code, op.Error.Errors[0].Message)
+	}
+	return nil
+}
+
+// do sends the request about the resource of the project, and decodes its response into the result. It returns false
+// when the resource doesn't exist.
+func (c *Client) do(method, resource string, body, result any) (bool, error) {
+	token, err := c.metadata.accessToken()
+	if err != nil {
+		return false, err
+	}
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return false, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/projects/%s/%s", computeURL, c.project, resource), reader)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Error.Message == "" {
+			return false, fmt.Errorf("%s %s failed with status [%s]", method, resource, resp.Status)
+		}
+		return false, fmt.Errorf("%s %s failed: %s", method, resource, apiErr.Error.Message)
+	}
+	return true, json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,147 @@
+package gcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// fakeGCP answers the metadata of the instance node1, and the calls of the Compute Engine API about the routes
+type fakeGCP struct {
+	mu     sync.Mutex
+	routes map[string]route
+	calls  []string
+}
+
+func (f *fakeGCP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if strings.HasPrefix(r.URL.Path, "/computeMetadata/v1/") {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		metadata := map[string]string{
+			"project/project-id":                      "my-project",
+			"instance/name":                           "node1",
+			"instance/zone":                           "projects/123/zones/europe-west1-b",
+			"instance/network-interfaces/0/network":   "projects/123/networks/default",
+			"instance/service-accounts/default/token": `{"access_token":"token","expires_in":3600,"token_type":"Bearer"}`,
+		}
+		value, exists := metadata[strings.TrimPrefix(r.URL.Path, "/computeMetadata/v1/")]
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(value))
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	resource := strings.TrimPrefix(r.URL.Path, "/compute/v1/projects/my-project/")
+	f.calls = append(f.calls, r.Method+" "+resource)
+	var body any
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(resource, "global/routes/"):
+		existing, exists := f.routes[strings.TrimPrefix(resource, "global/routes/")]
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		body = existing
+	case r.Method == http.MethodDelete && strings.HasPrefix(resource, "global/routes/"):
+		delete(f.routes, strings.TrimPrefix(resource, "global/routes/"))
+		body = operation{Name: "delete", Status: "RUNNING"}
+	case r.Method == http.MethodPost && resource == "global/routes":
+		var created route
+		_ = json.NewDecoder(r.Body).Decode(&created)
+		f.routes[created.Name] = created
+		body = operation{Name: "insert", Status: "DONE"}
+	case r.Method == http.MethodPost && strings.HasPrefix(resource, "global/operations/"):
+		body = operation{Name: "delete", Status: "DONE"}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func newTestClient(t *testing.T, f *fakeGCP, k *kubevip.Config) *Client {
+	t.Helper()
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	previousMetadata, previousCompute := metadataURL, computeURL
+	metadataURL, computeURL = server.URL, server.URL+"/compute/v1"
+	t.Cleanup(func() { metadataURL, computeURL = previousMetadata, previousCompute })
+
+	c, err := NewClient(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestAssignVIP(t *testing.T) {
+	f := &fakeGCP{routes: map[string]route{
+		"kube-vip-10-128-0-100": {
+			Name:            "kube-vip-10-128-0-100",
+			DestRange:       "10.128.0.100/32",
+			NextHopInstance: "https://www.googleapis.com/compute/v1/projects/my-project/zones/europe-west1-c/instances/node2",
+		},
+	}}
+	c := newTestClient(t, f, &kubevip.Config{})
+
+	// The route to the previous leader is replaced
+	if err := c.AssignVIP("10.128.0.100"); err != nil {
+		t.Fatal(err)
+	}
+	want := route{
+		Name:            "kube-vip-10-128-0-100",
+		Network:         "projects/my-project/global/networks/default",
+		DestRange:       "10.128.0.100/32",
+		NextHopInstance: "projects/my-project/zones/europe-west1-b/instances/node1",
+		Priority:        routePriority,
+		Description:     "The VIP of kube-vip, it points at the leader",
+	}
+	if got := f.routes[want.Name]; got != want {
+		t.Errorf("route = %+v, want %+v", got, want)
+	}
+	wantCalls := []string{
+		"GET global/routes/kube-vip-10-128-0-100",
+		"DELETE global/routes/kube-vip-10-128-0-100",
+		"POST global/operations/delete/wait",
+		"POST global/routes",
+	}
+	if strings.Join(f.calls, "\n") != strings.Join(wantCalls, "\n") {
+		t.Errorf("calls = %q, want %q", f.calls, wantCalls)
+	}
+
+	// The route that already points at this instance is left alone
+	f.calls = nil
+	if err := c.AssignVIP("10.128.0.100"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.calls) != 1 {
+		t.Errorf("calls = %q, want only the lookup of the route", f.calls)
+	}
+}
+
+func TestAssignVIPRouteName(t *testing.T) {
+	f := &fakeGCP{routes: map[string]route{}}
+	c := newTestClient(t, f, &kubevip.Config{GCPRouteName: "control-plane", GCPNetwork: "vpc"})
+	if err := c.AssignVIP("10.128.0.100"); err != nil {
+		t.Fatal(err)
+	}
+	got, exists := f.routes["control-plane"]
+	if !exists || got.Network != "projects/my-project/global/networks/vpc" {
+		t.Errorf("routes = %+v, want the route control-plane in the network vpc", f.routes)
+	}
+}
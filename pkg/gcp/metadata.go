@@ -0,0 +1,84 @@
+package gcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metadataURL is the metadata server of the Compute Engine instances
+var metadataURL = "http://metadata.google.internal"
+
+// metadata reads the metadata of the instance, and the access token of its service account
+type metadata struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func newMetadata() *metadata {
+	return &metadata{client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// get returns the value of the metadata path, relative to /computeMetadata/v1/
+func (m *metadata) get(p string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, metadataURL+"/computeMetadata/v1/"+p, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to reach the metadata server: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to read the instance metadata [%s], status [%s]", p, resp.Status)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// last returns the name at the end of a metadata path, the zone of projects/123/zones/europe-west1-b for example
+func (m *metadata) last(p string) (string, error) {
+	value, err := m.get(p)
+	if err != nil {
+		return "", err
+	}
+	return path.Base(value), nil
+}
+
+// accessToken returns the access token of the service account of the instance, a new one is requested before the last
+// one expires
+func (m *metadata) accessToken() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.token != "" && time.Now().Before(m.expires) {
+		return m.token, nil
+	}
+	document, err := m.get("instance/service-accounts/default/token")
+	if err != nil {
+		return "", err
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal([]byte(document), &token); err != nil {
+		return "", fmt.Errorf("unable to read the access token of the service account: %v", err)
+	}
+	m.token = token.AccessToken
+	// The token is renewed a minute before it expires
+	m.expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return m.token, nil
+}
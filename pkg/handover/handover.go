@@ -0,0 +1,85 @@
+package handover
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// State is what a kube-vip that is shutting down for an upgrade hands over to the kube-vip that replaces it on the
+// node, so that the VIPs stay on the node rather than being removed and added again
+type State struct {
+	// Node is the node that the state was written on, a state from another node (e.g. a host that was rebuilt with
+	// the same disk) is ignored
+	Node string `json:"node"`
+	// Lease is the lease that the previous kube-vip held, it isn't released so that its replacement renews it
+	Lease   string    `json:"lease"`
+	Written time.Time `json:"written"`
+	VIPs    []VIP     `json:"vips"`
+}
+
+// VIP is an address that is left on an interface for the replacement
+type VIP struct {
+	Address   string `json:"address"`
+	Interface string `json:"interface"`
+	// DHCP is set when the address is leased from DHCP, the replacement requests the same address again
+	DHCP bool `json:"dhcp,omitempty"`
+}
+
+// ServicesPath returns the file that the VIPs of the services are handed over in, next to the one of the control plane
+// (e.g. /var/run/kube-vip/handover-services.json), they are handed over by the manager rather than by the cluster
+func ServicesPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-services" + ext
+}
+
+// Write saves the state for the replacement, the file is replaced in one step so that a partial state is never read
+func Write(path string, state *State) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("unable to create the directory of the handover state: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("unable to write the handover state: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("unable to write the handover state: %v", err)
+	}
+	return nil
+}
+
+// Take reads the state that the previous kube-vip handed over and removes it, so that it is only taken once. It
+// returns nil if nothing was handed over, and an error if the state is from another node or older than maxAge (the
+// lease that it was written under has expired, so another node may hold the VIPs by now).
+func Take(path, node string, maxAge time.Duration, now time.Time) (*State, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the handover state: %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, fmt.Errorf("unable to remove the handover state: %v", err)
+	}
+
+	state := &State{}
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, fmt.Errorf("unable to parse the handover state: %v", err)
+	}
+	if state.Node != node {
+		return nil, fmt.Errorf("the handover state is from node [%s]", state.Node)
+	}
+	if age := now.Sub(state.Written); age > maxAge {
+		return nil, fmt.Errorf("the handover state is %s old, the lease has expired", age.Round(time.Second))
+	}
+	return state, nil
+}
@@ -0,0 +1,75 @@
+package handover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	state := &State{
+		Node:    "cp-0",
+		Lease:   "plndr-cp-lock",
+		Written: now.Add(-2 * time.Second),
+		VIPs:    []VIP{{Address: "192.168.0.40", Interface: "eth0"}, {Address: "192.168.0.41", Interface: "eth0", DHCP: true}},
+	}
+
+	tests := []struct {
+		name    string
+		node    string
+		maxAge  time.Duration
+		want    bool
+		wantErr bool
+	}{
+		{name: "handed over", node: "cp-0", maxAge: 5 * time.Second, want: true},
+		{name: "lease expired", node: "cp-0", maxAge: time.Second, wantErr: true},
+		{name: "other node", node: "cp-1", maxAge: 5 * time.Second, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "kube-vip", "handover.json")
+			if err := Write(path, state); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Take(path, tt.node, tt.maxAge, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Take() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got != nil) != tt.want {
+				t.Fatalf("Take() = %v, want a state %t", got, tt.want)
+			}
+			if got != nil && (len(got.VIPs) != 2 || !got.VIPs[1].DHCP || got.Lease != state.Lease) {
+				t.Errorf("Take() = %+v, want %+v", got, state)
+			}
+			// The state is only taken once, even if it isn't used
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("the state wasn't removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestTakeNothingHandedOver(t *testing.T) {
+	got, err := Take(filepath.Join(t.TempDir(), "handover.json"), "cp-0", time.Minute, time.Now())
+	if got != nil || err != nil {
+		t.Errorf("Take() = %v, %v without a state", got, err)
+	}
+}
+
+func TestServicesPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/var/run/kube-vip/handover.json", "/var/run/kube-vip/handover-services.json"},
+		{"/var/run/kube-vip/handover", "/var/run/kube-vip/handover-services"},
+	}
+	for _, tt := range tests {
+		if got := ServicesPath(tt.path); got != tt.want {
+			t.Errorf("ServicesPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// state is the health of an address, it only changes after rise probes in a row have succeeded or fall probes in a
+// row have failed so that a single probe doesn't move the VIP
+type state struct {
+	healthy   bool
+	successes int
+	failures  int
+}
+
+// record counts the result of a probe, returning true if the health of the address has changed
+func (s *state) record(ok bool, rise, fall int) bool {
+	if ok {
+		s.successes, s.failures = s.successes+1, 0
+		if !s.healthy && s.successes >= rise {
+			s.healthy = true
+			return true
+		}
+		return false
+	}
+	s.successes, s.failures = 0, s.failures+1
+	if s.healthy && s.failures >= fall {
+		s.healthy = false
+		return true
+	}
+	return false
+}
+
+// target is an address that is being probed
+type target struct {
+	state
+	cancel context.CancelFunc
+}
+
+// Checker probes a set of addresses, an address is unhealthy until it has passed the probe rise times
+type Checker struct {
+	probe Probe
+	// prober is the probe of an address, it is replaced by the tests
+	prober func(ctx context.Context, address string) error
+
+	mutex   sync.Mutex
+	targets map[string]*target
+	changed chan struct{}
+}
+
+// NewChecker creates a checker of the probe, which has to have been validated
+func NewChecker(probe Probe) *Checker {
+	return &Checker{
+		probe:   probe,
+		prober:  probe.run,
+		targets: map[string]*target{},
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// Changed is notified whenever an address becomes healthy or unhealthy, the notifications are coalesced
+func (c *Checker) Changed() <-chan struct{} {
+	return c.changed
+}
+
+// Healthy returns true if the address has passed its probes
+func (c *Checker) Healthy(address string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	t, exists := c.targets[address]
+	return exists && t.healthy
+}
+
+// Filter returns the addresses that are healthy
+func (c *Checker) Filter(addresses []string) []string {
+	var healthy []string
+	for _, address := range addresses {
+		if c.Healthy(address) {
+			healthy = append(healthy, address)
+		}
+	}
+	return healthy
+}
+
+// Set will probe the addresses until the context is cancelled, the addresses that aren't in the set are no longer
+// probed and are forgotten
+func (c *Checker) Set(ctx context.Context, addresses []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	wanted := map[string]bool{}
+	for _, address := range addresses {
+		wanted[address] = true
+		if _, exists := c.targets[address]; exists {
+			continue
+		}
+		targetCtx, cancel := context.WithCancel(ctx)
+		t := &target{cancel: cancel}
+		c.targets[address] = t
+		go c.run(targetCtx, address, t)
+	}
+	for address, t := range c.targets {
+		if !wanted[address] {
+			t.cancel()
+			delete(c.targets, address)
+		}
+	}
+}
+
+// Stop stops probing every address
+func (c *Checker) Stop() {
+	c.Set(context.Background(), nil)
+}
+
+func (c *Checker) run(ctx context.Context, address string, t *target) {
+	ticker := time.NewTicker(c.probe.Interval)
+	defer ticker.Stop()
+	for {
+		err := c.prober(ctx, address)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Debugf("(healthcheck) %s probe of [%s] failed: %v", c.probe.Type, address, err)
+		}
+
+		c.mutex.Lock()
+		changed := t.record(err == nil, c.probe.Rise, c.probe.Fall)
+		healthy := t.healthy
+		c.mutex.Unlock()
+		if changed {
+			if healthy {
+				log.Infof("(healthcheck) [%s] is healthy", address)
+			} else {
+				log.Warnf("(healthcheck) [%s] is unhealthy: %v", address, err)
+			}
+			select {
+			case c.changed <- struct{}{}:
+			default:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	tests := []struct {
+		name        string
+		healthy     bool
+		results     []bool
+		wantHealthy bool
+		wantChanged []bool
+	}{
+		{"rises after two successes", false, []bool{true, true}, true, []bool{false, true}},
+		{"a failure restarts the rise", false, []bool{true, false, true}, false, []bool{false, false, false}},
+		{"falls after three failures", true, []bool{false, false, false}, false, []bool{false, false, true}},
+		{"a success restarts the fall", true, []bool{false, false, true, false}, true, []bool{false, false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &state{healthy: tt.healthy}
+			for i, ok := range tt.results {
+				if changed := s.record(ok, 2, 3); changed != tt.wantChanged[i] {
+					t.Errorf("record(%t) #%d changed = %t, want %t", ok, i, changed, tt.wantChanged[i])
+				}
+			}
+			if s.healthy != tt.wantHealthy {
+				t.Errorf("healthy = %t, want %t", s.healthy, tt.wantHealthy)
+			}
+		})
+	}
+}
+
+func TestChecker(t *testing.T) {
+	var failing atomic.Bool
+	c := NewChecker(Probe{Type: TCP, Port: 80, Interval: 5 * time.Millisecond, Timeout: time.Millisecond, Rise: 2, Fall: 2})
+	c.prober = func(_ context.Context, address string) error {
+		if address == "10.0.0.2" || failing.Load() {
+			return errors.New("refused")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.Set(ctx, []string{"10.0.0.1", "10.0.0.2"})
+	if healthy := c.Filter([]string{"10.0.0.1", "10.0.0.2"}); len(healthy) != 0 {
+		t.Errorf("Filter() = %v before any probe has passed", healthy)
+	}
+
+	select {
+	case <-c.Changed():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the address didn't become healthy")
+	}
+	if healthy := c.Filter([]string{"10.0.0.1", "10.0.0.2"}); len(healthy) != 1 || healthy[0] != "10.0.0.1" {
+		t.Errorf("Filter() = %v, want [10.0.0.1]", healthy)
+	}
+
+	failing.Store(true)
+	select {
+	case <-c.Changed():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the address didn't become unhealthy")
+	}
+	if c.Healthy("10.0.0.1") {
+		t.Error("the failing address is still healthy")
+	}
+
+	// Addresses that are no longer set are forgotten
+	c.Stop()
+	if len(c.targets) != 0 {
+		t.Errorf("%d addresses are still probed", len(c.targets))
+	}
+}
@@ -0,0 +1,132 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Type is the protocol that an address is probed with
+type Type string
+
+const (
+	// TCP probes are healthy when a connection to the port can be opened
+	TCP = Type("tcp")
+	// HTTP probes are healthy when a GET of the path answers with a 2xx or 3xx status
+	HTTP = Type("http")
+	// GRPC probes are healthy when the gRPC health service answers that the service is serving
+	GRPC = Type("grpc")
+)
+
+const (
+	// DefaultInterval is how often an address is probed
+	DefaultInterval = 5 * time.Second
+	// DefaultTimeout is how long a probe can take before it fails
+	DefaultTimeout = 2 * time.Second
+	// DefaultRise is how many probes in a row have to succeed for an address to become healthy
+	DefaultRise = 2
+	// DefaultFall is how many probes in a row have to fail for an address to become unhealthy
+	DefaultFall = 3
+)
+
+// Probe defines how the addresses are checked
+type Probe struct {
+	Type Type
+	Port int
+	// Path is the path of HTTP probes, or the name of the service of gRPC probes (empty for the whole server)
+	Path     string
+	Interval time.Duration
+	Timeout  time.Duration
+	Rise     int
+	Fall     int
+}
+
+// Validate will ensure that the probe can be run, the unset durations and counts are defaulted
+func (p *Probe) Validate() error {
+	switch p.Type {
+	case TCP, HTTP, GRPC:
+	default:
+		return fmt.Errorf("unknown health check [%s], use tcp, http or grpc", p.Type)
+	}
+	if p.Port < 1 || p.Port > 65535 {
+		return fmt.Errorf("the port [%d] of the health check is invalid", p.Port)
+	}
+	if p.Type == HTTP && p.Path == "" {
+		p.Path = "/"
+	}
+	if p.Interval == 0 {
+		p.Interval = DefaultInterval
+	}
+	if p.Timeout == 0 {
+		p.Timeout = DefaultTimeout
+	}
+	if p.Rise == 0 {
+		p.Rise = DefaultRise
+	}
+	if p.Fall == 0 {
+		p.Fall = DefaultFall
+	}
+	if p.Interval < 0 || p.Timeout < 0 || p.Rise < 0 || p.Fall < 0 {
+		return fmt.Errorf("the interval, timeout, rise and fall of the health check can't be negative")
+	}
+	if p.Timeout > p.Interval {
+		return fmt.Errorf("the timeout [%s] of the health check is longer than its interval [%s]", p.Timeout, p.Interval)
+	}
+	return nil
+}
+
+// run probes the address once, an error is returned if it is unhealthy
+func (p *Probe) run(ctx context.Context, address string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+	target := net.JoinHostPort(address, strconv.Itoa(p.Port))
+
+	switch p.Type {
+	case HTTP:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+target+p.Path, nil)
+		if err != nil {
+			return err
+		}
+		client := &http.Client{
+			// A redirect is an answer from the backend, wherever it points to
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	case GRPC:
+		conn, err := grpc.DialContext(ctx, target, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: p.Path})
+		if err != nil {
+			return err
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("status %s", resp.Status)
+		}
+		return nil
+	default:
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", target)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
@@ -0,0 +1,106 @@
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       Probe
+		want    Probe
+		wantErr bool
+	}{
+		{"defaults", Probe{Type: HTTP, Port: 8080}, Probe{Type: HTTP, Port: 8080, Path: "/", Interval: DefaultInterval, Timeout: DefaultTimeout, Rise: DefaultRise, Fall: DefaultFall}, false},
+		{"grpc server", Probe{Type: GRPC, Port: 9090, Rise: 1, Fall: 1}, Probe{Type: GRPC, Port: 9090, Interval: DefaultInterval, Timeout: DefaultTimeout, Rise: 1, Fall: 1}, false},
+		{"unknown type", Probe{Type: "icmp", Port: 80}, Probe{}, true},
+		{"no port", Probe{Type: TCP}, Probe{}, true},
+		{"timeout longer than the interval", Probe{Type: TCP, Port: 80, Interval: time.Second, Timeout: 2 * time.Second}, Probe{}, true},
+		{"negative rise", Probe{Type: TCP, Port: 80, Rise: -1}, Probe{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.p != tt.want {
+				t.Errorf("Validate() = %+v, want %+v", tt.p, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	// A closed listener gives a port that refuses connections
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcpPort := l.Addr().(*net.TCPAddr).Port
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+	defer l.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://192.0.2.1/", http.StatusFound)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) })
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	httpPort := server.Listener.Addr().(*net.TCPAddr).Port
+
+	g, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("backend", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("draining", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go func() { _ = grpcServer.Serve(g) }()
+	defer grpcServer.Stop()
+	grpcPort := g.Addr().(*net.TCPAddr).Port
+
+	tests := []struct {
+		name    string
+		p       Probe
+		healthy bool
+	}{
+		{"tcp", Probe{Type: TCP, Port: tcpPort}, true},
+		{"tcp refused", Probe{Type: TCP, Port: closedPort}, false},
+		{"http", Probe{Type: HTTP, Port: httpPort, Path: "/healthz"}, true},
+		{"http redirect", Probe{Type: HTTP, Port: httpPort, Path: "/moved"}, true},
+		{"http error", Probe{Type: HTTP, Port: httpPort, Path: "/broken"}, false},
+		{"grpc", Probe{Type: GRPC, Port: grpcPort, Path: "backend"}, true},
+		{"grpc not serving", Probe{Type: GRPC, Port: grpcPort, Path: "draining"}, false},
+		{"grpc unknown service", Probe{Type: GRPC, Port: grpcPort, Path: "unknown"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.p.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			err := tt.p.run(context.Background(), "127.0.0.1")
+			if (err == nil) != tt.healthy {
+				t.Errorf("run() error = %v, want healthy %t", err, tt.healthy)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package httptls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/fips"
+	"github.com/kube-vip/kube-vip/pkg/utils"
+)
+
+// Certificates holds the serving certificate and (optional) client CA for the local HTTP endpoints, these can be
+// replaced at any time so that rotated certificates are used without a restart
+type Certificates struct {
+	mutex     sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// Load will parse and replace the certificates, an empty CA disables client certificate authentication
+func (c *Certificates) Load(certPEM, keyPEM, caPEM []byte) error {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("unable to parse certificate: %v", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if len(caPEM) != 0 {
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("unable to parse client CA, no certificates found")
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.cert = &cert
+	c.clientCAs = clientCAs
+	return nil
+}
+
+// LoadFiles will load the certificates from files, the CA file is optional
+func (c *Certificates) LoadFiles(certFile, keyFile, caFile string) error {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return err
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	var caPEM []byte
+	if caFile != "" {
+		caPEM, err = os.ReadFile(caFile)
+		if err != nil {
+			return err
+		}
+	}
+	return c.Load(certPEM, keyPEM, caPEM)
+}
+
+// ApplySecret will load the certificates from a TLS secret (tls.crt, tls.key and optionally ca.crt)
+func (c *Certificates) ApplySecret(s *v1.Secret) error {
+	return c.Load(s.Data[v1.TLSCertKey], s.Data[v1.TLSPrivateKeyKey], s.Data[v1.ServiceAccountRootCAKey])
+}
+
+// WatchFiles will reload the certificates whenever one of the files changes, mounted secrets are updated in place
+// by the kubelet when they are rotated
+func (c *Certificates) WatchFiles(ctx context.Context, certFile, keyFile, caFile string, interval time.Duration) {
+	files := []string{certFile, keyFile}
+	if caFile != "" {
+		files = append(files, caFile)
+	}
+	utils.WatchFiles(ctx, files, interval, func() error {
+		if err := c.LoadFiles(certFile, keyFile, caFile); err != nil {
+			return err
+		}
+		log.Info("(tls) certificates have changed, reloaded")
+		return nil
+	})
+}
+
+// TLSConfig returns a TLS configuration that always uses the current certificates, client certificates are
+// required when a client CA has been loaded
+func (c *Certificates) TLSConfig() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c.mutex.RLock()
+			defer c.mutex.RUnlock()
+			if c.cert == nil {
+				return nil, fmt.Errorf("no certificate has been loaded")
+			}
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*c.cert},
+			}
+			if c.clientCAs != nil {
+				config.ClientCAs = c.clientCAs
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			fips.RestrictTLS(config)
+			return config, nil
+		},
+	}
+	fips.RestrictTLS(cfg)
+	return cfg
+}
+
+// ClientTLSConfig returns a TLS configuration for connecting to a server that presents a certificate for the server
+// name, signed by the loaded CA. The current certificate is presented as the client certificate.
+func (c *Certificates) ClientTLSConfig(serverName string) (*tls.Config, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if c.cert == nil || c.clientCAs == nil {
+		return nil, fmt.Errorf("no certificate and CA have been loaded")
+	}
+	cert := *c.cert
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      c.clientCAs,
+		ServerName:   serverName,
+	}
+	fips.RestrictTLS(cfg)
+	return cfg, nil
+}
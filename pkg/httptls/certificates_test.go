@@ -0,0 +1,181 @@
+package httptls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newCertificate creates a certificate for 127.0.0.1 that can be used by both servers and clients, it is signed by
+// the parent or is self signed as a CA
+func newCertificate(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestLoad(t *testing.T) {
+	ca, caKey, caPEM, _ := newCertificate(t, "kube-vip-ca", nil, nil)
+	_, _, certPEM, keyPEM := newCertificate(t, "kube-vip", ca, caKey)
+	_, _, _, otherKeyPEM := newCertificate(t, "other", ca, caKey)
+
+	tests := []struct {
+		name          string
+		cert, key, ca []byte
+		wantClientCAs bool
+		wantErr       bool
+	}{
+		{name: "certificate", cert: certPEM, key: keyPEM},
+		{name: "certificate and client CA", cert: certPEM, key: keyPEM, ca: caPEM, wantClientCAs: true},
+		{name: "key of another certificate", cert: certPEM, key: otherKeyPEM, wantErr: true},
+		{name: "no certificate", key: keyPEM, wantErr: true},
+		{name: "invalid client CA", cert: certPEM, key: keyPEM, ca: []byte("not a certificate"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Certificates{}
+			err := c.Load(tt.cert, tt.key, tt.ca)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if c.cert != nil {
+					t.Error("Load() replaced the certificates with invalid ones")
+				}
+				return
+			}
+			if (c.clientCAs != nil) != tt.wantClientCAs {
+				t.Errorf("client CAs loaded = %v, want %v", c.clientCAs != nil, tt.wantClientCAs)
+			}
+		})
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	ca, caKey, caPEM, _ := newCertificate(t, "kube-vip-ca", nil, nil)
+	_, _, certPEM, keyPEM := newCertificate(t, "kube-vip", ca, caKey)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	tests := []struct {
+		name         string
+		load         bool
+		clientCA     bool
+		clientCert   bool
+		wantResponse bool
+	}{
+		{name: "TLS", load: true, wantResponse: true},
+		{name: "TLS with a client certificate", load: true, clientCert: true, wantResponse: true},
+		{name: "mTLS", load: true, clientCA: true, clientCert: true, wantResponse: true},
+		{name: "mTLS without a client certificate", load: true, clientCA: true},
+		{name: "no certificate loaded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Certificates{}
+			if tt.load {
+				var clientCA []byte
+				if tt.clientCA {
+					clientCA = caPEM
+				}
+				if err := c.Load(certPEM, keyPEM, clientCA); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			server.TLS = c.TLSConfig()
+			server.StartTLS()
+			defer server.Close()
+
+			clientConfig := &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: roots}
+			if tt.clientCert {
+				cert, err := tls.X509KeyPair(certPEM, keyPEM)
+				if err != nil {
+					t.Fatal(err)
+				}
+				clientConfig.Certificates = []tls.Certificate{cert}
+			}
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}, Timeout: 5 * time.Second}
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err == nil) != tt.wantResponse {
+				t.Errorf("GET error = %v, want a response %v", err, tt.wantResponse)
+			}
+		})
+	}
+}
+
+func TestClientTLSConfig(t *testing.T) {
+	ca, caKey, caPEM, _ := newCertificate(t, "kube-vip-ca", nil, nil)
+	_, _, certPEM, keyPEM := newCertificate(t, "kube-vip", ca, caKey)
+
+	tests := []struct {
+		name    string
+		ca      []byte
+		wantErr bool
+	}{
+		{name: "certificate and CA", ca: caPEM},
+		{name: "no CA", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Certificates{}
+			if err := c.Load(certPEM, keyPEM, tt.ca); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := c.ClientTLSConfig("kube-vip")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClientTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (cfg.ServerName != "kube-vip" || len(cfg.Certificates) != 1 || cfg.RootCAs == nil) {
+				t.Errorf("ClientTLSConfig() = %+v, want the server name, certificate and CA", cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package ipam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+// List will retrieve the KubeVipIPPool resources of every namespace from the cluster
+func List(ctx context.Context, clientSet *kubernetes.Clientset) ([]KubeVipIPPool, error) {
+	b, err := clientSet.CoreV1().RESTClient().Get().
+		AbsPath("/apis", Group, Version, Resource).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list KubeVipIPPoolList
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, fmt.Errorf("unable to parse ip pools: %v", err)
+	}
+	return list.Items, nil
+}
+
+// updateStatus will write the status of the pool, it fails with a conflict if the pool has been modified since it
+// was read so that two nodes can't allocate the same address
+func updateStatus(ctx context.Context, clientSet *kubernetes.Clientset, p *KubeVipIPPool) error {
+	p.APIVersion, p.Kind = Group+"/"+Version, Kind
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = clientSet.CoreV1().RESTClient().Put().
+		AbsPath("/apis", Group, Version, "namespaces", p.Namespace, Resource, p.Name, "status").
+		SetHeader("Content-Type", "application/json").
+		Body(b).
+		DoRaw(ctx)
+	return err
+}
+
+// Controller allocates the addresses of the services from the pools, the allocations of this node are made one at
+// a time and those of other nodes are detected by the resource version of the pools
+type Controller struct {
+	mutex     sync.Mutex
+	clientSet *kubernetes.Clientset
+
+	// global is the namespace of the pools that are used by the namespaces without pools
+	global string
+	// namespace is the namespace of the services that are known, all of them if empty
+	namespace string
+}
+
+// NewController creates a controller for the pools of the cluster
+func NewController(clientSet *kubernetes.Clientset, global, namespace string) *Controller {
+	return &Controller{clientSet: clientSet, global: global, namespace: namespace}
+}
+
+// pools lists and parses the pools, invalid pools are logged and skipped
+func (c *Controller) pools(ctx context.Context) ([]pool, error) {
+	items, err := List(ctx, c.clientSet)
+	if errors.IsNotFound(err) {
+		return nil, fmt.Errorf("the %s resource isn't installed", Kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to list ip pools: %v", err)
+	}
+	pools, err := parsePools(items)
+	if err != nil {
+		log.Errorf("(ipam) %v", err)
+	}
+	return pools, nil
+}
+
+// Allocate will allocate an address of each family of the service from the pools of its namespace, services are the
+// addresses of every service (by namespace/name) so that addresses that are already used are skipped
+func (c *Controller) Allocate(ctx context.Context, svc *v1.Service, services map[string][]string) ([]string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	pools, err := c.pools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidates := namespacePools(pools, c.global, svc.Namespace)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no ip pool in the namespace [%s] or [%s]", svc.Namespace, c.global)
+	}
+
+	service := ServiceKey(svc)
+	used := usedAddresses(pools, services)
+	wanted, required := families(svc)
+	var addresses []string
+	changed := map[*pool]bool{}
+	for _, family := range wanted {
+		p, address, found := allocate(candidates, service, family, used)
+		if !found {
+			if required {
+				return nil, fmt.Errorf("no %s address left in the ip pools for [%s]", family, service)
+			}
+			continue
+		}
+		used[address] = service
+		addresses = append(addresses, address.String())
+
+		allocation := Allocation{Service: service, Address: address.String()}
+		exists := false
+		for _, existing := range p.Status.Allocations {
+			exists = exists || existing == allocation
+		}
+		if !exists {
+			p.Status.Allocations = append(p.Status.Allocations, allocation)
+			changed[p] = true
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no address left in the ip pools for [%s]", service)
+	}
+
+	for p := range changed {
+		if err := updateStatus(ctx, c.clientSet, p.KubeVipIPPool); err != nil {
+			return nil, fmt.Errorf("unable to record the allocation of [%s] in the ip pool [%s/%s]: %v", service, p.Namespace, p.Name, err)
+		}
+	}
+	log.Infof("(ipam) allocated [%v] to [%s]", addresses, service)
+	return addresses, nil
+}
+
+// Poll will release the allocations of the services that no longer exist and record the conflicts of the pools every
+// interval, until the context is cancelled
+func (c *Controller) Poll(ctx context.Context, interval time.Duration, services func() map[string][]string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.reconcile(ctx, services())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Controller) reconcile(ctx context.Context, services map[string][]string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	pools, err := c.pools(ctx)
+	if err != nil {
+		log.Warnf("(ipam) %v", err)
+		return
+	}
+	for _, p := range reconcile(pools, services, c.namespace) {
+		for _, conflict := range p.Status.Conflicts {
+			log.Warnf("(ipam) conflict in the ip pool [%s/%s]: %s", p.Namespace, p.Name, conflict)
+		}
+		// A conflicting update is reconciled again on the next poll
+		if err := updateStatus(ctx, c.clientSet, p); err != nil {
+			log.Errorf("(ipam) unable to update the status of the ip pool [%s/%s]: %v", p.Namespace, p.Name, err)
+		}
+	}
+	log.Debugf("(ipam) reconciled [%d] ip pools", len(pools))
+}
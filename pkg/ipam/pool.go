@@ -0,0 +1,241 @@
+package ipam
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// addressRange is a range of the addresses of a pool, from first to last
+type addressRange struct {
+	first, last netip.Addr
+}
+
+func (r addressRange) contains(a netip.Addr) bool {
+	return r.first.Compare(a) <= 0 && a.Compare(r.last) <= 0
+}
+
+func (r addressRange) overlaps(o addressRange) bool {
+	return r.first.Is4() == o.first.Is4() && r.first.Compare(o.last) <= 0 && o.first.Compare(r.last) <= 0
+}
+
+// parseAddresses returns the ranges of the CIDRs and ranges of a pool
+func parseAddresses(addresses []string) ([]addressRange, error) {
+	var ranges []addressRange
+	for _, address := range addresses {
+		if address = strings.TrimSpace(address); address == "" {
+			continue
+		}
+		if first, last, found := strings.Cut(address, "-"); found {
+			start, err := netip.ParseAddr(strings.TrimSpace(first))
+			if err != nil {
+				return nil, fmt.Errorf("[%s] is not a valid range", address)
+			}
+			end, err := netip.ParseAddr(strings.TrimSpace(last))
+			if err != nil || start.Is4() != end.Is4() || end.Less(start) {
+				return nil, fmt.Errorf("[%s] is not a valid range", address)
+			}
+			ranges = append(ranges, addressRange{first: start.Unmap(), last: end.Unmap()})
+			continue
+		}
+		cidr, err := netip.ParsePrefix(address)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] is not a valid CIDR or range: %v", address, err)
+		}
+		r := addressRange{first: cidr.Masked().Addr(), last: lastAddr(cidr)}
+		// The network and broadcast addresses can't be used by a service
+		if cidr.Addr().Is4() && cidr.Bits() < 31 {
+			r.first, r.last = r.first.Next(), r.last.Prev()
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// lastAddr returns the last address of the CIDR
+func lastAddr(cidr netip.Prefix) netip.Addr {
+	b := cidr.Masked().Addr().AsSlice()
+	for i := cidr.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// ServiceKey is the namespace/name that the allocations of a service are kept under
+func ServiceKey(svc *v1.Service) string {
+	return svc.Namespace + "/" + svc.Name
+}
+
+// families returns the families of the addresses that a service needs, and whether it needs all of them (a service
+// that prefers dual-stack is fine with the families that have a pool)
+func families(svc *v1.Service) ([]v1.IPFamily, bool) {
+	families := svc.Spec.IPFamilies
+	if len(families) == 0 {
+		families = []v1.IPFamily{v1.IPv4Protocol}
+	}
+	policy := svc.Spec.IPFamilyPolicy
+	return families, policy == nil || *policy != v1.IPFamilyPolicyPreferDualStack
+}
+
+// pool is a KubeVipIPPool with its addresses already parsed
+type pool struct {
+	*KubeVipIPPool
+	ranges []addressRange
+}
+
+func (p *pool) contains(a netip.Addr) bool {
+	for _, r := range p.ranges {
+		if r.contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePools parses the addresses of the pools, pools that are invalid are skipped and returned as an error
+func parsePools(items []KubeVipIPPool) ([]pool, error) {
+	var pools []pool
+	var invalid []string
+	for x := range items {
+		ranges, err := parseAddresses(items[x].Spec.Addresses)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s/%s: %v", items[x].Namespace, items[x].Name, err))
+			continue
+		}
+		pools = append(pools, pool{KubeVipIPPool: &items[x], ranges: ranges})
+	}
+	if len(invalid) != 0 {
+		return pools, fmt.Errorf("invalid ip pools %v", invalid)
+	}
+	return pools, nil
+}
+
+// namespacePools returns the pools that the addresses of a service in the namespace are allocated from, those of the
+// namespace or the global pools if the namespace has none, ordered by name
+func namespacePools(pools []pool, global, namespace string) []*pool {
+	var selected []*pool
+	for _, ns := range []string{namespace, global} {
+		for x := range pools {
+			if pools[x].Namespace == ns {
+				selected = append(selected, &pools[x])
+			}
+		}
+		if len(selected) != 0 {
+			break
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+	return selected
+}
+
+// usedAddresses returns the service that each allocated or used address belongs to
+func usedAddresses(pools []pool, services map[string][]string) map[netip.Addr]string {
+	used := map[netip.Addr]string{}
+	for x := range pools {
+		for _, allocation := range pools[x].Status.Allocations {
+			if a, err := netip.ParseAddr(allocation.Address); err == nil {
+				used[a.Unmap()] = allocation.Service
+			}
+		}
+	}
+	for service, addresses := range services {
+		for _, address := range addresses {
+			if a, err := netip.ParseAddr(address); err == nil {
+				used[a.Unmap()] = service
+			}
+		}
+	}
+	return used
+}
+
+// allocate picks an address of the family for the service from the pools, the address that the service was allocated
+// before is kept so that it doesn't change when kube-vip restarts or the service is created again
+func allocate(pools []*pool, service string, family v1.IPFamily, used map[netip.Addr]string) (*pool, netip.Addr, bool) {
+	is4 := family == v1.IPv4Protocol
+	for _, p := range pools {
+		for _, allocation := range p.Status.Allocations {
+			a, err := netip.ParseAddr(allocation.Address)
+			if err != nil || allocation.Service != service || a.Unmap().Is4() != is4 || !p.contains(a.Unmap()) {
+				continue
+			}
+			if owner, exists := used[a.Unmap()]; !exists || owner == service {
+				return p, a.Unmap(), true
+			}
+		}
+	}
+
+	// The lowest free address, there are only as many addresses to skip as there are used addresses
+	for _, p := range pools {
+		for _, r := range p.ranges {
+			if r.first.Is4() != is4 {
+				continue
+			}
+			for a := r.first; a.IsValid() && r.contains(a); a = a.Next() {
+				if _, exists := used[a]; !exists {
+					return p, a, true
+				}
+			}
+		}
+	}
+	return nil, netip.Addr{}, false
+}
+
+// reconcile releases the allocations of the services that no longer exist or no longer use the address, and records
+// the conflicts of the pools. Only the services of the namespace are known if it isn't empty, the allocations of other
+// namespaces are left as they are. The pools whose status has changed are returned.
+func reconcile(pools []pool, services map[string][]string, namespace string) []*KubeVipIPPool {
+	var changed []*KubeVipIPPool
+	for x := range pools {
+		p := &pools[x]
+		status := Status{}
+		allocated := map[string]bool{}
+		for _, allocation := range p.Status.Allocations {
+			ns, _, _ := strings.Cut(allocation.Service, "/")
+			addresses, exists := services[allocation.Service]
+			// A service without addresses is still waiting for the allocation to be added to it
+			if (namespace == "" || ns == namespace) && (!exists || (len(addresses) != 0 && !slices.Contains(addresses, allocation.Address))) {
+				continue
+			}
+			status.Allocations = append(status.Allocations, allocation)
+			allocated[allocation.Service+"="+allocation.Address] = true
+		}
+
+		for service, addresses := range services {
+			for _, address := range addresses {
+				a, err := netip.ParseAddr(address)
+				if err == nil && p.contains(a.Unmap()) && !allocated[service+"="+address] {
+					status.Conflicts = append(status.Conflicts, fmt.Sprintf("address %s of %s isn't allocated from the pool", address, service))
+				}
+			}
+		}
+		for y := range pools {
+			if x != y && overlaps(p, &pools[y]) {
+				status.Conflicts = append(status.Conflicts, fmt.Sprintf("overlaps with the pool %s/%s", pools[y].Namespace, pools[y].Name))
+			}
+		}
+		sort.Strings(status.Conflicts)
+
+		if !slices.Equal(status.Allocations, p.Status.Allocations) || !slices.Equal(status.Conflicts, p.Status.Conflicts) {
+			p.Status = status
+			changed = append(changed, p.KubeVipIPPool)
+		}
+	}
+	return changed
+}
+
+// overlaps returns true if any of the addresses of the pools are the same
+func overlaps(a, b *pool) bool {
+	for _, x := range a.ranges {
+		for _, y := range b.ranges {
+			if x.overlaps(y) {
+				return true
+			}
+		}
+	}
+	return false
+}
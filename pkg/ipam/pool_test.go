@@ -0,0 +1,166 @@
+package ipam
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseAddresses(t *testing.T) {
+	tests := []struct {
+		name      string
+		addresses []string
+		want      []string
+		wantErr   bool
+	}{
+		{"cidr", []string{"192.168.0.0/29"}, []string{"192.168.0.1-192.168.0.6"}, false},
+		{"point to point", []string{"192.168.0.0/31"}, []string{"192.168.0.0-192.168.0.1"}, false},
+		{"range", []string{" 192.168.0.10 - 192.168.0.20 "}, []string{"192.168.0.10-192.168.0.20"}, false},
+		{"ipv6", []string{"fd00::/126"}, []string{"fd00::-fd00::3"}, false},
+		{"dual-stack", []string{"192.168.0.10/32", "fd00::10-fd00::20"}, []string{"192.168.0.10-192.168.0.10", "fd00::10-fd00::20"}, false},
+		{"backwards range", []string{"192.168.0.20-192.168.0.10"}, nil, true},
+		{"mixed range", []string{"192.168.0.10-fd00::10"}, nil, true},
+		{"address", []string{"192.168.0.10"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges, err := parseAddresses(tt.addresses)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAddresses() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, r := range ranges {
+				got = append(got, r.first.String()+"-"+r.last.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseAddresses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newPool(namespace, name string, addresses []string, allocations ...Allocation) KubeVipIPPool {
+	return KubeVipIPPool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       Spec{Addresses: addresses},
+		Status:     Status{Allocations: allocations},
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	pools, err := parsePools([]KubeVipIPPool{
+		newPool("kube-system", "global", []string{"10.0.0.0/30", "fd00::/127"}),
+		newPool("prod", "b", []string{"192.168.0.10-192.168.0.11"}, Allocation{Service: "prod/web", Address: "192.168.0.11"}),
+		newPool("prod", "a", []string{"192.168.1.10-192.168.1.10"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		service   string
+		family    v1.IPFamily
+		used      map[string]string
+		want      string
+	}{
+		{name: "global pool", namespace: "default", service: "default/a", family: v1.IPv4Protocol, want: "10.0.0.1"},
+		{name: "used address", namespace: "default", service: "default/a", family: v1.IPv4Protocol, used: map[string]string{"10.0.0.1": "default/b"}, want: "10.0.0.2"},
+		{name: "ipv6", namespace: "default", service: "default/a", family: v1.IPv6Protocol, want: "fd00::"},
+		{name: "pool exhausted", namespace: "default", service: "default/a", family: v1.IPv4Protocol, used: map[string]string{"10.0.0.1": "default/b", "10.0.0.2": "default/c"}},
+		{name: "namespace pools by name", namespace: "prod", service: "prod/api", family: v1.IPv4Protocol, want: "192.168.1.10"},
+		{name: "next namespace pool", namespace: "prod", service: "prod/api", family: v1.IPv4Protocol, used: map[string]string{"192.168.1.10": "prod/db"}, want: "192.168.0.10"},
+		{name: "sticky", namespace: "prod", service: "prod/web", family: v1.IPv4Protocol, want: "192.168.0.11"},
+		{name: "sticky address taken", namespace: "prod", service: "prod/web", family: v1.IPv4Protocol, used: map[string]string{"192.168.0.11": "prod/db"}, want: "192.168.1.10"},
+		{name: "no namespace pool of the family", namespace: "prod", service: "prod/api", family: v1.IPv6Protocol},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used := map[netip.Addr]string{}
+			for address, service := range tt.used {
+				used[netip.MustParseAddr(address)] = service
+			}
+			_, got, found := allocate(namespacePools(pools, "kube-system", tt.namespace), tt.service, tt.family, used)
+			if found != (tt.want != "") || (found && got.String() != tt.want) {
+				t.Errorf("allocate() = %s, %t, want %s", got, found, tt.want)
+			}
+		})
+	}
+}
+
+func TestFamilies(t *testing.T) {
+	prefer, require := v1.IPFamilyPolicyPreferDualStack, v1.IPFamilyPolicyRequireDualStack
+	dualStack := []v1.IPFamily{v1.IPv6Protocol, v1.IPv4Protocol}
+	tests := []struct {
+		name         string
+		spec         v1.ServiceSpec
+		want         []v1.IPFamily
+		wantRequired bool
+	}{
+		{"default", v1.ServiceSpec{}, []v1.IPFamily{v1.IPv4Protocol}, true},
+		{"prefer dual-stack", v1.ServiceSpec{IPFamilies: dualStack, IPFamilyPolicy: &prefer}, dualStack, false},
+		{"require dual-stack", v1.ServiceSpec{IPFamilies: dualStack, IPFamilyPolicy: &require}, dualStack, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, required := families(&v1.Service{Spec: tt.spec})
+			if !slices.Equal(got, tt.want) || required != tt.wantRequired {
+				t.Errorf("families() = %v, %t, want %v, %t", got, required, tt.want, tt.wantRequired)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	pools, err := parsePools([]KubeVipIPPool{
+		newPool("kube-system", "global", []string{"10.0.0.0/24"},
+			Allocation{Service: "default/kept", Address: "10.0.0.1"},
+			Allocation{Service: "default/pending", Address: "10.0.0.2"},
+			Allocation{Service: "default/deleted", Address: "10.0.0.3"},
+			Allocation{Service: "default/moved", Address: "10.0.0.4"},
+			Allocation{Service: "other/unknown", Address: "10.0.0.5"},
+		),
+		newPool("prod", "overlap", []string{"10.0.0.128/25"}),
+		newPool("dev", "separate", []string{"10.1.0.0/24"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := map[string][]string{
+		"default/kept":    {"10.0.0.1"},
+		"default/pending": {},
+		"default/moved":   {"192.168.0.10"},
+		"default/manual":  {"10.0.0.200"},
+	}
+
+	changed := reconcile(pools, services, "default")
+	if len(changed) != 2 || changed[0].Name != "global" || changed[1].Name != "overlap" {
+		t.Fatalf("reconcile() changed %d pools", len(changed))
+	}
+
+	// The allocations of the services outside of the known namespace are kept
+	wantAllocations := []Allocation{
+		{Service: "default/kept", Address: "10.0.0.1"},
+		{Service: "default/pending", Address: "10.0.0.2"},
+		{Service: "other/unknown", Address: "10.0.0.5"},
+	}
+	if got := pools[0].Status.Allocations; !slices.Equal(got, wantAllocations) {
+		t.Errorf("allocations = %v, want %v", got, wantAllocations)
+	}
+	wantConflicts := []string{
+		"address 10.0.0.200 of default/manual isn't allocated from the pool",
+		"overlaps with the pool prod/overlap",
+	}
+	if got := pools[0].Status.Conflicts; !slices.Equal(got, wantConflicts) {
+		t.Errorf("conflicts = %v, want %v", got, wantConflicts)
+	}
+
+	// Nothing changes once the status is up to date
+	if changed := reconcile(pools, services, "default"); len(changed) != 0 {
+		t.Errorf("reconcile() changed %d pools again", len(changed))
+	}
+}
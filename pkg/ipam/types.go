@@ -0,0 +1,109 @@
+package ipam
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Group is the API group of the kube-vip custom resources
+	Group = "kube-vip.io"
+	// Version is the API version of the KubeVipIPPool resource
+	Version = "v1alpha1"
+	// Kind is the kind of the KubeVipIPPool resource
+	Kind = "KubeVipIPPool"
+	// Resource is the plural resource name used in the API path
+	Resource = "kubevipippools"
+)
+
+// KubeVipIPPool is a pool of addresses that are allocated to the LoadBalancer services of its namespace, the pools
+// in the namespace of kube-vip are used by every namespace that doesn't have a pool of its own
+type KubeVipIPPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   Spec   `json:"spec"`
+	Status Status `json:"status,omitempty"`
+}
+
+// KubeVipIPPoolList is a list of KubeVipIPPool resources
+type KubeVipIPPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []KubeVipIPPool `json:"items"`
+}
+
+// Spec defines the addresses of a pool
+type Spec struct {
+	// Addresses are CIDRs (192.168.0.0/24) or ranges (192.168.0.10-192.168.0.20) of either family, the network and
+	// broadcast addresses of IPv4 CIDRs are never allocated
+	Addresses []string `json:"addresses"`
+}
+
+// Status is where the allocations of a pool are kept, so that a service keeps its addresses across restarts
+type Status struct {
+	// Allocations are the addresses of the pool that have been allocated to services
+	Allocations []Allocation `json:"allocations,omitempty"`
+
+	// Conflicts are the addresses of the pool that are used without having been allocated from it, and the pools
+	// that overlap with this one
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+// Allocation is an address of the pool that has been allocated to a service
+type Allocation struct {
+	// Service is the namespace/name of the service
+	Service string `json:"service"`
+
+	// Address is the address allocated to the service
+	Address string `json:"address"`
+}
+
+// CRD is the CustomResourceDefinition for the KubeVipIPPool resource
+const CRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: kubevipippools.kube-vip.io
+spec:
+  group: kube-vip.io
+  names:
+    kind: KubeVipIPPool
+    listKind: KubeVipIPPoolList
+    plural: kubevipippools
+    singular: kubevipippool
+  scope: Namespaced
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+    subresources:
+      status: {}
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: ["addresses"]
+            properties:
+              addresses:
+                type: array
+                items:
+                  type: string
+          status:
+            type: object
+            properties:
+              allocations:
+                type: array
+                items:
+                  type: object
+                  properties:
+                    service:
+                      type: string
+                    address:
+                      type: string
+              conflicts:
+                type: array
+                items:
+                  type: string
+`
@@ -4,14 +4,31 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"net/http"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+var (
+	clientCertificateMutex sync.RWMutex
+	clientCertificate      func(*tls.CertificateRequestInfo) (*tls.Certificate, error)
+)
+
+// UseClientCertificate will authenticate the clientsets that are created afterwards with the certificate from the
+// function (e.g. a SPIFFE SVID), instead of the credentials in the kubeconfig or of the service account. The function
+// is called for every connection so that rotated certificates are used.
+func UseClientCertificate(f func(*tls.CertificateRequestInfo) (*tls.Certificate, error)) {
+	clientCertificateMutex.Lock()
+	defer clientCertificateMutex.Unlock()
+	clientCertificate = f
+}
+
 // NewClientset takes an optional configPath and creates a new clientset.
 // If the configPath is not specified, and inCluster is true, then an
 // InClusterConfig is used.
@@ -21,12 +38,42 @@ func NewClientset(configPath string, inCluster bool, hostname string) (*kubernet
 	return newClientset(configPath, inCluster, hostname, time.Second*10)
 }
 
+// NewClientsetFromKubeconfig creates a new clientset from the contents of a kubeconfig rather than a file on the host
+// (e.g. from a secret), the hostname overrides the server of the kubeconfig if it is set.
+func NewClientsetFromKubeconfig(kubeconfig []byte, hostname string) (*kubernetes.Clientset, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the kubeconfig: %v", err)
+	}
+	if config, err = tuneConfig(config, time.Second*10); err != nil {
+		return nil, err
+	}
+	return clientsetFor(config, hostname)
+}
+
+// NewClientsetFromToken creates a new clientset for the API server that authenticates with a bearer token (e.g. a
+// bootstrap token), the API server is verified with the CA if one is given.
+func NewClientsetFromToken(server, token string, ca []byte) (*kubernetes.Clientset, error) {
+	config, err := tuneConfig(&rest.Config{
+		Host:            server,
+		BearerToken:     token,
+		TLSClientConfig: rest.TLSClientConfig{CAData: ca},
+	}, time.Second*10)
+	if err != nil {
+		return nil, err
+	}
+	return clientsetFor(config, "")
+}
+
 func newClientset(configPath string, inCluster bool, hostname string, timeout time.Duration) (*kubernetes.Clientset, error) {
 	config, err := restConfig(configPath, inCluster, timeout)
 	if err != nil {
 		panic(err.Error())
 	}
+	return clientsetFor(config, hostname)
+}
 
+func clientsetFor(config *rest.Config, hostname string) (*kubernetes.Clientset, error) {
 	if len(hostname) > 0 {
 		config.Host = hostname
 	}
@@ -51,12 +98,45 @@ func restConfig(kubeconfig string, inCluster bool, timeout time.Duration) (*rest
 	if err != nil {
 		return nil, err
 	}
+	return tuneConfig(cfg, timeout)
+}
 
+// tuneConfig sets the rate limits and timeout of the configuration, and the client certificate if one is used
+func tuneConfig(cfg *rest.Config, timeout time.Duration) (*rest.Config, error) {
 	// Override some of the defaults allowing a little bit more flexibility speaking with the API server
 	// these should hopefully be redundant, however issues will still be logged.
 	cfg.QPS = 100
 	cfg.Burst = 250
 	cfg.Timeout = timeout
+
+	clientCertificateMutex.RLock()
+	defer clientCertificateMutex.RUnlock()
+	if clientCertificate != nil {
+		return withClientCertificate(cfg, clientCertificate)
+	}
+	return cfg, nil
+}
+
+// withClientCertificate replaces the credentials of the configuration with the client certificate, the server is
+// still verified with the CA of the configuration
+func withClientCertificate(cfg *rest.Config, f func(*tls.CertificateRequestInfo) (*tls.Certificate, error)) (*rest.Config, error) {
+	cfg.TLSClientConfig.CertFile, cfg.TLSClientConfig.CertData = "", nil
+	cfg.TLSClientConfig.KeyFile, cfg.TLSClientConfig.KeyData = "", nil
+	cfg.BearerToken, cfg.BearerTokenFile = "", ""
+	cfg.Username, cfg.Password = "", ""
+	cfg.AuthProvider, cfg.ExecProvider = nil, nil
+	tlsConfig, err := rest.TLSConfigFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	tlsConfig.GetClientCertificate = f
+
+	// A custom transport can't be used together with the TLS options of the configuration
+	cfg.Transport = utilnet.SetTransportDefaults(&http.Transport{TLSClientConfig: tlsConfig})
+	cfg.TLSClientConfig = rest.TLSClientConfig{}
 	return cfg, nil
 }
 
@@ -1,5 +1,107 @@
 package k8s
 
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestWithClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.Header.Get("Authorization") != "" {
+			t.Errorf("expected only a client certificate, got %d certificates and authorization %q", len(r.TLS.PeerCertificates), r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"major":"1","minor":"29","gitVersion":"v1.29.1"}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	// The test server certificate is used as the client certificate as well
+	clientCert := server.TLS.Certificates[0]
+	called := 0
+	cfg, err := withClientCertificate(&rest.Config{
+		Host:            server.URL,
+		BearerToken:     "token",
+		TLSClientConfig: rest.TLSClientConfig{CAData: caPEM},
+	}, func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		called++
+		return &clientCert, nil
+	})
+	if err != nil {
+		t.Fatalf("withClientCertificate() error = %v", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clientset.Discovery().ServerVersion(); err != nil {
+		t.Fatalf("ServerVersion() error = %v", err)
+	}
+	if called == 0 {
+		t.Error("the client certificate wasn't requested")
+	}
+}
+
+func TestNewClientsetFromToken(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abcdef.0123456789abcdef" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"major":"1","minor":"29","gitVersion":"v1.29.1"}`))
+	}))
+	defer server.Close()
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	clientset, err := NewClientsetFromToken(server.URL, "abcdef.0123456789abcdef", caPEM)
+	if err != nil {
+		t.Fatalf("NewClientsetFromToken() error = %v", err)
+	}
+	if _, err := clientset.Discovery().ServerVersion(); err != nil {
+		t.Fatalf("ServerVersion() error = %v", err)
+	}
+}
+
+func TestNewClientsetFromKubeconfig(t *testing.T) {
+	kubeconfig := []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: kubernetes
+  cluster:
+    server: https://10.0.0.10:6443
+users:
+- name: kube-vip
+  user:
+    token: abcdef.0123456789abcdef
+contexts:
+- name: kube-vip
+  context:
+    cluster: kubernetes
+    user: kube-vip
+current-context: kube-vip
+`)
+	clientset, err := NewClientsetFromKubeconfig(kubeconfig, "127.0.0.1:6443")
+	if err != nil {
+		t.Fatalf("NewClientsetFromKubeconfig() error = %v", err)
+	}
+	if host := clientset.Discovery().RESTClient().Get().URL().Host; host != "127.0.0.1:6443" {
+		t.Errorf("the client uses %s, expected the hostname to override the server", host)
+	}
+
+	if _, err := NewClientsetFromKubeconfig([]byte("not: [a kubeconfig"), ""); err == nil {
+		t.Error("expected an error for an invalid kubeconfig")
+	}
+}
+
 //"192.168.0.174:6443"
 
 // func Test_findAddressFromRemoteCert(t *testing.T) {
@@ -0,0 +1,54 @@
+package k8s
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/tools/metrics"
+)
+
+var (
+	registerRequestMetrics sync.Once
+
+	// The requests of all the clientsets to the API server, so that the load that each kube-vip puts on it can be seen
+	apiRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kube_vip",
+		Subsystem: "api",
+		Name:      "requests_total",
+		Help:      "Count the requests to the API server by the method and the response code",
+	}, []string{"method", "code"})
+
+	apiRateLimiterWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "kube_vip",
+		Subsystem: "api",
+		Name:      "rate_limiter_wait_seconds",
+		Help:      "How long the requests to the API server waited for the client side rate limiter, by the method",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 8),
+	}, []string{"method"})
+)
+
+type requestResult struct{}
+
+func (requestResult) Increment(_ context.Context, code, method, _ string) {
+	apiRequests.WithLabelValues(method, code).Inc()
+}
+
+type rateLimiterLatency struct{}
+
+func (rateLimiterLatency) Observe(_ context.Context, verb string, _ url.URL, latency time.Duration) {
+	apiRateLimiterWait.WithLabelValues(verb).Observe(latency.Seconds())
+}
+
+// RequestMetrics hooks the metrics of the requests to the API server into client-go, and returns their collectors
+func RequestMetrics() []prometheus.Collector {
+	registerRequestMetrics.Do(func() {
+		metrics.Register(metrics.RegisterOpts{
+			RequestResult:      requestResult{},
+			RateLimiterLatency: rateLimiterLatency{},
+		})
+	})
+	return []prometheus.Collector{apiRequests, apiRateLimiterWait}
+}
@@ -0,0 +1,31 @@
+package k8s
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestRequestMetrics(t *testing.T) {
+	RequestMetrics()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := testutil.ToFloat64(apiRequests.WithLabelValues("GET", "503"))
+	_, _ = client.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(context.Background())
+	if got := testutil.ToFloat64(apiRequests.WithLabelValues("GET", "503")) - before; got < 1 {
+		t.Errorf("the request wasn't counted, got %v", got)
+	}
+}
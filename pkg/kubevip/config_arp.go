@@ -0,0 +1,30 @@
+package kubevip
+
+import "fmt"
+
+const (
+	// DefaultArpRepeatInterval is the milliseconds between the gratuitous updates that are repeated when a VIP is
+	// acquired
+	DefaultArpRepeatInterval = 200
+
+	// maxArpRepeat is the most gratuitous updates that can be sent when a VIP is acquired
+	maxArpRepeat = 100
+)
+
+// CheckARP will ensure that the gratuitous ARP/NDP updates that are repeated when a VIP is acquired are sent more
+// often than the broadcast rate
+func (c *Config) CheckARP() error {
+	if !c.EnableARP {
+		return nil
+	}
+	if c.ArpRepeat < 0 || c.ArpRepeat > maxArpRepeat {
+		return fmt.Errorf("the arp repeat [%d] has to be between 0 and %d", c.ArpRepeat, maxArpRepeat)
+	}
+	if c.ArpRepeatInterval < 0 {
+		return fmt.Errorf("the arp repeat interval [%d] can't be negative", c.ArpRepeatInterval)
+	}
+	if c.ArpBroadcastRate > 0 && c.ArpRepeatInterval > c.ArpBroadcastRate {
+		return fmt.Errorf("the arp repeat interval [%dms] is longer than the arp broadcast rate [%dms]", c.ArpRepeatInterval, c.ArpBroadcastRate)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package kubevip
+
+import "testing"
+
+func TestCheckARP(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *Config
+		wantErr bool
+	}{
+		{"defaults", &Config{EnableARP: true, ArpBroadcastRate: 3000}, false},
+		{"repeat", &Config{EnableARP: true, ArpBroadcastRate: 3000, ArpRepeat: 5, ArpRepeatInterval: 100, ArpBackoff: true}, false},
+		{"without arp", &Config{ArpRepeat: -1}, false},
+		{"negative repeat", &Config{EnableARP: true, ArpRepeat: -1}, true},
+		{"too many repeats", &Config{EnableARP: true, ArpRepeat: 1000}, true},
+		{"negative interval", &Config{EnableARP: true, ArpRepeat: 5, ArpRepeatInterval: -1}, true},
+		{"interval longer than the rate", &Config{EnableARP: true, ArpBroadcastRate: 3000, ArpRepeat: 5, ArpRepeatInterval: 5000}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckARP(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckARP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package kubevip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// CheckAWS will ensure that the VIP is an IPv4 address that can be assigned to an ENI, and that the EIP is given by its
+// allocation ID
+func (c *Config) CheckAWS() error {
+	if !c.EnableAWS {
+		return nil
+	}
+	if c.EnableMetal {
+		return fmt.Errorf("aws and metal can't both move the VIP")
+	}
+	if c.Address != "" {
+		return fmt.Errorf("aws only assigns a VIP address to the ENI, not the address [%s]", c.Address)
+	}
+	if c.VIP != "" {
+		// A dual-stack VIP is a list of addresses
+		for _, vip := range strings.Split(c.VIP, ",") {
+			if ip := net.ParseIP(vip); ip == nil || ip.To4() == nil {
+				return fmt.Errorf("aws only assigns IPv4 VIPs to the ENI, not [%s]", vip)
+			}
+		}
+	}
+	if c.AWSEIPAllocationID != "" && !strings.HasPrefix(c.AWSEIPAllocationID, "eipalloc-") {
+		return fmt.Errorf("aws EIP [%s] has to be the allocation ID of the EIP (eipalloc-...)", c.AWSEIPAllocationID)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package kubevip
+
+import "testing"
+
+func TestCheckAWS(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"disabled", Config{Address: "vip.example.com"}, false},
+		{"vip", Config{EnableAWS: true, VIP: "10.0.1.100"}, false},
+		{"eip", Config{EnableAWS: true, VIP: "10.0.1.100", AWSEIPAllocationID: "eipalloc-0123456789abcdef0"}, false},
+		{"eip address", Config{EnableAWS: true, VIP: "10.0.1.100", AWSEIPAllocationID: "203.0.113.10"}, true},
+		{"ipv6 vip", Config{EnableAWS: true, VIP: "2001:db8::100"}, true},
+		{"dual-stack vip", Config{EnableAWS: true, VIP: "10.0.1.100,2001:db8::100"}, true},
+		{"dns address", Config{EnableAWS: true, Address: "vip.example.com"}, true},
+		{"metal", Config{EnableAWS: true, EnableMetal: true, VIP: "10.0.1.100"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.CheckAWS(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckAWS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
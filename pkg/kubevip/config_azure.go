@@ -0,0 +1,47 @@
+package kubevip
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+var (
+	// azureIPConfigNameFormat is the format of the names of the IP configurations
+	azureIPConfigNameFormat = regexp.MustCompile(`^[a-zA-Z0-9]([-a-zA-Z0-9_.]{0,78}[a-zA-Z0-9_])?$`)
+	// azurePublicIPIDFormat is the format of the resource ID of a public IP
+	azurePublicIPIDFormat = regexp.MustCompile(`(?i)^/subscriptions/[^/]+/resourceGroups/[^/]+/providers/Microsoft\.Network/publicIPAddresses/[^/]+$`)
+)
+
+// CheckAzure will ensure that the VIP is an IPv4 address that an IP configuration can have, and that the name of the IP
+// configuration and the ID of the public IP are valid
+func (c *Config) CheckAzure() error {
+	if !c.EnableAzure {
+		return nil
+	}
+	if c.EnableMetal || c.EnableAWS || c.EnableGCP {
+		return fmt.Errorf("azure can't move the VIP together with metal, aws or gcp")
+	}
+	if c.Address != "" {
+		return fmt.Errorf("azure only moves a VIP address to the NIC, not the address [%s]", c.Address)
+	}
+	if c.VIP != "" {
+		vips := strings.Split(c.VIP, ",")
+		for _, vip := range vips {
+			if ip := net.ParseIP(vip); ip == nil || ip.To4() == nil {
+				return fmt.Errorf("azure only moves IPv4 VIPs to the NIC, not [%s]", vip)
+			}
+		}
+		if len(vips) > 1 && (c.AzureIPConfigName != "" || c.AzurePublicIPID != "") {
+			return fmt.Errorf("azure IP configuration name and public IP can only be set for a single VIP")
+		}
+	}
+	if c.AzureIPConfigName != "" && !azureIPConfigNameFormat.MatchString(c.AzureIPConfigName) {
+		return fmt.Errorf("azure IP configuration name [%s] has to be letters, digits, hyphens, underscores and periods", c.AzureIPConfigName)
+	}
+	if c.AzurePublicIPID != "" && !azurePublicIPIDFormat.MatchString(c.AzurePublicIPID) {
+		return fmt.Errorf("azure public IP [%s] has to be the resource ID of the public IP", c.AzurePublicIPID)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package kubevip
+
+import "testing"
+
+func TestCheckAzure(t *testing.T) {
+	publicIP := "/subscriptions/0000/resourceGroups/cluster/providers/Microsoft.Network/publicIPAddresses/control-plane"
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"disabled", Config{Address: "vip.example.com", AzurePublicIPID: "invalid"}, false},
+		{"vip", Config{EnableAzure: true, VIP: "10.0.0.100"}, false},
+		{"public ip", Config{EnableAzure: true, VIP: "10.0.0.100", AzureIPConfigName: "control-plane", AzurePublicIPID: publicIP}, false},
+		{"public ip name", Config{EnableAzure: true, VIP: "10.0.0.100", AzurePublicIPID: "control-plane"}, true},
+		{"invalid ip configuration name", Config{EnableAzure: true, VIP: "10.0.0.100", AzureIPConfigName: "control plane"}, true},
+		{"public ip for two vips", Config{EnableAzure: true, VIP: "10.0.0.100,10.0.0.101", AzurePublicIPID: publicIP}, true},
+		{"ipv6 vip", Config{EnableAzure: true, VIP: "2001:db8::100"}, true},
+		{"dns address", Config{EnableAzure: true, Address: "vip.example.com"}, true},
+		{"gcp", Config{EnableAzure: true, EnableGCP: true, VIP: "10.0.0.100"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.CheckAzure(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckAzure() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
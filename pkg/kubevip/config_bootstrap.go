@@ -0,0 +1,43 @@
+package kubevip
+
+import (
+	"fmt"
+)
+
+const (
+	// BootstrapEtcd elects the control plane VIP with etcd until the API server is healthy
+	BootstrapEtcd = "etcd"
+	// BootstrapStatic gives the control plane VIP to the first of the static peers until the API server is healthy
+	BootstrapStatic = "static"
+)
+
+// CheckBootstrap will ensure that the bootstrap can elect a leader without the API server, and that it has a
+// Kubernetes leader election to hand the VIP over to
+func (c *Config) CheckBootstrap() error {
+	if c.Bootstrap == "" {
+		return nil
+	}
+	if c.Bootstrap != BootstrapEtcd && c.Bootstrap != BootstrapStatic {
+		return fmt.Errorf("bootstrap [%s] is not supported, use %s or %s", c.Bootstrap, BootstrapEtcd, BootstrapStatic)
+	}
+	if !c.EnableControlPlane || !c.EnableLeaderElection {
+		return fmt.Errorf("bootstrap brings up the control plane VIP and requires the control plane and leader election")
+	}
+	if c.Standalone || (c.LeaderElectionType != "" && c.LeaderElectionType != "kubernetes") {
+		return fmt.Errorf("bootstrap moves the control plane VIP to kubernetes leader election, which it has to use")
+	}
+	switch c.Bootstrap {
+	case BootstrapEtcd:
+		if len(c.Etcd.Endpoints) == 0 {
+			return fmt.Errorf("bootstrap with etcd requires the etcd endpoints")
+		}
+		if c.Etcd.ClientSecret != "" {
+			return fmt.Errorf("bootstrap with etcd runs before the API server, the certificates can't be read from a secret")
+		}
+	case BootstrapStatic:
+		if len(c.BootstrapPeers) == 0 {
+			return fmt.Errorf("bootstrap with static peers requires the peers")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package kubevip
+
+import "testing"
+
+func TestCheckBootstrap(t *testing.T) {
+	election := KubernetesLeaderElection{EnableLeaderElection: true}
+	tests := []struct {
+		name    string
+		c       *Config
+		wantErr bool
+	}{
+		{"no bootstrap", &Config{}, false},
+		{"etcd", &Config{Bootstrap: "etcd", EnableControlPlane: true, KubernetesLeaderElection: election, Etcd: Etcd{Endpoints: []string{"https://127.0.0.1:2379"}}}, false},
+		{"static", &Config{Bootstrap: "static", EnableControlPlane: true, LeaderElectionType: "kubernetes", KubernetesLeaderElection: election, BootstrapPeers: []string{"cp-0", "cp-1"}}, false},
+		{"unknown", &Config{Bootstrap: "raft", EnableControlPlane: true, KubernetesLeaderElection: election}, true},
+		{"services only", &Config{Bootstrap: "static", EnableServices: true, KubernetesLeaderElection: election, BootstrapPeers: []string{"cp-0"}}, true},
+		{"no leader election", &Config{Bootstrap: "static", EnableControlPlane: true, BootstrapPeers: []string{"cp-0"}}, true},
+		{"etcd leader election", &Config{Bootstrap: "etcd", EnableControlPlane: true, LeaderElectionType: "etcd", KubernetesLeaderElection: election, Etcd: Etcd{Endpoints: []string{"https://127.0.0.1:2379"}}}, true},
+		{"etcd without endpoints", &Config{Bootstrap: "etcd", EnableControlPlane: true, KubernetesLeaderElection: election}, true},
+		{"etcd secret", &Config{Bootstrap: "etcd", EnableControlPlane: true, KubernetesLeaderElection: election, Etcd: Etcd{Endpoints: []string{"https://127.0.0.1:2379"}, ClientSecret: "etcd-certs"}}, true},
+		{"static without peers", &Config{Bootstrap: "static", EnableControlPlane: true, KubernetesLeaderElection: election}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckBootstrap(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckBootstrap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package kubevip
+
+// RequiredCapabilities returns the Linux capabilities that kube-vip needs for the enabled features, nothing that kube-vip
+// does requires it to be privileged (unless sysctls have to be changed on the node)
+func (c *Config) RequiredCapabilities() []string {
+	// Addresses, routes, IPVS, wireguard and iptables all need NET_ADMIN
+	capabilities := []string{"NET_ADMIN"}
+
+	// ARP/NDP advertisements and DHCP use raw sockets, as do iptables rules for egress and the wireguard probes
+	raw := c.EnableARP || c.EnableServices || c.DDNS || (c.EnableWireguard && c.WireguardProbeAddress != "")
+	for _, group := range c.VIPGroups {
+		raw = raw || group.Mode == "arp"
+	}
+	if raw {
+		capabilities = append(capabilities, "NET_RAW")
+	}
+
+	// The XDP load-balancer loads an eBPF program
+	if c.EnableLoadBalancer && c.LoadBalancerDataplane == LoadBalancerXDP {
+		capabilities = append(capabilities, "BPF")
+	}
+
+	// The DNS server is usually bound to a privileged port
+	if c.DNSServerZone != "" && c.DNSServerPort < 1024 {
+		capabilities = append(capabilities, "NET_BIND_SERVICE")
+	}
+	return capabilities
+}
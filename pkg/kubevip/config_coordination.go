@@ -0,0 +1,24 @@
+package kubevip
+
+import "fmt"
+
+// CheckCoordination will ensure that the channel between nodes can authenticate them
+func (c *Config) CheckCoordination() error {
+	if c.Coordination.Port == 0 {
+		return nil
+	}
+	if c.Coordination.Port < 0 || c.Coordination.Port > 65535 {
+		return fmt.Errorf("invalid coordination port [%d]", c.Coordination.Port)
+	}
+	if c.SPIFFE.NodeIDTemplate != "" {
+		// The nodes are authenticated by their SPIFFE IDs
+		return nil
+	}
+	if c.Coordination.Secret == "" {
+		return fmt.Errorf("the coordination channel requires a secret with the CA that the node certificates are signed by, or SPIFFE node IDs")
+	}
+	if c.Standalone {
+		return fmt.Errorf("the coordination channel reads its CA from a secret and can't be used in standalone mode")
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package kubevip
+
+import "testing"
+
+func TestCheckCoordination(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       Coordination
+		spiffe  SPIFFE
+		wantErr bool
+	}{
+		{name: "disabled"},
+		{name: "secret", c: Coordination{Port: 7443, Secret: "kube-vip-ca"}},
+		{name: "signer", c: Coordination{Port: 7443, Secret: "kube-vip-ca", SignerName: "example.com/kube-vip"}},
+		{name: "no secret", c: Coordination{Port: 7443}, wantErr: true},
+		{name: "spiffe", c: Coordination{Port: 7443}, spiffe: SPIFFE{Socket: "unix:///run/spire/sockets/agent.sock", NodeIDTemplate: "spiffe://example.org/kube-vip/{node}"}},
+		{name: "invalid port", c: Coordination{Port: 70000, Secret: "kube-vip-ca"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{Coordination: tt.c, SPIFFE: tt.spiffe}
+			if err := c.CheckCoordination(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckCoordination() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
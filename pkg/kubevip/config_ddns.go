@@ -0,0 +1,58 @@
+package kubevip
+
+import "fmt"
+
+const (
+	// DDNSProviderDHCP leaves the records to the DHCP server, which registers the hostname of the lease (the default)
+	DDNSProviderDHCP = "dhcp"
+	// DDNSProviderCloudflare publishes the records through the API of Cloudflare
+	DDNSProviderCloudflare = "cloudflare"
+	// DDNSProviderRoute53 publishes the records in a hosted zone of AWS Route53
+	DDNSProviderRoute53 = "route53"
+	// DDNSProviderRFC2136 publishes the records with dynamic updates (RFC2136) to a DNS server, signed with TSIG
+	DDNSProviderRFC2136 = "rfc2136"
+)
+
+const (
+	// ControlPlaneDNSNodes keeps the records of the control plane listing every healthy control plane node
+	ControlPlaneDNSNodes = "nodes"
+	// ControlPlaneDNSLeader keeps the records of the control plane pointing at the leader, instead of advertising a VIP
+	ControlPlaneDNSLeader = "leader"
+)
+
+// CheckDDNS will ensure that the dynamic DNS provider is known, and that the providers other than DHCP have a Secret
+// with their credentials. The records of the control plane need a provider other than DHCP (and the name of the node
+// when they point at the leader), and neither the TTL of the records nor the interval that DNS names are resolved at
+// can be negative.
+func (c *Config) CheckDDNS() error {
+	switch c.ControlPlaneDNSMode {
+	case "", ControlPlaneDNSNodes:
+	case ControlPlaneDNSLeader:
+		if c.ControlPlaneDNS != "" && c.NodeName == "" {
+			return fmt.Errorf("the records of the control plane [%s] point at the leader, which requires the name of the node", c.ControlPlaneDNS)
+		}
+	default:
+		return fmt.Errorf("the mode of the control plane records [%s] has to be %s or %s", c.ControlPlaneDNSMode, ControlPlaneDNSNodes, ControlPlaneDNSLeader)
+	}
+	if c.DDNSTTL < 0 {
+		return fmt.Errorf("the ttl of the ddns records [%d] can't be negative", c.DDNSTTL)
+	}
+	if c.DNSRefreshInterval < 0 {
+		return fmt.Errorf("the interval that DNS names are resolved at [%d] can't be negative", c.DNSRefreshInterval)
+	}
+	switch c.DDNSProvider {
+	case "", DDNSProviderDHCP:
+		if c.ControlPlaneDNS != "" {
+			return fmt.Errorf("the records of the control plane [%s] can't be published by DHCP, it requires another ddns provider", c.ControlPlaneDNS)
+		}
+		return nil
+	case DDNSProviderCloudflare, DDNSProviderRoute53, DDNSProviderRFC2136:
+	default:
+		return fmt.Errorf("ddns provider [%s] has to be one of %s, %s, %s or %s", c.DDNSProvider,
+			DDNSProviderDHCP, DDNSProviderCloudflare, DDNSProviderRoute53, DDNSProviderRFC2136)
+	}
+	if c.DDNSSecret == "" {
+		return fmt.Errorf("ddns provider [%s] requires a Secret with its credentials", c.DDNSProvider)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package kubevip
+
+import "testing"
+
+func TestCheckDDNS(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"default", Config{}, false},
+		{"dhcp", Config{DDNSProvider: DDNSProviderDHCP}, false},
+		{"cloudflare", Config{DDNSProvider: DDNSProviderCloudflare, DDNSSecret: "kube-vip-ddns"}, false},
+		{"control plane with dhcp", Config{ControlPlaneDNS: "api.example.com"}, true},
+		{"control plane", Config{ControlPlaneDNS: "api.example.com", DDNSProvider: DDNSProviderRFC2136, DDNSSecret: "kube-vip-ddns"}, false},
+		{"control plane leader", Config{ControlPlaneDNS: "api.example.com", ControlPlaneDNSMode: ControlPlaneDNSLeader, NodeName: "cp-1", DDNSProvider: DDNSProviderRFC2136, DDNSSecret: "kube-vip-ddns"}, false},
+		{"control plane leader without a node name", Config{ControlPlaneDNS: "api.example.com", ControlPlaneDNSMode: ControlPlaneDNSLeader, DDNSProvider: DDNSProviderRFC2136, DDNSSecret: "kube-vip-ddns"}, true},
+		{"unknown control plane mode", Config{ControlPlaneDNS: "api.example.com", ControlPlaneDNSMode: "vip", DDNSProvider: DDNSProviderRFC2136, DDNSSecret: "kube-vip-ddns"}, true},
+		{"route53 without a secret", Config{DDNSProvider: DDNSProviderRoute53}, true},
+		{"unknown provider", Config{DDNSProvider: "bind", DDNSSecret: "kube-vip-ddns"}, true},
+		{"ttl", Config{DDNSProvider: DDNSProviderCloudflare, DDNSSecret: "kube-vip-ddns", DDNSTTL: 300}, false},
+		{"negative ttl", Config{DDNSProvider: DDNSProviderCloudflare, DDNSSecret: "kube-vip-ddns", DDNSTTL: -1}, true},
+		{"negative refresh interval", Config{DNSRefreshInterval: -3}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.CheckDDNS(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckDDNS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
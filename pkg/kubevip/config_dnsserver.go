@@ -0,0 +1,46 @@
+package kubevip
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// CheckDNSServer will ensure that the zone of the DNS server is a valid domain, and that there is an address and a
+// port for the server to listen on
+func (c *Config) CheckDNSServer() error {
+	if c.DNSServerZone == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(strings.TrimSuffix(c.DNSServerZone, ".")); len(errs) != 0 {
+		return fmt.Errorf("dns server zone [%s] isn't a valid domain: %s", c.DNSServerZone, strings.Join(errs, ", "))
+	}
+	if c.DNSServerPort < 1 || c.DNSServerPort > 65535 {
+		return fmt.Errorf("dns server port [%d] has to be between 1 and 65535", c.DNSServerPort)
+	}
+	if c.DNSServerListenAddress() == "" {
+		return fmt.Errorf("dns server of zone [%s] requires an address, it is bound to the VIP of the control plane by default", c.DNSServerZone)
+	}
+	return nil
+}
+
+// DNSServerListenAddress returns the address and port that the DNS server listens on, the VIP of the control plane is
+// used if no address has been configured
+func (c *Config) DNSServerListenAddress() string {
+	address := c.DNSServerAddress
+	if address == "" {
+		for _, vip := range []string{c.Address, c.VIP} {
+			if net.ParseIP(vip) != nil {
+				address = vip
+				break
+			}
+		}
+	}
+	if net.ParseIP(address) == nil {
+		return ""
+	}
+	return net.JoinHostPort(address, strconv.Itoa(c.DNSServerPort))
+}
@@ -0,0 +1,29 @@
+package kubevip
+
+import "testing"
+
+func TestCheckDNSServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		want    string
+		wantErr bool
+	}{
+		{name: "disabled", config: Config{}},
+		{name: "control plane vip", config: Config{DNSServerZone: "lb.example.com", DNSServerPort: 53, Address: "192.168.0.40"}, want: "192.168.0.40:53"},
+		{name: "address", config: Config{DNSServerZone: "lb.example.com.", DNSServerPort: 5353, Address: "api.example.com", DNSServerAddress: "fd00::53"}, want: "[fd00::53]:5353"},
+		{name: "vip with a dns name", config: Config{DNSServerZone: "lb.example.com", DNSServerPort: 53, Address: "api.example.com"}, wantErr: true},
+		{name: "invalid zone", config: Config{DNSServerZone: "lb_example.com", DNSServerPort: 53, Address: "192.168.0.40"}, wantErr: true},
+		{name: "invalid port", config: Config{DNSServerZone: "lb.example.com", Address: "192.168.0.40"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.CheckDNSServer(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckDNSServer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := tt.config.DNSServerListenAddress(); tt.want != "" && got != tt.want {
+				t.Errorf("DNSServerListenAddress() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
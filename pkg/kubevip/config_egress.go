@@ -0,0 +1,26 @@
+package kubevip
+
+import "fmt"
+
+const (
+	// EgressPlacementLeader SNATs the egress of a service on the node that advertises its VIP, the rules move with the
+	// leadership of the service
+	EgressPlacementLeader = "leader"
+	// EgressPlacementPod SNATs the egress of a service on the node of its pod, whether or not that node is advertising
+	// the VIP, the return traffic has to reach that node (e.g. the VIP is advertised from every node with BGP)
+	EgressPlacementPod = "pod"
+)
+
+// CheckEgress will ensure that the placement of the egress is known
+func (c *Config) CheckEgress() error {
+	return ValidateEgressPlacement(c.EgressPlacement)
+}
+
+// ValidateEgressPlacement will ensure that the placement is leader or pod, an empty placement is the leader
+func ValidateEgressPlacement(placement string) error {
+	switch placement {
+	case "", EgressPlacementLeader, EgressPlacementPod:
+		return nil
+	}
+	return fmt.Errorf("egress placement [%s] has to be %s or %s", placement, EgressPlacementLeader, EgressPlacementPod)
+}
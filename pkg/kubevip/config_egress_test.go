@@ -0,0 +1,14 @@
+package kubevip
+
+import "testing"
+
+func TestCheckEgress(t *testing.T) {
+	for _, placement := range []string{"", EgressPlacementLeader, EgressPlacementPod} {
+		if err := (&Config{EgressPlacement: placement}).CheckEgress(); err != nil {
+			t.Errorf("CheckEgress(%q) = %v, want no error", placement, err)
+		}
+	}
+	if err := (&Config{EgressPlacement: "node"}).CheckEgress(); err == nil {
+		t.Error("CheckEgress(\"node\") = nil, want an unknown placement to be rejected")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/kube-vip/kube-vip/pkg/bgp"
 	"github.com/kube-vip/kube-vip/pkg/detector"
@@ -141,6 +142,25 @@ func ParseEnvironment(c *Config) error {
 		c.DDNS = b
 	}
 
+	env = os.Getenv(ddnsProvider)
+	if env != "" {
+		c.DDNSProvider = env
+	}
+
+	env = os.Getenv(ddnsSecret)
+	if env != "" {
+		c.DDNSSecret = env
+	}
+
+	env = os.Getenv(ddnsTTL)
+	if env != "" {
+		i, err := strconv.ParseInt(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.DDNSTTL = int(i)
+	}
+
 	// Find the namespace that the control plane should use (for leaderElection lock)
 	env = os.Getenv(cpNamespace)
 	if env != "" {
@@ -167,6 +187,16 @@ func ParseEnvironment(c *Config) error {
 		c.DetectControlPlane = b
 	}
 
+	env = os.Getenv(cpDNS)
+	if env != "" {
+		c.ControlPlaneDNS = env
+	}
+
+	env = os.Getenv(cpDNSMode)
+	if env != "" {
+		c.ControlPlaneDNSMode = env
+	}
+
 	env = os.Getenv(kubernetesAddr)
 	if env != "" {
 		c.KubernetesAddr = env
@@ -191,6 +221,26 @@ func ParseEnvironment(c *Config) error {
 			c.EnableServicesElection = b
 		}
 
+		// Find the number of shards of the services election
+		env = os.Getenv(svcElectionShards)
+		if env != "" {
+			i, err := strconv.ParseInt(env, 10, 32)
+			if err != nil {
+				return err
+			}
+			c.ServicesElectionShards = int(i)
+		}
+
+		// Find the allocation of service addresses from the ip pools
+		env = os.Getenv(svcIPAM)
+		if env != "" {
+			b, err := strconv.ParseBool(env)
+			if err != nil {
+				return err
+			}
+			c.EnableIPAM = b
+		}
+
 		// Find load-balancer class only
 		env = os.Getenv(lbClassOnly)
 		if env != "" {
@@ -218,6 +268,22 @@ func ParseEnvironment(c *Config) error {
 		if env != "" {
 			c.ServicesLeaseName = env
 		}
+
+		// Gets the number of services that are synchronised at the same time
+		env = os.Getenv(svcWorkers)
+		if env != "" {
+			i, err := strconv.ParseInt(env, 10, 32)
+			if err != nil {
+				return err
+			}
+			c.ServicesWorkers = int(i)
+		}
+
+		// Gets the label selector of the services that are watched
+		env = os.Getenv(svcSelector)
+		if env != "" {
+			c.ServicesSelector = env
+		}
 	}
 
 	// Find vip address cidr range
@@ -283,6 +349,33 @@ func ParseEnvironment(c *Config) error {
 		c.ArpBroadcastRate = 3000
 	}
 
+	env = os.Getenv(vipArpRepeat)
+	if env != "" {
+		i, err := strconv.Atoi(env)
+		if err != nil {
+			return err
+		}
+		c.ArpRepeat = i
+	}
+
+	env = os.Getenv(vipArpRepeatInterval)
+	if env != "" {
+		i64, err := strconv.ParseInt(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.ArpRepeatInterval = i64
+	}
+
+	env = os.Getenv(vipArpBackoff)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.ArpBackoff = b
+	}
+
 	// Wireguard Mode
 	env = os.Getenv(vipWireguard)
 	if env != "" {
@@ -313,6 +406,12 @@ func ParseEnvironment(c *Config) error {
 		c.RoutingTableID = int(i)
 	}
 
+	// Routing Table VRF
+	env = os.Getenv(vipRoutingTableVRF)
+	if env != "" {
+		c.RoutingTableVRF = env
+	}
+
 	// Routing Table Type
 	env = os.Getenv(vipRoutingTableType)
 	if env != "" {
@@ -333,6 +432,54 @@ func ParseEnvironment(c *Config) error {
 		c.RoutingProtocol = int(i)
 	}
 
+	// Routing metric
+	env = os.Getenv(vipRoutingMetric)
+	if env != "" {
+		i, err := strconv.ParseInt(env, 10, 64)
+		if err != nil {
+			return err
+		}
+		c.RoutingMetric = int(i)
+	}
+
+	// Routing next hops
+	env = os.Getenv(vipRoutingNextHops)
+	if env != "" {
+		c.RoutingNextHops = strings.Split(env, ",")
+	}
+
+	// Routing scope, onlink flag and source
+	env = os.Getenv(vipRoutingScope)
+	if env != "" {
+		c.RoutingScope = env
+	}
+
+	env = os.Getenv(vipRoutingOnLink)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.RoutingOnLink = b
+	}
+
+	env = os.Getenv(vipRoutingSource)
+	if env != "" {
+		c.RoutingSource = env
+	}
+
+	// Routing tables of the namespaces
+	env = os.Getenv(vipRoutingTableNamespaces)
+	if env != "" {
+		c.RoutingTableNamespaces = strings.Split(env, ",")
+	}
+
+	// Blackhole pools
+	env = os.Getenv(vipBlackholePools)
+	if env != "" {
+		c.BlackholePools = strings.Split(env, ",")
+	}
+
 	// Clean routing table
 	env = os.Getenv(vipCleanRoutingTable)
 	if env != "" {
@@ -349,6 +496,55 @@ func ParseEnvironment(c *Config) error {
 		c.DNSMode = env
 	}
 
+	env = os.Getenv(dnsRefreshInterval)
+	if env != "" {
+		i, err := strconv.ParseInt(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.DNSRefreshInterval = int(i)
+	}
+
+	env = os.Getenv(dnsHealthCheck)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.DNSHealthCheck = b
+	}
+
+	// Publishing the hostnames of the services
+	env = os.Getenv(externalDNSTemplate)
+	if env != "" {
+		c.ExternalDNSTemplate = env
+	}
+
+	env = os.Getenv(externalDNSTarget)
+	if env != "" {
+		c.ExternalDNSTarget = env
+	}
+
+	// Answering the queries for the services
+	env = os.Getenv(dnsServerZone)
+	if env != "" {
+		c.DNSServerZone = env
+	}
+
+	env = os.Getenv(dnsServerAddress)
+	if env != "" {
+		c.DNSServerAddress = env
+	}
+
+	env = os.Getenv(dnsServerPort)
+	if env != "" {
+		i, err := strconv.ParseInt(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.DNSServerPort = int(i)
+	}
+
 	// Disable updates for services (status.LoadBalancer.Ingress will not be updated)
 	env = os.Getenv(disableServiceUpdates)
 	if env != "" {
@@ -359,6 +555,16 @@ func ParseEnvironment(c *Config) error {
 		c.DisableServiceUpdates = b
 	}
 
+	// Record events on the services when their VIPs are advertised or withdrawn
+	env = os.Getenv(enableServiceEvents)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.EnableServiceEvents = b
+	}
+
 	// BGP Server options
 	env = os.Getenv(bgpEnable)
 	if env != "" {
@@ -469,6 +675,24 @@ func ParseEnvironment(c *Config) error {
 		c.BGPConfig.KeepaliveInterval = u64
 	}
 
+	// BGP graceful restart options
+	env = os.Getenv(bgpGracefulRestart)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.BGPConfig.GracefulRestart = b
+	}
+	env = os.Getenv(bgpRestartTime)
+	if env != "" {
+		u64, err := strconv.ParseUint(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.BGPConfig.RestartTime = uint32(u64)
+	}
+
 	// Enable the Equinix Metal API calls
 	env = os.Getenv(vipPacket)
 	if env != "" {
@@ -493,6 +717,130 @@ func ParseEnvironment(c *Config) error {
 		c.MetalProjectID = env
 	}
 
+	// Enable the AWS API calls
+	env = os.Getenv(vipAWS)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.EnableAWS = b
+	}
+
+	env = os.Getenv(awsRegion)
+	if env != "" {
+		c.AWSRegion = env
+	}
+
+	env = os.Getenv(awsEIPAllocationID)
+	if env != "" {
+		c.AWSEIPAllocationID = env
+	}
+
+	// Enable the GCP API calls
+	env = os.Getenv(vipGCP)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.EnableGCP = b
+	}
+
+	env = os.Getenv(gcpRouteName)
+	if env != "" {
+		c.GCPRouteName = env
+	}
+
+	env = os.Getenv(gcpNetwork)
+	if env != "" {
+		c.GCPNetwork = env
+	}
+
+	// Enable the Azure API calls
+	env = os.Getenv(vipAzure)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.EnableAzure = b
+	}
+
+	env = os.Getenv(azureIPConfigName)
+	if env != "" {
+		c.AzureIPConfigName = env
+	}
+
+	env = os.Getenv(azurePublicIPID)
+	if env != "" {
+		c.AzurePublicIPID = env
+	}
+
+	env = os.Getenv(azureClientID)
+	if env != "" {
+		c.AzureClientID = env
+	}
+
+	// Enable the OpenStack API calls
+	env = os.Getenv(vipOpenStack)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.EnableOpenStack = b
+	}
+
+	env = os.Getenv(openStackPools)
+	if env != "" {
+		c.OpenStackPools = strings.Split(env, ",")
+	}
+
+	env = os.Getenv(openStackPoolsConfigMap)
+	if env != "" {
+		c.OpenStackPoolsConfigMap = env
+	}
+
+	// Enable the NSX API calls
+	env = os.Getenv(vipNSX)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.EnableNSX = b
+	}
+
+	env = os.Getenv(nsxManager)
+	if env != "" {
+		c.NSXManager = env
+	}
+
+	env = os.Getenv(nsxInsecure)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.NSXInsecure = b
+	}
+
+	// Find the tag of the Equinix Metal EIP
+	env = os.Getenv(vipPacketEIPTag)
+	if env != "" {
+		c.MetalEIPTag = env
+	}
+
+	env = os.Getenv(vipPacketEIPSyncInterval)
+	if env != "" {
+		i, err := strconv.Atoi(env)
+		if err != nil {
+			return err
+		}
+		c.MetalEIPSyncInterval = i
+	}
+
 	// Enable the load-balancer
 	env = os.Getenv(lbEnable)
 	if env != "" {
@@ -519,6 +867,34 @@ func ParseEnvironment(c *Config) error {
 		c.LoadBalancerForwardingMethod = env
 	}
 
+	// Find loadbalancer data plane
+	env = os.Getenv(lbDataplane)
+	if env != "" {
+		c.LoadBalancerDataplane = env
+	}
+
+	// Find loadbalancer scheduler
+	env = os.Getenv(lbScheduler)
+	if env != "" {
+		c.LoadBalancerScheduler = env
+	}
+
+	// Find loadbalancer persistence timeout
+	env = os.Getenv(lbPersistenceTimeout)
+	if env != "" {
+		i, err := strconv.ParseInt(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.LoadBalancerPersistenceTimeout = int(i)
+	}
+
+	// Find loadbalancer backends
+	env = os.Getenv(lbBackends)
+	if env != "" {
+		c.LoadBalancerBackends = env
+	}
+
 	env = os.Getenv(EnableServiceSecurity)
 	if env != "" {
 		b, err := strconv.ParseBool(env)
@@ -544,6 +920,64 @@ func ParseEnvironment(c *Config) error {
 		c.PrometheusHTTPServer = env
 	}
 
+	env = os.Getenv(httpTLSCertFile)
+	if env != "" {
+		c.HTTPTLS.CertFile = env
+	}
+
+	env = os.Getenv(httpTLSKeyFile)
+	if env != "" {
+		c.HTTPTLS.KeyFile = env
+	}
+
+	env = os.Getenv(httpTLSClientCAFile)
+	if env != "" {
+		c.HTTPTLS.ClientCAFile = env
+	}
+
+	env = os.Getenv(httpTLSSecret)
+	if env != "" {
+		c.HTTPTLS.Secret = env
+	}
+
+	env = os.Getenv(coordinationPort)
+	if env != "" {
+		i, err := strconv.ParseInt(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.Coordination.Port = int(i)
+	}
+
+	env = os.Getenv(coordinationSecret)
+	if env != "" {
+		c.Coordination.Secret = env
+	}
+
+	env = os.Getenv(coordinationSignerName)
+	if env != "" {
+		c.Coordination.SignerName = env
+	}
+
+	env = os.Getenv(spiffeSocket)
+	if env != "" {
+		c.SPIFFE.Socket = env
+	}
+
+	env = os.Getenv(spiffeKubernetes)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.SPIFFE.Kubernetes = b
+	}
+
+	env = os.Getenv(spiffeNodeIDTemplate)
+	if env != "" {
+		c.SPIFFE.NodeIDTemplate = env
+	}
+
 	// Set Egress configuration(s)
 	env = os.Getenv(egressPodCidr)
 	if env != "" {
@@ -565,12 +999,57 @@ func ParseEnvironment(c *Config) error {
 		c.EgressWithNftables = b
 	}
 
+	// if this is set then the namespaces can have an egress VIP
+	env = os.Getenv(egressNamespaces)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.EgressNamespaces = b
+	}
+
+	env = os.Getenv(egressPlacement)
+	if env != "" {
+		c.EgressPlacement = env
+	}
+
 	// check to see if we're using a specific path to the Kubernetes config file
 	env = os.Getenv(k8sConfigFile)
 	if env != "" {
 		c.K8sConfigFile = env
 	}
 
+	// check to see if the host has no kubeconfig to read
+	env = os.Getenv(vipImmutableOS)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.ImmutableOS = b
+	}
+
+	env = os.Getenv(vipKubeconfig)
+	if env != "" {
+		c.Kubeconfig = env
+	}
+
+	env = os.Getenv(vipAPIServer)
+	if env != "" {
+		c.APIServer = env
+	}
+
+	env = os.Getenv(vipAPIToken)
+	if env != "" {
+		c.APIToken = env
+	}
+
+	env = os.Getenv(vipAPICA)
+	if env != "" {
+		c.APICA = env
+	}
+
 	env = os.Getenv(enableEndpointSlices)
 	if env != "" {
 		b, err := strconv.ParseBool(env)
@@ -585,11 +1064,58 @@ func ParseEnvironment(c *Config) error {
 		c.MirrorDestInterface = env
 	}
 
+	env = os.Getenv(enableUPNP)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.EnableUPNP = b
+	}
+
+	env = os.Getenv(upnpLeaseDuration)
+	if env != "" {
+		i, err := strconv.ParseInt(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.UPNPLeaseDuration = int(i)
+	}
+
+	env = os.Getenv(upnpProtocol)
+	if env != "" {
+		c.UPNPProtocol = env
+	}
+
+	env = os.Getenv(upnpOptIn)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.UPNPOptIn = b
+	}
+
+	env = os.Getenv(upnpGatewayURL)
+	if env != "" {
+		c.UPNPGatewayURL = env
+	}
+
+	env = os.Getenv(upnpInterface)
+	if env != "" {
+		c.UPNPInterface = env
+	}
+
 	env = os.Getenv(iptablesBackend)
 	if env != "" {
 		c.IptablesBackend = env
 	}
 
+	env = os.Getenv(firewallBackend)
+	if env != "" {
+		c.FirewallBackend = env
+	}
+
 	env = os.Getenv(backendHealthCheckInterval)
 	if env != "" {
 		i, err := strconv.ParseInt(env, 10, 32)
@@ -599,5 +1125,227 @@ func ParseEnvironment(c *Config) error {
 		c.BackendHealthCheckInterval = int(i)
 	}
 
+	env = os.Getenv(bgpPeerSecret)
+	if env != "" {
+		c.BGPPeerSecret = env
+	}
+
+	env = os.Getenv(wireguardSecret)
+	if env != "" {
+		c.WireguardSecret = env
+	}
+
+	env = os.Getenv(wireguardGenerateKeys)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.WireguardGenerateKeys = b
+	}
+
+	env = os.Getenv(wireguardMesh)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.WireguardMesh = b
+	}
+
+	env = os.Getenv(wireguardMeshSecret)
+	if env != "" {
+		c.WireguardMeshSecret = env
+	}
+
+	env = os.Getenv(wireguardHandshakeTimeout)
+	if env != "" {
+		i, err := strconv.ParseInt(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.WireguardHandshakeTimeout = int(i)
+	}
+
+	env = os.Getenv(wireguardProbeAddress)
+	if env != "" {
+		c.WireguardProbeAddress = env
+	}
+
+	env = os.Getenv(wireguardPort)
+	if env != "" {
+		i, err := strconv.ParseInt(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.WireguardPort = int(i)
+	}
+
+	env = os.Getenv(wireguardMeshPort)
+	if env != "" {
+		i, err := strconv.ParseInt(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.WireguardMeshPort = int(i)
+	}
+
+	env = os.Getenv(wireguardMTU)
+	if env != "" {
+		i, err := strconv.ParseInt(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.WireguardMTU = int(i)
+	}
+
+	env = os.Getenv(wireguardFirewallMark)
+	if env != "" {
+		i, err := strconv.ParseInt(env, 10, 32)
+		if err != nil {
+			return err
+		}
+		c.WireguardFirewallMark = int(i)
+	}
+
+	env = os.Getenv(vipStandalone)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.Standalone = b
+	}
+
+	env = os.Getenv(signatureKey)
+	if env != "" {
+		c.SignatureKey = env
+	}
+
+	env = os.Getenv(securityWebhook)
+	if env != "" {
+		c.SecurityWebhook = env
+	}
+
+	env = os.Getenv(vipFIPS)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.FIPS = b
+	}
+
+	env = os.Getenv(cpEngine)
+	if env != "" {
+		c.ControlPlaneEngine = env
+	}
+
+	env = os.Getenv(svcEngine)
+	if env != "" {
+		c.ServicesEngine = env
+	}
+
+	env = os.Getenv(featureGates)
+	if env != "" {
+		c.FeatureGates = env
+	}
+
+	env = os.Getenv(vipGroups)
+	if env != "" {
+		err := json.Unmarshal([]byte(env), &c.VIPGroups)
+		if err != nil {
+			return err
+		}
+	}
+
+	env = os.Getenv(etcdClientSecret)
+	if env != "" {
+		c.Etcd.ClientSecret = env
+	}
+
+	env = os.Getenv(vipBootstrap)
+	if env != "" {
+		c.Bootstrap = env
+	}
+
+	env = os.Getenv(vipBootstrapPeers)
+	if env != "" {
+		c.BootstrapPeers = strings.Split(env, ",")
+	}
+
+	env = os.Getenv(vipHandover)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.Handover = b
+	}
+
+	env = os.Getenv(vipHandoverPath)
+	if env != "" {
+		c.HandoverPath = env
+	}
+
+	env = os.Getenv(vipVRRP)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.EnableVRRP = b
+	}
+
+	env = os.Getenv(vrrpRouterID)
+	if env != "" {
+		i, err := strconv.Atoi(env)
+		if err != nil {
+			return err
+		}
+		c.VRRP.RouterID = i
+	}
+
+	env = os.Getenv(vrrpPriority)
+	if env != "" {
+		i, err := strconv.Atoi(env)
+		if err != nil {
+			return err
+		}
+		c.VRRP.Priority = i
+	}
+
+	env = os.Getenv(vrrpAdvertInterval)
+	if env != "" {
+		i, err := strconv.Atoi(env)
+		if err != nil {
+			return err
+		}
+		c.VRRP.AdvertInterval = i
+	}
+
+	env = os.Getenv(vrrpPreempt)
+	if env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		c.VRRP.Preempt = b
+	}
+
+	env = os.Getenv(vrrpVersion)
+	if env != "" {
+		i, err := strconv.Atoi(env)
+		if err != nil {
+			return err
+		}
+		c.VRRP.Version = i
+	}
+
+	env = os.Getenv(vrrpPassword)
+	if env != "" {
+		c.VRRP.Password = env
+	}
+
 	return nil
 }
@@ -9,6 +9,15 @@ const (
 	// vip_arpRate - defines the rate of gARP broadcasts
 	vipArpRate = "vip_arpRate"
 
+	// vipArpRepeat - defines how many gARP broadcasts are sent when a VIP is acquired
+	vipArpRepeat = "vip_arpRepeat"
+
+	// vipArpRepeatInterval - defines the milliseconds between the gARP broadcasts that are sent when a VIP is acquired
+	vipArpRepeatInterval = "vip_arpRepeatInterval"
+
+	// vipArpBackoff - defines if the time between gARP broadcasts backs off exponentially up to the rate
+	vipArpBackoff = "vip_arpBackoff"
+
 	// vipLeaderElection - defines if the kubernetes algorithm should be used
 	vipLeaderElection = "vip_leaderelection"
 
@@ -51,6 +60,12 @@ const (
 	// egressWithNftables - enables using nftables over iptables
 	egressWithNftables = "egress_withnftables"
 
+	// egressNamespaces - enables the egress VIPs of namespaces
+	egressNamespaces = "egress_namespaces"
+
+	// egressPlacement - defines the node that the egress of a service is placed on (leader or pod)
+	egressPlacement = "egress_placement"
+
 	/////////////////////////////////////
 	// TO DO:
 	// Determine how to tidy this mess up
@@ -74,6 +89,15 @@ const (
 	// vipDdns - defines if use dynamic dns to allocate IP for "address"
 	vipDdns = "vip_ddns"
 
+	// ddnsProvider defines the provider that publishes the records of dynamic dns (dhcp, cloudflare, route53, rfc2136)
+	ddnsProvider = "ddns_provider"
+
+	// ddnsSecret defines the Secret with the credentials of the dynamic dns provider
+	ddnsSecret = "ddns_secret"
+
+	// ddnsTTL defines the TTL of the records of the dynamic dns provider
+	ddnsTTL = "ddns_ttl"
+
 	// vipLeaseNodeName defines the node name that is used to acquire leases
 	nodeName = "vip_nodename"
 
@@ -92,6 +116,60 @@ const (
 	// vipPacketProjectID defines which projectID within Packet to use
 	vipPacketProjectID = "vip_packetprojectid"
 
+	// vipAWS defines that the EC2 API will be used to move the VIP between the ENIs
+	vipAWS = "vip_aws"
+
+	// awsRegion defines the region of the EC2 API
+	awsRegion = "aws_region"
+
+	// awsEIPAllocationID defines the EIP that is associated with the VIP
+	awsEIPAllocationID = "aws_eip_allocation_id"
+
+	// vipGCP defines that the Compute Engine API will be used to route the VIP to the leader
+	vipGCP = "vip_gcp"
+
+	// gcpRouteName defines the name of the route of the VIP
+	gcpRouteName = "gcp_route_name"
+
+	// gcpNetwork defines the VPC network of the route of the VIP
+	gcpNetwork = "gcp_network"
+
+	// vipAzure defines that the Azure API will be used to move the VIP between the NICs
+	vipAzure = "vip_azure"
+
+	// azureIPConfigName defines the name of the IP configuration of the VIP
+	azureIPConfigName = "azure_ip_config_name"
+
+	// azurePublicIPID defines the public IP that is associated with the VIP
+	azurePublicIPID = "azure_public_ip_id"
+
+	// azureClientID defines the user-assigned managed identity
+	azureClientID = "azure_client_id"
+
+	// vipOpenStack defines that the OpenStack API will be used to allow the VIPs on the port of the node
+	vipOpenStack = "vip_openstack"
+
+	// openStackPools defines the comma separated CIDRs that are allowed on the port of the node
+	openStackPools = "openstack_pools"
+
+	// openStackPoolsConfigMap defines the configmap of the cloud provider whose pools are allowed on the port
+	openStackPoolsConfigMap = "openstack_pools_configmap"
+
+	// vipNSX defines that the NSX API will be used to bind the VIP to the port of the leader
+	vipNSX = "vip_nsx"
+
+	// nsxManager defines the URL of the NSX manager
+	nsxManager = "nsx_manager"
+
+	// nsxInsecure defines if the certificate of the NSX manager is verified
+	nsxInsecure = "nsx_insecure"
+
+	// vipPacketEIPTag defines the tag of the EIP that becomes the VIP
+	vipPacketEIPTag = "vip_packeteiptag"
+
+	// vipPacketEIPSyncInterval defines how often the assignment of the EIP is checked
+	vipPacketEIPSyncInterval = "vip_packeteipsyncinterval"
+
 	// providerConfig defines a path to a configuration that should be parsed
 	providerConfig = "provider_config"
 
@@ -121,6 +199,10 @@ const (
 	bgpHoldTime = "bgp_hold_time"
 	// bgpKeepaliveInterval defines bgp timers keepalive interval
 	bgpKeepaliveInterval = "bgp_keepalive_interval"
+	// bgpGracefulRestart enables graceful restart with every bgp peer
+	bgpGracefulRestart = "bgp_graceful_restart"
+	// bgpRestartTime defines how long (in seconds) the bgp peers keep the routes while kube-vip restarts
+	bgpRestartTime = "bgp_restart_time"
 
 	// vipWireguard - defines if wireguard will be used for vips
 	vipWireguard = "vip_wireguard" //nolint
@@ -131,6 +213,9 @@ const (
 	// vipRoutingTableID - defines which table mode will be used for vips
 	vipRoutingTableID = "vip_routingtableid" //nolint
 
+	// vipRoutingTableVRF - defines the VRF that the routes for vips are added to
+	vipRoutingTableVRF = "vip_routingtablevrf" //nolint
+
 	// vipRoutingTableType - defines which table type will be used for vip routes
 	// 						 valid values for this variable can be found in:
 	//						 https://pkg.go.dev/golang.org/x/sys/unix#RTN_UNSPEC
@@ -142,6 +227,27 @@ const (
 	// vipRoutingProtocol - defines what value will be used as protocol when creating routes
 	vipRoutingProtocol = "vip_routingprotocol" //nolint
 
+	// vipRoutingMetric - defines the metric (priority) of the routes
+	vipRoutingMetric = "vip_routingmetric" //nolint
+
+	// vipRoutingNextHops - defines the comma separated next hops of multipath routes
+	vipRoutingNextHops = "vip_routingnexthops" //nolint
+
+	// vipRoutingScope - defines the scope of the routes (global, link or host)
+	vipRoutingScope = "vip_routingscope" //nolint
+
+	// vipRoutingOnLink - defines if the routes have the onlink flag
+	vipRoutingOnLink = "vip_routingonlink" //nolint
+
+	// vipRoutingSource - defines the preferred source of the routes ("vip" or an address)
+	vipRoutingSource = "vip_routingsource" //nolint
+
+	// vipRoutingTableNamespaces - defines the comma separated <namespace>=<table> routing tables of the services
+	vipRoutingTableNamespaces = "vip_routingtablenamespaces" //nolint
+
+	// vipBlackholePools - defines the comma separated pools whose unallocated addresses are blackholed
+	vipBlackholePools = "vip_blackholepools" //nolint
+
 	// vipCleanRoutingTable - defines if routing table will be cleaned of redundant routes on kube-vip's start
 	vipCleanRoutingTable = "vip_cleanroutingtable" //nolint
 
@@ -154,6 +260,12 @@ const (
 	// cpDetect will attempt to automatically find a working address for the control plane from loopback
 	cpDetect = "cp_detect"
 
+	// cpDNS is the hostname whose records list the healthy control plane nodes
+	cpDNS = "cp_dns"
+
+	// cpDNSMode is what the records of the control plane hostname list, the healthy nodes or the leader
+	cpDNSMode = "cp_dns_mode"
+
 	// kubernetesAddr，is the address of the Kubernetes API server on this machine
 	kubernetesAddr = "kubernetes_addr"
 
@@ -166,9 +278,21 @@ const (
 	// svcElection enables election per Kubernetes service
 	svcElection = "svc_election"
 
+	// svcElectionShards defines the number of shards that the services are elected in
+	svcElectionShards = "svc_election_shards"
+
+	// svcIPAM enables the allocation of the addresses of Kubernetes services from the ip pools
+	svcIPAM = "svc_ipam"
+
 	// svcLeaseName Name of the lease that is used for leader election for services (in arp mode)
 	svcLeaseName = "svc_leasename"
 
+	// svcWorkers defines the number of services that are synchronised at the same time
+	svcWorkers = "svc_workers"
+
+	// svcSelector is the label selector of the services that are watched
+	svcSelector = "svc_selector"
+
 	// lbClassOnly enables load-balancer for class "kube-vip.io/kube-vip-class" only
 	lbClassOnly = "lb_class_only"
 
@@ -184,6 +308,18 @@ const (
 	// lbForwardingMethod defines the forwarding method of load-balancer
 	lbForwardingMethod = "lb_fwdmethod"
 
+	// lbDataplane defines the data plane (ipvs or xdp) of load-balancer
+	lbDataplane = "lb_dataplane"
+
+	// lbScheduler defines the scheduler of the IPVS load-balancer
+	lbScheduler = "lb_scheduler"
+
+	// lbPersistenceTimeout defines the seconds that the connections of a client stay with its backend
+	lbPersistenceTimeout = "lb_persistence_timeout"
+
+	// lbBackends defines where the backends of the load-balancer come from (nodes or endpoints)
+	lbBackends = "lb_backends"
+
 	// EnableServiceSecurity defines if the load-balancer should only allow traffic to service ports
 	EnableServiceSecurity = "enable_service_security"
 
@@ -193,18 +329,69 @@ const (
 	// prometheusServer defines the address prometheus listens on
 	prometheusServer = "prometheus_server"
 
+	// httpTLSCertFile defines the certificate used to serve the HTTP endpoints
+	httpTLSCertFile = "http_tls_cert_file"
+
+	// httpTLSKeyFile defines the key used to serve the HTTP endpoints
+	httpTLSKeyFile = "http_tls_key_file"
+
+	// httpTLSClientCAFile defines the CA that client certificates must be signed by
+	httpTLSClientCAFile = "http_tls_client_ca_file"
+
+	// httpTLSSecret defines the secret that holds the HTTP endpoint certificates
+	httpTLSSecret = "http_tls_secret"
+
 	// vipConfigMap defines the configmap that kube-vip will watch for service definitions
 	// vipConfigMap = "vip_configmap"
 
 	// k8sConfigFile defines the path to the configfile used to speak with the API server
 	k8sConfigFile = "k8s_config_file"
 
+	// vipImmutableOS defines that no kubeconfig is read from the host
+	vipImmutableOS = "vip_immutable_os"
+
+	// vipKubeconfig defines the content of the kubeconfig used to speak with the API server
+	vipKubeconfig = "vip_kubeconfig"
+
+	// vipAPIServer defines the URL of the API server that is used with the token
+	vipAPIServer = "vip_api_server"
+
+	// vipAPIToken defines the bearer token that authenticates to the API server
+	vipAPIToken = "vip_api_token"
+
+	// vipAPICA defines the PEM encoded CA of the API server
+	vipAPICA = "vip_api_ca"
+
 	// dnsMode defines mode that DNS lookup will be performed with (first, ipv4, ipv6, dual)
 	dnsMode = "dns_mode"
 
+	// dnsRefreshInterval defines how often the DNS name of a VIP is resolved again
+	dnsRefreshInterval = "dns_refresh_interval"
+
+	// dnsHealthCheck enables the health checks of the addresses that the DNS name of a VIP resolves to
+	dnsHealthCheck = "dns_health_check"
+
+	// externalDNSTemplate defines the template of the hostnames that are published for the services
+	externalDNSTemplate = "external_dns_template"
+
+	// externalDNSTarget defines where the hostnames are published (annotation or status)
+	externalDNSTarget = "external_dns_target"
+
+	// dnsServerZone defines the zone that the embedded DNS server answers for
+	dnsServerZone = "dns_server_zone"
+
+	// dnsServerAddress defines the address that the embedded DNS server is bound to
+	dnsServerAddress = "dns_server_address"
+
+	// dnsServerPort defines the port that the embedded DNS server listens on
+	dnsServerPort = "dns_server_port"
+
 	// disableServiceUpdates disables service updating
 	disableServiceUpdates = "disable_service_updates"
 
+	// enableServiceEvents records events on the services when their VIPs are advertised or withdrawn
+	enableServiceEvents = "enable_service_events"
+
 	// enableEndpointSlices enables use of EndpointSlices instead of Endpoints
 	enableEndpointSlices = "enable_endpointslices"
 
@@ -213,9 +400,141 @@ const (
 	// + optional
 	mirrorDestInterface = "mirror_dest_interface"
 
+	// enableUPNP enables the port mappings of the services on the gateway
+	enableUPNP = "enableUPNP"
+
+	// upnpLeaseDuration defines the lease of the port mappings on the gateway
+	upnpLeaseDuration = "upnp_lease_duration"
+
+	// upnpProtocol defines the protocol that the ports are mapped with (auto, upnp, natpmp, pcp)
+	upnpProtocol = "upnp_protocol"
+
+	// upnpOptIn only forwards the ports of the services that opt in with their annotation
+	upnpOptIn = "upnp_opt_in"
+
+	// upnpGatewayURL defines the description URL of the UPNP gateway that the ports are mapped on
+	upnpGatewayURL = "upnp_gateway_url"
+
+	// upnpInterface defines the interface that the gateway is found through
+	upnpInterface = "upnp_interface"
+
 	// iptablesBackend iptables backend, can be specified as `nft` or `legacy`. If not set, it defaults to automatic detection.
 	iptablesBackend = "iptables_backend"
 
+	// firewallBackend defines what manages the firewall rules, iptables or nftables
+	firewallBackend = "firewall_backend"
+
 	// backendHealthCheckInterval Interval in seconds for checking backend health.
 	backendHealthCheckInterval = "backend_health_check_interval"
+
+	// bgpPeerSecret defines the name of the secret that holds the BGP peer password(s)
+	bgpPeerSecret = "bgp_peer_secret"
+
+	// wireguardSecret defines the name of the secret that holds the wireguard configuration
+	wireguardSecret = "wireguard_secret"
+
+	// wireguardGenerateKeys defines if the wireguard keys are generated and published in the secret
+	wireguardGenerateKeys = "wireguard_generate_keys"
+
+	// wireguardMesh defines if the kube-vip nodes are peered with each other
+	wireguardMesh = "wireguard_mesh"
+
+	// wireguardMeshSecret defines the name of the secret that holds the preshared keys of the mesh
+	wireguardMeshSecret = "wireguard_mesh_secret"
+
+	// wireguardHandshakeTimeout defines how old the last wireguard handshake can be before the tunnel is unhealthy
+	wireguardHandshakeTimeout = "wireguard_handshake_timeout"
+
+	// wireguardProbeAddress defines the address that is pinged through the wireguard tunnel
+	wireguardProbeAddress = "wireguard_probe_address"
+
+	// wireguardPort defines the UDP port of the wireguard tunnel
+	wireguardPort = "wireguard_port"
+
+	// wireguardMeshPort defines the UDP port of the wireguard mesh
+	wireguardMeshPort = "wireguard_mesh_port"
+
+	// wireguardMTU defines the MTU of the wireguard interfaces
+	wireguardMTU = "wireguard_mtu"
+
+	// wireguardFirewallMark defines the mark of the encrypted wireguard packets
+	wireguardFirewallMark = "wireguard_fwmark"
+
+	// vipStandalone defines if kube-vip runs without a Kubernetes cluster
+	vipStandalone = "vip_standalone"
+
+	// cpEngine defines the engine used for the control plane when several engines are enabled
+	cpEngine = "cp_engine"
+
+	// svcEngine defines the engine used for services when several engines are enabled
+	svcEngine = "svc_engine"
+
+	// featureGates defines the features that are enabled, format: Feature1=true,Feature2=false
+	featureGates = "feature_gates"
+
+	// vipGroups defines additional VIP groups (json encoded)
+	vipGroups = "vip_groups"
+
+	// etcdClientSecret defines the name of the secret that holds the etcd client certificates
+	etcdClientSecret = "etcd_client_secret"
+
+	// vipBootstrap defines how the control plane VIP is elected until the API server is healthy (etcd or static)
+	vipBootstrap = "vip_bootstrap"
+
+	// vipBootstrapPeers defines the control plane nodes of the static bootstrap (comma separated)
+	vipBootstrapPeers = "vip_bootstrap_peers"
+
+	// vipHandover enables handing the control plane VIP over to the kube-vip that replaces this one on the node
+	vipHandover = "vip_handover"
+
+	// vipHandoverPath defines the file on the host that the state is handed over in
+	vipHandoverPath = "vip_handover_path"
+
+	// vipVRRP enables electing the control plane VIP with VRRP
+	vipVRRP = "vip_vrrp"
+
+	// vrrpRouterID defines the ID of the virtual router
+	vrrpRouterID = "vrrp_router_id"
+
+	// vrrpPriority defines the priority of this node
+	vrrpPriority = "vrrp_priority"
+
+	// vrrpAdvertInterval defines the interval between the advertisements in milliseconds
+	vrrpAdvertInterval = "vrrp_advert_interval"
+
+	// vrrpPreempt enables taking the VIP over from a node with a lower priority
+	vrrpPreempt = "vrrp_preempt"
+
+	// vrrpVersion defines the version of VRRP (2 or 3)
+	vrrpVersion = "vrrp_version"
+
+	// vrrpPassword defines the simple text authentication of VRRPv2
+	vrrpPassword = "vrrp_password" // nolint
+
+	// coordinationPort defines the port of the channel between kube-vip nodes
+	coordinationPort = "coordination_port"
+
+	// coordinationSecret defines the secret that holds the CA of the channel between kube-vip nodes
+	coordinationSecret = "coordination_secret"
+
+	// coordinationSignerName defines the signer of the certificates for the channel between kube-vip nodes
+	coordinationSignerName = "coordination_signer_name"
+
+	// spiffeSocket defines the SPIFFE Workload API socket
+	spiffeSocket = "spiffe_socket"
+
+	// spiffeKubernetes enables authenticating to the API server with the SPIFFE SVID
+	spiffeKubernetes = "spiffe_kubernetes"
+
+	// spiffeNodeIDTemplate defines the SPIFFE ID of the nodes for the channel between kube-vip nodes
+	spiffeNodeIDTemplate = "spiffe_node_id_template"
+
+	// signatureKey defines the public key used to verify signed configuration
+	signatureKey = "signature_key"
+
+	// securityWebhook defines the URL that security events are sent to
+	securityWebhook = "security_webhook"
+
+	// vipFIPS defines if kube-vip is restricted to FIPS approved cryptography
+	vipFIPS = "vip_fips"
 )
@@ -0,0 +1,40 @@
+package kubevip
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// ExternalDNSTargetAnnotation publishes the hostname in the hostname annotation of external-dns
+	ExternalDNSTargetAnnotation = "annotation"
+	// ExternalDNSTargetStatus publishes the hostname in the load balancer status of the service, alongside its VIPs
+	ExternalDNSTargetStatus = "status"
+)
+
+// CheckExternalDNS will ensure that the hostname template makes valid hostnames, and that its target is known
+func (c *Config) CheckExternalDNS() error {
+	if c.ExternalDNSTemplate == "" {
+		return nil
+	}
+	switch c.ExternalDNSTarget {
+	case "", ExternalDNSTargetAnnotation, ExternalDNSTargetStatus:
+	default:
+		return fmt.Errorf("external-dns target [%s] has to be %s or %s", c.ExternalDNSTarget, ExternalDNSTargetAnnotation, ExternalDNSTargetStatus)
+	}
+	if errs := validation.IsDNS1123Subdomain(c.ExternalDNSHostname("service", "namespace")); len(errs) != 0 {
+		return fmt.Errorf("external-dns template [%s] doesn't make valid hostnames: %s", c.ExternalDNSTemplate, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// ExternalDNSHostname returns the hostname of the service from the template, {service} and {namespace} are replaced by
+// the name and namespace of the service
+func (c *Config) ExternalDNSHostname(service, namespace string) string {
+	if c.ExternalDNSTemplate == "" {
+		return ""
+	}
+	return strings.NewReplacer("{service}", service, "{namespace}", namespace).Replace(c.ExternalDNSTemplate)
+}
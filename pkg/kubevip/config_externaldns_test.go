@@ -0,0 +1,34 @@
+package kubevip
+
+import "testing"
+
+func TestExternalDNSHostname(t *testing.T) {
+	c := &Config{ExternalDNSTemplate: "{service}.{namespace}.lb.example.com"}
+	if got, want := c.ExternalDNSHostname("web", "shop"), "web.shop.lb.example.com"; got != want {
+		t.Errorf("ExternalDNSHostname() = %q, want %q", got, want)
+	}
+	if got := (&Config{}).ExternalDNSHostname("web", "shop"); got != "" {
+		t.Errorf("ExternalDNSHostname() = %q, want nothing without a template", got)
+	}
+}
+
+func TestCheckExternalDNS(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"disabled", Config{}, false},
+		{"annotation", Config{ExternalDNSTemplate: "{service}.{namespace}.lb.example.com"}, false},
+		{"status", Config{ExternalDNSTemplate: "{service}.lb.example.com", ExternalDNSTarget: ExternalDNSTargetStatus}, false},
+		{"unknown target", Config{ExternalDNSTemplate: "{service}.lb.example.com", ExternalDNSTarget: "record"}, true},
+		{"invalid hostname", Config{ExternalDNSTemplate: "{service}_{namespace}.example.com"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.CheckExternalDNS(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckExternalDNS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
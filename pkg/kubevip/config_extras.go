@@ -0,0 +1,62 @@
+package kubevip
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ManifestExtras defines user specified additions that are injected into the generated pod/daemonset manifests
+type ManifestExtras struct {
+	// Containers are additional (sidecar) containers that will run alongside kube-vip
+	Containers []corev1.Container `json:"containers,omitempty"`
+
+	// Volumes are additional volumes added to the pod
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// VolumeMounts are additional volume mounts added to the kube-vip container
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// Env is additional environment added to the kube-vip container
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// LoadManifestExtras will read the manifest extras from a yaml file
+func LoadManifestExtras(path string) (*ManifestExtras, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	extras := &ManifestExtras{}
+	if err = yaml.UnmarshalStrict(b, extras); err != nil {
+		return nil, fmt.Errorf("unable to parse manifest extras [%s]: %v", path, err)
+	}
+	return extras, nil
+}
+
+// ParseExtraEnv will parse a list of KEY=VALUE strings into environment variables
+func ParseExtraEnv(env []string) ([]corev1.EnvVar, error) {
+	var vars []corev1.EnvVar
+	for x := range env {
+		name, value, found := strings.Cut(env[x], "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid environment variable [%s], should be KEY=VALUE", env[x])
+		}
+		vars = append(vars, corev1.EnvVar{Name: name, Value: value})
+	}
+	return vars, nil
+}
+
+// applyManifestExtras will inject the extras into a generated pod, the kube-vip container is always the first container
+func applyManifestExtras(pod *corev1.Pod, extras *ManifestExtras) {
+	if extras == nil {
+		return
+	}
+	pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, extras.Env...)
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, extras.VolumeMounts...)
+	pod.Spec.Containers = append(pod.Spec.Containers, extras.Containers...)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, extras.Volumes...)
+}
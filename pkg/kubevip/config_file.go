@@ -0,0 +1,138 @@
+package kubevip
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ConfigKind is the kind of a kube-vip configuration file
+	ConfigKind = "KubeVipConfiguration"
+
+	// ConfigAPIVersion is the current schema of the kube-vip configuration file
+	ConfigAPIVersion = "kube-vip.io/v1alpha2"
+
+	// configAPIVersionV1Alpha1 is the original schema, which is also assumed when no apiVersion is set
+	configAPIVersionV1Alpha1 = "kube-vip.io/v1alpha1"
+)
+
+// configMigration describes how a configuration is migrated from one apiVersion to the next
+type configMigration struct {
+	next string
+
+	// renamed are deprecated keys and the key that replaces them
+	renamed map[string]string
+
+	// inlined are deprecated sections whose fields are now at the top level
+	inlined []string
+}
+
+// configMigrations are keyed by the apiVersion that they migrate from
+var configMigrations = map[string]configMigration{
+	configAPIVersionV1Alpha1: {
+		next: ConfigAPIVersion,
+		renamed: map[string]string{
+			"leaseNodeName":         "nodeName",
+			"dnsDualStackMode":      "dnsMode",
+			"EnableServiceSecurity": "enableServiceSecurity",
+		},
+		// The settings of the Kubernetes leader election were in a nested section, which is spelled as the embedded
+		// struct was (without a tag) or as it is documented
+		inlined: []string{"kubernetesleaderelection", "kubernetesLeaderElection"},
+	},
+}
+
+// migrateConfig will migrate a configuration to the current apiVersion, a warning is logged for every
+// deprecated field that is migrated
+func migrateConfig(raw map[string]interface{}) (map[string]interface{}, error) {
+	apiVersion, _ := raw["apiVersion"].(string)
+	if apiVersion == "" {
+		log.Warnf("configuration has no apiVersion, assuming [%s]", configAPIVersionV1Alpha1)
+		apiVersion = configAPIVersionV1Alpha1
+	}
+
+	for apiVersion != ConfigAPIVersion {
+		migration, exists := configMigrations[apiVersion]
+		if !exists {
+			return nil, fmt.Errorf("unknown configuration apiVersion [%s]", apiVersion)
+		}
+		for old, replacement := range migration.renamed {
+			value, exists := raw[old]
+			if !exists {
+				continue
+			}
+			if _, conflict := raw[replacement]; conflict {
+				return nil, fmt.Errorf("both the deprecated field [%s] and its replacement [%s] are set", old, replacement)
+			}
+			log.Warnf("configuration field [%s] is deprecated in [%s], migrating to [%s]", old, migration.next, replacement)
+			raw[replacement] = value
+			delete(raw, old)
+		}
+		for _, section := range migration.inlined {
+			value, exists := raw[section]
+			if !exists {
+				continue
+			}
+			fields, ok := value.(map[interface{}]interface{})
+			if !ok {
+				return nil, fmt.Errorf("configuration field [%s] is not a section", section)
+			}
+			log.Warnf("configuration section [%s] is deprecated in [%s], migrating its fields to the top level", section, migration.next)
+			for key, value := range fields {
+				field := fmt.Sprint(key)
+				if _, conflict := raw[field]; conflict {
+					return nil, fmt.Errorf("both the deprecated field [%s.%s] and its replacement [%s] are set", section, field, field)
+				}
+				raw[field] = value
+			}
+			delete(raw, section)
+		}
+		log.Infof("migrated configuration from [%s] to [%s]", apiVersion, migration.next)
+		apiVersion = migration.next
+	}
+
+	raw["apiVersion"] = ConfigAPIVersion
+	return raw, nil
+}
+
+// LoadConfigFile will load a configuration file over the top of an existing configuration, migrating it
+// to the current apiVersion if required
+func LoadConfigFile(path string, c *Config) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return loadConfig(b, c)
+}
+
+// LoadConfig will load a configuration document that isn't in a file (e.g. inline in a Talos machine config) over the
+// top of an existing configuration, migrating it to the current apiVersion if required
+func LoadConfig(b []byte, c *Config) error {
+	return loadConfig(b, c)
+}
+
+func loadConfig(b []byte, c *Config) error {
+	raw := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("unable to parse configuration: %v", err)
+	}
+	if kind, _ := raw["kind"].(string); kind != "" && kind != ConfigKind {
+		return fmt.Errorf("unexpected configuration kind [%s], expected [%s]", kind, ConfigKind)
+	}
+
+	raw, err := migrateConfig(raw)
+	if err != nil {
+		return err
+	}
+	delete(raw, "apiVersion")
+	delete(raw, "kind")
+
+	b, err = yaml.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalStrict(b, c)
+}
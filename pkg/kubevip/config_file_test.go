@@ -0,0 +1,94 @@
+package kubevip
+
+import "testing"
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantErr   bool
+		nodeName  string
+		dnsMode   string
+		leaseName string
+	}{
+		{
+			name:     "current apiVersion",
+			config:   "apiVersion: kube-vip.io/v1alpha2\nkind: KubeVipConfiguration\nnodeName: node1\ndnsMode: ipv4\n",
+			nodeName: "node1",
+			dnsMode:  "ipv4",
+		},
+		{
+			name:     "v1alpha1 is migrated",
+			config:   "apiVersion: kube-vip.io/v1alpha1\nleaseNodeName: node1\ndnsDualStackMode: dual\n",
+			nodeName: "node1",
+			dnsMode:  "dual",
+		},
+		{
+			name:     "no apiVersion is migrated",
+			config:   "leaseNodeName: node1\n",
+			nodeName: "node1",
+		},
+		{
+			name:      "v1alpha1 leader election section is migrated",
+			config:    "apiVersion: kube-vip.io/v1alpha1\nleaseNodeName: node1\nkubernetesLeaderElection:\n  enableLeaderElection: true\n  leaseName: plndr-cp-lock\n",
+			nodeName:  "node1",
+			leaseName: "plndr-cp-lock",
+		},
+		{
+			name:      "untagged leader election section is migrated",
+			config:    "kubernetesleaderelection:\n  leaseName: plndr-cp-lock\n",
+			leaseName: "plndr-cp-lock",
+		},
+		{
+			name:    "deprecated section and replacement field",
+			config:  "apiVersion: kube-vip.io/v1alpha1\nkubernetesLeaderElection:\n  leaseName: plndr-cp-lock\nleaseName: plndr-svcs-lock\n",
+			wantErr: true,
+		},
+		{
+			name:    "leader election section in the current apiVersion",
+			config:  "apiVersion: kube-vip.io/v1alpha2\nkubernetesLeaderElection:\n  leaseName: plndr-cp-lock\n",
+			wantErr: true,
+		},
+		{
+			name:    "deprecated and replacement field",
+			config:  "apiVersion: kube-vip.io/v1alpha1\nleaseNodeName: node1\nnodeName: node2\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown apiVersion",
+			config:  "apiVersion: kube-vip.io/v9\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown kind",
+			config:  "apiVersion: kube-vip.io/v1alpha2\nkind: Pod\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			config:  "apiVersion: kube-vip.io/v1alpha2\nleaseNodeName: node1\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{}
+			err := loadConfig([]byte(tt.config), c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if c.NodeName != tt.nodeName {
+				t.Errorf("loadConfig() nodeName = %v, want %v", c.NodeName, tt.nodeName)
+			}
+			if c.DNSMode != tt.dnsMode {
+				t.Errorf("loadConfig() dnsMode = %v, want %v", c.DNSMode, tt.dnsMode)
+			}
+			if c.LeaseName != tt.leaseName {
+				t.Errorf("loadConfig() leaseName = %v, want %v", c.LeaseName, tt.leaseName)
+			}
+		})
+	}
+}
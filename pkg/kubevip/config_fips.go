@@ -0,0 +1,41 @@
+package kubevip
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+)
+
+// CheckFIPS will ensure that the configuration only needs FIPS approved cryptography, in FIPS mode any configuration
+// that relies on something else is refused rather than silently weakened
+func (c *Config) CheckFIPS() error {
+	if c.EnableWireguard {
+		return fmt.Errorf("wireguard can't be used in FIPS mode, it relies on Curve25519 and ChaCha20-Poly1305 which aren't FIPS approved")
+	}
+
+	// BGP passwords are TCP MD5 signatures
+	bgpPassword := c.BGPPeerConfig.Password != "" || c.BGPPeerSecret != ""
+	for _, peer := range c.BGPConfig.Peers {
+		bgpPassword = bgpPassword || peer.Password != ""
+	}
+	if c.EnableBGP && bgpPassword {
+		return fmt.Errorf("BGP peer passwords can't be used in FIPS mode, they rely on TCP MD5 signatures which aren't FIPS approved")
+	}
+
+	for _, group := range c.VIPGroups {
+		if group.Mode != "bgp" || len(group.BGPPeers) == 0 {
+			continue
+		}
+		peers, err := bgp.ParseBGPPeerConfig(strings.Join(group.BGPPeers, ","))
+		if err != nil {
+			return fmt.Errorf("VIP group [%s]: %v", group.Name, err)
+		}
+		for _, peer := range peers {
+			if peer.Password != "" {
+				return fmt.Errorf("VIP group [%s] has a BGP peer password, these can't be used in FIPS mode", group.Name)
+			}
+		}
+	}
+	return nil
+}
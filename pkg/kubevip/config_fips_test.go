@@ -0,0 +1,29 @@
+package kubevip
+
+import (
+	"testing"
+
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+)
+
+func TestCheckFIPS(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *Config
+		wantErr bool
+	}{
+		{"arp", &Config{EnableARP: true, EnableControlPlane: true}, false},
+		{"bgp without passwords", &Config{EnableBGP: true, BGPConfig: bgp.Config{Peers: []bgp.Peer{{Address: "192.168.0.1", AS: 65000}}}}, false},
+		{"bgp peer password", &Config{EnableBGP: true, BGPConfig: bgp.Config{Peers: []bgp.Peer{{Address: "192.168.0.1", AS: 65000, Password: "secret"}}}}, true},
+		{"bgp peer secret", &Config{EnableBGP: true, BGPPeerSecret: "bgp-peers"}, true},
+		{"wireguard", &Config{EnableWireguard: true}, true},
+		{"vip group bgp password", &Config{VIPGroups: []VIPGroup{{Name: "ingress", Mode: "bgp", BGPPeers: []string{"192.168.0.1:65000:secret"}}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckFIPS(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckFIPS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
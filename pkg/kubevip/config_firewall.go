@@ -0,0 +1,259 @@
+package kubevip
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	applyMetaV1 "k8s.io/client-go/applyconfigurations/meta/v1"
+	applyNetworkingV1 "k8s.io/client-go/applyconfigurations/networking/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+// FirewallOptions are the addresses that can't be worked out from the kube-vip configuration, an empty list allows
+// any address
+type FirewallOptions struct {
+	// APIServers are the CIDRs of the Kubernetes API servers
+	APIServers []string
+	// Prometheus are the CIDRs that scrape the kube-vip metrics
+	Prometheus []string
+}
+
+// Flow is a single network flow that kube-vip needs
+type Flow struct {
+	Description string
+	Direction   networkingv1.PolicyType
+	Protocol    corev1.Protocol
+	Port        int32
+	// CIDRs are the remote addresses of the flow, empty allows any address
+	CIDRs []string
+}
+
+// GenerateFlows will work out the network flows that kube-vip needs for the features that are enabled
+func GenerateFlows(c *Config, o FirewallOptions) ([]Flow, error) {
+	for _, cidr := range append(append([]string{}, o.APIServers...), o.Prometheus...) {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return nil, err
+		}
+	}
+
+	var flows []Flow
+
+	if !c.Standalone {
+		ports := []int32{int32(c.Port)}
+		// The in-cluster configuration uses the kubernetes service
+		if !slices.Contains(ports, 443) {
+			ports = append(ports, 443)
+		}
+		for _, port := range ports {
+			flows = append(flows, Flow{Description: "kubernetes api server", Direction: networkingv1.PolicyTypeEgress, Protocol: corev1.ProtocolTCP, Port: port, CIDRs: o.APIServers})
+		}
+	}
+
+	if c.LeaderElectionType == "etcd" || c.Bootstrap == BootstrapEtcd {
+		for _, endpoint := range c.Etcd.Endpoints {
+			host, port, err := endpointAddress(endpoint)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse etcd endpoint [%s]: %v", endpoint, err)
+			}
+			flows = append(flows, Flow{Description: "etcd", Direction: networkingv1.PolicyTypeEgress, Protocol: corev1.ProtocolTCP, Port: port, CIDRs: []string{host}})
+		}
+	}
+
+	if c.EnableBGP {
+		// The peers can come from the environment, the flags used to generate a manifest or a single peer
+		peers := append([]bgp.Peer{}, c.BGPConfig.Peers...)
+		if len(c.BGPPeers) != 0 {
+			parsed, err := bgp.ParseBGPPeerConfig(strings.Join(c.BGPPeers, ","))
+			if err != nil {
+				return nil, err
+			}
+			peers = append(peers, parsed...)
+		}
+		if c.BGPPeerConfig.Address != "" {
+			peers = append(peers, c.BGPPeerConfig)
+		}
+
+		// The BGP server doesn't listen, sessions are always started by kube-vip
+		var addresses []string
+		for _, peer := range peers {
+			if slices.Contains(addresses, peer.Address) {
+				continue
+			}
+			addresses = append(addresses, peer.Address)
+			cidr, err := hostCIDR(peer.Address)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse BGP peer [%s]: %v", peer.Address, err)
+			}
+			flows = append(flows, Flow{Description: "bgp peer", Direction: networkingv1.PolicyTypeEgress, Protocol: corev1.ProtocolTCP, Port: 179, CIDRs: []string{cidr}})
+		}
+	}
+
+	if c.EnableWireguard {
+		port, meshPort := int32(c.WireguardOptions().ListenPort), int32(c.WireguardMeshOptions().ListenPort)
+		flows = append(flows,
+			Flow{Description: "wireguard", Direction: networkingv1.PolicyTypeIngress, Protocol: corev1.ProtocolUDP, Port: port},
+			Flow{Description: "wireguard", Direction: networkingv1.PolicyTypeEgress, Protocol: corev1.ProtocolUDP, Port: port},
+		)
+		if c.WireguardMesh {
+			flows = append(flows,
+				Flow{Description: "wireguard mesh", Direction: networkingv1.PolicyTypeIngress, Protocol: corev1.ProtocolUDP, Port: meshPort},
+				Flow{Description: "wireguard mesh", Direction: networkingv1.PolicyTypeEgress, Protocol: corev1.ProtocolUDP, Port: meshPort},
+			)
+		}
+	}
+
+	if c.DDNS || c.EnableServices {
+		flows = append(flows,
+			Flow{Description: "dhcp", Direction: networkingv1.PolicyTypeEgress, Protocol: corev1.ProtocolUDP, Port: 67},
+			Flow{Description: "dhcp", Direction: networkingv1.PolicyTypeIngress, Protocol: corev1.ProtocolUDP, Port: 68},
+		)
+	}
+
+	if c.EnableControlPlane && c.EnableLoadBalancer {
+		flows = append(flows, Flow{Description: "control plane load balancer", Direction: networkingv1.PolicyTypeIngress, Protocol: corev1.ProtocolTCP, Port: int32(c.LoadBalancerPort)})
+	}
+
+	if c.Coordination.Port != 0 {
+		flows = append(flows,
+			Flow{Description: "coordination", Direction: networkingv1.PolicyTypeIngress, Protocol: corev1.ProtocolTCP, Port: int32(c.Coordination.Port)},
+			Flow{Description: "coordination", Direction: networkingv1.PolicyTypeEgress, Protocol: corev1.ProtocolTCP, Port: int32(c.Coordination.Port)},
+		)
+	}
+
+	if c.PrometheusHTTPServer != "" {
+		_, port, err := net.SplitHostPort(c.PrometheusHTTPServer)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse prometheus address [%s]: %v", c.PrometheusHTTPServer, err)
+		}
+		p, err := strconv.ParseInt(port, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse prometheus port [%s]: %v", port, err)
+		}
+		flows = append(flows, Flow{Description: "prometheus metrics", Direction: networkingv1.PolicyTypeIngress, Protocol: corev1.ProtocolTCP, Port: int32(p), CIDRs: o.Prometheus})
+	}
+
+	return flows, nil
+}
+
+// hostCIDR returns the CIDR of a single address
+func hostCIDR(address string) (string, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return "", fmt.Errorf("invalid address")
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32", nil
+	}
+	return ip.String() + "/128", nil
+}
+
+// endpointAddress returns the CIDR and port of an etcd endpoint, e.g. https://192.168.0.1:2379
+func endpointAddress(endpoint string) (string, int32, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", 0, err
+	}
+	port := int64(2379)
+	if u.Port() != "" {
+		if port, err = strconv.ParseInt(u.Port(), 10, 32); err != nil {
+			return "", 0, err
+		}
+	}
+	cidr, err := hostCIDR(u.Hostname())
+	if err != nil {
+		return "", 0, err
+	}
+	return cidr, int32(port), nil
+}
+
+// GenerateNetworkPolicy will generate a NetworkPolicy for the kube-vip daemonset that only allows the flows, this
+// is only enforced for host network pods by CNIs that support it
+func GenerateNetworkPolicy(c *Config, flows []Flow) string {
+	namespace := c.Namespace
+	if namespace == "" {
+		namespace = "kube-system"
+	}
+
+	spec := applyNetworkingV1.NetworkPolicySpec().
+		WithPodSelector(applyMetaV1.LabelSelector().WithMatchLabels(map[string]string{"app.kubernetes.io/name": "kube-vip-ds"})).
+		WithPolicyTypes(networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress)
+
+	for _, flow := range flows {
+		port := applyNetworkingV1.NetworkPolicyPort().WithProtocol(flow.Protocol).WithPort(intstr.FromInt32(flow.Port))
+		var peers []*applyNetworkingV1.NetworkPolicyPeerApplyConfiguration
+		for _, cidr := range flow.CIDRs {
+			peers = append(peers, applyNetworkingV1.NetworkPolicyPeer().WithIPBlock(applyNetworkingV1.IPBlock().WithCIDR(cidr)))
+		}
+		if flow.Direction == networkingv1.PolicyTypeIngress {
+			spec.WithIngress(applyNetworkingV1.NetworkPolicyIngressRule().WithPorts(port).WithFrom(peers...))
+		} else {
+			spec.WithEgress(applyNetworkingV1.NetworkPolicyEgressRule().WithPorts(port).WithTo(peers...))
+		}
+	}
+
+	policy := applyNetworkingV1.NetworkPolicy("kube-vip", namespace).WithSpec(spec)
+	b, _ := yaml.Marshal(policy)
+	return string(b)
+}
+
+// GenerateNftablesRules will generate nftables chains that accept the flows, kube-vip runs in the host network so the
+// chains are left for the host firewall to jump to rather than changing the policy of the whole host
+func GenerateNftablesRules(flows []Flow) string {
+	var b strings.Builder
+	b.WriteString("# Flows required by kube-vip, jump to these chains from the host input and output chains\n")
+	b.WriteString("table inet kube-vip {\n")
+	for _, chain := range []struct {
+		name      string
+		direction networkingv1.PolicyType
+		match     string
+	}{
+		{"input", networkingv1.PolicyTypeIngress, "saddr"},
+		{"output", networkingv1.PolicyTypeEgress, "daddr"},
+	} {
+		fmt.Fprintf(&b, "\tchain %s {\n", chain.name)
+		// Replies to the flows in the other direction are accepted by connection tracking
+		b.WriteString("\t\tct state established,related accept\n")
+		for _, flow := range flows {
+			if flow.Direction != chain.direction {
+				continue
+			}
+			protocol := strings.ToLower(string(flow.Protocol))
+			if len(flow.CIDRs) == 0 {
+				fmt.Fprintf(&b, "\t\t%s dport %d accept comment \"%s\"\n", protocol, flow.Port, flow.Description)
+				continue
+			}
+			for _, cidr := range flow.CIDRs {
+				family := "ip"
+				if strings.Contains(cidr, ":") {
+					family = "ip6"
+				}
+				fmt.Fprintf(&b, "\t\t%s %s %s %s dport %d accept comment \"%s\"\n", family, chain.match, cidr, protocol, flow.Port, flow.Description)
+			}
+		}
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// CheckFirewallBackend will ensure that the backend of the firewall rules is known, an empty backend is detected
+func (c *Config) CheckFirewallBackend() error {
+	switch c.FirewallBackend {
+	case "", vip.FirewallIptables, vip.FirewallNftables:
+		return nil
+	}
+	return fmt.Errorf("unknown firewall backend [%s], use %s or %s", c.FirewallBackend, vip.FirewallIptables, vip.FirewallNftables)
+}
@@ -0,0 +1,66 @@
+package kubevip
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+)
+
+func TestGenerateFlows(t *testing.T) {
+	c := &Config{
+		Port:                 6443,
+		EnableBGP:            true,
+		EnableControlPlane:   true,
+		PrometheusHTTPServer: ":2112",
+		BGPConfig:            bgp.Config{Peers: []bgp.Peer{{Address: "192.168.0.1"}, {Address: "fd00::1"}}},
+	}
+	flows, err := GenerateFlows(c, FirewallOptions{Prometheus: []string{"10.0.0.0/8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := GenerateNftablesRules(flows)
+	for _, want := range []string{
+		"ip daddr 192.168.0.1/32 tcp dport 179 accept",
+		"ip6 daddr fd00::1/128 tcp dport 179 accept",
+		"ip saddr 10.0.0.0/8 tcp dport 2112 accept",
+		"tcp dport 6443 accept",
+	} {
+		if !strings.Contains(rules, want) {
+			t.Errorf("GenerateNftablesRules() missing [%s]:\n%s", want, rules)
+		}
+	}
+
+	policy := GenerateNetworkPolicy(c, flows)
+	for _, want := range []string{"cidr: 192.168.0.1/32", "port: 179", "- Egress"} {
+		if !strings.Contains(policy, want) {
+			t.Errorf("GenerateNetworkPolicy() missing [%s]:\n%s", want, policy)
+		}
+	}
+
+	if _, err := GenerateFlows(c, FirewallOptions{APIServers: []string{"10.0.0.1"}}); err == nil {
+		t.Errorf("GenerateFlows() expected an error for an invalid CIDR")
+	}
+}
+
+func TestCheckFirewallBackend(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		wantErr bool
+	}{
+		{"detected", "", false},
+		{"iptables", "iptables", false},
+		{"nftables", "nftables", false},
+		{"unknown", "ebpf", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{FirewallBackend: tt.backend}
+			if err := c.CheckFirewallBackend(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckFirewallBackend() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
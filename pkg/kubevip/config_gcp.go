@@ -0,0 +1,43 @@
+package kubevip
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// gcpResourceName is the format of the names of the Compute Engine resources (RFC1035)
+var gcpResourceName = regexp.MustCompile(`^[a-z]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// CheckGCP will ensure that the VIP is an IPv4 address that a route can point at the leader, and that the route and
+// network names are valid
+func (c *Config) CheckGCP() error {
+	if !c.EnableGCP {
+		return nil
+	}
+	if c.EnableMetal || c.EnableAWS {
+		return fmt.Errorf("gcp can't move the VIP together with metal or aws")
+	}
+	if c.Address != "" {
+		return fmt.Errorf("gcp only routes a VIP address to the leader, not the address [%s]", c.Address)
+	}
+	if c.VIP != "" {
+		vips := strings.Split(c.VIP, ",")
+		for _, vip := range vips {
+			if ip := net.ParseIP(vip); ip == nil || ip.To4() == nil {
+				return fmt.Errorf("gcp only routes IPv4 VIPs to the leader, not [%s]", vip)
+			}
+		}
+		if len(vips) > 1 && c.GCPRouteName != "" {
+			return fmt.Errorf("gcp route name [%s] can only be set for a single VIP", c.GCPRouteName)
+		}
+	}
+	if c.GCPRouteName != "" && !gcpResourceName.MatchString(c.GCPRouteName) {
+		return fmt.Errorf("gcp route name [%s] has to be lowercase letters, digits and hyphens, starting with a letter", c.GCPRouteName)
+	}
+	if c.GCPNetwork != "" && !gcpResourceName.MatchString(c.GCPNetwork) {
+		return fmt.Errorf("gcp network [%s] has to be the name of a network of the project", c.GCPNetwork)
+	}
+	return nil
+}
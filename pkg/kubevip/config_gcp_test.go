@@ -0,0 +1,28 @@
+package kubevip
+
+import "testing"
+
+func TestCheckGCP(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"disabled", Config{Address: "vip.example.com", GCPRouteName: "Invalid"}, false},
+		{"vip", Config{EnableGCP: true, VIP: "10.128.0.100"}, false},
+		{"route and network", Config{EnableGCP: true, VIP: "10.128.0.100", GCPRouteName: "control-plane-vip", GCPNetwork: "default"}, false},
+		{"invalid route name", Config{EnableGCP: true, VIP: "10.128.0.100", GCPRouteName: "Control_Plane"}, true},
+		{"network url", Config{EnableGCP: true, VIP: "10.128.0.100", GCPNetwork: "projects/p/global/networks/default"}, true},
+		{"route name for two vips", Config{EnableGCP: true, VIP: "10.128.0.100,10.128.0.101", GCPRouteName: "control-plane-vip"}, true},
+		{"ipv6 vip", Config{EnableGCP: true, VIP: "2001:db8::100"}, true},
+		{"dns address", Config{EnableGCP: true, Address: "vip.example.com"}, true},
+		{"aws", Config{EnableGCP: true, EnableAWS: true, VIP: "10.128.0.100"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.CheckGCP(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckGCP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
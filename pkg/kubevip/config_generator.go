@@ -1,8 +1,11 @@
 package kubevip
 
 import (
+	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	appv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -12,6 +15,8 @@ import (
 	applyRbacV1 "k8s.io/client-go/applyconfigurations/rbac/v1"
 
 	"sigs.k8s.io/yaml"
+
+	"github.com/kube-vip/kube-vip/pkg/loadbalancer"
 )
 
 // GenerateSA will create the service account for kube-vip
@@ -61,6 +66,16 @@ func GenerateCR() *applyRbacV1.ClusterRoleApplyConfiguration {
 				Resources: []string{"leases"},
 				Verbs:     []string{"list", "get", "watch", "update", "create"},
 			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"secrets"},
+				Verbs:     []string{"get", "watch"},
+			},
+			{
+				APIGroups: []string{"kube-vip.io"},
+				Resources: []string{"kubevipservicepolicies"},
+				Verbs:     []string{"list"},
+			},
 		},
 	}
 	return newManifest
@@ -189,6 +204,69 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 		newEnvironment = append(newEnvironment, dnsModeSelector...)
 	}
 
+	if c.DNSRefreshInterval != 0 {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  dnsRefreshInterval,
+			Value: strconv.Itoa(c.DNSRefreshInterval),
+		})
+	}
+
+	if c.DNSHealthCheck {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  dnsHealthCheck,
+			Value: strconv.FormatBool(c.DNSHealthCheck),
+		})
+	}
+
+	if c.DDNSProvider != "" && c.DDNSProvider != DDNSProviderDHCP {
+		newEnvironment = append(newEnvironment, []corev1.EnvVar{
+			{
+				Name:  ddnsProvider,
+				Value: c.DDNSProvider,
+			},
+			{
+				Name:  ddnsSecret,
+				Value: c.DDNSSecret,
+			},
+			{
+				Name:  ddnsTTL,
+				Value: strconv.Itoa(c.DDNSTTL),
+			},
+		}...)
+	}
+
+	if c.ExternalDNSTemplate != "" {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  externalDNSTemplate,
+			Value: c.ExternalDNSTemplate,
+		})
+		if c.ExternalDNSTarget != "" {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{
+				Name:  externalDNSTarget,
+				Value: c.ExternalDNSTarget,
+			})
+		}
+	}
+
+	if c.DNSServerZone != "" {
+		newEnvironment = append(newEnvironment, []corev1.EnvVar{
+			{
+				Name:  dnsServerZone,
+				Value: c.DNSServerZone,
+			},
+			{
+				Name:  dnsServerPort,
+				Value: strconv.Itoa(c.DNSServerPort),
+			},
+		}...)
+		if c.DNSServerAddress != "" {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{
+				Name:  dnsServerAddress,
+				Value: c.DNSServerAddress,
+			})
+		}
+	}
+
 	// If we're doing the hybrid mode
 	if c.EnableControlPlane {
 		cp := []corev1.EnvVar{
@@ -216,6 +294,19 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 		newEnvironment = append(newEnvironment, cp...)
 	}
 
+	if c.ControlPlaneDNS != "" {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  cpDNS,
+			Value: c.ControlPlaneDNS,
+		})
+		if c.ControlPlaneDNSMode != "" && c.ControlPlaneDNSMode != ControlPlaneDNSNodes {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{
+				Name:  cpDNSMode,
+				Value: c.ControlPlaneDNSMode,
+			})
+		}
+	}
+
 	// If we're doing the hybrid mode
 	if c.EnableServices {
 		svc := []corev1.EnvVar{
@@ -229,6 +320,18 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 			},
 		}
 		newEnvironment = append(newEnvironment, svc...)
+		if c.ServicesWorkers != 0 {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{
+				Name:  svcWorkers,
+				Value: strconv.Itoa(c.ServicesWorkers),
+			})
+		}
+		if c.ServicesSelector != "" {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{
+				Name:  svcSelector,
+				Value: c.ServicesSelector,
+			})
+		}
 		if c.EnableServicesElection {
 			svcElection := []corev1.EnvVar{
 				{
@@ -238,6 +341,18 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 			}
 			newEnvironment = append(newEnvironment, svcElection...)
 		}
+		if c.ServicesElectionShards != 0 {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{
+				Name:  svcElectionShards,
+				Value: strconv.Itoa(c.ServicesElectionShards),
+			})
+		}
+		if c.EnableIPAM {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{
+				Name:  svcIPAM,
+				Value: strconv.FormatBool(c.EnableIPAM),
+			})
+		}
 		if c.LoadBalancerClassOnly {
 			lbClassOnlyVar := []corev1.EnvVar{
 				{
@@ -301,6 +416,60 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 		newEnvironment = append(newEnvironment, leaderElection...)
 	}
 
+	// If the control plane VIP is brought up before the API server (Cluster API)
+	if c.Bootstrap != "" {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  vipBootstrap,
+			Value: c.Bootstrap,
+		})
+		if len(c.BootstrapPeers) != 0 {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{
+				Name:  vipBootstrapPeers,
+				Value: strings.Join(c.BootstrapPeers, ","),
+			})
+		}
+	}
+
+	// If the control plane VIP is handed over to the kube-vip that replaces this one
+	if c.Handover {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  vipHandover,
+			Value: strconv.FormatBool(c.Handover),
+		}, corev1.EnvVar{
+			Name:  vipHandoverPath,
+			Value: c.HandoverPath,
+		})
+	}
+
+	// If the control plane VIP is elected with VRRP
+	if c.EnableVRRP {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  vipVRRP,
+			Value: strconv.FormatBool(c.EnableVRRP),
+		}, corev1.EnvVar{
+			Name:  vrrpRouterID,
+			Value: strconv.Itoa(c.VRRP.RouterID),
+		}, corev1.EnvVar{
+			Name:  vrrpPriority,
+			Value: strconv.Itoa(c.VRRP.Priority),
+		}, corev1.EnvVar{
+			Name:  vrrpAdvertInterval,
+			Value: strconv.Itoa(c.VRRP.AdvertInterval),
+		}, corev1.EnvVar{
+			Name:  vrrpPreempt,
+			Value: strconv.FormatBool(c.VRRP.Preempt),
+		}, corev1.EnvVar{
+			Name:  vrrpVersion,
+			Value: strconv.Itoa(c.VRRP.Version),
+		})
+		if c.VRRP.Password != "" {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{
+				Name:  vrrpPassword,
+				Value: c.VRRP.Password,
+			})
+		}
+	}
+
 	// If we're enabling node labeling on leader election
 	if c.EnableNodeLabeling {
 		EnableNodeLabeling := []corev1.EnvVar{
@@ -354,10 +523,139 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 				Name:  "PACKET_AUTH_TOKEN",
 				Value: c.MetalAPIKey,
 			},
+			{
+				Name:  vipPacketEIPSyncInterval,
+				Value: strconv.Itoa(c.MetalEIPSyncInterval),
+			},
+		}
+		if c.MetalEIPTag != "" {
+			packet = append(packet, corev1.EnvVar{
+				Name:  vipPacketEIPTag,
+				Value: c.MetalEIPTag,
+			})
 		}
 		newEnvironment = append(newEnvironment, packet...)
 	}
 
+	// If AWS is enabled then add it to the manifest
+	if c.EnableAWS {
+		aws := []corev1.EnvVar{
+			{
+				Name:  vipAWS,
+				Value: strconv.FormatBool(c.EnableAWS),
+			},
+		}
+		if c.AWSRegion != "" {
+			aws = append(aws, corev1.EnvVar{
+				Name:  awsRegion,
+				Value: c.AWSRegion,
+			})
+		}
+		if c.AWSEIPAllocationID != "" {
+			aws = append(aws, corev1.EnvVar{
+				Name:  awsEIPAllocationID,
+				Value: c.AWSEIPAllocationID,
+			})
+		}
+		newEnvironment = append(newEnvironment, aws...)
+	}
+
+	// If GCP is enabled then add it to the manifest
+	if c.EnableGCP {
+		gcp := []corev1.EnvVar{
+			{
+				Name:  vipGCP,
+				Value: strconv.FormatBool(c.EnableGCP),
+			},
+		}
+		if c.GCPRouteName != "" {
+			gcp = append(gcp, corev1.EnvVar{
+				Name:  gcpRouteName,
+				Value: c.GCPRouteName,
+			})
+		}
+		if c.GCPNetwork != "" {
+			gcp = append(gcp, corev1.EnvVar{
+				Name:  gcpNetwork,
+				Value: c.GCPNetwork,
+			})
+		}
+		newEnvironment = append(newEnvironment, gcp...)
+	}
+
+	// If Azure is enabled then add it to the manifest
+	if c.EnableAzure {
+		azure := []corev1.EnvVar{
+			{
+				Name:  vipAzure,
+				Value: strconv.FormatBool(c.EnableAzure),
+			},
+		}
+		if c.AzureIPConfigName != "" {
+			azure = append(azure, corev1.EnvVar{
+				Name:  azureIPConfigName,
+				Value: c.AzureIPConfigName,
+			})
+		}
+		if c.AzurePublicIPID != "" {
+			azure = append(azure, corev1.EnvVar{
+				Name:  azurePublicIPID,
+				Value: c.AzurePublicIPID,
+			})
+		}
+		if c.AzureClientID != "" {
+			azure = append(azure, corev1.EnvVar{
+				Name:  azureClientID,
+				Value: c.AzureClientID,
+			})
+		}
+		newEnvironment = append(newEnvironment, azure...)
+	}
+
+	// If OpenStack is enabled then add it to the manifest, the credentials are the OS_* variables of the user
+	if c.EnableOpenStack {
+		openstack := []corev1.EnvVar{
+			{
+				Name:  vipOpenStack,
+				Value: strconv.FormatBool(c.EnableOpenStack),
+			},
+		}
+		if len(c.OpenStackPools) != 0 {
+			openstack = append(openstack, corev1.EnvVar{
+				Name:  openStackPools,
+				Value: strings.Join(c.OpenStackPools, ","),
+			})
+		}
+		if c.OpenStackPoolsConfigMap != "" {
+			openstack = append(openstack, corev1.EnvVar{
+				Name:  openStackPoolsConfigMap,
+				Value: c.OpenStackPoolsConfigMap,
+			})
+		}
+		newEnvironment = append(newEnvironment, openstack...)
+	}
+
+	// If NSX is enabled then add it to the manifest, the credentials are the NSX_* variables of the user
+	if c.EnableNSX {
+		nsx := []corev1.EnvVar{
+			{
+				Name:  vipNSX,
+				Value: strconv.FormatBool(c.EnableNSX),
+			},
+			{
+				Name:  nsxManager,
+				Value: c.NSXManager,
+			},
+		}
+		if c.NSXInsecure {
+			nsx = append(nsx, corev1.EnvVar{
+				Name:  nsxInsecure,
+				Value: strconv.FormatBool(c.NSXInsecure),
+			})
+		}
+		newEnvironment = append(newEnvironment, nsx...)
+	}
+
 	// Detect and enable wireguard mode
 	if c.EnableWireguard {
 		wireguard := []corev1.EnvVar{
@@ -366,6 +664,66 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 				Value: strconv.FormatBool(c.EnableWireguard),
 			},
 		}
+		if c.WireguardSecret != "" {
+			wireguard = append(wireguard, corev1.EnvVar{
+				Name:  wireguardSecret,
+				Value: c.WireguardSecret,
+			})
+		}
+		if c.WireguardGenerateKeys {
+			wireguard = append(wireguard, corev1.EnvVar{
+				Name:  wireguardGenerateKeys,
+				Value: strconv.FormatBool(c.WireguardGenerateKeys),
+			})
+		}
+		if c.WireguardMesh {
+			wireguard = append(wireguard, corev1.EnvVar{
+				Name:  wireguardMesh,
+				Value: strconv.FormatBool(c.WireguardMesh),
+			})
+		}
+		if c.WireguardMeshSecret != "" {
+			wireguard = append(wireguard, corev1.EnvVar{
+				Name:  wireguardMeshSecret,
+				Value: c.WireguardMeshSecret,
+			})
+		}
+		if c.WireguardHandshakeTimeout > 0 {
+			wireguard = append(wireguard, corev1.EnvVar{
+				Name:  wireguardHandshakeTimeout,
+				Value: strconv.Itoa(c.WireguardHandshakeTimeout),
+			})
+		}
+		if c.WireguardProbeAddress != "" {
+			wireguard = append(wireguard, corev1.EnvVar{
+				Name:  wireguardProbeAddress,
+				Value: c.WireguardProbeAddress,
+			})
+		}
+		if c.WireguardPort != 0 {
+			wireguard = append(wireguard, corev1.EnvVar{
+				Name:  wireguardPort,
+				Value: strconv.Itoa(c.WireguardPort),
+			})
+		}
+		if c.WireguardMeshPort != 0 {
+			wireguard = append(wireguard, corev1.EnvVar{
+				Name:  wireguardMeshPort,
+				Value: strconv.Itoa(c.WireguardMeshPort),
+			})
+		}
+		if c.WireguardMTU != 0 {
+			wireguard = append(wireguard, corev1.EnvVar{
+				Name:  wireguardMTU,
+				Value: strconv.Itoa(c.WireguardMTU),
+			})
+		}
+		if c.WireguardFirewallMark != 0 {
+			wireguard = append(wireguard, corev1.EnvVar{
+				Name:  wireguardFirewallMark,
+				Value: strconv.Itoa(c.WireguardFirewallMark),
+			})
+		}
 		newEnvironment = append(newEnvironment, wireguard...)
 	}
 
@@ -377,9 +735,65 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 				Value: strconv.FormatBool(c.EnableRoutingTable),
 			},
 		}
+		if c.RoutingProtocol != 0 {
+			routingtable = append(routingtable, corev1.EnvVar{
+				Name:  vipRoutingProtocol,
+				Value: strconv.Itoa(c.RoutingProtocol),
+			})
+		}
+		if c.RoutingMetric != 0 {
+			routingtable = append(routingtable, corev1.EnvVar{
+				Name:  vipRoutingMetric,
+				Value: strconv.Itoa(c.RoutingMetric),
+			})
+		}
+		if len(c.RoutingNextHops) != 0 {
+			routingtable = append(routingtable, corev1.EnvVar{
+				Name:  vipRoutingNextHops,
+				Value: strings.Join(c.RoutingNextHops, ","),
+			})
+		}
+		if c.RoutingScope != "" {
+			routingtable = append(routingtable, corev1.EnvVar{
+				Name:  vipRoutingScope,
+				Value: c.RoutingScope,
+			})
+		}
+		if c.RoutingOnLink {
+			routingtable = append(routingtable, corev1.EnvVar{
+				Name:  vipRoutingOnLink,
+				Value: strconv.FormatBool(c.RoutingOnLink),
+			})
+		}
+		if c.RoutingSource != "" {
+			routingtable = append(routingtable, corev1.EnvVar{
+				Name:  vipRoutingSource,
+				Value: c.RoutingSource,
+			})
+		}
+		if len(c.RoutingTableNamespaces) != 0 {
+			routingtable = append(routingtable, corev1.EnvVar{
+				Name:  vipRoutingTableNamespaces,
+				Value: strings.Join(c.RoutingTableNamespaces, ","),
+			})
+		}
+		if c.RoutingTableVRF != "" {
+			routingtable = append(routingtable, corev1.EnvVar{
+				Name:  vipRoutingTableVRF,
+				Value: c.RoutingTableVRF,
+			})
+		}
 		newEnvironment = append(newEnvironment, routingtable...)
 	}
 
+	// Blackhole routes for the unallocated addresses of the pools
+	if len(c.BlackholePools) != 0 {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  vipBlackholePools,
+			Value: strings.Join(c.BlackholePools, ","),
+		})
+	}
+
 	// If BGP, but we're not using Equinix Metal
 	if c.EnableBGP {
 		bgp := []corev1.EnvVar{
@@ -415,6 +829,15 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 			},
 		}
 
+		// Detect if the BGP peer password(s) should be read from a secret
+		if c.BGPPeerSecret != "" {
+			bgpConfig = append(bgpConfig, corev1.EnvVar{
+				Name:  bgpPeerSecret,
+				Value: c.BGPPeerSecret,
+			},
+			)
+		}
+
 		// Detect if we should be using a source interface for speaking to a bgp peer
 		if c.BGPConfig.SourceIF != "" {
 			bgpConfig = append(bgpConfig, corev1.EnvVar{
@@ -453,6 +876,31 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 
 	}
 
+	// If the peers keep the routes while kube-vip restarts
+	if c.EnableBGP && c.BGPConfig.GracefulRestart {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  bgpGracefulRestart,
+			Value: strconv.FormatBool(c.BGPConfig.GracefulRestart),
+		}, corev1.EnvVar{
+			Name:  bgpRestartTime,
+			Value: fmt.Sprintf("%d", c.BGPConfig.RestartTime),
+		})
+	}
+
+	// If the gratuitous ARP/NDP updates are repeated when a VIP is acquired
+	if c.EnableARP && (c.ArpRepeat != 0 || c.ArpBackoff) {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  vipArpRepeat,
+			Value: strconv.Itoa(c.ArpRepeat),
+		}, corev1.EnvVar{
+			Name:  vipArpRepeatInterval,
+			Value: fmt.Sprintf("%d", c.ArpRepeatInterval),
+		}, corev1.EnvVar{
+			Name:  vipArpBackoff,
+			Value: strconv.FormatBool(c.ArpBackoff),
+		})
+	}
+
 	// If the load-balancer is enabled then add the configuration to the manifest
 	if c.EnableLoadBalancer {
 		lb := []corev1.EnvVar{
@@ -469,10 +917,41 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 				Value: c.LoadBalancerForwardingMethod,
 			},
 		}
+		if c.LoadBalancerDataplane != "" && c.LoadBalancerDataplane != LoadBalancerIPVS {
+			lb = append(lb, corev1.EnvVar{
+				Name:  lbDataplane,
+				Value: c.LoadBalancerDataplane,
+			})
+		}
+		if c.LoadBalancerScheduler != "" && c.LoadBalancerScheduler != loadbalancer.ROUNDROBIN {
+			lb = append(lb, corev1.EnvVar{
+				Name:  lbScheduler,
+				Value: c.LoadBalancerScheduler,
+			})
+		}
+		if c.LoadBalancerPersistenceTimeout != 0 {
+			lb = append(lb, corev1.EnvVar{
+				Name:  lbPersistenceTimeout,
+				Value: strconv.Itoa(c.LoadBalancerPersistenceTimeout),
+			})
+		}
+		if c.LoadBalancerBackends != "" && c.LoadBalancerBackends != LoadBalancerBackendsNodes {
+			lb = append(lb, corev1.EnvVar{
+				Name:  lbBackends,
+				Value: c.LoadBalancerBackends,
+			})
+		}
 
 		newEnvironment = append(newEnvironment, lb...)
 	}
 
+	if c.FirewallBackend != "" {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  firewallBackend,
+			Value: c.FirewallBackend,
+		})
+	}
+
 	if c.Address != "" {
 		newEnvironment = append(newEnvironment, corev1.EnvVar{
 			Name:  address,
@@ -485,6 +964,35 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 		})
 	}
 
+	if c.ControlPlaneEngine != "" {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  cpEngine,
+			Value: c.ControlPlaneEngine,
+		})
+	}
+
+	if c.ServicesEngine != "" {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  svcEngine,
+			Value: c.ServicesEngine,
+		})
+	}
+
+	if c.FeatureGates != "" {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  featureGates,
+			Value: c.FeatureGates,
+		})
+	}
+
+	if len(c.VIPGroups) != 0 {
+		groups, _ := json.Marshal(c.VIPGroups)
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  vipGroups,
+			Value: string(groups),
+		})
+	}
+
 	if c.PrometheusHTTPServer != "" {
 		prometheus := []corev1.EnvVar{
 			{
@@ -495,6 +1003,60 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 		newEnvironment = append(newEnvironment, prometheus...)
 	}
 
+	for _, tlsEnv := range []corev1.EnvVar{
+		{Name: httpTLSCertFile, Value: c.HTTPTLS.CertFile},
+		{Name: httpTLSKeyFile, Value: c.HTTPTLS.KeyFile},
+		{Name: httpTLSClientCAFile, Value: c.HTTPTLS.ClientCAFile},
+		{Name: httpTLSSecret, Value: c.HTTPTLS.Secret},
+	} {
+		if tlsEnv.Value != "" {
+			newEnvironment = append(newEnvironment, tlsEnv)
+		}
+	}
+
+	if c.Coordination.Port != 0 {
+		for _, coordinationEnv := range []corev1.EnvVar{
+			{Name: coordinationPort, Value: strconv.Itoa(c.Coordination.Port)},
+			{Name: coordinationSecret, Value: c.Coordination.Secret},
+			{Name: coordinationSignerName, Value: c.Coordination.SignerName},
+		} {
+			if coordinationEnv.Value != "" {
+				newEnvironment = append(newEnvironment, coordinationEnv)
+			}
+		}
+	}
+
+	if c.SPIFFE.Socket != "" {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{Name: spiffeSocket, Value: c.SPIFFE.Socket})
+		if c.SPIFFE.Kubernetes {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{Name: spiffeKubernetes, Value: "true"})
+		}
+		if c.SPIFFE.NodeIDTemplate != "" {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{Name: spiffeNodeIDTemplate, Value: c.SPIFFE.NodeIDTemplate})
+		}
+	}
+
+	if c.SignatureKey != "" {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  signatureKey,
+			Value: c.SignatureKey,
+		})
+	}
+
+	if c.SecurityWebhook != "" {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  securityWebhook,
+			Value: c.SecurityWebhook,
+		})
+	}
+
+	if c.FIPS {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  vipFIPS,
+			Value: strconv.FormatBool(c.FIPS),
+		})
+	}
+
 	if c.EnableEndpointSlices {
 		newEnvironment = append(newEnvironment, corev1.EnvVar{
 			Name:  enableEndpointSlices,
@@ -513,6 +1075,13 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 		newEnvironment = append(newEnvironment, disServiceUpdates...)
 	}
 
+	if c.EnableServiceEvents {
+		newEnvironment = append(newEnvironment, corev1.EnvVar{
+			Name:  enableServiceEvents,
+			Value: strconv.FormatBool(c.EnableServiceEvents),
+		})
+	}
+
 	if c.MirrorDestInterface != "" {
 		mdif := []corev1.EnvVar{
 			{
@@ -523,31 +1092,84 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 		newEnvironment = append(newEnvironment, mdif...)
 	}
 
+	if c.EnableUPNP {
+		newEnvironment = append(newEnvironment, []corev1.EnvVar{
+			{
+				Name:  enableUPNP,
+				Value: strconv.FormatBool(c.EnableUPNP),
+			},
+			{
+				Name:  upnpLeaseDuration,
+				Value: strconv.Itoa(c.UPNPLeaseDuration),
+			},
+			{
+				Name:  upnpProtocol,
+				Value: c.UPNPProtocol,
+			},
+		}...)
+		if c.UPNPOptIn {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{
+				Name:  upnpOptIn,
+				Value: strconv.FormatBool(c.UPNPOptIn),
+			})
+		}
+		if c.UPNPGatewayURL != "" {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{
+				Name:  upnpGatewayURL,
+				Value: c.UPNPGatewayURL,
+			})
+		}
+		if c.UPNPInterface != "" {
+			newEnvironment = append(newEnvironment, corev1.EnvVar{
+				Name:  upnpInterface,
+				Value: c.UPNPInterface,
+			})
+		}
+	}
+
 	var securityContext *corev1.SecurityContext
 	if c.LoadBalancerForwardingMethod == "masquerade" {
+		// Masquerade writes sysctls on the node, which needs a privileged container unless they're already set
 		var privileged = true
 		securityContext = &corev1.SecurityContext{
 			Privileged: &privileged,
 		}
 	} else {
+		// Only the capabilities needed by the enabled features are added, everything else is dropped
+		var capabilities []corev1.Capability
+		for _, capability := range c.RequiredCapabilities() {
+			capabilities = append(capabilities, corev1.Capability(capability))
+		}
+		allowPrivilegeEscalation := false
 		securityContext = &corev1.SecurityContext{
+			AllowPrivilegeEscalation: &allowPrivilegeEscalation,
 			Capabilities: &corev1.Capabilities{
-				Add: []corev1.Capability{
-					"NET_ADMIN",
-					"NET_RAW",
-				},
+				Add:  capabilities,
+				Drop: []corev1.Capability{"ALL"},
 			},
 		}
 	}
 
+	var annotations map[string]string
+	if c.ManifestSecurityProfiles {
+		// Privileged containers run unconfined, so the profiles only apply without masquerade
+		localhostProfile := SeccompProfileName
+		securityContext.SeccompProfile = &corev1.SeccompProfile{
+			Type:             corev1.SeccompProfileTypeLocalhost,
+			LocalhostProfile: &localhostProfile,
+		}
+		annotations = map[string]string{appArmorAnnotation: "localhost/" + AppArmorProfileName}
+	}
+
 	newManifest := &corev1.Pod{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Pod",
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "kube-vip",
-			Namespace: namespace,
+			Name:        "kube-vip",
+			Namespace:   namespace,
+			Annotations: annotations,
 		},
 		Spec: corev1.PodSpec{
 			Containers: []corev1.Container{
@@ -569,6 +1191,13 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 	if inCluster {
 		// If we're running this inCluster then the account name will be required
 		newManifest.Spec.ServiceAccountName = "kube-vip"
+	} else if c.ImmutableOS {
+		// An immutable host has no kubeconfig to mount, the credentials are passed in the environment instead
+		newManifest.Spec.Containers[0].Env = append(newManifest.Spec.Containers[0].Env, immutableOSEnvironment(c)...)
+		newManifest.Spec.HostAliases = append(newManifest.Spec.HostAliases, corev1.HostAlias{
+			IP:        "127.0.0.1",
+			Hostnames: []string{"kubernetes"},
+		})
 	} else {
 		// If this isn't inside a cluster then add the external path mount
 		adminConfMount := corev1.VolumeMount{
@@ -614,6 +1243,48 @@ func generatePodSpec(c *Config, imageVersion string, inCluster bool) *corev1.Pod
 
 	}
 
+	if socket, ok := strings.CutPrefix(c.SPIFFE.Socket, "unix://"); ok {
+		// The Workload API socket is shared by the agent in a host directory
+		socketDir := filepath.Dir(socket)
+		newManifest.Spec.Containers[0].VolumeMounts = append(newManifest.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      "spiffe-workload-api",
+			MountPath: socketDir,
+			ReadOnly:  true,
+		})
+		hostPathType := corev1.HostPathDirectory
+		newManifest.Spec.Volumes = append(newManifest.Spec.Volumes, corev1.Volume{
+			Name: "spiffe-workload-api",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: socketDir,
+					Type: &hostPathType,
+				},
+			},
+		})
+	}
+
+	if c.Handover {
+		// The state is handed over in a host directory, that outlives the pod
+		handoverDir := filepath.Dir(c.HandoverPath)
+		newManifest.Spec.Containers[0].VolumeMounts = append(newManifest.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      "handover",
+			MountPath: handoverDir,
+		})
+		hostPathType := corev1.HostPathDirectoryOrCreate
+		newManifest.Spec.Volumes = append(newManifest.Spec.Volumes, corev1.Volume{
+			Name: "handover",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: handoverDir,
+					Type: &hostPathType,
+				},
+			},
+		})
+	}
+
+	// Add any user specified sidecars, volumes and environment
+	applyManifestExtras(newManifest, c.ManifestExtras)
+
 	return newManifest
 }
 
@@ -634,7 +1305,8 @@ func GenerateDaemonsetManifestFromConfig(c *Config, imageVersion string, inClust
 		namespace = metav1.NamespaceSystem
 	}
 
-	podSpec := generatePodSpec(c, imageVersion, inCluster).Spec
+	pod := generatePodSpec(c, imageVersion, inCluster)
+	podSpec := pod.Spec
 	newManifest := &appv1.DaemonSet{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "DaemonSet",
@@ -660,6 +1332,7 @@ func GenerateDaemonsetManifestFromConfig(c *Config, imageVersion string, inClust
 						"app.kubernetes.io/name":    "kube-vip-ds",
 						"app.kubernetes.io/version": imageVersion,
 					},
+					Annotations: pod.Annotations,
 				},
 				Spec: podSpec,
 			},
@@ -701,6 +1374,8 @@ func GenerateDaemonsetManifestFromConfig(c *Config, imageVersion string, inClust
 			},
 		}
 	}
+	applyManifestScheduling(&newManifest.Spec.Template.Spec, c.ManifestScheduling)
+
 	b, _ := yaml.Marshal(newManifest)
 	return string(b)
 }
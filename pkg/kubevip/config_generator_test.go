@@ -1,6 +1,11 @@
 package kubevip
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
 
 func TestParseEnvironment(t *testing.T) {
 
@@ -21,3 +26,195 @@ func TestParseEnvironment(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateKustomizeFromConfig(t *testing.T) {
+	files := GenerateKustomizeFromConfig(&Config{Interface: "eth0", VIP: "192.168.0.1"}, "v0.0.0", true, false)
+
+	tests := []string{
+		"base/kustomization.yaml",
+		"base/rbac.yaml",
+		"overlays/rbac/kustomization.yaml",
+		"overlays/daemonset/kustomization.yaml",
+		"overlays/daemonset/daemonset.yaml",
+		"overlays/pod/kustomization.yaml",
+		"overlays/pod/pod.yaml",
+	}
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			if files[tt] == "" {
+				t.Errorf("GenerateKustomizeFromConfig() missing file %s", tt)
+			}
+		})
+	}
+	if len(files) != len(tests) {
+		t.Errorf("GenerateKustomizeFromConfig() generated %d files, want %d", len(files), len(tests))
+	}
+}
+
+func TestGenerateHelmValuesFromConfig(t *testing.T) {
+	values := GenerateHelmValuesFromConfig(&Config{Interface: "eth0", VIP: "192.168.0.1", EnableARP: true}, "v0.0.0", true)
+
+	for _, want := range []string{"address: 192.168.0.1", "vip_arp: \"true\"", "vip_interface: eth0", "tag: v0.0.0", "vip_nodename:"} {
+		if !strings.Contains(values, want) {
+			t.Errorf("GenerateHelmValuesFromConfig() missing %q in:\n%s", want, values)
+		}
+	}
+}
+
+func TestGeneratePodManifestWithExtras(t *testing.T) {
+	env, err := ParseExtraEnv([]string{"HTTP_PROXY=http://proxy:3128"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ParseExtraEnv([]string{"=value"}); err == nil {
+		t.Errorf("ParseExtraEnv() expected error for missing name")
+	}
+
+	c := &Config{
+		VIP: "192.168.0.1",
+		ManifestExtras: &ManifestExtras{
+			Containers: []corev1.Container{{Name: "node-exporter", Image: "prom/node-exporter"}},
+			Env:        env,
+		},
+	}
+	pod := generatePodSpec(c, "v0.0.0", true)
+	if len(pod.Spec.Containers) != 2 || pod.Spec.Containers[1].Name != "node-exporter" {
+		t.Errorf("generatePodSpec() sidecar not added, containers = %v", pod.Spec.Containers)
+	}
+	found := false
+	for _, e := range pod.Spec.Containers[0].Env {
+		if e.Name == "HTTP_PROXY" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("generatePodSpec() extra env not added to the kube-vip container")
+	}
+}
+
+func TestGenerateDaemonsetManifestWithScheduling(t *testing.T) {
+	tolerations, err := ParseTolerations([]string{"dedicated=lb:NoSchedule", "node.kubernetes.io/unreachable:NoExecute", ":NoSchedule"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tolerations[0].Operator != corev1.TolerationOpEqual || tolerations[0].Value != "lb" || tolerations[1].Operator != corev1.TolerationOpExists || tolerations[2].Key != "" {
+		t.Errorf("ParseTolerations() = %v", tolerations)
+	}
+	if _, err = ParseTolerations([]string{"dedicated:Sometimes"}); err == nil {
+		t.Errorf("ParseTolerations() expected error for an unknown effect")
+	}
+
+	requests, err := ParseResourceList(map[string]string{"cpu": "100m", "memory": "64Mi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ParseResourceList(map[string]string{"cpu": "lots"}); err == nil {
+		t.Errorf("ParseResourceList() expected error for an invalid quantity")
+	}
+
+	c := &Config{
+		VIP: "192.168.0.1",
+		ManifestScheduling: &ManifestScheduling{
+			Tolerations:       tolerations,
+			NodeSelector:      map[string]string{"kube-vip.io/lb": "true"},
+			PriorityClassName: "system-node-critical",
+			Resources:         corev1.ResourceRequirements{Requests: requests},
+		},
+	}
+	manifest := GenerateDaemonsetManifestFromConfig(c, "v0.0.0", true, true)
+	for _, want := range []string{"priorityClassName: system-node-critical", "kube-vip.io/lb: \"true\"", "value: lb", "cpu: 100m", "node-role.kubernetes.io/control-plane"} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("GenerateDaemonsetManifestFromConfig() missing [%s]", want)
+		}
+	}
+}
+
+func TestGeneratePodSpecSecurityContext(t *testing.T) {
+	bgp := generatePodSpec(&Config{EnableBGP: true, EnableControlPlane: true}, "v0.0.0", true).Spec.Containers[0].SecurityContext
+	if bgp.Privileged != nil || *bgp.AllowPrivilegeEscalation || len(bgp.Capabilities.Add) != 1 || bgp.Capabilities.Drop[0] != "ALL" {
+		t.Errorf("generatePodSpec() BGP control plane security context = %v, want only NET_ADMIN", bgp)
+	}
+
+	arp := generatePodSpec(&Config{EnableARP: true, EnableServices: true}, "v0.0.0", true).Spec.Containers[0].SecurityContext
+	if len(arp.Capabilities.Add) != 2 {
+		t.Errorf("generatePodSpec() ARP security context = %v, want NET_ADMIN and NET_RAW", arp)
+	}
+}
+
+func TestGeneratePodSpecImmutableOS(t *testing.T) {
+	pod := generatePodSpec(&Config{
+		ImmutableOS:   true,
+		K8sConfigFile: "/etc/kubernetes/admin.conf",
+		APIServer:     "https://10.0.0.10:6443",
+		APIToken:      "abcdef.0123456789abcdef",
+	}, "v0.0.0", false)
+	if len(pod.Spec.Volumes) != 0 || len(pod.Spec.Containers[0].VolumeMounts) != 0 {
+		t.Errorf("generatePodSpec() mounts %v from the host of an immutable OS", pod.Spec.Volumes)
+	}
+	env := map[string]string{}
+	for _, e := range pod.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	if env[vipImmutableOS] != "true" || env[vipAPIServer] != "https://10.0.0.10:6443" || env[vipAPIToken] != "abcdef.0123456789abcdef" {
+		t.Errorf("generatePodSpec() environment = %v, want the API server and token", env)
+	}
+}
+
+func TestSecretReferences(t *testing.T) {
+	tests := []struct {
+		name string
+		c    *Config
+		env  map[string]string
+	}{
+		{
+			name: "bgp peer secret",
+			c:    &Config{EnableBGP: true, BGPPeerSecret: "bgp-peers"},
+			env:  map[string]string{bgpPeerSecret: "bgp-peers"},
+		},
+		{
+			name: "wireguard secret",
+			c:    &Config{EnableWireguard: true, WireguardSecret: "wireguard-keys"},
+			env:  map[string]string{wireguardSecret: "wireguard-keys"},
+		},
+		{
+			name: "no secrets",
+			c:    &Config{EnableBGP: true, EnableWireguard: true},
+			env:  map[string]string{bgpPeerSecret: "", wireguardSecret: ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{}
+			for _, e := range generatePodSpec(tt.c, "v0.0.0", true).Spec.Containers[0].Env {
+				env[e.Name] = e.Value
+			}
+			for name, value := range tt.env {
+				if env[name] != value {
+					t.Errorf("generatePodSpec() %s = %q, want %q", name, env[name], value)
+				}
+			}
+
+			// The secrets of the generated environment are read back into the configuration
+			for name, value := range env {
+				t.Setenv(name, value)
+			}
+			c := &Config{}
+			if err := ParseEnvironment(c); err != nil {
+				t.Fatal(err)
+			}
+			if c.BGPPeerSecret != tt.c.BGPPeerSecret || c.WireguardSecret != tt.c.WireguardSecret {
+				t.Errorf("ParseEnvironment() secrets = %q and %q, want %q and %q", c.BGPPeerSecret, c.WireguardSecret, tt.c.BGPPeerSecret, tt.c.WireguardSecret)
+			}
+		})
+	}
+	t.Run("etcd client secret", func(t *testing.T) {
+		t.Setenv(etcdClientSecret, "etcd-client")
+		c := &Config{}
+		if err := ParseEnvironment(c); err != nil {
+			t.Fatal(err)
+		}
+		if c.Etcd.ClientSecret != "etcd-client" {
+			t.Errorf("ParseEnvironment() etcd client secret = %q, want etcd-client", c.Etcd.ClientSecret)
+		}
+	})
+}
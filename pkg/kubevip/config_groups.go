@@ -0,0 +1,90 @@
+package kubevip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+)
+
+// VIPGroup modes
+const (
+	VIPGroupModeARP   = "arp"
+	VIPGroupModeBGP   = "bgp"
+	VIPGroupModeTable = "table"
+)
+
+// ValidateVIPGroups will ensure that all of the VIP groups can be started
+func ValidateVIPGroups(groups []VIPGroup) error {
+	names := map[string]bool{}
+	for x := range groups {
+		if groups[x].Name == "" {
+			return fmt.Errorf("VIP group [%d] has no name", x)
+		}
+		if names[groups[x].Name] {
+			return fmt.Errorf("VIP group [%s] is defined more than once", groups[x].Name)
+		}
+		names[groups[x].Name] = true
+
+		if groups[x].Address == "" {
+			return fmt.Errorf("VIP group [%s] has no address", groups[x].Name)
+		}
+		switch groups[x].Mode {
+		case VIPGroupModeARP, VIPGroupModeBGP, VIPGroupModeTable:
+		default:
+			return fmt.Errorf("VIP group [%s] has an unknown mode [%s], should be one of arp, bgp or table", groups[x].Name, groups[x].Mode)
+		}
+	}
+	return nil
+}
+
+// GroupConfig will build the configuration for a VIP group, the group settings are applied on top of a copy of
+// the kube-vip configuration
+func (c *Config) GroupConfig(g *VIPGroup) (*Config, error) {
+	groupConfig := *c
+	groupConfig.VIPGroups = nil
+
+	groupConfig.VIP = ""
+	groupConfig.Address = g.Address
+	groupConfig.VIPCIDR = groupCIDR(g.Address)
+	if g.Interface != "" {
+		groupConfig.Interface = g.Interface
+	}
+
+	// A group only advertises its VIP, the other features are left to the main configuration
+	groupConfig.EnableARP = g.Mode == VIPGroupModeARP
+	groupConfig.EnableBGP = g.Mode == VIPGroupModeBGP
+	groupConfig.EnableRoutingTable = g.Mode == VIPGroupModeTable
+	groupConfig.EnableLoadBalancer = false
+	groupConfig.EnableServices = false
+	groupConfig.DDNS = false
+
+	// Each group has its own lease so that leadership is independent of any other group
+	groupConfig.EnableLeaderElection = true
+	groupConfig.LeaseName = fmt.Sprintf("%s-%s", c.LeaseName, g.Name)
+
+	groupConfig.BGPConfig.Peers = append([]bgp.Peer{}, c.BGPConfig.Peers...)
+	if len(g.BGPPeers) != 0 {
+		peers, err := bgp.ParseBGPPeerConfig(strings.Join(g.BGPPeers, ","))
+		if err != nil {
+			return nil, fmt.Errorf("VIP group [%s]: %v", g.Name, err)
+		}
+		groupConfig.BGPConfig.Peers = peers
+	}
+
+	return &groupConfig, nil
+}
+
+// groupCIDR returns the host CIDR for each address of a group
+func groupCIDR(address string) string {
+	var cidrs []string
+	for _, a := range strings.Split(address, ",") {
+		if ip := net.ParseIP(a); ip != nil && ip.To4() == nil {
+			cidrs = append(cidrs, "128")
+		} else {
+			cidrs = append(cidrs, "32")
+		}
+	}
+	return strings.Join(cidrs, ",")
+}
@@ -0,0 +1,64 @@
+package kubevip
+
+import "testing"
+
+func TestGroupConfig(t *testing.T) {
+	c := &Config{
+		Interface: "eth0",
+		VIP:       "192.168.0.1",
+		EnableBGP: true,
+		KubernetesLeaderElection: KubernetesLeaderElection{
+			LeaseName: "plndr-cp-lock",
+		},
+	}
+	groups := []VIPGroup{
+		{Name: "mgmt", Address: "10.0.0.1", Interface: "eth1", Mode: VIPGroupModeARP},
+		{Name: "v6", Address: "fd00::1", Mode: VIPGroupModeBGP, BGPPeers: []string{"10.0.0.254:65000::false"}},
+	}
+	if err := ValidateVIPGroups(groups); err != nil {
+		t.Fatalf("ValidateVIPGroups() error = %v", err)
+	}
+
+	mgmt, err := c.GroupConfig(&groups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mgmt.Address != "10.0.0.1" || mgmt.VIP != "" || mgmt.Interface != "eth1" || mgmt.VIPCIDR != "32" {
+		t.Errorf("GroupConfig() address = %s, vip = %s, interface = %s, cidr = %s", mgmt.Address, mgmt.VIP, mgmt.Interface, mgmt.VIPCIDR)
+	}
+	if !mgmt.EnableARP || mgmt.EnableBGP || mgmt.LeaseName != "plndr-cp-lock-mgmt" {
+		t.Errorf("GroupConfig() arp = %t, bgp = %t, lease = %s", mgmt.EnableARP, mgmt.EnableBGP, mgmt.LeaseName)
+	}
+
+	v6, err := c.GroupConfig(&groups[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v6.Interface != "eth0" || v6.VIPCIDR != "128" || len(v6.BGPConfig.Peers) != 1 {
+		t.Errorf("GroupConfig() interface = %s, cidr = %s, peers = %v", v6.Interface, v6.VIPCIDR, v6.BGPConfig.Peers)
+	}
+
+	// The original configuration should be left untouched
+	if c.Address != "" || c.VIP != "192.168.0.1" || !c.EnableBGP {
+		t.Errorf("GroupConfig() modified the original configuration")
+	}
+}
+
+func TestValidateVIPGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []VIPGroup
+	}{
+		{"no name", []VIPGroup{{Address: "10.0.0.1", Mode: VIPGroupModeARP}}},
+		{"no address", []VIPGroup{{Name: "a", Mode: VIPGroupModeARP}}},
+		{"unknown mode", []VIPGroup{{Name: "a", Address: "10.0.0.1", Mode: "wireguard"}}},
+		{"duplicate", []VIPGroup{{Name: "a", Address: "10.0.0.1", Mode: VIPGroupModeARP}, {Name: "a", Address: "10.0.0.2", Mode: VIPGroupModeARP}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateVIPGroups(tt.groups); err == nil {
+				t.Errorf("ValidateVIPGroups() expected an error")
+			}
+		})
+	}
+}
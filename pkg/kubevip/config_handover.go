@@ -0,0 +1,27 @@
+package kubevip
+
+import (
+	"fmt"
+)
+
+// CheckHandover will ensure that the VIPs are elected with Kubernetes leases, as the replacement renews the leases
+// that it is handed (with the same identity) rather than waiting for them to expire. The VIPs of the services are only
+// handed over in ARP mode, the routes and BGP advertisements are withdrawn with the process.
+func (c *Config) CheckHandover() error {
+	if !c.Handover {
+		return nil
+	}
+	if c.EnableControlPlane && !c.EnableLeaderElection {
+		return fmt.Errorf("handover keeps the control plane VIP and requires leader election")
+	}
+	if !c.EnableControlPlane && (!c.EnableServices || !c.EnableARP || (!c.EnableLeaderElection && !c.EnableServicesElection)) {
+		return fmt.Errorf("handover keeps the VIPs of the control plane, or of the services in ARP mode with leader election")
+	}
+	if c.LeaderElectionType != "" && c.LeaderElectionType != "kubernetes" {
+		return fmt.Errorf("handover requires kubernetes leader election, the lease of etcd ends with the process")
+	}
+	if c.HandoverPath == "" {
+		return fmt.Errorf("handover requires the path of the state")
+	}
+	return nil
+}
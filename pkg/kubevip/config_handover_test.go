@@ -0,0 +1,31 @@
+package kubevip
+
+import "testing"
+
+func TestCheckHandover(t *testing.T) {
+	election := KubernetesLeaderElection{EnableLeaderElection: true}
+	path := "/var/run/kube-vip/handover.json"
+	tests := []struct {
+		name    string
+		c       *Config
+		wantErr bool
+	}{
+		{"no handover", &Config{}, false},
+		{"control plane", &Config{Handover: true, HandoverPath: path, EnableControlPlane: true, KubernetesLeaderElection: election}, false},
+		{"kubernetes leader election", &Config{Handover: true, HandoverPath: path, EnableControlPlane: true, LeaderElectionType: "kubernetes", KubernetesLeaderElection: election}, false},
+		{"services without leader election", &Config{Handover: true, HandoverPath: path, EnableServices: true, EnableARP: true}, true},
+		{"services with a common lease", &Config{Handover: true, HandoverPath: path, EnableServices: true, EnableARP: true, KubernetesLeaderElection: election}, false},
+		{"services with their own leases", &Config{Handover: true, HandoverPath: path, EnableServices: true, EnableARP: true, EnableServicesElection: true}, false},
+		{"services over bgp", &Config{Handover: true, HandoverPath: path, EnableServices: true, EnableBGP: true, EnableServicesElection: true}, true},
+		{"no leader election", &Config{Handover: true, HandoverPath: path, EnableControlPlane: true}, true},
+		{"etcd leader election", &Config{Handover: true, HandoverPath: path, EnableControlPlane: true, LeaderElectionType: "etcd", KubernetesLeaderElection: election}, true},
+		{"no path", &Config{Handover: true, EnableControlPlane: true, KubernetesLeaderElection: election}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckHandover(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckHandover() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
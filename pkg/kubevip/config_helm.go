@@ -0,0 +1,54 @@
+package kubevip
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// helmValues matches the layout of the values.yaml within the kube-vip helm chart
+type helmValues struct {
+	Image        helmImage                       `json:"image"`
+	Config       helmConfig                      `json:"config"`
+	Env          map[string]string               `json:"env"`
+	EnvValueFrom map[string]*corev1.EnvVarSource `json:"envValueFrom,omitempty"`
+}
+
+type helmImage struct {
+	Repository string            `json:"repository"`
+	PullPolicy corev1.PullPolicy `json:"pullPolicy"`
+	Tag        string            `json:"tag"`
+}
+
+type helmConfig struct {
+	Address string `json:"address"`
+}
+
+// GenerateHelmValuesFromConfig will take a kube-vip config and generate a values.yaml for the kube-vip helm chart,
+// the environment is built from the same pod spec as the manifests so that both install paths are consistent
+func GenerateHelmValuesFromConfig(c *Config, imageVersion string, inCluster bool) string {
+	values := helmValues{
+		Image: helmImage{
+			Repository: "ghcr.io/kube-vip/kube-vip",
+			PullPolicy: corev1.PullIfNotPresent,
+			Tag:        imageVersion,
+		},
+		Env:          map[string]string{},
+		EnvValueFrom: map[string]*corev1.EnvVarSource{},
+	}
+
+	pod := generatePodSpec(c, imageVersion, inCluster)
+	for _, env := range pod.Spec.Containers[0].Env {
+		switch {
+		case env.ValueFrom != nil:
+			values.EnvValueFrom[env.Name] = env.ValueFrom
+		// The chart exposes the address as its own value
+		case env.Name == address || env.Name == vipAddress:
+			values.Config.Address = env.Value
+		default:
+			values.Env[env.Name] = env.Value
+		}
+	}
+
+	b, _ := yaml.Marshal(values)
+	return string(b)
+}
@@ -0,0 +1,19 @@
+package kubevip
+
+import "fmt"
+
+// Enabled returns true if TLS has been configured
+func (h *HTTPTLS) Enabled() bool {
+	return h.Secret != "" || h.CertFile != ""
+}
+
+// Validate ensures that the certificates come from either files or a secret
+func (h *HTTPTLS) Validate() error {
+	if h.Secret != "" && (h.CertFile != "" || h.KeyFile != "" || h.ClientCAFile != "") {
+		return fmt.Errorf("the HTTP certificates can be loaded from files or a secret, not both")
+	}
+	if h.Secret == "" && (h.CertFile == "" || h.KeyFile == "") {
+		return fmt.Errorf("both a certificate and key file are required to serve HTTP over TLS")
+	}
+	return nil
+}
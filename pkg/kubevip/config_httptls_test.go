@@ -0,0 +1,30 @@
+package kubevip
+
+import "testing"
+
+func TestHTTPTLSValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		h           HTTPTLS
+		wantEnabled bool
+		wantErr     bool
+	}{
+		{name: "disabled", h: HTTPTLS{}, wantErr: true},
+		{name: "files", h: HTTPTLS{CertFile: "tls.crt", KeyFile: "tls.key"}, wantEnabled: true},
+		{name: "files with a client CA", h: HTTPTLS{CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt"}, wantEnabled: true},
+		{name: "certificate without a key", h: HTTPTLS{CertFile: "tls.crt"}, wantEnabled: true, wantErr: true},
+		{name: "secret", h: HTTPTLS{Secret: "kube-vip-tls"}, wantEnabled: true},
+		{name: "secret and files", h: HTTPTLS{Secret: "kube-vip-tls", CertFile: "tls.crt", KeyFile: "tls.key"}, wantEnabled: true, wantErr: true},
+		{name: "secret and a client CA file", h: HTTPTLS{Secret: "kube-vip-tls", ClientCAFile: "ca.crt"}, wantEnabled: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.h.Enabled(); got != tt.wantEnabled {
+				t.Errorf("Enabled() = %v, want %v", got, tt.wantEnabled)
+			}
+			if err := tt.h.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package kubevip
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// CheckImmutableOS will ensure that the credentials that replace a kubeconfig on the host are complete, an immutable
+// host without them uses the service account of the pod
+func (c *Config) CheckImmutableOS() error {
+	if c.Kubeconfig != "" && c.APIServer != "" {
+		return fmt.Errorf("use either the kubeconfig or the API server and token, not both")
+	}
+	if c.APIServer == "" {
+		if c.APIToken != "" || c.APICA != "" {
+			return fmt.Errorf("the API token and CA are only used with the API server")
+		}
+		return nil
+	}
+	if u, err := url.Parse(c.APIServer); err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("API server [%s] has to be an https URL", c.APIServer)
+	}
+	if c.APIToken == "" {
+		return fmt.Errorf("the API server requires a token")
+	}
+	if c.APICA != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(c.APICA)) {
+		return fmt.Errorf("the API CA has no PEM encoded certificates")
+	}
+	return nil
+}
+
+// immutableOSEnvironment returns the environment of a manifest for an immutable host, with the credentials that
+// replace the kubeconfig of the host
+func immutableOSEnvironment(c *Config) []corev1.EnvVar {
+	env := []corev1.EnvVar{{Name: vipImmutableOS, Value: strconv.FormatBool(c.ImmutableOS)}}
+	if c.Kubeconfig != "" {
+		env = append(env, corev1.EnvVar{Name: vipKubeconfig, Value: c.Kubeconfig})
+	}
+	if c.APIServer != "" {
+		env = append(env, corev1.EnvVar{Name: vipAPIServer, Value: c.APIServer}, corev1.EnvVar{Name: vipAPIToken, Value: c.APIToken})
+		if c.APICA != "" {
+			env = append(env, corev1.EnvVar{Name: vipAPICA, Value: c.APICA})
+		}
+	}
+	return env
+}
@@ -0,0 +1,56 @@
+package kubevip
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestCheckImmutableOS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kubernetes"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	tests := []struct {
+		name    string
+		c       *Config
+		wantErr bool
+	}{
+		{"host kubeconfig", &Config{}, false},
+		{"service account", &Config{ImmutableOS: true}, false},
+		{"kubeconfig", &Config{ImmutableOS: true, Kubeconfig: "apiVersion: v1"}, false},
+		{"bootstrap token", &Config{ImmutableOS: true, APIServer: "https://10.0.0.10:6443", APIToken: "abcdef.0123456789abcdef", APICA: ca}, false},
+		{"both", &Config{Kubeconfig: "apiVersion: v1", APIServer: "https://10.0.0.10:6443", APIToken: "abcdef.0123456789abcdef"}, true},
+		{"token without server", &Config{APIToken: "abcdef.0123456789abcdef"}, true},
+		{"http server", &Config{APIServer: "http://10.0.0.10:6443", APIToken: "abcdef.0123456789abcdef"}, true},
+		{"server without token", &Config{APIServer: "https://10.0.0.10:6443"}, true},
+		{"invalid CA", &Config{APIServer: "https://10.0.0.10:6443", APIToken: "abcdef.0123456789abcdef", APICA: "not a certificate"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckImmutableOS(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckImmutableOS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package kubevip
+
+import "fmt"
+
+// CheckIPAM will ensure that the addresses are allocated to services that kube-vip advertises, the pools in the
+// namespace of kube-vip are the pools of the namespaces without pools of their own
+func (c *Config) CheckIPAM() error {
+	if !c.EnableIPAM {
+		return nil
+	}
+	if !c.EnableServices {
+		return fmt.Errorf("ipam allocates the addresses of services and requires services to be enabled")
+	}
+	if c.Namespace == "" {
+		return fmt.Errorf("ipam requires the namespace of kube-vip, where the global ip pools are kept")
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package kubevip
+
+import "testing"
+
+func TestCheckIPAM(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *Config
+		wantErr bool
+	}{
+		{"no ipam", &Config{}, false},
+		{"services", &Config{EnableIPAM: true, EnableServices: true, Namespace: "kube-system"}, false},
+		{"control plane only", &Config{EnableIPAM: true, EnableControlPlane: true, Namespace: "kube-system"}, true},
+		{"no namespace", &Config{EnableIPAM: true, EnableServices: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckIPAM(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckIPAM() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
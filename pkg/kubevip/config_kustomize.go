@@ -0,0 +1,59 @@
+package kubevip
+
+import (
+	"path"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// kustomization is a minimal representation of a kustomize Kustomization file
+type kustomization struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Resources  []string `json:"resources"`
+}
+
+func newKustomization(namespace string, resources ...string) string {
+	k := kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Namespace:  namespace,
+		Resources:  resources,
+	}
+	b, _ := yaml.Marshal(k)
+	return string(b)
+}
+
+// GenerateRBACManifest will generate the ServiceAccount, ClusterRole and ClusterRoleBinding as a single multi-document manifest
+func GenerateRBACManifest() string {
+	var docs []string
+	for _, obj := range []interface{}{GenerateSA(), GenerateCR(), GenerateCRB()} {
+		b, _ := yaml.Marshal(obj)
+		docs = append(docs, string(b))
+	}
+	return strings.Join(docs, "---\n")
+}
+
+// GenerateKustomizeFromConfig will take a kube-vip config and generate a kustomize base (RBAC) along with
+// overlays for the static pod, daemonset and RBAC only deployments. The returned map is keyed by the
+// relative path of each file.
+func GenerateKustomizeFromConfig(c *Config, imageVersion string, inCluster, taint bool) map[string]string {
+	base := "base"
+	files := map[string]string{
+		path.Join(base, "kustomization.yaml"): newKustomization("", "rbac.yaml"),
+		path.Join(base, "rbac.yaml"):          GenerateRBACManifest(),
+
+		path.Join("overlays", "rbac", "kustomization.yaml"): newKustomization("", "../../base"),
+
+		path.Join("overlays", "daemonset", "kustomization.yaml"): newKustomization(c.ServiceNamespace, "../../base", "daemonset.yaml"),
+		path.Join("overlays", "daemonset", "daemonset.yaml"):     GenerateDaemonsetManifestFromConfig(c, imageVersion, inCluster, taint),
+
+		// A static pod is written to the manifests directory of the node and not applied, the RBAC from the base
+		// is still required when using the incluster token
+		path.Join("overlays", "pod", "kustomization.yaml"): newKustomization("", "../../base", "pod.yaml"),
+		path.Join("overlays", "pod", "pod.yaml"):           GeneratePodManifestFromConfig(c, imageVersion, inCluster),
+	}
+	return files
+}
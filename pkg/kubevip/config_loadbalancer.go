@@ -0,0 +1,81 @@
+package kubevip
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kube-vip/kube-vip/pkg/features"
+	"github.com/kube-vip/kube-vip/pkg/loadbalancer"
+)
+
+const (
+	// LoadBalancerIPVS balances the connections to the VIP with IPVS
+	LoadBalancerIPVS = "ipvs"
+	// LoadBalancerXDP balances the connections to the VIP with an XDP program on its interface
+	LoadBalancerXDP = "xdp"
+)
+
+const (
+	// LoadBalancerBackendsNodes balances the connections to the nodes with the control plane label
+	LoadBalancerBackendsNodes = "nodes"
+	// LoadBalancerBackendsEndpoints balances the connections to the API servers of the default/kubernetes endpoints
+	LoadBalancerBackendsEndpoints = "endpoints"
+)
+
+// CheckLoadBalancerDataplane will ensure that the data plane of the load-balancer is known, and that the XDP data plane
+// can balance the VIP
+func (c *Config) CheckLoadBalancerDataplane() error {
+	return c.checkLoadBalancerDataplane(features.DefaultFeatureGate.Enabled(features.EBPFDataplane), features.DefaultFeatureGate.Enabled(features.DirectServerReturn))
+}
+
+func (c *Config) checkLoadBalancerDataplane(ebpf, dsr bool) error {
+	switch c.LoadBalancerDataplane {
+	case "", LoadBalancerIPVS:
+		return nil
+	case LoadBalancerXDP:
+	default:
+		return fmt.Errorf("unknown load-balancer data plane [%s], use %s or %s", c.LoadBalancerDataplane, LoadBalancerIPVS, LoadBalancerXDP)
+	}
+	if !c.EnableLoadBalancer {
+		return nil
+	}
+	if !ebpf {
+		return fmt.Errorf("the %s load-balancer data plane needs the %s feature gate", LoadBalancerXDP, features.EBPFDataplane)
+	}
+	// The backends reply to the clients directly, bypassing the load-balancer
+	if !dsr {
+		return fmt.Errorf("the %s load-balancer data plane needs the %s feature gate", LoadBalancerXDP, features.DirectServerReturn)
+	}
+	// The XDP program forwards the packets to the backends as they are, it can't translate the port
+	if c.LoadBalancerPort != c.Port {
+		return fmt.Errorf("the %s load-balancer has to listen on the port of the backends [%d], not [%d]", LoadBalancerXDP, c.Port, c.LoadBalancerPort)
+	}
+	return nil
+}
+
+// CheckLoadBalancerScheduler will ensure that the scheduler and the persistence of the load-balancer are known, and
+// that the data plane can schedule the connections with them
+func (c *Config) CheckLoadBalancerScheduler() error {
+	switch c.LoadBalancerBackends {
+	case "", LoadBalancerBackendsNodes, LoadBalancerBackendsEndpoints:
+	default:
+		return fmt.Errorf("unknown load-balancer backends [%s], use %s or %s", c.LoadBalancerBackends, LoadBalancerBackendsNodes, LoadBalancerBackendsEndpoints)
+	}
+	if c.LoadBalancerScheduler != "" && !slices.Contains(loadbalancer.Schedulers, c.LoadBalancerScheduler) {
+		return fmt.Errorf("unknown load-balancer scheduler [%s], use one of %s", c.LoadBalancerScheduler, strings.Join(loadbalancer.Schedulers, ", "))
+	}
+	if c.LoadBalancerPersistenceTimeout < 0 {
+		return fmt.Errorf("load-balancer persistence timeout [%d] can't be negative", c.LoadBalancerPersistenceTimeout)
+	}
+	// The XDP program has a scheduler of its own, which keeps the connections (but not the clients) with their backend
+	if c.EnableLoadBalancer && c.LoadBalancerDataplane == LoadBalancerXDP {
+		if c.LoadBalancerScheduler != "" && c.LoadBalancerScheduler != loadbalancer.ROUNDROBIN {
+			return fmt.Errorf("the %s load-balancer data plane can't schedule with [%s]", LoadBalancerXDP, c.LoadBalancerScheduler)
+		}
+		if c.LoadBalancerPersistenceTimeout != 0 {
+			return fmt.Errorf("the %s load-balancer data plane doesn't support a persistence timeout", LoadBalancerXDP)
+		}
+	}
+	return nil
+}
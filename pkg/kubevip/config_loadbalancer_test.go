@@ -0,0 +1,55 @@
+package kubevip
+
+import "testing"
+
+func TestCheckLoadBalancerDataplane(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *Config
+		ebpf    bool
+		dsr     bool
+		wantErr bool
+	}{
+		{"default", &Config{EnableLoadBalancer: true, LoadBalancerPort: 6443, Port: 6443}, false, false, false},
+		{"ipvs", &Config{EnableLoadBalancer: true, LoadBalancerDataplane: "ipvs", LoadBalancerPort: 6444, Port: 6443}, false, false, false},
+		{"unknown", &Config{EnableLoadBalancer: true, LoadBalancerDataplane: "dpdk"}, true, true, true},
+		{"xdp", &Config{EnableLoadBalancer: true, LoadBalancerDataplane: "xdp", LoadBalancerPort: 6443, Port: 6443}, true, true, false},
+		{"xdp without the load-balancer", &Config{LoadBalancerDataplane: "xdp"}, false, false, false},
+		{"xdp without the ebpf feature gate", &Config{EnableLoadBalancer: true, LoadBalancerDataplane: "xdp", LoadBalancerPort: 6443, Port: 6443}, false, true, true},
+		{"xdp without the direct server return feature gate", &Config{EnableLoadBalancer: true, LoadBalancerDataplane: "xdp", LoadBalancerPort: 6443, Port: 6443}, true, false, true},
+		{"xdp on another port", &Config{EnableLoadBalancer: true, LoadBalancerDataplane: "xdp", LoadBalancerPort: 6444, Port: 6443}, true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.checkLoadBalancerDataplane(tt.ebpf, tt.dsr); (err != nil) != tt.wantErr {
+				t.Errorf("checkLoadBalancerDataplane() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckLoadBalancerScheduler(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *Config
+		wantErr bool
+	}{
+		{"default", &Config{EnableLoadBalancer: true}, false},
+		{"least connection", &Config{EnableLoadBalancer: true, LoadBalancerScheduler: "lc"}, false},
+		{"source hashing with persistence", &Config{EnableLoadBalancer: true, LoadBalancerScheduler: "sh", LoadBalancerPersistenceTimeout: 300}, false},
+		{"unknown scheduler", &Config{EnableLoadBalancer: true, LoadBalancerScheduler: "mh"}, true},
+		{"negative persistence", &Config{EnableLoadBalancer: true, LoadBalancerPersistenceTimeout: -1}, true},
+		{"endpoints", &Config{EnableLoadBalancer: true, LoadBalancerBackends: "endpoints"}, false},
+		{"unknown backends", &Config{EnableLoadBalancer: true, LoadBalancerBackends: "pods"}, true},
+		{"xdp", &Config{EnableLoadBalancer: true, LoadBalancerDataplane: "xdp", LoadBalancerScheduler: "rr"}, false},
+		{"xdp with a scheduler", &Config{EnableLoadBalancer: true, LoadBalancerDataplane: "xdp", LoadBalancerScheduler: "lc"}, true},
+		{"xdp with persistence", &Config{EnableLoadBalancer: true, LoadBalancerDataplane: "xdp", LoadBalancerPersistenceTimeout: 300}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckLoadBalancerScheduler(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckLoadBalancerScheduler() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
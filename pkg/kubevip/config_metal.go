@@ -0,0 +1,18 @@
+package kubevip
+
+import "fmt"
+
+// CheckMetal will ensure that the EIP is only looked up by its tag when no VIP is set, and that its assignment is
+// checked at a valid interval
+func (c *Config) CheckMetal() error {
+	if !c.EnableMetal {
+		return nil
+	}
+	if c.MetalEIPTag != "" && (c.VIP != "" || c.Address != "") {
+		return fmt.Errorf("metal EIP tag [%s] is only used when no VIP or address is set", c.MetalEIPTag)
+	}
+	if c.MetalEIPSyncInterval < 0 {
+		return fmt.Errorf("metal EIP sync interval [%d] has to be 0 (disabled) or more seconds", c.MetalEIPSyncInterval)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package kubevip
+
+import "testing"
+
+func TestCheckMetal(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"disabled", Config{MetalEIPSyncInterval: -1}, false},
+		{"vip", Config{EnableMetal: true, VIP: "192.0.2.10", MetalEIPSyncInterval: 30}, false},
+		{"tag", Config{EnableMetal: true, MetalEIPTag: "kube-vip"}, false},
+		{"tag with a vip", Config{EnableMetal: true, VIP: "192.0.2.10", MetalEIPTag: "kube-vip"}, true},
+		{"tag with an address", Config{EnableMetal: true, Address: "vip.example.com", MetalEIPTag: "kube-vip"}, true},
+		{"negative interval", Config{EnableMetal: true, MetalEIPSyncInterval: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.CheckMetal(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckMetal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package kubevip
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// CheckNSX will ensure that the NSX manager is an https URL, and that the VIP is an address that can be bound to the
+// logical port
+func (c *Config) CheckNSX() error {
+	if !c.EnableNSX {
+		return nil
+	}
+	if c.EnableMetal || c.EnableAWS || c.EnableGCP || c.EnableAzure {
+		return fmt.Errorf("nsx can't move the VIP together with metal, aws, gcp or azure")
+	}
+	if u, err := url.Parse(c.NSXManager); err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("nsx manager [%s] has to be the https URL of the NSX manager", c.NSXManager)
+	}
+	if c.Address != "" && net.ParseIP(c.Address) == nil {
+		return fmt.Errorf("nsx only binds VIP addresses to the port, not the name [%s]", c.Address)
+	}
+	if c.VIP != "" {
+		for _, vip := range strings.Split(c.VIP, ",") {
+			if net.ParseIP(vip) == nil {
+				return fmt.Errorf("nsx only binds VIP addresses to the port, not [%s]", vip)
+			}
+		}
+	}
+	return nil
+}
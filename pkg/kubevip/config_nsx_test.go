@@ -0,0 +1,26 @@
+package kubevip
+
+import "testing"
+
+func TestCheckNSX(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"disabled", Config{NSXManager: "nsx.example.com"}, false},
+		{"manager", Config{EnableNSX: true, NSXManager: "https://nsx.example.com", VIP: "192.168.0.100"}, false},
+		{"dual-stack vip", Config{EnableNSX: true, NSXManager: "https://nsx.example.com", VIP: "192.168.0.100,fd00::100"}, false},
+		{"no manager", Config{EnableNSX: true, VIP: "192.168.0.100"}, true},
+		{"http manager", Config{EnableNSX: true, NSXManager: "http://nsx.example.com", VIP: "192.168.0.100"}, true},
+		{"dns address", Config{EnableNSX: true, NSXManager: "https://nsx.example.com", Address: "vip.example.com"}, true},
+		{"aws", Config{EnableNSX: true, EnableAWS: true, NSXManager: "https://nsx.example.com"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.CheckNSX(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckNSX() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package kubevip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// CheckOpenStack will ensure that the VIP and the pools are addresses and CIDRs that can be allowed on the port of the
+// node
+func (c *Config) CheckOpenStack() error {
+	if !c.EnableOpenStack {
+		return nil
+	}
+	if c.Address != "" && net.ParseIP(c.Address) == nil {
+		return fmt.Errorf("openstack only allows VIP addresses on the port, not the name [%s]", c.Address)
+	}
+	if c.VIP != "" {
+		for _, vip := range strings.Split(c.VIP, ",") {
+			if net.ParseIP(strings.TrimSpace(vip)) == nil {
+				return fmt.Errorf("openstack only allows VIP addresses on the port, not [%s]", vip)
+			}
+		}
+	}
+	if _, err := ParseCIDRs(c.OpenStackPools); err != nil {
+		return fmt.Errorf("openstack %v", err)
+	}
+	return nil
+}
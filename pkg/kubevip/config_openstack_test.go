@@ -0,0 +1,25 @@
+package kubevip
+
+import "testing"
+
+func TestCheckOpenStack(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"disabled", Config{Address: "vip.example.com", OpenStackPools: []string{"invalid"}}, false},
+		{"vip", Config{EnableOpenStack: true, VIP: "192.168.0.100"}, false},
+		{"dual-stack vip and pools", Config{EnableOpenStack: true, VIP: "192.168.0.100,fd00::100", OpenStackPools: []string{"192.168.1.0/24", "fd00:1::/64"}}, false},
+		{"address", Config{EnableOpenStack: true, Address: "192.168.0.100"}, false},
+		{"dns address", Config{EnableOpenStack: true, Address: "vip.example.com"}, true},
+		{"invalid pool", Config{EnableOpenStack: true, OpenStackPools: []string{"192.168.1.0-192.168.1.10"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.CheckOpenStack(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckOpenStack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
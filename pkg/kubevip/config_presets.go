@@ -0,0 +1,80 @@
+package kubevip
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Preset is a named set of flag defaults for a common deployment, any flag that is set explicitly overrides the preset
+type Preset struct {
+	Name        string
+	Description string
+
+	// Flags maps flag names to the value that the preset sets
+	Flags map[string]string
+}
+
+// Presets are the built-in presets
+var Presets = []Preset{
+	{
+		Name:        "homelab-arp",
+		Description: "control plane and services on a flat layer 2 network using ARP and leader election",
+		Flags: map[string]string{
+			"arp":            "true",
+			"controlplane":   "true",
+			"services":       "true",
+			"leaderElection": "true",
+		},
+	},
+	{
+		Name:        "datacenter-bgp",
+		Description: "control plane and services advertised from every node to BGP peers (ECMP)",
+		Flags: map[string]string{
+			"bgp":          "true",
+			"controlplane": "true",
+			"services":     "true",
+		},
+	},
+	{
+		Name:        "cloud-hybrid",
+		Description: "control plane using ARP with leader election, services added to the routing table for a cloud router or CNI to advertise",
+		Flags: map[string]string{
+			"arp":                "true",
+			"table":              "true",
+			"controlplane":       "true",
+			"services":           "true",
+			"leaderElection":     "true",
+			"controlPlaneEngine": "arp",
+			"servicesEngine":     "table",
+		},
+	},
+}
+
+// GetPreset returns the preset with the given name
+func GetPreset(name string) (*Preset, error) {
+	for x := range Presets {
+		if Presets[x].Name == name {
+			return &Presets[x], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown preset [%s], known presets are %v", name, PresetNames())
+}
+
+// PresetNames returns the names of all of the presets
+func PresetNames() []string {
+	var names []string
+	for x := range Presets {
+		names = append(names, Presets[x].Name)
+	}
+	return names
+}
+
+// SortedFlags returns the names of the flags that the preset sets, in a stable order
+func (p *Preset) SortedFlags() []string {
+	var flags []string
+	for flag := range p.Flags {
+		flags = append(flags, flag)
+	}
+	sort.Strings(flags)
+	return flags
+}
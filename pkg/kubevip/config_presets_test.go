@@ -0,0 +1,18 @@
+package kubevip
+
+import "testing"
+
+func TestGetPreset(t *testing.T) {
+	for _, name := range PresetNames() {
+		p, err := GetPreset(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.Flags) == 0 {
+			t.Errorf("preset [%s] sets no flags", name)
+		}
+	}
+	if _, err := GetPreset("unknown"); err == nil {
+		t.Errorf("GetPreset() expected an error for an unknown preset")
+	}
+}
@@ -0,0 +1,261 @@
+package kubevip
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	applyRbacV1 "k8s.io/client-go/applyconfigurations/rbac/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kube-vip/kube-vip/pkg/features"
+)
+
+// RBACRules are the permissions that kube-vip needs for a configuration, rules that only apply to a single namespace
+// are kept separate so that they can be granted with a Role instead of the ClusterRole
+type RBACRules struct {
+	Cluster    []rbacv1.PolicyRule
+	Namespaced map[string][]rbacv1.PolicyRule
+}
+
+// add will add a rule to the namespace (or the cluster if it is empty), the verbs of rules for the same
+// resources are merged
+func (r *RBACRules) add(namespace string, rule rbacv1.PolicyRule) {
+	rules := r.Cluster
+	if namespace != "" {
+		rules = r.Namespaced[namespace]
+	}
+
+	found := false
+	for x := range rules {
+		if slices.Equal(rules[x].APIGroups, rule.APIGroups) && slices.Equal(rules[x].Resources, rule.Resources) &&
+			slices.Equal(rules[x].ResourceNames, rule.ResourceNames) {
+			for _, verb := range rule.Verbs {
+				if !slices.Contains(rules[x].Verbs, verb) {
+					rules[x].Verbs = append(rules[x].Verbs, verb)
+				}
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		rules = append(rules, rule)
+	}
+
+	if namespace != "" {
+		r.Namespaced[namespace] = rules
+	} else {
+		r.Cluster = rules
+	}
+}
+
+// Namespaces returns the namespaces that have rules, in a stable order
+func (r *RBACRules) Namespaces() []string {
+	var namespaces []string
+	for namespace := range r.Namespaced {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// GenerateRBACRules will work out the least privileged rules for the features that are enabled in the configuration,
+// egress is enabled per service so has to be asked for explicitly
+func GenerateRBACRules(c *Config, egress bool) RBACRules {
+	rules := RBACRules{Namespaced: map[string][]rbacv1.PolicyRule{}}
+
+	leaseVerbs := []string{"get", "create", "update"}
+	leases := func(namespace string) {
+		rules.add(namespace, rbacv1.PolicyRule{APIGroups: []string{"coordination.k8s.io"}, Resources: []string{"leases"}, Verbs: leaseVerbs})
+	}
+
+	if c.EnableControlPlane {
+		if c.EnableLeaderElection && c.LeaderElectionType != "etcd" {
+			leases(c.Namespace)
+		}
+		// The load balancer watches for control plane nodes (or the API servers of the kubernetes endpoints) joining and
+		// leaving
+		if c.EnableLoadBalancer && c.LoadBalancerBackends == LoadBalancerBackendsEndpoints {
+			rules.add("default", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"endpoints"}, ResourceNames: []string{"kubernetes"}, Verbs: []string{"list", "watch"}})
+		} else if c.EnableLoadBalancer {
+			rules.add("", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"list", "watch"}})
+		}
+	}
+
+	if c.EnableServices {
+		// An empty service namespace is every namespace, so these need to be part of the ClusterRole
+		serviceNamespace := c.ServiceNamespace
+
+		rules.add(serviceNamespace, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"services"}, Verbs: []string{"get", "list", "watch", "update"}})
+		rules.add(serviceNamespace, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"services/status"}, Verbs: []string{"update"}})
+		// The events of the services are recorded in their namespaces
+		if c.EnableServiceEvents {
+			rules.add(serviceNamespace, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{"create", "patch"}})
+		}
+
+		// Endpoints are watched to find local endpoints, and the active endpoint for egress
+		if egress || c.EnableServicesElection || ((c.EnableBGP || c.EnableRoutingTable) && !c.EnableLeaderElection) {
+			if c.EnableEndpointSlices {
+				rules.add(serviceNamespace, rbacv1.PolicyRule{APIGroups: []string{"discovery.k8s.io"}, Resources: []string{"endpointslices"}, Verbs: []string{"list", "watch"}})
+			} else {
+				rules.add(serviceNamespace, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"endpoints"}, Verbs: []string{"list", "watch"}})
+			}
+		}
+
+		if c.EnableServicesElection {
+			// Each service has its own lease, in the namespace of the service
+			leases(serviceNamespace)
+			// The shards can have services of any namespace, their leases are in the namespace of kube-vip
+			if c.ServicesElectionShards != 0 {
+				leases(c.Namespace)
+			}
+		} else if c.EnableLeaderElection || c.EnableWireguard {
+			leases(c.Namespace)
+		}
+
+		if features.DefaultFeatureGate.Enabled(features.CRDConfig) {
+			rules.add("", rbacv1.PolicyRule{APIGroups: []string{"kube-vip.io"}, Resources: []string{"kubevipservicepolicies"}, Verbs: []string{"list"}})
+		}
+
+		// The pools of every namespace are listed, and the allocations are kept in their status
+		if c.EnableIPAM {
+			rules.add("", rbacv1.PolicyRule{APIGroups: []string{"kube-vip.io"}, Resources: []string{"kubevipippools"}, Verbs: []string{"list"}})
+			rules.add("", rbacv1.PolicyRule{APIGroups: []string{"kube-vip.io"}, Resources: []string{"kubevipippools/status"}, Verbs: []string{"update"}})
+		}
+	}
+
+	// The namespaces are watched for an egress VIP, and the pods of those namespaces on the node
+	if c.EgressNamespaces {
+		rules.add("", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"list", "watch"}})
+		rules.add("", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list", "watch"}})
+	}
+
+	// The DNS server answers with the VIPs of all of the services
+	if c.DNSServerZone != "" {
+		rules.add(c.ServiceNamespace, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"services"}, Verbs: []string{"list", "watch"}})
+	}
+
+	// The control plane nodes (or only its own node) are listed by the leader of the records of the control plane
+	if c.ControlPlaneDNS != "" {
+		rules.add("", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"list"}})
+		leases(c.Namespace)
+	}
+
+	if c.EnableNodeLabeling {
+		rules.add("", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get", "patch"}})
+	}
+	if c.Bootstrap != "" {
+		// The status of the bootstrap is published as annotations of the node
+		rules.add("", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get", "patch"}})
+	}
+	if c.Annotations != "" {
+		rules.add("", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"list", "watch"}})
+	}
+	if c.Coordination.Port != 0 && c.EnableServices && c.EnableServicesElection {
+		// The leaders of the services ask the other nodes whether they are also leading
+		rules.add("", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"list", "watch"}})
+	}
+	if c.EnableWireguard && c.WireguardMesh {
+		// Every node publishes its key as annotations and watches the others
+		rules.add("", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get", "patch", "list", "watch"}})
+	}
+
+	// The pools of the cloud provider are watched for changes
+	if c.EnableOpenStack && c.OpenStackPoolsConfigMap != "" {
+		rules.add(c.Namespace, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{c.OpenStackPoolsConfigMap}, Verbs: []string{"list", "watch"}})
+	}
+
+	// Only the secrets that are referenced by the configuration can be read
+	referenced := []string{c.HTTPTLS.Secret}
+	if c.EnableBGP {
+		referenced = append(referenced, c.BGPPeerSecret)
+	}
+	if c.EnableWireguard {
+		if c.WireguardSecret == "" {
+			referenced = append(referenced, "wireguard")
+		} else {
+			referenced = append(referenced, c.WireguardSecret)
+		}
+		if c.WireguardMesh {
+			referenced = append(referenced, c.WireguardMeshSecret)
+		}
+	}
+	if c.LeaderElectionType == "etcd" {
+		referenced = append(referenced, c.Etcd.ClientSecret)
+	}
+	if c.DDNSProvider != "" && c.DDNSProvider != DDNSProviderDHCP {
+		referenced = append(referenced, c.DDNSSecret)
+	}
+	if c.Coordination.Port != 0 && c.SPIFFE.NodeIDTemplate == "" {
+		referenced = append(referenced, c.Coordination.Secret)
+		if c.Coordination.SignerName != "" {
+			// The requests are watched until they have been signed
+			rules.add("", rbacv1.PolicyRule{APIGroups: []string{"certificates.k8s.io"}, Resources: []string{"certificatesigningrequests"}, Verbs: []string{"create", "get", "list", "watch"}})
+		}
+	}
+	var secrets []string
+	for _, secret := range referenced {
+		if secret != "" && !slices.Contains(secrets, secret) {
+			secrets = append(secrets, secret)
+		}
+	}
+	if len(secrets) != 0 {
+		rules.add(c.Namespace, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: secrets, Verbs: []string{"get", "watch"}})
+	}
+	if c.EnableWireguard && c.WireguardGenerateKeys {
+		// The generated keys are written back to the secret
+		secret := c.WireguardSecret
+		if secret == "" {
+			secret = "wireguard"
+		}
+		rules.add(c.Namespace, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{secret}, Verbs: []string{"update"}})
+	}
+
+	return rules
+}
+
+// applyRules converts the policy rules into their apply configurations
+func applyRules(rules []rbacv1.PolicyRule) []*applyRbacV1.PolicyRuleApplyConfiguration {
+	var applied []*applyRbacV1.PolicyRuleApplyConfiguration
+	for _, rule := range rules {
+		r := applyRbacV1.PolicyRule().WithAPIGroups(rule.APIGroups...).WithResources(rule.Resources...).WithVerbs(rule.Verbs...)
+		if len(rule.ResourceNames) != 0 {
+			r = r.WithResourceNames(rule.ResourceNames...)
+		}
+		applied = append(applied, r)
+	}
+	return applied
+}
+
+// GenerateRBACManifestFromConfig will generate the ServiceAccount along with a ClusterRole and a Role per namespace that
+// only grant what the configuration needs
+func GenerateRBACManifestFromConfig(c *Config, egress bool) string {
+	rules := GenerateRBACRules(c, egress)
+	sa := GenerateSA()
+	subject := applyRbacV1.Subject().WithKind("ServiceAccount").WithName(*sa.Name).WithNamespace(*sa.Namespace)
+
+	objects := []interface{}{sa}
+	if len(rules.Cluster) != 0 {
+		cr := GenerateCR()
+		cr.Rules = nil
+		cr.WithRules(applyRules(rules.Cluster)...)
+		objects = append(objects, cr, GenerateCRB())
+	}
+	for _, namespace := range rules.Namespaces() {
+		role := applyRbacV1.Role("kube-vip-role", namespace).
+			WithRules(applyRules(rules.Namespaced[namespace])...)
+		binding := applyRbacV1.RoleBinding("kube-vip-role-binding", namespace).
+			WithRoleRef(applyRbacV1.RoleRef().WithAPIGroup(rbacv1.GroupName).WithKind("Role").WithName("kube-vip-role")).
+			WithSubjects(subject)
+		objects = append(objects, role, binding)
+	}
+
+	var docs []string
+	for _, obj := range objects {
+		b, _ := yaml.Marshal(obj)
+		docs = append(docs, string(b))
+	}
+	return strings.Join(docs, "---\n")
+}
@@ -0,0 +1,158 @@
+package kubevip
+
+import (
+	"slices"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// resources returns the resources of all of the rules
+func resources(rules []rbacv1.PolicyRule) []string {
+	var r []string
+	for _, rule := range rules {
+		r = append(r, rule.Resources...)
+	}
+	return r
+}
+
+func TestGenerateRBACRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		c          *Config
+		egress     bool
+		cluster    []string
+		namespaced []string
+	}{
+		{
+			name:       "control plane only",
+			c:          &Config{EnableControlPlane: true, EnableARP: true, Namespace: "kube-system", KubernetesLeaderElection: KubernetesLeaderElection{EnableLeaderElection: true}},
+			namespaced: []string{"leases"},
+		},
+		{
+			name:    "services only",
+			c:       &Config{EnableServices: true, EnableBGP: true, Namespace: "kube-system", KubernetesLeaderElection: KubernetesLeaderElection{EnableLeaderElection: true}},
+			cluster: []string{"services", "services/status"},
+			// The services are all advertised under one lease
+			namespaced: []string{"leases"},
+		},
+		{
+			name:       "services with events",
+			c:          &Config{EnableServices: true, EnableBGP: true, EnableServiceEvents: true, Namespace: "kube-system", KubernetesLeaderElection: KubernetesLeaderElection{EnableLeaderElection: true}},
+			cluster:    []string{"services", "services/status", "events"},
+			namespaced: []string{"leases"},
+		},
+		{
+			name:    "services with egress",
+			c:       &Config{EnableServices: true, EnableARP: true, EnableEndpointSlices: true, Namespace: "kube-system", KubernetesLeaderElection: KubernetesLeaderElection{EnableLeaderElection: true}},
+			egress:  true,
+			cluster: []string{"services", "services/status", "endpointslices"},
+			// The services are all advertised under one lease
+			namespaced: []string{"leases"},
+		},
+		{
+			name:       "namespace egress",
+			c:          &Config{EnableServices: true, EnableARP: true, EgressNamespaces: true, Namespace: "kube-system", KubernetesLeaderElection: KubernetesLeaderElection{EnableLeaderElection: true}},
+			cluster:    []string{"services", "services/status", "namespaces", "pods"},
+			namespaced: []string{"leases"},
+		},
+		{
+			name:       "services with ipam",
+			c:          &Config{EnableServices: true, EnableARP: true, EnableIPAM: true, Namespace: "kube-system", KubernetesLeaderElection: KubernetesLeaderElection{EnableLeaderElection: true}},
+			cluster:    []string{"services", "services/status", "kubevipippools", "kubevipippools/status"},
+			namespaced: []string{"leases"},
+		},
+		{
+			name:    "services election",
+			c:       &Config{EnableServices: true, EnableARP: true, EnableServicesElection: true, Namespace: "kube-system"},
+			cluster: []string{"services", "services/status", "endpoints", "leases"},
+		},
+		{
+			name:       "services election shards",
+			c:          &Config{EnableServices: true, EnableARP: true, EnableServicesElection: true, ServicesElectionShards: 8, ServiceNamespace: "apps", Namespace: "kube-system"},
+			namespaced: []string{"leases"},
+		},
+		{
+			name:       "coordination signer",
+			c:          &Config{EnableControlPlane: true, Coordination: Coordination{Port: 7443, Secret: "kube-vip-ca", SignerName: "example.com/kube-vip"}, Namespace: "kube-system"},
+			cluster:    []string{"certificatesigningrequests"},
+			namespaced: []string{"secrets"},
+		},
+		{
+			name:       "coordination with services election",
+			c:          &Config{EnableServices: true, EnableARP: true, EnableServicesElection: true, Coordination: Coordination{Port: 7443, Secret: "kube-vip-ca"}, Namespace: "kube-system"},
+			cluster:    []string{"services", "services/status", "endpoints", "leases", "nodes"},
+			namespaced: []string{"secrets"},
+		},
+		{
+			name: "wireguard generated keys",
+			c:    &Config{EnableControlPlane: true, EnableWireguard: true, WireguardGenerateKeys: true, Namespace: "kube-system"},
+			// The update verb is merged into the rule for the secret
+			namespaced: []string{"secrets"},
+		},
+		{
+			name:       "wireguard mesh",
+			c:          &Config{EnableControlPlane: true, EnableWireguard: true, WireguardMesh: true, Namespace: "kube-system"},
+			cluster:    []string{"nodes"},
+			namespaced: []string{"secrets"},
+		},
+		{
+			name:       "bgp secret",
+			c:          &Config{EnableControlPlane: true, EnableBGP: true, BGPPeerSecret: "bgp-peers", WireguardSecret: "wireguard", Namespace: "kube-system"},
+			namespaced: []string{"secrets"},
+		},
+		{
+			name:       "control plane dns",
+			c:          &Config{ControlPlaneDNS: "api.example.com", DDNSProvider: DDNSProviderRFC2136, DDNSSecret: "rfc2136", Namespace: "kube-system"},
+			cluster:    []string{"nodes"},
+			namespaced: []string{"leases", "secrets"},
+		},
+		{
+			name:    "dns server",
+			c:       &Config{EnableControlPlane: true, DNSServerZone: "lb.example.com", Namespace: "kube-system"},
+			cluster: []string{"services"},
+		},
+		{
+			name:    "control plane load balancer",
+			c:       &Config{EnableControlPlane: true, EnableLoadBalancer: true, Namespace: "kube-system"},
+			cluster: []string{"nodes"},
+		},
+		{
+			name: "control plane load balancer of the endpoints",
+			c:    &Config{EnableControlPlane: true, EnableLoadBalancer: true, LoadBalancerBackends: LoadBalancerBackendsEndpoints, Namespace: "default"},
+			// The endpoints of the kubernetes service are always in the default namespace
+			namespaced: []string{"endpoints"},
+		},
+		{
+			name:       "ddns secret",
+			c:          &Config{EnableControlPlane: true, DDNS: true, DDNSProvider: DDNSProviderCloudflare, DDNSSecret: "cloudflare", Namespace: "kube-system"},
+			namespaced: []string{"secrets"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := GenerateRBACRules(tt.c, tt.egress)
+			if got := resources(rules.Cluster); !slices.Equal(got, tt.cluster) {
+				t.Errorf("GenerateRBACRules() cluster resources = %v, want %v", got, tt.cluster)
+			}
+			if got := resources(rules.Namespaced[tt.c.Namespace]); !slices.Equal(got, tt.namespaced) {
+				t.Errorf("GenerateRBACRules() namespaced resources = %v, want %v", got, tt.namespaced)
+			}
+		})
+	}
+}
+
+func TestGenerateRBACRulesSecretNames(t *testing.T) {
+	c := &Config{EnableBGP: true, BGPPeerSecret: "bgp-peers", HTTPTLS: HTTPTLS{Secret: "kube-vip-tls"}, Namespace: "kube-system"}
+	rules := GenerateRBACRules(c, false)
+	secrets := rules.Namespaced["kube-system"]
+	if len(secrets) != 1 || !slices.Equal(secrets[0].ResourceNames, []string{"kube-vip-tls", "bgp-peers"}) {
+		t.Errorf("GenerateRBACRules() secrets = %v, want only the referenced secrets", secrets)
+	}
+
+	c = &Config{EnableWireguard: true, WireguardMesh: true, WireguardMeshSecret: "wireguard-mesh", Namespace: "kube-system"}
+	secrets = GenerateRBACRules(c, false).Namespaced["kube-system"]
+	if len(secrets) != 1 || !slices.Equal(secrets[0].ResourceNames, []string{"wireguard", "wireguard-mesh"}) {
+		t.Errorf("GenerateRBACRules() secrets = %v, want the wireguard and mesh secrets", secrets)
+	}
+}
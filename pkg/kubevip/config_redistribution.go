@@ -0,0 +1,52 @@
+package kubevip
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The routes of routing table mode are a stable contract with the routing daemon that redistributes them, they are
+// host routes of the VIPs in this table and with this protocol (unless they are configured otherwise)
+const (
+	// DefaultRoutingTableID is the table that the routes of the VIPs are added to
+	DefaultRoutingTableID = 198
+	// DefaultRoutingProtocol is the protocol of the routes, it tells them apart from the routes of the kernel, the CNI
+	// and the routing daemon itself
+	DefaultRoutingProtocol = 248
+)
+
+// maxImportTable is the highest table that FRR can import (ip import-table), the tables above are reserved
+const maxImportTable = 252
+
+// GenerateFRRConfig generates the FRR configuration that imports the table of the VIP routes into zebra and
+// redistributes it from the BGP instance of the AS. FRR can't match the routes by their protocol, so they have to be in
+// a table of their own.
+func GenerateFRRConfig(c *Config) (string, error) {
+	if c.BGPConfig.AS == 0 {
+		return "", fmt.Errorf("the AS of the FRR BGP instance has to be set")
+	}
+	if c.RoutingTableID <= 0 || c.RoutingTableID > maxImportTable {
+		return "", fmt.Errorf("routing table [%d] can't be imported by FRR, it has to be between 1 and %d", c.RoutingTableID, maxImportTable)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "! kube-vip routes: table %d, protocol %d\n", c.RoutingTableID, c.RoutingProtocol)
+	fmt.Fprintf(&b, "ip import-table %d\n!\n", c.RoutingTableID)
+	fmt.Fprintf(&b, "router bgp %d\n address-family ipv4 unicast\n  redistribute table %d\n exit-address-family\nexit\n", c.BGPConfig.AS, c.RoutingTableID)
+	return b.String(), nil
+}
+
+// GenerateBIRDConfig generates the BIRD 2 configuration that learns the routes of the VIPs from the kernel table, by
+// their protocol, and pipes them into the master tables to be exported by the BGP protocols
+func GenerateBIRDConfig(c *Config) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# kube-vip routes: table %d, protocol %d\n", c.RoutingTableID, c.RoutingProtocol)
+	for _, family := range []struct{ name, table, master string }{{"ipv4", "kubevip4", "master4"}, {"ipv6", "kubevip6", "master6"}} {
+		name, master := family.table, family.master
+		fmt.Fprintf(&b, "%s table %s;\n\n", family.name, name)
+		fmt.Fprintf(&b, "protocol kernel %s {\n", name)
+		fmt.Fprintf(&b, "  kernel table %d;\n  learn;\n", c.RoutingTableID)
+		fmt.Fprintf(&b, "  %s {\n    table %s;\n    import where krt_source = %d;\n    export none;\n  };\n}\n\n", family.name, name, c.RoutingProtocol)
+		fmt.Fprintf(&b, "protocol pipe %s_pipe {\n  table %s;\n  peer table %s;\n  import all;\n  export none;\n}\n\n", name, master, name)
+	}
+	return b.String()
+}
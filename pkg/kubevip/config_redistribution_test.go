@@ -0,0 +1,35 @@
+package kubevip
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+)
+
+func TestGenerateFRRConfig(t *testing.T) {
+	c := &Config{RoutingTableID: DefaultRoutingTableID, RoutingProtocol: DefaultRoutingProtocol, BGPConfig: bgp.Config{AS: 65000}}
+	out, err := GenerateFRRConfig(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"ip import-table 198", "router bgp 65000", "redistribute table 198"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("GenerateFRRConfig() is missing [%s]:\n%s", want, out)
+		}
+	}
+
+	c.RoutingTableID = 254
+	if _, err := GenerateFRRConfig(c); err == nil {
+		t.Error("GenerateFRRConfig() expected an error with the main table")
+	}
+}
+
+func TestGenerateBIRDConfig(t *testing.T) {
+	out := GenerateBIRDConfig(&Config{RoutingTableID: DefaultRoutingTableID, RoutingProtocol: DefaultRoutingProtocol})
+	for _, want := range []string{"kernel table 198;", "import where krt_source = 248;", "table master4;", "table master6;"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("GenerateBIRDConfig() is missing [%s]:\n%s", want, out)
+		}
+	}
+}
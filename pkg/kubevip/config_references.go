@@ -0,0 +1,95 @@
+package kubevip
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// fileReferencePrefix is used to reference a file that contains the value for a configuration field
+const fileReferencePrefix = "file://"
+
+// envReference matches a ${ENV_VAR} reference within a configuration value
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ResolveValue will expand any ${ENV_VAR} references within a value, and if the value is a file:// reference
+// it will be replaced with the contents of that file (with surrounding whitespace removed)
+func ResolveValue(value string) (string, error) {
+	var missing []string
+	value = envReference.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envReference.FindStringSubmatch(ref)[1]
+		env, exists := os.LookupEnv(name)
+		if !exists {
+			missing = append(missing, name)
+		}
+		return env
+	})
+	if len(missing) != 0 {
+		return "", fmt.Errorf("environment variable(s) %v referenced in config are not set", missing)
+	}
+
+	if strings.HasPrefix(value, fileReferencePrefix) {
+		path := strings.TrimPrefix(value, fileReferencePrefix)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("unable to read config value from [%s]: %v", path, err)
+		}
+		value = strings.TrimSpace(string(b))
+	}
+	return value, nil
+}
+
+// ResolveReferences will resolve any ${ENV_VAR} or file:// references in the configuration fields that are
+// likely to hold secrets or machine specific values, this should only be done when kube-vip is starting so
+// that the references aren't resolved into generated manifests
+func ResolveReferences(c *Config) error {
+	fields := map[string]*string{
+		"vip":                &c.VIP,
+		"address":            &c.Address,
+		"interface":          &c.Interface,
+		"servicesInterface":  &c.ServicesInterface,
+		"nodeName":           &c.NodeName,
+		"kubernetesAddr":     &c.KubernetesAddr,
+		"bgpRouterID":        &c.BGPConfig.RouterID,
+		"bgpSourceIP":        &c.BGPConfig.SourceIP,
+		"bgpSourceIF":        &c.BGPConfig.SourceIF,
+		"bgpPeerAddress":     &c.BGPPeerConfig.Address,
+		"bgpPeerPassword":    &c.BGPPeerConfig.Password,
+		"metalAPIKey":        &c.MetalAPIKey,
+		"metalProject":       &c.MetalProject,
+		"metalProjectID":     &c.MetalProjectID,
+		"etcdCAFile":         &c.Etcd.CAFile,
+		"etcdClientCertFile": &c.Etcd.ClientCertFile,
+		"etcdClientKeyFile":  &c.Etcd.ClientKeyFile,
+		"kubeconfig":         &c.Kubeconfig,
+		"apiServer":          &c.APIServer,
+		"apiToken":           &c.APIToken,
+		"apiCA":              &c.APICA,
+	}
+	passwordFiles := map[int]string{}
+	for x := range c.BGPConfig.Peers {
+		fields[fmt.Sprintf("bgpPeers[%d].address", x)] = &c.BGPConfig.Peers[x].Address
+		fields[fmt.Sprintf("bgpPeers[%d].password", x)] = &c.BGPConfig.Peers[x].Password
+		if strings.HasPrefix(c.BGPConfig.Peers[x].Password, fileReferencePrefix) {
+			passwordFiles[x] = strings.TrimPrefix(c.BGPConfig.Peers[x].Password, fileReferencePrefix)
+		}
+	}
+
+	for name, field := range fields {
+		value, err := ResolveValue(*field)
+		if err != nil {
+			return fmt.Errorf("unable to resolve [%s]: %v", name, err)
+		}
+		*field = value
+	}
+
+	// The addresses of the peers are only known once they have been resolved
+	for x, path := range passwordFiles {
+		if c.BGPPasswordFiles == nil {
+			c.BGPPasswordFiles = map[string]string{}
+		}
+		c.BGPPasswordFiles[c.BGPConfig.Peers[x].Address] = path
+	}
+	return nil
+}
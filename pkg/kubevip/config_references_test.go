@@ -0,0 +1,67 @@
+package kubevip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+)
+
+func TestResolveValue(t *testing.T) {
+	t.Setenv("KUBE_VIP_TEST_PASSWORD", "secret")
+
+	file := filepath.Join(t.TempDir(), "vip")
+	if err := os.WriteFile(file, []byte("192.168.0.1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"plain value", "192.168.0.1", "192.168.0.1", false},
+		{"env reference", "${KUBE_VIP_TEST_PASSWORD}", "secret", false},
+		{"env reference within value", "pre-${KUBE_VIP_TEST_PASSWORD}-post", "pre-secret-post", false},
+		{"dollar without braces", "pa$$word", "pa$$word", false},
+		{"missing env reference", "${KUBE_VIP_TEST_MISSING}", "", true},
+		{"file reference", "file://" + file, "192.168.0.1", false},
+		{"missing file reference", "file://" + file + ".missing", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveValue(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ResolveValue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ResolveValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveReferencesPasswordFiles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{}
+	c.BGPConfig.Peers = []bgp.Peer{
+		{Address: "192.168.0.1", Password: "file://" + file},
+		{Address: "192.168.0.2", Password: "plain"},
+	}
+	if err := ResolveReferences(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.BGPConfig.Peers[0].Password != "secret" {
+		t.Errorf("ResolveReferences() password = %v, want secret", c.BGPConfig.Peers[0].Password)
+	}
+	if len(c.BGPPasswordFiles) != 1 || c.BGPPasswordFiles["192.168.0.1"] != file {
+		t.Errorf("ResolveReferences() password files = %v, want only the file of 192.168.0.1", c.BGPPasswordFiles)
+	}
+}
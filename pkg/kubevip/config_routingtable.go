@@ -0,0 +1,110 @@
+package kubevip
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+// CheckRoutingTable will ensure that the routes can be told apart from the routes of the kernel, routing daemons and
+// the CNI, as the routes with the protocol are the ones that kube-vip cleans up
+func (c *Config) CheckRoutingTable() error {
+	if !c.EnableRoutingTable {
+		return nil
+	}
+	if c.RoutingProtocol < 0 || c.RoutingProtocol > math.MaxUint8 {
+		return fmt.Errorf("routing protocol [%d] has to be between 0 and %d", c.RoutingProtocol, math.MaxUint8)
+	}
+	if c.RoutingProtocol > unix.RTPROT_UNSPEC && c.RoutingProtocol <= unix.RTPROT_STATIC {
+		// Routes added by the kernel, or with "ip route add", would be removed as redundant
+		return fmt.Errorf("routing protocol [%d] is reserved for the kernel and static routes", c.RoutingProtocol)
+	}
+	if c.RoutingMetric < 0 || int64(c.RoutingMetric) > math.MaxUint32 {
+		return fmt.Errorf("routing metric [%d] has to be between 0 and %d", c.RoutingMetric, uint32(math.MaxUint32))
+	}
+	if _, err := vip.ParseNextHops(c.RoutingNextHops); err != nil {
+		return err
+	}
+	if _, err := ParseNamespaceTables(c.RoutingTableNamespaces); err != nil {
+		return err
+	}
+	if err := c.RouteAttributes().Validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RouteAttributes returns the scope, onlink flag and source of the routes
+func (c *Config) RouteAttributes() vip.RouteAttributes {
+	return vip.RouteAttributes{Scope: c.RoutingScope, OnLink: c.RoutingOnLink, Source: c.RoutingSource}
+}
+
+// ParseNamespaceTables parses the <namespace>=<table> routing tables of the namespaces
+func ParseNamespaceTables(entries []string) (map[string]int, error) {
+	tables := map[string]int{}
+	for _, entry := range entries {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		namespace, table, found := strings.Cut(entry, "=")
+		if !found || namespace == "" {
+			return nil, fmt.Errorf("routing table [%s] has to be <namespace>=<table>", entry)
+		}
+		id, err := strconv.Atoi(table)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("routing table [%s] of namespace [%s] is not a valid table", table, namespace)
+		}
+		tables[namespace] = id
+	}
+	return tables, nil
+}
+
+// RoutingTables returns the routing tables that the routes of the services are added to, unless a service has its
+// own table (or VRF)
+func (c *Config) RoutingTables() []int {
+	tables := []int{c.RoutingTableID}
+	namespaces, _ := ParseNamespaceTables(c.RoutingTableNamespaces)
+	for _, table := range namespaces {
+		if !slices.Contains(tables, table) {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// CheckBlackholePools will ensure that the pools are CIDRs, the allocated addresses are only known from the services
+func (c *Config) CheckBlackholePools() error {
+	if len(c.BlackholePools) == 0 {
+		return nil
+	}
+	if !c.EnableServices {
+		return fmt.Errorf("the unallocated addresses of the pools can only be blackholed with services enabled")
+	}
+	if _, err := ParseCIDRs(c.BlackholePools); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseCIDRs parses the CIDRs of the pools
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("pool [%s] is not a valid CIDR: %v", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
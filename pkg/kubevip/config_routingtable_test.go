@@ -0,0 +1,60 @@
+package kubevip
+
+import "testing"
+
+func TestCheckRoutingTable(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       Config
+		wantErr bool
+	}{
+		{name: "disabled", c: Config{RoutingProtocol: 3}},
+		{name: "defaults", c: Config{EnableRoutingTable: true, RoutingProtocol: 248}},
+		{name: "metric", c: Config{EnableRoutingTable: true, RoutingProtocol: 248, RoutingMetric: 100}},
+		{name: "next hops", c: Config{EnableRoutingTable: true, RoutingProtocol: 248, RoutingNextHops: []string{"10.0.0.1", "10.0.1.1@eth1", "fd00::1"}}},
+		{name: "invalid next hop", c: Config{EnableRoutingTable: true, RoutingProtocol: 248, RoutingNextHops: []string{"eth1"}}, wantErr: true},
+		{name: "namespace tables", c: Config{EnableRoutingTable: true, RoutingProtocol: 248, RoutingTableNamespaces: []string{"public=200", " internal=254"}}},
+		{name: "invalid namespace table", c: Config{EnableRoutingTable: true, RoutingProtocol: 248, RoutingTableNamespaces: []string{"public"}}, wantErr: true},
+		{name: "route attributes", c: Config{EnableRoutingTable: true, RoutingProtocol: 248, RoutingScope: "link", RoutingOnLink: true, RoutingSource: "vip"}},
+		{name: "invalid scope", c: Config{EnableRoutingTable: true, RoutingProtocol: 248, RoutingScope: "site"}, wantErr: true},
+		{name: "invalid source", c: Config{EnableRoutingTable: true, RoutingProtocol: 248, RoutingSource: "eth0"}, wantErr: true},
+		{name: "unspecified protocol", c: Config{EnableRoutingTable: true}},
+		{name: "static protocol", c: Config{EnableRoutingTable: true, RoutingProtocol: 4}, wantErr: true},
+		{name: "protocol out of range", c: Config{EnableRoutingTable: true, RoutingProtocol: 256}, wantErr: true},
+		{name: "negative metric", c: Config{EnableRoutingTable: true, RoutingProtocol: 248, RoutingMetric: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckRoutingTable(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckRoutingTable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckBlackholePools(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       Config
+		wantErr bool
+	}{
+		{name: "disabled"},
+		{name: "pools", c: Config{EnableServices: true, BlackholePools: []string{"192.168.10.0/24", "fd00::/120"}}},
+		{name: "without services", c: Config{BlackholePools: []string{"192.168.10.0/24"}}, wantErr: true},
+		{name: "invalid pool", c: Config{EnableServices: true, BlackholePools: []string{"192.168.10.0"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckBlackholePools(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckBlackholePools() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoutingTables(t *testing.T) {
+	c := Config{RoutingTableID: 198, RoutingTableNamespaces: []string{"public=200", "edge=200", "internal=198"}}
+	if tables := c.RoutingTables(); len(tables) != 2 || tables[0] != 198 || tables[1] != 200 {
+		t.Errorf("RoutingTables() = %v, want [198 200]", tables)
+	}
+}
@@ -0,0 +1,111 @@
+package kubevip
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// ManifestScheduling defines where and how the generated daemonset is scheduled
+type ManifestScheduling struct {
+	// Tolerations are added to any tolerations from the control plane taint
+	Tolerations []corev1.Toleration
+
+	// NodeSelector restricts the nodes that kube-vip is scheduled on
+	NodeSelector map[string]string
+
+	// Affinity replaces any affinity from the control plane taint
+	Affinity *corev1.Affinity
+
+	// PriorityClassName is the priority class of the kube-vip pods
+	PriorityClassName string
+
+	// Resources are the requests and limits of the kube-vip container
+	Resources corev1.ResourceRequirements
+}
+
+// ParseTolerations will parse tolerations in the same format as taints, key[=value]:Effect, where the effect
+// or the key can be left out to tolerate all effects or all taints
+func ParseTolerations(tolerations []string) ([]corev1.Toleration, error) {
+	var parsed []corev1.Toleration
+	for _, t := range tolerations {
+		keyValue, effect, _ := strings.Cut(t, ":")
+		toleration := corev1.Toleration{
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffect(effect),
+		}
+		switch toleration.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return nil, fmt.Errorf("invalid toleration [%s], unknown effect [%s]", t, effect)
+		}
+
+		key, value, found := strings.Cut(keyValue, "=")
+		if found {
+			if key == "" {
+				return nil, fmt.Errorf("invalid toleration [%s], a value requires a key", t)
+			}
+			toleration.Operator = corev1.TolerationOpEqual
+			toleration.Value = value
+		}
+		toleration.Key = key
+		parsed = append(parsed, toleration)
+	}
+	return parsed, nil
+}
+
+// ParseResourceList will parse a map of resource names to quantities, e.g. cpu=100m,memory=64Mi
+func ParseResourceList(resources map[string]string) (corev1.ResourceList, error) {
+	if len(resources) == 0 {
+		return nil, nil
+	}
+	list := corev1.ResourceList{}
+	for name, value := range resources {
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity [%s] for resource [%s]: %v", value, name, err)
+		}
+		list[corev1.ResourceName(name)] = q
+	}
+	return list, nil
+}
+
+// LoadAffinity will read a node/pod affinity from a yaml file
+func LoadAffinity(path string) (*corev1.Affinity, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	affinity := &corev1.Affinity{}
+	if err = yaml.UnmarshalStrict(b, affinity); err != nil {
+		return nil, fmt.Errorf("unable to parse affinity [%s]: %v", path, err)
+	}
+	return affinity, nil
+}
+
+// applyManifestScheduling will apply the scheduling options to a generated pod spec
+func applyManifestScheduling(spec *corev1.PodSpec, scheduling *ManifestScheduling) {
+	if scheduling == nil {
+		return
+	}
+	spec.Tolerations = append(spec.Tolerations, scheduling.Tolerations...)
+	if len(scheduling.NodeSelector) != 0 {
+		spec.NodeSelector = scheduling.NodeSelector
+	}
+	if scheduling.Affinity != nil {
+		spec.Affinity = scheduling.Affinity
+	}
+	if scheduling.PriorityClassName != "" {
+		spec.PriorityClassName = scheduling.PriorityClassName
+	}
+	if scheduling.Resources.Requests != nil {
+		spec.Containers[0].Resources.Requests = scheduling.Resources.Requests
+	}
+	if scheduling.Resources.Limits != nil {
+		spec.Containers[0].Resources.Limits = scheduling.Resources.Limits
+	}
+}
@@ -0,0 +1,152 @@
+package kubevip
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// SeccompProfileName is the name of the seccomp profile, relative to the kubelet seccomp directory
+	// (/var/lib/kubelet/seccomp)
+	SeccompProfileName = "kube-vip.json"
+	// AppArmorProfileName is the name of the AppArmor profile, it has to be loaded on every node (apparmor_parser -r)
+	AppArmorProfileName = "kube-vip"
+	// appArmorAnnotation sets the AppArmor profile of the kube-vip container
+	appArmorAnnotation = "container.apparmor.security.beta.kubernetes.io/kube-vip"
+)
+
+// seccompSyscalls are the syscalls of the Go runtime, the network calls and the netlink/ioctl calls that configure
+// addresses, routes, IPVS and wireguard. Names that don't exist on an architecture are ignored by the runtime.
+var seccompSyscalls = []string{
+	// Go runtime
+	"arch_prctl", "brk", "clone", "clone3", "exit", "exit_group", "futex", "getpid", "getppid", "gettid", "madvise",
+	"mincore", "mmap", "mprotect", "munmap", "nanosleep", "clock_nanosleep", "clock_gettime", "gettimeofday", "prctl",
+	"rseq", "rt_sigaction", "rt_sigprocmask", "rt_sigreturn", "sched_getaffinity", "sched_yield", "set_robust_list",
+	"set_tid_address", "sigaltstack", "tgkill", "getrandom", "uname", "getrlimit", "prlimit64", "sysinfo", "membarrier",
+	"getuid", "geteuid", "getgid", "getegid", "capget",
+	// Files, for the configuration, secrets, /proc and /sys
+	"openat", "open", "close", "read", "write", "writev", "pread64", "pwrite64", "lseek", "fstat", "newfstatat", "stat",
+	"lstat", "statx", "fstatfs", "fcntl", "fsync", "getdents64", "readlinkat", "readlink", "faccessat", "faccessat2",
+	"access", "getcwd", "mkdirat", "unlinkat", "renameat", "dup", "dup2", "dup3", "pipe", "pipe2", "umask",
+	// Polling
+	"epoll_create", "epoll_create1", "epoll_ctl", "epoll_pwait", "epoll_wait", "eventfd2", "poll", "ppoll", "pselect6",
+	"select",
+	// Network, netlink and the interface ioctls
+	"accept", "accept4", "bind", "connect", "getpeername", "getsockname", "getsockopt", "setsockopt", "listen",
+	"recvfrom", "recvmsg", "sendmsg", "sendto", "shutdown", "ioctl",
+	// Starting the container and the iptables binaries (for egress)
+	"execve", "execveat", "wait4", "waitid", "kill",
+}
+
+// socket address families
+const (
+	afUnix    = 1
+	afInet    = 2
+	afInet6   = 10
+	afNetlink = 16
+	afPacket  = 17
+)
+
+type seccompArg struct {
+	Index uint   `json:"index"`
+	Value uint64 `json:"value"`
+	Op    string `json:"op"`
+}
+
+type seccompSyscall struct {
+	Names  []string     `json:"names"`
+	Action string       `json:"action"`
+	Args   []seccompArg `json:"args,omitempty"`
+}
+
+type seccompProfile struct {
+	DefaultAction string           `json:"defaultAction"`
+	Architectures []string         `json:"architectures"`
+	Syscalls      []seccompSyscall `json:"syscalls"`
+}
+
+// needsRawSockets returns true if kube-vip needs raw (AF_PACKET) sockets, these are only allowed when the NET_RAW
+// capability is required
+func (c *Config) needsRawSockets() bool {
+	return slices.Contains(c.RequiredCapabilities(), "NET_RAW")
+}
+
+// GenerateSeccompProfile will generate a seccomp profile that only allows the syscalls kube-vip needs, sockets are
+// limited to the address families of the enabled features
+func GenerateSeccompProfile(c *Config) (string, error) {
+	families := []uint64{afUnix, afInet, afInet6, afNetlink}
+	if c.needsRawSockets() {
+		families = append(families, afPacket)
+	}
+
+	profile := seccompProfile{
+		DefaultAction: "SCMP_ACT_ERRNO",
+		Architectures: []string{"SCMP_ARCH_X86_64", "SCMP_ARCH_X86", "SCMP_ARCH_AARCH64", "SCMP_ARCH_ARM"},
+		Syscalls: []seccompSyscall{
+			{Names: seccompSyscalls, Action: "SCMP_ACT_ALLOW"},
+		},
+	}
+	// Each rule only allows one family, the arguments of a single rule all have to match
+	for _, family := range families {
+		profile.Syscalls = append(profile.Syscalls, seccompSyscall{
+			Names:  []string{"socket", "socketpair"},
+			Action: "SCMP_ACT_ALLOW",
+			Args:   []seccompArg{{Index: 0, Value: family, Op: "SCMP_CMP_EQ"}},
+		})
+	}
+
+	b, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+// GenerateAppArmorProfile will generate an AppArmor profile that only allows the capabilities, networks and files
+// that kube-vip needs
+func GenerateAppArmorProfile(c *Config) string {
+	var b strings.Builder
+	b.WriteString("#include <tunables/global>\n\n")
+	fmt.Fprintf(&b, "profile %s flags=(attach_disconnected,mediate_deleted) {\n", AppArmorProfileName)
+	b.WriteString("  #include <abstractions/base>\n\n")
+
+	for _, capability := range c.RequiredCapabilities() {
+		fmt.Fprintf(&b, "  capability %s,\n", strings.ToLower(capability))
+	}
+	b.WriteString("\n  network inet,\n  network inet6,\n  network netlink,\n  network unix,\n")
+	if c.needsRawSockets() {
+		b.WriteString("  network packet,\n")
+	}
+
+	b.WriteString(`
+  /kube-vip mr,
+  /etc/** r,
+  /var/run/secrets/** r,
+  /run/secrets/** r,
+  @{PROC}/** r,
+  /sys/** r,
+  /tmp/** rw,
+`)
+	if c.LoadBalancerForwardingMethod == "masquerade" {
+		b.WriteString("  @{PROC}/sys/net/** rw,\n")
+	}
+	if c.EnableServices {
+		// The iptables binaries are used for egress
+		b.WriteString("  /{usr/,}{s,}bin/* mrix,\n")
+	}
+
+	b.WriteString(`
+  deny mount,
+  deny umount,
+  deny pivot_root,
+  deny ptrace,
+  deny @{PROC}/sys/kernel/** w,
+  deny @{PROC}/sysrq-trigger rwklx,
+  deny /sys/firmware/** rwklx,
+  deny /sys/kernel/security/** rwklx,
+}
+`)
+	return b.String()
+}
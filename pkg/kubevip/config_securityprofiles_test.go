@@ -0,0 +1,81 @@
+package kubevip
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSeccompProfile(t *testing.T) {
+	tests := []struct {
+		name   string
+		c      *Config
+		packet bool
+	}{
+		{
+			name: "bgp",
+			c:    &Config{EnableControlPlane: true, EnableBGP: true},
+		},
+		{
+			name:   "arp",
+			c:      &Config{EnableControlPlane: true, EnableARP: true},
+			packet: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := GenerateSeccompProfile(tt.c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var profile seccompProfile
+			if err := json.Unmarshal([]byte(out), &profile); err != nil {
+				t.Fatalf("GenerateSeccompProfile() is not valid JSON: %v", err)
+			}
+			if profile.DefaultAction != "SCMP_ACT_ERRNO" {
+				t.Errorf("GenerateSeccompProfile() default action = %s, want SCMP_ACT_ERRNO", profile.DefaultAction)
+			}
+
+			var families []uint64
+			for _, syscall := range profile.Syscalls {
+				if len(syscall.Args) != 0 {
+					families = append(families, syscall.Args[0].Value)
+				}
+			}
+			packet := false
+			for _, family := range families {
+				packet = packet || family == afPacket
+			}
+			if packet != tt.packet {
+				t.Errorf("GenerateSeccompProfile() allows packet sockets = %t, want %t", packet, tt.packet)
+			}
+		})
+	}
+}
+
+func TestGenerateAppArmorProfile(t *testing.T) {
+	profile := GenerateAppArmorProfile(&Config{EnableControlPlane: true, EnableBGP: true})
+	if !strings.Contains(profile, "capability net_admin,") || strings.Contains(profile, "capability net_raw,") {
+		t.Errorf("GenerateAppArmorProfile() should only allow NET_ADMIN:\n%s", profile)
+	}
+	if strings.Contains(profile, "network packet,") {
+		t.Errorf("GenerateAppArmorProfile() shouldn't allow packet sockets without ARP:\n%s", profile)
+	}
+}
+
+func TestGenerateManifestSecurityProfiles(t *testing.T) {
+	c := &Config{EnableControlPlane: true, EnableARP: true, ManifestSecurityProfiles: true}
+	pod := generatePodSpec(c, "v0.0.0", true)
+	if pod.Annotations[appArmorAnnotation] != "localhost/"+AppArmorProfileName {
+		t.Errorf("generatePodSpec() AppArmor annotation = %q", pod.Annotations[appArmorAnnotation])
+	}
+	seccomp := pod.Spec.Containers[0].SecurityContext.SeccompProfile
+	if seccomp == nil || *seccomp.LocalhostProfile != SeccompProfileName {
+		t.Errorf("generatePodSpec() seccomp profile = %v", seccomp)
+	}
+
+	manifest := GenerateDaemonsetManifestFromConfig(c, "v0.0.0", true, false)
+	if !strings.Contains(manifest, appArmorAnnotation) {
+		t.Errorf("GenerateDaemonsetManifestFromConfig() is missing the AppArmor annotation:\n%s", manifest)
+	}
+}
@@ -0,0 +1,21 @@
+package kubevip
+
+import "fmt"
+
+// maxServicesElectionShards is the most shards that the services can be elected in, each shard is a lease that every
+// node renews
+const maxServicesElectionShards = 1024
+
+// CheckServicesElection will ensure that the services are only sharded when they are elected per service
+func (c *Config) CheckServicesElection() error {
+	if c.ServicesElectionShards == 0 {
+		return nil
+	}
+	if !c.EnableServicesElection {
+		return fmt.Errorf("the services are only elected in shards with the services election")
+	}
+	if c.ServicesElectionShards < 0 || c.ServicesElectionShards > maxServicesElectionShards {
+		return fmt.Errorf("the services election shards [%d] have to be between 1 and %d", c.ServicesElectionShards, maxServicesElectionShards)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package kubevip
+
+import "testing"
+
+func TestCheckServicesElection(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *Config
+		wantErr bool
+	}{
+		{"no shards", &Config{EnableServicesElection: true}, false},
+		{"shards", &Config{EnableServicesElection: true, ServicesElectionShards: 8}, false},
+		{"without services election", &Config{ServicesElectionShards: 8}, true},
+		{"negative", &Config{EnableServicesElection: true, ServicesElectionShards: -1}, true},
+		{"too many", &Config{EnableServicesElection: true, ServicesElectionShards: 2048}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckServicesElection(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckServicesElection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
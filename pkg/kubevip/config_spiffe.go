@@ -0,0 +1,30 @@
+package kubevip
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kube-vip/kube-vip/pkg/spiffe"
+)
+
+// CheckSPIFFE will ensure that the features that use SPIFFE identities have a Workload API to fetch them from
+func (c *Config) CheckSPIFFE() error {
+	if c.SPIFFE.Socket == "" {
+		if c.SPIFFE.Kubernetes || c.SPIFFE.NodeIDTemplate != "" {
+			return fmt.Errorf("SPIFFE identities require the socket of a Workload API")
+		}
+		return nil
+	}
+	if !strings.HasPrefix(c.SPIFFE.Socket, "unix://") && !strings.HasPrefix(c.SPIFFE.Socket, "tcp://") {
+		return fmt.Errorf("SPIFFE socket [%s] has to be a unix:// or tcp:// address", c.SPIFFE.Socket)
+	}
+	if c.SPIFFE.Kubernetes && c.Standalone {
+		return fmt.Errorf("there is no Kubernetes client to authenticate with the SPIFFE identity in standalone mode")
+	}
+	if c.SPIFFE.NodeIDTemplate != "" {
+		if err := spiffe.ValidateTemplate(c.SPIFFE.NodeIDTemplate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
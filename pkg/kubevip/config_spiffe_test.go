@@ -0,0 +1,28 @@
+package kubevip
+
+import "testing"
+
+func TestCheckSPIFFE(t *testing.T) {
+	tests := []struct {
+		name       string
+		c          SPIFFE
+		standalone bool
+		wantErr    bool
+	}{
+		{name: "disabled"},
+		{name: "kubernetes", c: SPIFFE{Socket: "unix:///run/spire/sockets/agent.sock", Kubernetes: true}},
+		{name: "node IDs", c: SPIFFE{Socket: "unix:///run/spire/sockets/agent.sock", NodeIDTemplate: "spiffe://example.org/kube-vip/{node}"}},
+		{name: "standalone", c: SPIFFE{Socket: "unix:///run/spire/sockets/agent.sock", Kubernetes: true}, standalone: true, wantErr: true},
+		{name: "no socket", c: SPIFFE{Kubernetes: true}, wantErr: true},
+		{name: "invalid socket", c: SPIFFE{Socket: "/run/spire/sockets/agent.sock"}, wantErr: true},
+		{name: "no node placeholder", c: SPIFFE{Socket: "unix:///run/spire/sockets/agent.sock", NodeIDTemplate: "spiffe://example.org/kube-vip"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{SPIFFE: tt.c, Standalone: tt.standalone}
+			if err := c.CheckSPIFFE(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckSPIFFE() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package kubevip
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckStandalone will ensure that the configuration can run without a Kubernetes cluster, in standalone mode
+// kube-vip is started by systemd (typically before the kubelet) so there is no API server to talk to
+func (c *Config) CheckStandalone() error {
+	if !c.Standalone {
+		return nil
+	}
+	if c.EnableServices {
+		return fmt.Errorf("services are watched from Kubernetes and can't be enabled in standalone mode")
+	}
+	if c.EnableLoadBalancer {
+		return fmt.Errorf("the control plane load balancer watches Kubernetes nodes and can't be enabled in standalone mode")
+	}
+	if c.LeaderElectionType == "etcd" {
+		if c.Etcd.ClientSecret != "" {
+			return fmt.Errorf("etcd certificates can't be read from a secret in standalone mode, use the certificate files")
+		}
+		return nil
+	}
+	if c.EnableLeaderElection {
+		return fmt.Errorf("kubernetes leader election can't be used in standalone mode, disable leader election or use etcd")
+	}
+	if len(c.VIPGroups) != 0 {
+		return fmt.Errorf("vip groups use leader election and require etcd in standalone mode")
+	}
+	return nil
+}
+
+// systemdCapabilities are the only capabilities that kube-vip is given, and is able to gain, when run by systemd
+const systemdCapabilities = "CAP_NET_ADMIN CAP_NET_RAW"
+
+// GenerateSystemdUnit will generate a systemd unit that runs kube-vip in standalone mode with a configuration file, as
+// an unprivileged user with only the capabilities that it needs
+func GenerateSystemdUnit(binary, configFile, user string) string {
+	var unit strings.Builder
+	unit.WriteString("[Unit]\n")
+	unit.WriteString("Description=kube-vip virtual IP and load balancer\n")
+	unit.WriteString("Documentation=https://kube-vip.io\n")
+	unit.WriteString("Wants=network-online.target\n")
+	unit.WriteString("After=network-online.target\n")
+	unit.WriteString("\n")
+	unit.WriteString("[Service]\n")
+	fmt.Fprintf(&unit, "ExecStart=%s manager --standalone --configFile %s\n", binary, configFile)
+	unit.WriteString("Restart=always\n")
+	unit.WriteString("RestartSec=5\n")
+	fmt.Fprintf(&unit, "User=%s\n", user)
+	fmt.Fprintf(&unit, "AmbientCapabilities=%s\n", systemdCapabilities)
+	fmt.Fprintf(&unit, "CapabilityBoundingSet=%s\n", systemdCapabilities)
+	unit.WriteString("\n")
+	unit.WriteString("[Install]\n")
+	unit.WriteString("WantedBy=multi-user.target\n")
+	return unit.String()
+}
@@ -0,0 +1,42 @@
+package kubevip
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckStandalone(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *Config
+		wantErr bool
+	}{
+		{"not standalone", &Config{EnableServices: true}, false},
+		{"single node control plane", &Config{Standalone: true, EnableControlPlane: true}, false},
+		{"etcd leader election", &Config{Standalone: true, EnableControlPlane: true, LeaderElectionType: "etcd", KubernetesLeaderElection: KubernetesLeaderElection{EnableLeaderElection: true}}, false},
+		{"services", &Config{Standalone: true, EnableServices: true}, true},
+		{"kubernetes leader election", &Config{Standalone: true, EnableControlPlane: true, KubernetesLeaderElection: KubernetesLeaderElection{EnableLeaderElection: true}}, true},
+		{"etcd secret", &Config{Standalone: true, LeaderElectionType: "etcd", Etcd: Etcd{ClientSecret: "etcd-certs"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckStandalone(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckStandalone() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateSystemdUnit(t *testing.T) {
+	unit := GenerateSystemdUnit("/usr/local/bin/kube-vip", "/etc/kube-vip/config.yaml", "kube-vip")
+	for _, want := range []string{
+		"ExecStart=/usr/local/bin/kube-vip manager --standalone --configFile /etc/kube-vip/config.yaml\n",
+		"User=kube-vip\n",
+		"AmbientCapabilities=CAP_NET_ADMIN CAP_NET_RAW\n",
+		"CapabilityBoundingSet=CAP_NET_ADMIN CAP_NET_RAW\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("GenerateSystemdUnit() is missing %q:\n%s", want, unit)
+		}
+	}
+}
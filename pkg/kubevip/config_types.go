@@ -27,6 +27,15 @@ type Config struct {
 	// DetectControlPlane, will attempt to find the control plane from loopback (127.0.0.1)
 	DetectControlPlane bool `yaml:"detectControlPlane"`
 
+	// ControlPlaneDNS, is a hostname whose records are kept listing the healthy control plane nodes through the DDNS
+	// provider, instead of (or along with) the VIP of the control plane
+	ControlPlaneDNS string `yaml:"controlPlaneDNS"`
+
+	// ControlPlaneDNSMode, is what the records of the control plane hostname list, every healthy control plane node
+	// (nodes) or only the leader of the records (leader), which then stands in for a VIP where ARP and BGP aren't
+	// available
+	ControlPlaneDNSMode string `yaml:"controlPlaneDNSMode"`
+
 	// KubernetesAddr，is the address of the Kubernetes API server on this machine
 	KubernetesAddr string `yaml:"kubernetesAddr"`
 
@@ -36,6 +45,12 @@ type Config struct {
 	// EnableServicesElection, will enable leaderElection per service
 	EnableServicesElection bool `yaml:"enableServicesElection"`
 
+	// ServicesElectionShards, when set, groups the services into this many shards that are elected with one lease each
+	ServicesElectionShards int `yaml:"servicesElectionShards"`
+
+	// EnableIPAM, will allocate the addresses of the LoadBalancer services without one from the KubeVipIPPool resources
+	EnableIPAM bool `yaml:"enableIPAM"`
+
 	// EnableNodeLabeling, will enable node labeling as it becomes leader
 	EnableNodeLabeling bool `yaml:"enableNodeLabeling"`
 
@@ -46,11 +61,19 @@ type Config struct {
 	LoadBalancerClassName string `yaml:"lbClassName"`
 
 	// EnableServiceSecurity, will enable the use of iptables to secure services
-	EnableServiceSecurity bool `yaml:"EnableServiceSecurity"`
+	EnableServiceSecurity bool `yaml:"enableServiceSecurity"`
 
 	// ArpBroadcastRate, defines how often kube-vip will update the network about updates to the network
 	ArpBroadcastRate int64 `yaml:"arpBroadcastRate"`
 
+	// ArpRepeat is how many gratuitous ARP/NDP updates are sent ArpRepeatInterval (in milliseconds) apart when a VIP
+	// is acquired, before they're sent every ArpBroadcastRate
+	ArpRepeat         int   `yaml:"arpRepeat"`
+	ArpRepeatInterval int64 `yaml:"arpRepeatInterval"`
+
+	// ArpBackoff doubles the time between the gratuitous updates after the repeats, until it reaches ArpBroadcastRate
+	ArpBackoff bool `yaml:"arpBackoff"`
+
 	// Annotations will define if we're going to wait and lookup configuration from Kubernetes node annotations
 	Annotations string
 
@@ -59,18 +82,41 @@ type Config struct {
 	LeaderElectionType string `yaml:"leaderElectionType"`
 
 	// KubernetesLeaderElection defines the settings around Kubernetes KubernetesLeaderElection
-	KubernetesLeaderElection
+	KubernetesLeaderElection `yaml:",inline"`
 
 	// Etcd defines all the settings for the etcd client.
 	Etcd Etcd
 
+	// Bootstrap, elects the control plane VIP with etcd or the static peers until the API server is healthy (for
+	// Cluster API, where the VIP has to be up before the API server exists), then moves to Kubernetes leader election
+	Bootstrap string `yaml:"bootstrap"`
+
+	// BootstrapPeers, are the control plane nodes for the static bootstrap, the first of them holds the VIP
+	BootstrapPeers []string `yaml:"bootstrapPeers"`
+
+	// Handover, keeps the control plane VIP (and the VIPs of the services in ARP mode) and their leases on the node when
+	// kube-vip is shut down, and hands them to the kube-vip that replaces it (for upgrades, where the VIPs would
+	// otherwise move to another node and back). The routes and BGP advertisements are still withdrawn.
+	Handover bool `yaml:"handover"`
+
+	// HandoverPath, is the file on the host that the state is handed over in
+	HandoverPath string `yaml:"handoverPath"`
+
+	// EnableVRRP, elects the control plane VIP with VRRP instead of leader election, so that the VIP can be shared
+	// with keepalived or the routers that already use VRRP on the link
+	EnableVRRP bool `yaml:"enableVRRP"`
+
+	// VRRP defines the virtual router of the control plane VIP
+	VRRP VRRP `yaml:"vrrp"`
+
 	// AddPeersAsBackends, this will automatically add RAFT peers as backends to a loadbalancer
 	AddPeersAsBackends bool `yaml:"addPeersAsBackends"`
 
 	// VIP is the Virtual IP address exposed for the cluster (TODO: deprecate)
 	VIP string `yaml:"vip"`
 
-	// VipSubnet is the Subnet that is applied to the VIP
+	// VipSubnet is the Subnet that is applied to the VIP, an IPv4 and an IPv6 subnet (/32,/128) can be set for
+	// dual-stack services
 	VIPSubnet string `yaml:"vipSubnet"`
 
 	// VIPCIDR is cidr range for the VIP (primarily needed for BGP)
@@ -91,8 +137,18 @@ type Config struct {
 	// use DDNS to allocate IP when Address is set to a DNS Name
 	DDNS bool `yaml:"ddns"`
 
+	// DDNSProvider is the provider that publishes the records of the DDNS addresses and the DHCP services with a hostname
+	// (dhcp, cloudflare, route53, rfc2136), the DHCP server registers the hostname itself by default
+	DDNSProvider string `yaml:"ddnsProvider"`
+
+	// DDNSSecret is the Secret in the namespace of kube-vip with the credentials of the DDNS provider
+	DDNSSecret string `yaml:"ddnsSecret"`
+
+	// DDNSTTL is the TTL (in seconds) of the records that the DDNS provider publishes
+	DDNSTTL int `yaml:"ddnsTTL"`
+
 	// NodeName - used for matching node name from pod spec
-	NodeName string `yaml:"leaseNodeName"`
+	NodeName string `yaml:"nodeName"`
 
 	// SingleNode will start the cluster as a single Node (Raft disabled)
 	SingleNode bool `yaml:"singleNode"`
@@ -115,16 +171,58 @@ type Config struct {
 	// Forwarding method for the IPVS Service
 	LoadBalancerForwardingMethod string `yaml:"lbForwardingMethod"`
 
+	// Data plane of the load-balancer, ipvs or xdp (which needs the EBPFDataplane feature gate)
+	LoadBalancerDataplane string `yaml:"lbDataplane"`
+
+	// Scheduler of the IPVS Service, rr, wrr, lc or sh
+	LoadBalancerScheduler string `yaml:"lbScheduler"`
+
+	// Seconds that the connections of a client stay with its backend in the IPVS Service, 0 disables persistence
+	LoadBalancerPersistenceTimeout int `yaml:"lbPersistenceTimeout"`
+
+	// Where the backends of the load-balancer come from, the control plane nodes or the endpoints of the kubernetes service
+	LoadBalancerBackends string `yaml:"lbBackends"`
+
 	// Routing Table ID for when using routing table mode
 	RoutingTableID int `yaml:"routingTableID"`
 
+	// Routing Table VRF, the routes are added to the table of this VRF (instead of RoutingTableID) and the VIPs are bound
+	// inside it
+	RoutingTableVRF string `yaml:"routingTableVRF"`
+
 	// Routing Table Type, what sort of route should be added to the routing table
 	RoutingTableType int `yaml:"routingTableType"`
 
 	// Routing Protocol, value that will be used as protocol when creating rutes
 	RoutingProtocol int `yaml:"routingProtocol"`
 
-	// Clean routing table of redundant routes on start
+	// Routing Metric, the priority of the routes so that they can be ordered against routes to the same VIP that are
+	// added by a routing daemon or the CNI (lower is preferred)
+	RoutingMetric int `yaml:"routingMetric"`
+
+	// Routing Next Hops, the routes are added as multipath routes through these gateways (<gateway>[@<interface>]) so
+	// that the kernel balances the traffic to the VIPs across them
+	RoutingNextHops []string `yaml:"routingNextHops"`
+
+	// RoutingScope, RoutingOnLink and RoutingSource are the scope (global, link or host), onlink flag and preferred
+	// source ("vip" or an address) of the routes, for the upstream routers and CNIs that need them
+	RoutingScope  string `yaml:"routingScope"`
+	RoutingOnLink bool   `yaml:"routingOnLink"`
+	RoutingSource string `yaml:"routingSource"`
+
+	// RoutingTableNamespaces map namespaces to the routing table of their services (<namespace>=<table>), e.g. so
+	// that the routes of public VIPs are in the table that a routing daemon redistributes
+	RoutingTableNamespaces []string `yaml:"routingTableNamespaces"`
+
+	// RoutingRuleTable is the table of the ip rule (from <VIP> lookup <table>) that is added alongside the route of
+	// the VIP, it is set per service so that the replies from the VIP can leave through a specific uplink
+	RoutingRuleTable int `yaml:"routingRuleTable"`
+
+	// BlackholePools are the CIDRs of the pools that service VIPs are allocated from, the addresses that haven't been
+	// allocated to a service are blackholed so that their traffic is dropped instead of following the default route
+	BlackholePools []string `yaml:"blackholePools"`
+
+	// Clean routing table of redundant routes on start, the routes left by a crash are removed (on by default)
 	CleanRoutingTable bool `yaml:"cleanRoutingTable"`
 
 	// BGP Configuration
@@ -132,6 +230,49 @@ type Config struct {
 	BGPPeerConfig bgp.Peer
 	BGPPeers      []string
 
+	// BGPPeerSecret, is the name of a secret that holds the BGP peer password(s), the "password" key is used
+	// for all peers unless a key matching the address of a peer exists
+	BGPPeerSecret string `yaml:"bgpPeerSecret"`
+
+	// BGPPasswordFiles are the files that BGP peer passwords were read from (using a file:// reference), indexed by
+	// the address of the peer, these are watched so that a rotated password is applied to the peer
+	BGPPasswordFiles map[string]string `yaml:"-"`
+
+	// WireguardSecret, is the name of the secret that holds the wireguard keys and peer configuration
+	WireguardSecret string `yaml:"wireguardSecret"`
+
+	// WireguardGenerateKeys will generate the private key if the secret doesn't have one, and publish its public key
+	// in the secret (publicKey) so that the peer can be configured with it
+	WireguardGenerateKeys bool `yaml:"wireguardGenerateKeys"`
+
+	// WireguardMesh will also peer every kube-vip node with the others, each node publishes its key and endpoint as
+	// node annotations, so that traffic can be tunneled to the node with the backend
+	WireguardMesh bool `yaml:"wireguardMesh"`
+
+	// WireguardMeshSecret is the name of the secret that holds the preshared keys of the mesh, either one key for
+	// every pair of nodes (presharedKey) or a key per pair (<node>_<node>)
+	WireguardMeshSecret string `yaml:"wireguardMeshSecret"`
+
+	// WireguardHandshakeTimeout is how many seconds the last handshake with the peer can be old before the tunnel is
+	// unhealthy, an unhealthy node gives up the leadership so that another node advertises the VIPs. 0 disables it.
+	WireguardHandshakeTimeout int `yaml:"wireguardHandshakeTimeout"`
+
+	// WireguardProbeAddress is pinged through the tunnel (e.g. the tunnel address of the peer), the node gives up the
+	// leadership when it stops replying even though the handshakes with the peer still work
+	WireguardProbeAddress string `yaml:"wireguardProbeAddress"`
+
+	// WireguardPort and WireguardMeshPort are the UDP ports of the tunnel and of the mesh, they need to be changed if
+	// the CNI already uses the default wireguard ports (51820 and 51821). 0 uses the default port.
+	WireguardPort     int `yaml:"wireguardPort"`
+	WireguardMeshPort int `yaml:"wireguardMeshPort"`
+
+	// WireguardMTU is the MTU of the wireguard interfaces, 0 leaves the MTU of the kernel (1420)
+	WireguardMTU int `yaml:"wireguardMTU"`
+
+	// WireguardFirewallMark is set on the encrypted packets so that they can be matched by policy routing or the
+	// firewall, 0 doesn't mark them
+	WireguardFirewallMark int `yaml:"wireguardFirewallMark"`
+
 	// EnableMetal, will use the metal API to update the EIP <-> VIP (if BGP is enabled then BGP will be used)
 	EnableMetal bool `yaml:"enableMetal"`
 
@@ -144,6 +285,63 @@ type Config struct {
 	// MetalProjectID, is the name of a particular defined project
 	MetalProjectID string
 
+	// MetalEIPTag, is the tag of the EIP that becomes the VIP when no VIP is configured
+	MetalEIPTag string `yaml:"metalEIPTag"`
+
+	// MetalEIPSyncInterval, is how often (in seconds) the leader checks that the EIP is still assigned to it, 0 disables
+	// the check
+	MetalEIPSyncInterval int `yaml:"metalEIPSyncInterval"`
+
+	// EnableAWS, will assign the VIP as a secondary private IP of the ENI of the leader with the EC2 API
+	EnableAWS bool `yaml:"enableAWS"`
+
+	// AWSRegion, is the region of the EC2 API, it is read from the instance metadata when it is empty
+	AWSRegion string `yaml:"awsRegion"`
+
+	// AWSEIPAllocationID, is the allocation ID of the EIP that is associated with the VIP on the leader
+	AWSEIPAllocationID string `yaml:"awsEIPAllocationID"`
+
+	// EnableGCP, will point the VPC route of the VIP at the leader with the Compute Engine API
+	EnableGCP bool `yaml:"enableGCP"`
+
+	// GCPRouteName, is the name of the route of the VIP, it is derived from the VIP when it is empty
+	GCPRouteName string `yaml:"gcpRouteName"`
+
+	// GCPNetwork, is the VPC network of the route, the network of the first interface of the instance when it is empty
+	GCPNetwork string `yaml:"gcpNetwork"`
+
+	// EnableAzure, will move the IP configuration of the VIP to the NIC of the leader with the Azure API
+	EnableAzure bool `yaml:"enableAzure"`
+
+	// AzureIPConfigName, is the name of the IP configuration of the VIP, it is derived from the VIP when it is empty
+	AzureIPConfigName string `yaml:"azureIPConfigName"`
+
+	// AzurePublicIPID, is the resource ID of the public IP that is associated with the VIP on the leader
+	AzurePublicIPID string `yaml:"azurePublicIPID"`
+
+	// AzureClientID, is the client ID of the user-assigned managed identity, the system-assigned identity is used when
+	// it is empty
+	AzureClientID string `yaml:"azureClientID"`
+
+	// EnableOpenStack, will add the VIP and the pools to the allowed address pairs of the Neutron port of the node
+	EnableOpenStack bool `yaml:"enableOpenStack"`
+
+	// OpenStackPools, are the CIDRs of the pools that are added to the allowed address pairs alongside the VIP
+	OpenStackPools []string `yaml:"openStackPools"`
+
+	// OpenStackPoolsConfigMap, is the configmap of the kube-vip cloud provider whose pools are added to the allowed
+	// address pairs whenever they change, it isn't watched when it is empty
+	OpenStackPoolsConfigMap string `yaml:"openStackPoolsConfigMap"`
+
+	// EnableNSX, will bind the VIP to the NSX logical port of the leader so that SpoofGuard doesn't drop its traffic
+	EnableNSX bool `yaml:"enableNSX"`
+
+	// NSXManager, is the URL of the NSX manager (https://<address>)
+	NSXManager string `yaml:"nsxManager"`
+
+	// NSXInsecure, skips the verification of the certificate of the NSX manager
+	NSXInsecure bool `yaml:"nsxInsecure"`
+
 	// ProviderConfig, is the path to a provider configuration file
 	ProviderConfig string
 
@@ -153,6 +351,15 @@ type Config struct {
 	// The hostport used to expose Prometheus metrics over an HTTP server
 	PrometheusHTTPServer string `yaml:"prometheusHTTPServer,omitempty"`
 
+	// HTTPTLS will serve the local HTTP endpoints over TLS
+	HTTPTLS HTTPTLS `yaml:"httpTLS,omitempty"`
+
+	// Coordination is the mTLS authenticated channel between kube-vip nodes
+	Coordination Coordination `yaml:"coordination,omitempty"`
+
+	// SPIFFE is the Workload API that identities are fetched from, instead of static credentials
+	SPIFFE SPIFFE `yaml:"spiffe,omitempty"`
+
 	// Egress configuration
 
 	// EgressPodCidr, this contains the pod cidr range to ignore Egress
@@ -164,18 +371,75 @@ type Config struct {
 	// EgressWithNftables, this will use the iptables-nftables OVER iptables
 	EgressWithNftables bool
 
+	// EgressNamespaces, this will SNAT the pods of the namespaces with an egress VIP annotation to that VIP
+	EgressNamespaces bool
+
+	// EgressPlacement, this is the node that SNATs the egress of a service, the leader that advertises its VIP or the
+	// node of its pod (for VIPs that are advertised from every node)
+	EgressPlacement string
+
 	// ServicesLeaseName, this will set the lease name for services leader in arp mode
 	ServicesLeaseName string `yaml:"servicesLeaseName"`
 
+	// ServicesWorkers, is the number of services that are synchronised at the same time
+	ServicesWorkers int `yaml:"servicesWorkers"`
+
+	// ServicesSelector, is a label selector that the API server filters the services (and their endpoints, that have
+	// the labels of the service) with, so that each kube-vip only lists and watches the services that it advertises
+	ServicesSelector string `yaml:"servicesSelector"`
+
 	// K8sConfigFile, this is the path to the config file used to speak with the API server
 	K8sConfigFile string `yaml:"k8sConfigFile"`
 
+	// ImmutableOS, never reads a kubeconfig from the host (/etc/kubernetes/admin.conf or $HOME/.kube/config), for
+	// immutable operating systems such as Talos or Flatcar
+	ImmutableOS bool `yaml:"immutableOS"`
+
+	// Kubeconfig, is the content of a kubeconfig (e.g. from a secret), it is used instead of a file on the host
+	Kubeconfig string `yaml:"kubeconfig"`
+
+	// APIServer, is the URL of the API server that is used with the APIToken instead of a kubeconfig
+	APIServer string `yaml:"apiServer"`
+
+	// APIToken, is the bearer token (e.g. a bootstrap token) that authenticates to the APIServer
+	APIToken string `yaml:"apiToken"`
+
+	// APICA, is the PEM encoded CA that the APIServer is verified with
+	APICA string `yaml:"apiCA"`
+
 	// DNSMode, this will set the mode DSN lookup will be performed (first, ipv4, ipv6, dual)
-	DNSMode string `yaml:"dnsDualStackMode"`
+	DNSMode string `yaml:"dnsMode"`
+
+	// DNSRefreshInterval, this is how often (in seconds) the DNS name of a VIP is resolved again
+	DNSRefreshInterval int `yaml:"dnsRefreshInterval"`
+
+	// DNSHealthCheck, this will health check the addresses that the DNS name of the VIP resolves to (the port of the
+	// API server) and keep the VIP on a healthy one
+	DNSHealthCheck bool `yaml:"dnsHealthCheck"`
+
+	// ExternalDNSTemplate, this is the template of the hostname that is published for the VIP of a service (e.g.
+	// {service}.{namespace}.lb.example.com), for external-dns to create its records
+	ExternalDNSTemplate string `yaml:"externalDNSTemplate"`
+
+	// ExternalDNSTarget, this is where the hostname is published, the external-dns annotation or the status
+	ExternalDNSTarget string `yaml:"externalDNSTarget"`
+
+	// DNSServerZone, this is the zone that kube-vip answers the queries of as an authoritative server, the services
+	// are named {service}.{namespace}.{zone}
+	DNSServerZone string `yaml:"dnsServerZone"`
+
+	// DNSServerAddress, this is the address that the DNS server is bound to (the VIP of the control plane by default)
+	DNSServerAddress string `yaml:"dnsServerAddress"`
+
+	// DNSServerPort, this is the port that the DNS server listens on
+	DNSServerPort int `yaml:"dnsServerPort"`
 
 	// DisableServiceUpdates, if true, kube-vip will only advertise service, but it will not update service's Status.LoadBalancer.Ingress slice
 	DisableServiceUpdates bool `yaml:"disableServiceUpdates"`
 
+	// EnableServiceEvents, if true, kube-vip records events on the services when their VIPs are advertised or withdrawn
+	EnableServiceEvents bool `yaml:"enableServiceEvents"`
+
 	// EnableEndpointSlices, if enabled, EndpointSlices will be used instead of Endpoints
 	EnableEndpointSlices bool `yaml:"enableEndpointSlices"`
 
@@ -184,11 +448,68 @@ type Config struct {
 	// + optional
 	MirrorDestInterface string `yaml:"mirrorDestInterface"`
 
+	// EnableUPNP, this will forward the ports of the services from the gateway to their VIPs with UPNP
+	EnableUPNP bool `yaml:"enableUPNP"`
+
+	// UPNPLeaseDuration, this is the lease (in seconds) of the port mappings, they are renewed half way through it
+	UPNPLeaseDuration int `yaml:"upnpLeaseDuration"`
+
+	// UPNPProtocol, this is the protocol that the ports are mapped with (auto, upnp, natpmp, pcp)
+	UPNPProtocol string `yaml:"upnpProtocol"`
+
+	// UPNPOptIn, this will only forward the ports of the services that have the kube-vip.io/upnp annotation set to true
+	UPNPOptIn bool `yaml:"upnpOptIn"`
+
+	// UPNPGatewayURL, this is the description URL of the UPNP gateway, for when more than one gateway answers the search
+	UPNPGatewayURL string `yaml:"upnpGatewayURL"`
+
+	// UPNPInterface, this is the interface that the gateway is searched for (or routed to) through
+	UPNPInterface string `yaml:"upnpInterface"`
+
 	// IptablesBackend iptables backend, can be specified as `nft` or `legacy`. If not set, it defaults to automatic detection.
 	IptablesBackend string `yaml:"iptablesBackend"`
 
+	// FirewallBackend manages the egress, service security and masquerade rules with `iptables` or natively with
+	// `nftables`. If not set, nftables is used unless only the legacy iptables tables have rules.
+	FirewallBackend string `yaml:"firewallBackend"`
+
 	// BackendHealthCheckInterval Interval in seconds for checking backend health.
 	BackendHealthCheckInterval int `yaml:"backendHealthCheckInterval"`
+
+	// Standalone will run kube-vip without a Kubernetes cluster, e.g. as a systemd service before the kubelet exists
+	Standalone bool `yaml:"standalone,omitempty"`
+
+	// SignatureKey is the path to a public key, when set the configuration file and service policies must be signed
+	SignatureKey string `yaml:"signatureKey,omitempty"`
+
+	// SecurityWebhook is a URL that security events (VIP conflicts, BGP authentication failures, configuration changes
+	// and leadership being taken) are posted to
+	SecurityWebhook string `yaml:"securityWebhook,omitempty"`
+
+	// FIPS restricts kube-vip to FIPS approved cryptography, configuration that needs anything else is refused
+	FIPS bool `yaml:"fips,omitempty"`
+
+	// ControlPlaneEngine, when several engines are enabled this is the engine (arp, bgp, wireguard, table) used for the control plane
+	ControlPlaneEngine string `yaml:"controlPlaneEngine,omitempty"`
+
+	// ServicesEngine, when several engines are enabled this is the engine (arp, bgp, wireguard, table) used for services
+	ServicesEngine string `yaml:"servicesEngine,omitempty"`
+
+	// FeatureGates are a comma separated list of Feature=bool pairs, used to enable experimental features
+	FeatureGates string `yaml:"featureGates,omitempty"`
+
+	// VIPGroups are additional VIPs, each with their own address, interface, engine and peers
+	VIPGroups []VIPGroup `yaml:"vipGroups,omitempty"`
+
+	// ManifestExtras are additional containers, volumes and environment injected into generated manifests
+	ManifestExtras *ManifestExtras `yaml:"-"`
+
+	// ManifestSecurityProfiles sets the seccomp and AppArmor profiles of a generated manifest, the profiles have to be
+	// installed on every node first
+	ManifestSecurityProfiles bool `yaml:"-"`
+
+	// ManifestScheduling are the tolerations, node selector, affinity, priority and resources of a generated daemonset
+	ManifestScheduling *ManifestScheduling `yaml:"-"`
 }
 
 // KubernetesLeaderElection defines all of the settings for Kubernetes KubernetesLeaderElection
@@ -218,6 +539,88 @@ type Etcd struct {
 	ClientCertFile string
 	ClientKeyFile  string
 	Endpoints      []string
+
+	// ClientSecret is the name of a TLS secret (ca.crt, tls.crt, tls.key) that holds the etcd client certificates
+	ClientSecret string
+}
+
+// VRRP defines the virtual router that the control plane VIP is elected with
+type VRRP struct {
+	// RouterID identifies the virtual router on the link (1-255), every router of the VIP uses the same ID
+	RouterID int `yaml:"routerID"`
+
+	// Priority of this node (1-254), the node with the highest priority holds the VIP
+	Priority int `yaml:"priority"`
+
+	// AdvertInterval is the interval between the advertisements in milliseconds, in whole seconds with version 2
+	AdvertInterval int `yaml:"advertInterval"`
+
+	// Preempt takes the VIP over from a node with a lower priority
+	Preempt bool `yaml:"preempt"`
+
+	// Version of VRRP, 3 (RFC 5798) or 2 (RFC 3768) for the routers that don't speak version 3
+	Version int `yaml:"version"`
+
+	// Password is the simple text authentication of version 2 (up to 8 characters)
+	Password string `yaml:"password,omitempty"`
+}
+
+// HTTPTLS defines the certificates for the local HTTP endpoints, either from files or a secret
+type HTTPTLS struct {
+	// CertFile and KeyFile are the serving certificate
+	CertFile string `yaml:"certFile,omitempty"`
+	KeyFile  string `yaml:"keyFile,omitempty"`
+
+	// ClientCAFile will require clients to present a certificate signed by this CA
+	ClientCAFile string `yaml:"clientCAFile,omitempty"`
+
+	// Secret is the name of a TLS secret (tls.crt, tls.key and optionally ca.crt for client certificates)
+	Secret string `yaml:"secret,omitempty"`
+}
+
+// Coordination defines the mTLS authenticated channel between kube-vip nodes
+type Coordination struct {
+	// Port that the channel listens on (on every node), 0 disables the channel
+	Port int `yaml:"port,omitempty"`
+
+	// Secret holds the CA (ca.crt) that the node certificates are signed by, along with the CA key (ca.key) when the
+	// nodes issue their own certificates
+	Secret string `yaml:"secret,omitempty"`
+
+	// SignerName requests the node certificates with a CertificateSigningRequest instead of issuing them with the CA key
+	SignerName string `yaml:"signerName,omitempty"`
+}
+
+// SPIFFE defines the Workload API (e.g. a SPIRE agent) that X.509 identities are fetched from
+type SPIFFE struct {
+	// Socket of the Workload API, e.g. unix:///run/spire/sockets/agent.sock, empty disables SPIFFE
+	Socket string `yaml:"socket,omitempty"`
+
+	// Kubernetes authenticates to the API server with the SVID, instead of the kubeconfig or service account
+	// credentials. The API server has to trust the CA of the trust domain.
+	Kubernetes bool `yaml:"kubernetes,omitempty"`
+
+	// NodeIDTemplate is the SPIFFE ID of every node, e.g. spiffe://example.org/kube-vip/{node}. The coordination
+	// channel uses the SVIDs and authenticates peers by their ID when it is set, instead of using its secret.
+	NodeIDTemplate string `yaml:"nodeIDTemplate,omitempty"`
+}
+
+// VIPGroup defines an additional VIP that is managed independently by the same kube-vip instance
+type VIPGroup struct {
+	// Name of the group, this is also used to name the lease for the group
+	Name string `yaml:"name"`
+
+	// Address is the IP or DNS Name to use as a VirtualIP
+	Address string `yaml:"address"`
+
+	// Interface is the network interface to bind to (defaults to the kube-vip interface)
+	Interface string `yaml:"interface,omitempty"`
+
+	// Mode is the engine used to advertise the VIP, either arp, bgp or table
+	Mode string `yaml:"mode"`
+
+	// BGPPeers are the peers for this group, format: address:as:password:multihop (defaults to the kube-vip peers)
+	BGPPeers []string `yaml:"bgpPeers,omitempty"`
 }
 
 // LoadBalancer contains the configuration of a load balancing instance
@@ -0,0 +1,46 @@
+package kubevip
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	// UPNPProtocolAuto uses the first protocol that the gateway answers, UPnP, PCP and then NAT-PMP (the default)
+	UPNPProtocolAuto = "auto"
+	// UPNPProtocolUPNP maps the ports with the WANIPConnection service of an UPnP Internet Gateway Device
+	UPNPProtocolUPNP = "upnp"
+	// UPNPProtocolNATPMP maps the ports with NAT-PMP (RFC6886)
+	UPNPProtocolNATPMP = "natpmp"
+	// UPNPProtocolPCP maps the ports with the Port Control Protocol (RFC6887)
+	UPNPProtocolPCP = "pcp"
+)
+
+// maxUPNPLeaseDuration is the longest lease of a port mapping that a gateway accepts (UPnP IGD 2)
+const maxUPNPLeaseDuration = 604800
+
+// CheckUPNP will ensure that the protocol of the port mappings is known, that the gateway URL is one of an UPNP gateway
+// and that their lease is one that the gateway accepts
+func (c *Config) CheckUPNP() error {
+	if !c.EnableUPNP {
+		return nil
+	}
+	switch c.UPNPProtocol {
+	case "", UPNPProtocolAuto, UPNPProtocolUPNP, UPNPProtocolNATPMP, UPNPProtocolPCP:
+	default:
+		return fmt.Errorf("upnp protocol [%s] has to be one of %s, %s, %s or %s", c.UPNPProtocol,
+			UPNPProtocolAuto, UPNPProtocolUPNP, UPNPProtocolNATPMP, UPNPProtocolPCP)
+	}
+	if c.UPNPGatewayURL != "" {
+		if c.UPNPProtocol == UPNPProtocolNATPMP || c.UPNPProtocol == UPNPProtocolPCP {
+			return fmt.Errorf("upnp gateway URL is only used by the %s protocol, not %s", UPNPProtocolUPNP, c.UPNPProtocol)
+		}
+		if u, err := url.Parse(c.UPNPGatewayURL); err != nil || u.Scheme != "http" || u.Host == "" {
+			return fmt.Errorf("upnp gateway URL [%s] has to be the http URL of the description of the gateway", c.UPNPGatewayURL)
+		}
+	}
+	if c.UPNPLeaseDuration < 0 || c.UPNPLeaseDuration > maxUPNPLeaseDuration {
+		return fmt.Errorf("upnp lease duration [%d] has to be between 0 (permanent) and %d seconds", c.UPNPLeaseDuration, maxUPNPLeaseDuration)
+	}
+	return nil
+}
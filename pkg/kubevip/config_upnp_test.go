@@ -0,0 +1,29 @@
+package kubevip
+
+import "testing"
+
+func TestCheckUPNP(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"disabled", Config{UPNPLeaseDuration: -1}, false},
+		{"lease", Config{EnableUPNP: true, UPNPLeaseDuration: 3600}, false},
+		{"permanent", Config{EnableUPNP: true}, false},
+		{"negative lease", Config{EnableUPNP: true, UPNPLeaseDuration: -1}, true},
+		{"lease too long", Config{EnableUPNP: true, UPNPLeaseDuration: 1209600}, true},
+		{"pcp", Config{EnableUPNP: true, UPNPProtocol: UPNPProtocolPCP}, false},
+		{"unknown protocol", Config{EnableUPNP: true, UPNPProtocol: "igd"}, true},
+		{"gateway URL", Config{EnableUPNP: true, UPNPGatewayURL: "http://192.168.0.1:5000/rootDesc.xml"}, false},
+		{"gateway URL without a host", Config{EnableUPNP: true, UPNPGatewayURL: "192.168.0.1/rootDesc.xml"}, true},
+		{"gateway URL with natpmp", Config{EnableUPNP: true, UPNPProtocol: UPNPProtocolNATPMP, UPNPGatewayURL: "http://192.168.0.1:5000/rootDesc.xml"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.CheckUPNP(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckUPNP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package kubevip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	// DefaultVRRPPriority is the priority of RFC 5798 for the routers that don't own the addresses
+	DefaultVRRPPriority = 100
+	// DefaultVRRPAdvertInterval is the advertisement interval of RFC 5798, in milliseconds
+	DefaultVRRPAdvertInterval = 1000
+)
+
+// CheckVRRP will ensure that the virtual router can be advertised: VRRP takes the place of the leader election of the
+// control plane VIP, and its intervals and addresses have to fit in the advertisements of the version
+func (c *Config) CheckVRRP() error {
+	if !c.EnableVRRP {
+		return nil
+	}
+	if !c.EnableControlPlane || !c.EnableARP {
+		return fmt.Errorf("vrrp elects the control plane VIP and requires the control plane and arp")
+	}
+	if c.EnableLeaderElection || c.LeaderElectionType == "etcd" || c.Bootstrap != "" {
+		return fmt.Errorf("vrrp elects the control plane VIP instead of leader election, disable leader election and bootstrap")
+	}
+	if c.Handover {
+		return fmt.Errorf("handover keeps the lease of the control plane VIP and can't be used with vrrp")
+	}
+	if c.DDNS {
+		return fmt.Errorf("vrrp advertises the addresses of the VIP, which can't be leased with DHCP")
+	}
+	if c.VRRP.RouterID < 1 || c.VRRP.RouterID > 255 {
+		return fmt.Errorf("the vrrp router ID [%d] has to be between 1 and 255", c.VRRP.RouterID)
+	}
+	// 255 is the priority of the router that owns the addresses, which kube-vip never does
+	if c.VRRP.Priority < 1 || c.VRRP.Priority > 254 {
+		return fmt.Errorf("the vrrp priority [%d] has to be between 1 and 254", c.VRRP.Priority)
+	}
+
+	switch c.VRRP.Version {
+	case 3:
+		if c.VRRP.AdvertInterval < 10 || c.VRRP.AdvertInterval > 40950 || c.VRRP.AdvertInterval%10 != 0 {
+			return fmt.Errorf("the vrrp advertisement interval [%dms] has to be in centiseconds, up to 40950ms", c.VRRP.AdvertInterval)
+		}
+		if c.VRRP.Password != "" {
+			return fmt.Errorf("vrrp version 3 has no authentication, the password requires version 2")
+		}
+	case 2:
+		if c.VRRP.AdvertInterval < 1000 || c.VRRP.AdvertInterval > 255000 || c.VRRP.AdvertInterval%1000 != 0 {
+			return fmt.Errorf("the vrrp advertisement interval [%dms] has to be in whole seconds with version 2, up to 255s", c.VRRP.AdvertInterval)
+		}
+		if len(c.VRRP.Password) > 8 {
+			return fmt.Errorf("the vrrp password is longer than 8 characters")
+		}
+	default:
+		return fmt.Errorf("vrrp version [%d] is not supported, use 2 or 3", c.VRRP.Version)
+	}
+
+	address := c.VIP
+	if c.Address != "" {
+		address = c.Address
+	}
+	var ipv4, ipv6 bool
+	for _, vip := range strings.Split(address, ",") {
+		ip := net.ParseIP(strings.TrimSpace(vip))
+		if ip == nil {
+			return fmt.Errorf("vrrp advertises the addresses of the VIP, [%s] isn't an address", vip)
+		}
+		if ip.To4() != nil {
+			ipv4 = true
+		} else {
+			ipv6 = true
+		}
+	}
+	if ipv4 && ipv6 {
+		return fmt.Errorf("a vrrp router advertises the addresses of one family, the VIP can't be dual-stack")
+	}
+	if ipv6 && c.VRRP.Version == 2 {
+		return fmt.Errorf("vrrp version 2 only advertises IPv4 addresses")
+	}
+	return nil
+}
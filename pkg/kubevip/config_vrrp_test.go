@@ -0,0 +1,46 @@
+package kubevip
+
+import "testing"
+
+func TestCheckVRRP(t *testing.T) {
+	router := VRRP{RouterID: 51, Priority: DefaultVRRPPriority, AdvertInterval: DefaultVRRPAdvertInterval, Preempt: true, Version: 3}
+	config := func(vip string, mutate func(*Config)) *Config {
+		c := &Config{EnableVRRP: true, EnableControlPlane: true, EnableARP: true, VIP: vip, VRRP: router}
+		if mutate != nil {
+			mutate(c)
+		}
+		return c
+	}
+	tests := []struct {
+		name    string
+		c       *Config
+		wantErr bool
+	}{
+		{"no vrrp", &Config{}, false},
+		{"ipv4", config("192.168.0.40", nil), false},
+		{"ipv6", config("fd00::40", nil), false},
+		{"version 2 with a password", config("192.168.0.40", func(c *Config) { c.VRRP.Version = 2; c.VRRP.Password = "secret" }), false},
+		{"services only", config("192.168.0.40", func(c *Config) { c.EnableControlPlane = false; c.EnableServices = true }), true},
+		{"bgp", config("192.168.0.40", func(c *Config) { c.EnableARP = false; c.EnableBGP = true }), true},
+		{"leader election", config("192.168.0.40", func(c *Config) { c.EnableLeaderElection = true }), true},
+		{"handover", config("192.168.0.40", func(c *Config) { c.Handover = true }), true},
+		{"dhcp", config("0.0.0.0", func(c *Config) { c.DDNS = true }), true},
+		{"no router ID", config("192.168.0.40", func(c *Config) { c.VRRP.RouterID = 0 }), true},
+		{"owner priority", config("192.168.0.40", func(c *Config) { c.VRRP.Priority = 255 }), true},
+		{"interval in milliseconds", config("192.168.0.40", func(c *Config) { c.VRRP.AdvertInterval = 1005 }), true},
+		{"version 2 interval", config("192.168.0.40", func(c *Config) { c.VRRP.Version = 2; c.VRRP.AdvertInterval = 500 }), true},
+		{"version 3 password", config("192.168.0.40", func(c *Config) { c.VRRP.Password = "secret" }), true},
+		{"long password", config("192.168.0.40", func(c *Config) { c.VRRP.Version = 2; c.VRRP.Password = "too-long-password" }), true},
+		{"unknown version", config("192.168.0.40", func(c *Config) { c.VRRP.Version = 1 }), true},
+		{"hostname", config("cp.example.com", nil), true},
+		{"dual-stack", config("192.168.0.40,fd00::40", nil), true},
+		{"version 2 ipv6", config("fd00::40", func(c *Config) { c.VRRP.Version = 2 }), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckVRRP(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckVRRP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
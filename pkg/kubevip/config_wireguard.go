@@ -0,0 +1,58 @@
+package kubevip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/kube-vip/kube-vip/pkg/wireguard"
+)
+
+// minWireguardMTU is the smallest MTU that still carries IPv6 through the tunnel
+const minWireguardMTU = 1280
+
+// CheckWireguard will ensure that the wireguard interfaces can be configured with the ports, MTU and mark
+func (c *Config) CheckWireguard() error {
+	if !c.EnableWireguard {
+		return nil
+	}
+	tunnel, mesh := c.WireguardOptions(), c.WireguardMeshOptions()
+	for _, port := range []int{tunnel.ListenPort, mesh.ListenPort} {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("wireguard port [%d] is not a valid port", port)
+		}
+	}
+	if c.WireguardMesh && tunnel.ListenPort == mesh.ListenPort {
+		return fmt.Errorf("the wireguard tunnel and mesh can't both listen on port [%d]", tunnel.ListenPort)
+	}
+	if c.WireguardMTU != 0 && (c.WireguardMTU < minWireguardMTU || c.WireguardMTU > 65535) {
+		return fmt.Errorf("wireguard MTU [%d] has to be between %d and 65535", c.WireguardMTU, minWireguardMTU)
+	}
+	if c.WireguardProbeAddress != "" && net.ParseIP(c.WireguardProbeAddress) == nil {
+		return fmt.Errorf("wireguard probe address [%s] is not an IP address", c.WireguardProbeAddress)
+	}
+	if c.WireguardMeshSecret != "" && !c.WireguardMesh {
+		return fmt.Errorf("the wireguard mesh secret [%s] is only used with the mesh", c.WireguardMeshSecret)
+	}
+	if c.WireguardFirewallMark < 0 {
+		return fmt.Errorf("wireguard firewall mark [%d] can't be negative", c.WireguardFirewallMark)
+	}
+	return nil
+}
+
+// WireguardOptions returns the settings of the tunnel interface
+func (c *Config) WireguardOptions() wireguard.Options {
+	port := c.WireguardPort
+	if port == 0 {
+		port = wireguard.DefaultPort
+	}
+	return wireguard.Options{ListenPort: port, MTU: c.WireguardMTU, FirewallMark: c.WireguardFirewallMark}
+}
+
+// WireguardMeshOptions returns the settings of the mesh interface
+func (c *Config) WireguardMeshOptions() wireguard.Options {
+	port := c.WireguardMeshPort
+	if port == 0 {
+		port = wireguard.DefaultMeshPort
+	}
+	return wireguard.Options{ListenPort: port, MTU: c.WireguardMTU, FirewallMark: c.WireguardFirewallMark}
+}
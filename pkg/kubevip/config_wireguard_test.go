@@ -0,0 +1,31 @@
+package kubevip
+
+import "testing"
+
+func TestCheckWireguard(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       Config
+		wantErr bool
+	}{
+		{name: "disabled", c: Config{WireguardPort: -1}},
+		{name: "defaults", c: Config{EnableWireguard: true, WireguardMesh: true}},
+		{name: "custom", c: Config{EnableWireguard: true, WireguardMesh: true, WireguardPort: 51830, WireguardMeshPort: 51831, WireguardMTU: 1380, WireguardFirewallMark: 0x4000}},
+		{name: "invalid port", c: Config{EnableWireguard: true, WireguardPort: 70000}, wantErr: true},
+		{name: "same ports", c: Config{EnableWireguard: true, WireguardMesh: true, WireguardPort: 51821}, wantErr: true},
+		{name: "same ports without mesh", c: Config{EnableWireguard: true, WireguardPort: 51821}},
+		{name: "small MTU", c: Config{EnableWireguard: true, WireguardMTU: 576}, wantErr: true},
+		{name: "mesh secret", c: Config{EnableWireguard: true, WireguardMesh: true, WireguardMeshSecret: "wireguard-mesh"}},
+		{name: "mesh secret without mesh", c: Config{EnableWireguard: true, WireguardMeshSecret: "wireguard-mesh"}, wantErr: true},
+		{name: "probe", c: Config{EnableWireguard: true, WireguardProbeAddress: "10.0.0.1"}},
+		{name: "invalid probe", c: Config{EnableWireguard: true, WireguardProbeAddress: "peer"}, wantErr: true},
+		{name: "negative mark", c: Config{EnableWireguard: true, WireguardFirewallMark: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.CheckWireguard(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckWireguard() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// LoadServices will read all of the services from yaml manifests, directories are walked for .yaml/.yml files
+func LoadServices(paths []string) ([]v1.Service, error) {
+	var services []v1.Service
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			// Only filter by extension when walking a directory, files that are named explicitly are always read
+			if file != path && !strings.HasSuffix(file, ".yaml") && !strings.HasSuffix(file, ".yml") {
+				return nil
+			}
+			found, err := loadServicesFile(file)
+			if err != nil {
+				return err
+			}
+			services = append(services, found...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return services, nil
+}
+
+// loadServicesFile will read the services from a single (multi document) yaml file
+func loadServicesFile(file string) ([]v1.Service, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var services []v1.Service
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(b), 4096)
+	for {
+		// Decode each document before its kind is known, other kinds won't fit into a service
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("unable to parse [%s]: %v", file, err)
+		}
+		var typeMeta metav1.TypeMeta
+		if err := json.Unmarshal(raw, &typeMeta); err != nil || typeMeta.Kind != "Service" {
+			continue
+		}
+		var svc v1.Service
+		if err := json.Unmarshal(raw, &svc); err != nil {
+			return nil, fmt.Errorf("unable to parse service in [%s]: %v", file, err)
+		}
+		if svc.Namespace == "" {
+			svc.Namespace = "default"
+		}
+		services = append(services, svc)
+	}
+	return services, nil
+}
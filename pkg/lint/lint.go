@@ -0,0 +1,312 @@
+package lint
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Severity is how serious a finding is, only errors should fail a CI run
+type Severity string
+
+const (
+	// Error findings are annotations that kube-vip can't use
+	Error Severity = "ERROR"
+	// Warning findings are annotations that are probably not doing what was intended
+	Warning Severity = "WARN"
+)
+
+// Finding is a single problem with the annotations of a service
+type Finding struct {
+	Namespace string
+	Name      string
+	Severity  Severity
+	Message   string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("[%s] %s/%s: %s", f.Severity, f.Namespace, f.Name, f.Message)
+}
+
+// The annotations that kube-vip reads from services
+const (
+	annotationPrefix         = "kube-vip.io/"
+	loadbalancerIPAnnotation = "kube-vip.io/loadbalancerIPs"
+	requestedIP              = "kube-vip.io/requestedIP"
+	hwAddrKey                = "kube-vip.io/hwaddr"
+	egress                   = "kube-vip.io/egress"
+	egressDestinationPorts   = "kube-vip.io/egress-destination-ports"
+	egressSourcePorts        = "kube-vip.io/egress-source-ports"
+	egressSNATPorts          = "kube-vip.io/egress-snat-ports"
+	egressExcludeCidrs       = "kube-vip.io/egress-exclude-cidrs"
+	egressPlacement          = "kube-vip.io/egress-placement"
+	flushContrack            = "kube-vip.io/flush-conntrack"
+	ignore                   = "kube-vip.io/ignore"
+	ignoreServiceSecurity    = "kube-vip.io/ignore-service-security"
+	serviceEngine            = "kube-vip.io/engine"
+)
+
+// knownAnnotations are all of the annotations that kube-vip reads or writes on services
+var knownAnnotations = map[string]bool{
+	loadbalancerIPAnnotation:           true,
+	requestedIP:                        true,
+	hwAddrKey:                          true,
+	egress:                             true,
+	egressDestinationPorts:             true,
+	egressSourcePorts:                  true,
+	egressSNATPorts:                    true,
+	egressExcludeCidrs:                 true,
+	egressPlacement:                    true,
+	flushContrack:                      true,
+	ignore:                             true,
+	ignoreServiceSecurity:              true,
+	serviceEngine:                      true,
+	"kube-vip.io/vipHost":              true,
+	"kube-vip.io/active-endpoint":      true,
+	"kube-vip.io/active-endpoint-ipv6": true,
+	"kube-vip.io/loadbalancerHostname": true,
+	"kube-vip.io/serviceInterface":     true,
+}
+
+// boolAnnotations are only enabled with the exact value "true"
+var boolAnnotations = []string{egress, flushContrack, ignore, ignoreServiceSecurity}
+
+// Services will check the kube-vip annotations of each service, and whether services can share their addresses
+func Services(services []v1.Service) []Finding {
+	var findings []Finding
+	for x := range services {
+		findings = append(findings, service(&services[x])...)
+	}
+	findings = append(findings, sharing(services)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Namespace != findings[j].Namespace {
+			return findings[i].Namespace < findings[j].Namespace
+		}
+		return findings[i].Name < findings[j].Name
+	})
+	return findings
+}
+
+// service will check the annotations of a single service
+func service(svc *v1.Service) []Finding {
+	var findings []Finding
+	add := func(severity Severity, format string, args ...interface{}) {
+		findings = append(findings, Finding{
+			Namespace: svc.Namespace,
+			Name:      svc.Name,
+			Severity:  severity,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+
+	var keys []string
+	for key := range svc.Annotations {
+		if strings.HasPrefix(key, annotationPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+
+	if svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+		add(Warning, "has kube-vip annotations but is of type [%s], only LoadBalancer services are managed", svc.Spec.Type)
+	}
+
+	for _, key := range keys {
+		if !knownAnnotations[key] {
+			add(Warning, "unknown annotation [%s]", key)
+		}
+	}
+
+	if v, exists := svc.Annotations[loadbalancerIPAnnotation]; exists {
+		for _, ip := range strings.Split(v, ",") {
+			if net.ParseIP(strings.TrimSpace(ip)) == nil {
+				add(Error, "[%s] has an invalid address [%s]", loadbalancerIPAnnotation, strings.TrimSpace(ip))
+			}
+		}
+		if svc.Spec.LoadBalancerIP != "" {
+			add(Warning, "both [%s] and spec.loadBalancerIP are set, spec.loadBalancerIP is ignored", loadbalancerIPAnnotation)
+		}
+	}
+	if svc.Spec.LoadBalancerIP != "" && net.ParseIP(svc.Spec.LoadBalancerIP) == nil {
+		add(Error, "spec.loadBalancerIP has an invalid address [%s]", svc.Spec.LoadBalancerIP)
+	}
+
+	if v, exists := svc.Annotations[requestedIP]; exists && net.ParseIP(v) == nil {
+		add(Error, "[%s] has an invalid address [%s]", requestedIP, v)
+	}
+	if v, exists := svc.Annotations[hwAddrKey]; exists {
+		if _, err := net.ParseMAC(v); err != nil {
+			add(Error, "[%s] has an invalid hardware address [%s]", hwAddrKey, v)
+		}
+	}
+
+	for _, key := range boolAnnotations {
+		if v, exists := svc.Annotations[key]; exists && v != "true" && v != "false" {
+			add(Warning, "[%s] is [%s], only \"true\" enables it", key, v)
+		}
+	}
+
+	for _, key := range []string{egressDestinationPorts, egressSourcePorts} {
+		if v, exists := svc.Annotations[key]; exists {
+			if err := checkPorts(v); err != nil {
+				add(Error, "[%s] %v", key, err)
+			}
+			if svc.Annotations[egress] != "true" {
+				add(Warning, "[%s] is set but egress isn't enabled", key)
+			}
+		}
+	}
+	if v, exists := svc.Annotations[egressSNATPorts]; exists {
+		if err := checkPortRange(v); err != nil {
+			add(Error, "[%s] %v", egressSNATPorts, err)
+		}
+		if svc.Annotations[egress] != "true" {
+			add(Warning, "[%s] is set but egress isn't enabled", egressSNATPorts)
+		}
+	}
+	if v, exists := svc.Annotations[serviceEngine]; exists && !slices.Contains([]string{"arp", "bgp", "wireguard", "table"}, v) {
+		add(Error, "[%s] is [%s], it has to be arp, bgp, wireguard or table", serviceEngine, v)
+	}
+	if v, exists := svc.Annotations[egressPlacement]; exists && v != "leader" && v != "pod" {
+		add(Error, "[%s] is [%s], it has to be leader or pod", egressPlacement, v)
+	}
+	if v, exists := svc.Annotations[egressExcludeCidrs]; exists {
+		for _, cidr := range strings.Split(v, ",") {
+			cidr = strings.TrimSpace(cidr)
+			if _, _, err := net.ParseCIDR(cidr); err != nil && cidr != "rfc1918" {
+				add(Error, "[%s] has an invalid CIDR [%s]", egressExcludeCidrs, cidr)
+			}
+		}
+		if svc.Annotations[egress] != "true" {
+			add(Warning, "[%s] is set but egress isn't enabled", egressExcludeCidrs)
+		}
+	}
+	return findings
+}
+
+// checkPortRange will check a range of ports in the format first-last
+func checkPortRange(ports string) error {
+	first, last, found := strings.Cut(ports, "-")
+	if !found {
+		last = first
+	}
+	start, err := strconv.ParseUint(first, 10, 16)
+	if err != nil || start == 0 {
+		return fmt.Errorf("invalid port range [%s], expected the format first-last", ports)
+	}
+	if end, err := strconv.ParseUint(last, 10, 16); err != nil || end < start {
+		return fmt.Errorf("invalid port range [%s], expected the format first-last", ports)
+	}
+	return nil
+}
+
+// checkPorts will check a list of ports in the format protocol, protocol:port or protocol:first-last
+func checkPorts(ports string) error {
+	for _, p := range strings.Split(ports, ",") {
+		protocol, port, found := strings.Cut(strings.TrimSpace(p), ":")
+		switch strings.ToLower(protocol) {
+		case "tcp", "udp", "sctp":
+		default:
+			if found {
+				return fmt.Errorf("invalid port [%s], unknown protocol [%s]", p, protocol)
+			}
+			// A port on its own is a TCP port
+			port, found = protocol, true
+		}
+		if !found {
+			continue
+		}
+		if err := checkPortRange(port); err != nil {
+			return fmt.Errorf("invalid port [%s]", p)
+		}
+	}
+	return nil
+}
+
+// addresses returns the addresses that a service requests, the same way that kube-vip finds them
+func addresses(svc *v1.Service) []string {
+	if v, exists := svc.Annotations[loadbalancerIPAnnotation]; exists {
+		var ips []string
+		for _, ip := range strings.Split(v, ",") {
+			ips = append(ips, strings.TrimSpace(ip))
+		}
+		return ips
+	}
+	if svc.Spec.LoadBalancerIP != "" {
+		return []string{svc.Spec.LoadBalancerIP}
+	}
+	return nil
+}
+
+// sharing will find services that share an address but can't, because the ports overlap or egress is enabled
+func sharing(services []v1.Service) []Finding {
+	type user struct {
+		svc   *v1.Service
+		ports []string
+	}
+	shared := map[string][]user{}
+	var order []string
+
+	for x := range services {
+		svc := &services[x]
+		if svc.Spec.Type != v1.ServiceTypeLoadBalancer || svc.Annotations[ignore] == "true" {
+			continue
+		}
+		var ports []string
+		for _, p := range svc.Spec.Ports {
+			protocol := p.Protocol
+			if protocol == "" {
+				protocol = v1.ProtocolTCP
+			}
+			ports = append(ports, fmt.Sprintf("%s/%d", protocol, p.Port))
+		}
+		for _, address := range addresses(svc) {
+			// DHCP addresses are allocated per service
+			if address == "0.0.0.0" || net.ParseIP(address) == nil {
+				continue
+			}
+			if _, exists := shared[address]; !exists {
+				order = append(order, address)
+			}
+			shared[address] = append(shared[address], user{svc: svc, ports: ports})
+		}
+	}
+
+	var findings []Finding
+	for _, address := range order {
+		users := shared[address]
+		for i := range users {
+			for j := i + 1; j < len(users); j++ {
+				a, b := users[i], users[j]
+				for _, port := range a.ports {
+					if slices.Contains(b.ports, port) {
+						findings = append(findings, Finding{
+							Namespace: b.svc.Namespace,
+							Name:      b.svc.Name,
+							Severity:  Error,
+							Message:   fmt.Sprintf("shares address [%s] with %s/%s but both use port [%s]", address, a.svc.Namespace, a.svc.Name, port),
+						})
+					}
+				}
+				if a.svc.Annotations[egress] == "true" || b.svc.Annotations[egress] == "true" {
+					findings = append(findings, Finding{
+						Namespace: b.svc.Namespace,
+						Name:      b.svc.Name,
+						Severity:  Error,
+						Message:   fmt.Sprintf("shares address [%s] with %s/%s but egress can't be used with a shared address", address, a.svc.Namespace, a.svc.Name),
+					})
+				}
+			}
+		}
+	}
+	return findings
+}
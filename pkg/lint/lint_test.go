@@ -0,0 +1,108 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func loadBalancer(name string, annotations map[string]string, ports ...int32) v1.Service {
+	svc := v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+		Spec:       v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+	}
+	for _, port := range ports {
+		svc.Spec.Ports = append(svc.Spec.Ports, v1.ServicePort{Port: port, Protocol: v1.ProtocolTCP})
+	}
+	return svc
+}
+
+func TestServices(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []v1.Service
+		errors   int
+		warnings int
+	}{
+		{"valid", []v1.Service{loadBalancer("a", map[string]string{loadbalancerIPAnnotation: "10.0.0.1, fd00::1"}, 80)}, 0, 0},
+		{"bad address", []v1.Service{loadBalancer("a", map[string]string{loadbalancerIPAnnotation: "10.0.0.300"}, 80)}, 1, 0},
+		{"unknown key", []v1.Service{loadBalancer("a", map[string]string{"kube-vip.io/loadBalancerIPs": "10.0.0.1"}, 80)}, 0, 1},
+		{"bad egress ports", []v1.Service{loadBalancer("a", map[string]string{egress: "true", egressDestinationPorts: "tcp:80,http:8080"}, 80)}, 1, 0},
+		{"snat ports", []v1.Service{loadBalancer("a", map[string]string{egress: "true", egressSNATPorts: "20000-29999"}, 80)}, 0, 0},
+		{"bad snat ports", []v1.Service{loadBalancer("a", map[string]string{egress: "true", egressSNATPorts: "29999-20000"}, 80)}, 1, 0},
+		{"exclude cidrs", []v1.Service{loadBalancer("a", map[string]string{egress: "true", egressExcludeCidrs: "rfc1918, 100.64.0.0/10"}, 80)}, 0, 0},
+		{"bad exclude cidrs", []v1.Service{loadBalancer("a", map[string]string{egress: "true", egressExcludeCidrs: "10.0.0.0"}, 80)}, 1, 0},
+		{"egress protocols", []v1.Service{loadBalancer("a", map[string]string{egress: "true", egressDestinationPorts: "udp, tcp:8000-9000, 443"}, 80)}, 0, 0},
+		{"engine", []v1.Service{loadBalancer("a", map[string]string{serviceEngine: "bgp"}, 80)}, 0, 0},
+		{"unknown engine", []v1.Service{loadBalancer("a", map[string]string{serviceEngine: "ospf"}, 80)}, 1, 0},
+		{"not a bool", []v1.Service{loadBalancer("a", map[string]string{egress: "yes"}, 80)}, 0, 1},
+		{"sharing", []v1.Service{
+			loadBalancer("a", map[string]string{loadbalancerIPAnnotation: "10.0.0.1"}, 80),
+			loadBalancer("b", map[string]string{loadbalancerIPAnnotation: "10.0.0.1"}, 443),
+		}, 0, 0},
+		{"impossible sharing", []v1.Service{
+			loadBalancer("a", map[string]string{loadbalancerIPAnnotation: "10.0.0.1"}, 80, 443),
+			loadBalancer("b", map[string]string{loadbalancerIPAnnotation: "10.0.0.1"}, 443),
+		}, 1, 0},
+		{"sharing with egress", []v1.Service{
+			loadBalancer("a", map[string]string{loadbalancerIPAnnotation: "10.0.0.1", egress: "true"}, 80),
+			loadBalancer("b", map[string]string{loadbalancerIPAnnotation: "10.0.0.1"}, 443),
+		}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errors, warnings int
+			findings := Services(tt.services)
+			for _, f := range findings {
+				switch f.Severity {
+				case Error:
+					errors++
+				case Warning:
+					warnings++
+				}
+			}
+			if errors != tt.errors || warnings != tt.warnings {
+				t.Errorf("Services() errors = %d, warnings = %d, want %d, %d: %v", errors, warnings, tt.errors, tt.warnings, findings)
+			}
+		})
+	}
+}
+
+func TestLoadServices(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `apiVersion: v1
+kind: Service
+metadata:
+  name: a
+  annotations:
+    kube-vip.io/loadbalancerIPs: 10.0.0.1
+spec:
+  type: LoadBalancer
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: b
+spec:
+  selector:
+    matchLabels:
+      app: b
+`
+	if err := os.WriteFile(filepath.Join(dir, "svc.yaml"), []byte(manifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# not a manifest"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	services, err := LoadServices([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || services[0].Name != "a" || services[0].Namespace != "default" {
+		t.Errorf("LoadServices() = %v", services)
+	}
+}
@@ -36,9 +36,21 @@ the nodes from the IPVS load-balancer.
 */
 
 const (
-	ROUNDROBIN = "rr"
+	ROUNDROBIN         = "rr"
+	WEIGHTEDROUNDROBIN = "wrr"
+	LEASTCONNECTION    = "lc"
+	SOURCEHASHING      = "sh"
 )
 
+// Schedulers are the IPVS schedulers that the control plane can be balanced with
+var Schedulers = []string{ROUNDROBIN, WEIGHTEDROUNDROBIN, LEASTCONNECTION, SOURCEHASHING}
+
+// Backends is a load-balancer that the control plane nodes are added to and removed from
+type Backends interface {
+	AddBackend(address string, port int) error
+	RemoveBackend(address string, port int) error
+}
+
 type Backend struct {
 	Addr string
 	Port int
@@ -55,7 +67,9 @@ type IPVSLoadBalancer struct {
 	stop                chan struct{}
 }
 
-func NewIPVSLB(address string, port int, forwardingMethod string, backendHealthCheckInterval int) (*IPVSLoadBalancer, error) {
+// NewIPVSLB creates the IPVS load-balancer of the VIP, the connections are scheduled with the scheduler (round robin if
+// it is empty) and the connections of a client stay with its backend for the persistence timeout (in seconds)
+func NewIPVSLB(address string, port int, forwardingMethod string, backendHealthCheckInterval int, scheduler string, persistenceTimeout int) (*IPVSLoadBalancer, error) {
 	// Create IPVS client
 	c, err := ipvs.New()
 	if err != nil {
@@ -70,35 +84,23 @@ func NewIPVSLB(address string, port int, forwardingMethod string, backendHealthC
 	log.Infof("IPVS Loadbalancer enabled for %d.%d.%d", i.Version[0], i.Version[1], i.Version[2])
 
 	if strings.ToLower(forwardingMethod) == "masquerade" {
-		err = sysctl.WriteProcSys("/proc/sys/net/ipv4/vs/conntrack", "1")
-		if err != nil {
-			log.Fatalf("Error ensuring net.ipv4.vs.conntrack enabled [%v]", err)
-		}
-		log.Infof("sysctl set net.ipv4.vs.conntrack to 1")
-
-		err = sysctl.WriteProcSys("/proc/sys/net/ipv4/ip_forward", "1")
-		if err != nil {
-			log.Fatalf("Error ensuring net.ipv4.ip_forward enabled [%v]", err)
+		for _, setting := range []struct{ name, path string }{
+			{"net.ipv4.vs.conntrack", "/proc/sys/net/ipv4/vs/conntrack"},
+			{"net.ipv4.ip_forward", "/proc/sys/net/ipv4/ip_forward"},
+		} {
+			changed, err := sysctl.EnsureProcSys(setting.path, "1")
+			if err != nil {
+				log.Fatalf("Error ensuring %s enabled, set it to 1 on the node or run kube-vip privileged [%v]", setting.name, err)
+			}
+			if changed {
+				log.Infof("sysctl set %s to 1", setting.name)
+			}
 		}
-		log.Infof("sysctl set net.ipv4.ip_forward to 1")
-	}
-
-	ip, family := ipAndFamily(address)
-
-	netMask := netmask.MaskFrom(31, 32) // For ipv4
-	if family == ipvs.INET6 {
-		netMask = netmask.MaskFrom(128, 128) // For ipv6
 	}
 
 	// Generate out API Server LoadBalancer instance
-	svc := ipvs.Service{
-		Netmask:   netMask,
-		Family:    family,
-		Protocol:  ipvs.TCP,
-		Port:      uint16(port),
-		Address:   ip,
-		Scheduler: ROUNDROBIN,
-	}
+	svc := newService(address, port, scheduler, persistenceTimeout)
+	log.Infof("IPVS Loadbalancer scheduling with [%s], persistence timeout [%ds]", svc.Scheduler, svc.Timeout)
 
 	var m ipvs.ForwardType
 	switch strings.ToLower(forwardingMethod) {
@@ -138,6 +140,36 @@ func NewIPVSLB(address string, port int, forwardingMethod string, backendHealthC
 	return lb, nil
 }
 
+// newService returns the IPVS service of the VIP, the scheduler defaults to round robin
+func newService(address string, port int, scheduler string, persistenceTimeout int) ipvs.Service {
+	ip, family := ipAndFamily(address)
+
+	netMask := netmask.MaskFrom(31, 32) // For ipv4
+	if family == ipvs.INET6 {
+		netMask = netmask.MaskFrom(128, 128) // For ipv6
+	}
+
+	if scheduler == "" {
+		scheduler = ROUNDROBIN
+	}
+
+	svc := ipvs.Service{
+		Netmask:   netMask,
+		Family:    family,
+		Protocol:  ipvs.TCP,
+		Port:      uint16(port),
+		Address:   ip,
+		Scheduler: scheduler,
+	}
+	// The connections of a client (of the netmask) are sent to the same backend until the timeout has passed since
+	// its last connection
+	if persistenceTimeout > 0 {
+		svc.Flags |= ipvs.ServicePersistent
+		svc.Timeout = uint32(persistenceTimeout)
+	}
+	return svc
+}
+
 func (lb *IPVSLoadBalancer) RemoveIPVSLB() error {
 	close(lb.stop)
 	err := lb.client.RemoveService(lb.loadBalancerService)
@@ -47,3 +47,32 @@ func Test_ipAndFamily(t *testing.T) {
 		})
 	}
 }
+
+func Test_newService(t *testing.T) {
+	tests := []struct {
+		name               string
+		scheduler          string
+		persistenceTimeout int
+		wantScheduler      string
+		wantFlags          ipvs.Flags
+		wantTimeout        uint32
+	}{
+		{name: "default", wantScheduler: ROUNDROBIN},
+		{name: "least connection", scheduler: LEASTCONNECTION, wantScheduler: LEASTCONNECTION},
+		{name: "persistent", scheduler: WEIGHTEDROUNDROBIN, persistenceTimeout: 300, wantScheduler: WEIGHTEDROUNDROBIN, wantFlags: ipvs.ServicePersistent, wantTimeout: 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newService("192.168.0.100", 6443, tt.scheduler, tt.persistenceTimeout)
+			if svc.Scheduler != tt.wantScheduler {
+				t.Errorf("newService() scheduler = %s, want %s", svc.Scheduler, tt.wantScheduler)
+			}
+			if svc.Flags != tt.wantFlags || svc.Timeout != tt.wantTimeout {
+				t.Errorf("newService() flags = %v timeout = %d, want %v and %d", svc.Flags, svc.Timeout, tt.wantFlags, tt.wantTimeout)
+			}
+			if svc.Port != 6443 || svc.Family != ipvs.INET || svc.Address != netip.MustParseAddr("192.168.0.100") {
+				t.Errorf("newService() = %+v, want the VIP on port 6443", svc)
+			}
+		})
+	}
+}
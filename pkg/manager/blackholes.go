@@ -0,0 +1,115 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+// startBlackholes will blackhole the addresses of the pools that aren't allocated to a service, the routes are kept in
+// line with the services in every namespace (not only those advertised by this node)
+func (sm *Manager) startBlackholes(ctx context.Context) error {
+	pools, err := kubevip.ParseCIDRs(sm.config.BlackholePools)
+	if err != nil {
+		return err
+	}
+	table := unix.RT_TABLE_MAIN
+	if sm.config.EnableRoutingTable {
+		table = sm.config.RoutingTableID
+	}
+
+	services, err := sm.clientSet.CoreV1().Services(v1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list the services allocated from the pools: %v", err)
+	}
+	allocated := map[types.UID][]net.IP{}
+	for x := range services.Items {
+		updateAllocated(allocated, &services.Items[x], false)
+	}
+	sm.syncBlackholes(table, pools, allocated)
+
+	rw, err := watchtools.NewRetryWatcher(services.ResourceVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return sm.clientSet.CoreV1().Services(v1.NamespaceAll).Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating blackhole services watcher: %v", err)
+	}
+	go func() {
+		select {
+		case <-sm.shutdownChan:
+		case <-ctx.Done():
+		}
+		rw.Stop()
+	}()
+	go func() {
+		for event := range rw.ResultChan() {
+			svc, ok := event.Object.(*v1.Service)
+			if !ok {
+				continue
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if !updateAllocated(allocated, svc, false) {
+					continue
+				}
+			case watch.Deleted:
+				updateAllocated(allocated, svc, true)
+			default:
+				continue
+			}
+			sm.syncBlackholes(table, pools, allocated)
+		}
+	}()
+	return nil
+}
+
+// updateAllocated updates the addresses that are allocated to the service, returning true if they have changed
+func updateAllocated(allocated map[types.UID][]net.IP, svc *v1.Service, deleted bool) bool {
+	existing, exists := allocated[svc.UID]
+	if deleted || svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+		delete(allocated, svc.UID)
+		return exists
+	}
+	var addresses []net.IP
+	for _, address := range fetchServiceAddresses(svc) {
+		if ip := net.ParseIP(address); ip != nil {
+			addresses = append(addresses, ip)
+		}
+	}
+	if exists && slices.EqualFunc(existing, addresses, func(a, b net.IP) bool { return a.Equal(b) }) {
+		return false
+	}
+	allocated[svc.UID] = addresses
+	return true
+}
+
+// syncBlackholes configures the blackhole routes for the unallocated addresses of the pools
+func (sm *Manager) syncBlackholes(table int, pools []*net.IPNet, allocated map[types.UID][]net.IP) {
+	var addresses []net.IP
+	for _, ips := range allocated {
+		addresses = append(addresses, ips...)
+	}
+	added, removed, err := vip.SyncBlackholeRoutes(table, sm.config.RoutingProtocol, vip.UnallocatedNetworks(pools, addresses))
+	if err != nil {
+		log.Errorf("[route] unable to configure the blackhole routes of the pools: %v", err)
+		return
+	}
+	if added != 0 || removed != 0 {
+		log.Infof("[route] blackhole routes of the pools updated in table [%d], %d added and %d removed", table, added, removed)
+	}
+}
@@ -1,10 +1,13 @@
 package manager
 
 import (
+	"context"
+
 	"github.com/pkg/errors"
 
 	"github.com/kube-vip/kube-vip/pkg/cluster"
 	"github.com/kube-vip/kube-vip/pkg/etcd"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
 )
 
 func initClusterManager(sm *Manager) (*cluster.Manager, error) {
@@ -15,12 +18,27 @@ func initClusterManager(sm *Manager) (*cluster.Manager, error) {
 	switch sm.config.LeaderElectionType {
 	case "kubernetes", "":
 		m.KubernetesClient = sm.clientSet
+		if sm.config.Bootstrap == kubevip.BootstrapEtcd {
+			// etcd elects the leader until the API server is healthy
+			client, err := etcd.NewClient(sm.config)
+			if err != nil {
+				return nil, err
+			}
+			m.EtcdClient = client
+		}
 	case "etcd":
+		if err := sm.loadEtcdSecret(context.TODO()); err != nil {
+			return nil, err
+		}
 		client, err := etcd.NewClient(sm.config)
 		if err != nil {
 			return nil, err
 		}
 		m.EtcdClient = client
+		if sm.config.Etcd.ClientCertFile != "" {
+			// Reconnect with renewed certificates rather than waiting for the old ones to be rejected
+			go etcd.WatchCertificate(context.TODO(), client, sm.config.Etcd.ClientCertFile, credentialWatchInterval, sm.etcdCertificateExpiry)
+		}
 	default:
 		return nil, errors.Errorf("invalid LeaderElectionMode %s not supported", sm.config.LeaderElectionType)
 	}
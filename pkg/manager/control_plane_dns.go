@@ -0,0 +1,200 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/kube-vip/kube-vip/pkg/ddns"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
+)
+
+const (
+	// controlPlaneDNSLock is the lease of the node that keeps the records of the control plane
+	controlPlaneDNSLock = "plndr-cp-dns-lock"
+	// controlPlaneDNSInterval is how often the control plane nodes are checked
+	controlPlaneDNSInterval = 10 * time.Second
+	// controlPlaneLabel is the label of the control plane nodes
+	controlPlaneLabel = "node-role.kubernetes.io/control-plane"
+)
+
+// startControlPlaneDNS keeps the records of the control plane hostname listing the control plane nodes that are ready
+// and whose API server answers, a single node (the leader of its lease) publishes them. In the leader mode the records
+// only list the leader, which gives up the leadership when its own API server isn't healthy.
+func (sm *Manager) startControlPlaneDNS(ctx context.Context) error {
+	provider, err := ddns.NewFromConfig(ctx, sm.config, sm.clientSet)
+	if err != nil {
+		return err
+	}
+	records, ok := provider.(ddns.RecordSetProvider)
+	if !ok {
+		return fmt.Errorf("ddns provider [%s] can't publish the records of the control plane", provider.Name())
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		<-sm.shutdownChan
+		cancel()
+	}()
+
+	id := sm.config.NodeName
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      controlPlaneDNSLock,
+			Namespace: sm.config.Namespace,
+		},
+		Client: sm.clientSet.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: id,
+		},
+	}
+	leadership := securityevents.NewLeadership(ctx, controlPlaneDNSLock, id)
+
+	leader := sm.config.ControlPlaneDNSMode == kubevip.ControlPlaneDNSLeader
+	if leader {
+		log.Infof("[ddns] the records of [%s] point at the leader, lock name [%s], id [%s]", sm.config.ControlPlaneDNS, controlPlaneDNSLock, id)
+	} else {
+		log.Infof("[ddns] the records of [%s] list the healthy control plane nodes, lock name [%s], id [%s]", sm.config.ControlPlaneDNS, controlPlaneDNSLock, id)
+	}
+	go func() {
+		for ctx.Err() == nil {
+			// Each round of the election can be ended by the leader stepping down
+			round, stepDown := context.WithCancel(ctx)
+			unhealthy := false
+			leaderelection.RunOrDie(round, leaderelection.LeaderElectionConfig{
+				Lock:            lock,
+				ReleaseOnCancel: true,
+				LeaseDuration:   time.Duration(sm.config.LeaseDuration) * time.Second,
+				RenewDeadline:   time.Duration(sm.config.RenewDeadline) * time.Second,
+				RetryPeriod:     time.Duration(sm.config.RetryPeriod) * time.Second,
+				Callbacks: leaderelection.LeaderCallbacks{
+					OnStartedLeading: func(ctx context.Context) {
+						leadership.Started()
+						if !sm.publishControlPlaneDNS(ctx, records, leader) {
+							unhealthy = true
+							stepDown()
+						}
+					},
+					OnStoppedLeading: func() {
+						log.Infof("[ddns] no longer publishing the records of [%s]", sm.config.ControlPlaneDNS)
+					},
+					OnNewLeader: func(identity string) {
+						leadership.NewLeader(identity)
+					},
+				},
+			})
+			stepDown()
+
+			// The other nodes are given the time to take over, before this node is elected again
+			if unhealthy {
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Duration(sm.config.LeaseDuration) * time.Second):
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// publishControlPlaneDNS publishes the addresses of the healthy control plane nodes (or only those of this node, as the
+// leader) whenever they change, until the leadership is lost. It returns false if this node stops being healthy while
+// the records point at it.
+func (sm *Manager) publishControlPlaneDNS(ctx context.Context, records ddns.RecordSetProvider, leader bool) bool {
+	ticker := time.NewTicker(controlPlaneDNSInterval)
+	defer ticker.Stop()
+
+	listOptions := metav1.ListOptions{LabelSelector: controlPlaneLabel}
+	if leader {
+		listOptions.FieldSelector = fields.OneTermEqualSelector("metadata.name", sm.config.NodeName).String()
+	}
+
+	var published []string
+	for {
+		nodes, err := sm.clientSet.CoreV1().Nodes().List(ctx, listOptions)
+		if err != nil {
+			log.Errorf("[ddns] unable to list the control plane nodes: %v", err)
+		} else {
+			addresses := healthyControlPlaneAddresses(nodes.Items, func(address string) bool {
+				return probeAPIServer(ctx, address, sm.config.Port)
+			})
+			switch {
+			case len(addresses) == 0 && leader:
+				// The records are left for the next leader to replace, rather than removing the only record
+				log.Warnf("[ddns] node [%s] isn't healthy, giving up the records of [%s]", sm.config.NodeName, sm.config.ControlPlaneDNS)
+				return false
+			case len(addresses) == 0:
+				// The last nodes are kept, a record without any address would make the cluster unreachable anyway
+				log.Warnf("[ddns] none of the control plane nodes are healthy, [%s] is left as %v", sm.config.ControlPlaneDNS, published)
+			case !slices.Equal(addresses, published):
+				if err := records.UpdateSet(ctx, sm.config.ControlPlaneDNS, addresses); err != nil {
+					log.Errorf("[ddns] unable to publish the records of [%s]: %v", sm.config.ControlPlaneDNS, err)
+				} else {
+					log.Infof("[ddns] the records of [%s] are now %v", sm.config.ControlPlaneDNS, addresses)
+					published = addresses
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return true
+		case <-ticker.C:
+		}
+	}
+}
+
+// healthyControlPlaneAddresses returns the sorted internal addresses of the nodes that are ready, and whose API server
+// answers the probe
+func healthyControlPlaneAddresses(nodes []v1.Node, probe func(address string) bool) []string {
+	var addresses []string
+	for x := range nodes {
+		if !nodeReady(&nodes[x]) {
+			continue
+		}
+		for _, address := range nodes[x].Status.Addresses {
+			if address.Type != v1.NodeInternalIP || net.ParseIP(address.Address) == nil {
+				continue
+			}
+			if probe(address.Address) && !slices.Contains(addresses, address.Address) {
+				addresses = append(addresses, address.Address)
+			}
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// nodeReady returns true if the node is reporting that it is ready
+func nodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// probeAPIServer returns true if the API server of the node accepts connections
+func probeAPIServer(ctx context.Context, address string, port int) bool {
+	dialer := &net.Dialer{Timeout: 2 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+	if err != nil {
+		log.Debugf("[ddns] the API server of [%s] isn't answering: %v", address, err)
+		return false
+	}
+	conn.Close()
+	return true
+}
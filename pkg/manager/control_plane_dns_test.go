@@ -0,0 +1,32 @@
+package manager
+
+import (
+	"slices"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestHealthyControlPlaneAddresses(t *testing.T) {
+	node := func(ready v1.ConditionStatus, addresses ...string) v1.Node {
+		n := v1.Node{Status: v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: ready}}}}
+		for _, address := range addresses {
+			n.Status.Addresses = append(n.Status.Addresses, v1.NodeAddress{Type: v1.NodeInternalIP, Address: address})
+		}
+		n.Status.Addresses = append(n.Status.Addresses, v1.NodeAddress{Type: v1.NodeHostName, Address: "cp"})
+		return n
+	}
+	nodes := []v1.Node{
+		node(v1.ConditionTrue, "192.168.0.12", "fd00::12"),
+		node(v1.ConditionTrue, "192.168.0.11"),
+		node(v1.ConditionFalse, "192.168.0.13"),
+		node(v1.ConditionTrue, "192.168.0.14"),
+	}
+	// The API server of the last node isn't answering
+	probe := func(address string) bool { return address != "192.168.0.14" }
+
+	want := []string{"192.168.0.11", "192.168.0.12", "fd00::12"}
+	if got := healthyControlPlaneAddresses(nodes, probe); !slices.Equal(got, want) {
+		t.Errorf("healthyControlPlaneAddresses() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,142 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/coordination"
+	"github.com/kube-vip/kube-vip/pkg/spiffe"
+)
+
+const (
+	// coordinationCAKey is the key of the CA private key in the coordination secret, ca.crt holds the certificate
+	coordinationCAKey = "ca.key"
+
+	// coordinationRenewInterval is how often the node certificate is renewed, well before it expires
+	coordinationRenewInterval = 24 * time.Hour
+
+	// coordinationRequestTimeout is how long to wait for a certificate request to be signed
+	coordinationRequestTimeout = 5 * time.Minute
+)
+
+// startCoordination will start the mTLS channel between kube-vip nodes, the certificate of this node is issued when
+// the CA secret is loaded or rotated and renewed every day. With SPIFFE node IDs the SVIDs of the node are used.
+func (sm *Manager) startCoordination(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		<-sm.shutdownChan
+		cancel()
+	}()
+
+	var err error
+	if template := sm.config.SPIFFE.NodeIDTemplate; template != "" {
+		err = sm.startSPIFFECoordination(ctx, template)
+	} else {
+		err = sm.startSecretCoordination(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	sm.coordination.HandleLeading(sm.leading.isLeading)
+
+	go func() {
+		if err := sm.coordination.Serve(ctx); err != nil {
+			log.Errorf("(coordination) %v", err)
+		}
+	}()
+	// Look for other nodes that are still advertising the services that this node leads
+	if sm.clientSet != nil && sm.config.EnableServices && sm.config.EnableServicesElection {
+		go sm.probeSplitBrain(ctx)
+	}
+	return nil
+}
+
+// startSPIFFECoordination will set the SVIDs of the node on the channel whenever they're rotated
+func (sm *Manager) startSPIFFECoordination(ctx context.Context, template string) error {
+	sm.coordination = coordination.NewSPIFFEChannel(sm.config.NodeName, sm.config.Coordination.Port, template)
+
+	waitCtx, cancel := context.WithTimeout(ctx, spiffeTimeout)
+	defer cancel()
+	if _, err := sm.spiffe.WaitForSVID(waitCtx); err != nil {
+		return err
+	}
+	sm.spiffe.OnUpdate(func(svid *spiffe.SVID) {
+		if err := sm.coordination.SetSVID(svid); err != nil {
+			log.Errorf("(coordination) unable to use SVID: %v", err)
+			return
+		}
+		log.Infof("(coordination) SVID [%s] has been loaded", svid.ID)
+	})
+	return nil
+}
+
+// startSecretCoordination will issue or request the certificate of the node with the CA from the secret
+func (sm *Manager) startSecretCoordination(ctx context.Context) error {
+	name := sm.config.Coordination.Secret
+	sm.coordination = coordination.NewChannel(sm.config.NodeName, sm.config.Coordination.Port)
+
+	log.Infof("(coordination) reading the CA from Kubernetes secret [%s]", name)
+	s, err := sm.readSecret(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := sm.applyCoordinationSecret(ctx, s); err != nil {
+		return err
+	}
+	if err := sm.watchSecret(ctx, name, func(s *v1.Secret) error {
+		return sm.applyCoordinationSecret(ctx, s)
+	}); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(coordinationRenewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			s, err := sm.readSecret(ctx, name)
+			if err == nil {
+				err = sm.applyCoordinationSecret(ctx, s)
+			}
+			if err != nil {
+				log.Errorf("(coordination) unable to renew the node certificate: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// applyCoordinationSecret will get a new certificate for this node that is signed by the CA in the secret
+func (sm *Manager) applyCoordinationSecret(ctx context.Context, s *v1.Secret) error {
+	caPEM := s.Data[v1.ServiceAccountRootCAKey]
+	if len(caPEM) == 0 {
+		return fmt.Errorf("secret [%s] has no %s", s.Name, v1.ServiceAccountRootCAKey)
+	}
+
+	var certPEM, keyPEM []byte
+	var err error
+	if signer := sm.config.Coordination.SignerName; signer != "" {
+		log.Infof("(coordination) requesting a certificate for [%s] from [%s]", sm.config.NodeName, signer)
+		requestCtx, cancel := context.WithTimeout(ctx, coordinationRequestTimeout)
+		defer cancel()
+		certPEM, keyPEM, err = coordination.Request(requestCtx, sm.clientSet, signer, sm.config.NodeName)
+	} else {
+		certPEM, keyPEM, err = coordination.Issue(caPEM, s.Data[coordinationCAKey], sm.config.NodeName)
+	}
+	if err != nil {
+		return err
+	}
+	if err := sm.coordination.SetCredentials(certPEM, keyPEM, caPEM); err != nil {
+		return err
+	}
+	log.Infof("(coordination) certificate for [%s] has been loaded", sm.config.NodeName)
+	return nil
+}
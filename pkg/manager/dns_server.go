@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	"github.com/kube-vip/kube-vip/pkg/dnsserver"
+)
+
+// startDNSServer will answer the queries for the names of the services with their VIPs, the records are kept in line
+// with the services in every namespace (not only those advertised by this node)
+func (sm *Manager) startDNSServer(ctx context.Context) error {
+	server := dnsserver.New(sm.config.DNSServerZone, sm.config.DNSServerListenAddress())
+
+	services, err := sm.clientSet.CoreV1().Services(sm.config.ServiceNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list the services of the dns server: %v", err)
+	}
+	for x := range services.Items {
+		updateDNSRecord(server, &services.Items[x], false)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		<-sm.shutdownChan
+		cancel()
+	}()
+	go func() {
+		if err := server.Serve(ctx); err != nil {
+			log.Errorf("[dns] %v", err)
+		}
+	}()
+
+	rw, err := watchtools.NewRetryWatcher(services.ResourceVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return sm.clientSet.CoreV1().Services(sm.config.ServiceNamespace).Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		cancel()
+		return fmt.Errorf("error creating dns server services watcher: %v", err)
+	}
+	go func() {
+		<-ctx.Done()
+		rw.Stop()
+	}()
+	go func() {
+		for event := range rw.ResultChan() {
+			svc, ok := event.Object.(*v1.Service)
+			if !ok {
+				continue
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				updateDNSRecord(server, svc, false)
+			case watch.Deleted:
+				updateDNSRecord(server, svc, true)
+			}
+		}
+	}()
+	return nil
+}
+
+// updateDNSRecord replaces the addresses of the service with its VIPs, the record of a service that has been deleted
+// (or isn't a load balancer) is removed
+func updateDNSRecord(server *dnsserver.Server, svc *v1.Service, deleted bool) {
+	var addresses []net.IP
+	if !deleted && svc.Spec.Type == v1.ServiceTypeLoadBalancer {
+		for _, address := range fetchServiceAddresses(svc) {
+			if ip := net.ParseIP(address); ip != nil {
+				addresses = append(addresses, ip)
+			}
+		}
+	}
+	server.Set(server.Name(svc.Name, svc.Namespace), addresses)
+}
@@ -0,0 +1,64 @@
+package manager
+
+import (
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// egressPlacementAnnotation is the annotation of a service with the node that SNATs its egress, it overrides the
+// egress_placement of kube-vip
+const egressPlacementAnnotation = "kube-vip.io/egress-placement"
+
+// egressPlacement returns where the egress of the service is placed, the leader of the service unless it is configured
+// otherwise
+func (sm *Manager) egressPlacement(svc *v1.Service) string {
+	placement := sm.config.EgressPlacement
+	if v, exists := svc.Annotations[egressPlacementAnnotation]; exists {
+		if err := kubevip.ValidateEgressPlacement(v); err != nil {
+			log.Errorf("[egress] service [%s/%s]: %v", svc.Namespace, svc.Name, err)
+		} else {
+			placement = v
+		}
+	}
+	if placement == "" {
+		return kubevip.EgressPlacementLeader
+	}
+	return placement
+}
+
+// placePodEgress moves the egress of a service that is placed on the node of its pod to the local pod, the rules of
+// the previous pod are removed. It returns the pod that is now SNATed, nothing removes the rules.
+func (sm *Manager) placePodEgress(svc *v1.Service, current, podIP string) string {
+	if current == podIP {
+		return current
+	}
+	serviceIPs := fetchServiceAddresses(svc)
+	for _, serviceIP := range serviceIPs {
+		if current == "" || !serviceEgressSelected(serviceIPs, serviceIP, current) {
+			continue
+		}
+		if err := sm.TeardownEgress(current, serviceIP, svc.Annotations[egressDestinationPorts], svc.Annotations[egressSNATPorts], svc.Annotations[egressExcludeCidrs], svc.Namespace); err != nil {
+			log.Errorf("[egress] unable to remove egress of pod [%s] for service [%s/%s]: %v", current, svc.Namespace, svc.Name, err)
+		}
+	}
+	if podIP == "" {
+		return ""
+	}
+	if err := sm.iptablesCheck(); err != nil {
+		log.Errorf("[egress] unable to configure egress for service [%s/%s]: %v", svc.Namespace, svc.Name, err)
+		return ""
+	}
+	for _, serviceIP := range serviceIPs {
+		if !serviceEgressSelected(serviceIPs, serviceIP, podIP) {
+			continue
+		}
+		if err := sm.configureEgress(serviceIP, podIP, svc.Annotations[egressDestinationPorts], svc.Annotations[egressSNATPorts], svc.Annotations[egressExcludeCidrs], svc.Namespace); err != nil {
+			log.Errorf("[egress] unable to configure egress of pod [%s] for service [%s/%s]: %v", podIP, svc.Namespace, svc.Name, err)
+			return ""
+		}
+		log.Infof("[egress] service [%s/%s] egresses from [%s] on the node of its pod [%s]", svc.Namespace, svc.Name, serviceIP, podIP)
+	}
+	return podIP
+}
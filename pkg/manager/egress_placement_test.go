@@ -0,0 +1,30 @@
+package manager
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+func TestEgressPlacement(t *testing.T) {
+	sm := &Manager{config: &kubevip.Config{}}
+	svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+	if got := sm.egressPlacement(svc); got != kubevip.EgressPlacementLeader {
+		t.Errorf("egressPlacement() = %s, want the leader by default", got)
+	}
+	sm.config.EgressPlacement = kubevip.EgressPlacementPod
+	if got := sm.egressPlacement(svc); got != kubevip.EgressPlacementPod {
+		t.Errorf("egressPlacement() = %s, want the configured placement", got)
+	}
+	svc.Annotations[egressPlacementAnnotation] = kubevip.EgressPlacementLeader
+	if got := sm.egressPlacement(svc); got != kubevip.EgressPlacementLeader {
+		t.Errorf("egressPlacement() = %s, want the placement of the service", got)
+	}
+	svc.Annotations[egressPlacementAnnotation] = "node"
+	if got := sm.egressPlacement(svc); got != kubevip.EgressPlacementPod {
+		t.Errorf("egressPlacement() = %s, want an invalid placement to be ignored", got)
+	}
+}
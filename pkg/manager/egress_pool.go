@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+// egressVIPPolicy is the annotation of a service or namespace with the policy that selects the egress VIP of each pod,
+// when there is more than one VIP of the pod's family
+const egressVIPPolicy = "kube-vip.io/egress-vip-policy"
+
+const (
+	// egressPolicyHash selects the VIP from a hash of the pod address, a pod keeps its VIP for as long as the VIPs
+	// don't change
+	egressPolicyHash = "hash"
+	// egressPolicyRoundRobin gives the VIPs to the pods in turn, a pod keeps its VIP for as long as it is available
+	egressPolicyRoundRobin = "round-robin"
+)
+
+// egressPool selects the egress VIP of each pod from a pool of VIPs, spreading the pods across the VIPs
+type egressPool struct {
+	policy string
+	// The VIP given to each pod, and the turn of the next pod (round-robin)
+	assigned map[string]string
+	next     int
+}
+
+// newEgressPool creates a pool with the policy, which defaults to hash
+func newEgressPool(policy string) (*egressPool, error) {
+	switch policy {
+	case "":
+		policy = egressPolicyHash
+	case egressPolicyHash, egressPolicyRoundRobin:
+	default:
+		return nil, fmt.Errorf("egress VIP policy [%s] has to be %s or %s", policy, egressPolicyHash, egressPolicyRoundRobin)
+	}
+	return &egressPool{policy: policy, assigned: map[string]string{}}, nil
+}
+
+// selectVIP returns the VIP of the pod from the VIPs of its family, or nothing if there isn't one
+func (p *egressPool) selectVIP(podIP string, vips []string) string {
+	var candidates []string
+	for _, address := range vips {
+		if vip.IsIPv4(address) == vip.IsIPv4(podIP) {
+			candidates = append(candidates, address)
+		}
+	}
+	if len(candidates) == 0 {
+		delete(p.assigned, podIP)
+		return ""
+	}
+	sort.Strings(candidates)
+
+	if p.policy == egressPolicyRoundRobin {
+		for _, address := range candidates {
+			if p.assigned[podIP] == address {
+				return address
+			}
+		}
+		address := candidates[p.next%len(candidates)]
+		p.next++
+		p.assigned[podIP] = address
+		return address
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(podIP))
+	return candidates[h.Sum32()%uint32(len(candidates))]
+}
+
+// release forgets the VIP of a pod that has gone
+func (p *egressPool) release(podIP string) {
+	delete(p.assigned, podIP)
+}
+
+// serviceEgressSelected returns true if the address of the service is the VIP that the pod egresses from, a service with
+// several addresses of a family has its pod SNATed to one of them (from the hash of the pod address)
+func serviceEgressSelected(serviceIPs []string, serviceIP, podIP string) bool {
+	pool := &egressPool{policy: egressPolicyHash}
+	return pool.selectVIP(podIP, serviceIPs) == serviceIP
+}
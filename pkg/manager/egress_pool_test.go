@@ -0,0 +1,52 @@
+package manager
+
+import "testing"
+
+func TestEgressPool(t *testing.T) {
+	vips := []string{"192.168.0.12", "192.168.0.10", "192.168.0.11", "fd00::10"}
+
+	if _, err := newEgressPool("random"); err == nil {
+		t.Error("newEgressPool() = nil error, want an unknown policy to be rejected")
+	}
+
+	hash, _ := newEgressPool("")
+	first := hash.selectVIP("10.0.0.5", vips)
+	if first == "" || first == "fd00::10" {
+		t.Fatalf("selectVIP() = %q, want an IPv4 VIP", first)
+	}
+	// The VIP of a pod is the same whatever the order of the VIPs
+	if got := hash.selectVIP("10.0.0.5", []string{"192.168.0.10", "192.168.0.11", "fd00::10", "192.168.0.12"}); got != first {
+		t.Errorf("selectVIP() = %s, want %s", got, first)
+	}
+	if got := hash.selectVIP("fd00:10::5", vips); got != "fd00::10" {
+		t.Errorf("selectVIP() = %s, want the IPv6 VIP", got)
+	}
+	if got := hash.selectVIP("fd00:10::5", vips[:3]); got != "" {
+		t.Errorf("selectVIP() = %s, want no VIP without one of the family", got)
+	}
+
+	rr, _ := newEgressPool(egressPolicyRoundRobin)
+	seen := map[string]bool{}
+	for _, podIP := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		seen[rr.selectVIP(podIP, vips)] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("selectVIP() = %v, want the pods spread across the three IPv4 VIPs", seen)
+	}
+	// A pod keeps its VIP while it is available
+	kept := rr.selectVIP("10.0.0.2", vips)
+	if got := rr.selectVIP("10.0.0.2", vips); got != kept {
+		t.Errorf("selectVIP() = %s, want the pod to keep %s", got, kept)
+	}
+	rr.release("10.0.0.2")
+	if _, exists := rr.assigned["10.0.0.2"]; exists {
+		t.Error("release() kept the VIP of the pod")
+	}
+
+	if !serviceEgressSelected([]string{"192.168.0.10", "fd00::10"}, "fd00::10", "fd00:10::5") {
+		t.Error("serviceEgressSelected() = false, want the only VIP of the family")
+	}
+	if serviceEgressSelected([]string{"192.168.0.10", "fd00::10"}, "fd00::10", "10.0.0.5") {
+		t.Error("serviceEgressSelected() = true, want a VIP of another family to be skipped")
+	}
+}
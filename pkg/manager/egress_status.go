@@ -0,0 +1,148 @@
+package manager
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/iptables"
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+var (
+	egressMappingsDesc = prometheus.NewDesc("kube_vip_egress_mappings",
+		"Pods whose traffic is SNATed to the egress VIP by this node", []string{"vip"}, nil)
+	egressPacketsDesc = prometheus.NewDesc("kube_vip_egress_packets_total",
+		"Packets of the pods that were marked to leave from the egress VIP, read from the marking rules", []string{"vip"}, nil)
+	egressBytesDesc = prometheus.NewDesc("kube_vip_egress_bytes_total",
+		"Bytes of the pods that were marked to leave from the egress VIP, read from the marking rules", []string{"vip"}, nil)
+)
+
+// egressMapping is a pod whose traffic is SNATed to an egress VIP by this node
+type egressMapping struct {
+	Namespace        string    `json:"namespace"`
+	PodIP            string    `json:"podIP"`
+	VIP              string    `json:"vip"`
+	DestinationPorts string    `json:"destinationPorts,omitempty"`
+	SNATPorts        string    `json:"snatPorts,omitempty"`
+	Since            time.Time `json:"since"`
+	Packets          uint64    `json:"packets"`
+	Bytes            uint64    `json:"bytes"`
+}
+
+func egressMappingKey(podIP, vipIP string) string {
+	return podIP + "/" + vipIP
+}
+
+// addEgressMapping records the pod as SNATed to the VIP
+func (sm *Manager) addEgressMapping(mapping egressMapping) {
+	sm.egressMu.Lock()
+	defer sm.egressMu.Unlock()
+	if sm.egressMappings == nil {
+		sm.egressMappings = map[string]egressMapping{}
+	}
+	sm.egressMappings[egressMappingKey(mapping.PodIP, mapping.VIP)] = mapping
+}
+
+// removeEgressMapping forgets the pod, its rules are being removed
+func (sm *Manager) removeEgressMapping(podIP, vipIP string) {
+	sm.egressMu.Lock()
+	defer sm.egressMu.Unlock()
+	delete(sm.egressMappings, egressMappingKey(podIP, vipIP))
+}
+
+// countEgressRuleError counts the error of programming the egress rules, if there was one
+func (sm *Manager) countEgressRuleError(operation string, err *error) {
+	if *err != nil && sm.egressRuleErrors != nil {
+		sm.egressRuleErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// egressState returns the pods that are SNATed by this node sorted by their VIP, along with the counters of their
+// marking rules
+func (sm *Manager) egressState() []egressMapping {
+	sm.egressMu.Lock()
+	mappings := make([]egressMapping, 0, len(sm.egressMappings))
+	for _, mapping := range sm.egressMappings {
+		mappings = append(mappings, mapping)
+	}
+	sm.egressMu.Unlock()
+	sort.Slice(mappings, func(i, j int) bool {
+		if mappings[i].VIP != mappings[j].VIP {
+			return mappings[i].VIP < mappings[j].VIP
+		}
+		return mappings[i].PodIP < mappings[j].PodIP
+	})
+	if len(mappings) == 0 {
+		return mappings
+	}
+
+	counters := map[string]vip.EgressCounters{}
+	for _, protocol := range []iptables.Protocol{iptables.ProtocolIPv4, iptables.ProtocolIPv6} {
+		i, err := vip.NewEgressRules(sm.config.FirewallBackend, sm.config.EgressWithNftables, "", protocol)
+		if err != nil {
+			log.Debugf("[egress] unable to read the counters of the egress rules: %v", err)
+			continue
+		}
+		familyCounters, err := i.MarkingCounters(vip.MangleChainName)
+		if err != nil {
+			log.Debugf("[egress] unable to read the counters of the egress rules: %v", err)
+			continue
+		}
+		for podIP, counter := range familyCounters {
+			counters[podIP] = counter
+		}
+	}
+	for x := range mappings {
+		mappings[x].Packets = counters[mappings[x].PodIP].Packets
+		mappings[x].Bytes = counters[mappings[x].PodIP].Bytes
+	}
+	return mappings
+}
+
+// egressCollector exports the egress VIPs of this node, the counters are read from the rules when they're collected
+type egressCollector struct {
+	sm *Manager
+}
+
+// Describe implements prometheus.Collector
+func (c *egressCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- egressMappingsDesc
+	ch <- egressPacketsDesc
+	ch <- egressBytesDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *egressCollector) Collect(ch chan<- prometheus.Metric) {
+	type total struct{ mappings, packets, bytes uint64 }
+	totals := map[string]*total{}
+	for _, mapping := range c.sm.egressState() {
+		t, exists := totals[mapping.VIP]
+		if !exists {
+			t = &total{}
+			totals[mapping.VIP] = t
+		}
+		t.mappings++
+		t.packets += mapping.Packets
+		t.bytes += mapping.Bytes
+	}
+	for address, t := range totals {
+		ch <- prometheus.MustNewConstMetric(egressMappingsDesc, prometheus.GaugeValue, float64(t.mappings), address)
+		ch <- prometheus.MustNewConstMetric(egressPacketsDesc, prometheus.CounterValue, float64(t.packets), address)
+		ch <- prometheus.MustNewConstMetric(egressBytesDesc, prometheus.CounterValue, float64(t.bytes), address)
+	}
+}
+
+// EgressStatusHandler serves the pods that are SNATed to an egress VIP by this node, as JSON
+func (sm *Manager) EgressStatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(sm.egressState()); err != nil {
+			log.Errorf("[egress] unable to write the egress status: %v", err)
+		}
+	})
+}
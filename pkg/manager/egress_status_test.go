@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestEgressMappings(t *testing.T) {
+	sm := &Manager{egressRuleErrors: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "errors"}, []string{"operation"})}
+
+	sm.addEgressMapping(egressMapping{Namespace: "default", PodIP: "10.0.0.5", VIP: "192.168.0.10"})
+	sm.addEgressMapping(egressMapping{Namespace: "default", PodIP: "10.0.0.6", VIP: "192.168.0.10"})
+	sm.removeEgressMapping("10.0.0.5", "192.168.0.10")
+	if len(sm.egressMappings) != 1 {
+		t.Fatalf("egressMappings = %v, want only 10.0.0.6", sm.egressMappings)
+	}
+	if _, exists := sm.egressMappings[egressMappingKey("10.0.0.6", "192.168.0.10")]; !exists {
+		t.Errorf("egressMappings = %v, want 10.0.0.6", sm.egressMappings)
+	}
+
+	var err error
+	sm.countEgressRuleError("configure", &err)
+	err = fmt.Errorf("iptables failed")
+	sm.countEgressRuleError("teardown", &err)
+	if got := testutil.ToFloat64(sm.egressRuleErrors.WithLabelValues("configure")); got != 0 {
+		t.Errorf("configure errors = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(sm.egressRuleErrors.WithLabelValues("teardown")); got != 1 {
+		t.Errorf("teardown errors = %v, want 1", got)
+	}
+}
+
+func TestEgressStatusHandlerEmpty(t *testing.T) {
+	sm := &Manager{}
+	w := httptest.NewRecorder()
+	sm.EgressStatusHandler().ServeHTTP(w, httptest.NewRequest("GET", "/status/egress", nil))
+	if got := w.Body.String(); got != "[]\n" {
+		t.Errorf("EgressStatusHandler() = %q, want an empty list", got)
+	}
+}
@@ -0,0 +1,206 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Advertisement engines, these are also the values used for the ControlPlaneEngine and ServicesEngine
+const (
+	engineBGP       = "bgp"
+	engineARP       = "arp"
+	engineWireguard = "wireguard"
+	engineTable     = "table"
+)
+
+// enabledEngines returns the engines that have been enabled, in the order that they have always been selected
+func (sm *Manager) enabledEngines() []string {
+	var engines []string
+	if sm.config.EnableBGP {
+		engines = append(engines, engineBGP)
+	}
+	if sm.config.EnableARP {
+		engines = append(engines, engineARP)
+	}
+	if sm.config.EnableWireguard {
+		engines = append(engines, engineWireguard)
+	}
+	if sm.config.EnableRoutingTable {
+		engines = append(engines, engineTable)
+	}
+	return engines
+}
+
+// startEngine will start a single engine, this blocks until the engine exits
+func (sm *Manager) startEngine(engine string) error {
+	switch engine {
+	case engineBGP:
+		// If Annotations have been set then we will look them up
+		err := sm.parseAnnotations()
+		if err != nil {
+			return err
+		}
+
+		log.Infoln("Starting Kube-vip Manager with the BGP engine")
+		return sm.startBGP()
+	case engineARP:
+		log.Infoln("Starting Kube-vip Manager with the ARP engine")
+		return sm.startARP(sm.config.NodeName)
+	case engineWireguard:
+		log.Infoln("Starting Kube-vip Manager with the Wireguard engine")
+		return sm.startWireguard(sm.config.NodeName)
+	case engineTable:
+		log.Infoln("Starting Kube-vip Manager with the Routing Table engine")
+		return sm.startTableMode(sm.config.NodeName)
+	}
+	return fmt.Errorf("unknown engine [%s]", engine)
+}
+
+// engineManager creates a manager for a single engine, when several engines are running concurrently. The control
+// plane and services are only enabled if they have been assigned to this engine.
+func (sm *Manager) engineManager(engine string) *Manager {
+	config := *sm.config
+	config.EnableBGP = engine == engineBGP
+	config.EnableARP = engine == engineARP
+	config.EnableWireguard = engine == engineWireguard
+	config.EnableRoutingTable = engine == engineTable
+	config.EnableControlPlane = sm.config.EnableControlPlane && sm.config.ControlPlaneEngine == engine
+	config.EnableServices = sm.config.EnableServices && sm.config.ServicesEngine == engine
+	// VIP groups are started once by the parent manager
+	config.VIPGroups = nil
+
+	// A service can be assigned to any engine with its annotation or a service policy, so every engine watches
+	// services and only advertises those that are assigned to it
+	if sm.config.EnableServices {
+		config.EnableServices = true
+		if engine != sm.config.ServicesEngine {
+			config.ServicesLeaseName = fmt.Sprintf("%s-%s", sm.config.ServicesLeaseName, engine)
+		}
+	}
+
+	// The engines share the informers (so the services and endpoints are only listed and watched once) and the
+	// metrics, each of them keeps the state of the services that it advertises
+	m := &Manager{
+		clientSet:              sm.clientSet,
+		informers:              sm.informers,
+		configMap:              sm.configMap,
+		config:                 &config,
+		spiffe:                 sm.spiffe,
+		httpCertificates:       sm.httpCertificates,
+		coordination:           sm.coordination,
+		leading:                sm.leading,
+		ddnsPublisher:          sm.ddnsPublisher,
+		countServiceWatchEvent: sm.countServiceWatchEvent,
+		bgpSessionInfoGauge:    sm.bgpSessionInfoGauge,
+		etcdCertificateExpiry:  sm.etcdCertificateExpiry,
+		wireguardTunnelHealthy: sm.wireguardTunnelHealthy,
+		routeRepairs:           sm.routeRepairs,
+		egressRuleErrors:       sm.egressRuleErrors,
+		leaders:                sm.leaders,
+		upnpRenewalFailures:    sm.upnpRenewalFailures,
+		servicePolicies:        sm.servicePolicies,
+		recorder:               sm.recorder,
+		ipam:                   sm.ipam,
+		defaultServicesEngine:  sm.config.ServicesEngine,
+		signalChan:             make(chan os.Signal, 1),
+		shutdownChan:           make(chan struct{}),
+		egressMappings:         map[string]egressMapping{},
+		upnpMappings:           map[string][]*upnpMapping{},
+	}
+	// Each engine will shut down on its own signal channel
+	signal.Notify(m.signalChan, syscall.SIGINT, syscall.SIGTERM)
+	// The statuses are written for the services of each engine, from its own instances
+	if m.clientSet != nil {
+		m.startStatusUpdates()
+	}
+	return m
+}
+
+// assignEngines assigns the control plane and services to the engines when several have been enabled, any role that
+// isn't assigned is given to the engine that would have been selected on its own (the order of enabledEngines, BGP
+// first). It returns the engines that are started.
+func (sm *Manager) assignEngines(engines []string) ([]string, error) {
+	if sm.config.EnableControlPlane && sm.config.ControlPlaneEngine == "" {
+		log.Warnf("engines %v are enabled but the control plane engine isn't set, the control plane is advertised with [%s]", engines, engines[0])
+		sm.config.ControlPlaneEngine = engines[0]
+	}
+	if sm.config.EnableServices && sm.config.ServicesEngine == "" {
+		log.Warnf("engines %v are enabled but the services engine isn't set, services are advertised with [%s]", engines, engines[0])
+		sm.config.ServicesEngine = engines[0]
+	}
+
+	assigned := map[string]bool{}
+	if sm.config.EnableControlPlane {
+		assigned[sm.config.ControlPlaneEngine] = true
+	}
+	if sm.config.EnableServices {
+		assigned[sm.config.ServicesEngine] = true
+	}
+	enabled := map[string]bool{}
+	started := make([]string, 0, len(engines))
+	for _, engine := range engines {
+		enabled[engine] = true
+		switch {
+		case assigned[engine]:
+			started = append(started, engine)
+		case sm.config.EnableServices:
+			// Services can still be assigned to it with their annotation or a service policy
+			log.Warnf("engine [%s] isn't assigned to the control plane or services, it only advertises the services that are assigned to it", engine)
+			started = append(started, engine)
+		default:
+			log.Warnf("engine [%s] isn't assigned to the control plane or services, it won't be started", engine)
+		}
+	}
+	for engine := range assigned {
+		if !enabled[engine] {
+			return nil, fmt.Errorf("engine [%s] is assigned to the control plane or services but isn't enabled", engine)
+		}
+	}
+	return started, nil
+}
+
+// startEngines will run several engines concurrently, the control plane and services are each advertised by one of
+// the engines
+func (sm *Manager) startEngines(engines []string) error {
+	engines, err := sm.assignEngines(engines)
+	if err != nil {
+		return err
+	}
+
+	managers := make([]*Manager, 0, len(engines))
+	for _, engine := range engines {
+		managers = append(managers, sm.engineManager(engine))
+	}
+
+	errs := make(chan error, len(engines))
+	for x := range engines {
+		engine, m := engines[x], managers[x]
+		log.Infof("Starting engine [%s], Control Plane:[%t], Services:[%t]", engine, m.config.EnableControlPlane, m.config.EnableServices)
+		go func() {
+			err := m.startEngine(engine)
+			if err != nil {
+				err = fmt.Errorf("engine [%s]: %v", engine, err)
+			}
+			errs <- err
+		}()
+	}
+
+	// Wait for all of the engines to exit, if one exits with an error then stop the others
+	for range engines {
+		if engineErr := <-errs; engineErr != nil && err == nil {
+			err = engineErr
+			log.Error(err)
+			for _, m := range managers {
+				select {
+				case m.signalChan <- syscall.SIGINT:
+				default:
+				}
+			}
+		}
+	}
+	return err
+}
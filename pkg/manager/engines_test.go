@@ -0,0 +1,221 @@
+package manager
+
+import (
+	"slices"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/servicepolicy"
+)
+
+func TestIgnoreServiceEngine(t *testing.T) {
+	tests := []struct {
+		name          string
+		engine        string
+		defaultEngine string
+		annotation    string
+		want          bool
+	}{
+		{name: "single engine", engine: engineARP},
+		{name: "default engine", engine: engineBGP, defaultEngine: engineBGP},
+		{name: "another default engine", engine: engineARP, defaultEngine: engineBGP, want: true},
+		{name: "annotation", engine: engineARP, defaultEngine: engineBGP, annotation: engineARP},
+		{name: "another annotation", engine: engineBGP, defaultEngine: engineBGP, annotation: engineARP, want: true},
+		{name: "annotation without a default engine", engine: engineARP, annotation: engineBGP, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := &Manager{
+				config: &kubevip.Config{
+					EnableARP: tt.engine == engineARP,
+					EnableBGP: tt.engine == engineBGP,
+				},
+				defaultServicesEngine: tt.defaultEngine,
+			}
+			svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Annotations: map[string]string{}}}
+			if tt.annotation != "" {
+				svc.Annotations[serviceEngine] = tt.annotation
+			}
+			if got := sm.ignoreServiceEngine(svc); got != tt.want {
+				t.Errorf("ignoreServiceEngine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineManagerServices(t *testing.T) {
+	parent := &Manager{config: &kubevip.Config{
+		EnableARP:          true,
+		EnableBGP:          true,
+		EnableControlPlane: true,
+		EnableServices:     true,
+		ControlPlaneEngine: engineARP,
+		ServicesEngine:     engineBGP,
+		ServicesLeaseName:  "plndr-svcs-lock",
+	}}
+
+	// Each engine watches the services, as any of them can be assigned to it by its annotation, under a lease of its own
+	bgp := parent.engineManager(engineBGP)
+	arp := parent.engineManager(engineARP)
+	if !bgp.config.EnableServices || !arp.config.EnableServices {
+		t.Errorf("services enabled for bgp = %v and arp = %v, want both", bgp.config.EnableServices, arp.config.EnableServices)
+	}
+	if bgp.config.ServicesLeaseName != "plndr-svcs-lock" || arp.config.ServicesLeaseName != "plndr-svcs-lock-arp" {
+		t.Errorf("services leases = %q and %q, want the lease of the services engine and one of the arp engine", bgp.config.ServicesLeaseName, arp.config.ServicesLeaseName)
+	}
+	if bgp.defaultServicesEngine != engineBGP || arp.defaultServicesEngine != engineBGP {
+		t.Error("the engines don't default the services to the services engine")
+	}
+}
+
+func TestEnabledEngines(t *testing.T) {
+	tests := []struct {
+		name string
+		c    *kubevip.Config
+		want []string
+	}{
+		{name: "none", c: &kubevip.Config{}},
+		{name: "arp", c: &kubevip.Config{EnableARP: true}, want: []string{engineARP}},
+		{name: "all", c: &kubevip.Config{EnableARP: true, EnableBGP: true, EnableWireguard: true, EnableRoutingTable: true}, want: []string{engineBGP, engineARP, engineWireguard, engineTable}},
+		{name: "table and arp", c: &kubevip.Config{EnableRoutingTable: true, EnableARP: true}, want: []string{engineARP, engineTable}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := &Manager{config: tt.c}
+			if got := sm.enabledEngines(); !slices.Equal(got, tt.want) {
+				t.Errorf("enabledEngines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartEnginesAssignment(t *testing.T) {
+	tests := []struct {
+		name string
+		c    *kubevip.Config
+	}{
+		{
+			name: "control plane engine not enabled",
+			c:    &kubevip.Config{EnableARP: true, EnableBGP: true, EnableControlPlane: true, EnableServices: true, ControlPlaneEngine: engineWireguard, ServicesEngine: engineBGP},
+		},
+		{
+			name: "services engine not enabled",
+			c:    &kubevip.Config{EnableARP: true, EnableBGP: true, EnableControlPlane: true, EnableServices: true, ControlPlaneEngine: engineARP, ServicesEngine: engineTable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := &Manager{config: tt.c}
+			if err := sm.startEngines(sm.enabledEngines()); err == nil {
+				t.Error("startEngines() error = nil, want the engines to be rejected before they are started")
+			}
+		})
+	}
+}
+
+func TestAssignEngines(t *testing.T) {
+	tests := []struct {
+		name             string
+		c                *kubevip.Config
+		wantControlPlane string
+		wantServices     string
+		wantStarted      []string
+	}{
+		{
+			name:             "no engines assigned",
+			c:                &kubevip.Config{EnableARP: true, EnableBGP: true, EnableControlPlane: true, EnableServices: true},
+			wantControlPlane: engineBGP,
+			wantServices:     engineBGP,
+			wantStarted:      []string{engineBGP, engineARP},
+		},
+		{
+			name:             "services engine not assigned",
+			c:                &kubevip.Config{EnableARP: true, EnableBGP: true, EnableControlPlane: true, EnableServices: true, ControlPlaneEngine: engineARP},
+			wantControlPlane: engineARP,
+			wantServices:     engineBGP,
+			wantStarted:      []string{engineBGP, engineARP},
+		},
+		{
+			name:             "engine not assigned without services",
+			c:                &kubevip.Config{EnableARP: true, EnableBGP: true, EnableControlPlane: true, ControlPlaneEngine: engineARP},
+			wantControlPlane: engineARP,
+			wantStarted:      []string{engineARP},
+		},
+		{
+			name:             "both engines assigned",
+			c:                &kubevip.Config{EnableARP: true, EnableBGP: true, EnableControlPlane: true, EnableServices: true, ControlPlaneEngine: engineARP, ServicesEngine: engineBGP},
+			wantControlPlane: engineARP,
+			wantServices:     engineBGP,
+			wantStarted:      []string{engineBGP, engineARP},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := &Manager{config: tt.c}
+			started, err := sm.assignEngines(sm.enabledEngines())
+			if err != nil {
+				t.Fatalf("assignEngines() error = %v", err)
+			}
+			if !slices.Equal(started, tt.wantStarted) {
+				t.Errorf("assignEngines() = %v, want %v", started, tt.wantStarted)
+			}
+			if sm.config.ControlPlaneEngine != tt.wantControlPlane || sm.config.ServicesEngine != tt.wantServices {
+				t.Errorf("control plane engine = [%s] and services engine = [%s], want [%s] and [%s]", sm.config.ControlPlaneEngine, sm.config.ServicesEngine, tt.wantControlPlane, tt.wantServices)
+			}
+		})
+	}
+}
+
+func TestEngineManager(t *testing.T) {
+	tests := []struct {
+		name             string
+		engine           string
+		wantControlPlane bool
+		wantServices     bool
+	}{
+		{name: "control plane engine", engine: engineARP, wantControlPlane: true, wantServices: true},
+		{name: "services engine", engine: engineBGP, wantServices: true},
+		{name: "unassigned engine", engine: engineWireguard, wantServices: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := &Manager{
+				config: &kubevip.Config{
+					EnableARP:          true,
+					EnableBGP:          true,
+					EnableWireguard:    true,
+					EnableControlPlane: true,
+					EnableServices:     true,
+					ControlPlaneEngine: engineARP,
+					ServicesEngine:     engineBGP,
+					VIPGroups:          []kubevip.VIPGroup{{Name: "web"}},
+				},
+				servicePolicies: servicepolicy.NewStore(),
+			}
+
+			m := parent.engineManager(tt.engine)
+			engines := m.enabledEngines()
+			if len(engines) != 1 || engines[0] != tt.engine {
+				t.Errorf("enabledEngines() = %v, want only [%s]", engines, tt.engine)
+			}
+			if m.config.EnableControlPlane != tt.wantControlPlane || m.config.EnableServices != tt.wantServices {
+				t.Errorf("control plane = %v and services = %v, want %v and %v", m.config.EnableControlPlane, m.config.EnableServices, tt.wantControlPlane, tt.wantServices)
+			}
+			if m.config.VIPGroups != nil {
+				t.Error("the VIP groups are started by each engine, want them only started by the parent")
+			}
+			if m.servicePolicies != parent.servicePolicies {
+				t.Error("the service policies aren't shared with the engine")
+			}
+			if m.config == parent.config || !parent.config.EnableARP || !parent.config.EnableBGP {
+				t.Error("the configuration of the parent was changed by the engine")
+			}
+			if m.signalChan == nil || m.shutdownChan == nil || m.egressMappings == nil || m.upnpMappings == nil {
+				t.Error("the engine is missing its own channels and mappings")
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package manager
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// externalDNSHostname is the annotation that external-dns reads the hostnames of a service from
+const externalDNSHostname = "external-dns.alpha.kubernetes.io/hostname"
+
+// publishHostnameAnnotation sets the hostname of the service from the template of kube-vip, for external-dns to create
+// its records. A hostname that is already set on the service is left as it is. It returns true if the service changed.
+func (sm *Manager) publishHostnameAnnotation(svc *v1.Service) bool {
+	if sm.config.ExternalDNSTarget == kubevip.ExternalDNSTargetStatus {
+		return false
+	}
+	hostname := sm.config.ExternalDNSHostname(svc.Name, svc.Namespace)
+	if hostname == "" || svc.Annotations[externalDNSHostname] != "" {
+		return false
+	}
+	if svc.Annotations == nil {
+		svc.Annotations = make(map[string]string)
+	}
+	svc.Annotations[externalDNSHostname] = hostname
+	return true
+}
+
+// publishHostnameStatus returns the hostname of the service from the template of kube-vip for the load balancer status,
+// or nothing if it is published in the annotation
+func (sm *Manager) publishHostnameStatus(svc *v1.Service) string {
+	if sm.config.ExternalDNSTarget != kubevip.ExternalDNSTargetStatus {
+		return ""
+	}
+	return sm.config.ExternalDNSHostname(svc.Name, svc.Namespace)
+}
@@ -0,0 +1,128 @@
+package manager
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// informerWatchBuffer is the number of events that are buffered for a consumer before the informer waits for it
+const informerWatchBuffer = 16
+
+// informerWatch is a watch.Interface over the events of a shared informer for the objects that match, so that the
+// existing watch loops can consume it while the list and watch of the API server is shared (and resumed after a
+// disconnect) by the informer
+type informerWatch struct {
+	informer     cache.SharedIndexInformer
+	registration cache.ResourceEventHandlerRegistration
+
+	// result is closed by Stop, the mutex ensures that nothing is sent after that
+	result chan watch.Event
+	mutex  sync.RWMutex
+	done   chan struct{}
+	once   sync.Once
+}
+
+// newInformerWatch registers a handler on the informer that forwards the events of the matching objects, the existing
+// objects are sent as added first
+func newInformerWatch(informer cache.SharedIndexInformer, match func(metav1.Object) bool) (*informerWatch, error) {
+	w := &informerWatch{
+		informer: informer,
+		result:   make(chan watch.Event, informerWatchBuffer),
+		done:     make(chan struct{}),
+	}
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.send(watch.Added, obj, match)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			w.send(watch.Modified, obj, match)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			w.send(watch.Deleted, obj, match)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.registration = registration
+	return w, nil
+}
+
+func (w *informerWatch) send(eventType watch.EventType, obj interface{}, match func(metav1.Object) bool) {
+	object, ok := obj.(runtime.Object)
+	if !ok {
+		return
+	}
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		log.Debugf("[informer] ignoring an object without metadata: %v", err)
+		return
+	}
+	if !match(accessor) {
+		return
+	}
+
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	select {
+	case <-w.done:
+	case w.result <- watch.Event{Type: eventType, Object: object}:
+	}
+}
+
+// Stop removes the handler from the informer and closes the result channel
+func (w *informerWatch) Stop() {
+	w.once.Do(func() {
+		close(w.done)
+		if err := w.informer.RemoveEventHandler(w.registration); err != nil {
+			log.Warnf("[informer] unable to remove the event handler: %v", err)
+		}
+		w.mutex.Lock()
+		close(w.result)
+		w.mutex.Unlock()
+	})
+}
+
+// ResultChan returns the events of the matching objects
+func (w *informerWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// newInformers creates the shared factory of the informers of the services and their endpoints, in the namespace of
+// the services and filtered by the API server with the selector of the services if one is configured
+func newInformers(client kubernetes.Interface, c *kubevip.Config) (informers.SharedInformerFactory, error) {
+	options := []informers.SharedInformerOption{informers.WithNamespace(c.ServiceNamespace)}
+	if c.ServicesSelector != "" {
+		selector, err := labels.Parse(c.ServicesSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid services selector [%s]: %v", c.ServicesSelector, err)
+		}
+		log.Infof("(svcs) only watching the services that match [%s]", selector)
+		// The endpoints and endpointslices have the labels of their service, so they're filtered the same way
+		options = append(options, informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = selector.String()
+		}))
+	}
+	return informers.NewSharedInformerFactoryWithOptions(client, 0, options...), nil
+}
+
+// startInformers starts any informer that has been requested from the shared factory since it was last started, the
+// informers are stopped when kube-vip shuts down
+func (sm *Manager) startInformers() {
+	sm.informers.Start(sm.shutdownChan)
+}
@@ -0,0 +1,162 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+func nextEvent(t *testing.T, ch <-chan watch.Event) watch.Event {
+	t.Helper()
+	select {
+	case event := <-ch:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return watch.Event{}
+}
+
+func TestInformerWatch(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}},
+		&v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"}},
+	)
+	factory := informers.NewSharedInformerFactory(client, 0)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	w, err := newInformerWatch(factory.Core().V1().Endpoints().Informer(), func(o metav1.Object) bool {
+		return o.GetName() == "web"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	factory.Start(stop)
+
+	if event := nextEvent(t, w.ResultChan()); event.Type != watch.Added || event.Object.(*v1.Endpoints).Name != "web" {
+		t.Errorf("expected web to be added, got %s %s", event.Type, event.Object.(*v1.Endpoints).Name)
+	}
+
+	ctx := context.Background()
+	if err := client.CoreV1().Endpoints("default").Delete(ctx, "db", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CoreV1().Endpoints("default").Delete(ctx, "web", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(t, w.ResultChan()); event.Type != watch.Deleted || event.Object.(*v1.Endpoints).Name != "web" {
+		t.Errorf("expected web to be deleted, got %s %s", event.Type, event.Object.(*v1.Endpoints).Name)
+	}
+
+	w.Stop()
+	if _, open := <-w.ResultChan(); open {
+		t.Error("the result channel wasn't closed by Stop")
+	}
+	// Stopping twice is harmless, as the consumers and the shutdown can both stop the watch
+	w.Stop()
+}
+
+func TestServicesWatcher(t *testing.T) {
+	loadBalancer := func(name, uid string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(uid)},
+			Spec:       v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+			Status:     v1.ServiceStatus{LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "192.168.0.10"}}}},
+		}
+	}
+	clusterIP := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "internal", Namespace: "default", UID: "internal"}}
+	client := fake.NewSimpleClientset(loadBalancer("web", "web"), loadBalancer("api", "api"), clusterIP)
+
+	sm := &Manager{
+		config:                 &kubevip.Config{ServicesWorkers: 2},
+		informers:              informers.NewSharedInformerFactory(client, 0),
+		shutdownChan:           make(chan struct{}),
+		countServiceWatchEvent: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "events"}, []string{"type"}),
+	}
+
+	var mutex sync.Mutex
+	synced := map[string]int{}
+	var calls atomic.Int32
+	serviceFunc := func(_ context.Context, svc *v1.Service, wg *sync.WaitGroup) error {
+		defer wg.Done()
+		mutex.Lock()
+		defer mutex.Unlock()
+		synced[svc.Name]++
+		calls.Add(1)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- sm.servicesWatcher(ctx, serviceFunc) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// An active service isn't synchronised again when it is modified
+	web := loadBalancer("web", "web")
+	web.Labels = map[string]string{"tier": "frontend"}
+	if _, err := client.CoreV1().Services("default").Update(ctx, web, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("servicesWatcher() error = %v", err)
+	}
+	close(sm.shutdownChan)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if synced["web"] != 1 || synced["api"] != 1 || synced["internal"] != 0 {
+		t.Errorf("unexpected services synchronised %v", synced)
+	}
+	for _, uid := range []string{"web", "api"} {
+		setServiceActive(uid, false)
+	}
+}
+
+func TestNewInformersSelector(t *testing.T) {
+	service := func(name string, labels map[string]string) *v1.Service {
+		return &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}}
+	}
+	client := fake.NewSimpleClientset(
+		service("web", map[string]string{"kube-vip.io/shard": "a"}),
+		service("api", map[string]string{"kube-vip.io/shard": "b"}),
+		service("db", nil),
+	)
+
+	if _, err := newInformers(client, &kubevip.Config{ServicesSelector: "kube-vip.io/shard in (a"}); err == nil {
+		t.Error("an invalid selector was accepted")
+	}
+
+	factory, err := newInformers(client, &kubevip.Config{ServicesSelector: "kube-vip.io/shard=a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	informer := factory.Core().V1().Services().Informer()
+	stop := make(chan struct{})
+	defer close(stop)
+	factory.Start(stop)
+	factory.WaitForCacheSync(stop)
+
+	if keys := informer.GetStore().ListKeys(); len(keys) != 1 || keys[0] != "default/web" {
+		t.Errorf("the informer has the services %v, want only default/web", keys)
+	}
+}
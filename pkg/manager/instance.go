@@ -3,6 +3,8 @@ package manager
 import (
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/kube-vip/kube-vip/pkg/cluster"
 	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/servicepolicy"
 	"github.com/kube-vip/kube-vip/pkg/vip"
 )
 
@@ -38,7 +41,7 @@ type Instance struct {
 	serviceSnapshot *v1.Service
 }
 
-func NewInstance(svc *v1.Service, config *kubevip.Config) (*Instance, error) {
+func NewInstance(svc *v1.Service, config *kubevip.Config, overrides servicepolicy.Overrides) (*Instance, error) {
 	instanceAddresses := fetchServiceAddresses(svc)
 	instanceUID := string(svc.UID)
 
@@ -46,6 +49,11 @@ func NewInstance(svc *v1.Service, config *kubevip.Config) (*Instance, error) {
 	var svcInterface string
 	svcInterface = svc.Annotations[serviceInterface] // If the service has a specific interface defined, then use it
 
+	// Then any interface from a service policy
+	if svcInterface == "" {
+		svcInterface = overrides.Interface
+	}
+
 	// If it is still blank then use the
 	if svcInterface == "" {
 		if config.ServicesInterface != "" {
@@ -54,6 +62,52 @@ func NewInstance(svc *v1.Service, config *kubevip.Config) (*Instance, error) {
 			svcInterface = config.Interface
 		}
 	}
+	// In routing table mode the routes can be added to the table of a VRF, with the VIP bound inside it
+	routingTableID := config.RoutingTableID
+	nextHops := config.RoutingNextHops
+	ruleTable := 0
+	if config.EnableRoutingTable {
+		// The table of the service, or of its namespace, is used instead of the global table (or VRF)
+		ownTable := false
+		if table, exists := svc.Annotations[serviceRoutingTable]; exists {
+			var err error
+			if routingTableID, err = strconv.Atoi(table); err != nil || routingTableID <= 0 {
+				return nil, fmt.Errorf("service %s/%s has an invalid routing table [%s]", svc.Namespace, svc.Name, table)
+			}
+			ownTable = true
+		} else if namespaces, _ := kubevip.ParseNamespaceTables(config.RoutingTableNamespaces); namespaces[svc.Namespace] != 0 {
+			routingTableID = namespaces[svc.Namespace]
+			ownTable = true
+		}
+		if table, exists := svc.Annotations[serviceRuleTable]; exists {
+			var err error
+			if ruleTable, err = strconv.Atoi(table); err != nil || ruleTable <= 0 {
+				return nil, fmt.Errorf("service %s/%s has an invalid rule table [%s]", svc.Namespace, svc.Name, table)
+			}
+		}
+		if hops, exists := svc.Annotations[serviceNextHops]; exists {
+			nextHops = strings.Split(hops, ",")
+		}
+		vrf := svc.Annotations[serviceVRF]
+		if vrf == "" {
+			vrf = overrides.VRF
+		}
+		if vrf == "" && !ownTable {
+			vrf = config.RoutingTableVRF
+		}
+		if vrf != "" {
+			var err error
+			if routingTableID, svcInterface, err = vip.VRF(vrf, svcInterface); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	arp, err := serviceARPConfig(svc, config, overrides)
+	if err != nil {
+		return nil, err
+	}
+
 	var newVips []*kubevip.Config
 
 	for _, address := range instanceAddresses {
@@ -67,10 +121,19 @@ func NewInstance(svc *v1.Service, config *kubevip.Config) (*Instance, error) {
 			VIPCIDR:                config.VIPCIDR,
 			VIPSubnet:              config.VIPSubnet,
 			EnableRoutingTable:     config.EnableRoutingTable,
-			RoutingTableID:         config.RoutingTableID,
+			RoutingTableID:         routingTableID,
 			RoutingTableType:       config.RoutingTableType,
 			RoutingProtocol:        config.RoutingProtocol,
-			ArpBroadcastRate:       config.ArpBroadcastRate,
+			RoutingMetric:          config.RoutingMetric,
+			RoutingNextHops:        nextHops,
+			RoutingScope:           config.RoutingScope,
+			RoutingOnLink:          config.RoutingOnLink,
+			RoutingSource:          config.RoutingSource,
+			RoutingRuleTable:       ruleTable,
+			ArpBroadcastRate:       arp.ArpBroadcastRate,
+			ArpRepeat:              arp.ArpRepeat,
+			ArpRepeatInterval:      arp.ArpRepeatInterval,
+			ArpBackoff:             arp.ArpBackoff,
 			EnableServiceSecurity:  config.EnableServiceSecurity,
 			DNSMode:                config.DNSMode,
 			DisableServiceUpdates:  config.DisableServiceUpdates,
@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
@@ -9,16 +10,26 @@ import (
 	"sync"
 	"syscall"
 
-	"github.com/kamhlos/upnp"
 	"github.com/kube-vip/kube-vip/pkg/bgp"
+	"github.com/kube-vip/kube-vip/pkg/coordination"
+	"github.com/kube-vip/kube-vip/pkg/ddns"
+	"github.com/kube-vip/kube-vip/pkg/httptls"
+	"github.com/kube-vip/kube-vip/pkg/ipam"
 	"github.com/kube-vip/kube-vip/pkg/k8s"
 	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/portmap"
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
+	"github.com/kube-vip/kube-vip/pkg/servicepolicy"
+	"github.com/kube-vip/kube-vip/pkg/spiffe"
 	"github.com/kube-vip/kube-vip/pkg/trafficmirror"
 	"github.com/kube-vip/kube-vip/pkg/utils"
+	"github.com/kube-vip/kube-vip/pkg/wireguard"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
-	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 )
 
 const plunderLock = "plndr-svcs-lock"
@@ -26,17 +37,36 @@ const plunderLock = "plndr-svcs-lock"
 // Manager degines the manager of the load-balancing services
 type Manager struct {
 	clientSet *kubernetes.Clientset
+
+	// The informers that the services and their endpoints are watched with, they share one list and watch of each
+	// resource that resumes after a disconnect from the API server
+	informers informers.SharedInformerFactory
+	// The services whose status is to be written, the updates that are requested for the same service are coalesced
+	statusUpdates workqueue.RateLimitingInterface
+
 	configMap string
 	config    *kubevip.Config
 
 	// Manager services
 	// service bool
 
-	// Keeps track of all running instances
-	serviceInstances []*Instance
+	// Keeps track of all running instances, by the UID of their service
+	serviceInstances serviceIndex
+	instancesMutex   sync.RWMutex
+	// The reconciles of the services that share a VIP are serialised, the others run at the same time
+	serviceLocks keyedMutex
+	// The elections of the shards of services, by shard, when the services are elected in shards
+	serviceShards map[int]*serviceShard
+	shardsMutex   sync.Mutex
 
 	// Additional functionality
-	upnp *upnp.Upnp
+	// The client of the gateway, with whichever of UPnP, NAT-PMP or PCP the gateway speaks
+	portMapper portmap.Client
+	// The ports that the gateway forwards to the VIPs of each service, they are renewed before their leases expire
+	upnpMappings map[string][]*upnpMapping
+	upnpMutex    sync.Mutex
+	// The mappings that the gateway failed to renew
+	upnpRenewalFailures prometheus.Counter
 
 	// BGP Manager, this is a singleton that manages all BGP advertisements
 	bgpServer *bgp.Server
@@ -55,6 +85,57 @@ type Manager struct {
 	// 1 means "ESTABLISHED", 0 means "NOT ESTABLISHED"
 	bgpSessionInfoGauge *prometheus.GaugeVec
 
+	// This is a prometheus gauge with the expiry of the etcd client certificate, as a unix timestamp
+	etcdCertificateExpiry prometheus.Gauge
+
+	// This is a prometheus gauge indicating the health of the wireguard tunnel, from the age of the last handshake and
+	// the probes through it
+	wireguardTunnelHealthy prometheus.Gauge
+
+	// This is a prometheus counter of the routes that were re-installed after another process deleted or replaced them
+	routeRepairs prometheus.Counter
+
+	// This is a prometheus counter of the errors programming the egress rules, by the operation (configure or teardown)
+	egressRuleErrors *prometheus.CounterVec
+
+	// These are the prometheus metrics of the leaders of the leases, and of their changes
+	leaders *leaderMetrics
+
+	// The recorder of the events of the services, nil unless the events are enabled
+	recorder record.EventRecorder
+
+	// The pods that are SNATed to an egress VIP by this node, keyed by the pod and the VIP
+	egressMappings map[string]egressMapping
+	egressMu       sync.Mutex
+
+	// Policies that override the settings of the services that they select
+	servicePolicies *servicepolicy.Store
+
+	// The allocation of the addresses of services from the ip pools, nil unless ipam is enabled
+	ipam *ipam.Controller
+
+	// The engine that advertises services without an engine policy, when several engines are running
+	defaultServicesEngine string
+
+	// Certificates for the local HTTP endpoints, these are loaded from a secret if one is configured
+	httpCertificates *httptls.Certificates
+
+	// The mTLS channel to the other kube-vip nodes, for features that need to talk to them
+	coordination *coordination.Channel
+	// The leases that this node is leading, which the other nodes can ask for over the coordination channel
+	leading *leadingLeases
+	// The VIPs of the services that the previous kube-vip on the node handed over
+	handedOver servicesHandover
+
+	// The SVIDs of this node from the SPIFFE Workload API, if one is configured
+	spiffe *spiffe.Source
+
+	// The wireguard tunnel, it is reconfigured when the keys in its secret are rotated
+	wireguard *wireguard.Tunnel
+
+	// Publishes the records of the DHCP services with a hostname, if the DDNS provider isn't the DHCP server
+	ddnsPublisher *ddns.Publisher
+
 	// This mutex is to protect calls from various goroutines
 	mutex sync.Mutex
 }
@@ -80,12 +161,42 @@ func New(configMap string, config *kubevip.Config) (*Manager, error) {
 	var clientset *kubernetes.Clientset
 	var err error
 
+	// The SVID has to be ready before the Kubernetes client, if it is used to authenticate
+	var spiffeSource *spiffe.Source
+	if config.SPIFFE.Socket != "" {
+		if spiffeSource, err = startSPIFFE(config); err != nil {
+			return nil, err
+		}
+	}
+
 	adminConfigPath := "/etc/kubernetes/admin.conf"
 	homeConfigPath := filepath.Join(os.Getenv("HOME"), ".kube", "config")
 
 	switch {
-	case config.LeaderElectionType == "etcd":
-		// Do nothing, we don't construct a k8s client for etcd leader election
+	case config.Standalone:
+		// Do nothing, in standalone mode there is no Kubernetes cluster to talk to
+		log.Info("Running in standalone mode, no Kubernetes client will be created")
+	case config.LeaderElectionType == "etcd" && config.Etcd.ClientSecret == "":
+		// Do nothing, we don't construct a k8s client for etcd leader election (unless the certificates are in a secret)
+	case config.Kubeconfig != "":
+		clientset, err = k8s.NewClientsetFromKubeconfig([]byte(config.Kubeconfig), config.KubernetesAddr)
+		if err != nil {
+			return nil, fmt.Errorf("could not create k8s clientset from the kubeconfig: %v", err)
+		}
+		log.Debug("Using the Kubernetes configuration from the environment")
+	case config.APIServer != "":
+		clientset, err = k8s.NewClientsetFromToken(config.APIServer, config.APIToken, []byte(config.APICA))
+		if err != nil {
+			return nil, fmt.Errorf("could not create k8s clientset for %s: %v", config.APIServer, err)
+		}
+		log.Debugf("Using the token for the API server [%s]", config.APIServer)
+	case config.ImmutableOS:
+		// The host has no kubeconfig to read, so the service account of the pod is used
+		clientset, err = k8s.NewClientset("", true, "")
+		if err != nil {
+			return nil, fmt.Errorf("could not create k8s clientset from incluster config: %v", err)
+		}
+		log.Debug("Using the incluster config, no kubeconfig is read from the host")
 	case utils.FileExists(adminConfigPath):
 		if config.KubernetesAddr != "" {
 			fmt.Println(config.KubernetesAddr)
@@ -142,6 +253,8 @@ func New(configMap string, config *kubevip.Config) (*Manager, error) {
 		clientSet: clientset,
 		configMap: configMap,
 		config:    config,
+		spiffe:    spiffeSource,
+		leading:   newLeadingLeases(),
 		countServiceWatchEvent: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: "kube_vip",
 			Subsystem: "manager",
@@ -154,6 +267,39 @@ func New(configMap string, config *kubevip.Config) (*Manager, error) {
 			Name:      "bgp_session_info",
 			Help:      "Display state of session by setting metric for label value with current state to 1",
 		}, []string{"state", "peer"}),
+		etcdCertificateExpiry: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "kube_vip",
+			Subsystem: "etcd",
+			Name:      "client_certificate_expiration_timestamp_seconds",
+			Help:      "Expiry of the etcd client certificate used for leader election, as a unix timestamp",
+		}),
+		wireguardTunnelHealthy: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "kube_vip",
+			Subsystem: "wireguard",
+			Name:      "tunnel_healthy",
+			Help:      "1 if the last handshake with the wireguard peer is recent enough and the probes get through the tunnel, the node doesn't lead otherwise",
+		}),
+		routeRepairs: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "kube_vip",
+			Subsystem: "routing_table",
+			Name:      "route_repairs_total",
+			Help:      "Count the routes that were re-installed after another process deleted or replaced them",
+		}),
+		egressRuleErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "kube_vip",
+			Subsystem: "egress",
+			Name:      "rule_errors_total",
+			Help:      "Count the errors programming the egress rules, by the operation",
+		}, []string{"operation"}),
+		leaders: newLeaderMetrics(),
+		upnpRenewalFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "kube_vip",
+			Subsystem: "upnp",
+			Name:      "renewal_failures_total",
+			Help:      "Count the port mappings that the gateway failed to renew",
+		}),
+		egressMappings: map[string]egressMapping{},
+		upnpMappings:   map[string][]*upnpMapping{},
 	}, nil
 }
 
@@ -172,33 +318,123 @@ func (sm *Manager) Start() error {
 	// All watchers and other goroutines should have an additional goroutine that blocks on this, to shut things down
 	sm.shutdownChan = make(chan struct{})
 
-	// If BGP is enabled then we start a server instance that will broadcast VIPs
-	if sm.config.EnableBGP {
-
-		// If Annotations have been set then we will look them up
-		err := sm.parseAnnotations()
+	if sm.clientSet != nil {
+		factory, err := newInformers(sm.clientSet, sm.config)
 		if err != nil {
 			return err
 		}
+		sm.informers = factory
+		sm.startStatusUpdates()
+		if sm.config.EnableServiceEvents {
+			sm.startServiceEvents()
+		}
+	}
 
-		log.Infoln("Starting Kube-vip Manager with the BGP engine")
-		return sm.startBGP()
+	// Security events are always logged, they're also sent to the webhook if one has been configured
+	if sm.config.SecurityWebhook != "" {
+		log.Info("security events will be sent to the webhook")
+		securityevents.Configure(sm.config.SecurityWebhook, sm.config.NodeName)
 	}
+	// The leaders of the leases that this node takes part in are reported in the metrics
+	securityevents.ObserveLeaders(sm.leaders)
 
-	// If ARP is enabled then we start a LeaderElection that will use ARP to advertise VIPs
-	if sm.config.EnableARP {
-		log.Infoln("Starting Kube-vip Manager with the ARP engine")
-		return sm.startARP(sm.config.NodeName)
+	// Make sure that kube-vip can do everything that the configuration needs before starting anything
+	if sm.clientSet != nil {
+		if err := sm.checkPermissions(context.Background()); err != nil {
+			return err
+		}
 	}
 
-	if sm.config.EnableWireguard {
-		log.Infoln("Starting Kube-vip Manager with the Wireguard engine")
-		return sm.startWireguard(sm.config.NodeName)
+	// Load the HTTP endpoint certificates from a secret, these are reloaded whenever the secret is rotated
+	if sm.httpCertificates != nil && sm.config.HTTPTLS.Secret != "" {
+		if err := sm.watchHTTPTLSSecret(context.Background()); err != nil {
+			return err
+		}
 	}
 
-	if sm.config.EnableRoutingTable {
-		log.Infoln("Starting Kube-vip Manager with the Routing Table engine")
-		return sm.startTableMode(sm.config.NodeName)
+	// Start the channel to the other nodes before anything that might use it
+	if sm.config.Coordination.Port != 0 && (sm.clientSet != nil || sm.config.SPIFFE.NodeIDTemplate != "") {
+		if err := sm.startCoordination(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	// Start any additional VIP groups, these are independent of the engine below
+	if err := sm.startVIPGroups(); err != nil {
+		return err
+	}
+
+	// Load any service policies before the services are watched
+	if err := sm.startServicePolicies(); err != nil {
+		return err
+	}
+
+	// Allocate the addresses of the services without one from the ip pools
+	sm.startIPAM()
+
+	// Allow the VIP and the pools on the port of this node, Neutron drops their traffic otherwise
+	if sm.config.EnableOpenStack {
+		if err := sm.startOpenStack(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	// Blackhole the addresses of the pools that haven't been allocated to a service
+	if len(sm.config.BlackholePools) != 0 && sm.clientSet != nil {
+		if err := sm.startBlackholes(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	// Publish the records of the DHCP services through the DDNS provider
+	if sm.clientSet != nil {
+		if err := sm.startDDNS(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	// Answer the queries for the names of the services with their VIPs
+	if sm.config.DNSServerZone != "" && sm.clientSet != nil {
+		if err := sm.startDNSServer(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	// Keep the records of the control plane listing its healthy nodes
+	if sm.config.ControlPlaneDNS != "" && sm.clientSet != nil {
+		if err := sm.startControlPlaneDNS(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	// SNAT the pods of the namespaces with an egress VIP
+	if sm.config.EgressNamespaces && sm.clientSet != nil {
+		if err := sm.startNamespaceEgress(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	// Start the enabled engine, if more than one has been enabled they are run concurrently
+	engines := sm.enabledEngines()
+	switch len(engines) {
+	case 0:
+	case 1:
+		return sm.startEngine(engines[0])
+	default:
+		return sm.startEngines(engines)
+	}
+
+	if len(sm.config.VIPGroups) != 0 {
+		log.Infoln("Starting Kube-vip Manager with only VIP groups")
+		<-sm.signalChan
+		return nil
+	}
+
+	if sm.config.ControlPlaneDNS != "" {
+		log.Infoln("Starting Kube-vip Manager with only the records of the control plane")
+		<-sm.signalChan
+		close(sm.shutdownChan)
+		return nil
 	}
 
 	log.Errorln("prematurely exiting Load-balancer as no modes [ARP/BGP/Wireguard] are enabled")
@@ -261,15 +497,3 @@ func (sm *Manager) stopTrafficMirroringIfEnabled() error {
 	}
 	return nil
 }
-
-func (sm *Manager) findServiceInstance(svc *v1.Service) *Instance {
-	svcUID := string(svc.UID)
-	log.Debugf("service UID: %s", svcUID)
-	for i := range sm.serviceInstances {
-		log.Debugf("saved service instance %d UID: %s", i, sm.serviceInstances[i].UID)
-		if sm.serviceInstances[i].UID == svcUID {
-			return sm.serviceInstances[i]
-		}
-	}
-	return nil
-}
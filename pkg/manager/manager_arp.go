@@ -3,11 +3,9 @@ package manager
 import (
 	"context"
 	"os"
-	"strconv"
 	"syscall"
 	"time"
 
-	"github.com/kamhlos/upnp"
 	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/leaderelection"
@@ -15,6 +13,7 @@ import (
 
 	"github.com/kube-vip/kube-vip/pkg/cluster"
 	"github.com/kube-vip/kube-vip/pkg/iptables"
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
 	"github.com/kube-vip/kube-vip/pkg/vip"
 )
 
@@ -36,6 +35,10 @@ func (sm *Manager) startARP(id string) error {
 		if sm.config.EnableControlPlane {
 			cpCluster.Stop()
 		}
+		// The VIPs of the services are handed over rather than released, for an upgrade
+		if sm.config.Handover {
+			sm.writeServicesHandover()
+		}
 		// Close all go routines
 		close(sm.shutdownChan)
 		// Cancel the context, which will in turn cancel the leadership
@@ -54,7 +57,13 @@ func (sm *Manager) startARP(id string) error {
 		}
 
 		go func() {
-			err := cpCluster.StartCluster(sm.config, clusterManager, nil)
+			var err error
+			if sm.config.Standalone && !sm.config.EnableLeaderElection && !sm.config.EnableVRRP {
+				// Without a Kubernetes cluster there is nothing to elect a leader with, this node owns the VIP
+				err = cpCluster.StartVipService(sm.config, clusterManager, nil, nil)
+			} else {
+				err = cpCluster.StartCluster(sm.config, clusterManager, nil)
+			}
 			if err != nil {
 				log.Errorf("Control Plane Error [%v]", err)
 				// Trigger the shutdown of this manager instance
@@ -82,29 +91,20 @@ func (sm *Manager) startARP(id string) error {
 	}
 
 	// Before starting the leader Election enable any additional functionality
-	upnpEnabled, _ := strconv.ParseBool(os.Getenv("enableUPNP"))
+	sm.startUPNP(ctx)
 
-	if upnpEnabled {
-		sm.upnp = new(upnp.Upnp)
-		err := sm.upnp.ExternalIPAddr()
-		if err != nil {
-			log.Errorf("Error Enabling UPNP %s", err.Error())
-			// Set the struct to nil so nothing should use it in future
-			sm.upnp = nil
-		} else {
-			log.Infof("Successfully enabled UPNP, Gateway address [%s]", sm.upnp.GatewayOutsideIP)
-		}
-	}
+	// The VIPs of the services that the previous kube-vip handed over are kept until they are advertised again
+	sm.takeServicesHandover()
 
 	// This will tidy any dangling kube-vip iptables rules
 	if os.Getenv("EGRESS_CLEAN") != "" {
-		i, err := vip.CreateIptablesClient(sm.config.EgressWithNftables, sm.config.ServiceNamespace, iptables.ProtocolIPv4)
+		i, err := vip.NewEgressRules(sm.config.FirewallBackend, sm.config.EgressWithNftables, sm.config.ServiceNamespace, iptables.ProtocolIPv4)
 		if err != nil {
 			log.Warnf("(egress) Unable to clean any dangling egress rules [%v]", err)
 			log.Warn("(egress) Can be ignored in non iptables release of kube-vip")
 		} else {
 			log.Info("(egress) Cleaning any dangling kube-vip egress rules")
-			cleanErr := i.CleanIPtables()
+			cleanErr := i.CleanRules()
 			if cleanErr != nil {
 				log.Errorf("Error cleaning rules [%v]", cleanErr)
 			}
@@ -135,6 +135,8 @@ func (sm *Manager) startARP(id string) error {
 			},
 		}
 
+		leadership := securityevents.NewLeadership(ctx, sm.config.ServicesLeaseName, id)
+
 		// start the leader election code loop
 		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
 			Lock: lock,
@@ -144,12 +146,13 @@ func (sm *Manager) startARP(id string) error {
 			// loop still running and another process could
 			// get elected before your background loop finished, violating
 			// the stated goal of the lease.
-			ReleaseOnCancel: true,
+			ReleaseOnCancel: !sm.config.Handover,
 			LeaseDuration:   time.Duration(sm.config.LeaseDuration) * time.Second,
 			RenewDeadline:   time.Duration(sm.config.RenewDeadline) * time.Second,
 			RetryPeriod:     time.Duration(sm.config.RetryPeriod) * time.Second,
 			Callbacks: leaderelection.LeaderCallbacks{
 				OnStartedLeading: func(ctx context.Context) {
+					leadership.Started()
 					err = sm.servicesWatcher(ctx, sm.syncServices)
 					if err != nil {
 						log.Fatal(err)
@@ -158,16 +161,18 @@ func (sm *Manager) startARP(id string) error {
 				OnStoppedLeading: func() {
 					// we can do cleanup here
 					log.Infof("leader lost: %s", id)
-					for _, instance := range sm.serviceInstances {
+					for _, instance := range sm.instances() {
 						for _, cluster := range instance.clusters {
 							cluster.Stop()
 						}
 					}
 
+					securityevents.Flush()
 					log.Fatal("lost leadership, restarting kube-vip")
 				},
 				OnNewLeader: func(identity string) {
 					// we're notified when new leader elected
+					leadership.NewLeader(identity)
 					if sm.config.EnableNodeLabeling {
 						applyNodeLabel(sm.clientSet, sm.config.Address, id, identity)
 					}
@@ -175,6 +180,8 @@ func (sm *Manager) startARP(id string) error {
 						// I just got the lock
 						return
 					}
+					// Another node was elected while this node kept the VIPs that were handed over
+					sm.handedOver.release()
 					log.Infof("new leader elected: %s", identity)
 				},
 			},
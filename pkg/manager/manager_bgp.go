@@ -3,16 +3,17 @@ package manager
 import (
 	"context"
 	"fmt"
-	"os"
 	"syscall"
 
 	"github.com/kube-vip/kube-vip/pkg/bgp"
 	"github.com/kube-vip/kube-vip/pkg/cluster"
 	"github.com/kube-vip/kube-vip/pkg/equinixmetal"
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
 	api "github.com/osrg/gobgp/v3/api"
 	"github.com/packethost/packngo"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
 )
 
 // Start will begin the Manager, which will start services and watch the configmap
@@ -24,18 +25,7 @@ func (sm *Manager) startBGP() error {
 	// If Equinix Metal is enabled then we can begin our preparation work
 	var packetClient *packngo.Client
 	if sm.config.EnableMetal {
-		if sm.config.ProviderConfig != "" {
-			key, project, err := equinixmetal.GetPacketConfig(sm.config.ProviderConfig)
-			if err != nil {
-				return err
-			}
-			// Set the environment variable with the key for the project
-			os.Setenv("PACKET_AUTH_TOKEN", key)
-			// Update the configuration with the project key
-			sm.config.MetalProjectID = project
-
-		}
-		packetClient, err = packngo.NewClient()
+		packetClient, err = equinixmetal.NewClient(sm.config)
 		if err != nil {
 			return err
 		}
@@ -50,6 +40,16 @@ func (sm *Manager) startBGP() error {
 		}
 	}
 
+	// Read the peer password(s) from a secret if one has been specified
+	if sm.config.BGPPeerSecret != "" {
+		log.Infof("reading BGP peer password(s) from Kubernetes secret [%s]", sm.config.BGPPeerSecret)
+		s, err := sm.readSecret(context.TODO(), sm.config.BGPPeerSecret)
+		if err != nil {
+			return err
+		}
+		sm.applyBGPSecret(s)
+	}
+
 	log.Info("Starting the BGP server to advertise VIP routes to BGP peers")
 	sm.bgpServer, err = bgp.NewBGPServer(&sm.config.BGPConfig, func(p *api.WatchEventResponse_PeerEvent) {
 		ipaddr := p.GetPeer().GetState().GetNeighborAddress()
@@ -77,10 +77,11 @@ func (sm *Manager) startBGP() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Defer a function to check if the bgpServer has been created and if so attempt to close it
+	// Defer a function to check if the bgpServer has been created and if so attempt to close it, the sessions are
+	// left open for the peers that kube-vip restarts gracefully with
 	defer func() {
 		if sm.bgpServer != nil {
-			sm.bgpServer.Close()
+			sm.bgpServer.Shutdown()
 		}
 	}()
 
@@ -97,6 +98,30 @@ func (sm *Manager) startBGP() error {
 		cancel()
 	}()
 
+	if sm.config.BGPPeerSecret != "" {
+		err = sm.watchSecret(ctx, sm.config.BGPPeerSecret, func(s *v1.Secret) error {
+			changed := sm.applyBGPSecret(s)
+			log.Infof("BGP peer password(s) updated from secret [%s], [%d] peer(s) have changed", s.Name, len(changed))
+			sm.updateBGPPeers(changed)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Passwords from mounted files are re-read when the files are rotated
+	sm.watchBGPPasswordFiles(ctx)
+
+	// Peers that are added to or removed from the annotations of the node are applied without a restart
+	if sm.config.Annotations != "" {
+		if err = sm.watchBGPAnnotations(ctx); err != nil {
+			return err
+		}
+	}
+
+	go sm.bgpServer.WatchAuthFailures(ctx, credentialWatchInterval, securityevents.BGPAuthFailed)
+
 	if sm.config.EnableControlPlane {
 		cpCluster, err = cluster.InitCluster(sm.config, false)
 		if err != nil {
@@ -2,12 +2,16 @@ package manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"syscall"
 	"time"
 
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
 	"github.com/kube-vip/kube-vip/pkg/vip"
 	log "github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
@@ -22,7 +26,20 @@ func (sm *Manager) startTableMode(id string) error {
 	// want to step down
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	log.Infof("all routing table entries will exist in table [%d] with protocol [%d]", sm.config.RoutingTableID, sm.config.RoutingProtocol)
+	if sm.config.RoutingTableVRF != "" {
+		table, _, err := vip.VRF(sm.config.RoutingTableVRF, "")
+		if err != nil {
+			return err
+		}
+		sm.config.RoutingTableID = table
+		log.Infof("routing table entries will exist in the table of VRF [%s]", sm.config.RoutingTableVRF)
+	}
+	log.Infof("all routing table entries will exist in table [%d] with protocol [%d] and metric [%d]", sm.config.RoutingTableID, sm.config.RoutingProtocol, sm.config.RoutingMetric)
+
+	// Re-install the routes of the services if another process deletes or replaces them
+	if err := vip.MonitorRoutes(sm.shutdownChan, func(_ *netlink.Route) { sm.routeRepairs.Inc() }); err != nil {
+		return err
+	}
 
 	if sm.config.CleanRoutingTable {
 		go func() {
@@ -38,6 +55,9 @@ func (sm *Manager) startTableMode(id string) error {
 	go func() {
 		<-sm.signalChan
 		log.Info("Received termination, signaling shutdown")
+		// Close all go routines, so that nothing is re-added while the routes are removed
+		close(sm.shutdownChan)
+		sm.teardownRoutes()
 
 		// Cancel the context, which will in turn cancel the leadership
 		cancel()
@@ -72,6 +92,8 @@ func (sm *Manager) startTableMode(id string) error {
 				Identity: id,
 			},
 		}
+		leadership := securityevents.NewLeadership(ctx, plunderLock, id)
+
 		// start the leader election code loop
 		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
 			Lock: lock,
@@ -87,6 +109,7 @@ func (sm *Manager) startTableMode(id string) error {
 			RetryPeriod:     time.Duration(sm.config.RetryPeriod) * time.Second,
 			Callbacks: leaderelection.LeaderCallbacks{
 				OnStartedLeading: func(ctx context.Context) {
+					leadership.Started()
 					err = sm.servicesWatcher(ctx, sm.syncServices)
 					if err != nil {
 						log.Fatal(err)
@@ -95,16 +118,18 @@ func (sm *Manager) startTableMode(id string) error {
 				OnStoppedLeading: func() {
 					// we can do cleanup here
 					log.Infof("leader lost: %s", id)
-					for _, instance := range sm.serviceInstances {
+					for _, instance := range sm.instances() {
 						for _, cluster := range instance.clusters {
 							cluster.Stop()
 						}
 					}
 
+					securityevents.Flush()
 					log.Fatal("lost leadership, restarting kube-vip")
 				},
 				OnNewLeader: func(identity string) {
 					// we're notified when new leader elected
+					leadership.NewLeader(identity)
 					if identity == id {
 						// I just got the lock
 						return
@@ -124,26 +149,32 @@ func (sm *Manager) startTableMode(id string) error {
 }
 
 func (sm *Manager) cleanRoutes() error {
-	routes, err := vip.ListRoutes(sm.config.RoutingTableID, sm.config.RoutingProtocol)
-	if err != nil {
-		return fmt.Errorf("error getting routes: %w", err)
+	var routes []netlink.Route
+	for _, table := range sm.config.RoutingTables() {
+		tableRoutes, err := vip.ListRoutes(table, sm.config.RoutingProtocol)
+		if err != nil {
+			return fmt.Errorf("error getting routes: %w", err)
+		}
+		routes = append(routes, tableRoutes...)
 	}
 
-	for i := range routes {
-		found := false
-		for _, instance := range sm.serviceInstances {
-			for _, cluster := range instance.clusters {
-				for n := range cluster.Network {
-					r := cluster.Network[n].PrepareRoute()
-					if r.Dst.String() == routes[i].Dst.String() {
-						found = true
-					}
-				}
+	// The destinations of the services are collected once, rather than for each of the routes
+	destinations := make(map[string]struct{})
+	for _, instance := range sm.instances() {
+		for _, cluster := range instance.clusters {
+			for n := range cluster.Network {
+				destinations[cluster.Network[n].PrepareRoute().Dst.String()] = struct{}{}
 			}
 		}
-		if !found {
-			err = netlink.RouteDel(&(routes[i]))
-			if err != nil {
+	}
+
+	for i := range routes {
+		// The blackholes of the pools are kept in line with the services separately
+		if routes[i].Type == unix.RTN_BLACKHOLE {
+			continue
+		}
+		if _, found := destinations[routes[i].Dst.String()]; !found {
+			if err := netlink.RouteDel(&(routes[i])); err != nil {
 				log.Errorf("[route] error deleting route: %v", routes[i])
 			}
 			log.Debugf("[route] deleted route: %v", routes[i])
@@ -152,9 +183,45 @@ func (sm *Manager) cleanRoutes() error {
 	return nil
 }
 
+// teardownRoutes removes the routes that kube-vip has installed, the routes of the services (that may be in the table
+// of a VRF) and then anything left in the tables with our protocol (the blackholes of the pools and any orphans)
+func (sm *Manager) teardownRoutes() {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	removed := 0
+	for _, instance := range sm.instances() {
+		for _, cluster := range instance.clusters {
+			for n := range cluster.Network {
+				if err := cluster.Network[n].DeleteRoute(); err != nil && !errors.Is(err, syscall.ESRCH) {
+					log.Errorf("[route] error deleting route for [%s]: %v", cluster.Network[n].IP(), err)
+					continue
+				}
+				removed++
+			}
+		}
+	}
+
+	for _, table := range sm.config.RoutingTables() {
+		routes, err := vip.ListRoutes(table, sm.config.RoutingProtocol)
+		if err != nil {
+			log.Errorf("[route] unable to remove the routes left in table [%d]: %v", table, err)
+			continue
+		}
+		for i := range routes {
+			if err := netlink.RouteDel(&routes[i]); err != nil {
+				log.Errorf("[route] error deleting route: %v", routes[i])
+				continue
+			}
+			removed++
+		}
+	}
+	log.Infof("[route] removed %d routes from the routing table on shutdown", removed)
+}
+
 func (sm *Manager) countRouteReferences(route *netlink.Route) int {
 	cnt := 0
-	for _, instance := range sm.serviceInstances {
+	for _, instance := range sm.instances() {
 		for _, cluster := range instance.clusters {
 			for n := range cluster.Network {
 				r := cluster.Network[n].PrepareRoute()
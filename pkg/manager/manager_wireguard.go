@@ -2,13 +2,13 @@ package manager
 
 import (
 	"context"
-	"os"
-	"strconv"
 	"time"
 
-	"github.com/kamhlos/upnp"
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
 	"github.com/kube-vip/kube-vip/pkg/wireguard"
 	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
@@ -23,20 +23,63 @@ func (sm *Manager) startWireguard(id string) error {
 	// want to step down
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	log.Infoln("reading wireguard peer configuration from Kubernetes secret")
-	s, err := sm.clientSet.CoreV1().Secrets(sm.config.Namespace).Get(ctx, "wireguard", metav1.GetOptions{})
+	secretName := sm.config.WireguardSecret
+	if secretName == "" {
+		secretName = defaultWireguardSecret
+	}
+	log.Infof("reading wireguard peer configuration from Kubernetes secret [%s]", secretName)
+	s, err := sm.readSecret(ctx, secretName)
 	if err != nil {
-		return err
+		// The peers of the mesh are discovered from the nodes, so the secret is only needed for an external peer
+		if !sm.config.WireguardMesh || !apierrors.IsNotFound(err) {
+			return err
+		}
+		log.Infof("(wireguard) secret [%s] doesn't exist, only the mesh between kube-vip nodes is configured", secretName)
+		s = nil
 	}
-	// parse all the details needed for Wireguard
-	peerPublicKey := s.Data["peerPublicKey"]
-	peerEndpoint := s.Data["peerEndpoint"]
-	privateKey := s.Data["privateKey"]
 
-	// Configure the interface to join the Wireguard VPN
-	err = wireguard.ConfigureInterface(string(privateKey), string(peerPublicKey), string(peerEndpoint))
-	if err != nil {
-		return err
+	if s != nil {
+		// Generate the keys if there aren't any and publish the public key for the peer
+		if sm.config.WireguardGenerateKeys {
+			if s, err = sm.publishWireguardKeys(ctx, s); err != nil {
+				return err
+			}
+		}
+
+		// Configure the interface to join the Wireguard VPN
+		sm.wireguard = wireguard.NewTunnel(sm.config.WireguardOptions())
+		err = sm.applyWireguardSecret(s)
+		if err != nil {
+			return err
+		}
+
+		// Reconfigure the interface if the keys or peer are rotated
+		err = sm.watchSecret(ctx, secretName, func(s *v1.Secret) error {
+			if sm.config.WireguardGenerateKeys {
+				// The public key is published again when the private key has been replaced
+				var err error
+				if s, err = sm.publishWireguardKeys(ctx, s); err != nil {
+					return err
+				}
+			}
+			return sm.applyWireguardSecret(s)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Peer with the other kube-vip nodes, so that traffic can be tunneled to the node with the backend
+	leaveMesh := func() {}
+	if sm.config.WireguardMesh {
+		leave, err := sm.startWireguardMesh(ctx)
+		if leave != nil {
+			leaveMesh = leave
+		}
+		if err != nil {
+			leaveMesh()
+			return err
+		}
 	}
 
 	// Shutdown function that will wait on this signal, unless we call it ourselves
@@ -44,6 +87,9 @@ func (sm *Manager) startWireguard(id string) error {
 		<-sm.signalChan
 		log.Info("Received termination, signaling shutdown")
 
+		// The other nodes stop peering with this node before the leadership is released
+		leaveMesh()
+
 		// Cancel the context, which will in turn cancel the leadership
 		cancel()
 	}()
@@ -54,21 +100,17 @@ func (sm *Manager) startWireguard(id string) error {
 		ns = sm.config.Namespace
 	}
 
-	// Before starting the leader Election enable any additional functionality
-	upnpEnabled, _ := strconv.ParseBool(os.Getenv("enableUPNP"))
-
-	if upnpEnabled {
-		sm.upnp = new(upnp.Upnp)
-		err := sm.upnp.ExternalIPAddr()
-		if err != nil {
-			log.Errorf("Error Enabling UPNP %s", err.Error())
-			// Set the struct to nil so nothing should use it in future
-			sm.upnp = nil
-		} else {
-			log.Infof("Successfully enabled UPNP, Gateway address [%s]", sm.upnp.GatewayOutsideIP)
+	// Only take part in the election with a working tunnel, and step down if it stops working
+	if health := sm.newWireguardHealth(); health != nil {
+		if err = sm.waitForWireguardTunnel(ctx, health); err != nil {
+			return err
 		}
+		go sm.watchWireguardHealth(ctx, health, cancel)
 	}
 
+	// Before starting the leader Election enable any additional functionality
+	sm.startUPNP(ctx)
+
 	// Start a services watcher (all kube-vip pods will watch services), upon a new service
 	// a lock based upon that service is created that they will all leaderElection on
 	if sm.config.EnableServicesElection {
@@ -93,6 +135,8 @@ func (sm *Manager) startWireguard(id string) error {
 			},
 		}
 
+		leadership := securityevents.NewLeadership(ctx, plunderLock, id)
+
 		// start the leader election code loop
 		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
 			Lock: lock,
@@ -108,6 +152,7 @@ func (sm *Manager) startWireguard(id string) error {
 			RetryPeriod:     time.Duration(sm.config.RetryPeriod) * time.Second,
 			Callbacks: leaderelection.LeaderCallbacks{
 				OnStartedLeading: func(ctx context.Context) {
+					leadership.Started()
 					err = sm.servicesWatcher(ctx, sm.syncServices)
 					if err != nil {
 						log.Fatal(err)
@@ -116,16 +161,18 @@ func (sm *Manager) startWireguard(id string) error {
 				OnStoppedLeading: func() {
 					// we can do cleanup here
 					log.Infof("leader lost: %s", id)
-					for _, instance := range sm.serviceInstances {
+					for _, instance := range sm.instances() {
 						for _, cluster := range instance.clusters {
 							cluster.Stop()
 						}
 					}
 
+					securityevents.Flush()
 					log.Fatal("lost leadership, restarting kube-vip")
 				},
 				OnNewLeader: func(identity string) {
 					// we're notified when new leader elected
+					leadership.NewLeader(identity)
 					if identity == id {
 						// I just got the lock
 						return
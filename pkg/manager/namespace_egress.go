@@ -0,0 +1,279 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+// namespaceEgressVIP is the annotation of a namespace with the VIP that the traffic of all of its pods leaves from, or a
+// pool of VIPs (separated by commas) that the pods are spread across by the egress-vip-policy
+const namespaceEgressVIP = "kube-vip.io/egress-vip"
+
+// namespaceEgressResync is how often the rules are checked against the node that holds the VIP, the VIP can move
+// without any change to the pods
+const namespaceEgressResync = 10 * time.Second
+
+// namespaceEgress is the egress VIPs of a namespace, along with the addresses of its pods on this node
+type namespaceEgress struct {
+	vips []string
+	// The range of source ports that the pods are SNATed to, from the egress-snat-ports annotation
+	snatPorts string
+	// The destinations that are left out of the egress, from the egress-exclude-cidrs annotation
+	excludeCidrs string
+	policy       string
+	pool         *egressPool
+	cancel       context.CancelFunc
+
+	mu sync.Mutex
+	// The addresses of the running pods
+	pods map[types.UID][]string
+	// The pod addresses that are SNATed, and the VIP that each of them is SNATed to
+	configured map[string]string
+}
+
+// startNamespaceEgress will watch the namespaces for an egress VIP, the pods of an annotated namespace that are on this
+// node are SNATed to the VIP while this node holds it
+func (sm *Manager) startNamespaceEgress(ctx context.Context) error {
+	if err := sm.iptablesCheck(); err != nil {
+		return fmt.Errorf("unable to configure the egress of the namespaces: %v", err)
+	}
+	namespaces, err := sm.clientSet.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list the namespaces with an egress VIP: %v", err)
+	}
+	egresses := map[string]*namespaceEgress{}
+	for x := range namespaces.Items {
+		sm.syncNamespaceEgress(ctx, egresses, &namespaces.Items[x], false)
+	}
+
+	rw, err := watchtools.NewRetryWatcher(namespaces.ResourceVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return sm.clientSet.CoreV1().Namespaces().Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating namespace egress watcher: %v", err)
+	}
+	go func() {
+		select {
+		case <-sm.shutdownChan:
+		case <-ctx.Done():
+		}
+		rw.Stop()
+	}()
+	go func() {
+		for event := range rw.ResultChan() {
+			namespace, ok := event.Object.(*v1.Namespace)
+			if !ok {
+				continue
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				sm.syncNamespaceEgress(ctx, egresses, namespace, false)
+			case watch.Deleted:
+				sm.syncNamespaceEgress(ctx, egresses, namespace, true)
+			}
+		}
+		// The rules are removed from this node on shutdown, the node that takes over the VIPs will add its own
+		for name := range egresses {
+			egresses[name].cancel()
+		}
+	}()
+	return nil
+}
+
+// syncNamespaceEgress starts (or restarts) the pod watcher of a namespace when its egress VIP changes
+func (sm *Manager) syncNamespaceEgress(ctx context.Context, egresses map[string]*namespaceEgress, namespace *v1.Namespace, deleted bool) {
+	var addresses []string
+	if v := namespace.Annotations[namespaceEgressVIP]; v != "" && !deleted {
+		for _, address := range strings.Split(v, ",") {
+			addresses = append(addresses, strings.TrimSpace(address))
+		}
+	}
+	snatPorts, policy := namespace.Annotations[egressSNATPorts], namespace.Annotations[egressVIPPolicy]
+	excludeCidrs := namespace.Annotations[egressExcludeCidrs]
+	existing, exists := egresses[namespace.Name]
+	if exists && slices.Equal(existing.vips, addresses) && existing.snatPorts == snatPorts && existing.policy == policy && existing.excludeCidrs == excludeCidrs {
+		return
+	}
+	if exists {
+		existing.cancel()
+		delete(egresses, namespace.Name)
+	}
+	if len(addresses) == 0 {
+		return
+	}
+	for _, address := range addresses {
+		if !vip.IsIP(address) {
+			log.Errorf("[egress] namespace [%s] has an invalid egress VIP [%s]", namespace.Name, address)
+			return
+		}
+	}
+	if err := vip.ValidateSourceNatPorts(snatPorts); err != nil {
+		log.Errorf("[egress] namespace [%s] has invalid egress ports: %v", namespace.Name, err)
+		return
+	}
+	if _, err := vip.EgressExclusions(excludeCidrs, addresses[0]); err != nil {
+		log.Errorf("[egress] namespace [%s]: %v", namespace.Name, err)
+		return
+	}
+	pool, err := newEgressPool(policy)
+	if err != nil {
+		log.Errorf("[egress] namespace [%s]: %v", namespace.Name, err)
+		return
+	}
+
+	egressCtx, cancel := context.WithCancel(ctx)
+	egress := &namespaceEgress{vips: addresses, snatPorts: snatPorts, excludeCidrs: excludeCidrs, policy: policy, pool: pool, cancel: cancel, pods: map[types.UID][]string{}, configured: map[string]string{}}
+	egresses[namespace.Name] = egress
+	log.Infof("[egress] the pods of namespace [%s] on this node will egress from [%s]", namespace.Name, strings.Join(addresses, ","))
+	go func() {
+		if err := sm.watchNamespaceEgressPods(egressCtx, namespace.Name, egress); err != nil {
+			log.Errorf("[egress] %v", err)
+		}
+	}()
+}
+
+// watchNamespaceEgressPods keeps the rules of the namespace in line with its pods on this node, until the context is
+// cancelled when the rules are removed
+func (sm *Manager) watchNamespaceEgressPods(ctx context.Context, namespace string, egress *namespaceEgress) error {
+	defer sm.reconcileNamespaceEgress(namespace, egress, false)
+
+	opts := metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("spec.nodeName", sm.config.NodeName).String()}
+	pods, err := sm.clientSet.CoreV1().Pods(namespace).List(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("unable to list the pods of namespace [%s]: %v", namespace, err)
+	}
+	for x := range pods.Items {
+		egress.updatePod(&pods.Items[x], false)
+	}
+	sm.reconcileNamespaceEgress(namespace, egress, true)
+
+	rw, err := watchtools.NewRetryWatcher(pods.ResourceVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = opts.FieldSelector
+			return sm.clientSet.CoreV1().Pods(namespace).Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating the pod watcher of namespace [%s]: %v", namespace, err)
+	}
+	defer rw.Stop()
+
+	ticker := time.NewTicker(namespaceEgressResync)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case event, ok := <-rw.ResultChan():
+			if !ok {
+				return nil
+			}
+			pod, isPod := event.Object.(*v1.Pod)
+			if !isPod {
+				continue
+			}
+			if !egress.updatePod(pod, event.Type == watch.Deleted) {
+				continue
+			}
+		}
+		sm.reconcileNamespaceEgress(namespace, egress, true)
+	}
+}
+
+// updatePod updates the addresses of the pod, returning true if they have changed
+func (e *namespaceEgress) updatePod(pod *v1.Pod, deleted bool) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, exists := e.pods[pod.UID]
+	if deleted || pod.Spec.HostNetwork || pod.Status.Phase != v1.PodRunning || len(pod.Status.PodIPs) == 0 {
+		delete(e.pods, pod.UID)
+		return exists
+	}
+	var addresses []string
+	for _, podIP := range pod.Status.PodIPs {
+		addresses = append(addresses, podIP.IP)
+	}
+	e.pods[pod.UID] = addresses
+	return true
+}
+
+// desiredAddresses returns the addresses of the running pods, that should be SNATed to a VIP of their family
+func (e *namespaceEgress) desiredAddresses() map[string]bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	desired := map[string]bool{}
+	for _, addresses := range e.pods {
+		for _, address := range addresses {
+			desired[address] = true
+		}
+	}
+	return desired
+}
+
+// reconcileNamespaceEgress adds the rules for the pods to the VIPs that this node holds, and removes them otherwise. The
+// pods are spread across the VIPs of their family by the policy of the namespace.
+func (sm *Manager) reconcileNamespaceEgress(namespace string, egress *namespaceEgress, active bool) {
+	desired := map[string]string{}
+	if active {
+		var local []string
+		for _, address := range egress.vips {
+			isLocal, err := vip.IsLocalAddress(address)
+			if err != nil {
+				log.Errorf("[egress] unable to check if egress VIP [%s] is on this node: %v", address, err)
+				return
+			}
+			if isLocal {
+				local = append(local, address)
+			}
+		}
+		for podIP := range egress.desiredAddresses() {
+			if address := egress.pool.selectVIP(podIP, local); address != "" {
+				desired[podIP] = address
+			}
+		}
+	}
+
+	// The rules of a pod are removed before it is SNATed to another VIP
+	for podIP, address := range egress.configured {
+		if desired[podIP] == address {
+			continue
+		}
+		if err := sm.TeardownEgress(podIP, address, "", egress.snatPorts, egress.excludeCidrs, namespace); err != nil {
+			log.Errorf("[egress] unable to remove egress of pod [%s] in namespace [%s]: %v", podIP, namespace, err)
+		}
+		delete(egress.configured, podIP)
+		if _, exists := desired[podIP]; !exists {
+			egress.pool.release(podIP)
+		}
+	}
+	for podIP, address := range desired {
+		if egress.configured[podIP] == address {
+			continue
+		}
+		if err := sm.configureEgress(address, podIP, "", egress.snatPorts, egress.excludeCidrs, namespace); err != nil {
+			log.Errorf("[egress] unable to configure egress of pod [%s] in namespace [%s]: %v", podIP, namespace, err)
+			continue
+		}
+		egress.configured[podIP] = address
+	}
+}
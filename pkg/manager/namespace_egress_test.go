@@ -0,0 +1,40 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestNamespaceEgressPods(t *testing.T) {
+	egress := &namespaceEgress{vips: []string{"192.168.0.10"}, pods: map[types.UID][]string{}}
+	pod := func(uid string, phase v1.PodPhase, ips ...string) *v1.Pod {
+		p := &v1.Pod{ObjectMeta: metav1.ObjectMeta{UID: types.UID(uid)}, Status: v1.PodStatus{Phase: phase}}
+		for _, ip := range ips {
+			p.Status.PodIPs = append(p.Status.PodIPs, v1.PodIP{IP: ip})
+		}
+		return p
+	}
+
+	if !egress.updatePod(pod("a", v1.PodRunning, "10.0.0.1", "fd00::1"), false) {
+		t.Error("updatePod() = false, want a running pod to be added")
+	}
+	if egress.updatePod(pod("b", v1.PodPending), false) {
+		t.Error("updatePod() = true, want a pending pod to be ignored")
+	}
+	egress.updatePod(pod("c", v1.PodRunning, "10.0.0.3"), false)
+	if got, want := egress.desiredAddresses(), map[string]bool{"10.0.0.1": true, "fd00::1": true, "10.0.0.3": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("desiredAddresses() = %v, want %v", got, want)
+	}
+
+	if !egress.updatePod(pod("c", v1.PodSucceeded, "10.0.0.3"), false) {
+		t.Error("updatePod() = false, want a finished pod to be removed")
+	}
+	egress.updatePod(pod("a", v1.PodRunning), true)
+	if got := egress.desiredAddresses(); len(got) != 0 {
+		t.Errorf("desiredAddresses() = %v, want none", got)
+	}
+}
@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	"github.com/kube-vip/kube-vip/pkg/openstack"
+)
+
+// startOpenStack will add the VIP and the pools to the allowed address pairs of the port of this node, and the pools of
+// the configmap of the cloud provider again whenever they change
+func (sm *Manager) startOpenStack(ctx context.Context) error {
+	client, err := openstack.NewClient(sm.config)
+	if err != nil {
+		return fmt.Errorf("unable to create the OpenStack client: %v", err)
+	}
+	configured, err := openstack.ConfiguredCIDRs(sm.config)
+	if err != nil {
+		return err
+	}
+	if sm.config.OpenStackPoolsConfigMap == "" || sm.clientSet == nil {
+		syncAllowedAddressPairs(client, configured, nil)
+		return nil
+	}
+
+	selector := fields.OneTermEqualSelector("metadata.name", sm.config.OpenStackPoolsConfigMap).String()
+	configMaps, err := sm.clientSet.CoreV1().ConfigMaps(sm.config.Namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return fmt.Errorf("unable to get the pools of the configmap [%s]: %v", sm.config.OpenStackPoolsConfigMap, err)
+	}
+	var data map[string]string
+	if len(configMaps.Items) != 0 {
+		data = configMaps.Items[0].Data
+	}
+	syncAllowedAddressPairs(client, configured, data)
+
+	rw, err := watchtools.NewRetryWatcher(configMaps.ResourceVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return sm.clientSet.CoreV1().ConfigMaps(sm.config.Namespace).Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating the pools configmap watcher: %v", err)
+	}
+	go func() {
+		select {
+		case <-sm.shutdownChan:
+		case <-ctx.Done():
+		}
+		rw.Stop()
+	}()
+	go func() {
+		for event := range rw.ResultChan() {
+			cm, ok := event.Object.(*v1.ConfigMap)
+			if !ok || (event.Type != watch.Added && event.Type != watch.Modified) {
+				continue
+			}
+			log.Infof("[OpenStack] the pools of the configmap [%s] have changed", cm.Name)
+			syncAllowedAddressPairs(client, configured, cm.Data)
+		}
+	}()
+	return nil
+}
+
+// syncAllowedAddressPairs adds the configured CIDRs and those of the pools of the configmap to the allowed address
+// pairs of the port
+func syncAllowedAddressPairs(client *openstack.Client, configured []netip.Prefix, data map[string]string) {
+	cidrs := slices.Clone(configured)
+	pools, err := openstack.PoolCIDRs(data)
+	if err != nil {
+		log.Errorf("[OpenStack] unable to read the pools: %v", err)
+	}
+	cidrs = append(cidrs, pools...)
+	if _, err := client.AddAllowedAddressPairs(cidrs); err != nil {
+		log.Errorf("[OpenStack] %v", err)
+	}
+}
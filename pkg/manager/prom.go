@@ -1,8 +1,70 @@
 package manager
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kube-vip/kube-vip/pkg/k8s"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/vip"
+	"github.com/kube-vip/kube-vip/pkg/wireguard"
+	"github.com/kube-vip/kube-vip/pkg/xdplb"
+)
 
 // PrometheusCollector defines a service watch event counter.
 func (sm *Manager) PrometheusCollector() []prometheus.Collector {
-	return []prometheus.Collector{sm.countServiceWatchEvent, sm.bgpSessionInfoGauge}
+	collectors := []prometheus.Collector{sm.countServiceWatchEvent, sm.bgpSessionInfoGauge, sm.etcdCertificateExpiry, sm.egressRuleErrors, &egressCollector{sm: sm}, &vipCollector{sm: sm}}
+	collectors = append(collectors, k8s.RequestMetrics()...)
+	collectors = append(collectors, vip.AdvertisementMetrics()...)
+	collectors = append(collectors, sm.leaders.info, sm.leaders.transitions)
+	if sm.config.EnableWireguard {
+		collectors = append(collectors, sm.wireguardTunnelHealthy, wireguard.NewCollector(wireguard.Device, wireguard.MeshDevice))
+	}
+	if sm.config.EnableUPNP {
+		collectors = append(collectors, sm.upnpRenewalFailures, &upnpCollector{sm: sm})
+	}
+	if sm.config.EnableLoadBalancer && sm.config.LoadBalancerDataplane == kubevip.LoadBalancerXDP {
+		collectors = append(collectors, xdplb.NewCollector())
+	}
+	if sm.config.EnableRoutingTable {
+		collectors = append(collectors, sm.routeRepairs)
+	}
+	return collectors
+}
+
+// leaderMetrics are the leaders of every lease that this node takes part in the election of, so that the leaders can be
+// seen from any node
+type leaderMetrics struct {
+	info        *prometheus.GaugeVec
+	transitions *prometheus.CounterVec
+}
+
+func newLeaderMetrics() *leaderMetrics {
+	return &leaderMetrics{
+		info: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "kube_vip",
+			Subsystem: "leader_election",
+			Name:      "leader_info",
+			Help:      "The current leader of each lease that this node takes part in the election of",
+		}, []string{"lease", "leader"}),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "kube_vip",
+			Subsystem: "leader_election",
+			Name:      "transitions_total",
+			Help:      "Count the changes of the leader of each lease that have been observed by this node",
+		}, []string{"lease"}),
+	}
+}
+
+// NewLeader reports the leader of the lease
+func (m *leaderMetrics) NewLeader(lease, leader string, transition bool) {
+	if transition {
+		m.transitions.WithLabelValues(lease).Inc()
+	}
+	m.info.DeletePartialMatch(prometheus.Labels{"lease": lease})
+	m.info.WithLabelValues(lease, leader).Set(1)
+}
+
+// Stopped no longer reports the leader of the lease
+func (m *leaderMetrics) Stopped(lease string) {
+	m.info.DeletePartialMatch(prometheus.Labels{"lease": lease})
 }
@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// reportedLeaders returns the leaders of the lease that are reported
+func reportedLeaders(m *leaderMetrics, lease string) []string {
+	ch := make(chan prometheus.Metric, 100)
+	m.info.Collect(ch)
+	close(ch)
+
+	var identities []string
+	for metric := range ch {
+		var written dto.Metric
+		_ = metric.Write(&written)
+		labels := map[string]string{}
+		for _, label := range written.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		if labels["lease"] == lease {
+			identities = append(identities, labels["leader"])
+		}
+	}
+	return identities
+}
+
+func TestLeaderMetrics(t *testing.T) {
+	m := newLeaderMetrics()
+	m.NewLeader("kube-system/plndr-svcs-lock", "node-2", false)
+	m.NewLeader("kube-system/plndr-svcs-lock", "node-1", true)
+	m.NewLeader("kube-system/plndr-cp-lock", "node-3", false)
+
+	if got := testutil.ToFloat64(m.transitions.WithLabelValues("kube-system/plndr-svcs-lock")); got != 1 {
+		t.Errorf("expected 1 transition, got %v", got)
+	}
+	if got := reportedLeaders(m, "kube-system/plndr-svcs-lock"); len(got) != 1 || got[0] != "node-1" {
+		t.Errorf("expected node-1 to be the only leader, got %v", got)
+	}
+
+	// The leader isn't reported once the election has stopped, the other leases still are
+	m.Stopped("kube-system/plndr-svcs-lock")
+	if got := reportedLeaders(m, "kube-system/plndr-svcs-lock"); len(got) != 0 {
+		t.Errorf("expected no leaders, got %v", got)
+	}
+	if got := reportedLeaders(m, "kube-system/plndr-cp-lock"); len(got) != 1 || got[0] != "node-3" {
+		t.Errorf("expected node-3 to still be the leader, got %v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// checkPermissions will ask the API server if kube-vip has every permission that its configuration needs, so that
+// missing RBAC is reported at startup instead of as a failed watch or update later on
+func (sm *Manager) checkPermissions(ctx context.Context) error {
+	rules := kubevip.GenerateRBACRules(sm.config, false)
+
+	var missing []string
+	check := func(namespace string, rule rbacv1.PolicyRule) error {
+		names := rule.ResourceNames
+		if len(names) == 0 {
+			names = []string{""}
+		}
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				resource, subresource, _ := strings.Cut(resource, "/")
+				for _, verb := range rule.Verbs {
+					for _, name := range names {
+						review := &authorizationv1.SelfSubjectAccessReview{
+							Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+								ResourceAttributes: &authorizationv1.ResourceAttributes{
+									Namespace:   namespace,
+									Verb:        verb,
+									Group:       group,
+									Resource:    resource,
+									Subresource: subresource,
+									Name:        name,
+								},
+							},
+						}
+						result, err := sm.clientSet.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+						if err != nil {
+							return err
+						}
+						if !result.Status.Allowed {
+							missing = append(missing, describePermission(review.Spec.ResourceAttributes))
+						}
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, rule := range rules.Cluster {
+		if err := check("", rule); err != nil {
+			// Not being able to check isn't a reason to stop, any missing permissions will show up when they are used
+			log.Warnf("unable to verify the kube-vip permissions: %v", err)
+			return nil
+		}
+	}
+	for _, namespace := range rules.Namespaces() {
+		for _, rule := range rules.Namespaced[namespace] {
+			if err := check(namespace, rule); err != nil {
+				log.Warnf("unable to verify the kube-vip permissions: %v", err)
+				return nil
+			}
+		}
+	}
+
+	if len(missing) != 0 {
+		return fmt.Errorf("kube-vip is missing the permissions [%s], the required RBAC can be generated with \"kube-vip manifest rbac\" using the same configuration",
+			strings.Join(missing, ", "))
+	}
+	log.Debugf("verified the kube-vip permissions")
+	return nil
+}
+
+// describePermission returns a readable description of a permission, e.g. "update services/status in namespace default"
+func describePermission(attributes *authorizationv1.ResourceAttributes) string {
+	resource := attributes.Resource
+	if attributes.Subresource != "" {
+		resource += "/" + attributes.Subresource
+	}
+	if attributes.Group != "" {
+		resource += "." + attributes.Group
+	}
+	if attributes.Name != "" {
+		resource += "/" + attributes.Name
+	}
+	if attributes.Namespace == "" {
+		return fmt.Sprintf("%s %s in all namespaces", attributes.Verb, resource)
+	}
+	return fmt.Sprintf("%s %s in namespace %s", attributes.Verb, resource, attributes.Namespace)
+}
@@ -0,0 +1,298 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+	"github.com/kube-vip/kube-vip/pkg/httptls"
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
+	"github.com/kube-vip/kube-vip/pkg/utils"
+	"github.com/kube-vip/kube-vip/pkg/wireguard"
+)
+
+const (
+	// defaultWireguardSecret is the secret that has historically been used for the wireguard configuration
+	defaultWireguardSecret = "wireguard"
+
+	// wireguardPrivateKey and wireguardPublicKey are the keys of this end of the tunnel within the wireguard secret,
+	// the public key is only written when the keys are generated
+	wireguardPrivateKey = "privateKey"
+	wireguardPublicKey  = "publicKey"
+
+	// bgpPasswordKey is the key within the BGP secret that holds the password used for all peers
+	bgpPasswordKey = "password"
+
+	// credentialWatchInterval is how often files that hold credentials are checked for changes
+	credentialWatchInterval = 10 * time.Second
+
+	// etcdCertDir is where the etcd client certificates from a secret are written, as the etcd client expects files
+	etcdCertDir = "/tmp/kube-vip/etcd"
+)
+
+// readSecret will read a secret from the namespace that kube-vip is configured to use
+func (sm *Manager) readSecret(ctx context.Context, name string) (*v1.Secret, error) {
+	if sm.clientSet == nil {
+		return nil, fmt.Errorf("unable to read secret [%s], no Kubernetes client has been configured", name)
+	}
+	return sm.clientSet.CoreV1().Secrets(sm.config.Namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// applyBGPSecret will update the BGP peer passwords from the secret, a key matching the address of a peer takes
+// precedence over the "password" key. The peers whose password has changed are returned.
+func (sm *Manager) applyBGPSecret(s *v1.Secret) []bgp.Peer {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	if password, exists := s.Data[bgpPasswordKey]; exists {
+		sm.config.BGPPeerConfig.Password = string(password)
+	}
+	var changed []bgp.Peer
+	for x := range sm.config.BGPConfig.Peers {
+		password, exists := s.Data[sm.config.BGPConfig.Peers[x].Address]
+		if !exists {
+			password, exists = s.Data[bgpPasswordKey]
+		}
+		if exists && sm.config.BGPConfig.Peers[x].Password != string(password) {
+			sm.config.BGPConfig.Peers[x].Password = string(password)
+			changed = append(changed, sm.config.BGPConfig.Peers[x])
+		}
+	}
+	return changed
+}
+
+// setBGPPassword will update the password of a single peer, returning false if it hasn't changed
+func (sm *Manager) setBGPPassword(address, password string) (bgp.Peer, bool) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	for x := range sm.config.BGPConfig.Peers {
+		if sm.config.BGPConfig.Peers[x].Address == address && sm.config.BGPConfig.Peers[x].Password != password {
+			sm.config.BGPConfig.Peers[x].Password = password
+			return sm.config.BGPConfig.Peers[x], true
+		}
+	}
+	return bgp.Peer{}, false
+}
+
+// updateBGPPeers will re-authenticate the peers with their new passwords, the sessions with other peers are left alone
+func (sm *Manager) updateBGPPeers(peers []bgp.Peer) {
+	if sm.bgpServer == nil {
+		return
+	}
+	for _, peer := range peers {
+		if err := sm.bgpServer.UpdatePeer(peer); err != nil {
+			log.Errorf("unable to update BGP peer [%s] with its new password: %v", peer.Address, err)
+			continue
+		}
+		log.Infof("BGP peer [%s] password has changed, the session has been reset", peer.Address)
+	}
+}
+
+// watchBGPPasswordFiles will watch the files that BGP peer passwords were read from, when a mounted secret is rotated
+// the new password is applied to the affected peer
+func (sm *Manager) watchBGPPasswordFiles(ctx context.Context) {
+	for address, file := range sm.config.BGPPasswordFiles {
+		log.Infof("watching [%s] for changes to the password of BGP peer [%s]", file, address)
+		go utils.WatchFiles(ctx, []string{file}, credentialWatchInterval, func() error {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if peer, changed := sm.setBGPPassword(address, strings.TrimSpace(string(b))); changed {
+				securityevents.Emit(securityevents.ConfigChanged, "BGP peer password file has been modified", map[string]string{
+					"file": file,
+					"peer": address,
+				})
+				sm.updateBGPPeers([]bgp.Peer{peer})
+			}
+			return nil
+		})
+	}
+}
+
+// applyWireguardSecret will configure the wireguard interface from the secret
+func (sm *Manager) applyWireguardSecret(s *v1.Secret) error {
+	// Configure the interface to join the Wireguard VPN, rotated keys are applied without interrupting the tunnel
+	return sm.wireguard.Apply(wireguard.Keys{
+		PrivateKey:    string(s.Data[wireguardPrivateKey]),
+		PeerPublicKey: string(s.Data["peerPublicKey"]),
+		PeerEndpoint:  string(s.Data["peerEndpoint"]),
+		PresharedKey:  string(s.Data[wireguard.PresharedKeyName]),
+	})
+}
+
+// publishWireguardKeys will generate a private key if the secret doesn't have one, and publish its public key in the
+// secret so that the peer can be configured with it. The secret that has been written is returned.
+func (sm *Manager) publishWireguardKeys(ctx context.Context, s *v1.Secret) (*v1.Secret, error) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		privateKey := string(s.Data[wireguardPrivateKey])
+		generated := privateKey == ""
+		if generated {
+			var err error
+			if privateKey, _, err = wireguard.GenerateKeys(); err != nil {
+				return err
+			}
+		}
+		publicKey, err := wireguard.PublicKey(privateKey)
+		if err != nil {
+			return fmt.Errorf("unable to parse the private key in secret [%s]: %v", s.Name, err)
+		}
+		if !generated && string(s.Data[wireguardPublicKey]) == publicKey {
+			return nil
+		}
+
+		updated := s.DeepCopy()
+		if updated.Data == nil {
+			updated.Data = map[string][]byte{}
+		}
+		updated.Data[wireguardPrivateKey] = []byte(privateKey)
+		updated.Data[wireguardPublicKey] = []byte(publicKey)
+		written, err := sm.clientSet.CoreV1().Secrets(s.Namespace).Update(ctx, updated, metav1.UpdateOptions{})
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				// Another node may have published keys first, they're used instead
+				if latest, readErr := sm.readSecret(ctx, s.Name); readErr == nil {
+					s = latest
+				}
+			}
+			return err
+		}
+		if generated {
+			log.Infof("(wireguard) generated a private key, public key [%s] has been published in secret [%s]", publicKey, s.Name)
+		} else {
+			log.Infof("(wireguard) public key [%s] has been published in secret [%s]", publicKey, s.Name)
+		}
+		s = written
+		return nil
+	})
+	return s, err
+}
+
+// applyEtcdSecret will write the etcd client certificates from the secret to disk and point the configuration at them
+func (sm *Manager) applyEtcdSecret(s *v1.Secret) error {
+	if err := os.MkdirAll(etcdCertDir, 0700); err != nil {
+		return err
+	}
+	files := map[string]*string{
+		v1.ServiceAccountRootCAKey: &sm.config.Etcd.CAFile,
+		v1.TLSCertKey:              &sm.config.Etcd.ClientCertFile,
+		v1.TLSPrivateKeyKey:        &sm.config.Etcd.ClientKeyFile,
+	}
+	for key, path := range files {
+		data, exists := s.Data[key]
+		if !exists {
+			continue
+		}
+		*path = filepath.Join(etcdCertDir, key)
+		if err := os.WriteFile(*path, data, 0600); err != nil {
+			return fmt.Errorf("unable to write [%s] from secret [%s]: %v", key, s.Name, err)
+		}
+	}
+	return nil
+}
+
+// loadEtcdSecret will read the etcd client certificates from a secret (if one is configured), this happens before
+// the etcd client is created. The files are rewritten when the secret is rotated, which the etcd client will use
+// for any new connections.
+func (sm *Manager) loadEtcdSecret(ctx context.Context) error {
+	if sm.config.Etcd.ClientSecret == "" {
+		return nil
+	}
+	log.Infof("reading etcd client certificates from Kubernetes secret [%s]", sm.config.Etcd.ClientSecret)
+	s, err := sm.readSecret(ctx, sm.config.Etcd.ClientSecret)
+	if err != nil {
+		return err
+	}
+	if err := sm.applyEtcdSecret(s); err != nil {
+		return err
+	}
+	return sm.watchSecret(ctx, sm.config.Etcd.ClientSecret, sm.applyEtcdSecret)
+}
+
+// watchSecret will watch a secret and call the apply function whenever it is modified, this allows sensitive
+// configuration to be rotated without restarting kube-vip
+func (sm *Manager) watchSecret(ctx context.Context, name string, apply func(*v1.Secret) error) error {
+	s, err := sm.readSecret(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	rw, err := watchtools.NewRetryWatcher(s.ResourceVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+			return sm.clientSet.CoreV1().Secrets(sm.config.Namespace).Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating secret watcher: %s", err.Error())
+	}
+
+	go func() {
+		select {
+		case <-sm.shutdownChan:
+		case <-ctx.Done():
+		}
+		log.Debugf("(secrets) stopping watcher for [%s]", name)
+		rw.Stop()
+	}()
+
+	go func() {
+		for event := range rw.ResultChan() {
+			switch event.Type {
+			case watch.Modified:
+				s, ok := event.Object.(*v1.Secret)
+				if !ok {
+					log.Errorf("(secrets) unable to parse Kubernetes secret from API watcher")
+					continue
+				}
+				log.Infof("(secrets) secret [%s] has been modified, reloading", name)
+				securityevents.Emit(securityevents.ConfigChanged, "secret has been modified", map[string]string{
+					"secret":          fmt.Sprintf("%s/%s", s.Namespace, s.Name),
+					"resourceVersion": s.ResourceVersion,
+				})
+				if err := apply(s); err != nil {
+					log.Errorf("(secrets) unable to apply secret [%s]: %v", name, err)
+				}
+			case watch.Deleted:
+				log.Warnf("(secrets) secret [%s] has been deleted, keeping the existing configuration", name)
+			case watch.Error:
+				log.Errorf("(secrets) error attempting to watch secret [%s]: %v", name, event.Object)
+			}
+		}
+	}()
+	return nil
+}
+
+// SetHTTPCertificates sets the certificates of the local HTTP endpoints, so that they can be loaded from a secret
+func (sm *Manager) SetHTTPCertificates(certs *httptls.Certificates) {
+	sm.httpCertificates = certs
+}
+
+// watchHTTPTLSSecret will load the HTTP endpoint certificates from the secret and reload them when it is rotated
+func (sm *Manager) watchHTTPTLSSecret(ctx context.Context) error {
+	name := sm.config.HTTPTLS.Secret
+	log.Infof("reading HTTP endpoint certificates from Kubernetes secret [%s]", name)
+	s, err := sm.readSecret(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := sm.httpCertificates.ApplySecret(s); err != nil {
+		return fmt.Errorf("unable to load certificates from secret [%s]: %v", name, err)
+	}
+	return sm.watchSecret(ctx, name, sm.httpCertificates.ApplySecret)
+}
@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+func TestApplyBGPSecret(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      map[string]string
+		passwords []string
+		changed   []string
+	}{
+		{
+			name:      "password for all peers",
+			data:      map[string]string{bgpPasswordKey: "secret"},
+			passwords: []string{"secret", "secret"},
+			changed:   []string{"192.168.0.1", "192.168.0.2"},
+		},
+		{
+			name:      "password of a peer",
+			data:      map[string]string{bgpPasswordKey: "secret", "192.168.0.2": "peer"},
+			passwords: []string{"secret", "peer"},
+			changed:   []string{"192.168.0.1", "192.168.0.2"},
+		},
+		{
+			name:      "unchanged password",
+			data:      map[string]string{bgpPasswordKey: "old", "192.168.0.2": "peer"},
+			passwords: []string{"old", "peer"},
+			changed:   []string{"192.168.0.2"},
+		},
+		{
+			name:      "no password",
+			data:      map[string]string{},
+			passwords: []string{"old", "old"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := &Manager{config: &kubevip.Config{BGPConfig: bgp.Config{Peers: []bgp.Peer{
+				{Address: "192.168.0.1", Password: "old"},
+				{Address: "192.168.0.2", Password: "old"},
+			}}}}
+			s := &v1.Secret{Data: map[string][]byte{}}
+			for key, value := range tt.data {
+				s.Data[key] = []byte(value)
+			}
+
+			changed := sm.applyBGPSecret(s)
+			if len(changed) != len(tt.changed) {
+				t.Fatalf("applyBGPSecret() changed %v, want %v", changed, tt.changed)
+			}
+			for x := range changed {
+				if changed[x].Address != tt.changed[x] {
+					t.Errorf("applyBGPSecret() changed %v, want %v", changed, tt.changed)
+				}
+			}
+			for x, peer := range sm.config.BGPConfig.Peers {
+				if peer.Password != tt.passwords[x] {
+					t.Errorf("password of peer [%s] = %q, want %q", peer.Address, peer.Password, tt.passwords[x])
+				}
+			}
+		})
+	}
+}
+
+func TestSetBGPPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		password string
+		changed  bool
+	}{
+		{name: "new password", address: "192.168.0.1", password: "new", changed: true},
+		{name: "same password", address: "192.168.0.1", password: "old"},
+		{name: "unknown peer", address: "192.168.0.9", password: "new"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := &Manager{config: &kubevip.Config{BGPConfig: bgp.Config{Peers: []bgp.Peer{{Address: "192.168.0.1", Password: "old"}}}}}
+			peer, changed := sm.setBGPPassword(tt.address, tt.password)
+			if changed != tt.changed {
+				t.Fatalf("setBGPPassword() changed = %v, want %v", changed, tt.changed)
+			}
+			if changed && (peer.Address != tt.address || peer.Password != tt.password) {
+				t.Errorf("setBGPPassword() = %+v, want the peer with its new password", peer)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/servicepolicy"
+)
+
+// serviceARPConfig returns the gratuitous ARP/NDP settings of a service, its annotations take precedence over its
+// policy which takes precedence over the configuration
+func serviceARPConfig(svc *v1.Service, config *kubevip.Config, overrides servicepolicy.Overrides) (*kubevip.Config, error) {
+	c := &kubevip.Config{
+		EnableARP:         config.EnableARP,
+		ArpBroadcastRate:  config.ArpBroadcastRate,
+		ArpRepeat:         config.ArpRepeat,
+		ArpRepeatInterval: config.ArpRepeatInterval,
+		ArpBackoff:        config.ArpBackoff,
+	}
+	if overrides.ArpBroadcastRate != 0 {
+		c.ArpBroadcastRate = overrides.ArpBroadcastRate
+	}
+
+	for annotation, milliseconds := range map[string]*int64{serviceArpBroadcastRate: &c.ArpBroadcastRate, serviceArpRepeatInterval: &c.ArpRepeatInterval} {
+		if value := svc.Annotations[annotation]; value != "" {
+			i64, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("service %s/%s has an invalid %s [%s]", svc.Namespace, svc.Name, annotation, value)
+			}
+			*milliseconds = i64
+		}
+	}
+	if value := svc.Annotations[serviceArpRepeat]; value != "" {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("service %s/%s has an invalid %s [%s]", svc.Namespace, svc.Name, serviceArpRepeat, value)
+		}
+		c.ArpRepeat = i
+	}
+	if value := svc.Annotations[serviceArpBackoff]; value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("service %s/%s has an invalid %s [%s]", svc.Namespace, svc.Name, serviceArpBackoff, value)
+		}
+		c.ArpBackoff = b
+	}
+
+	if err := c.CheckARP(); err != nil {
+		return nil, fmt.Errorf("service %s/%s: %v", svc.Namespace, svc.Name, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,51 @@
+package manager
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/servicepolicy"
+)
+
+func TestServiceARPConfig(t *testing.T) {
+	config := &kubevip.Config{EnableARP: true, ArpBroadcastRate: 3000, ArpRepeat: 2}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		overrides   servicepolicy.Overrides
+		want        kubevip.Config
+		wantErr     bool
+	}{
+		{name: "configuration", want: kubevip.Config{ArpBroadcastRate: 3000, ArpRepeat: 2}},
+		{name: "policy", overrides: servicepolicy.Overrides{ArpBroadcastRate: 1000}, want: kubevip.Config{ArpBroadcastRate: 1000, ArpRepeat: 2}},
+		{
+			name:        "annotations take precedence over the policy",
+			annotations: map[string]string{serviceArpBroadcastRate: "2000", serviceArpRepeat: "5", serviceArpRepeatInterval: "100", serviceArpBackoff: "true"},
+			overrides:   servicepolicy.Overrides{ArpBroadcastRate: 1000},
+			want:        kubevip.Config{ArpBroadcastRate: 2000, ArpRepeat: 5, ArpRepeatInterval: 100, ArpBackoff: true},
+		},
+		{name: "invalid repeat", annotations: map[string]string{serviceArpRepeat: "many"}, wantErr: true},
+		{name: "invalid backoff", annotations: map[string]string{serviceArpBackoff: "often"}, wantErr: true},
+		{name: "repeat interval longer than the rate", annotations: map[string]string{serviceArpRepeatInterval: "5000"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Annotations: tt.annotations}}
+			got, err := serviceARPConfig(svc, config, tt.overrides)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serviceARPConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.ArpBroadcastRate != tt.want.ArpBroadcastRate || got.ArpRepeat != tt.want.ArpRepeat ||
+				got.ArpRepeatInterval != tt.want.ArpRepeatInterval || got.ArpBackoff != tt.want.ArpBackoff {
+				t.Errorf("serviceARPConfig() = %d/%d/%d/%t, want %d/%d/%d/%t", got.ArpBroadcastRate, got.ArpRepeat, got.ArpRepeatInterval, got.ArpBackoff,
+					tt.want.ArpBroadcastRate, tt.want.ArpRepeat, tt.want.ArpRepeatInterval, tt.want.ArpBackoff)
+			}
+		})
+	}
+}
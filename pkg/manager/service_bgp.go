@@ -0,0 +1,71 @@
+package manager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+	"github.com/kube-vip/kube-vip/pkg/servicepolicy"
+)
+
+// serviceBGPAttributes returns the path attributes of the routes of a service, the annotations of the service take
+// precedence over the policies that select it. It returns nil if the routes have no additional attributes.
+func serviceBGPAttributes(svc *v1.Service, overrides servicepolicy.Overrides) (*bgp.HostAttributes, error) {
+	attrs := &bgp.HostAttributes{}
+	if overrides.BGP != nil {
+		attrs.Communities = overrides.BGP.Communities
+		attrs.LocalPref = overrides.BGP.LocalPref
+	}
+
+	if communities := svc.Annotations[serviceBGPCommunities]; communities != "" {
+		attrs.Communities = nil
+		for _, community := range strings.Split(communities, ",") {
+			attrs.Communities = append(attrs.Communities, strings.TrimSpace(community))
+		}
+	}
+	if localPref := svc.Annotations[serviceBGPLocalPref]; localPref != "" {
+		value, err := strconv.ParseUint(localPref, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("service %s/%s has an invalid BGP local preference [%s]", svc.Namespace, svc.Name, localPref)
+		}
+		attrs.LocalPref = uint32(value)
+	}
+
+	if len(attrs.Communities) == 0 && attrs.LocalPref == 0 {
+		return nil, nil
+	}
+	return attrs, nil
+}
+
+// setBGPAttributes will set the BGP path attributes of the service for each of its VIPs, the service isn't
+// advertised if they are invalid as the routes could otherwise get past the filters of the peers
+func (sm *Manager) setBGPAttributes(instance *Instance, svc *v1.Service, overrides servicepolicy.Overrides) error {
+	if sm.bgpServer == nil {
+		return nil
+	}
+	attrs, err := serviceBGPAttributes(svc, overrides)
+	if err != nil || attrs == nil {
+		return err
+	}
+	for _, vipConfig := range instance.vipConfigs {
+		if err := sm.bgpServer.SetHostAttributes(vipConfig.VIP, attrs); err != nil {
+			return fmt.Errorf("service %s/%s has invalid BGP attributes: %v", svc.Namespace, svc.Name, err)
+		}
+		log.Debugf("[BGP] routes of [%s] are advertised with communities %v and local preference [%d]", vipConfig.VIP, attrs.Communities, attrs.LocalPref)
+	}
+	return nil
+}
+
+// bgpAnnotationsChanged returns if the BGP annotations of a service have changed since its routes were advertised
+func bgpAnnotationsChanged(previous, svc *v1.Service) bool {
+	for _, annotation := range []string{serviceBGPCommunities, serviceBGPLocalPref} {
+		if previous.Annotations[annotation] != svc.Annotations[annotation] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+	"github.com/kube-vip/kube-vip/pkg/servicepolicy"
+)
+
+func TestServiceBGPAttributes(t *testing.T) {
+	policy := servicepolicy.Overrides{BGP: &servicepolicy.BGPOverrides{Communities: []string{"65000:100"}, LocalPref: 100}}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		overrides   servicepolicy.Overrides
+		want        *bgp.HostAttributes
+		wantErr     bool
+	}{
+		{name: "no attributes"},
+		{
+			name:        "annotations",
+			annotations: map[string]string{serviceBGPCommunities: "65000:200, 65000:201", serviceBGPLocalPref: "300"},
+			want:        &bgp.HostAttributes{Communities: []string{"65000:200", "65000:201"}, LocalPref: 300},
+		},
+		{name: "policy", overrides: policy, want: &bgp.HostAttributes{Communities: []string{"65000:100"}, LocalPref: 100}},
+		{
+			name:        "annotations take precedence over the policy",
+			annotations: map[string]string{serviceBGPLocalPref: "50"},
+			overrides:   policy,
+			want:        &bgp.HostAttributes{Communities: []string{"65000:100"}, LocalPref: 50},
+		},
+		{name: "invalid local preference", annotations: map[string]string{serviceBGPLocalPref: "high"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Annotations: tt.annotations}}
+			got, err := serviceBGPAttributes(svc, tt.overrides)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serviceBGPAttributes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("serviceBGPAttributes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBGPAnnotationsChanged(t *testing.T) {
+	previous := &v1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{serviceBGPCommunities: "65000:100", serviceUPNP: "true"}}}
+	if bgpAnnotationsChanged(previous, previous.DeepCopy()) {
+		t.Error("the annotations haven't changed")
+	}
+	svc := previous.DeepCopy()
+	delete(svc.Annotations, serviceUPNP)
+	if bgpAnnotationsChanged(previous, svc) {
+		t.Error("only the BGP annotations re-advertise the routes")
+	}
+	svc.Annotations[serviceBGPLocalPref] = "200"
+	if !bgpAnnotationsChanged(previous, svc) {
+		t.Error("the local preference was added")
+	}
+}
@@ -0,0 +1,36 @@
+package manager
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/ddns"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// startDDNS creates the DDNS provider that publishes the records of the DHCP services with a hostname, the DHCP server
+// registers the hostname itself without one
+func (sm *Manager) startDDNS(ctx context.Context) error {
+	if sm.config.DDNSProvider == "" || sm.config.DDNSProvider == kubevip.DDNSProviderDHCP {
+		return nil
+	}
+	provider, err := ddns.NewFromConfig(ctx, sm.config, sm.clientSet)
+	if err != nil {
+		return err
+	}
+	log.Infof("[ddns] the records of the DHCP services with a hostname are published through %s", provider.Name())
+	sm.ddnsPublisher = &ddns.Publisher{Provider: provider}
+	return nil
+}
+
+// publishServiceDDNS publishes the record of the hostname of a DHCP service with the address of its lease
+func (sm *Manager) publishServiceDDNS(i *Instance) {
+	if sm.ddnsPublisher == nil || !i.isDHCP || i.dhcpHostname == "" || i.dhcpInterfaceIP == "" {
+		return
+	}
+	if err := sm.ddnsPublisher.Publish(context.TODO(), i.dhcpHostname, i.dhcpInterfaceIP); err != nil {
+		log.Errorf("[ddns] unable to publish the record of [%s] with [%s] for service [%s/%s]: %v", i.dhcpHostname,
+			i.dhcpInterfaceIP, i.serviceSnapshot.Namespace, i.serviceSnapshot.Name, err)
+	}
+}
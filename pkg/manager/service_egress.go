@@ -4,11 +4,14 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/kube-vip/kube-vip/pkg/iptables"
 	"github.com/kube-vip/kube-vip/pkg/vip"
+	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -45,19 +48,26 @@ func (sm *Manager) iptablesCheck() error {
 	return nil
 }
 
+// getSameFamilyCidr returns the CIDR that contains the address, or else the first CIDR of the same family as the
+// address, or nothing if there isn't one
 func getSameFamilyCidr(source, ip string) string {
-	cidrs := strings.Split(source, ",")
-	for _, cidr := range cidrs {
-		source = cidr
-		if vip.IsIPv4(cidr) == vip.IsIPv4(ip) {
+	var sameFamily string
+	for _, cidr := range strings.Split(source, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(net.ParseIP(ip)) {
 			return cidr
 		}
+		address, _, _ := strings.Cut(cidr, "/")
+		if sameFamily == "" && vip.IsIPv4(address) == vip.IsIPv4(ip) {
+			sameFamily = cidr
+		}
 	}
-	// return to the default behaviour of setting the CIDR to the first one (or only one)
-	return source
+	return sameFamily
 }
 
-func (sm *Manager) configureEgress(vipIP, podIP, destinationPorts, namespace string) error {
+func (sm *Manager) configureEgress(vipIP, podIP, destinationPorts, snatPorts, excludeCidrs, namespace string) (err error) {
+	defer sm.countEgressRuleError("configure", &err)
+
 	// serviceCIDR, podCIDR, err := sm.AutoDiscoverCIDRs()
 	// if err != nil {
 	// 	serviceCIDR = "10.96.0.0/12"
@@ -68,22 +78,21 @@ func (sm *Manager) configureEgress(vipIP, podIP, destinationPorts, namespace str
 
 	if sm.config.EgressPodCidr != "" {
 		podCidr = getSameFamilyCidr(sm.config.EgressPodCidr, podIP)
-	} else {
-		// There's no default IPv6 pod CIDR, therefore we silently back off if CIDR s not specified.
-		if !vip.IsIPv4(podIP) {
-			return nil
-		}
-		podCidr = defaultPodCIDR
 	}
-
 	if sm.config.EgressServiceCidr != "" {
 		serviceCidr = getSameFamilyCidr(sm.config.EgressServiceCidr, vipIP)
-	} else {
-		// There's no default IPv6 service CIDR, therefore we silently back off if CIDR s not specified.
-		if !vip.IsIPv4(vipIP) {
-			return nil
+	}
+	if vip.IsIPv4(podIP) {
+		if podCidr == "" {
+			podCidr = defaultPodCIDR
+		}
+		if serviceCidr == "" {
+			serviceCidr = defaultServiceCIDR
 		}
-		serviceCidr = defaultServiceCIDR
+	} else if podCidr == "" || serviceCidr == "" {
+		// There are no default IPv6 CIDRs, therefore we back off if they aren't specified.
+		log.Warnf("[egress] no IPv6 pod and service CIDRs are configured (egress_podcidr, egress_servicecidr), pod [%s] will egress from the node address", podIP)
+		return nil
 	}
 
 	protocol := iptables.ProtocolIPv4
@@ -92,10 +101,13 @@ func (sm *Manager) configureEgress(vipIP, podIP, destinationPorts, namespace str
 		protocol = iptables.ProtocolIPv6
 	}
 
-	i, err := vip.CreateIptablesClient(sm.config.EgressWithNftables, namespace, protocol)
+	i, err := vip.NewEgressRules(sm.config.FirewallBackend, sm.config.EgressWithNftables, namespace, protocol)
 	if err != nil {
 		return fmt.Errorf("error Creating iptables client [%s]", err)
 	}
+	if err = i.SetSourceNatPorts(snatPorts); err != nil {
+		return fmt.Errorf("error configuring egress source ports [%s]", err)
+	}
 
 	// Check if the kube-vip mangle chain exists, if not create it
 	exists, err := i.CheckMangleChain(vip.MangleChainName)
@@ -122,39 +134,42 @@ func (sm *Manager) configureEgress(vipIP, podIP, destinationPorts, namespace str
 		mask = "/128"
 	}
 
+	// The excluded destinations are returned ahead of the marking, so that this traffic leaves from the node address
+	exclusions, err := vip.EgressExclusions(excludeCidrs, podIP)
+	if err != nil {
+		return err
+	}
+	for _, cidr := range exclusions {
+		err = i.InsertExclusionRule(vip.MangleChainName, podIP, cidr)
+		if err != nil {
+			return fmt.Errorf("error adding exclusion rules to mangle chain [%s], error [%s]", vip.MangleChainName, err)
+		}
+	}
+
 	err = i.AppendReturnRulesForMarking(vip.MangleChainName, podIP+mask)
 	if err != nil {
 		return fmt.Errorf("error adding marking rules to mangle chain [%s], error [%s]", vip.MangleChainName, err)
 	}
 
-	err = i.InsertMangeTableIntoPrerouting(vip.MangleChainName)
+	err = i.InsertMangleTableIntoPrerouting(vip.MangleChainName)
 	if err != nil {
 		return fmt.Errorf("error adding prerouting mangle chain [%s], error [%s]", vip.MangleChainName, err)
 	}
 
 	if destinationPorts != "" {
-
-		fixedPorts := strings.Split(destinationPorts, ",")
-
-		for _, fixedPort := range fixedPorts {
-			var proto, port string
-
-			data := strings.Split(fixedPort, ":")
-			if len(data) == 0 {
-				continue
-			} else if len(data) == 1 {
-				proto = "tcp"
-				port = data[0]
-			} else {
-				proto = data[0]
-				port = data[1]
-			}
-
-			err = i.InsertSourceNatForDestinationPort(vipIP, podIP, port, proto)
+		ports, err := vip.ParseEgressPorts(destinationPorts)
+		if err != nil {
+			return fmt.Errorf("error parsing the egress ports [%s]", err)
+		}
+		err = i.CleanSourceNatForVIP(vipIP)
+		if err != nil {
+			return fmt.Errorf("error cleaning snat rules of [%s], error [%s]", vipIP, err)
+		}
+		for _, port := range ports {
+			err = i.InsertSourceNatForDestinationPort(vipIP, podIP, port)
 			if err != nil {
 				return fmt.Errorf("error adding snat rules to nat chain [%s], error [%s]", vip.MangleChainName, err)
 			}
-
 		}
 	} else {
 		err = i.InsertSourceNat(vipIP, podIP)
@@ -168,6 +183,14 @@ func (sm *Manager) configureEgress(vipIP, podIP, destinationPorts, namespace str
 		return err
 	}
 
+	sm.addEgressMapping(egressMapping{
+		Namespace:        namespace,
+		PodIP:            podIP,
+		VIP:              vipIP,
+		DestinationPorts: destinationPorts,
+		SNATPorts:        snatPorts,
+		Since:            time.Now(),
+	})
 	return nil
 }
 
@@ -191,46 +214,50 @@ func (sm *Manager) AutoDiscoverCIDRs() (serviceCIDR, podCIDR string, err error)
 	return
 }
 
-func (sm *Manager) TeardownEgress(podIP, vipIP, destinationPorts, namespace string) error {
+func (sm *Manager) TeardownEgress(podIP, vipIP, destinationPorts, snatPorts, excludeCidrs, namespace string) (err error) {
+	defer sm.countEgressRuleError("teardown", &err)
+	sm.removeEgressMapping(podIP, vipIP)
+
 	protocol := iptables.ProtocolIPv4
 	if vip.IsIPv6(podIP) {
 		protocol = iptables.ProtocolIPv6
 	}
 
-	i, err := vip.CreateIptablesClient(sm.config.EgressWithNftables, namespace, protocol)
+	i, err := vip.NewEgressRules(sm.config.FirewallBackend, sm.config.EgressWithNftables, namespace, protocol)
 	if err != nil {
 		return fmt.Errorf("error Creating iptables client [%s]", err)
 	}
+	if err = i.SetSourceNatPorts(snatPorts); err != nil {
+		return fmt.Errorf("error configuring egress source ports [%s]", err)
+	}
 
 	// Remove the marking of egress packets
 	err = i.DeleteMangleMarking(podIP, vip.MangleChainName)
 	if err != nil {
 		return fmt.Errorf("error changing iptables rules for egress [%s]", err)
 	}
+	exclusions, err := vip.EgressExclusions(excludeCidrs, podIP)
+	if err != nil {
+		return err
+	}
+	for _, cidr := range exclusions {
+		err = i.DeleteExclusionRule(vip.MangleChainName, podIP, cidr)
+		if err != nil {
+			return fmt.Errorf("error changing iptables rules for egress [%s]", err)
+		}
+	}
 
 	// Clear up SNAT rules
 	if destinationPorts != "" {
-		fixedPorts := strings.Split(destinationPorts, ",")
-
-		for _, fixedPort := range fixedPorts {
-			var proto, port string
-
-			data := strings.Split(fixedPort, ":")
-			if len(data) == 0 {
-				continue
-			} else if len(data) == 1 {
-				proto = "tcp"
-				port = data[0]
-			} else {
-				proto = data[0]
-				port = data[1]
-			}
-
-			err = i.DeleteSourceNatForDestinationPort(podIP, vipIP, port, proto)
+		ports, err := vip.ParseEgressPorts(destinationPorts)
+		if err != nil {
+			return fmt.Errorf("error parsing the egress ports [%s]", err)
+		}
+		for _, port := range ports {
+			err = i.DeleteSourceNatForDestinationPort(podIP, vipIP, port)
 			if err != nil {
 				return fmt.Errorf("error changing iptables rules for egress [%s]", err)
 			}
-
 		}
 	} else {
 		err = i.DeleteSourceNat(podIP, vipIP)
@@ -242,5 +269,15 @@ func (sm *Manager) TeardownEgress(podIP, vipIP, destinationPorts, namespace stri
 	if err != nil {
 		return fmt.Errorf("error changing iptables rules for egress [%s]", err)
 	}
+	// Once the VIP has left this node, any connection that is still SNATed to it is moved over to the node that holds
+	// the VIP (the connections of the other pods are left alone while the VIP is still here)
+	if local, err := vip.IsLocalAddress(vipIP); err != nil || local {
+		return err
+	}
+	deleted, err := vip.DeleteEgressSessions(vipIP)
+	if err != nil {
+		return fmt.Errorf("error flushing the connections of egress [%s]", err)
+	}
+	log.Infof("[egress] flushed [%d] connections that were SNATed to [%s]", deleted, vipIP)
 	return nil
 }
@@ -36,6 +36,22 @@ func Test_getSameFamilyCidr(t *testing.T) {
 			},
 			want: "10.96.0.0/16",
 		},
+		{
+			name: "This returns the IPv6 cidr of dual-stack cidrs",
+			args: args{
+				source: "10.96.0.0/16, fd00:10:96::/112",
+				ip:     "fd00:10:96::1",
+			},
+			want: "fd00:10:96::/112",
+		},
+		{
+			name: "This returns nothing without a cidr of the family",
+			args: args{
+				source: "10.96.0.0/16",
+				ip:     "fd00:10:96::1",
+			},
+			want: "",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+)
+
+// The condition that kube-vip sets on the services that it advertises, and the reasons of the condition and of the
+// events of the services
+const (
+	advertisedCondition = "kube-vip.io/Advertised"
+	reasonAdvertised    = "VIPAdvertised"
+	reasonWithdrawn     = "VIPWithdrawn"
+)
+
+// startServiceEvents starts the recorder of the events of the services, until kube-vip shuts down
+func (sm *Manager) startServiceEvents() {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: sm.clientSet.CoreV1().Events("")})
+	sm.recorder = broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "kube-vip", Host: sm.config.NodeName})
+	go func() {
+		<-sm.shutdownChan
+		broadcaster.Shutdown()
+	}()
+}
+
+// serviceEvent records an event on the service, nothing is recorded unless the events of the services are enabled
+func (sm *Manager) serviceEvent(svc *v1.Service, reason, message string) {
+	if sm.recorder == nil {
+		return
+	}
+	sm.recorder.Event(svc, v1.EventTypeNormal, reason, message)
+}
+
+// instanceVIPs returns the VIPs that the instance advertises, for a DHCP service this is the address of its lease
+func instanceVIPs(i *Instance) []string {
+	vips := make([]string, 0, len(i.vipConfigs))
+	for _, c := range i.vipConfigs {
+		vips = append(vips, c.VIP)
+	}
+	return vips
+}
+
+// advertisedMessage is the message of the condition and event of the VIPs that are advertised by the node
+func advertisedMessage(vips []string, node string) string {
+	return fmt.Sprintf("VIP %s advertised by node %s", strings.Join(vips, ","), node)
+}
+
+// withdrawnMessage is the message of the condition and event of the VIPs that are withdrawn by the node
+func withdrawnMessage(vips []string, node string) string {
+	return fmt.Sprintf("VIP %s withdrawn by node %s", strings.Join(vips, ","), node)
+}
+
+// setAdvertisedCondition sets the advertised condition of the status of the service, the time of its transition only
+// changes with its status
+func setAdvertisedCondition(status *v1.ServiceStatus, generation int64, advertised bool, reason, message string) {
+	conditionStatus := metav1.ConditionFalse
+	if advertised {
+		conditionStatus = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               advertisedCondition,
+		Status:             conditionStatus,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// advertisedBy returns whether the advertised condition of the service is the one that the node set for the VIPs, a
+// condition that another node has since set (once it has taken over the VIPs) is left as it is
+func advertisedBy(svc *v1.Service, vips []string, node string) bool {
+	condition := meta.FindStatusCondition(svc.Status.Conditions, advertisedCondition)
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.Message == advertisedMessage(vips, node)
+}
+
+// withdrawService records that this node no longer advertises the VIPs of the instance, with an event and with the
+// advertised condition of the service
+func (sm *Manager) withdrawService(i *Instance) {
+	vips := instanceVIPs(i)
+	sm.serviceEvent(i.serviceSnapshot, reasonWithdrawn, withdrawnMessage(vips, sm.config.NodeName))
+	if sm.clientSet == nil || sm.config.DisableServiceUpdates {
+		return
+	}
+
+	cached := true
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		currentService, err := sm.currentService(i, cached)
+		if err != nil {
+			return err
+		}
+		cached = false
+
+		if !advertisedBy(currentService, vips, sm.config.NodeName) {
+			return nil
+		}
+		setAdvertisedCondition(&currentService.Status, currentService.Generation, false, reasonWithdrawn, withdrawnMessage(vips, sm.config.NodeName))
+		_, err = sm.clientSet.CoreV1().Services(currentService.Namespace).UpdateStatus(context.TODO(), currentService, metav1.UpdateOptions{})
+		return err
+	})
+	// A service that has been deleted has nothing left to update
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Warnf("error withdrawing the advertised condition of service %s/%s: %v", i.serviceSnapshot.Namespace, i.serviceSnapshot.Name, err)
+	}
+}
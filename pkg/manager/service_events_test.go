@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+func TestAdvertisedCondition(t *testing.T) {
+	vips := []string{"192.168.0.10", "fd00::10"}
+	svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", Generation: 3}}
+
+	setAdvertisedCondition(&svc.Status, svc.Generation, true, reasonAdvertised, advertisedMessage(vips, "node-1"))
+	condition := meta.FindStatusCondition(svc.Status.Conditions, advertisedCondition)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.ObservedGeneration != 3 {
+		t.Fatalf("advertised condition = %v, want it true for generation 3", condition)
+	}
+	if condition.Message != "VIP 192.168.0.10,fd00::10 advertised by node node-1" {
+		t.Errorf("advertised condition message = %q, want the VIPs and the node", condition.Message)
+	}
+	if !advertisedBy(svc, vips, "node-1") {
+		t.Error("advertisedBy() = false for the node that advertises the VIPs")
+	}
+	if advertisedBy(svc, vips, "node-2") {
+		t.Error("advertisedBy() = true for a node that doesn't advertise the VIPs")
+	}
+	if advertisedBy(svc, vips[:1], "node-1") {
+		t.Error("advertisedBy() = true for VIPs that the node doesn't advertise")
+	}
+
+	// Another node taking over the VIPs replaces the condition, rather than adding one
+	setAdvertisedCondition(&svc.Status, svc.Generation, true, reasonAdvertised, advertisedMessage(vips, "node-2"))
+	if len(svc.Status.Conditions) != 1 || advertisedBy(svc, vips, "node-1") || !advertisedBy(svc, vips, "node-2") {
+		t.Errorf("conditions = %v, want the one condition of node-2", svc.Status.Conditions)
+	}
+
+	setAdvertisedCondition(&svc.Status, svc.Generation, false, reasonWithdrawn, withdrawnMessage(vips, "node-2"))
+	if advertisedBy(svc, vips, "node-2") {
+		t.Errorf("conditions = %v, want the VIPs withdrawn", svc.Status.Conditions)
+	}
+}
+
+func TestServiceEvents(t *testing.T) {
+	svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}}
+	instance := &Instance{
+		vipConfigs:      []*kubevip.Config{{VIP: "192.168.0.10"}},
+		serviceSnapshot: svc,
+	}
+
+	// Without a recorder the events aren't recorded
+	sm := &Manager{config: &kubevip.Config{NodeName: "node-1"}}
+	sm.serviceEvent(svc, reasonAdvertised, advertisedMessage(instanceVIPs(instance), sm.config.NodeName))
+
+	recorder := record.NewFakeRecorder(2)
+	sm.recorder = recorder
+	sm.serviceEvent(svc, reasonAdvertised, advertisedMessage(instanceVIPs(instance), sm.config.NodeName))
+	sm.withdrawService(instance)
+
+	for _, want := range []string{
+		"Normal VIPAdvertised VIP 192.168.0.10 advertised by node node-1",
+		"Normal VIPWithdrawn VIP 192.168.0.10 withdrawn by node node-1",
+	} {
+		if got := <-recorder.Events; got != want {
+			t.Errorf("event = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package manager
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/cluster"
+	"github.com/kube-vip/kube-vip/pkg/handover"
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+// servicesHandover holds the VIPs of the services that the previous kube-vip on the node handed over, until they are
+// advertised again by this one or released
+type servicesHandover struct {
+	mutex sync.Mutex
+	vips  []handover.VIP
+}
+
+func (h *servicesHandover) set(vips []handover.VIP) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.vips = vips
+}
+
+// adopt takes the VIPs of the cluster of a service out of the ones that were handed over, and returns them
+func (h *servicesHandover) adopt(c *cluster.Cluster) []handover.VIP {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	var adopted []handover.VIP
+	for _, v := range c.HandoverVIPs() {
+		for i := range h.vips {
+			if h.vips[i].Address == v.Address && h.vips[i].Interface == v.Interface {
+				adopted = append(adopted, v)
+				h.vips = append(h.vips[:i], h.vips[i+1:]...)
+				break
+			}
+		}
+	}
+	return adopted
+}
+
+// release removes the VIPs that weren't advertised again, their services are now held by another node (or were deleted)
+func (h *servicesHandover) release() {
+	h.mutex.Lock()
+	vips := h.vips
+	h.vips = nil
+	h.mutex.Unlock()
+	for _, v := range vips {
+		log.Infof("[handover] removing the VIP [%s] from [%s], it isn't advertised by this node", v.Address, v.Interface)
+		if _, err := vip.GarbageCollect(v.Interface, v.Address); err != nil {
+			log.Warnf("[handover] %v", err)
+		}
+	}
+}
+
+// takeServicesHandover keeps the VIPs of the services that the previous kube-vip on the node handed over, the ones
+// that aren't advertised again by the time that the leases they were handed over with expire are removed
+func (sm *Manager) takeServicesHandover() {
+	if !sm.config.Handover {
+		return
+	}
+	leaseDuration := time.Duration(sm.config.LeaseDuration) * time.Second
+	state, err := handover.Take(handover.ServicesPath(sm.config.HandoverPath), sm.config.NodeName, leaseDuration, time.Now())
+	if err != nil {
+		log.Warnf("[handover] ignoring the services of the previous kube-vip: %v", err)
+		return
+	}
+	if state == nil || len(state.VIPs) == 0 {
+		return
+	}
+	log.Infof("[handover] the previous kube-vip handed over %d VIPs of services", len(state.VIPs))
+	sm.handedOver.set(state.VIPs)
+	time.AfterFunc(leaseDuration, sm.handedOver.release)
+}
+
+// writeServicesHandover hands the VIPs of the services that this node advertises over to the kube-vip that replaces
+// this one, and leaves them on their interfaces when the services are stopped. The VIPs of services with DHCP, and
+// the routes and BGP advertisements of services, are withdrawn as before.
+func (sm *Manager) writeServicesHandover() {
+	state := &handover.State{
+		Node:    sm.config.NodeName,
+		Written: time.Now(),
+	}
+	if !sm.config.EnableServicesElection {
+		state.Lease = sm.config.ServicesLeaseName
+	}
+	var clusters []*cluster.Cluster
+	for _, instance := range sm.instances() {
+		if instance.isDHCP {
+			continue
+		}
+		for x := range instance.clusters {
+			if instance.vipConfigs[x].EnableRoutingTable || instance.vipConfigs[x].EnableBGP {
+				continue
+			}
+			clusters = append(clusters, instance.clusters[x])
+			state.VIPs = append(state.VIPs, instance.clusters[x].HandoverVIPs()...)
+		}
+	}
+	if len(state.VIPs) == 0 {
+		return
+	}
+	path := handover.ServicesPath(sm.config.HandoverPath)
+	if err := handover.Write(path, state); err != nil {
+		log.Errorf("[handover] unable to hand the VIPs of the services over, releasing them: %v", err)
+		return
+	}
+	for _, c := range clusters {
+		c.HandOver()
+	}
+	log.Infof("[handover] %d VIPs of services are handed over in [%s]", len(state.VIPs), path)
+}
@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kube-vip/kube-vip/pkg/cluster"
+	"github.com/kube-vip/kube-vip/pkg/handover"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+func TestServicesHandover(t *testing.T) {
+	config := &kubevip.Config{
+		NodeName:                 "node-0",
+		Handover:                 true,
+		HandoverPath:             filepath.Join(t.TempDir(), "handover.json"),
+		EnableARP:                true,
+		KubernetesLeaderElection: kubevip.KubernetesLeaderElection{LeaseDuration: 5},
+	}
+	newCluster := func(address string, routingTable bool) (*kubevip.Config, *cluster.Cluster) {
+		vipConfig := *config
+		vipConfig.VIP = address
+		vipConfig.Interface = "lo"
+		vipConfig.EnableRoutingTable = routingTable
+		c, err := cluster.InitCluster(&vipConfig, false)
+		if err != nil {
+			t.Skipf("the loopback interface isn't available: %v", err)
+		}
+		return &vipConfig, c
+	}
+
+	sm := &Manager{config: config}
+	arpConfig, arpCluster := newCluster("192.168.0.50", false)
+	tableConfig, tableCluster := newCluster("192.168.0.51", true)
+	dhcpConfig, dhcpCluster := newCluster("192.168.0.52", false)
+	sm.storeServiceInstance(&Instance{UID: "arp", VIPs: []string{"192.168.0.50"}, vipConfigs: []*kubevip.Config{arpConfig}, clusters: []*cluster.Cluster{arpCluster}})
+	sm.storeServiceInstance(&Instance{UID: "table", VIPs: []string{"192.168.0.51"}, vipConfigs: []*kubevip.Config{tableConfig}, clusters: []*cluster.Cluster{tableCluster}})
+	sm.storeServiceInstance(&Instance{UID: "dhcp", VIPs: []string{"192.168.0.52"}, isDHCP: true, vipConfigs: []*kubevip.Config{dhcpConfig}, clusters: []*cluster.Cluster{dhcpCluster}})
+
+	sm.writeServicesHandover()
+
+	// Only the VIP of the service in ARP mode is handed over
+	state, err := handover.Take(handover.ServicesPath(config.HandoverPath), config.NodeName, time.Minute, time.Now())
+	if err != nil || state == nil {
+		t.Fatalf("Take() = %v, %v", state, err)
+	}
+	if len(state.VIPs) != 1 || state.VIPs[0].Address != "192.168.0.50" || state.VIPs[0].Interface != "lo" {
+		t.Fatalf("unexpected VIPs handed over %+v", state.VIPs)
+	}
+
+	// The replacement keeps the VIP for the service that it advertises again, and only once
+	replacement := &Manager{config: config}
+	replacement.handedOver.set(state.VIPs)
+	if adopted := replacement.handedOver.adopt(tableCluster); len(adopted) != 0 {
+		t.Errorf("adopt() = %+v for a VIP that wasn't handed over", adopted)
+	}
+	if adopted := replacement.handedOver.adopt(arpCluster); len(adopted) != 1 {
+		t.Errorf("adopt() = %+v, want the VIP that was handed over", adopted)
+	}
+	if adopted := replacement.handedOver.adopt(arpCluster); len(adopted) != 0 {
+		t.Errorf("adopt() = %+v, the VIP was adopted twice", adopted)
+	}
+}
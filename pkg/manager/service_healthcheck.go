@@ -0,0 +1,193 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/kube-vip/kube-vip/pkg/healthcheck"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// serviceHealthProbe returns the probe of the endpoints of a service from its annotations, nil if the endpoints
+// aren't health checked. The port defaults to the target port of the first port of the service.
+func serviceHealthProbe(svc *v1.Service) (*healthcheck.Probe, error) {
+	probeType := svc.Annotations[serviceHealthCheck]
+	if probeType == "" {
+		return nil, nil
+	}
+	probe := &healthcheck.Probe{Type: healthcheck.Type(probeType), Path: svc.Annotations[serviceHealthCheckPath]}
+
+	if port := svc.Annotations[serviceHealthCheckPort]; port != "" {
+		value, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("service %s/%s has an invalid health check port [%s]", svc.Namespace, svc.Name, port)
+		}
+		probe.Port = value
+	} else if len(svc.Spec.Ports) != 0 {
+		probe.Port = svc.Spec.Ports[0].TargetPort.IntValue()
+		if probe.Port == 0 {
+			probe.Port = int(svc.Spec.Ports[0].Port)
+		}
+	}
+
+	for annotation, duration := range map[string]*time.Duration{serviceHealthInterval: &probe.Interval, serviceHealthTimeout: &probe.Timeout} {
+		if value := svc.Annotations[annotation]; value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("service %s/%s has an invalid %s [%s]", svc.Namespace, svc.Name, annotation, value)
+			}
+			*duration = d
+		}
+	}
+	for annotation, count := range map[string]*int{serviceHealthRise: &probe.Rise, serviceHealthFall: &probe.Fall} {
+		if value := svc.Annotations[annotation]; value != "" {
+			i, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("service %s/%s has an invalid %s [%s]", svc.Namespace, svc.Name, annotation, value)
+			}
+			*count = i
+		}
+	}
+
+	if err := probe.Validate(); err != nil {
+		return nil, fmt.Errorf("service %s/%s: %v", svc.Namespace, svc.Name, err)
+	}
+	return probe, nil
+}
+
+// healthCheckedProvider only returns the endpoints that pass the health checks of the service, so that a node without
+// a healthy endpoint stops advertising the service and its VIP fails over to a node with one
+type healthCheckedProvider struct {
+	epProvider
+	ctx     context.Context
+	checker *healthcheck.Checker
+
+	// local is the node whose endpoints are probed, all of the endpoints are probed if it is empty
+	local  string
+	config *kubevip.Config
+}
+
+// healthCheckedProvider returns the provider with the health checks of the service, or the provider as it is if the
+// service has none
+func (sm *Manager) healthCheckedProvider(ctx context.Context, svc *v1.Service, provider epProvider) epProvider {
+	probe, err := serviceHealthProbe(svc)
+	if err != nil {
+		log.Errorf("(healthcheck) %v, the endpoints aren't health checked", err)
+		return provider
+	}
+	if probe == nil {
+		return provider
+	}
+	log.Infof("(healthcheck) [%s/%s] endpoints are probed with %s on port [%d] every [%s]", svc.Namespace, svc.Name, probe.Type, probe.Port, probe.Interval)
+
+	p := &healthCheckedProvider{epProvider: provider, ctx: ctx, checker: healthcheck.NewChecker(*probe), config: sm.config}
+	if !sm.usesAllEndpoints(svc) {
+		p.local = sm.config.NodeName
+	}
+	return p
+}
+
+// createWatcher also sends the endpoints again whenever one of them becomes healthy or unhealthy
+func (p *healthCheckedProvider) createWatcher(sm *Manager, service *v1.Service) (watch.Interface, error) {
+	w, err := p.epProvider.createWatcher(sm, service)
+	if err != nil {
+		return nil, err
+	}
+	return newHealthWatch(w, p.checker), nil
+}
+
+// loadObject starts probing the endpoints that are advertised from
+func (p *healthCheckedProvider) loadObject(obj runtime.Object, cancel context.CancelFunc) error {
+	if err := p.epProvider.loadObject(obj, cancel); err != nil {
+		return err
+	}
+	var endpoints []string
+	var err error
+	if p.local == "" {
+		endpoints, err = p.epProvider.getAllEndpoints()
+	} else {
+		endpoints, err = p.epProvider.getLocalEndpoints(p.local, p.config)
+	}
+	if err != nil {
+		return err
+	}
+	p.checker.Set(p.ctx, endpoints)
+	return nil
+}
+
+func (p *healthCheckedProvider) getAllEndpoints() ([]string, error) {
+	endpoints, err := p.epProvider.getAllEndpoints()
+	return p.checker.Filter(endpoints), err
+}
+
+func (p *healthCheckedProvider) getLocalEndpoints(id string, c *kubevip.Config) ([]string, error) {
+	endpoints, err := p.epProvider.getLocalEndpoints(id, c)
+	return p.checker.Filter(endpoints), err
+}
+
+// healthWatch forwards the events of the endpoints, and sends the last endpoints again when their health changes
+type healthWatch struct {
+	w       watch.Interface
+	result  chan watch.Event
+	stop    chan struct{}
+	stopped sync.Once
+}
+
+func newHealthWatch(w watch.Interface, checker *healthcheck.Checker) *healthWatch {
+	h := &healthWatch{w: w, result: make(chan watch.Event), stop: make(chan struct{})}
+	go func() {
+		defer close(h.result)
+		defer checker.Stop()
+
+		var last runtime.Object
+		for {
+			var event watch.Event
+			select {
+			case e, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+				switch e.Type {
+				case watch.Added, watch.Modified:
+					last = e.Object
+				case watch.Deleted:
+					last = nil
+				}
+				event = e
+			case <-checker.Changed():
+				if last == nil {
+					continue
+				}
+				event = watch.Event{Type: watch.Modified, Object: last}
+			case <-h.stop:
+				return
+			}
+
+			select {
+			case h.result <- event:
+			case <-h.stop:
+				return
+			}
+		}
+	}()
+	return h
+}
+
+// Stop stops the watch of the endpoints and the health checks
+func (h *healthWatch) Stop() {
+	h.stopped.Do(func() { close(h.stop) })
+	h.w.Stop()
+}
+
+// ResultChan returns the events of the endpoints
+func (h *healthWatch) ResultChan() <-chan watch.Event {
+	return h.result
+}
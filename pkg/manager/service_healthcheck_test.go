@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/kube-vip/kube-vip/pkg/healthcheck"
+)
+
+func TestServiceHealthProbe(t *testing.T) {
+	ports := []v1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		ports       []v1.ServicePort
+		want        *healthcheck.Probe
+		wantErr     bool
+	}{
+		{name: "no health check", ports: ports},
+		{
+			name:        "target port",
+			annotations: map[string]string{serviceHealthCheck: "http", serviceHealthCheckPath: "/ready"},
+			ports:       ports,
+			want:        &healthcheck.Probe{Type: healthcheck.HTTP, Port: 8080, Path: "/ready", Interval: healthcheck.DefaultInterval, Timeout: healthcheck.DefaultTimeout, Rise: healthcheck.DefaultRise, Fall: healthcheck.DefaultFall},
+		},
+		{
+			name:        "named target port",
+			annotations: map[string]string{serviceHealthCheck: "tcp"},
+			ports:       []v1.ServicePort{{Port: 443, TargetPort: intstr.FromString("https")}},
+			want:        &healthcheck.Probe{Type: healthcheck.TCP, Port: 443, Interval: healthcheck.DefaultInterval, Timeout: healthcheck.DefaultTimeout, Rise: healthcheck.DefaultRise, Fall: healthcheck.DefaultFall},
+		},
+		{
+			name: "every setting",
+			annotations: map[string]string{serviceHealthCheck: "grpc", serviceHealthCheckPort: "9090", serviceHealthCheckPath: "backend",
+				serviceHealthInterval: "10s", serviceHealthTimeout: "1s", serviceHealthRise: "1", serviceHealthFall: "5"},
+			ports: ports,
+			want:  &healthcheck.Probe{Type: healthcheck.GRPC, Port: 9090, Path: "backend", Interval: 10 * time.Second, Timeout: time.Second, Rise: 1, Fall: 5},
+		},
+		{name: "invalid port", annotations: map[string]string{serviceHealthCheck: "tcp", serviceHealthCheckPort: "http"}, ports: ports, wantErr: true},
+		{name: "invalid interval", annotations: map[string]string{serviceHealthCheck: "tcp", serviceHealthInterval: "5"}, ports: ports, wantErr: true},
+		{name: "invalid type", annotations: map[string]string{serviceHealthCheck: "udp"}, ports: ports, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Annotations: tt.annotations}, Spec: v1.ServiceSpec{Ports: tt.ports}}
+			got, err := serviceHealthProbe(svc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serviceHealthProbe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("serviceHealthProbe() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthWatch(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	checker := healthcheck.NewChecker(healthcheck.Probe{Type: healthcheck.TCP, Port: l.Addr().(*net.TCPAddr).Port, Interval: 10 * time.Millisecond, Timeout: 5 * time.Millisecond, Rise: 1, Fall: 1})
+	fake := watch.NewFake()
+	h := newHealthWatch(fake, checker)
+	defer h.Stop()
+
+	endpoints := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	go fake.Add(endpoints)
+	if event := <-h.ResultChan(); event.Type != watch.Added || event.Object != endpoints {
+		t.Fatalf("event = %v, want the added endpoints", event)
+	}
+
+	// The endpoints are sent again once the endpoint is healthy
+	checker.Set(context.Background(), []string{"127.0.0.1"})
+	select {
+	case event := <-h.ResultChan():
+		if event.Type != watch.Modified || event.Object != endpoints {
+			t.Errorf("event = %v, want the endpoints again", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the endpoints weren't sent again when the endpoint became healthy")
+	}
+	if !checker.Healthy("127.0.0.1") {
+		t.Error("the endpoint isn't healthy")
+	}
+}
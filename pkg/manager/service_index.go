@@ -0,0 +1,97 @@
+package manager
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// serviceIndex holds the instances of the advertised services by their UID, and how many of them share each VIP, so
+// that finding, adding and removing a service doesn't scan every other service (there can be thousands of them)
+type serviceIndex struct {
+	instances map[string]*Instance
+	vips      map[string]int
+}
+
+func (s *serviceIndex) add(i *Instance) {
+	if s.instances == nil {
+		s.instances = make(map[string]*Instance)
+		s.vips = make(map[string]int)
+	}
+	if previous, found := s.instances[i.UID]; found {
+		s.release(previous)
+	}
+	s.instances[i.UID] = i
+	for _, vip := range i.VIPs {
+		s.vips[vip]++
+	}
+}
+
+// remove takes the instance of the service out of the index, and returns it with whether its VIPs are still used by
+// another service
+func (s *serviceIndex) remove(uid string) (*Instance, bool) {
+	i, found := s.instances[uid]
+	if !found {
+		return nil, false
+	}
+	delete(s.instances, uid)
+	s.release(i)
+
+	shared := false
+	for _, vip := range i.VIPs {
+		if s.vips[vip] > 0 {
+			shared = true
+		}
+	}
+	return i, shared
+}
+
+func (s *serviceIndex) release(i *Instance) {
+	for _, vip := range i.VIPs {
+		if s.vips[vip] <= 1 {
+			delete(s.vips, vip)
+			continue
+		}
+		s.vips[vip]--
+	}
+}
+
+// storeServiceInstance adds the instance of a service that is now advertised
+func (sm *Manager) storeServiceInstance(i *Instance) {
+	sm.instancesMutex.Lock()
+	defer sm.instancesMutex.Unlock()
+	sm.serviceInstances.add(i)
+}
+
+// removeServiceInstance removes the instance of a service, it returns nil if the service isn't advertised
+func (sm *Manager) removeServiceInstance(uid string) (*Instance, bool) {
+	sm.instancesMutex.Lock()
+	defer sm.instancesMutex.Unlock()
+	return sm.serviceInstances.remove(uid)
+}
+
+// instances returns the instances of the advertised services, they can be iterated while services are added or removed
+func (sm *Manager) instances() []*Instance {
+	sm.instancesMutex.RLock()
+	defer sm.instancesMutex.RUnlock()
+	instances := make([]*Instance, 0, len(sm.serviceInstances.instances))
+	for _, i := range sm.serviceInstances.instances {
+		instances = append(instances, i)
+	}
+	return instances
+}
+
+func (sm *Manager) findServiceInstance(svc *v1.Service) *Instance {
+	return sm.serviceInstance(string(svc.UID))
+}
+
+func (sm *Manager) serviceInstance(uid string) *Instance {
+	sm.instancesMutex.RLock()
+	defer sm.instancesMutex.RUnlock()
+	return sm.serviceInstances.instances[uid]
+}
+
+// serviceCount returns the number of advertised services
+func (sm *Manager) serviceCount() int {
+	sm.instancesMutex.RLock()
+	defer sm.instancesMutex.RUnlock()
+	return len(sm.serviceInstances.instances)
+}
@@ -0,0 +1,204 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+// scaleServices is the number of LoadBalancer services that a cluster is expected to be able to advertise
+const scaleServices = 5000
+
+func TestServiceIndex(t *testing.T) {
+	var index serviceIndex
+	index.add(&Instance{UID: "web", VIPs: []string{"192.168.0.10"}})
+	index.add(&Instance{UID: "web-udp", VIPs: []string{"192.168.0.10"}})
+	index.add(&Instance{UID: "api", VIPs: []string{"192.168.0.11", "fd00::11"}})
+
+	if i, shared := index.remove("web"); i == nil || !shared {
+		t.Errorf("remove(web) = %v, %t, the VIP is still used by web-udp", i, shared)
+	}
+	if i, shared := index.remove("web-udp"); i == nil || shared {
+		t.Errorf("remove(web-udp) = %v, %t, the VIP is no longer used", i, shared)
+	}
+	if i, _ := index.remove("web"); i != nil {
+		t.Errorf("remove(web) = %v after it was removed", i)
+	}
+
+	// Storing a service again replaces its VIPs
+	index.add(&Instance{UID: "api", VIPs: []string{"192.168.0.12"}})
+	if len(index.vips) != 1 || index.vips["192.168.0.12"] != 1 {
+		t.Errorf("unexpected VIPs %v", index.vips)
+	}
+}
+
+func scaleManager(services int) (*Manager, []*v1.Service) {
+	sm := &Manager{config: &kubevip.Config{}}
+	svcs := make([]*v1.Service, 0, services)
+	for n := 0; n < services; n++ {
+		address := fmt.Sprintf("10.%d.%d.%d", n>>16, (n>>8)&0xff, n&0xff)
+		svc := &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("svc-%d", n), Namespace: "default", UID: types.UID(fmt.Sprintf("uid-%d", n))},
+			Spec:       v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer, Ports: []v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}}},
+			Status: v1.ServiceStatus{LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{
+				IP:    address,
+				Ports: []v1.PortStatus{{Port: 80, Protocol: v1.ProtocolTCP}},
+			}}}},
+		}
+		svcs = append(svcs, svc)
+		sm.storeServiceInstance(&Instance{UID: string(svc.UID), VIPs: []string{address}, serviceSnapshot: svc})
+	}
+	return sm, svcs
+}
+
+func BenchmarkFindServiceInstance(b *testing.B) {
+	sm, svcs := scaleManager(scaleServices)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if sm.findServiceInstance(svcs[n%len(svcs)]) == nil {
+			b.Fatal("the service wasn't found")
+		}
+	}
+}
+
+// BenchmarkResyncServices synchronises all the services that are already advertised, as happens on every resync and
+// when the services are listed again after a failover of the API server
+func BenchmarkResyncServices(b *testing.B) {
+	level := log.GetLevel()
+	log.SetLevel(log.WarnLevel)
+	defer log.SetLevel(level)
+
+	sm, svcs := scaleManager(scaleServices)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		var wg sync.WaitGroup
+		for _, svc := range svcs {
+			wg.Add(1)
+			if err := sm.syncServices(context.Background(), svc, &wg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkDeleteService removes a service while the others are still advertised, and checks that their VIPs aren't
+// shared with it
+func BenchmarkDeleteService(b *testing.B) {
+	level := log.GetLevel()
+	log.SetLevel(log.WarnLevel)
+	defer log.SetLevel(level)
+
+	sm, svcs := scaleManager(scaleServices)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		svc := svcs[n%len(svcs)]
+		if err := sm.deleteService(string(svc.UID)); err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+		sm.storeServiceInstance(&Instance{UID: string(svc.UID), VIPs: fetchServiceAddresses(svc), serviceSnapshot: svc})
+		b.StartTimer()
+	}
+}
+
+// gratuitousSent returns the gratuitous ARPs that have been sent via the interface
+func gratuitousSent(b *testing.B, registry *prometheus.Registry, iface string) float64 {
+	families, err := registry.Gather()
+	if err != nil {
+		b.Fatal(err)
+	}
+	var sent float64
+	for _, family := range families {
+		if family.GetName() != "kube_vip_advertisement_gratuitous_sent_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "interface" && label.GetValue() == iface {
+					sent += metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return sent
+}
+
+// BenchmarkFailover takes over the VIPs of all the services, as a new leader does after a failover: the services are
+// synchronised by the workers of the services watcher until every VIP has been added to the interface and announced
+// with a gratuitous ARP. It needs a veth pair, so it is skipped without the privileges to create one.
+func BenchmarkFailover(b *testing.B) {
+	level := log.GetLevel()
+	log.SetLevel(log.ErrorLevel)
+	defer log.SetLevel(level)
+
+	veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "kvbench0"}, PeerName: "kvbench1"}
+	if err := netlink.LinkAdd(veth); err != nil {
+		b.Skipf("a veth pair can't be created: %v", err)
+	}
+	defer func() {
+		_ = netlink.LinkDel(veth)
+	}()
+	if err := netlink.LinkSetUp(veth); err != nil {
+		b.Fatal(err)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(vip.AdvertisementMetrics()...)
+	_, svcs := scaleManager(scaleServices)
+	const workers = 4
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		sm := &Manager{config: &kubevip.Config{EnableARP: true, Interface: veth.Name, DisableServiceUpdates: true}}
+		announced := gratuitousSent(b, registry, veth.Name) + scaleServices
+		queue := make(chan *v1.Service, len(svcs))
+		for _, svc := range svcs {
+			queue <- svc
+		}
+		close(queue)
+		b.StartTimer()
+
+		var workersGroup sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			workersGroup.Add(1)
+			go func() {
+				defer workersGroup.Done()
+				for svc := range queue {
+					var wg sync.WaitGroup
+					wg.Add(1)
+					if err := sm.syncServices(context.Background(), svc, &wg); err != nil {
+						b.Error(err)
+					}
+				}
+			}()
+		}
+		workersGroup.Wait()
+		for gratuitousSent(b, registry, veth.Name) < announced {
+			time.Sleep(time.Millisecond)
+		}
+
+		b.StopTimer()
+		for _, instance := range sm.instances() {
+			for _, cluster := range instance.clusters {
+				cluster.Stop()
+			}
+		}
+		b.StartTimer()
+	}
+}
@@ -0,0 +1,89 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/kube-vip/kube-vip/pkg/ipam"
+)
+
+// ipamInterval is how often the allocations of the ip pools are reconciled with the services
+const ipamInterval = 30 * time.Second
+
+// startIPAM will begin allocating the addresses of the LoadBalancer services without one from the KubeVipIPPool
+// resources, and releasing the allocations of the services that have been removed
+func (sm *Manager) startIPAM() {
+	if !sm.config.EnableIPAM || !sm.config.EnableServices || sm.clientSet == nil {
+		return
+	}
+
+	sm.ipam = ipam.NewController(sm.clientSet, sm.config.Namespace, sm.config.ServiceNamespace)
+	informer := sm.informers.Core().V1().Services().Informer()
+	sm.startInformers()
+
+	log.Infof("(ipam) allocating the addresses of services from the ip pools, the global pools are in [%s]", sm.config.Namespace)
+	go func() {
+		// The allocations of the services that haven't been listed yet would be released
+		if !cache.WaitForCacheSync(sm.shutdownChan, informer.HasSynced) {
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-sm.shutdownChan
+			cancel()
+		}()
+		sm.ipam.Poll(ctx, ipamInterval, sm.serviceAddresses)
+	}()
+}
+
+// serviceAddresses returns the addresses of every LoadBalancer service, by namespace/name
+func (sm *Manager) serviceAddresses() map[string][]string {
+	services, err := sm.informers.Core().V1().Services().Lister().List(labels.Everything())
+	if err != nil {
+		log.Errorf("(ipam) unable to list the services: %v", err)
+		return nil
+	}
+	addresses := map[string][]string{}
+	for _, svc := range services {
+		if svc.Spec.Type == v1.ServiceTypeLoadBalancer {
+			addresses[ipam.ServiceKey(svc)] = fetchServiceAddresses(svc)
+		}
+	}
+	return addresses
+}
+
+// allocateServiceAddresses allocates the addresses of a service from the ip pools and adds them to the service, which
+// is then advertised as any other service once it has been modified
+func (sm *Manager) allocateServiceAddresses(svc *v1.Service) error {
+	addresses, err := sm.ipam.Allocate(context.TODO(), svc, sm.serviceAddresses())
+	if err != nil {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		currentService, err := sm.clientSet.CoreV1().Services(svc.Namespace).Get(context.TODO(), svc.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		// Another node has already added the same allocation
+		if len(fetchServiceAddresses(currentService)) != 0 {
+			return nil
+		}
+
+		currentServiceCopy := currentService.DeepCopy()
+		if currentServiceCopy.Annotations == nil {
+			currentServiceCopy.Annotations = make(map[string]string)
+		}
+		currentServiceCopy.Annotations[loadbalancerIPAnnotation] = strings.Join(addresses, ",")
+		_, err = sm.clientSet.CoreV1().Services(currentService.Namespace).Update(context.TODO(), currentServiceCopy, metav1.UpdateOptions{})
+		return err
+	})
+}
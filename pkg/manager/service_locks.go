@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"net"
+	"slices"
+	"sync"
+)
+
+// keyedMutex serialises the reconciles that work on the same keys (the UID of a service and its VIPs), while the
+// reconciles of independent services run at the same time, so that a slow DHCP or BGP operation only holds up the
+// services that it would conflict with
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	// The reconciles that hold or wait for the lock, it is dropped once there are none
+	refs int
+}
+
+// lock locks all the keys and returns the function that unlocks them. The keys are always locked in the same order,
+// so that two reconciles that share several keys can't deadlock.
+func (k *keyedMutex) lock(keys ...string) func() {
+	keys = slices.Clone(keys)
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	locks := make([]*keyedLock, 0, len(keys))
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyedLock)
+	}
+	for _, key := range keys {
+		l, found := k.locks[key]
+		if !found {
+			l = &keyedLock{}
+			k.locks[key] = l
+		}
+		l.refs++
+		locks = append(locks, l)
+	}
+	k.mu.Unlock()
+
+	for _, l := range locks {
+		l.Lock()
+	}
+
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		for i, key := range keys {
+			if locks[i].refs--; locks[i].refs == 0 {
+				delete(k.locks, key)
+			}
+		}
+	}
+}
+
+// lockService locks the UID of a service and its VIPs, the unspecified address of the services that get their VIP
+// with DHCP isn't shared between them so it isn't locked
+func (sm *Manager) lockService(uid string, vips ...[]string) func() {
+	keys := []string{"uid/" + uid}
+	for _, addresses := range vips {
+		for _, address := range addresses {
+			if ip := net.ParseIP(address); ip != nil && ip.IsUnspecified() {
+				continue
+			}
+			keys = append(keys, "vip/"+address)
+		}
+	}
+	return sm.serviceLocks.lock(keys...)
+}
@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	var k keyedMutex
+
+	// The services that don't share a key are reconciled at the same time
+	unlockWeb := k.lock("uid/web", "vip/192.168.0.10")
+	done := make(chan struct{})
+	go func() {
+		k.lock("uid/api", "vip/192.168.0.11")()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("an independent service waited for the lock of another")
+	}
+
+	// A service that shares the VIP waits for the other one
+	locked := make(chan struct{})
+	go func() {
+		k.lock("uid/web-udp", "vip/192.168.0.10")()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("a service that shares the VIP was reconciled at the same time")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockWeb()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the lock of the VIP wasn't released")
+	}
+
+	if len(k.locks) != 0 {
+		t.Errorf("the locks weren't dropped once they were released: %v", k.locks)
+	}
+}
+
+func TestKeyedMutexOrder(t *testing.T) {
+	// Reconciles that share several keys, listed in a different order, don't deadlock
+	var k keyedMutex
+	var wg sync.WaitGroup
+	for n := 0; n < 100; n++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			k.lock("vip/a", "vip/b", "uid/1")()
+		}()
+		go func() {
+			defer wg.Done()
+			k.lock("uid/2", "vip/b", "vip/a", "vip/a")()
+		}()
+	}
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the reconciles deadlocked")
+	}
+}
+
+func TestLockServiceDHCP(t *testing.T) {
+	// The services that get their VIP with DHCP don't wait for each other
+	sm := &Manager{}
+	unlock := sm.lockService("a", []string{"0.0.0.0"})
+	defer unlock()
+	done := make(chan struct{})
+	go func() {
+		sm.lockService("b", []string{"0.0.0.0"})()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a DHCP service waited for another")
+	}
+}
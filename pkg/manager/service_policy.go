@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/features"
+	"github.com/kube-vip/kube-vip/pkg/servicepolicy"
+	"github.com/kube-vip/kube-vip/pkg/signature"
+)
+
+// servicePolicyInterval is how often the service policies are refreshed from the cluster
+const servicePolicyInterval = 30 * time.Second
+
+// startServicePolicies will begin loading the KubeVipServicePolicy resources, these are only used when the
+// CRDConfig feature gate is enabled
+func (sm *Manager) startServicePolicies() error {
+	if !features.DefaultFeatureGate.Enabled(features.CRDConfig) || !sm.config.EnableServices || sm.clientSet == nil {
+		return nil
+	}
+
+	store := servicepolicy.NewStore()
+	if sm.config.SignatureKey != "" {
+		verifier, err := signature.LoadVerifier(sm.config.SignatureKey)
+		if err != nil {
+			return err
+		}
+		log.Infof("(svc policy) only service policies signed by [%s] will be applied", sm.config.SignatureKey)
+		store.SetVerifier(verifier)
+	}
+
+	log.Infof("(svc policy) loading service policies every [%s]", servicePolicyInterval)
+	sm.servicePolicies = store
+	go servicepolicy.Poll(context.Background(), sm.clientSet, sm.servicePolicies, servicePolicyInterval)
+	return nil
+}
+
+// serviceOverrides returns the overrides from every policy that selects the service
+func (sm *Manager) serviceOverrides(svc *v1.Service) servicepolicy.Overrides {
+	overrides, matched := sm.servicePolicies.Resolve(svc)
+	if len(matched) != 0 {
+		log.Debugf("(svc policy) [%s/%s] is selected by policies %v", svc.Namespace, svc.Name, matched)
+	}
+	return overrides
+}
+
+// ignoreServiceEngine returns true if the service has been assigned to an engine other than the one this manager is
+// running, the annotation of the service takes precedence over the policies that select it
+func (sm *Manager) ignoreServiceEngine(svc *v1.Service) bool {
+	engine := svc.Annotations[serviceEngine]
+	if engine == "" {
+		engine = sm.serviceOverrides(svc).Engine
+	}
+	if engine == "" {
+		engine = sm.defaultServicesEngine
+	}
+	if engine == "" {
+		return false
+	}
+
+	engines := sm.enabledEngines()
+	if len(engines) == 1 && engines[0] == engine {
+		return false
+	}
+	log.Debugf("(svcs) [%s] is assigned to the [%s] engine, ignoring", svc.Name, engine)
+	return true
+}
@@ -0,0 +1,177 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
+)
+
+// serviceShard is the election of a group of services, the node that leads the shard advertises all of them
+type serviceShard struct {
+	cancel context.CancelFunc
+	// leading is the context of the leadership of the shard, nil unless this node leads it
+	leading  context.Context
+	services map[string]shardService
+}
+
+// shardService is a service of a shard, with the wait group of its watcher
+type shardService struct {
+	svc *v1.Service
+	wg  *sync.WaitGroup
+}
+
+// serviceShardIndex returns the shard of a service, by the hash of its namespace and name so that every node agrees
+// on it and it doesn't change when the service is created again
+func serviceShardIndex(svc *v1.Service, shards int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(svc.Namespace + "/" + svc.Name))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// shardedServicesElection returns true if the service is elected with the other services of its shard. The services
+// with local endpoints are still elected on their own, as only the nodes with endpoints can advertise them.
+func (sm *Manager) shardedServicesElection(svc *v1.Service) bool {
+	return sm.config.ServicesElectionShards > 0 && svc.Spec.ExternalTrafficPolicy != v1.ServiceExternalTrafficPolicyTypeLocal
+}
+
+// startShardedServiceElection adds the service to the election of its shard until the context is cancelled, the
+// election of the shard runs for as long as it has services
+func (sm *Manager) startShardedServiceElection(ctx context.Context, service *v1.Service, wg *sync.WaitGroup) error {
+	index := serviceShardIndex(service, sm.config.ServicesElectionShards)
+	uid := string(service.UID)
+
+	sm.shardsMutex.Lock()
+	if sm.serviceShards == nil {
+		sm.serviceShards = map[int]*serviceShard{}
+	}
+	shard, exists := sm.serviceShards[index]
+	if !exists {
+		var shardCtx context.Context
+		shard = &serviceShard{services: map[string]shardService{}}
+		shardCtx, shard.cancel = context.WithCancel(context.Background())
+		sm.serviceShards[index] = shard
+		go sm.runShardElection(shardCtx, index, shard)
+	}
+	shard.services[uid] = shardService{svc: service, wg: wg}
+	leading := shard.leading
+	sm.shardsMutex.Unlock()
+
+	log.Infof("(svc election) service [%s], namespace [%s], shard [%d]", service.Name, service.Namespace, index)
+	setServiceActive(uid, true)
+	if leading != nil {
+		sm.startShardService(leading, service, wg)
+	}
+
+	<-ctx.Done()
+
+	sm.shardsMutex.Lock()
+	delete(shard.services, uid)
+	if len(shard.services) == 0 {
+		shard.cancel()
+		delete(sm.serviceShards, index)
+	}
+	sm.shardsMutex.Unlock()
+
+	// The service stops being advertised here, whether or not this node leads the shard
+	if sm.serviceInstance(uid) != nil {
+		if err := sm.deleteService(uid); err != nil {
+			log.Errorln(err)
+		}
+	}
+	setServiceActive(uid, false)
+	log.Infof("(svc election) for service [%s] stopping", service.Name)
+	return nil
+}
+
+// startShardService advertises a service of a shard that this node leads
+func (sm *Manager) startShardService(ctx context.Context, service *v1.Service, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		if err := sm.syncServices(ctx, service, wg); err != nil {
+			log.Errorln(err)
+		}
+	}()
+}
+
+// runShardElection elects the leader of a shard until the context is cancelled, the leader advertises every service of
+// the shard
+func (sm *Manager) runShardElection(ctx context.Context, index int, shard *serviceShard) {
+	shardLease := fmt.Sprintf("%s-shard-%d", sm.config.ServicesLeaseName, index)
+	log.Infof("(svc election) shard [%d], namespace [%s], lock name [%s], host id [%s]", index, sm.config.Namespace, shardLease, sm.config.NodeName)
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      shardLease,
+			Namespace: sm.config.Namespace,
+		},
+		Client: sm.clientSet.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: sm.config.NodeName,
+		},
+	}
+
+	leadership := securityevents.NewLeadership(ctx, fmt.Sprintf("%s/%s", sm.config.Namespace, shardLease), sm.config.NodeName)
+
+	// The election is joined again whenever the leadership is lost, until the shard has no services
+	for ctx.Err() == nil {
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			ReleaseOnCancel: !sm.config.Handover,
+			LeaseDuration:   time.Duration(sm.config.LeaseDuration) * time.Second,
+			RenewDeadline:   time.Duration(sm.config.RenewDeadline) * time.Second,
+			RetryPeriod:     time.Duration(sm.config.RetryPeriod) * time.Second,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					leadership.Started()
+					sm.shardsMutex.Lock()
+					shard.leading = ctx
+					services := make([]shardService, 0, len(shard.services))
+					for _, s := range shard.services {
+						services = append(services, s)
+					}
+					sm.shardsMutex.Unlock()
+
+					log.Infof("(svc election) shard [%d] leader elected, advertising [%d] services", index, len(services))
+					for _, s := range services {
+						sm.startShardService(ctx, s.svc, s.wg)
+					}
+				},
+				OnStoppedLeading: func() {
+					log.Infof("(svc election) shard [%d] leader lost: [%s]", index, sm.config.NodeName)
+					sm.shardsMutex.Lock()
+					shard.leading = nil
+					var uids []string
+					for uid := range shard.services {
+						uids = append(uids, uid)
+					}
+					sm.shardsMutex.Unlock()
+
+					for _, uid := range uids {
+						if sm.serviceInstance(uid) != nil {
+							if err := sm.deleteService(uid); err != nil {
+								log.Errorln(err)
+							}
+						}
+					}
+				},
+				OnNewLeader: func(identity string) {
+					leadership.NewLeader(identity)
+					if identity == sm.config.NodeName {
+						return
+					}
+					log.Infof("(svc election) shard [%d] new leader elected: %s", index, identity)
+				},
+			},
+		})
+	}
+	log.Infof("(svc election) for shard [%d] stopping", index)
+}
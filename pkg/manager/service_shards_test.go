@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+func TestServiceShardIndex(t *testing.T) {
+	const shards = 4
+	counts := make([]int, shards)
+	for i := 0; i < 400; i++ {
+		svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("svc-%d", i)}}
+		index := serviceShardIndex(svc, shards)
+		// Every node has to agree on the shard of a service
+		if again := serviceShardIndex(svc.DeepCopy(), shards); again != index {
+			t.Fatalf("serviceShardIndex() = %d then %d", index, again)
+		}
+		counts[index]++
+	}
+	for index, count := range counts {
+		if count < 50 {
+			t.Errorf("shard %d has %d of 400 services", index, count)
+		}
+	}
+
+	a := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "web"}}
+	b := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "b", Name: "web"}}
+	if serviceShardIndex(a, 1024) == serviceShardIndex(b, 1024) {
+		t.Error("services of the same name in different namespaces are in the same shard")
+	}
+}
+
+func TestShardedServicesElection(t *testing.T) {
+	tests := []struct {
+		name   string
+		shards int
+		policy v1.ServiceExternalTrafficPolicyType
+		want   bool
+	}{
+		{"no shards", 0, v1.ServiceExternalTrafficPolicyTypeCluster, false},
+		{"cluster", 8, v1.ServiceExternalTrafficPolicyTypeCluster, true},
+		{"local endpoints", 8, v1.ServiceExternalTrafficPolicyTypeLocal, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := &Manager{config: &kubevip.Config{EnableServicesElection: true, ServicesElectionShards: tt.shards}}
+			svc := &v1.Service{Spec: v1.ServiceSpec{ExternalTrafficPolicy: tt.policy}}
+			if got := sm.shardedServicesElection(svc); got != tt.want {
+				t.Errorf("shardedServicesElection() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
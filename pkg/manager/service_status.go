@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// startStatusUpdates starts the worker that writes the statuses of the services that are queued, until kube-vip shuts
+// down
+func (sm *Manager) startStatusUpdates() {
+	sm.statusUpdates = workqueue.NewRateLimitingQueueWithConfig(workqueue.DefaultControllerRateLimiter(), workqueue.RateLimitingQueueConfig{Name: "service-status"})
+	go func() {
+		<-sm.shutdownChan
+		sm.statusUpdates.ShutDown()
+	}()
+	go func() {
+		for sm.processNextStatusUpdate() {
+		}
+	}()
+}
+
+// queueStatusUpdate requests that the status of a service is written. The requests for a service that is already
+// queued are coalesced into one, as it writes the state of the instance when it is processed.
+func (sm *Manager) queueStatusUpdate(i *Instance) {
+	if sm.statusUpdates == nil {
+		if err := sm.updateStatus(i); err != nil {
+			log.Warnf("error updating svc: %s", err)
+		}
+		return
+	}
+	sm.statusUpdates.Add(i.UID)
+}
+
+// processNextStatusUpdate writes the status of the next service in the queue, a failed update is retried with a
+// backoff. It returns false once the queue has been shut down.
+func (sm *Manager) processNextStatusUpdate() bool {
+	item, shutdown := sm.statusUpdates.Get()
+	if shutdown {
+		return false
+	}
+	defer sm.statusUpdates.Done(item)
+	uid := item.(string)
+
+	// The service may have been removed while its update was queued
+	instance := sm.serviceInstance(uid)
+	if instance == nil {
+		sm.statusUpdates.Forget(item)
+		return true
+	}
+	if err := sm.updateStatus(instance); err != nil {
+		log.Warnf("error updating svc: %s", err)
+		sm.statusUpdates.AddRateLimited(item)
+		return true
+	}
+	sm.statusUpdates.Forget(item)
+	return true
+}
+
+// currentService returns the service of the instance to update. The first attempt reads it from the cache of the
+// services informer, rather than from the API server, and a conflict then reads it from the API server.
+func (sm *Manager) currentService(i *Instance, cached bool) (*v1.Service, error) {
+	if cached && sm.informers != nil {
+		informer := sm.informers.Core().V1().Services()
+		if informer.Informer().HasSynced() {
+			svc, err := informer.Lister().Services(i.serviceSnapshot.Namespace).Get(i.serviceSnapshot.Name)
+			if err == nil {
+				// The objects of the cache are shared, so they must not be modified
+				return svc.DeepCopy(), nil
+			}
+		}
+	}
+	return sm.clientSet.CoreV1().Services(i.serviceSnapshot.Namespace).Get(context.TODO(), i.serviceSnapshot.Name, metav1.GetOptions{})
+}
@@ -15,6 +15,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/retry"
 
+	"github.com/kube-vip/kube-vip/pkg/bgp"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
 	"github.com/kube-vip/kube-vip/pkg/vip"
 )
 
@@ -25,12 +27,35 @@ const (
 	egress                   = "kube-vip.io/egress"
 	egressDestinationPorts   = "kube-vip.io/egress-destination-ports"
 	egressSourcePorts        = "kube-vip.io/egress-source-ports"
+	egressSNATPorts          = "kube-vip.io/egress-snat-ports"
+	egressExcludeCidrs       = "kube-vip.io/egress-exclude-cidrs"
 	activeEndpoint           = "kube-vip.io/active-endpoint"
 	activeEndpointIPv6       = "kube-vip.io/active-endpoint-ipv6"
 	flushContrack            = "kube-vip.io/flush-conntrack"
 	loadbalancerIPAnnotation = "kube-vip.io/loadbalancerIPs"
 	loadbalancerHostname     = "kube-vip.io/loadbalancerHostname"
 	serviceInterface         = "kube-vip.io/serviceInterface"
+	serviceVRF               = "kube-vip.io/vrf"
+	serviceNextHops          = "kube-vip.io/nexthops"
+	serviceRuleTable         = "kube-vip.io/rule-table"
+	serviceRoutingTable      = "kube-vip.io/routing-table"
+	serviceUPNP              = "kube-vip.io/upnp"
+	serviceUPNPExternalPort  = "kube-vip.io/upnp-external-port"
+	serviceUPNPProtocol      = "kube-vip.io/upnp-protocol"
+	serviceEngine            = "kube-vip.io/engine"
+	serviceBGPCommunities    = "kube-vip.io/bgp-communities"
+	serviceBGPLocalPref      = "kube-vip.io/bgp-local-pref"
+	serviceHealthCheck       = "kube-vip.io/healthcheck"
+	serviceHealthCheckPort   = "kube-vip.io/healthcheck-port"
+	serviceHealthCheckPath   = "kube-vip.io/healthcheck-path"
+	serviceHealthInterval    = "kube-vip.io/healthcheck-interval"
+	serviceHealthTimeout     = "kube-vip.io/healthcheck-timeout"
+	serviceHealthRise        = "kube-vip.io/healthcheck-rise"
+	serviceHealthFall        = "kube-vip.io/healthcheck-fall"
+	serviceArpBroadcastRate  = "kube-vip.io/arp-broadcast-rate"
+	serviceArpRepeat         = "kube-vip.io/arp-repeat"
+	serviceArpRepeatInterval = "kube-vip.io/arp-repeat-interval"
+	serviceArpBackoff        = "kube-vip.io/arp-backoff"
 )
 
 func (sm *Manager) syncServices(_ context.Context, svc *v1.Service, wg *sync.WaitGroup) error {
@@ -49,30 +74,38 @@ func (sm *Manager) syncServices(_ context.Context, svc *v1.Service, wg *sync.Wai
 		ingressIPs = append(ingressIPs, ingress.IP)
 	}
 
-	shouldBreake := false
+	// The service is reconciled while the other services run theirs, unless they share a VIP with it
+	var currentVIPs []string
+	if instance := sm.findServiceInstance(svc); instance != nil {
+		currentVIPs = instance.VIPs
+	}
+	unlock := sm.lockService(newServiceUID, newServiceAddresses, currentVIPs)
+	defer unlock()
 
-	for x := range sm.serviceInstances {
-		if shouldBreake {
-			break
-		}
+	if instance := sm.findServiceInstance(svc); instance != nil {
+		foundInstance = true
 		for _, newServiceAddress := range newServiceAddresses {
-			log.Debugf("isDHCP: %t, newServiceAddress: %s", sm.serviceInstances[x].isDHCP, newServiceAddress)
-			if sm.serviceInstances[x].UID == newServiceUID {
-				// If the found instance's DHCP configuration doesn't match the new service, delete it.
-				if (sm.serviceInstances[x].isDHCP && newServiceAddress != "0.0.0.0") ||
-					(!sm.serviceInstances[x].isDHCP && newServiceAddress == "0.0.0.0") ||
-					(!sm.serviceInstances[x].isDHCP && len(svc.Status.LoadBalancer.Ingress) > 0 && !slices.Contains(ingressIPs, newServiceAddress)) ||
-					(len(svc.Status.LoadBalancer.Ingress) > 0 && !comparePortsAndPortStatuses(svc)) ||
-					(sm.serviceInstances[x].isDHCP && len(svc.Status.LoadBalancer.Ingress) > 0 && !slices.Contains(ingressIPs, sm.serviceInstances[x].dhcpInterfaceIP)) {
-					if err := sm.deleteService(newServiceUID); err != nil {
-						return err
-					}
-					shouldBreake = true
-					break
+			log.Debugf("isDHCP: %t, newServiceAddress: %s", instance.isDHCP, newServiceAddress)
+			// If the found instance's DHCP configuration doesn't match the new service, delete it.
+			if (instance.isDHCP && newServiceAddress != "0.0.0.0") ||
+				(!instance.isDHCP && newServiceAddress == "0.0.0.0") ||
+				(!instance.isDHCP && len(svc.Status.LoadBalancer.Ingress) > 0 && !slices.Contains(ingressIPs, newServiceAddress)) ||
+				// An address of the service has been removed (e.g. the IPv6 address of a dual-stack service)
+				(!instance.isDHCP && !slices.Equal(instance.VIPs, newServiceAddresses)) ||
+				(len(svc.Status.LoadBalancer.Ingress) > 0 && !comparePortsAndPortStatuses(svc)) ||
+				(instance.isDHCP && len(svc.Status.LoadBalancer.Ingress) > 0 && !slices.Contains(ingressIPs, instance.dhcpInterfaceIP)) ||
+				// The routes are advertised again with the new path attributes
+				(sm.config.EnableBGP && bgpAnnotationsChanged(instance.serviceSnapshot, svc)) {
+				if err := sm.teardownService(newServiceUID); err != nil {
+					return err
 				}
-				foundInstance = true
+				foundInstance = false
+				break
 			}
 		}
+		if foundInstance && len(newServiceAddresses) > 0 {
+			sm.upnpRemap(instance, svc)
+		}
 	}
 
 	// This instance wasn't found, we need to add it to the manager
@@ -101,16 +134,22 @@ func comparePortsAndPortStatuses(svc *v1.Service) bool {
 func (sm *Manager) addService(svc *v1.Service) error {
 	startTime := time.Now()
 
-	newService, err := NewInstance(svc, sm.config)
+	overrides := sm.serviceOverrides(svc)
+	newService, err := NewInstance(svc, sm.config, overrides)
 	if err != nil {
 		return err
 	}
+	if err := sm.setBGPAttributes(newService, svc, overrides); err != nil {
+		return err
+	}
 
 	for x := range newService.vipConfigs {
+		// The VIPs that the previous kube-vip handed over are kept on their interfaces
+		newService.clusters[x].KeepHandedOver(sm.handedOver.adopt(newService.clusters[x]))
 		newService.clusters[x].StartLoadBalancerService(newService.vipConfigs[x], sm.bgpServer)
 	}
 
-	sm.upnpMap(newService)
+	sm.upnpMap(newService, svc)
 
 	if newService.isDHCP && len(newService.vipConfigs) == 1 {
 		go func() {
@@ -118,28 +157,29 @@ func (sm *Manager) addService(svc *v1.Service) error {
 				log.Debugf("IP %s may have changed", ip)
 				newService.vipConfigs[0].VIP = ip
 				newService.dhcpInterfaceIP = ip
+				sm.publishServiceDDNS(newService)
 				if !sm.config.DisableServiceUpdates {
-					if err := sm.updateStatus(newService); err != nil {
-						log.Warnf("error updating svc: %s", err)
-					}
+					sm.queueStatusUpdate(newService)
 				}
 			}
 			log.Debugf("IP update channel closed, stopping")
 		}()
 	}
 
-	sm.serviceInstances = append(sm.serviceInstances, newService)
+	sm.storeServiceInstance(newService)
+	sm.publishServiceDDNS(newService)
 
 	if !sm.config.DisableServiceUpdates {
 		log.Debugf("(svcs) will update [%s/%s]", newService.serviceSnapshot.Namespace, newService.serviceSnapshot.Name)
 		if err := sm.updateStatus(newService); err != nil {
 			// delete service to collect garbage
-			if deleteErr := sm.deleteService(newService.UID); deleteErr != nil {
+			if deleteErr := sm.teardownService(newService.UID); deleteErr != nil {
 				return deleteErr
 			}
 			return err
 		}
 	}
+	sm.serviceEvent(svc, reasonAdvertised, advertisedMessage(instanceVIPs(newService), sm.config.NodeName))
 
 	serviceIPs := fetchServiceAddresses(svc)
 
@@ -159,8 +199,9 @@ func (sm *Manager) addService(svc *v1.Service) error {
 		}
 	}
 
-	// Check if egress is enabled on the service, if so we'll need to configure some rules
-	if svc.Annotations[egress] == "true" && len(serviceIPs) > 0 {
+	// Check if egress is enabled on the service, if so we'll need to configure some rules (on this node as the leader, the
+	// endpoint watchers place the egress on the node of the pod otherwise)
+	if svc.Annotations[egress] == "true" && len(serviceIPs) > 0 && sm.egressPlacement(svc) == kubevip.EgressPlacementLeader {
 		log.Debugf("Enabling egress for the service [%s]", svc.Name)
 		if svc.Annotations[activeEndpoint] != "" {
 			// We will need to modify the iptables rules
@@ -174,7 +215,10 @@ func (sm *Manager) addService(svc *v1.Service) error {
 				if sm.config.EnableEndpointSlices && vip.IsIPv6(serviceIP) {
 					podIPs = svc.Annotations[activeEndpointIPv6]
 				}
-				err = sm.configureEgress(serviceIP, podIPs, svc.Annotations[egressDestinationPorts], svc.Namespace)
+				if !serviceEgressSelected(serviceIPs, serviceIP, podIPs) {
+					continue
+				}
+				err = sm.configureEgress(serviceIP, podIPs, svc.Annotations[egressDestinationPorts], svc.Annotations[egressSNATPorts], svc.Annotations[egressExcludeCidrs], svc.Namespace)
 				if err != nil {
 					errList = append(errList, err)
 					log.Errorf("Error configuring egress for loadbalancer [%s]", err)
@@ -202,43 +246,29 @@ func (sm *Manager) addService(svc *v1.Service) error {
 	return nil
 }
 
+// deleteService stops advertising a service, once any other reconcile of the service or of its VIPs has finished
 func (sm *Manager) deleteService(uid string) error {
-	// protect multiple calls
-	sm.mutex.Lock()
-	defer sm.mutex.Unlock()
-
-	var updatedInstances []*Instance
-	var serviceInstance *Instance
-	found := false
-	for x := range sm.serviceInstances {
-		log.Debugf("Looking for [%s], found [%s]", uid, sm.serviceInstances[x].UID)
-		// Add the running services to the new array
-		if sm.serviceInstances[x].UID != uid {
-			updatedInstances = append(updatedInstances, sm.serviceInstances[x])
-		} else {
-			// Flip the found when we match
-			found = true
-			serviceInstance = sm.serviceInstances[x]
-		}
+	var vips []string
+	if instance := sm.serviceInstance(uid); instance != nil {
+		vips = instance.VIPs
 	}
+	unlock := sm.lockService(uid, vips)
+	defer unlock()
+	return sm.teardownService(uid)
+}
+
+// teardownService stops advertising a service, the caller holds the locks of the service
+func (sm *Manager) teardownService(uid string) error {
+	serviceInstance, shared := sm.removeServiceInstance(uid)
 	// If we've been through all services and not found the correct one then error
-	if !found {
+	if serviceInstance == nil {
 		// TODO: - fix UX
 		// return fmt.Errorf("unable to find/stop service [%s]", uid)
 		return nil
 	}
-	shared := false
-	vipSet := make(map[string]interface{})
-	for x := range updatedInstances {
-		for _, vip := range updatedInstances[x].VIPs {
-			vipSet[vip] = nil
-		}
-	}
-	for _, vip := range serviceInstance.VIPs {
-		if _, found := vipSet[vip]; found {
-			shared = true
-		}
-	}
+
+	// The ports are no longer forwarded to the VIPs of the service
+	sm.upnpUnmap(uid)
 	if !shared {
 		for x := range serviceInstance.clusters {
 			serviceInstance.clusters[x].Stop()
@@ -255,63 +285,55 @@ func (sm *Manager) deleteService(uid string) error {
 				return fmt.Errorf("error deleting DHCP Link : %v", err)
 			}
 		}
-		// TODO: Implement dual-stack loadbalancer support if BGP is enabled
 		for i := range serviceInstance.vipConfigs {
 			if serviceInstance.vipConfigs[i].EnableBGP {
-				cidrVip := fmt.Sprintf("%s/%s", serviceInstance.vipConfigs[i].VIP, serviceInstance.vipConfigs[i].VIPCIDR)
+				cidrVip := bgp.HostCIDR(serviceInstance.vipConfigs[i].VIP)
 				err := sm.bgpServer.DelHost(cidrVip)
 				if err != nil {
 					return fmt.Errorf("[BGP] error deleting BGP host: %v", err)
 				}
 				log.Debugf("[BGP] deleted host: %s", cidrVip)
+				_ = sm.bgpServer.SetHostAttributes(serviceInstance.vipConfigs[i].VIP, nil)
 			}
 		}
 
 		// We will need to tear down the egress
-		if serviceInstance.serviceSnapshot.Annotations[egress] == "true" {
-			if serviceInstance.serviceSnapshot.Annotations[activeEndpoint] != "" {
-				log.Infof("service [%s] has an egress re-write enabled", serviceInstance.serviceSnapshot.Name)
-				err := sm.TeardownEgress(serviceInstance.serviceSnapshot.Annotations[activeEndpoint], serviceInstance.serviceSnapshot.Spec.LoadBalancerIP, serviceInstance.serviceSnapshot.Annotations[egressDestinationPorts], serviceInstance.serviceSnapshot.Namespace)
+		if svc := serviceInstance.serviceSnapshot; svc.Annotations[egress] == "true" && sm.egressPlacement(svc) == kubevip.EgressPlacementLeader {
+			log.Infof("service [%s] has an egress re-write enabled", svc.Name)
+			// The egress of each family is removed, the pod of an IPv6 address is in its own annotation
+			serviceIPs := fetchServiceAddresses(svc)
+			for _, serviceIP := range serviceIPs {
+				podIP := svc.Annotations[activeEndpoint]
+				if sm.config.EnableEndpointSlices && vip.IsIPv6(serviceIP) {
+					podIP = svc.Annotations[activeEndpointIPv6]
+				}
+				if podIP == "" || !serviceEgressSelected(serviceIPs, serviceIP, podIP) {
+					continue
+				}
+				err := sm.TeardownEgress(podIP, serviceIP, svc.Annotations[egressDestinationPorts], svc.Annotations[egressSNATPorts], svc.Annotations[egressExcludeCidrs], svc.Namespace)
 				if err != nil {
 					log.Errorf("%v", err)
 				}
 			}
 		}
+		sm.withdrawService(serviceInstance)
 	}
 
-	// Update the service array
-	sm.serviceInstances = updatedInstances
-
-	log.Infof("Removed [%s] from manager, [%d] advertised services remain", uid, len(sm.serviceInstances))
+	log.Infof("Removed [%s] from manager, [%d] advertised services remain", uid, sm.serviceCount())
 
 	return nil
 }
 
-func (sm *Manager) upnpMap(s *Instance) {
-	// If upnp is enabled then update the gateway/router with the address
-	// TODO - work out if we need to mapping.Reclaim()
-	// TODO - check if this implementation for dualstack is correct
-	if sm.upnp != nil {
-		for _, vip := range s.VIPs {
-			log.Infof("[UPNP] Adding map to [%s:%d - %s]", vip, s.Port, s.serviceSnapshot.Name)
-			if err := sm.upnp.AddPortMapping(int(s.Port), int(s.Port), 0, vip, strings.ToUpper(s.Type), s.serviceSnapshot.Name); err == nil {
-				log.Infof("service should be accessible externally on port [%d]", s.Port)
-			} else {
-				sm.upnp.Reclaim()
-				log.Errorf("unable to map port to gateway [%s]", err.Error())
-			}
-		}
-	}
-}
-
 func (sm *Manager) updateStatus(i *Instance) error {
+	cached := true
 	retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		// Retrieve the latest version of Deployment before attempting update
 		// RetryOnConflict uses exponential backoff to avoid exhausting the apiserver
-		currentService, err := sm.clientSet.CoreV1().Services(i.serviceSnapshot.Namespace).Get(context.TODO(), i.serviceSnapshot.Name, metav1.GetOptions{})
+		currentService, err := sm.currentService(i, cached)
 		if err != nil {
 			return err
 		}
+		cached = false
 
 		currentServiceCopy := currentService.DeepCopy()
 		if currentServiceCopy.Annotations == nil {
@@ -327,6 +349,7 @@ func (sm *Manager) updateStatus(i *Instance) error {
 			currentServiceCopy.Annotations[hwAddrKey] = i.dhcpInterfaceHwaddr
 			currentServiceCopy.Annotations[requestedIP] = i.dhcpInterfaceIP
 		}
+		sm.publishHostnameAnnotation(currentServiceCopy)
 
 		if !cmp.Equal(currentService, currentServiceCopy) {
 			currentService, err = sm.clientSet.CoreV1().Services(currentServiceCopy.Namespace).Update(context.TODO(), currentServiceCopy, metav1.UpdateOptions{})
@@ -344,6 +367,7 @@ func (sm *Manager) updateStatus(i *Instance) error {
 			})
 		}
 
+		hostname := sm.publishHostnameStatus(currentService)
 		ingresses := []v1.LoadBalancerIngress{}
 
 		for _, c := range i.vipConfigs {
@@ -354,21 +378,28 @@ func (sm *Manager) updateStatus(i *Instance) error {
 				}
 				for _, ip := range ips {
 					i := v1.LoadBalancerIngress{
-						IP:    ip,
-						Ports: ports,
+						IP:       ip,
+						Hostname: hostname,
+						Ports:    ports,
 					}
 					ingresses = append(ingresses, i)
 				}
 			} else {
 				i := v1.LoadBalancerIngress{
-					IP:    c.VIP,
-					Ports: ports,
+					IP:       c.VIP,
+					Hostname: hostname,
+					Ports:    ports,
 				}
 				ingresses = append(ingresses, i)
 			}
 		}
-		if !cmp.Equal(currentService.Status.LoadBalancer.Ingress, ingresses) {
-			currentService.Status.LoadBalancer.Ingress = ingresses
+		// The service is advertised by this node, the condition says which node so that it can be found without the logs
+		status := currentService.Status.DeepCopy()
+		status.LoadBalancer.Ingress = ingresses
+		vips := instanceVIPs(i)
+		setAdvertisedCondition(status, currentService.Generation, true, reasonAdvertised, advertisedMessage(vips, sm.config.NodeName))
+		if !cmp.Equal(currentService.Status, *status) {
+			currentService.Status = *status
 			_, err = sm.clientSet.CoreV1().Services(currentService.Namespace).UpdateStatus(context.TODO(), currentService, metav1.UpdateOptions{})
 			if err != nil {
 				log.Errorf("Error updating Service %s/%s Status: %v", i.serviceSnapshot.Namespace, i.serviceSnapshot.Name, err)
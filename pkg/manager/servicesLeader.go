@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -20,7 +21,7 @@ func (sm *Manager) startServicesWatchForLeaderElection(ctx context.Context) erro
 		return err
 	}
 
-	for _, instance := range sm.serviceInstances {
+	for _, instance := range sm.instances() {
 		for _, cluster := range instance.clusters {
 			for i := range cluster.Network {
 				_ = cluster.Network[i].DeleteRoute()
@@ -36,6 +37,11 @@ func (sm *Manager) startServicesWatchForLeaderElection(ctx context.Context) erro
 
 // The startServicesWatchForLeaderElection function will start a services watcher, the
 func (sm *Manager) StartServicesLeaderElection(ctx context.Context, service *v1.Service, wg *sync.WaitGroup) error {
+	// The service is advertised by the leader of its shard, instead of having its own lease
+	if sm.shardedServicesElection(service) {
+		return sm.startShardedServiceElection(ctx, service, wg)
+	}
+
 	serviceLease := fmt.Sprintf("kubevip-%s", service.Name)
 	log.Infof("(svc election) service [%s], namespace [%s], lock name [%s], host id [%s]", service.Name, service.Namespace, serviceLease, sm.config.NodeName)
 	// we use the Lease lock type since edits to Leases are less common
@@ -51,7 +57,10 @@ func (sm *Manager) StartServicesLeaderElection(ctx context.Context, service *v1.
 		},
 	}
 
-	activeService[string(service.UID)] = true
+	leaseKey := fmt.Sprintf("%s/%s", service.Namespace, serviceLease)
+	leadership := securityevents.NewLeadership(ctx, leaseKey, sm.config.NodeName)
+
+	setServiceActive(string(service.UID), true)
 	// start the leader election code loop
 	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
 		Lock: lock,
@@ -61,12 +70,15 @@ func (sm *Manager) StartServicesLeaderElection(ctx context.Context, service *v1.
 		// loop still running and another process could
 		// get elected before your background loop finished, violating
 		// the stated goal of the lease.
-		ReleaseOnCancel: true,
+		ReleaseOnCancel: !sm.config.Handover,
 		LeaseDuration:   time.Duration(sm.config.LeaseDuration) * time.Second,
 		RenewDeadline:   time.Duration(sm.config.RenewDeadline) * time.Second,
 		RetryPeriod:     time.Duration(sm.config.RetryPeriod) * time.Second,
 		Callbacks: leaderelection.LeaderCallbacks{
 			OnStartedLeading: func(ctx context.Context) {
+				leadership.Started()
+				// Look for other nodes that are still advertising the service
+				sm.leading.set(leaseKey, true)
 				// Mark this service as active (as we've started leading)
 				// we run this in background as it's blocking
 				wg.Add(1)
@@ -79,16 +91,18 @@ func (sm *Manager) StartServicesLeaderElection(ctx context.Context, service *v1.
 			OnStoppedLeading: func() {
 				// we can do cleanup here
 				log.Infof("(svc election) service [%s] leader lost: [%s]", service.Name, sm.config.NodeName)
-				if activeService[string(service.UID)] {
+				sm.leading.set(leaseKey, false)
+				if isServiceActive(string(service.UID)) {
 					if err := sm.deleteService(string(service.UID)); err != nil {
 						log.Errorln(err)
 					}
 				}
 				// Mark this service is inactive
-				activeService[string(service.UID)] = false
+				setServiceActive(string(service.UID), false)
 			},
 			OnNewLeader: func(identity string) {
 				// we're notified when new leader elected
+				leadership.NewLeader(identity)
 				if identity == sm.config.NodeName {
 					// I just got the lock
 					return
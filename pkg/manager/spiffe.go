@@ -0,0 +1,36 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/k8s"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/spiffe"
+)
+
+// spiffeTimeout is how long to wait for the first SVID from the Workload API, the agent has to attest the workload
+// before it is issued
+const spiffeTimeout = 2 * time.Minute
+
+// startSPIFFE will fetch the SVIDs of this node from the Workload API for the rest of the process. When the Kubernetes
+// client uses the SVID the first one has to be received before the client is created.
+func startSPIFFE(config *kubevip.Config) (*spiffe.Source, error) {
+	source := spiffe.NewSource(config.SPIFFE.Socket)
+	go source.Run(context.Background())
+	log.Infof("(spiffe) fetching identities from the workload API [%s]", config.SPIFFE.Socket)
+
+	if config.SPIFFE.Kubernetes {
+		ctx, cancel := context.WithTimeout(context.Background(), spiffeTimeout)
+		defer cancel()
+		svid, err := source.WaitForSVID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		k8s.UseClientCertificate(source.GetClientCertificate)
+		log.Infof("(spiffe) the Kubernetes client will authenticate as [%s]", svid.ID)
+	}
+	return source, nil
+}
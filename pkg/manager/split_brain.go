@@ -0,0 +1,142 @@
+package manager
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
+)
+
+// splitBrainInterval is how often a leader asks the other nodes whether they are also leading its lease
+const splitBrainInterval = 30 * time.Second
+
+// leadingLeases are the leases that this node is leading, from its own leader elections. The other nodes ask for them
+// over the coordination channel.
+type leadingLeases struct {
+	mutex  sync.Mutex
+	leases map[string]bool
+}
+
+func newLeadingLeases() *leadingLeases {
+	return &leadingLeases{leases: map[string]bool{}}
+}
+
+func (l *leadingLeases) isLeading(lease string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.leases[lease]
+}
+
+func (l *leadingLeases) set(lease string, leading bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if leading {
+		l.leases[lease] = true
+	} else {
+		delete(l.leases, lease)
+	}
+}
+
+// list returns the leases that are led, in order
+func (l *leadingLeases) list() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	leases := make([]string, 0, len(l.leases))
+	for lease := range l.leases {
+		leases = append(leases, lease)
+	}
+	slices.Sort(leases)
+	return leases
+}
+
+// peer is another node that can be asked over the coordination channel
+type peer struct {
+	node    string
+	address string
+}
+
+// coordinationPeers returns the other nodes and their internal addresses
+func coordinationPeers(nodes []v1.Node, self string) []peer {
+	var peers []peer
+	for x := range nodes {
+		if nodes[x].Name == self {
+			continue
+		}
+		for _, address := range nodes[x].Status.Addresses {
+			if address.Type == v1.NodeInternalIP && net.ParseIP(address.Address) != nil {
+				peers = append(peers, peer{node: nodes[x].Name, address: address.Address})
+				break
+			}
+		}
+	}
+	return peers
+}
+
+// probeSplitBrain will ask the other nodes whether they are also leading the leases that this node leads, until kube-vip
+// shuts down. A node that still believes that it is leading (e.g. because it can't reach the API server to renew the
+// lease) keeps advertising the same addresses as the new leader. The nodes are read from a single informer, however many
+// leases are led.
+func (sm *Manager) probeSplitBrain(ctx context.Context) {
+	factory := informers.NewSharedInformerFactory(sm.clientSet, 0)
+	nodes := factory.Core().V1().Nodes().Lister()
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	ticker := time.NewTicker(splitBrainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		leases := sm.leading.list()
+		if len(leases) == 0 {
+			continue
+		}
+		list, err := nodes.List(labels.Everything())
+		if err != nil {
+			log.Warnf("(coordination) unable to list the nodes to probe for the leases %v: %v", leases, err)
+			continue
+		}
+		all := make([]v1.Node, 0, len(list))
+		for _, node := range list {
+			all = append(all, *node)
+		}
+		peers := coordinationPeers(all, sm.config.NodeName)
+		for _, lease := range leases {
+			for _, node := range sm.splitBrain(ctx, lease, peers) {
+				log.Errorf("(coordination) split brain, node [%s] is also leading lease [%s]", node, lease)
+				securityevents.Emit(securityevents.SplitBrain, "another node is also leading a lease that this node leads", map[string]string{
+					"lease": lease,
+					"node":  node,
+				})
+			}
+		}
+	}
+}
+
+// splitBrain returns the peers that are also leading the lease, peers that can't be reached (e.g. nodes that aren't
+// running kube-vip) are skipped
+func (sm *Manager) splitBrain(ctx context.Context, lease string, peers []peer) []string {
+	var leaders []string
+	for _, p := range peers {
+		leading, err := sm.coordination.Leading(ctx, p.node, p.address, lease)
+		if err != nil {
+			log.Debugf("(coordination) unable to ask [%s] about lease [%s]: %v", p.node, lease, err)
+			continue
+		}
+		if leading {
+			leaders = append(leaders, p.node)
+		}
+	}
+	return leaders
+}
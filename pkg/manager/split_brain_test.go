@@ -0,0 +1,168 @@
+package manager
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/coordination"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+func TestCoordinationPeers(t *testing.T) {
+	node := func(name string, addresses ...v1.NodeAddress) v1.Node {
+		return v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: v1.NodeStatus{Addresses: addresses}}
+	}
+	internal := func(address string) v1.NodeAddress {
+		return v1.NodeAddress{Type: v1.NodeInternalIP, Address: address}
+	}
+	tests := []struct {
+		name  string
+		nodes []v1.Node
+		want  []peer
+	}{
+		{name: "only this node", nodes: []v1.Node{node("node-1", internal("192.168.0.1"))}},
+		{
+			name:  "other nodes",
+			nodes: []v1.Node{node("node-1", internal("192.168.0.1")), node("node-2", internal("192.168.0.2")), node("node-3", internal("192.168.0.3"))},
+			want:  []peer{{node: "node-2", address: "192.168.0.2"}, {node: "node-3", address: "192.168.0.3"}},
+		},
+		{
+			name:  "first internal address",
+			nodes: []v1.Node{node("node-2", v1.NodeAddress{Type: v1.NodeExternalIP, Address: "203.0.113.2"}, internal("192.168.0.2"), internal("192.168.1.2"))},
+			want:  []peer{{node: "node-2", address: "192.168.0.2"}},
+		},
+		{name: "no internal address", nodes: []v1.Node{node("node-2", v1.NodeAddress{Type: v1.NodeHostName, Address: "node-2"})}},
+		{name: "invalid internal address", nodes: []v1.Node{node("node-2", internal("node-2.example.com"))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := coordinationPeers(tt.nodes, "node-1"); !slices.Equal(got, tt.want) {
+				t.Errorf("coordinationPeers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeadingLeases(t *testing.T) {
+	l := newLeadingLeases()
+	l.set("default/kubevip-web", true)
+	l.set("default/kubevip-api", true)
+	if !l.isLeading("default/kubevip-web") || l.isLeading("default/kubevip-db") {
+		t.Error("isLeading() doesn't match the leases that are led")
+	}
+	if got, want := l.list(), []string{"default/kubevip-api", "default/kubevip-web"}; !slices.Equal(got, want) {
+		t.Errorf("list() = %v, want %v", got, want)
+	}
+	l.set("default/kubevip-web", false)
+	if l.isLeading("default/kubevip-web") {
+		t.Error("isLeading() is true once the leadership has been lost")
+	}
+	if got, want := l.list(), []string{"default/kubevip-api"}; !slices.Equal(got, want) {
+		t.Errorf("list() = %v, want %v", got, want)
+	}
+}
+
+// newCoordinationChannel creates a channel for the node, with a certificate signed by the CA
+func newCoordinationChannel(t *testing.T, node string, port int, caPEM, caKeyPEM []byte) *coordination.Channel {
+	t.Helper()
+	certPEM, keyPEM, err := coordination.Issue(caPEM, caKeyPEM, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := coordination.NewChannel(node, port)
+	if err := c.SetCredentials(certPEM, keyPEM, caPEM); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSplitBrain(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kube-vip-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	caKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	// node-2 is still leading the lease of the web service, as well as this node
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	other := newCoordinationChannel(t, "node-2", port, caPEM, caKeyPEM)
+	other.HandleLeading(func(lease string) bool {
+		return lease == "default/kubevip-web"
+	})
+	go func() {
+		_ = other.Serve(ctx)
+	}()
+
+	sm := &Manager{
+		config:       &kubevip.Config{NodeName: "node-1"},
+		coordination: newCoordinationChannel(t, "node-1", port, caPEM, caKeyPEM),
+	}
+	for attempt := 0; attempt < 50; attempt++ {
+		if err = sm.coordination.Ping(ctx, "node-2", "127.0.0.1"); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		lease string
+		peers []peer
+		want  []string
+	}{
+		{name: "split brain", lease: "default/kubevip-web", peers: []peer{{node: "node-2", address: "127.0.0.1"}}, want: []string{"node-2"}},
+		{name: "single leader", lease: "default/kubevip-api", peers: []peer{{node: "node-2", address: "127.0.0.1"}}},
+		// node-3 isn't running kube-vip, and the certificate of node-2 isn't for node-4
+		{name: "unreachable peers", lease: "default/kubevip-web", peers: []peer{{node: "node-3", address: "127.0.0.2"}, {node: "node-4", address: "127.0.0.1"}, {node: "node-2", address: "127.0.0.1"}}, want: []string{"node-2"}},
+		{name: "no peers", lease: "default/kubevip-web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sm.splitBrain(ctx, tt.lease, tt.peers); !slices.Equal(got, tt.want) {
+				t.Errorf("splitBrain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/portmap"
+)
+
+// upnpRenewInterval is how often the leases of the port mappings are checked for renewal
+const upnpRenewInterval = 10 * time.Second
+
+var (
+	upnpMappingsDesc = prometheus.NewDesc("kube_vip_upnp_mappings",
+		"Ports that the gateway forwards to the VIP, or pinholes that it has opened for it", []string{"vip"}, nil)
+	upnpGatewayDesc = prometheus.NewDesc("kube_vip_upnp_gateway_info",
+		"The gateway that the ports are mapped on, along with the protocol and the external address that was discovered", []string{"gateway", "protocol", "external_ip"}, nil)
+)
+
+// upnpMapping is a port that the gateway forwards to a VIP, it is renewed before its lease expires
+type upnpMapping struct {
+	vip          string
+	port         int
+	externalPort int
+	protocol     string
+	description  string
+	// renew is when the mapping is added again (half way through its lease), it is zero for a permanent mapping
+	renew time.Time
+	// active is whether the gateway forwards the port, the mapping failed otherwise
+	active bool
+}
+
+// startUPNP will find the gateway with the configured protocol (UPnP, NAT-PMP or PCP) if UPNP is enabled, the leases
+// of the port mappings are then renewed until the context is cancelled
+func (sm *Manager) startUPNP(ctx context.Context) {
+	if !sm.config.EnableUPNP {
+		return
+	}
+	portMapper, err := portmap.New(sm.config.UPNPProtocol, sm.config.UPNPGatewayURL, sm.config.UPNPInterface)
+	if err != nil {
+		log.Errorf("Error Enabling UPNP %s", err.Error())
+		return
+	}
+	sm.portMapper = portMapper
+	log.Infof("Successfully enabled UPNP with [%s], Gateway [%s] address [%s]", portMapper.Name(), portMapper.Gateway(), portMapper.ExternalIP())
+	go sm.renewUPNPMappings(ctx)
+}
+
+// upnpMap forwards the port of the service from the gateway to each of its VIPs, unless the service has opted out (or
+// hasn't opted in) with its annotations
+func (sm *Manager) upnpMap(s *Instance, svc *v1.Service) {
+	if sm.portMapper == nil {
+		return
+	}
+	enabled, externalPort, protocol, err := upnpServiceOptions(svc, int(s.Port), s.Type, sm.config.UPNPOptIn)
+	if err != nil {
+		log.Errorf("[UPNP] unable to map the port of service %s/%s: %v", svc.Namespace, svc.Name, err)
+		return
+	}
+	if !enabled {
+		return
+	}
+	sm.upnpMutex.Lock()
+	defer sm.upnpMutex.Unlock()
+
+	// The IPv6 VIPs of a dual-stack service have a pinhole opened in the firewall of the gateway instead
+	for _, vip := range s.VIPs {
+		mapping := &upnpMapping{vip: vip, port: int(s.Port), externalPort: externalPort, protocol: protocol, description: svc.Name}
+		log.Infof("[UPNP] Adding map to [%s:%d - %s] from port [%d]", vip, s.Port, svc.Name, externalPort)
+		if err := sm.addUPNPMapping(mapping, time.Now()); err != nil {
+			// The mapping is kept, so that it is retried along with the renewals
+			log.Errorf("unable to map port to gateway [%s]", err.Error())
+		} else {
+			log.Infof("service should be accessible externally on port [%d]", externalPort)
+		}
+		sm.upnpMappings[s.UID] = append(sm.upnpMappings[s.UID], mapping)
+	}
+}
+
+// upnpUnmap removes the port mappings of a service that has been deleted, so that they are no longer renewed
+func (sm *Manager) upnpUnmap(uid string) {
+	if sm.portMapper == nil {
+		return
+	}
+	sm.upnpMutex.Lock()
+	defer sm.upnpMutex.Unlock()
+
+	for _, mapping := range sm.upnpMappings[uid] {
+		log.Infof("[UPNP] Removing map to [%s:%d - %s]", mapping.vip, mapping.port, mapping.description)
+		if err := sm.deleteUPNPMapping(mapping); err != nil {
+			log.Errorf("unable to remove the port mapping from the gateway [%s]", err.Error())
+		}
+	}
+	delete(sm.upnpMappings, uid)
+}
+
+// upnpRemap maps the ports of a service again when its UPNP annotations have changed
+func (sm *Manager) upnpRemap(s *Instance, svc *v1.Service) {
+	if sm.portMapper == nil {
+		return
+	}
+	changed := false
+	for _, annotation := range []string{serviceUPNP, serviceUPNPExternalPort, serviceUPNPProtocol} {
+		if s.serviceSnapshot.Annotations[annotation] != svc.Annotations[annotation] {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	log.Infof("[UPNP] the annotations of service %s/%s have changed, mapping its ports again", svc.Namespace, svc.Name)
+	sm.upnpUnmap(s.UID)
+	sm.upnpMap(s, svc)
+	// The snapshot may be shared with the cache of the watcher, so it is copied rather than changed
+	s.serviceSnapshot = s.serviceSnapshot.DeepCopy()
+	s.serviceSnapshot.Annotations = svc.Annotations
+}
+
+// renewUPNPMappings adds the mappings again once they are half way through their lease, along with any mappings that
+// failed
+func (sm *Manager) renewUPNPMappings(ctx context.Context) {
+	ticker := time.NewTicker(upnpRenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sm.shutdownChan:
+			return
+		case now := <-ticker.C:
+			sm.upnpMutex.Lock()
+			for _, mappings := range sm.upnpMappings {
+				for _, mapping := range mappings {
+					if mapping.renew.IsZero() || now.Before(mapping.renew) {
+						continue
+					}
+					if err := sm.addUPNPMapping(mapping, now); err != nil {
+						if sm.upnpRenewalFailures != nil {
+							sm.upnpRenewalFailures.Inc()
+						}
+						log.Errorf("[UPNP] unable to renew the map to [%s:%d - %s]: %v", mapping.vip, mapping.port, mapping.description, err)
+						continue
+					}
+					log.Debugf("[UPNP] renewed the map to [%s:%d - %s]", mapping.vip, mapping.port, mapping.description)
+				}
+			}
+			sm.upnpMutex.Unlock()
+		}
+	}
+}
+
+// addUPNPMapping adds the mapping with the configured lease and works out when it has to be renewed from the lease that
+// the gateway granted, a mapping that fails is retried straight away
+func (sm *Manager) addUPNPMapping(mapping *upnpMapping, now time.Time) error {
+	lease := time.Duration(sm.config.UPNPLeaseDuration) * time.Second
+	var granted time.Duration
+	var err error
+	if mapping.externalPort == mapping.port {
+		granted, err = sm.portMapper.AddMapping(mapping.vip, mapping.port, mapping.protocol, lease, mapping.description)
+	} else if mapper, ok := sm.portMapper.(portmap.ExternalPortClient); ok {
+		granted, err = mapper.AddExternalMapping(mapping.vip, mapping.port, mapping.externalPort, mapping.protocol, lease, mapping.description)
+	} else {
+		err = fmt.Errorf("%s doesn't forward the external port [%d] to another port [%d], only UPnP does", sm.portMapper.Name(), mapping.externalPort, mapping.port)
+	}
+	mapping.active = err == nil
+	if err != nil {
+		mapping.renew = now
+		return err
+	}
+	if granted == 0 {
+		// The mapping is permanent, it doesn't need to be renewed
+		mapping.renew = time.Time{}
+		return nil
+	}
+	mapping.renew = now.Add(granted / 2)
+	return nil
+}
+
+// deleteUPNPMapping removes the mapping from the gateway, with its external port when it isn't the port of the VIP
+func (sm *Manager) deleteUPNPMapping(mapping *upnpMapping) error {
+	if mapper, ok := sm.portMapper.(portmap.ExternalPortClient); ok && mapping.externalPort != mapping.port {
+		return mapper.DeleteExternalMapping(mapping.vip, mapping.port, mapping.externalPort, mapping.protocol)
+	}
+	return sm.portMapper.DeleteMapping(mapping.vip, mapping.port, mapping.protocol)
+}
+
+// upnpServiceOptions returns whether the port of the service is forwarded from the gateway, along with the external
+// port and the protocol of the mapping, from the annotations of the service. Every service is forwarded unless it opts
+// out, or only those that opt in when optIn is set.
+func upnpServiceOptions(svc *v1.Service, port int, protocol string, optIn bool) (bool, int, string, error) {
+	enabled := !optIn
+	if value, exists := svc.Annotations[serviceUPNP]; exists {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, 0, "", fmt.Errorf("invalid %s annotation [%s]", serviceUPNP, value)
+		}
+		enabled = b
+	}
+	if !enabled {
+		return false, 0, "", nil
+	}
+
+	externalPort := port
+	if value, exists := svc.Annotations[serviceUPNPExternalPort]; exists {
+		p, err := strconv.Atoi(value)
+		if err != nil || p < 1 || p > 65535 {
+			return false, 0, "", fmt.Errorf("invalid %s annotation [%s]", serviceUPNPExternalPort, value)
+		}
+		externalPort = p
+	}
+
+	protocol = strings.ToUpper(protocol)
+	if value, exists := svc.Annotations[serviceUPNPProtocol]; exists {
+		protocol = strings.ToUpper(value)
+		if protocol != string(v1.ProtocolTCP) && protocol != string(v1.ProtocolUDP) && protocol != string(v1.ProtocolSCTP) {
+			return false, 0, "", fmt.Errorf("invalid %s annotation [%s]", serviceUPNPProtocol, value)
+		}
+	}
+	return true, externalPort, protocol, nil
+}
+
+// upnpCollector exports the mappings that the gateway forwards, and the gateway once it has been found
+type upnpCollector struct {
+	sm *Manager
+}
+
+// Describe implements prometheus.Collector
+func (c *upnpCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- upnpMappingsDesc
+	ch <- upnpGatewayDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *upnpCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.sm.portMapper == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(upnpGatewayDesc, prometheus.GaugeValue, 1,
+		c.sm.portMapper.Gateway(), c.sm.portMapper.Name(), c.sm.portMapper.ExternalIP())
+
+	active := map[string]int{}
+	c.sm.upnpMutex.Lock()
+	for _, mappings := range c.sm.upnpMappings {
+		for _, mapping := range mappings {
+			if _, exists := active[mapping.vip]; !exists {
+				active[mapping.vip] = 0
+			}
+			if mapping.active {
+				active[mapping.vip]++
+			}
+		}
+	}
+	c.sm.upnpMutex.Unlock()
+	for address, count := range active {
+		ch <- prometheus.MustNewConstMetric(upnpMappingsDesc, prometheus.GaugeValue, float64(count), address)
+	}
+}
@@ -0,0 +1,180 @@
+package manager
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// fakePortMapper grants the lease that it is configured with, a lease of zero is a permanent mapping
+type fakePortMapper struct {
+	granted time.Duration
+	err     error
+	lease   time.Duration
+}
+
+func (f *fakePortMapper) Name() string       { return "fake" }
+func (f *fakePortMapper) Gateway() string    { return "192.168.0.1" }
+func (f *fakePortMapper) ExternalIP() string { return "203.0.113.1" }
+
+func (f *fakePortMapper) AddMapping(_ string, _ int, _ string, lease time.Duration, _ string) (time.Duration, error) {
+	f.lease = lease
+	return f.granted, f.err
+}
+
+func (f *fakePortMapper) DeleteMapping(_ string, _ int, _ string) error {
+	return f.err
+}
+
+// fakeUPNPMapper also forwards another external port, as an UPnP gateway does
+type fakeUPNPMapper struct {
+	fakePortMapper
+	externalPort int
+}
+
+func (f *fakeUPNPMapper) AddExternalMapping(_ string, _, externalPort int, _ string, lease time.Duration, _ string) (time.Duration, error) {
+	f.lease, f.externalPort = lease, externalPort
+	return f.granted, f.err
+}
+
+func (f *fakeUPNPMapper) DeleteExternalMapping(_ string, _, externalPort int, _ string) error {
+	f.externalPort = externalPort
+	return f.err
+}
+
+func TestAddUPNPMapping(t *testing.T) {
+	gateway := &fakePortMapper{granted: time.Hour}
+	sm := &Manager{
+		config:     &kubevip.Config{UPNPLeaseDuration: 3600},
+		portMapper: gateway,
+	}
+	now := time.Now()
+	mapping := &upnpMapping{vip: "192.168.0.10", port: 443, externalPort: 443, protocol: "TCP", description: "web"}
+
+	if err := sm.addUPNPMapping(mapping, now); err != nil {
+		t.Fatalf("addUPNPMapping() error = %v", err)
+	}
+	if gateway.lease != time.Hour {
+		t.Errorf("addUPNPMapping() requested lease = %s, want the configured %s", gateway.lease, time.Hour)
+	}
+	if want := now.Add(30 * time.Minute); !mapping.renew.Equal(want) {
+		t.Errorf("addUPNPMapping() renew = %s, want half way through the lease %s", mapping.renew, want)
+	}
+
+	// A gateway may grant a shorter lease than the one that is requested
+	gateway.granted = 10 * time.Minute
+	if err := sm.addUPNPMapping(mapping, now); err != nil {
+		t.Fatalf("addUPNPMapping() error = %v", err)
+	}
+	if want := now.Add(5 * time.Minute); !mapping.renew.Equal(want) {
+		t.Errorf("addUPNPMapping() renew = %s, want half way through the granted lease %s", mapping.renew, want)
+	}
+
+	gateway.err = errors.New("no answer from the gateway")
+	if err := sm.addUPNPMapping(mapping, now); err == nil {
+		t.Fatal("addUPNPMapping() = nil, want the error of the gateway")
+	}
+	if !mapping.renew.Equal(now) {
+		t.Errorf("addUPNPMapping() renew = %s, want a failed mapping to be retried straight away", mapping.renew)
+	}
+
+	gateway.err, gateway.granted = nil, 0
+	if err := sm.addUPNPMapping(mapping, now); err != nil {
+		t.Fatalf("addUPNPMapping() error = %v", err)
+	}
+	if !mapping.renew.IsZero() {
+		t.Errorf("addUPNPMapping() renew = %s, want a permanent mapping not to be renewed", mapping.renew)
+	}
+}
+
+func TestUPNPExternalPort(t *testing.T) {
+	mapping := &upnpMapping{vip: "192.168.0.10", port: 443, externalPort: 8443, protocol: "TCP", description: "web"}
+
+	// Only an UPnP gateway forwards another external port
+	sm := &Manager{config: &kubevip.Config{}, portMapper: &fakePortMapper{}}
+	if err := sm.addUPNPMapping(mapping, time.Now()); err == nil || mapping.active {
+		t.Error("addUPNPMapping() = nil, want an error as the gateway doesn't forward another external port")
+	}
+
+	gateway := &fakeUPNPMapper{}
+	sm.portMapper = gateway
+	if err := sm.addUPNPMapping(mapping, time.Now()); err != nil || !mapping.active {
+		t.Fatalf("addUPNPMapping() error = %v", err)
+	}
+	if gateway.externalPort != 8443 {
+		t.Errorf("addUPNPMapping() external port = %d, want 8443", gateway.externalPort)
+	}
+	gateway.externalPort = 0
+	if err := sm.deleteUPNPMapping(mapping); err != nil {
+		t.Fatalf("deleteUPNPMapping() error = %v", err)
+	}
+	if gateway.externalPort != 8443 {
+		t.Errorf("deleteUPNPMapping() external port = %d, want 8443", gateway.externalPort)
+	}
+}
+
+func TestUPNPServiceOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		annotations  map[string]string
+		optIn        bool
+		wantEnabled  bool
+		wantPort     int
+		wantProtocol string
+		wantErr      bool
+	}{
+		{"forwarded by default", nil, false, true, 443, "TCP", false},
+		{"opted out", map[string]string{serviceUPNP: "false"}, false, false, 0, "", false},
+		{"not opted in", nil, true, false, 0, "", false},
+		{"opted in", map[string]string{serviceUPNP: "true"}, true, true, 443, "TCP", false},
+		{"external port", map[string]string{serviceUPNPExternalPort: "8443"}, false, true, 8443, "TCP", false},
+		{"protocol", map[string]string{serviceUPNPProtocol: "udp"}, false, true, 443, "UDP", false},
+		{"invalid annotation", map[string]string{serviceUPNP: "yes please"}, false, false, 0, "", true},
+		{"invalid external port", map[string]string{serviceUPNPExternalPort: "70000"}, false, false, 0, "", true},
+		{"invalid protocol", map[string]string{serviceUPNPProtocol: "icmp"}, false, false, 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Annotations: tt.annotations}}
+			enabled, port, protocol, err := upnpServiceOptions(svc, 443, "tcp", tt.optIn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("upnpServiceOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if enabled != tt.wantEnabled || port != tt.wantPort || protocol != tt.wantProtocol {
+				t.Errorf("upnpServiceOptions() = %t, %d, %s, want %t, %d, %s", enabled, port, protocol, tt.wantEnabled, tt.wantPort, tt.wantProtocol)
+			}
+		})
+	}
+}
+
+func TestUPNPCollector(t *testing.T) {
+	sm := &Manager{
+		portMapper: &fakePortMapper{},
+		upnpMappings: map[string][]*upnpMapping{
+			"web": {
+				{vip: "192.168.0.10", port: 443, externalPort: 443, protocol: "TCP", active: true},
+				{vip: "2001:db8::10", port: 443, externalPort: 443, protocol: "TCP"},
+			},
+			"dns": {{vip: "192.168.0.10", port: 53, externalPort: 53, protocol: "UDP", active: true}},
+		},
+	}
+	want := `
+# HELP kube_vip_upnp_gateway_info The gateway that the ports are mapped on, along with the protocol and the external address that was discovered
+# TYPE kube_vip_upnp_gateway_info gauge
+kube_vip_upnp_gateway_info{external_ip="203.0.113.1",gateway="192.168.0.1",protocol="fake"} 1
+# HELP kube_vip_upnp_mappings Ports that the gateway forwards to the VIP, or pinholes that it has opened for it
+# TYPE kube_vip_upnp_mappings gauge
+kube_vip_upnp_mappings{vip="192.168.0.10"} 2
+kube_vip_upnp_mappings{vip="2001:db8::10"} 0
+`
+	if err := testutil.CollectAndCompare(&upnpCollector{sm: sm}, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
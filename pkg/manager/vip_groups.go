@@ -0,0 +1,50 @@
+package manager
+
+import (
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/cluster"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// startVIPGroups will start a leader election for each of the VIP groups, these run alongside whichever engine
+// has been enabled for the control plane and services
+func (sm *Manager) startVIPGroups() error {
+	if len(sm.config.VIPGroups) == 0 {
+		return nil
+	}
+
+	if err := kubevip.ValidateVIPGroups(sm.config.VIPGroups); err != nil {
+		return err
+	}
+
+	for x := range sm.config.VIPGroups {
+		group := &sm.config.VIPGroups[x]
+		groupConfig, err := sm.config.GroupConfig(group)
+		if err != nil {
+			return err
+		}
+
+		groupCluster, err := cluster.InitCluster(groupConfig, false)
+		if err != nil {
+			return err
+		}
+
+		clusterManager, err := initClusterManager(sm)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("(vip group) starting [%s], address [%s], mode [%s], lock name [%s]", group.Name, groupConfig.Address, group.Mode, groupConfig.LeaseName)
+		go func() {
+			if err := groupCluster.StartCluster(groupConfig, clusterManager, nil); err != nil {
+				log.Errorf("(vip group) [%s] error [%v]", group.Name, err)
+				// Trigger the shutdown of this manager instance
+				sm.signalChan <- syscall.SIGINT
+			}
+		}()
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package manager
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	vipsHeldDesc = prometheus.NewDesc("kube_vip_vips_held",
+		"VIPs of the services that are currently held by this node", nil, nil)
+	serviceVIPUpDesc = prometheus.NewDesc("kube_vip_service_vip_up",
+		"Whether the VIP of a service that is advertised by this node is up (1) or down (0)", []string{"namespace", "service", "vip"}, nil)
+)
+
+// vipState is a VIP of a service that is advertised by this node
+type vipState struct {
+	Namespace string
+	Service   string
+	VIP       string
+	Up        bool
+}
+
+// vipStates returns the VIPs of the services that this node advertises sorted by service. In routing table mode a VIP
+// is up as long as it is advertised, as it is routed instead of being bound to the interface.
+func (sm *Manager) vipStates() []vipState {
+	var states []vipState
+	for _, instance := range sm.instances() {
+		if instance.serviceSnapshot == nil {
+			continue
+		}
+		for _, c := range instance.clusters {
+			for _, network := range c.Network {
+				up := true
+				if !sm.config.EnableRoutingTable {
+					set, err := network.IsSet()
+					if err != nil {
+						log.Debugf("unable to check the VIP [%s]: %v", network.IP(), err)
+					}
+					up = set
+				}
+				states = append(states, vipState{
+					Namespace: instance.serviceSnapshot.Namespace,
+					Service:   instance.serviceSnapshot.Name,
+					VIP:       network.IP(),
+					Up:        up,
+				})
+			}
+		}
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Namespace != states[j].Namespace {
+			return states[i].Namespace < states[j].Namespace
+		}
+		if states[i].Service != states[j].Service {
+			return states[i].Service < states[j].Service
+		}
+		return states[i].VIP < states[j].VIP
+	})
+	return states
+}
+
+// vipCollector exports the VIPs of the services that are held by this node, they're checked when they're collected
+type vipCollector struct {
+	sm *Manager
+}
+
+// Describe implements prometheus.Collector
+func (c *vipCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- vipsHeldDesc
+	ch <- serviceVIPUpDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *vipCollector) Collect(ch chan<- prometheus.Metric) {
+	held := 0
+	for _, state := range c.sm.vipStates() {
+		up := 0.0
+		if state.Up {
+			held++
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(serviceVIPUpDesc, prometheus.GaugeValue, up, state.Namespace, state.Service, state.VIP)
+	}
+	ch <- prometheus.MustNewConstMetric(vipsHeldDesc, prometheus.GaugeValue, float64(held))
+}
@@ -0,0 +1,74 @@
+package manager
+
+import (
+	"slices"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/cluster"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/vip"
+)
+
+// boundNetwork is a VIP that is, or isn't, bound to the interface
+type boundNetwork struct {
+	vip.Network
+	address string
+	set     bool
+}
+
+func (n *boundNetwork) IP() string {
+	return n.address
+}
+
+func (n *boundNetwork) IsSet() (bool, error) {
+	return n.set, nil
+}
+
+func TestVIPStates(t *testing.T) {
+	newInstance := func(uid, namespace, name string, networks ...vip.Network) *Instance {
+		return &Instance{
+			UID:             uid,
+			serviceSnapshot: &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}},
+			clusters:        []*cluster.Cluster{{Network: networks}},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		routingTable bool
+		want         []vipState
+	}{
+		{
+			name: "bound addresses",
+			want: []vipState{
+				{Namespace: "default", Service: "api", VIP: "192.168.0.10", Up: true},
+				{Namespace: "default", Service: "web", VIP: "192.168.0.11", Up: false},
+				{Namespace: "default", Service: "web", VIP: "fd00::11", Up: true},
+			},
+		},
+		{
+			name:         "routing table",
+			routingTable: true,
+			want: []vipState{
+				{Namespace: "default", Service: "api", VIP: "192.168.0.10", Up: true},
+				{Namespace: "default", Service: "web", VIP: "192.168.0.11", Up: true},
+				{Namespace: "default", Service: "web", VIP: "fd00::11", Up: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := &Manager{config: &kubevip.Config{EnableRoutingTable: tt.routingTable}}
+			sm.storeServiceInstance(newInstance("2", "default", "web",
+				&boundNetwork{address: "fd00::11", set: true}, &boundNetwork{address: "192.168.0.11"}))
+			sm.storeServiceInstance(newInstance("1", "default", "api", &boundNetwork{address: "192.168.0.10", set: true}))
+
+			if got := sm.vipStates(); !slices.Equal(got, tt.want) {
+				t.Errorf("vipStates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
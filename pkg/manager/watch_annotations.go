@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/kube-vip/kube-vip/pkg/bgp"
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/davecgh/go-spew/spew"
@@ -56,9 +57,11 @@ func (sm *Manager) annotationsWatcher() error {
 	// they're as needed
 	log.Warn(err)
 
-	rw, err := watchtools.NewRetryWatcher(node.ResourceVersion, &cache.ListWatch{
+	rw, err := watchtools.NewRetryWatcher(nodeList.ResourceVersion, &cache.ListWatch{
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-			return sm.clientSet.CoreV1().Nodes().Watch(context.Background(), listOptions)
+			// The options have the version that the watch resumes from
+			options.LabelSelector = listOptions.LabelSelector
+			return sm.clientSet.CoreV1().Nodes().Watch(context.Background(), options)
 		},
 	})
 	if err != nil {
@@ -246,3 +249,134 @@ func parseBgpAnnotations(bgpConfig bgp.Config, node *v1.Node, prefix string) (bg
 
 	return bgpConfig, bgpPeer, nil
 }
+
+// watchBGPAnnotations will keep the BGP peers in line with the annotations of this node until the context is
+// cancelled, so that routers can be added to or removed from the annotations without restarting kube-vip
+func (sm *Manager) watchBGPAnnotations(ctx context.Context) error {
+	listOptions := metav1.ListOptions{
+		LabelSelector: labels.Set{"kubernetes.io/hostname": sm.config.NodeName}.String(),
+	}
+	nodeList, err := sm.clientSet.CoreV1().Nodes().List(ctx, listOptions)
+	if err != nil {
+		return err
+	}
+	// The annotations may have changed since they were read at startup
+	for x := range nodeList.Items {
+		sm.applyBGPAnnotations(&nodeList.Items[x])
+	}
+
+	rw, err := watchtools.NewRetryWatcher(nodeList.ResourceVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = listOptions.LabelSelector
+			return sm.clientSet.CoreV1().Nodes().Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating annotations watcher: %s", err.Error())
+	}
+
+	go func() {
+		select {
+		case <-sm.shutdownChan:
+		case <-ctx.Done():
+		}
+		log.Debug("[annotations] stopping the BGP peers watcher")
+		rw.Stop()
+	}()
+
+	go func() {
+		for event := range rw.ResultChan() {
+			if event.Type != watch.Modified {
+				continue
+			}
+			node, ok := event.Object.(*v1.Node)
+			if !ok {
+				log.Error("[annotations] unable to parse Kubernetes Node from Annotation watcher")
+				continue
+			}
+			sm.applyBGPAnnotations(node)
+		}
+	}()
+	return nil
+}
+
+// applyBGPAnnotations will peer with the routers that have been added to the annotations of the node, and close the
+// sessions with the routers that have been removed from them. The AS and the router ID of this node can't be changed
+// without restarting the BGP server, so they're only read at startup.
+func (sm *Manager) applyBGPAnnotations(node *v1.Node) {
+	sm.mutex.Lock()
+	bgpConfig, _, err := parseBgpAnnotations(sm.config.BGPConfig, node, sm.config.Annotations)
+	if err != nil {
+		sm.mutex.Unlock()
+		log.Warnf("[annotations] the BGP peers are unchanged: %v", err)
+		return
+	}
+	if bgpConfig.AS != sm.config.BGPConfig.AS || bgpConfig.RouterID != sm.config.BGPConfig.RouterID {
+		log.Warnf("[annotations] the node-asn [%d] and src-ip [%s] are applied when kube-vip restarts", bgpConfig.AS, bgpConfig.RouterID)
+	}
+	peers, added, updated, removed := bgpPeerChanges(sm.config.BGPConfig.Peers, bgpConfig.Peers, sm.config.BGPPeerConfig.Password)
+	sm.config.BGPConfig.Peers = peers
+	sm.mutex.Unlock()
+
+	if sm.bgpServer == nil {
+		return
+	}
+	for _, peer := range removed {
+		if err := sm.bgpServer.DeletePeer(peer.Address); err != nil {
+			log.Errorf("[annotations] %v", err)
+			continue
+		}
+		sm.bgpSessionInfoGauge.DeletePartialMatch(prometheus.Labels{"peer": fmt.Sprintf("%s:%d", peer.Address, 179)})
+		log.Infof("[annotations] BGP peer [%s] has been removed", peer.Address)
+	}
+	for _, peer := range added {
+		if err := sm.bgpServer.AddPeer(peer); err != nil {
+			log.Errorf("[annotations] unable to add BGP peer [%s]: %v", peer.Address, err)
+			continue
+		}
+		log.Infof("[annotations] BGP peer [%s] has been added", peer.Address)
+	}
+	for _, peer := range updated {
+		if err := sm.bgpServer.UpdatePeer(peer); err != nil {
+			log.Errorf("[annotations] unable to update BGP peer [%s]: %v", peer.Address, err)
+			continue
+		}
+		log.Infof("[annotations] BGP peer [%s] has changed, the session has been reset", peer.Address)
+	}
+}
+
+// bgpPeerChanges compares the peers from the annotations with the current peers by their address, and returns the
+// peers to use along with the changes to make. A peer without a password in the annotations keeps its current
+// password, or takes the password of every peer, as it can be read from a secret or a file instead.
+func bgpPeerChanges(current, annotated []bgp.Peer, password string) (peers, added, updated, removed []bgp.Peer) {
+	existing := map[string]bgp.Peer{}
+	for _, peer := range current {
+		existing[peer.Address] = peer
+	}
+
+	for _, peer := range annotated {
+		previous, exists := existing[peer.Address]
+		if peer.Password == "" {
+			peer.Password = password
+			if exists {
+				peer.Password = previous.Password
+			}
+		}
+		peers = append(peers, peer)
+
+		switch {
+		case !exists:
+			added = append(added, peer)
+		case previous != peer:
+			updated = append(updated, peer)
+		}
+		delete(existing, peer.Address)
+	}
+
+	for _, peer := range current {
+		if _, exists := existing[peer.Address]; exists {
+			removed = append(removed, peer)
+		}
+	}
+	return peers, added, updated, removed
+}
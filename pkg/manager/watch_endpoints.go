@@ -4,27 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
 
+	"github.com/kube-vip/kube-vip/pkg/bgp"
 	"github.com/kube-vip/kube-vip/pkg/kubevip"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/tools/cache"
-	watchtools "k8s.io/client-go/tools/watch"
 	"k8s.io/client-go/util/retry"
 )
 
 type epProvider interface {
-	createRetryWatcher(context.Context, *Manager,
-		*v1.Service) (*watchtools.RetryWatcher, error)
+	createWatcher(*Manager, *v1.Service) (watch.Interface, error)
 	getAllEndpoints() ([]string, error)
 	getLocalEndpoints(string, *kubevip.Config) ([]string, error)
 	getLabel() string
@@ -38,23 +36,16 @@ type endpointsProvider struct {
 	endpoints *v1.Endpoints
 }
 
-func (ep *endpointsProvider) createRetryWatcher(ctx context.Context, sm *Manager,
-	service *v1.Service) (*watchtools.RetryWatcher, error) {
-	opts := metav1.ListOptions{
-		FieldSelector: fields.OneTermEqualSelector("metadata.name", service.Name).String(),
-	}
-
-	rw, err := watchtools.NewRetryWatcher("1", &cache.ListWatch{
-		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-			return sm.clientSet.CoreV1().Endpoints(service.Namespace).Watch(ctx, opts)
-		},
+func (ep *endpointsProvider) createWatcher(sm *Manager, service *v1.Service) (watch.Interface, error) {
+	informer := sm.informers.Core().V1().Endpoints().Informer()
+	w, err := newInformerWatch(informer, func(o metav1.Object) bool {
+		return o.GetNamespace() == service.Namespace && o.GetName() == service.Name
 	})
-
 	if err != nil {
 		return nil, fmt.Errorf("error creating endpoint watcher: %s", err.Error())
 	}
-
-	return rw, nil
+	sm.startInformers()
+	return w, nil
 }
 
 func (ep *endpointsProvider) loadObject(endpoints runtime.Object, cancel context.CancelFunc) error {
@@ -142,6 +133,13 @@ func (ep *endpointsProvider) getProtocol() string {
 	return ""
 }
 
+// usesAllEndpoints returns true if the service is advertised by every node with routes to all of its endpoints, rather
+// than by the nodes with local endpoints
+func (sm *Manager) usesAllEndpoints(service *v1.Service) bool {
+	return (sm.config.EnableBGP || sm.config.EnableRoutingTable) && !sm.config.EnableLeaderElection && !sm.config.EnableServicesElection &&
+		service.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyTypeCluster
+}
+
 func (sm *Manager) watchEndpoint(ctx context.Context, id string, service *v1.Service, wg *sync.WaitGroup, provider epProvider) error {
 	log.Infof("[%s] watching for service [%s] in namespace [%s]", provider.getLabel(), service.Name, service.Namespace)
 	// Use a restartable watcher, as this should help in the event of etcd or timeout issues
@@ -150,7 +148,7 @@ func (sm *Manager) watchEndpoint(ctx context.Context, id string, service *v1.Ser
 
 	var leaderElectionActive bool
 
-	rw, err := provider.createRetryWatcher(leaderContext, sm, service)
+	rw, err := provider.createWatcher(sm, service)
 	if err != nil {
 		cancel()
 		return fmt.Errorf("[%s] error watching endpoints: %w", provider.getLabel(), err)
@@ -186,6 +184,11 @@ func (sm *Manager) watchEndpoint(ctx context.Context, id string, service *v1.Ser
 	ch := rw.ResultChan()
 
 	var lastKnownGoodEndpoint string
+	// The pod that is SNATed by this node, when the egress is placed on the node of the pod rather than the leader
+	var podEgressIP string
+	defer func() {
+		sm.placePodEgress(service, podEgressIP, "")
+	}()
 	for event := range ch {
 		activeEndpointAnnotation := activeEndpoint
 		// We need to inspect the event and get ResourceVersion out of it
@@ -203,8 +206,7 @@ func (sm *Manager) watchEndpoint(ctx context.Context, id string, service *v1.Ser
 
 			// Build endpoints
 			var endpoints []string
-			if (sm.config.EnableBGP || sm.config.EnableRoutingTable) && !sm.config.EnableLeaderElection && !sm.config.EnableServicesElection &&
-				service.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyTypeCluster {
+			if sm.usesAllEndpoints(service) {
 				if endpoints, err = provider.getAllEndpoints(); err != nil {
 					return fmt.Errorf("[%s] error getting all endpoints: %w", provider.getLabel(), err)
 				}
@@ -253,6 +255,21 @@ func (sm *Manager) watchEndpoint(ctx context.Context, id string, service *v1.Ser
 				// Set the service accordingly
 				if service.Annotations[egress] == "true" {
 					service.Annotations[activeEndpointAnnotation] = lastKnownGoodEndpoint
+					if sm.egressPlacement(service) == kubevip.EgressPlacementPod {
+						// The endpoints can be those of every node, the egress is placed on the pod of this node
+						local, err := provider.getLocalEndpoints(id, sm.config)
+						if err != nil {
+							return fmt.Errorf("[%s] error getting local endpoints: %w", provider.getLabel(), err)
+						}
+						podIP := ""
+						if len(local) != 0 {
+							podIP = local[0]
+							if slices.Contains(local, podEgressIP) {
+								podIP = podEgressIP
+							}
+						}
+						podEgressIP = sm.placePodEgress(service, podEgressIP, podIP)
+					}
 				}
 
 				if !leaderElectionActive && sm.config.EnableServicesElection {
@@ -299,6 +316,7 @@ func (sm *Manager) watchEndpoint(ctx context.Context, id string, service *v1.Ser
 											if isUpdated {
 												log.Debugf("[%s] updated route: %s", provider.getLabel(), cluster.Network[i].IP())
 											}
+											configuredLocalRoutes.Store(string(service.UID), true)
 										} else {
 											// If other error occurs, return error
 											return fmt.Errorf("[%s] error adding route: %s", provider.getLabel(), err.Error())
@@ -319,7 +337,7 @@ func (sm *Manager) watchEndpoint(ctx context.Context, id string, service *v1.Ser
 						if instance := sm.findServiceInstance(service); instance != nil {
 							for _, cluster := range instance.clusters {
 								for i := range cluster.Network {
-									address := fmt.Sprintf("%s/%s", cluster.Network[i].IP(), sm.config.VIPCIDR)
+									address := bgp.HostCIDR(cluster.Network[i].IP())
 									log.Debugf("[%s] attempting to advertise BGP service: %s", provider.getLabel(), address)
 									err := sm.bgpServer.AddHost(address)
 									if err != nil {
@@ -350,7 +368,7 @@ func (sm *Manager) watchEndpoint(ctx context.Context, id string, service *v1.Ser
 						if instance := sm.findServiceInstance(service); instance != nil {
 							for _, cluster := range instance.clusters {
 								for i := range cluster.Network {
-									address := fmt.Sprintf("%s/%s", cluster.Network[i].IP(), sm.config.VIPCIDR)
+									address := bgp.HostCIDR(cluster.Network[i].IP())
 									err := sm.bgpServer.DelHost(address)
 									if err != nil {
 										log.Errorf("[%s] error deleting BGP host%s:  %s\n", provider.getLabel(), address, err.Error())
@@ -366,6 +384,8 @@ func (sm *Manager) watchEndpoint(ctx context.Context, id string, service *v1.Ser
 					}
 				}
 
+				podEgressIP = sm.placePodEgress(service, podEgressIP, "")
+
 				// If there are no local endpoints, and we had one then remove it and stop the leaderElection
 				if lastKnownGoodEndpoint != "" {
 					log.Warnf("[%s] existing [%s] has been removed, no remaining endpoints for leaderElection", provider.getLabel(), lastKnownGoodEndpoint)
@@ -384,8 +404,7 @@ func (sm *Manager) watchEndpoint(ctx context.Context, id string, service *v1.Ser
 			if !sm.config.EnableServicesElection && !sm.config.EnableLeaderElection {
 				// find all existing local endpoints
 				var endpoints []string
-				if (sm.config.EnableBGP || sm.config.EnableRoutingTable) && !sm.config.EnableLeaderElection && !sm.config.EnableServicesElection &&
-					service.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyTypeCluster {
+				if sm.usesAllEndpoints(service) {
 					if endpoints, err = provider.getAllEndpoints(); err != nil {
 						return fmt.Errorf("[%s] error getting all endpoints: %w", provider.getLabel(), err)
 					}
@@ -451,7 +470,7 @@ func (sm *Manager) clearBGPHosts(service *v1.Service) {
 	if instance := sm.findServiceInstance(service); instance != nil {
 		for _, cluster := range instance.clusters {
 			for i := range cluster.Network {
-				address := fmt.Sprintf("%s/%s", cluster.Network[i].IP(), sm.config.VIPCIDR)
+				address := bgp.HostCIDR(cluster.Network[i].IP())
 				err := sm.bgpServer.DelHost(address)
 				if err != nil {
 					log.Errorf("[endpoint] error deleting BGP host %s\n", err.Error())
@@ -9,11 +9,8 @@ import (
 	v1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/tools/cache"
-	watchtools "k8s.io/client-go/tools/watch"
 	"k8s.io/client-go/util/retry"
 )
 
@@ -22,24 +19,16 @@ type endpointslicesProvider struct {
 	endpoints *discoveryv1.EndpointSlice
 }
 
-func (ep *endpointslicesProvider) createRetryWatcher(ctx context.Context, sm *Manager,
-	service *v1.Service) (*watchtools.RetryWatcher, error) {
-	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/service-name": service.Name}}
-
-	opts := metav1.ListOptions{
-		LabelSelector: labels.Set(labelSelector.MatchLabels).String(),
-	}
-
-	rw, err := watchtools.NewRetryWatcher("1", &cache.ListWatch{
-		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-			return sm.clientSet.DiscoveryV1().EndpointSlices(service.Namespace).Watch(ctx, opts)
-		},
+func (ep *endpointslicesProvider) createWatcher(sm *Manager, service *v1.Service) (watch.Interface, error) {
+	informer := sm.informers.Discovery().V1().EndpointSlices().Informer()
+	w, err := newInformerWatch(informer, func(o metav1.Object) bool {
+		return o.GetNamespace() == service.Namespace && o.GetLabels()[discoveryv1.LabelServiceName] == service.Name
 	})
 	if err != nil {
 		return nil, fmt.Errorf("[%s] error creating endpointslices watcher: %s", ep.label, err.Error())
 	}
-
-	return rw, nil
+	sm.startInformers()
+	return w, nil
 }
 
 func (ep *endpointslicesProvider) loadObject(endpoints runtime.Object, cancel context.CancelFunc) error {
@@ -61,9 +50,29 @@ func (ep *endpointslicesProvider) getAllEndpoints() ([]string, error) {
 }
 
 func (ep *endpointslicesProvider) getLocalEndpoints(id string, _ *kubevip.Config) ([]string, error) {
+	// The ready endpoints are used, unless there are only terminating endpoints that are still serving (as kube-proxy
+	// does for the local traffic policy)
+	localEndpoints := ep.localEndpoints(id, isReady)
+	if len(localEndpoints) == 0 {
+		localEndpoints = ep.localEndpoints(id, isServingTerminating)
+	}
+	return localEndpoints, nil
+}
+
+// isReady returns true if the endpoint is ready, an unknown condition is ready
+func isReady(conditions discoveryv1.EndpointConditions) bool {
+	return conditions.Ready == nil || *conditions.Ready
+}
+
+// isServingTerminating returns true if the endpoint is terminating but still serving
+func isServingTerminating(conditions discoveryv1.EndpointConditions) bool {
+	return conditions.Serving != nil && *conditions.Serving && conditions.Terminating != nil && *conditions.Terminating
+}
+
+func (ep *endpointslicesProvider) localEndpoints(id string, selected func(discoveryv1.EndpointConditions) bool) []string {
 	var localEndpoints []string
 	for _, endpoint := range ep.endpoints.Endpoints {
-		if !*endpoint.Conditions.Serving {
+		if !selected(endpoint.Conditions) {
 			continue
 		}
 		for _, address := range endpoint.Addresses {
@@ -87,7 +96,7 @@ func (ep *endpointslicesProvider) getLocalEndpoints(id string, _ *kubevip.Config
 			}
 		}
 	}
-	return localEndpoints, nil
+	return localEndpoints
 }
 
 func (ep *endpointslicesProvider) updateServiceAnnotation(endpoint, endpointIPv6 string, service *v1.Service, sm *Manager) error {
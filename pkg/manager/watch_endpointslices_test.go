@@ -0,0 +1,62 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+)
+
+func TestEndpointSlicesLocalEndpoints(t *testing.T) {
+	node, other := "node-a", "node-b"
+	yes, no := true, false
+	endpoint := func(address string, nodeName *string, ready, serving, terminating *bool) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			Addresses:  []string{address},
+			NodeName:   nodeName,
+			Conditions: discoveryv1.EndpointConditions{Ready: ready, Serving: serving, Terminating: terminating},
+		}
+	}
+	tests := []struct {
+		name      string
+		endpoints []discoveryv1.Endpoint
+		want      []string
+	}{
+		{
+			name:      "ready",
+			endpoints: []discoveryv1.Endpoint{endpoint("10.0.0.1", &node, &yes, &yes, &no), endpoint("10.0.0.2", &other, &yes, &yes, &no)},
+			want:      []string{"10.0.0.1"},
+		},
+		{
+			name:      "unknown readiness",
+			endpoints: []discoveryv1.Endpoint{endpoint("10.0.0.1", &node, nil, nil, nil)},
+			want:      []string{"10.0.0.1"},
+		},
+		{
+			name:      "not ready",
+			endpoints: []discoveryv1.Endpoint{endpoint("10.0.0.1", &node, &no, &no, &no)},
+		},
+		{
+			name:      "only terminating",
+			endpoints: []discoveryv1.Endpoint{endpoint("10.0.0.1", &node, &no, &yes, &yes), endpoint("10.0.0.2", &node, &no, &no, &yes)},
+			want:      []string{"10.0.0.1"},
+		},
+		{
+			name:      "ready before terminating",
+			endpoints: []discoveryv1.Endpoint{endpoint("10.0.0.1", &node, &no, &yes, &yes), endpoint("10.0.0.2", &node, &yes, &yes, &no)},
+			want:      []string{"10.0.0.2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := &endpointslicesProvider{label: "endpointslices", endpoints: &discoveryv1.EndpointSlice{Endpoints: tt.endpoints}}
+			got, err := ep.getLocalEndpoints(node, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getLocalEndpoints() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
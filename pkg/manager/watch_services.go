@@ -5,17 +5,15 @@ import (
 	"fmt"
 	"sync"
 
-	"github.com/davecgh/go-spew/spew"
+	"github.com/kube-vip/kube-vip/pkg/bgp"
 	"github.com/kube-vip/kube-vip/pkg/vip"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
-	watchtools "k8s.io/client-go/tools/watch"
+	"k8s.io/client-go/util/workqueue"
 )
 
 // TODO: Fix the naming of these contexts
@@ -43,7 +41,25 @@ func init() {
 	watchedService = make(map[string]bool)
 }
 
-// This function handles the watching of a services endpoints and updates a load balancers endpoint configurations accordingly
+// serviceStateMutex protects the maps of the active and watched services, they are updated by the workers of the
+// services watcher and by the leader elections of the services
+var serviceStateMutex sync.Mutex
+
+func isServiceActive(uid string) bool {
+	serviceStateMutex.Lock()
+	defer serviceStateMutex.Unlock()
+	return activeService[uid]
+}
+
+func setServiceActive(uid string, active bool) {
+	serviceStateMutex.Lock()
+	defer serviceStateMutex.Unlock()
+	activeService[uid] = active
+}
+
+// This function handles the watching of a services endpoints and updates a load balancers endpoint configurations accordingly.
+// The services come from the shared informer, the changes are queued by the key of the service and synchronised by a
+// bounded pool of workers (a service is only handled by one worker at a time).
 func (sm *Manager) servicesWatcher(ctx context.Context, serviceFunc func(context.Context, *v1.Service, *sync.WaitGroup) error) error {
 	// Watch function
 	var wg sync.WaitGroup
@@ -68,265 +84,314 @@ func (sm *Manager) servicesWatcher(ctx context.Context, serviceFunc func(context
 		log.Infof("(svcs) starting services watcher for services in namespace [%s]", sm.config.ServiceNamespace)
 	}
 
-	// Use a restartable watcher, as this should help in the event of etcd or timeout issues
-	rw, err := watchtools.NewRetryWatcher("1", &cache.ListWatch{
-		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-			return sm.clientSet.CoreV1().Services(sm.config.ServiceNamespace).Watch(ctx, metav1.ListOptions{})
+	queue := workqueue.NewRateLimitingQueueWithConfig(workqueue.DefaultControllerRateLimiter(), workqueue.RateLimitingQueueConfig{Name: "services"})
+	// The informer no longer has a deleted service when its key is processed, so its last state is kept here
+	var deleted sync.Map
+	// The services that have been modified since they were last processed
+	var modified sync.Map
+
+	informer := sm.informers.Core().V1().Services().Informer()
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			sm.countServiceWatchEvent.With(prometheus.Labels{"type": string(watch.Added)}).Add(1)
+			if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
+				queue.Add(key)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			sm.countServiceWatchEvent.With(prometheus.Labels{"type": string(watch.Modified)}).Add(1)
+			if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
+				modified.Store(key, true)
+				queue.Add(key)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			sm.countServiceWatchEvent.With(prometheus.Labels{"type": string(watch.Deleted)}).Add(1)
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			svc, ok := obj.(*v1.Service)
+			if !ok {
+				log.Errorf("(svcs) unable to parse Kubernetes services from API watcher")
+				return
+			}
+			if key, err := cache.MetaNamespaceKeyFunc(svc); err == nil {
+				deleted.Store(key, svc)
+				queue.Add(key)
+			}
 		},
 	})
 	if err != nil {
 		return fmt.Errorf("error creating services watcher: %s", err.Error())
 	}
-	exitFunction := make(chan struct{})
-	go func() {
-		select {
-		case <-sm.shutdownChan:
-			log.Debug("(svcs) shutdown called")
-			// Stop the retry watcher
-			rw.Stop()
-			return
-		case <-exitFunction:
-			log.Debug("(svcs) function ending")
-			// Stop the retry watcher
-			rw.Stop()
-			return
+	sm.startInformers()
+
+	workers := sm.config.ServicesWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	var workersGroup sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		workersGroup.Add(1)
+		go func() {
+			defer workersGroup.Done()
+			for sm.processNextService(queue, &deleted, &modified, serviceFunc, &wg) {
+			}
+		}()
+	}
+
+	select {
+	case <-sm.shutdownChan:
+		log.Debug("(svcs) shutdown called")
+	case <-ctx.Done():
+		log.Debug("(svcs) function ending")
+	}
+	if err := informer.RemoveEventHandler(registration); err != nil {
+		log.Warnf("(svcs) unable to remove the event handler: %v", err)
+	}
+	queue.ShutDown()
+	workersGroup.Wait()
+
+	log.Warnln("Stopping watching services for type: LoadBalancer in all namespaces")
+	return nil
+}
+
+// processNextService synchronises the next service from the queue, a failed service is retried with a backoff. It
+// returns false once the queue has been shut down.
+func (sm *Manager) processNextService(queue workqueue.RateLimitingInterface, deleted, modified *sync.Map, serviceFunc func(context.Context, *v1.Service, *sync.WaitGroup) error, wg *sync.WaitGroup) bool {
+	item, shutdown := queue.Get()
+	if shutdown {
+		return false
+	}
+	defer queue.Done(item)
+	key := item.(string)
+
+	// A service that was deleted and then created again with the same name is removed before it is added
+	if svc, ok := deleted.LoadAndDelete(key); ok {
+		if err := sm.watchedServiceDeleted(svc.(*v1.Service)); err != nil {
+			log.Errorf("(svcs) [%s] %v, retrying", key, err)
+			deleted.Store(key, svc)
+			queue.AddRateLimited(key)
+			return true
 		}
-	}()
-	ch := rw.ResultChan()
+	}
 
-	// Used for tracking an active endpoint / pod
-	for event := range ch {
-		sm.countServiceWatchEvent.With(prometheus.Labels{"type": string(event.Type)}).Add(1)
+	obj, exists, err := sm.informers.Core().V1().Services().Informer().GetIndexer().GetByKey(key)
+	if err == nil && exists {
+		_, wasModified := modified.LoadAndDelete(key)
+		err = sm.watchedServiceAdded(obj.(*v1.Service), wasModified, serviceFunc, wg)
+	}
+	if err != nil {
+		log.Errorf("(svcs) [%s] %v, retrying", key, err)
+		queue.AddRateLimited(key)
+		return true
+	}
+	queue.Forget(key)
+	return true
+}
 
-		// We need to inspect the event and get ResourceVersion out of it
-		switch event.Type {
-		case watch.Added, watch.Modified:
-			// log.Debugf("Endpoints for service [%s] have been Created or modified", s.service.ServiceName)
-			svc, ok := event.Object.(*v1.Service)
-			if !ok {
-				return fmt.Errorf("unable to parse Kubernetes services from API watcher")
-			}
+// watchedServiceAdded starts advertising a service that has been added or modified, if it isn't already
+func (sm *Manager) watchedServiceAdded(svc *v1.Service, modified bool, serviceFunc func(context.Context, *v1.Service, *sync.WaitGroup) error, wg *sync.WaitGroup) error {
+	// We only care about LoadBalancer services
+	if svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+		return nil
+	}
 
-			// We only care about LoadBalancer services
-			if svc.Spec.Type != v1.ServiceTypeLoadBalancer {
-				break
-			}
+	svcAddresses := fetchServiceAddresses(svc)
 
-			svcAddresses := fetchServiceAddresses(svc)
+	// We only care about LoadBalancer services that have been allocated an address, or that can be allocated one
+	if len(svcAddresses) <= 0 && sm.ipam == nil {
+		return nil
+	}
 
-			// We only care about LoadBalancer services that have been allocated an address
-			if len(svcAddresses) <= 0 {
-				break
-			}
+	// Check the loadBalancer class
+	if svc.Spec.LoadBalancerClass != nil {
+		// if this isn't nil then it has been configured, check if it the kube-vip loadBalancer class
+		if *svc.Spec.LoadBalancerClass != sm.config.LoadBalancerClassName {
+			log.Infof("(svcs) [%s] specified the loadBalancer class [%s], ignoring", svc.Name, *svc.Spec.LoadBalancerClass)
+			return nil
+		}
+	} else if sm.config.LoadBalancerClassOnly {
+		// if kube-vip is configured to only recognize services with kube-vip's lb class, then ignore the services without any lb class
+		log.Infof("(svcs) kube-vip configured to only recognize services with kube-vip's lb class but the service [%s] didn't specify any loadBalancer class, ignoring", svc.Name)
+		return nil
+	}
 
-			// Check the loadBalancer class
-			if svc.Spec.LoadBalancerClass != nil {
-				// if this isn't nil then it has been configured, check if it the kube-vip loadBalancer class
-				if *svc.Spec.LoadBalancerClass != sm.config.LoadBalancerClassName {
-					log.Infof("(svcs) [%s] specified the loadBalancer class [%s], ignoring", svc.Name, *svc.Spec.LoadBalancerClass)
-					break
-				}
-			} else if sm.config.LoadBalancerClassOnly {
-				// if kube-vip is configured to only recognize services with kube-vip's lb class, then ignore the services without any lb class
-				log.Infof("(svcs) kube-vip configured to only recognize services with kube-vip's lb class but the service [%s] didn't specify any loadBalancer class, ignoring", svc.Name)
-				break
-			}
+	// Check if we ignore this service
+	if svc.Annotations["kube-vip.io/ignore"] == "true" {
+		log.Infof("(svcs) [%s] has an ignore annotation for kube-vip", svc.Name)
+		return nil
+	}
 
-			// Check if we ignore this service
-			if svc.Annotations["kube-vip.io/ignore"] == "true" {
-				log.Infof("(svcs) [%s] has an ignore annotation for kube-vip", svc.Name)
-				break
-			}
+	// Check if the annotation or a policy has assigned this service to another engine
+	if sm.ignoreServiceEngine(svc) {
+		return nil
+	}
 
-			// The modified event should only be triggered if the service has been modified (i.e. moved somewhere else)
-			if event.Type == watch.Modified {
-				for _, addr := range svcAddresses {
-					// log.Debugf("(svcs) Retreiving local addresses, to ensure that this modified address doesn't exist: %s", addr)
-					f, err := vip.GarbageCollect(sm.config.Interface, addr)
-					if err != nil {
-						log.Errorf("(svcs) cleaning existing address error: [%s]", err.Error())
-					}
-					if f {
-						log.Warnf("(svcs) already found existing address [%s] on adapter [%s]", addr, sm.config.Interface)
-					}
-				}
+	// The service is advertised once it has been modified with the addresses allocated from the ip pools
+	if len(svcAddresses) == 0 {
+		return sm.allocateServiceAddresses(svc)
+	}
+
+	// The modified event should only be triggered if the service has been modified (i.e. moved somewhere else)
+	if modified {
+		for _, addr := range svcAddresses {
+			// log.Debugf("(svcs) Retreiving local addresses, to ensure that this modified address doesn't exist: %s", addr)
+			f, err := vip.GarbageCollect(sm.config.Interface, addr)
+			if err != nil {
+				log.Errorf("(svcs) cleaning existing address error: [%s]", err.Error())
 			}
-			// Scenarios:
-			// 1.
-			if !activeService[string(svc.UID)] {
-				log.Debugf("(svcs) [%s] has been added/modified with addresses [%s]", svc.Name, fetchServiceAddresses(svc))
-
-				wg.Add(1)
-				activeServiceLoadBalancer[string(svc.UID)], activeServiceLoadBalancerCancel[string(svc.UID)] = context.WithCancel(context.TODO())
-				// Background the services election
-				// EnableServicesElection enabled
-				// watchEndpoint will do a ServicesElection by Service and understands local endpoints
-				//
-				// EnableRoutingTable enabled and EnableLeaderElection disabled
-				// watchEndpoint will also not do a leaderElection by service.
-				if sm.config.EnableServicesElection ||
-					((sm.config.EnableRoutingTable || sm.config.EnableBGP) && (!sm.config.EnableLeaderElection && !sm.config.EnableServicesElection)) {
-					if svc.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyTypeLocal {
-						// Start an endpoint watcher if we're not watching it already
-						if !watchedService[string(svc.UID)] {
-							// background the endpoint watcher
-							go func() {
-								if svc.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyTypeLocal {
-									// Add Endpoint or EndpointSlices watcher
-									wg.Add(1)
-									var provider epProvider
-									if !sm.config.EnableEndpointSlices {
-										provider = &endpointsProvider{label: "endpoints"}
-									} else {
-										provider = &endpointslicesProvider{label: "endpointslices"}
-									}
-									if err = sm.watchEndpoint(activeServiceLoadBalancer[string(svc.UID)], sm.config.NodeName, svc, &wg, provider); err != nil {
-										log.Error(err)
-									}
-									wg.Done()
-								}
-							}()
-
-							if (sm.config.EnableRoutingTable || sm.config.EnableBGP) && (!sm.config.EnableLeaderElection && !sm.config.EnableServicesElection) {
-								wg.Add(1)
-								go func() {
-									err = serviceFunc(activeServiceLoadBalancer[string(svc.UID)], svc, &wg)
-									if err != nil {
-										log.Error(err)
-									}
-									wg.Done()
-								}()
-							}
-							// We're now watching this service
-							watchedService[string(svc.UID)] = true
-						}
-					} else if (sm.config.EnableBGP || sm.config.EnableRoutingTable) && (!sm.config.EnableLeaderElection && !sm.config.EnableServicesElection) {
-						go func() {
-							if svc.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyTypeCluster {
-								// Add Endpoint watcher
-								wg.Add(1)
-								var provider epProvider
-								if !sm.config.EnableEndpointSlices {
-									provider = &endpointsProvider{label: "endpoints"}
-								} else {
-									provider = &endpointslicesProvider{label: "endpointslices"}
-								}
-								if err = sm.watchEndpoint(activeServiceLoadBalancer[string(svc.UID)], sm.config.NodeName, svc, &wg, provider); err != nil {
-									log.Error(err)
-								}
-								wg.Done()
-							}
-						}()
-
-						wg.Add(1)
-						go func() {
-							err = serviceFunc(activeServiceLoadBalancer[string(svc.UID)], svc, &wg)
-							if err != nil {
-								log.Error(err)
-							}
-							wg.Done()
-						}()
-					} else {
-						// Increment the waitGroup before the service Func is called (Done is completed in there)
-						wg.Add(1)
-						go func() {
-							err = serviceFunc(activeServiceLoadBalancer[string(svc.UID)], svc, &wg)
-							if err != nil {
-								log.Error(err)
-							}
-							wg.Done()
-						}()
-					}
-				} else {
-					// Increment the waitGroup before the service Func is called (Done is completed in there)
-					wg.Add(1)
-					err = serviceFunc(activeServiceLoadBalancer[string(svc.UID)], svc, &wg)
-					if err != nil {
-						log.Error(err)
-					}
-					wg.Done()
-				}
-				activeService[string(svc.UID)] = true
+			if f {
+				log.Warnf("(svcs) already found existing address [%s] on adapter [%s]", addr, sm.config.Interface)
 			}
-		case watch.Deleted:
-			svc, ok := event.Object.(*v1.Service)
-			if !ok {
-				return fmt.Errorf("unable to parse Kubernetes services from API watcher")
+		}
+	}
+	// Scenarios:
+	// 1.
+	uid := string(svc.UID)
+	if isServiceActive(uid) {
+		return nil
+	}
+	log.Debugf("(svcs) [%s] has been added/modified with addresses [%s]", svc.Name, fetchServiceAddresses(svc))
+
+	wg.Add(1)
+	svcCtx, svcCancel := context.WithCancel(context.TODO())
+	serviceStateMutex.Lock()
+	activeServiceLoadBalancer[uid], activeServiceLoadBalancerCancel[uid] = svcCtx, svcCancel
+	watched := watchedService[uid]
+	serviceStateMutex.Unlock()
+
+	startServiceFunc := func() {
+		wg.Add(1)
+		go func() {
+			if err := serviceFunc(svcCtx, svc, wg); err != nil {
+				log.Error(err)
+			}
+			wg.Done()
+		}()
+	}
+	// Add Endpoint or EndpointSlices watcher
+	startEndpointWatcher := func() {
+		wg.Add(1)
+		go func() {
+			var provider epProvider
+			if !sm.config.EnableEndpointSlices {
+				provider = &endpointsProvider{label: "endpoints"}
+			} else {
+				provider = &endpointslicesProvider{label: "endpointslices"}
+			}
+			// Only the endpoints that pass the health checks of the service are advertised
+			provider = sm.healthCheckedProvider(svcCtx, svc, provider)
+			if err := sm.watchEndpoint(svcCtx, sm.config.NodeName, svc, wg, provider); err != nil {
+				log.Error(err)
 			}
-			if activeService[string(svc.UID)] {
+			wg.Done()
+		}()
+	}
 
-				// We only care about LoadBalancer services
-				if svc.Spec.Type != v1.ServiceTypeLoadBalancer {
-					break
+	// Background the services election
+	// EnableServicesElection enabled
+	// watchEndpoint will do a ServicesElection by Service and understands local endpoints
+	//
+	// EnableRoutingTable enabled and EnableLeaderElection disabled
+	// watchEndpoint will also not do a leaderElection by service.
+	withoutElection := (sm.config.EnableRoutingTable || sm.config.EnableBGP) && (!sm.config.EnableLeaderElection && !sm.config.EnableServicesElection)
+	if sm.config.EnableServicesElection || withoutElection {
+		if svc.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyTypeLocal {
+			// Start an endpoint watcher if we're not watching it already
+			if !watched {
+				// background the endpoint watcher
+				startEndpointWatcher()
+
+				if withoutElection {
+					startServiceFunc()
 				}
+				// We're now watching this service
+				serviceStateMutex.Lock()
+				watchedService[uid] = true
+				serviceStateMutex.Unlock()
+			}
+		} else if withoutElection {
+			if svc.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyTypeCluster {
+				startEndpointWatcher()
+			}
+			startServiceFunc()
+		} else {
+			// Increment the waitGroup before the service Func is called (Done is completed in there)
+			startServiceFunc()
+		}
+	} else {
+		// Increment the waitGroup before the service Func is called (Done is completed in there)
+		wg.Add(1)
+		if err := serviceFunc(svcCtx, svc, wg); err != nil {
+			log.Error(err)
+		}
+		wg.Done()
+	}
+	setServiceActive(uid, true)
+	return nil
+}
 
-				// We can ignore this service
-				if svc.Annotations["kube-vip.io/ignore"] == "true" {
-					log.Infof("(svcs) [%s] has an ignore annotation for kube-vip", svc.Name)
-					break
-				}
+// watchedServiceDeleted stops advertising a service that has been deleted
+func (sm *Manager) watchedServiceDeleted(svc *v1.Service) error {
+	uid := string(svc.UID)
+	if isServiceActive(uid) {
 
-				isRouteConfigured, err := isRouteConfigured(svc.UID)
-				if err != nil {
-					return fmt.Errorf("error while checkig if route is configured: %w", err)
-				}
-				// If no leader election is enabled, delete routes here
-				if !sm.config.EnableLeaderElection && !sm.config.EnableServicesElection &&
-					sm.config.EnableRoutingTable && isRouteConfigured {
-					if errs := sm.clearRoutes(svc); len(errs) == 0 {
-						configuredLocalRoutes.Store(string(svc.UID), false)
-					}
-				}
+		// We only care about LoadBalancer services
+		if svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+			return nil
+		}
 
-				// If this is an active service then and additional leaderElection will handle stopping
-				err = sm.deleteService(string(svc.UID))
-				if err != nil {
-					log.Error(err)
-				}
+		// We can ignore this service
+		if svc.Annotations["kube-vip.io/ignore"] == "true" {
+			log.Infof("(svcs) [%s] has an ignore annotation for kube-vip", svc.Name)
+			return nil
+		}
 
-				// Calls the cancel function of the context
-				if activeServiceLoadBalancerCancel[string(svc.UID)] != nil {
-					activeServiceLoadBalancerCancel[string(svc.UID)]()
-				}
-				activeService[string(svc.UID)] = false
-				watchedService[string(svc.UID)] = false
+		isRouteConfigured, err := isRouteConfigured(svc.UID)
+		if err != nil {
+			return fmt.Errorf("error while checkig if route is configured: %w", err)
+		}
+		// If no leader election is enabled, delete routes here
+		if !sm.config.EnableLeaderElection && !sm.config.EnableServicesElection &&
+			sm.config.EnableRoutingTable && isRouteConfigured {
+			if errs := sm.clearRoutes(svc); len(errs) == 0 {
+				configuredLocalRoutes.Store(uid, false)
 			}
+		}
 
-			if (sm.config.EnableBGP || sm.config.EnableRoutingTable) && sm.config.EnableLeaderElection && !sm.config.EnableServicesElection {
-				if sm.config.EnableBGP {
-					instance := sm.findServiceInstance(svc)
-					for _, vip := range instance.vipConfigs {
-						vipCidr := fmt.Sprintf("%s/%s", vip.VIP, vip.VIPCIDR)
-						err = sm.bgpServer.DelHost(vipCidr)
-						if err != nil {
-							log.Errorf("error deleting host %s: %s", vipCidr, err.Error())
-						}
-					}
-				} else {
-					sm.clearRoutes(svc)
-				}
-			}
+		// If this is an active service then and additional leaderElection will handle stopping
+		err = sm.deleteService(uid)
+		if err != nil {
+			log.Error(err)
+		}
 
-			log.Infof("(svcs) [%s/%s] has been deleted", svc.Namespace, svc.Name)
-		case watch.Bookmark:
-			// Un-used
-		case watch.Error:
-			log.Error("Error attempting to watch Kubernetes services")
+		// Calls the cancel function of the context
+		serviceStateMutex.Lock()
+		if activeServiceLoadBalancerCancel[uid] != nil {
+			activeServiceLoadBalancerCancel[uid]()
+		}
+		activeService[uid] = false
+		watchedService[uid] = false
+		serviceStateMutex.Unlock()
+	}
 
-			// This round trip allows us to handle unstructured status
-			errObject := apierrors.FromObject(event.Object)
-			statusErr, ok := errObject.(*apierrors.StatusError)
-			if !ok {
-				log.Errorf(spew.Sprintf("Received an error which is not *metav1.Status but %#+v", event.Object))
+	if (sm.config.EnableBGP || sm.config.EnableRoutingTable) && sm.config.EnableLeaderElection && !sm.config.EnableServicesElection {
+		if sm.config.EnableBGP {
+			instance := sm.findServiceInstance(svc)
+			for _, vip := range instance.vipConfigs {
+				vipCidr := bgp.HostCIDR(vip.VIP)
+				err := sm.bgpServer.DelHost(vipCidr)
+				if err != nil {
+					log.Errorf("error deleting host %s: %s", vipCidr, err.Error())
+				}
 			}
-
-			status := statusErr.ErrStatus
-			log.Errorf("services -> %v", status)
-		default:
+		} else {
+			sm.clearRoutes(svc)
 		}
 	}
-	close(exitFunction)
-	log.Warnln("Stopping watching services for type: LoadBalancer in all namespaces")
+
+	log.Infof("(svcs) [%s/%s] has been deleted", svc.Namespace, svc.Name)
 	return nil
 }
 
@@ -143,3 +143,31 @@ func Test_parseBgpAnnotations(t *testing.T) {
 		})
 	}
 }
+
+func TestBGPPeerChanges(t *testing.T) {
+	current := []bgp.Peer{
+		{Address: "10.0.0.1", AS: 64000, Password: "secret"},
+		{Address: "10.0.0.2", AS: 64000},
+		{Address: "10.0.0.3", AS: 64000},
+	}
+	annotated := []bgp.Peer{
+		{Address: "10.0.0.1", AS: 64000},
+		{Address: "10.0.0.2", AS: 64001},
+		{Address: "10.0.0.4", AS: 64000},
+	}
+
+	peers, added, updated, removed := bgpPeerChanges(current, annotated, "default")
+	assert.Equal(t, []bgp.Peer{
+		{Address: "10.0.0.1", AS: 64000, Password: "secret"},
+		{Address: "10.0.0.2", AS: 64001},
+		{Address: "10.0.0.4", AS: 64000, Password: "default"},
+	}, peers, "the passwords that aren't annotated should be kept")
+	assert.Equal(t, []bgp.Peer{{Address: "10.0.0.4", AS: 64000, Password: "default"}}, added)
+	assert.Equal(t, []bgp.Peer{{Address: "10.0.0.2", AS: 64001}}, updated)
+	assert.Equal(t, []bgp.Peer{{Address: "10.0.0.3", AS: 64000}}, removed)
+
+	_, added, updated, removed = bgpPeerChanges(peers, annotated, "default")
+	assert.Empty(t, added)
+	assert.Empty(t, updated)
+	assert.Empty(t, removed)
+}
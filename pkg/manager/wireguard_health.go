@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/wireguard"
+)
+
+// wireguardHealthInterval is how often the health of the wireguard tunnel is checked
+const wireguardHealthInterval = 5 * time.Second
+
+// wireguardHealth checks the tunnel from the age of the last handshake with the peer and, if there is a probe address,
+// by pinging it through the tunnel
+type wireguardHealth struct {
+	tunnel  *wireguard.Tunnel
+	timeout time.Duration
+	prober  *wireguard.Prober
+}
+
+// newWireguardHealth returns the health checks of the tunnel, nil is returned if none are configured
+func (sm *Manager) newWireguardHealth() *wireguardHealth {
+	if sm.config.WireguardHandshakeTimeout <= 0 && sm.config.WireguardProbeAddress == "" {
+		return nil
+	}
+	if sm.wireguard == nil {
+		log.Warnf("(wireguard) there is no external peer, the health checks of the tunnel are ignored")
+		return nil
+	}
+	h := &wireguardHealth{tunnel: sm.wireguard, timeout: time.Duration(sm.config.WireguardHandshakeTimeout) * time.Second}
+	if sm.config.WireguardProbeAddress != "" {
+		h.prober = wireguard.NewProber(sm.config.Interface, net.ParseIP(sm.config.WireguardProbeAddress))
+	}
+	return h
+}
+
+// check returns an error if the tunnel is unhealthy
+func (h *wireguardHealth) check(ctx context.Context) error {
+	if h.timeout > 0 {
+		last, err := h.tunnel.LastHandshake()
+		if err != nil {
+			return err
+		}
+		if last.IsZero() || time.Since(last) > h.timeout {
+			return fmt.Errorf("no handshake with the peer in the last %s", h.timeout)
+		}
+	}
+	if h.prober != nil {
+		healthy, err := h.prober.Healthy(ctx)
+		if !healthy {
+			return fmt.Errorf("%d probes in a row have failed: %v", wireguard.ProbeFailures, err)
+		}
+		if err != nil {
+			log.Debugf("(wireguard) probe failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// wireguardHealthy returns nil if the tunnel is healthy, and exports its health
+func (sm *Manager) wireguardHealthy(ctx context.Context, h *wireguardHealth) error {
+	err := h.check(ctx)
+	if err == nil {
+		sm.wireguardTunnelHealthy.Set(1)
+	} else {
+		sm.wireguardTunnelHealthy.Set(0)
+	}
+	return err
+}
+
+// waitForWireguardTunnel will wait until the tunnel is healthy, so that a node can't lead without a working tunnel
+func (sm *Manager) waitForWireguardTunnel(ctx context.Context, h *wireguardHealth) error {
+	err := sm.wireguardHealthy(ctx, h)
+	if err == nil {
+		return nil
+	}
+	log.Infof("(wireguard) waiting for the tunnel to be healthy before taking part in the leader election: %v", err)
+	ticker := time.NewTicker(wireguardHealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		err := sm.wireguardHealthy(ctx, h)
+		if err == nil {
+			log.Info("(wireguard) tunnel is healthy")
+			return nil
+		}
+		log.Debugf("(wireguard) tunnel is unhealthy: %v", err)
+	}
+}
+
+// watchWireguardHealth will call unhealthy once the tunnel stops working, the node then gives up the leadership so
+// that a node with a working tunnel advertises the VIPs
+func (sm *Manager) watchWireguardHealth(ctx context.Context, h *wireguardHealth, unhealthy func()) {
+	ticker := time.NewTicker(wireguardHealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := sm.wireguardHealthy(ctx, h); err != nil {
+			log.Errorf("(wireguard) the tunnel is unhealthy: %v", err)
+			unhealthy()
+			return
+		}
+	}
+}
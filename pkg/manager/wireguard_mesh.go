@@ -0,0 +1,184 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	"github.com/kube-vip/kube-vip/pkg/wireguard"
+)
+
+// meshLeaveTimeout is how long the annotations of the node are given to be removed when kube-vip shuts down
+const meshLeaveTimeout = 5 * time.Second
+
+// startWireguardMesh will join this node to the mesh between kube-vip nodes, its key is published on the node and the
+// peers are reconciled from the annotations of the other nodes until the context is cancelled. The returned function
+// removes the node from the mesh, so that the other nodes don't keep a peer that has gone away.
+func (sm *Manager) startWireguardMesh(ctx context.Context) (func(), error) {
+	mesh, err := wireguard.NewMesh(sm.config.WireguardMeshOptions())
+	if err != nil {
+		return nil, err
+	}
+	if err := mesh.Start(); err != nil {
+		return nil, err
+	}
+	if err := sm.watchMeshPresharedKeys(ctx, mesh); err != nil {
+		return nil, err
+	}
+
+	node, err := sm.clientSet.CoreV1().Nodes().Get(ctx, sm.config.NodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read node [%s]: %v", sm.config.NodeName, err)
+	}
+	address := wireguard.NodeAddress(node)
+	if address == nil {
+		return nil, fmt.Errorf("node [%s] has no address for the wireguard mesh", sm.config.NodeName)
+	}
+	annotations := mesh.Annotations(address, node.Spec.PodCIDRs)
+	if err := sm.patchMeshAnnotations(ctx, annotations, false); err != nil {
+		return nil, fmt.Errorf("unable to publish the wireguard key on node [%s]: %v", sm.config.NodeName, err)
+	}
+	log.Infof("(wireguard) joined the mesh with public key [%s] at [%s]", annotations[wireguard.PublicKeyAnnotation], annotations[wireguard.EndpointAnnotation])
+	leave := func() {
+		// The context of the mesh is usually cancelled by now
+		ctx, cancel := context.WithTimeout(context.Background(), meshLeaveTimeout)
+		defer cancel()
+		if err := sm.patchMeshAnnotations(ctx, annotations, true); err != nil {
+			log.Warnf("(wireguard) unable to remove the wireguard key from node [%s]: %v", sm.config.NodeName, err)
+			return
+		}
+		log.Info("(wireguard) left the mesh")
+	}
+
+	nodes, err := sm.clientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return leave, err
+	}
+	peers := map[string]wireguard.MeshPeer{}
+	for x := range nodes.Items {
+		updateMeshPeer(peers, &nodes.Items[x], false)
+	}
+	reconcileMesh(mesh, peers)
+
+	rw, err := watchtools.NewRetryWatcher(nodes.ResourceVersion, &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return sm.clientSet.CoreV1().Nodes().Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		return leave, fmt.Errorf("error creating wireguard mesh watcher: %v", err)
+	}
+	go func() {
+		<-ctx.Done()
+		rw.Stop()
+	}()
+	go func() {
+		for event := range rw.ResultChan() {
+			node, ok := event.Object.(*v1.Node)
+			if !ok {
+				continue
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if !updateMeshPeer(peers, node, false) {
+					continue
+				}
+			case watch.Deleted:
+				updateMeshPeer(peers, node, true)
+			default:
+				continue
+			}
+			reconcileMesh(mesh, peers)
+		}
+	}()
+	return leave, nil
+}
+
+// watchMeshPresharedKeys will configure the mesh with the preshared keys from its secret, if it has one, and
+// reconfigure it when they're rotated
+func (sm *Manager) watchMeshPresharedKeys(ctx context.Context, mesh *wireguard.Mesh) error {
+	name := sm.config.WireguardMeshSecret
+	if name == "" {
+		return nil
+	}
+	apply := func(s *v1.Secret) error {
+		keys, err := wireguard.MeshPresharedKeys(sm.config.NodeName, s.Data)
+		if err != nil {
+			return err
+		}
+		return mesh.SetPresharedKeys(keys)
+	}
+	log.Infof("(wireguard) reading the preshared keys of the mesh from Kubernetes secret [%s]", name)
+	s, err := sm.readSecret(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := apply(s); err != nil {
+		return err
+	}
+	return sm.watchSecret(ctx, name, apply)
+}
+
+// patchMeshAnnotations publishes the mesh annotations on the node, or removes them
+func (sm *Manager) patchMeshAnnotations(ctx context.Context, annotations map[string]string, remove bool) error {
+	values := map[string]interface{}{}
+	for k, v := range annotations {
+		if remove {
+			// null removes the annotation in a merge patch
+			values[k] = nil
+		} else {
+			values[k] = v
+		}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": values},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = sm.clientSet.CoreV1().Nodes().Patch(ctx, sm.config.NodeName, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// updateMeshPeer updates the peer of the node, returning true if the peers have changed
+func updateMeshPeer(peers map[string]wireguard.MeshPeer, node *v1.Node, deleted bool) bool {
+	peer, joined, err := wireguard.PeerFromNode(node)
+	if err != nil {
+		log.Warnf("(wireguard) %v", err)
+	}
+	existing, exists := peers[node.Name]
+	if deleted || !joined {
+		delete(peers, node.Name)
+		return exists
+	}
+	if exists && existing.PublicKey == peer.PublicKey && existing.Endpoint.String() == peer.Endpoint.String() &&
+		fmt.Sprint(existing.AllowedIPs) == fmt.Sprint(peer.AllowedIPs) {
+		return false
+	}
+	peers[node.Name] = peer
+	return true
+}
+
+// reconcileMesh configures the mesh with the peers, in the order of their node names
+func reconcileMesh(mesh *wireguard.Mesh, peers map[string]wireguard.MeshPeer) {
+	var list []wireguard.MeshPeer
+	for _, peer := range peers {
+		list = append(list, peer)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Node < list[j].Node })
+	if err := mesh.Reconcile(list); err != nil {
+		log.Errorf("(wireguard) unable to configure the mesh: %v", err)
+		return
+	}
+	log.Infof("(wireguard) mesh has %d nodes", len(list))
+}
@@ -0,0 +1,172 @@
+package nsx
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// Client binds the VIP to the NSX logical port of the VNIC of this virtual machine. SpoofGuard and the IP discovery of
+// the segments drop the traffic of an address that isn't bound to the port it comes from, so the VIP (and its
+// gratuitous ARP) only reaches the network from the port of the leader once the VIP is bound to it.
+type Client struct {
+	manager  string
+	username string
+	password string
+	// mac is the MAC address of the VIP interface, the VNIC (and its logical port) is found by it
+	mac string
+
+	client *http.Client
+}
+
+// logicalPort is the subset of a logical port that kube-vip manages, the rest of the port is kept as it is
+type logicalPort map[string]any
+
+// addressBinding is an address that is bound to a logical port
+type addressBinding struct {
+	IPAddress  string `json:"ip_address"`
+	MACAddress string `json:"mac_address,omitempty"`
+}
+
+// NewClient returns the client of the NSX manager, the credentials are read from NSX_USERNAME and NSX_PASSWORD
+func NewClient(k *kubevip.Config) (*Client, error) {
+	ifi, err := net.InterfaceByName(k.Interface)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the interface [%s]: %v", k.Interface, err)
+	}
+	return newClient(k, ifi.HardwareAddr.String())
+}
+
+func newClient(k *kubevip.Config, mac string) (*Client, error) {
+	c := &Client{
+		mac:      mac,
+		manager:  strings.TrimSuffix(k.NSXManager, "/"),
+		username: os.Getenv("NSX_USERNAME"),
+		password: os.Getenv("NSX_PASSWORD"),
+		client: &http.Client{Timeout: 30 * time.Second, Transport: &http.Transport{
+			// The NSX managers often have a self-signed certificate
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: k.NSXInsecure}, //nolint:gosec
+		}},
+	}
+	if c.username == "" || c.password == "" {
+		return nil, fmt.Errorf("NSX_USERNAME and NSX_PASSWORD have to be set to authenticate with the NSX manager")
+	}
+	return c, nil
+}
+
+// AssignVIP binds the VIP to the logical port of this virtual machine, with the MAC address of its VNIC
+func (c *Client) AssignVIP(vip string) error {
+	port, err := c.findPort()
+	if err != nil {
+		return err
+	}
+	id, _ := port["id"].(string)
+
+	var bindings []addressBinding
+	if raw, err := json.Marshal(port["address_bindings"]); err == nil {
+		_ = json.Unmarshal(raw, &bindings)
+	}
+	for _, binding := range bindings {
+		if binding.IPAddress == vip && (binding.MACAddress == "" || strings.EqualFold(binding.MACAddress, c.mac)) {
+			log.Infof("[NSX] the VIP [%s] is already bound to the logical port [%s]", vip, id)
+			return nil
+		}
+	}
+	port["address_bindings"] = append(bindings, addressBinding{IPAddress: vip, MACAddress: c.mac})
+
+	// The port is updated with the revision that was read, a concurrent update fails rather than being overwritten
+	log.Infof("[NSX] binding the VIP [%s] to the logical port [%s]", vip, id)
+	if err := c.do(http.MethodPut, "/api/v1/logical-ports/"+id, port, nil); err != nil {
+		return fmt.Errorf("unable to bind the VIP [%s] to the logical port [%s]: %v", vip, id, err)
+	}
+	return nil
+}
+
+// findPort returns the logical port of the VNIC with the MAC address of the VIP interface
+func (c *Client) findPort() (logicalPort, error) {
+	attachment := ""
+	cursor := ""
+	for attachment == "" {
+		var vifs struct {
+			Results []struct {
+				MACAddress      string `json:"mac_address"`
+				LportAttachment string `json:"lport_attachment_id"`
+			} `json:"results"`
+			Cursor string `json:"cursor"`
+		}
+		if err := c.do(http.MethodGet, "/api/v1/fabric/vifs?cursor="+url.QueryEscape(cursor), nil, &vifs); err != nil {
+			return nil, fmt.Errorf("unable to list the VNICs: %v", err)
+		}
+		for _, vif := range vifs.Results {
+			if strings.EqualFold(vif.MACAddress, c.mac) && vif.LportAttachment != "" {
+				attachment = vif.LportAttachment
+			}
+		}
+		if vifs.Cursor == "" || vifs.Cursor == cursor {
+			break
+		}
+		cursor = vifs.Cursor
+	}
+	if attachment == "" {
+		return nil, fmt.Errorf("unable to find the VNIC with the MAC address [%s] of this virtual machine", c.mac)
+	}
+
+	var ports struct {
+		Results []logicalPort `json:"results"`
+	}
+	if err := c.do(http.MethodGet, "/api/v1/logical-ports?attachment_id="+url.QueryEscape(attachment), nil, &ports); err != nil {
+		return nil, fmt.Errorf("unable to find the logical port of the VNIC: %v", err)
+	}
+	if len(ports.Results) == 0 {
+		return nil, fmt.Errorf("the VNIC with the MAC address [%s] isn't attached to a logical port", c.mac)
+	}
+	return ports.Results[0], nil
+}
+
+// do sends the request to the NSX manager, and decodes its response into the result
+func (c *Client) do(method, resource string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.manager+resource, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			ErrorMessage string `json:"error_message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.ErrorMessage == "" {
+			return fmt.Errorf("%s failed with status [%s]", method, resp.Status)
+		}
+		return fmt.Errorf("%s failed: %s", method, apiErr.ErrorMessage)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,97 @@
+package nsx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// fakeNSX answers the VNICs and the logical ports of the NSX manager API
+type fakeNSX struct {
+	mu      sync.Mutex
+	port    map[string]any
+	updates int
+}
+
+func (f *fakeNSX) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if username, password, _ := r.BasicAuth(); username != "admin" || password != "secret" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	var body any
+	switch r.Method + " " + r.URL.Path {
+	case "GET /api/v1/fabric/vifs":
+		// The VNICs are returned over two pages
+		if r.URL.Query().Get("cursor") == "" {
+			body = map[string]any{"results": []any{map[string]any{"mac_address": "00:50:56:00:00:02", "lport_attachment_id": "other"}}, "cursor": "1"}
+		} else {
+			body = map[string]any{"results": []any{map[string]any{"mac_address": "00:50:56:00:00:01", "lport_attachment_id": "vnic"}}}
+		}
+	case "GET /api/v1/logical-ports":
+		if r.URL.Query().Get("attachment_id") != "vnic" {
+			body = map[string]any{"results": []any{}}
+			break
+		}
+		body = map[string]any{"results": []any{f.port}}
+	case "PUT /api/v1/logical-ports/port":
+		var port map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&port)
+		if port["_revision"] != f.port["_revision"] {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error_message": "the object was modified"})
+			return
+		}
+		port["_revision"] = port["_revision"].(float64) + 1
+		f.port = port
+		f.updates++
+		body = port
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestAssignVIP(t *testing.T) {
+	f := &fakeNSX{port: map[string]any{
+		"id":               "port",
+		"_revision":        float64(3),
+		"display_name":     "node1/vnic",
+		"address_bindings": []any{map[string]any{"ip_address": "192.168.0.10", "mac_address": "00:50:56:00:00:01"}},
+	}}
+	server := httptest.NewTLSServer(f)
+	defer server.Close()
+	t.Setenv("NSX_USERNAME", "admin")
+	t.Setenv("NSX_PASSWORD", "secret")
+
+	c, err := newClient(&kubevip.Config{NSXManager: server.URL, NSXInsecure: true}, "00:50:56:00:00:01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AssignVIP("192.168.0.100"); err != nil {
+		t.Fatal(err)
+	}
+	bindings, _ := f.port["address_bindings"].([]any)
+	if len(bindings) != 2 || f.port["display_name"] != "node1/vnic" {
+		t.Fatalf("port = %v, want the VIP bound alongside the address of the VNIC", f.port)
+	}
+	if binding := bindings[1].(map[string]any); binding["ip_address"] != "192.168.0.100" || binding["mac_address"] != "00:50:56:00:00:01" {
+		t.Errorf("binding = %v, want the VIP with the MAC address of the VNIC", binding)
+	}
+
+	// The VIP that is already bound isn't bound again
+	if err := c.AssignVIP("192.168.0.100"); err != nil || f.updates != 1 {
+		t.Errorf("AssignVIP() = %v with %d updates, want the port left alone", err, f.updates)
+	}
+
+	c.mac = "00:50:56:00:00:03"
+	if err := c.AssignVIP("192.168.0.100"); err == nil {
+		t.Errorf("AssignVIP() bound the VIP without a VNIC with the MAC address")
+	}
+}
@@ -0,0 +1,143 @@
+package openstack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// identity authenticates with Keystone (v3) with the OS_* variables of the environment, like the openstack client, and
+// finds the endpoint of the network service (Neutron) in the catalog of the token
+type identity struct {
+	authURL string
+	region  string
+	// endpointInterface is the interface of the endpoints that are used (public, internal or admin)
+	endpointInterface string
+
+	client *http.Client
+
+	token   string
+	expires time.Time
+	network string
+}
+
+func newIdentity() (*identity, error) {
+	i := &identity{
+		authURL:           strings.TrimSuffix(os.Getenv("OS_AUTH_URL"), "/"),
+		region:            os.Getenv("OS_REGION_NAME"),
+		endpointInterface: strings.TrimSuffix(os.Getenv("OS_INTERFACE"), "URL"),
+		client:            &http.Client{Timeout: 30 * time.Second},
+	}
+	if i.authURL == "" {
+		return nil, fmt.Errorf("OS_AUTH_URL has to be set to authenticate with OpenStack")
+	}
+	if !strings.HasSuffix(i.authURL, "/v3") {
+		i.authURL += "/v3"
+	}
+	if i.endpointInterface == "" {
+		i.endpointInterface = "public"
+	}
+	return i, nil
+}
+
+// authRequest returns the request of a token, with an application credential or with the password of a user that is
+// scoped to a project
+func authRequest() (map[string]any, error) {
+	if id := os.Getenv("OS_APPLICATION_CREDENTIAL_ID"); id != "" {
+		return map[string]any{"auth": map[string]any{"identity": map[string]any{
+			"methods":                []string{"application_credential"},
+			"application_credential": map[string]any{"id": id, "secret": os.Getenv("OS_APPLICATION_CREDENTIAL_SECRET")},
+		}}}, nil
+	}
+	username, password := os.Getenv("OS_USERNAME"), os.Getenv("OS_PASSWORD")
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("OS_USERNAME and OS_PASSWORD (or OS_APPLICATION_CREDENTIAL_ID) have to be set to authenticate with OpenStack")
+	}
+	user := map[string]any{"name": username, "password": password, "domain": domain("OS_USER_DOMAIN_ID", "OS_USER_DOMAIN_NAME")}
+	project := map[string]any{"domain": domain("OS_PROJECT_DOMAIN_ID", "OS_PROJECT_DOMAIN_NAME")}
+	if id := os.Getenv("OS_PROJECT_ID"); id != "" {
+		project = map[string]any{"id": id}
+	} else {
+		project["name"] = os.Getenv("OS_PROJECT_NAME")
+	}
+	return map[string]any{"auth": map[string]any{
+		"identity": map[string]any{"methods": []string{"password"}, "password": map[string]any{"user": user}},
+		"scope":    map[string]any{"project": project},
+	}}, nil
+}
+
+// domain returns the domain of the ID or the name of the variables, the default domain when neither is set
+func domain(idVariable, nameVariable string) map[string]any {
+	if id := os.Getenv(idVariable); id != "" {
+		return map[string]any{"id": id}
+	}
+	if name := os.Getenv(nameVariable); name != "" {
+		return map[string]any{"name": name}
+	}
+	return map[string]any{"id": "default"}
+}
+
+// authenticate returns the token and the endpoint of the network service, a new token is requested before the last one
+// expires
+func (i *identity) authenticate() (string, string, error) {
+	if i.token != "" && time.Now().Add(time.Minute).Before(i.expires) {
+		return i.token, i.network, nil
+	}
+	request, err := authRequest()
+	if err != nil {
+		return "", "", err
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := i.client.Post(i.authURL+"/auth/tokens", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", "", fmt.Errorf("unable to authenticate with OpenStack: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", "", fmt.Errorf("unable to authenticate with OpenStack, status [%s]", resp.Status)
+	}
+
+	var response struct {
+		Token struct {
+			ExpiresAt time.Time `json:"expires_at"`
+			Catalog   []struct {
+				Type      string `json:"type"`
+				Endpoints []struct {
+					Interface string `json:"interface"`
+					Region    string `json:"region"`
+					RegionID  string `json:"region_id"`
+					URL       string `json:"url"`
+				} `json:"endpoints"`
+			} `json:"catalog"`
+		} `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", "", fmt.Errorf("unable to read the OpenStack token: %v", err)
+	}
+	network := ""
+	for _, service := range response.Token.Catalog {
+		if service.Type != "network" {
+			continue
+		}
+		for _, endpoint := range service.Endpoints {
+			if endpoint.Interface == i.endpointInterface && (i.region == "" || endpoint.Region == i.region || endpoint.RegionID == i.region) {
+				network = strings.TrimSuffix(endpoint.URL, "/")
+				break
+			}
+		}
+	}
+	if network == "" {
+		return "", "", fmt.Errorf("the OpenStack catalog has no %s network endpoint in region [%s]", i.endpointInterface, i.region)
+	}
+	i.token = resp.Header.Get("X-Subject-Token")
+	i.expires = response.Token.ExpiresAt
+	i.network = network
+	return i.token, i.network, nil
+}
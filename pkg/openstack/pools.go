@@ -0,0 +1,95 @@
+package openstack
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// ConfiguredCIDRs returns the CIDRs of the VIP and of the pools of the configuration
+func ConfiguredCIDRs(k *kubevip.Config) ([]netip.Prefix, error) {
+	var cidrs []netip.Prefix
+	if k.VIP != "" {
+		for _, vip := range strings.Split(k.VIP, ",") {
+			addr, err := netip.ParseAddr(strings.TrimSpace(vip))
+			if err != nil {
+				return nil, fmt.Errorf("VIP [%s] is not an address: %v", vip, err)
+			}
+			cidrs = append(cidrs, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	for _, pool := range k.OpenStackPools {
+		cidr, err := netip.ParsePrefix(strings.TrimSpace(pool))
+		if err != nil {
+			return nil, fmt.Errorf("pool [%s] is not a valid CIDR: %v", pool, err)
+		}
+		cidrs = append(cidrs, cidr.Masked())
+	}
+	return cidrs, nil
+}
+
+// PoolCIDRs returns the CIDRs of the pools of the configmap of the kube-vip cloud provider, the cidr-<namespace> keys
+// are lists of CIDRs and the range-<namespace> keys lists of address ranges (which are split into CIDRs)
+func PoolCIDRs(data map[string]string) ([]netip.Prefix, error) {
+	var cidrs []netip.Prefix
+	for key, value := range data {
+		for _, pool := range strings.Split(value, ",") {
+			if pool = strings.TrimSpace(pool); pool == "" {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(key, "cidr-"):
+				cidr, err := netip.ParsePrefix(pool)
+				if err != nil {
+					return nil, fmt.Errorf("pool [%s] of [%s] is not a valid CIDR: %v", pool, key, err)
+				}
+				cidrs = append(cidrs, cidr.Masked())
+			case strings.HasPrefix(key, "range-"):
+				first, last, found := strings.Cut(pool, "-")
+				start, err := netip.ParseAddr(strings.TrimSpace(first))
+				if !found || err != nil {
+					return nil, fmt.Errorf("pool [%s] of [%s] is not a valid range", pool, key)
+				}
+				end, err := netip.ParseAddr(strings.TrimSpace(last))
+				if err != nil || start.Is4() != end.Is4() || end.Less(start) {
+					return nil, fmt.Errorf("pool [%s] of [%s] is not a valid range", pool, key)
+				}
+				cidrs = append(cidrs, rangeCIDRs(start, end)...)
+			}
+		}
+	}
+	return cidrs, nil
+}
+
+// rangeCIDRs returns the fewest CIDRs that cover the addresses from start to end
+func rangeCIDRs(start, end netip.Addr) []netip.Prefix {
+	var cidrs []netip.Prefix
+	for start.IsValid() && !end.Less(start) {
+		// The largest CIDR that starts at the address and doesn't go past the end
+		bits := start.BitLen()
+		for bits > 0 {
+			wider := netip.PrefixFrom(start, bits-1).Masked()
+			if wider.Addr() != start || end.Less(lastAddr(wider)) {
+				break
+			}
+			bits--
+		}
+		cidr := netip.PrefixFrom(start, bits)
+		cidrs = append(cidrs, cidr)
+		// The next address is invalid past the end of the address space
+		start = lastAddr(cidr).Next()
+	}
+	return cidrs
+}
+
+// lastAddr returns the last address of the CIDR
+func lastAddr(cidr netip.Prefix) netip.Addr {
+	b := cidr.Masked().Addr().AsSlice()
+	for i := cidr.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
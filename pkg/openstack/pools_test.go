@@ -0,0 +1,71 @@
+package openstack
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+func TestRangeCIDRs(t *testing.T) {
+	tests := []struct {
+		start, end string
+		want       []string
+	}{
+		{"192.168.0.200", "192.168.0.200", []string{"192.168.0.200/32"}},
+		{"192.168.0.200", "192.168.0.250", []string{"192.168.0.200/29", "192.168.0.208/28", "192.168.0.224/28", "192.168.0.240/29", "192.168.0.248/31", "192.168.0.250/32"}},
+		{"10.0.0.0", "10.0.1.255", []string{"10.0.0.0/23"}},
+		{"255.255.255.254", "255.255.255.255", []string{"255.255.255.254/31"}},
+		{"fd00::1", "fd00::3", []string{"fd00::1/128", "fd00::2/127"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, cidr := range rangeCIDRs(netip.MustParseAddr(tt.start), netip.MustParseAddr(tt.end)) {
+			got = append(got, cidr.String())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("rangeCIDRs(%s, %s) = %v, want %v", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestPoolCIDRs(t *testing.T) {
+	cidrs, err := PoolCIDRs(map[string]string{
+		"cidr-global":    "192.168.1.0/24, 192.168.2.7/24",
+		"range-default":  "192.168.0.200-192.168.0.201",
+		"allow-share-ns": "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, cidr := range cidrs {
+		got = append(got, cidr.String())
+	}
+	slices.Sort(got)
+	if want := []string{"192.168.0.200/31", "192.168.1.0/24", "192.168.2.0/24"}; !slices.Equal(got, want) {
+		t.Errorf("PoolCIDRs() = %v, want %v", got, want)
+	}
+
+	for _, invalid := range []map[string]string{
+		{"cidr-global": "192.168.1.0"},
+		{"range-global": "192.168.0.201-192.168.0.200"},
+		{"range-global": "192.168.0.200-fd00::1"},
+	} {
+		if _, err := PoolCIDRs(invalid); err == nil {
+			t.Errorf("PoolCIDRs(%v) accepted an invalid pool", invalid)
+		}
+	}
+}
+
+func TestConfiguredCIDRs(t *testing.T) {
+	cidrs, err := ConfiguredCIDRs(&kubevip.Config{VIP: "192.168.0.100,fd00::100", OpenStackPools: []string{"192.168.1.1/24"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []netip.Prefix{netip.MustParsePrefix("192.168.0.100/32"), netip.MustParsePrefix("fd00::100/128"), netip.MustParsePrefix("192.168.1.0/24")}
+	if !slices.Equal(cidrs, want) {
+		t.Errorf("ConfiguredCIDRs() = %v, want %v", cidrs, want)
+	}
+}
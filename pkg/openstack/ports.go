@@ -0,0 +1,202 @@
+package openstack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// metadataURL is the metadata service of the OpenStack instances
+var metadataURL = "http://169.254.169.254"
+
+// Client adds the addresses of the VIPs to the allowed address pairs of the Neutron port of this instance. The port
+// security of Neutron drops the traffic of any address that isn't the address of the port or one of its allowed
+// address pairs, so the VIPs that move to the instance are silently dropped without them.
+type Client struct {
+	identity *identity
+	client   *http.Client
+
+	// port is the ID of the port of this instance that the VIP interface is attached to
+	port string
+
+	mu sync.Mutex
+}
+
+// port is the subset of a Neutron port that kube-vip manages
+type port struct {
+	ID                  string        `json:"id"`
+	MACAddress          string        `json:"mac_address"`
+	AllowedAddressPairs []addressPair `json:"allowed_address_pairs"`
+}
+
+type addressPair struct {
+	IPAddress  string `json:"ip_address"`
+	MACAddress string `json:"mac_address,omitempty"`
+}
+
+// NewClient returns the client of the port of the VIP interface, the ID of the instance is read from the metadata
+// service and its port is the one with the MAC address of the interface
+func NewClient(k *kubevip.Config) (*Client, error) {
+	identity, err := newIdentity()
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{identity: identity, client: &http.Client{Timeout: 30 * time.Second}}
+
+	instance, err := c.instanceID()
+	if err != nil {
+		return nil, err
+	}
+	var ports struct {
+		Ports []port `json:"ports"`
+	}
+	if err := c.do(http.MethodGet, "/v2.0/ports?device_id="+instance, nil, &ports); err != nil {
+		return nil, fmt.Errorf("unable to list the ports of the instance [%s]: %v", instance, err)
+	}
+	var mac string
+	if ifi, err := net.InterfaceByName(k.Interface); err == nil {
+		mac = ifi.HardwareAddr.String()
+	}
+	for _, p := range ports.Ports {
+		if strings.EqualFold(p.MACAddress, mac) || (mac == "" && len(ports.Ports) == 1) {
+			c.port = p.ID
+		}
+	}
+	if c.port == "" {
+		return nil, fmt.Errorf("unable to find the port of the interface [%s] (%s) among the %d ports of the instance [%s]", k.Interface, mac, len(ports.Ports), instance)
+	}
+	return c, nil
+}
+
+// AddAllowedAddressPairs adds the CIDRs that the allowed address pairs of the port don't already cover, and returns how
+// many were added. The pairs that are already there are kept, they may have been added by someone else.
+func (c *Client) AddAllowedAddressPairs(cidrs []netip.Prefix) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var current struct {
+		Port port `json:"port"`
+	}
+	if err := c.do(http.MethodGet, "/v2.0/ports/"+c.port, nil, &current); err != nil {
+		return 0, fmt.Errorf("unable to get the port [%s]: %v", c.port, err)
+	}
+	pairs := current.Port.AllowedAddressPairs
+	added := 0
+	for _, cidr := range cidrs {
+		if covered(pairs, cidr) {
+			continue
+		}
+		pairs = append(pairs, addressPair{IPAddress: pairAddress(cidr)})
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	update := map[string]any{"port": map[string]any{"allowed_address_pairs": pairs}}
+	if err := c.do(http.MethodPut, "/v2.0/ports/"+c.port, update, nil); err != nil {
+		return 0, fmt.Errorf("unable to update the allowed address pairs of the port [%s]: %v", c.port, err)
+	}
+	log.Infof("[OpenStack] added %d allowed address pairs to the port [%s]", added, c.port)
+	return added, nil
+}
+
+// covered returns whether one of the pairs (without a MAC address of their own) already allows the CIDR
+func covered(pairs []addressPair, cidr netip.Prefix) bool {
+	for _, pair := range pairs {
+		if pair.MACAddress != "" {
+			continue
+		}
+		existing, err := netip.ParsePrefix(pair.IPAddress)
+		if err != nil {
+			addr, err := netip.ParseAddr(pair.IPAddress)
+			if err != nil {
+				continue
+			}
+			existing = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		if existing.Bits() <= cidr.Bits() && existing.Contains(cidr.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// pairAddress returns the address of the pair of the CIDR, a single address is added without its prefix length
+func pairAddress(cidr netip.Prefix) string {
+	if cidr.IsSingleIP() {
+		return cidr.Addr().String()
+	}
+	return cidr.Masked().String()
+}
+
+// instanceID returns the ID of this instance from the metadata service
+func (c *Client) instanceID() (string, error) {
+	resp, err := c.client.Get(metadataURL + "/openstack/latest/meta_data.json")
+	if err != nil {
+		return "", fmt.Errorf("unable to reach the metadata service: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to read the instance metadata, status [%s]", resp.Status)
+	}
+	var metadata struct {
+		UUID string `json:"uuid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return "", fmt.Errorf("unable to read the instance metadata: %v", err)
+	}
+	return metadata.UUID, nil
+}
+
+// do sends the request to the network service, and decodes its response into the result
+func (c *Client) do(method, resource string, body, result any) error {
+	token, endpoint, err := c.identity.authenticate()
+	if err != nil {
+		return err
+	}
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, endpoint+resource, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Auth-Token", token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			NeutronError struct {
+				Message string `json:"message"`
+			} `json:"NeutronError"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.NeutronError.Message == "" {
+			return fmt.Errorf("%s failed with status [%s]", method, resp.Status)
+		}
+		return fmt.Errorf("%s failed: %s", method, apiErr.NeutronError.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,143 @@
+package openstack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// fakeOpenStack answers the metadata of the instance, the tokens of Keystone and the ports of Neutron
+type fakeOpenStack struct {
+	url string
+
+	mu      sync.Mutex
+	pairs   []addressPair
+	updates int
+}
+
+func (f *fakeOpenStack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var body any
+	switch r.Method + " " + r.URL.Path {
+	case "GET /openstack/latest/meta_data.json":
+		body = map[string]any{"uuid": "instance"}
+	case "POST /identity/v3/auth/tokens":
+		var request struct {
+			Auth struct {
+				Identity struct {
+					Password struct {
+						User struct {
+							Name     string `json:"name"`
+							Password string `json:"password"`
+						} `json:"user"`
+					} `json:"password"`
+				} `json:"identity"`
+			} `json:"auth"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request.Auth.Identity.Password.User.Name != "kube-vip" || request.Auth.Identity.Password.User.Password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-Subject-Token", "token")
+		w.WriteHeader(http.StatusCreated)
+		body = map[string]any{"token": map[string]any{
+			"expires_at": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			"catalog": []any{map[string]any{"type": "network", "endpoints": []any{
+				map[string]any{"interface": "internal", "region": "RegionOne", "url": "http://internal.invalid"},
+				map[string]any{"interface": "public", "region": "RegionOne", "url": f.url + "/network/"},
+			}}},
+		}}
+	case "GET /network/v2.0/ports":
+		if r.URL.Query().Get("device_id") != "instance" {
+			http.NotFound(w, r)
+			return
+		}
+		body = map[string]any{"ports": []port{{ID: "port", MACAddress: "fa:16:3e:00:00:01"}}}
+	case "GET /network/v2.0/ports/port":
+		body = map[string]any{"port": port{ID: "port", AllowedAddressPairs: f.pairs}}
+	case "PUT /network/v2.0/ports/port":
+		var update struct {
+			Port port `json:"port"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&update)
+		f.pairs = update.Port.AllowedAddressPairs
+		f.updates++
+		body = update
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Header.Get("X-Auth-Token") != "token" && r.URL.Path != "/identity/v3/auth/tokens" && r.URL.Path != "/openstack/latest/meta_data.json" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestAddAllowedAddressPairs(t *testing.T) {
+	f := &fakeOpenStack{pairs: []addressPair{
+		{IPAddress: "192.168.1.0/24"},
+		{IPAddress: "10.0.0.5", MACAddress: "fa:16:3e:00:00:99"},
+	}}
+	server := httptest.NewServer(f)
+	defer server.Close()
+	f.url = server.URL
+	previous := metadataURL
+	metadataURL = server.URL
+	defer func() { metadataURL = previous }()
+	t.Setenv("OS_AUTH_URL", server.URL+"/identity")
+	t.Setenv("OS_USERNAME", "kube-vip")
+	t.Setenv("OS_PASSWORD", "secret")
+	t.Setenv("OS_PROJECT_NAME", "cluster")
+	t.Setenv("OS_REGION_NAME", "RegionOne")
+	t.Setenv("OS_APPLICATION_CREDENTIAL_ID", "")
+
+	// The only port of the instance is used when the interface isn't found
+	c, err := NewClient(&kubevip.Config{Interface: "missing0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cidrs := []netip.Prefix{
+		netip.MustParsePrefix("192.168.0.100/32"),
+		// Already allowed by the pool
+		netip.MustParsePrefix("192.168.1.10/32"),
+		// Only allowed for another MAC address
+		netip.MustParsePrefix("10.0.0.5/32"),
+		netip.MustParsePrefix("fd00::/64"),
+	}
+	added, err := c.AddAllowedAddressPairs(cidrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 3 {
+		t.Errorf("added = %d, want 3", added)
+	}
+	want := []addressPair{
+		{IPAddress: "192.168.1.0/24"},
+		{IPAddress: "10.0.0.5", MACAddress: "fa:16:3e:00:00:99"},
+		{IPAddress: "192.168.0.100"},
+		{IPAddress: "10.0.0.5"},
+		{IPAddress: "fd00::/64"},
+	}
+	if len(f.pairs) != len(want) {
+		t.Fatalf("pairs = %+v, want %+v", f.pairs, want)
+	}
+	for i := range want {
+		if f.pairs[i] != want[i] {
+			t.Errorf("pair %d = %+v, want %+v", i, f.pairs[i], want[i])
+		}
+	}
+
+	// Nothing is updated when every CIDR is already allowed
+	if added, err := c.AddAllowedAddressPairs(cidrs); err != nil || added != 0 || f.updates != 1 {
+		t.Errorf("AddAllowedAddressPairs() = %d, %v with %d updates, want nothing added", added, err, f.updates)
+	}
+}
@@ -0,0 +1,127 @@
+package portmap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+const (
+	natpmpOpExternalAddress = 0
+	natpmpOpMapUDP          = 1
+	natpmpOpMapTCP          = 2
+)
+
+// natpmpResults are the result codes of NAT-PMP (RFC6886 3.5)
+var natpmpResults = map[uint16]string{
+	1: "unsupported version",
+	2: "not authorized",
+	3: "network failure",
+	4: "out of resources",
+	5: "unsupported opcode",
+}
+
+// natpmpClient maps the ports with NAT-PMP (RFC6886), which maps the ports to the address that the request comes from,
+// the requests are sent from the VIP
+type natpmpClient struct {
+	gateway string
+
+	mu         sync.Mutex
+	externalIP string
+}
+
+// newNATPMP returns the client once the gateway has answered with its external address
+func newNATPMP(gateway string) (*natpmpClient, error) {
+	c := &natpmpClient{gateway: gateway}
+	response, err := c.request(nil, []byte{0, natpmpOpExternalAddress}, natpmpOpExternalAddress, 12)
+	if err != nil {
+		return nil, err
+	}
+	c.externalIP = net.IP(response[8:12]).String()
+	return c, nil
+}
+
+func (c *natpmpClient) Name() string {
+	return kubevip.UPNPProtocolNATPMP
+}
+
+func (c *natpmpClient) Gateway() string {
+	host, _, _ := net.SplitHostPort(c.gateway)
+	return host
+}
+
+func (c *natpmpClient) ExternalIP() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.externalIP
+}
+
+func (c *natpmpClient) AddMapping(address string, port int, protocol string, lease time.Duration, _ string) (time.Duration, error) {
+	if lease == 0 {
+		lease = defaultLease
+	}
+	response, err := c.mapPort(address, port, port, protocol, lease)
+	if err != nil {
+		return 0, err
+	}
+	if external := int(binary.BigEndian.Uint16(response[10:12])); external != port {
+		// The port is taken, the mapping that was created instead is removed
+		_, _ = c.mapPort(address, port, 0, protocol, 0)
+		return 0, fmt.Errorf("the gateway mapped port [%d] to [%d] rather than the same port", port, external)
+	}
+	return time.Duration(binary.BigEndian.Uint32(response[12:16])) * time.Second, nil
+}
+
+// DeleteMapping removes the mapping, with a lifetime and external port of zero (RFC6886 3.4)
+func (c *natpmpClient) DeleteMapping(address string, port int, protocol string) error {
+	_, err := c.mapPort(address, port, 0, protocol, 0)
+	return err
+}
+
+// mapPort sends the request of a mapping from the address
+func (c *natpmpClient) mapPort(address string, port, external int, protocol string, lease time.Duration) ([]byte, error) {
+	local := net.ParseIP(address).To4()
+	if local == nil {
+		return nil, fmt.Errorf("NAT-PMP only maps the ports of IPv4 addresses, not [%s]", address)
+	}
+	var op byte
+	switch strings.ToUpper(protocol) {
+	case "UDP":
+		op = natpmpOpMapUDP
+	case "TCP":
+		op = natpmpOpMapTCP
+	default:
+		return nil, fmt.Errorf("NAT-PMP doesn't map the ports of protocol [%s]", protocol)
+	}
+	request := make([]byte, 12)
+	request[1] = op
+	binary.BigEndian.PutUint16(request[4:6], uint16(port))
+	binary.BigEndian.PutUint16(request[6:8], uint16(external))
+	binary.BigEndian.PutUint32(request[8:12], uint32(lease/time.Second))
+	return c.request(local, request, op, 16)
+}
+
+// request sends the request to the gateway and checks the result of its response
+func (c *natpmpClient) request(local net.IP, request []byte, op byte, size int) ([]byte, error) {
+	response, err := exchange(local, c.gateway, request, func(b []byte) bool {
+		return len(b) >= 4 && b[0] == 0 && b[1] == 128+op
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result := binary.BigEndian.Uint16(response[2:4]); result != 0 {
+		if reason, known := natpmpResults[result]; known {
+			return nil, fmt.Errorf("the gateway refused the request: %s", reason)
+		}
+		return nil, fmt.Errorf("the gateway refused the request with result [%d]", result)
+	}
+	if len(response) < size {
+		return nil, fmt.Errorf("the response of the gateway is too short")
+	}
+	return response, nil
+}
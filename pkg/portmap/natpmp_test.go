@@ -0,0 +1,112 @@
+package portmap
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeGateway answers each request that it receives with the response of the handler
+func fakeGateway(t *testing.T, handler func(request []byte) []byte) string {
+	t.Helper()
+	return listenGateway(t, net.IPv4(127, 0, 0, 1), handler)
+}
+
+// fakeGateway6 is the fake gateway of the IPv6 addresses
+func fakeGateway6(t *testing.T, handler func(request []byte) []byte) string {
+	t.Helper()
+	return listenGateway(t, net.IPv6loopback, handler)
+}
+
+func listenGateway(t *testing.T, ip net.IP, handler func(request []byte) []byte) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: ip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 1100)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if response := handler(append([]byte(nil), buf[:n]...)); response != nil {
+				_, _ = conn.WriteToUDP(response, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// natpmpGateway maps the ports that aren't taken with the requested lifetime, up to an hour
+func natpmpGateway(taken int) func([]byte) []byte {
+	return func(request []byte) []byte {
+		response := make([]byte, 16)
+		response[1] = 128 + request[1]
+		if request[1] == natpmpOpExternalAddress {
+			copy(response[8:12], net.IPv4(203, 0, 113, 1).To4())
+			return response[:12]
+		}
+		copy(response[8:10], request[4:6])
+		external := binary.BigEndian.Uint16(request[6:8])
+		if int(external) == taken {
+			external++
+		}
+		binary.BigEndian.PutUint16(response[10:12], external)
+		lifetime := min(binary.BigEndian.Uint32(request[8:12]), 3600)
+		binary.BigEndian.PutUint32(response[12:16], lifetime)
+		return response
+	}
+}
+
+func TestNATPMPAddMapping(t *testing.T) {
+	c, err := newNATPMP(fakeGateway(t, natpmpGateway(8080)))
+	if err != nil {
+		t.Fatalf("newNATPMP() error = %v", err)
+	}
+	if c.ExternalIP() != "203.0.113.1" {
+		t.Errorf("ExternalIP() = %s, want 203.0.113.1", c.ExternalIP())
+	}
+
+	tests := []struct {
+		name     string
+		address  string
+		port     int
+		protocol string
+		lease    time.Duration
+		want     time.Duration
+		wantErr  bool
+	}{
+		{"tcp", "127.0.0.1", 443, "TCP", 30 * time.Minute, 30 * time.Minute, false},
+		{"lease shortened by the gateway", "127.0.0.1", 53, "udp", 2 * time.Hour, time.Hour, false},
+		{"permanent", "127.0.0.1", 443, "TCP", 0, time.Hour, false},
+		{"port taken", "127.0.0.1", 8080, "TCP", time.Hour, 0, true},
+		{"ipv6", "::1", 443, "TCP", time.Hour, 0, true},
+		{"sctp", "127.0.0.1", 443, "SCTP", time.Hour, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.AddMapping(tt.address, tt.port, tt.protocol, tt.lease, "web")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddMapping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("AddMapping() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNATPMPRefused(t *testing.T) {
+	gateway := fakeGateway(t, func(request []byte) []byte {
+		// Out of resources
+		return []byte{0, 128 + request[1], 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	})
+	c := &natpmpClient{gateway: gateway}
+	if _, err := c.AddMapping("127.0.0.1", 443, "TCP", time.Hour, "web"); err == nil {
+		t.Fatal("AddMapping() = nil, want the refusal of the gateway")
+	}
+}
@@ -0,0 +1,207 @@
+package portmap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+const (
+	pcpVersion    = 2
+	pcpOpAnnounce = 0
+	pcpOpMap      = 1
+	// pcpResponse is the bit of the opcode that is set in a response
+	pcpResponse = 0x80
+)
+
+// pcpResults are the result codes of PCP (RFC6887 7.4)
+var pcpResults = map[byte]string{
+	1:  "UNSUPP_VERSION",
+	2:  "NOT_AUTHORIZED",
+	3:  "MALFORMED_REQUEST",
+	4:  "UNSUPP_OPCODE",
+	5:  "UNSUPP_OPTION",
+	6:  "MALFORMED_OPTION",
+	7:  "NETWORK_FAILURE",
+	8:  "NO_RESOURCES",
+	9:  "UNSUPP_PROTOCOL",
+	10: "USER_EX_QUOTA",
+	11: "CANNOT_PROVIDE_EXTERNAL",
+	12: "ADDRESS_MISMATCH",
+	13: "EXCESSIVE_REMOTE_PEERS",
+}
+
+// pcpClient maps the ports with the MAP opcode of PCP (RFC6887), the requests are sent from the VIP as the gateway only
+// maps the ports of the address that the request comes from
+type pcpClient struct {
+	gateway string
+	// gateway6 is the IPv6 router that opens the pinholes of the IPv6 addresses, it is empty without an IPv6 default
+	// route
+	gateway6 string
+
+	mu         sync.Mutex
+	externalIP string
+	// The nonce of each mapping, the gateway only renews or deletes a mapping with the nonce that created it
+	nonces map[string][]byte
+}
+
+// newPCP returns the client once the gateway has answered an announcement
+func newPCP(gateway string) (*pcpClient, error) {
+	c := &pcpClient{gateway: gateway, nonces: map[string][]byte{}}
+	// The address of the client in the request has to be the one that the request comes from
+	local, err := localAddress(gateway)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.request(local, pcpHeader(pcpOpAnnounce, 0, local)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *pcpClient) Name() string {
+	return kubevip.UPNPProtocolPCP
+}
+
+func (c *pcpClient) Gateway() string {
+	host, _, _ := net.SplitHostPort(c.gateway)
+	return host
+}
+
+func (c *pcpClient) ExternalIP() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.externalIP
+}
+
+func (c *pcpClient) AddMapping(address string, port int, protocol string, lease time.Duration, _ string) (time.Duration, error) {
+	if lease == 0 {
+		lease = defaultLease
+	}
+	response, err := c.mapPort(address, port, protocol, lease)
+	if err != nil {
+		return 0, err
+	}
+	if external := int(binary.BigEndian.Uint16(response[42:44])); external != port {
+		// The port is taken, the mapping that was created instead is removed
+		_, _ = c.mapPort(address, port, protocol, 0)
+		return 0, fmt.Errorf("the gateway mapped port [%d] to [%d] rather than the same port", port, external)
+	}
+	// The external address of a pinhole is the IPv6 address itself
+	if external := net.IP(response[44:60]).To4(); external != nil {
+		c.mu.Lock()
+		c.externalIP = external.String()
+		c.mu.Unlock()
+	}
+	return time.Duration(binary.BigEndian.Uint32(response[4:8])) * time.Second, nil
+}
+
+// DeleteMapping removes the mapping, with a lifetime of zero (RFC6887 15)
+func (c *pcpClient) DeleteMapping(address string, port int, protocol string) error {
+	_, err := c.mapPort(address, port, protocol, 0)
+	c.mu.Lock()
+	delete(c.nonces, mappingKey(address, port, protocol))
+	c.mu.Unlock()
+	return err
+}
+
+// mapPort sends the MAP request of the port from the address, with the same port as the suggested external port
+func (c *pcpClient) mapPort(address string, port int, protocol string, lease time.Duration) ([]byte, error) {
+	local := net.ParseIP(address)
+	if local == nil {
+		return nil, fmt.Errorf("invalid address [%s]", address)
+	}
+	if local.To4() == nil && c.gateway6 == "" {
+		return nil, fmt.Errorf("there is no IPv6 router to open the pinhole of [%s] with", address)
+	}
+	number, supported := protocolNumbers[strings.ToUpper(protocol)]
+	if !supported {
+		return nil, fmt.Errorf("PCP doesn't map the ports of protocol [%s]", protocol)
+	}
+
+	request := pcpHeader(pcpOpMap, lease, local)
+	request = append(request, c.nonce(mappingKey(address, port, protocol))...)
+	request = append(request, number, 0, 0, 0)
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	// Any external address of the family of the VIP
+	if local.To4() != nil {
+		request = append(request, net.IPv4zero.To16()...)
+	} else {
+		request = append(request, net.IPv6zero...)
+	}
+
+	response, err := c.request(local, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(response) < 60 {
+		return nil, fmt.Errorf("the response of the gateway is too short")
+	}
+	return response, nil
+}
+
+// nonce returns the nonce of the mapping, a new one is generated for a new mapping
+func (c *pcpClient) nonce(key string) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if nonce, exists := c.nonces[key]; exists {
+		return nonce
+	}
+	nonce := make([]byte, 12)
+	_, _ = rand.Read(nonce)
+	c.nonces[key] = nonce
+	return nonce
+}
+
+// request sends the request to the gateway (the IPv6 router for an IPv6 address) and checks the result of its
+// response, a gateway that only speaks NAT-PMP answers with a NAT-PMP response
+func (c *pcpClient) request(local net.IP, request []byte) ([]byte, error) {
+	gateway := c.gateway
+	if local.To4() == nil {
+		gateway = c.gateway6
+	}
+	op := request[1]
+	response, err := exchange(local, gateway, request, func(b []byte) bool {
+		if len(b) >= 4 && b[0] == 0 {
+			return true
+		}
+		if len(b) < 24 || b[0] != pcpVersion || b[1] != pcpResponse|op {
+			return false
+		}
+		// The response to a MAP request has the nonce of the request
+		return op != pcpOpMap || (len(b) >= 36 && bytes.Equal(b[24:36], request[24:36]))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response[0] != pcpVersion {
+		return nil, fmt.Errorf("the gateway only speaks NAT-PMP")
+	}
+	if result := response[3]; result != 0 {
+		if reason, known := pcpResults[result]; known {
+			return nil, fmt.Errorf("the gateway refused the request: %s", reason)
+		}
+		return nil, fmt.Errorf("the gateway refused the request with result [%d]", result)
+	}
+	return response, nil
+}
+
+// pcpHeader returns the header of a request from the address (RFC6887 7.1)
+func pcpHeader(op byte, lease time.Duration, local net.IP) []byte {
+	header := []byte{pcpVersion, op, 0, 0}
+	header = binary.BigEndian.AppendUint32(header, uint32(lease/time.Second))
+	return append(header, local.To16()...)
+}
+
+// mappingKey returns the key of the mapping of the port to the address
+func mappingKey(address string, port int, protocol string) string {
+	return fmt.Sprintf("%s/%d/%s", address, port, strings.ToUpper(protocol))
+}
@@ -0,0 +1,112 @@
+package portmap
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// pcpGateway maps the suggested ports that aren't taken with the requested lifetime, up to an hour
+func pcpGateway(taken int) func([]byte) []byte {
+	return func(request []byte) []byte {
+		response := make([]byte, len(request))
+		copy(response, request)
+		response[1] |= pcpResponse
+		if request[1] != pcpOpMap {
+			return response
+		}
+		lifetime := min(binary.BigEndian.Uint32(request[4:8]), 3600)
+		binary.BigEndian.PutUint32(response[4:8], lifetime)
+		if int(binary.BigEndian.Uint16(request[42:44])) == taken {
+			binary.BigEndian.PutUint16(response[42:44], uint16(taken+1))
+		}
+		if client := net.IP(request[8:24]); client.To4() == nil {
+			// The external address of a pinhole is the address of the client
+			copy(response[44:60], client)
+		} else {
+			copy(response[44:60], net.IPv4(203, 0, 113, 1).To16())
+		}
+		return response
+	}
+}
+
+func TestPCPAddMapping(t *testing.T) {
+	c, err := newPCP(fakeGateway(t, pcpGateway(8080)))
+	if err != nil {
+		t.Fatalf("newPCP() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		address  string
+		port     int
+		protocol string
+		lease    time.Duration
+		want     time.Duration
+		wantErr  bool
+	}{
+		{"tcp", "127.0.0.1", 443, "TCP", 30 * time.Minute, 30 * time.Minute, false},
+		{"lease shortened by the gateway", "127.0.0.1", 5060, "SCTP", 2 * time.Hour, time.Hour, false},
+		{"permanent", "127.0.0.1", 53, "udp", 0, time.Hour, false},
+		{"port taken", "127.0.0.1", 8080, "TCP", time.Hour, 0, true},
+		{"unknown protocol", "127.0.0.1", 443, "ICMP", time.Hour, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.AddMapping(tt.address, tt.port, tt.protocol, tt.lease, "web")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddMapping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("AddMapping() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	if c.ExternalIP() != "203.0.113.1" {
+		t.Errorf("ExternalIP() = %s, want 203.0.113.1", c.ExternalIP())
+	}
+
+	// The mapping is renewed and deleted with the nonce that created it
+	nonce := c.nonce(mappingKey("127.0.0.1", 443, "tcp"))
+	if err := c.DeleteMapping("127.0.0.1", 443, "TCP"); err != nil {
+		t.Fatalf("DeleteMapping() error = %v", err)
+	}
+	if renewed := c.nonce(mappingKey("127.0.0.1", 443, "TCP")); string(renewed) == string(nonce) {
+		t.Error("DeleteMapping() kept the nonce of the deleted mapping")
+	}
+}
+
+func TestPCPOnlyNATPMP(t *testing.T) {
+	// A gateway that only speaks NAT-PMP answers with UNSUPP_VERSION and its own version
+	gateway := fakeGateway(t, func(request []byte) []byte {
+		return []byte{0, 128 + request[1], 0, 1, 0, 0, 0, 0}
+	})
+	if _, err := newPCP(gateway); err == nil {
+		t.Fatal("newPCP() = nil, want an error as the gateway only speaks NAT-PMP")
+	}
+}
+
+func TestPCPPinhole(t *testing.T) {
+	c := &pcpClient{gateway: fakeGateway(t, pcpGateway(0)), nonces: map[string][]byte{}}
+	if _, err := c.AddMapping("::1", 443, "TCP", time.Hour, "web"); err == nil {
+		t.Fatal("AddMapping() = nil, want an error without an IPv6 router")
+	}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv6loopback})
+	if err != nil {
+		t.Skipf("IPv6 isn't available: %v", err)
+	}
+	conn.Close()
+	c.gateway6 = fakeGateway6(t, pcpGateway(0))
+	lease, err := c.AddMapping("::1", 443, "TCP", time.Hour, "web")
+	if err != nil {
+		t.Fatalf("AddMapping() error = %v", err)
+	}
+	if lease != time.Hour {
+		t.Errorf("AddMapping() = %s, want %s", lease, time.Hour)
+	}
+	if c.ExternalIP() != "" {
+		t.Errorf("ExternalIP() = %s, want the external address to be left to the IPv4 mappings", c.ExternalIP())
+	}
+}
@@ -0,0 +1,94 @@
+package portmap
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	firewallServiceType = "urn:schemas-upnp-org:service:WANIPv6FirewallControl:1"
+	// maxPinholeLease is the longest lease of a pinhole (WANIPv6FirewallControl 2.5.8)
+	maxPinholeLease = 86400 * time.Second
+)
+
+// protocolNumbers are the IANA numbers of the protocols that are mapped
+var protocolNumbers = map[string]byte{"TCP": 6, "UDP": 17, "SCTP": 132}
+
+// upnpFirewall opens pinholes for the IPv6 addresses with the WANIPv6FirewallControl service of an UPnP Internet
+// Gateway Device, as the IPv6 addresses aren't translated but the firewall of the gateway still drops the connections
+type upnpFirewall struct {
+	service *soapService
+
+	mu sync.Mutex
+	// The unique ID of each pinhole, it is updated or deleted with it
+	pinholes map[string]string
+}
+
+// newUPNPFirewall returns the firewall if the gateway offers the WANIPv6FirewallControl service
+func newUPNPFirewall(client *http.Client, services map[string]string) (*upnpFirewall, error) {
+	service, err := newSOAPService(client, services, firewallServiceType)
+	if err != nil {
+		return nil, err
+	}
+	return &upnpFirewall{service: service, pinholes: map[string]string{}}, nil
+}
+
+// addPinhole opens the port of the address for the lease, an existing pinhole has its lease updated instead
+func (f *upnpFirewall) addPinhole(address string, port int, protocol string, lease time.Duration) (time.Duration, error) {
+	number, supported := protocolNumbers[strings.ToUpper(protocol)]
+	if !supported {
+		return 0, fmt.Errorf("the gateway doesn't open pinholes for protocol [%s]", protocol)
+	}
+	if lease == 0 {
+		lease = defaultLease
+	}
+	lease = min(lease, maxPinholeLease)
+	seconds := strconv.Itoa(int(lease / time.Second))
+
+	key := mappingKey(address, port, protocol)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id, exists := f.pinholes[key]; exists {
+		_, err := f.service.call("UpdatePinhole", [][2]string{{"UniqueID", id}, {"NewLeaseTime", seconds}})
+		if err == nil {
+			return lease, nil
+		}
+		// The pinhole has expired or the gateway has restarted, it is opened again
+		delete(f.pinholes, key)
+	}
+	response, err := f.service.call("AddPinhole", [][2]string{
+		{"RemoteHost", ""},
+		{"RemotePort", "0"},
+		{"InternalClient", address},
+		{"InternalPort", strconv.Itoa(port)},
+		{"Protocol", strconv.Itoa(int(number))},
+		{"LeaseTime", seconds},
+	})
+	if err != nil {
+		return 0, err
+	}
+	id, exists := response["UniqueID"]
+	if !exists {
+		return 0, fmt.Errorf("the gateway didn't return the ID of the pinhole")
+	}
+	f.pinholes[key] = id
+	return lease, nil
+}
+
+// deletePinhole closes the pinhole of the port of the address
+func (f *upnpFirewall) deletePinhole(address string, port int, protocol string) error {
+	key := mappingKey(address, port, protocol)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id, exists := f.pinholes[key]
+	if !exists {
+		return nil
+	}
+	delete(f.pinholes, key)
+	_, err := f.service.call("DeletePinhole", [][2]string{{"UniqueID", id}})
+	return err
+}
@@ -0,0 +1,133 @@
+package portmap
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:2</deviceType>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:2</deviceType>
+        <serviceList>
+          <service>
+            <serviceType>urn:schemas-upnp-org:service:WANIPConnection:2</serviceType>
+            <controlURL>/ctl/IPConn</controlURL>
+          </service>
+          <service>
+            <serviceType>urn:schemas-upnp-org:service:WANIPv6FirewallControl:1</serviceType>
+            <controlURL>/ctl/IP6FCtl</controlURL>
+          </service>
+        </serviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`
+
+func TestUPNPFirewall(t *testing.T) {
+	var actions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(testDescription))
+			return
+		}
+		if r.URL.Path != "/ctl/IP6FCtl" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		action := r.Header.Get("SOAPAction")
+		actions = append(actions, action)
+		switch {
+		case strings.Contains(action, "#AddPinhole"):
+			if !strings.Contains(string(body), "<InternalClient>2001:db8::10</InternalClient>") || !strings.Contains(string(body), "<Protocol>6</Protocol>") {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("<errorCode>402</errorCode><errorDescription>Invalid Args</errorDescription>"))
+				return
+			}
+			_, _ = fmt.Fprint(w, `<s:Envelope><s:Body><u:AddPinholeResponse><UniqueID>7</UniqueID></u:AddPinholeResponse></s:Body></s:Envelope>`)
+		case strings.Contains(action, "#UpdatePinhole"), strings.Contains(action, "#DeletePinhole"):
+			if !strings.Contains(string(body), "<UniqueID>7</UniqueID>") {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("<errorCode>704</errorCode><errorDescription>NoSuchEntry</errorDescription>"))
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := newHTTPClient()
+	services, err := findServices(client, server.URL+"/rootDesc.xml")
+	if err != nil {
+		t.Fatalf("findServices() error = %v", err)
+	}
+	firewall, err := newUPNPFirewall(client, services)
+	if err != nil {
+		t.Fatalf("newUPNPFirewall() error = %v", err)
+	}
+	if want := server.URL + "/ctl/IP6FCtl"; firewall.service.controlURL != want {
+		t.Errorf("newUPNPFirewall() control URL = %s, want %s", firewall.service.controlURL, want)
+	}
+
+	c := &upnpClient{firewall: firewall}
+	lease, err := c.AddMapping("2001:db8::10", 443, "TCP", 0, "web")
+	if err != nil {
+		t.Fatalf("AddMapping() error = %v", err)
+	}
+	if lease != defaultLease {
+		t.Errorf("AddMapping() = %s, want the default lease %s for a permanent pinhole", lease, defaultLease)
+	}
+	// The pinhole is renewed with its ID, and its lease is capped
+	if lease, err = c.AddMapping("2001:db8::10", 443, "TCP", 7*24*time.Hour, "web"); err != nil {
+		t.Fatalf("AddMapping() error = %v", err)
+	}
+	if lease != maxPinholeLease {
+		t.Errorf("AddMapping() = %s, want the longest lease of a pinhole %s", lease, maxPinholeLease)
+	}
+	if err := c.DeleteMapping("2001:db8::10", 443, "TCP"); err != nil {
+		t.Fatalf("DeleteMapping() error = %v", err)
+	}
+	wantActions := []string{"AddPinhole", "UpdatePinhole", "DeletePinhole"}
+	if len(actions) != len(wantActions) {
+		t.Fatalf("the gateway was sent %v, want %v", actions, wantActions)
+	}
+	for i := range wantActions {
+		if want := fmt.Sprintf(`"%s#%s"`, firewallServiceType, wantActions[i]); actions[i] != want {
+			t.Errorf("the gateway was sent %s, want %s", actions[i], want)
+		}
+	}
+
+	if _, err := c.AddExternalMapping("2001:db8::10", 443, 8443, "TCP", time.Hour, "web"); err == nil {
+		t.Error("AddExternalMapping() = nil, want an error as the port of an IPv6 address isn't translated")
+	}
+	if _, err := c.AddMapping("2001:db8::20", 443, "TCP", time.Hour, "web"); err == nil {
+		t.Error("AddMapping() = nil, want the refusal of the gateway")
+	}
+}
+
+func TestUPNPFirewallUnsupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(strings.ReplaceAll(testDescription, "WANIPv6FirewallControl", "WANPPPConnection")))
+	}))
+	defer server.Close()
+
+	client := newHTTPClient()
+	services, err := findServices(client, server.URL+"/rootDesc.xml")
+	if err != nil {
+		t.Fatalf("findServices() error = %v", err)
+	}
+	if _, err := newUPNPFirewall(client, services); err == nil {
+		t.Fatal("newUPNPFirewall() = nil, want an error as the gateway doesn't offer the service")
+	}
+	c := &upnpClient{}
+	if _, err := c.AddMapping("2001:db8::10", 443, "TCP", time.Hour, "web"); err == nil {
+		t.Error("AddMapping() = nil, want an error without the firewall service")
+	}
+}
@@ -0,0 +1,182 @@
+// Package portmap forwards the ports of the gateway to the VIPs of the services, with UPnP IGD, NAT-PMP (RFC6886) or
+// PCP (RFC6887), whichever the gateway speaks. The IPv6 VIPs aren't translated, the firewall of the gateway has a
+// pinhole opened for them instead (with UPnP or PCP).
+package portmap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// defaultLease is the lease that is requested for a permanent mapping from NAT-PMP and PCP, which don't have them
+const defaultLease = 2 * time.Hour
+
+// Client maps the ports of the gateway to the addresses of this network
+type Client interface {
+	// Name returns the protocol of the client
+	Name() string
+	// Gateway returns the address of the gateway
+	Gateway() string
+	// ExternalIP returns the external address of the gateway, once it is known
+	ExternalIP() string
+	// AddMapping forwards the port of the gateway to the same port of the address for the lease (permanently when it
+	// is zero), the lease that the gateway granted is returned (zero for a permanent mapping)
+	AddMapping(address string, port int, protocol string, lease time.Duration, description string) (time.Duration, error)
+	// DeleteMapping removes the mapping of the port to the address
+	DeleteMapping(address string, port int, protocol string) error
+}
+
+// ExternalPortClient is a client that forwards another port of the gateway than the port of the address, which only
+// UPnP does
+type ExternalPortClient interface {
+	// AddExternalMapping forwards the external port of the gateway to the port of the address, as AddMapping does
+	AddExternalMapping(address string, port, externalPort int, protocol string, lease time.Duration, description string) (time.Duration, error)
+	// DeleteExternalMapping removes the mapping of the external port to the port of the address
+	DeleteExternalMapping(address string, port, externalPort int, protocol string) error
+}
+
+// New finds the gateway and returns the client of the protocol, the protocols are tried in turn (UPnP, PCP and then
+// NAT-PMP) until the gateway answers one of them when it is auto. The UPnP gateway is the one with the description URL
+// when it is set, and the gateways are only searched for through the interface when it is set.
+func New(protocol, gatewayURL, iface string) (Client, error) {
+	switch protocol {
+	case kubevip.UPNPProtocolUPNP:
+		return newUPNP(gatewayURL, iface)
+	case kubevip.UPNPProtocolNATPMP, kubevip.UPNPProtocolPCP:
+		gateway, err := defaultGateway(iface)
+		if err != nil {
+			return nil, err
+		}
+		if protocol == kubevip.UPNPProtocolPCP {
+			client, err := newPCP(gateway)
+			if err != nil {
+				return nil, err
+			}
+			if client.gateway6, err = defaultGateway6(iface); err != nil {
+				log.Debugf("[UPNP] the pinholes of the IPv6 addresses won't be opened: %v", err)
+			}
+			return client, nil
+		}
+		return newNATPMP(gateway)
+	case "", kubevip.UPNPProtocolAuto:
+		var errs []error
+		for _, p := range []string{kubevip.UPNPProtocolUPNP, kubevip.UPNPProtocolPCP, kubevip.UPNPProtocolNATPMP} {
+			client, err := New(p, gatewayURL, iface)
+			if err == nil {
+				return client, nil
+			}
+			log.Debugf("[UPNP] the gateway doesn't answer %s: %v", p, err)
+			errs = append(errs, fmt.Errorf("%s: %v", p, err))
+		}
+		return nil, fmt.Errorf("the gateway doesn't answer any of the port mapping protocols: %v", errors.Join(errs...))
+	}
+	return nil, fmt.Errorf("unknown port mapping protocol [%s]", protocol)
+}
+
+// defaultGateway returns the address of the gateway of the default IPv4 route (through the interface when it is set),
+// along with the port of NAT-PMP and PCP
+func defaultGateway(iface string) (string, error) {
+	routes, err := defaultRoutes(iface, netlink.FAMILY_V4)
+	if err != nil {
+		return "", fmt.Errorf("unable to list the routes: %v", err)
+	}
+	for _, route := range routes {
+		if (route.Dst == nil || route.Dst.String() == "0.0.0.0/0") && route.Gw != nil {
+			return net.JoinHostPort(route.Gw.String(), "5351"), nil
+		}
+	}
+	return "", fmt.Errorf("there is no default route to find the gateway with")
+}
+
+// defaultGateway6 returns the address of the router of the default IPv6 route (along with its interface when it is a
+// link-local address) and the port of PCP
+func defaultGateway6(iface string) (string, error) {
+	routes, err := defaultRoutes(iface, netlink.FAMILY_V6)
+	if err != nil {
+		return "", fmt.Errorf("unable to list the routes: %v", err)
+	}
+	for _, route := range routes {
+		if (route.Dst != nil && route.Dst.String() != "::/0") || route.Gw == nil {
+			continue
+		}
+		host := route.Gw.String()
+		if route.Gw.IsLinkLocalUnicast() {
+			link, err := netlink.LinkByIndex(route.LinkIndex)
+			if err != nil {
+				return "", fmt.Errorf("unable to find the interface of the IPv6 router: %v", err)
+			}
+			host += "%" + link.Attrs().Name
+		}
+		return net.JoinHostPort(host, "5351"), nil
+	}
+	return "", fmt.Errorf("there is no default IPv6 route to find the router with")
+}
+
+// defaultRoutes lists the routes of the family, only those through the interface when it is set
+func defaultRoutes(iface string, family int) ([]netlink.Route, error) {
+	var link netlink.Link
+	if iface != "" {
+		var err error
+		if link, err = netlink.LinkByName(iface); err != nil {
+			return nil, fmt.Errorf("unable to find the interface [%s]: %v", iface, err)
+		}
+	}
+	return netlink.RouteList(link, family)
+}
+
+// localAddress returns the address of this node that the gateway is reached from
+func localAddress(gateway string) (net.IP, error) {
+	conn, err := net.Dial("udp", gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+// exchange sends the request from the address (any address when it is nil) to the gateway, and returns the first
+// response that the check accepts. The request is sent again with a doubling timeout (RFC6886 3.1).
+func exchange(local net.IP, gateway string, request []byte, check func([]byte) bool) ([]byte, error) {
+	raddr, err := net.ResolveUDPAddr("udp", gateway)
+	if err != nil {
+		return nil, err
+	}
+	var laddr *net.UDPAddr
+	if local != nil {
+		// The gateway maps the ports to the address that the request comes from
+		laddr = &net.UDPAddr{IP: local}
+	}
+	conn, err := net.DialUDP("udp", laddr, raddr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 1100)
+	timeout := 250 * time.Millisecond
+	for attempt := 0; attempt < 4; attempt++ {
+		if _, err := conn.Write(request); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(timeout)
+		_ = conn.SetReadDeadline(deadline)
+		for time.Now().Before(deadline) {
+			n, err := conn.Read(buf)
+			if err != nil {
+				break
+			}
+			if check(buf[:n]) {
+				return append([]byte(nil), buf[:n]...), nil
+			}
+		}
+		timeout *= 2
+	}
+	return nil, fmt.Errorf("no answer from the gateway [%s]", gateway)
+}
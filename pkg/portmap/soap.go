@@ -0,0 +1,156 @@
+package portmap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// soapService is a service of an UPnP device, whose actions are called with SOAP
+type soapService struct {
+	serviceType string
+	controlURL  string
+	client      *http.Client
+}
+
+// findServices returns the control URL of each service in the description of the device (and its embedded devices),
+// by the type of the service
+func findServices(client *http.Client, descriptionURL string) (map[string]string, error) {
+	base, err := url.Parse(descriptionURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid description URL [%s]: %v", descriptionURL, err)
+	}
+	resp, err := client.Get(descriptionURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to read the description of the gateway, status [%s]", resp.Status)
+	}
+
+	services := map[string]string{}
+	decoder := xml.NewDecoder(resp.Body)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return services, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the description of the gateway: %v", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "URLBase":
+			// The control URLs are relative to the base URL rather than to the description, when it is set
+			var urlBase string
+			if err := decoder.DecodeElement(&urlBase, &start); err == nil && strings.TrimSpace(urlBase) != "" {
+				if u, err := url.Parse(strings.TrimSpace(urlBase)); err == nil {
+					base = u
+				}
+			}
+		case "service":
+			var service struct {
+				ServiceType string `xml:"serviceType"`
+				ControlURL  string `xml:"controlURL"`
+			}
+			if err := decoder.DecodeElement(&service, &start); err != nil {
+				return nil, fmt.Errorf("unable to read the description of the gateway: %v", err)
+			}
+			controlURL, err := url.Parse(strings.TrimSpace(service.ControlURL))
+			if err != nil {
+				continue
+			}
+			services[strings.TrimSpace(service.ServiceType)] = base.ResolveReference(controlURL).String()
+		}
+	}
+}
+
+// newSOAPService returns the first of the types of services that the device offers
+func newSOAPService(client *http.Client, services map[string]string, serviceTypes ...string) (*soapService, error) {
+	for _, serviceType := range serviceTypes {
+		if controlURL, exists := services[serviceType]; exists {
+			return &soapService{serviceType: serviceType, controlURL: controlURL, client: client}, nil
+		}
+	}
+	return nil, fmt.Errorf("the gateway doesn't offer the %s service", strings.Join(serviceTypes, " or "))
+}
+
+// call sends the action with its arguments to the service, and returns the values of its response
+func (s *soapService) call(action string, arguments [][2]string) (map[string]string, error) {
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(&body, `<u:%s xmlns:u="%s">`, action, s.serviceType)
+	for _, argument := range arguments {
+		fmt.Fprintf(&body, "<%s>", argument[0])
+		_ = xml.EscapeText(&body, []byte(argument[1]))
+		fmt.Fprintf(&body, "</%s>", argument[0])
+	}
+	fmt.Fprintf(&body, `</u:%s></s:Body></s:Envelope>`, action)
+
+	req, err := http.NewRequest(http.MethodPost, s.controlURL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s#%s"`, s.serviceType, action))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The values of the response (or of the fault) are the elements that only have text
+	values := map[string]string{}
+	decoder := xml.NewDecoder(resp.Body)
+	var name, text string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			name, text = t.Name.Local, ""
+		case xml.CharData:
+			text += string(t)
+		case xml.EndElement:
+			if t.Name.Local == name {
+				values[name] = strings.TrimSpace(text)
+			}
+			name = ""
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &soapError{action: action, status: resp.Status, code: values["errorCode"], description: values["errorDescription"]}
+	}
+	return values, nil
+}
+
+// soapError is the fault of an action, with the UPnP error of the service when there is one
+type soapError struct {
+	action      string
+	status      string
+	code        string
+	description string
+}
+
+func (e *soapError) Error() string {
+	if e.description != "" {
+		return fmt.Sprintf("%s failed: %s", e.action, e.description)
+	}
+	return fmt.Sprintf("%s failed with status [%s]", e.action, e.status)
+}
+
+// newHTTPClient returns the client of the services of the gateway
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: 5 * time.Second}
+}
@@ -0,0 +1,93 @@
+package portmap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/ipv4"
+)
+
+const ssdpAddress = "239.255.255.250:1900"
+
+// igdSearchTargets are the devices that are searched for, an IGDv2 device doesn't always answer the search of IGDv1
+var igdSearchTargets = []string{
+	"urn:schemas-upnp-org:device:InternetGatewayDevice:1",
+	"urn:schemas-upnp-org:device:InternetGatewayDevice:2",
+}
+
+// discoverGateways searches for the Internet Gateway Devices with SSDP from the interface (from the interface of the
+// default route when it is empty), and returns the description URLs of all those that answered within the timeout
+func discoverGateways(iface string, timeout time.Duration) ([]string, error) {
+	laddr := &net.UDPAddr{IP: net.IPv4zero}
+	var ifi *net.Interface
+	if iface != "" {
+		var err error
+		if ifi, err = net.InterfaceByName(iface); err != nil {
+			return nil, fmt.Errorf("unable to find the interface [%s]: %v", iface, err)
+		}
+		if laddr.IP, err = interfaceIPv4(ifi); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := net.ListenUDP("udp4", laddr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if ifi != nil {
+		if err := ipv4.NewPacketConn(conn).SetMulticastInterface(ifi); err != nil {
+			return nil, fmt.Errorf("unable to search from the interface [%s]: %v", iface, err)
+		}
+	}
+
+	raddr, err := net.ResolveUDPAddr("udp4", ssdpAddress)
+	if err != nil {
+		return nil, err
+	}
+	for _, target := range igdSearchTargets {
+		search := fmt.Sprintf("M-SEARCH * HTTP/1.1\r\nHOST: %s\r\nST: %s\r\nMAN: \"ssdp:discover\"\r\nMX: 2\r\n\r\n", ssdpAddress, target)
+		if _, err := conn.WriteToUDP([]byte(search), raddr); err != nil {
+			return nil, fmt.Errorf("unable to search for the gateways: %v", err)
+		}
+	}
+
+	var locations []string
+	seen := map[string]bool{}
+	buf := make([]byte, 2048)
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			// The timeout, every gateway has had the time to answer
+			break
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if location := resp.Header.Get("Location"); location != "" && !seen[location] {
+			seen[location] = true
+			locations = append(locations, location)
+		}
+	}
+	return locations, nil
+}
+
+// interfaceIPv4 returns the first IPv4 address of the interface
+func interfaceIPv4(ifi *net.Interface) (net.IP, error) {
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+			return ipnet.IP.To4(), nil
+		}
+	}
+	return nil, fmt.Errorf("the interface [%s] has no IPv4 address", ifi.Name)
+}
@@ -0,0 +1,195 @@
+package portmap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+// ssdpTimeout is how long the gateways have to answer the search
+const ssdpTimeout = 3 * time.Second
+
+// upnpConnectionTypes are the services that map the ports, the first that the gateway offers is used
+var upnpConnectionTypes = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:2",
+	"urn:schemas-upnp-org:service:WANIPConnection:1",
+	"urn:schemas-upnp-org:service:WANPPPConnection:1",
+}
+
+// upnpOnlyPermanentLeases is the error of a gateway that doesn't expire the mappings (WANIPConnection 2.4.16)
+const upnpOnlyPermanentLeases = "725"
+
+// upnpClient maps the ports with the WANIPConnection service of an UPnP Internet Gateway Device, and opens pinholes for
+// the IPv6 addresses with its WANIPv6FirewallControl service. The actions are called with SOAP here rather than with
+// github.com/kamhlos/upnp, which can't be given the selected gateway: its mappings are only usable once its own search
+// (from the address of the default route, keeping the first answer) has run, and it only speaks WANIPConnection:1.
+type upnpClient struct {
+	gateway    string
+	connection *soapService
+
+	mu         sync.Mutex
+	externalIP string
+
+	// firewall is nil when the gateway doesn't offer the WANIPv6FirewallControl service
+	firewall *upnpFirewall
+}
+
+// newUPNP returns the client of the gateway with the description URL, or of the first gateway that answers the search
+// from the interface when it is empty
+func newUPNP(gatewayURL, iface string) (*upnpClient, error) {
+	locations := []string{gatewayURL}
+	if gatewayURL == "" {
+		var err error
+		if locations, err = discoverGateways(iface, ssdpTimeout); err != nil {
+			return nil, err
+		}
+		if len(locations) == 0 {
+			return nil, fmt.Errorf("no gateway answered the search")
+		}
+		if len(locations) > 1 {
+			log.Warnf("[UPNP] %d gateways answered %v, the first that maps the ports is used unless the gateway URL is set", len(locations), locations)
+		}
+	}
+	var errs []error
+	for _, location := range locations {
+		c, err := newUPNPGateway(location)
+		if err == nil {
+			return c, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %v", location, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// newUPNPGateway returns the client of the gateway once it has answered with its external address
+func newUPNPGateway(descriptionURL string) (*upnpClient, error) {
+	location, err := url.Parse(descriptionURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gateway URL [%s]: %v", descriptionURL, err)
+	}
+	client := newHTTPClient()
+	services, err := findServices(client, descriptionURL)
+	if err != nil {
+		return nil, err
+	}
+	connection, err := newSOAPService(client, services, upnpConnectionTypes...)
+	if err != nil {
+		return nil, err
+	}
+	c := &upnpClient{gateway: location.Hostname(), connection: connection}
+	if err := c.updateExternalIP(); err != nil {
+		return nil, err
+	}
+	if c.firewall, err = newUPNPFirewall(client, services); err != nil {
+		log.Debugf("[UPNP] the pinholes of the IPv6 addresses won't be opened: %v", err)
+	}
+	return c, nil
+}
+
+func (c *upnpClient) Name() string {
+	return kubevip.UPNPProtocolUPNP
+}
+
+func (c *upnpClient) Gateway() string {
+	return c.gateway
+}
+
+func (c *upnpClient) ExternalIP() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.externalIP
+}
+
+func (c *upnpClient) AddMapping(address string, port int, protocol string, lease time.Duration, description string) (time.Duration, error) {
+	return c.AddExternalMapping(address, port, port, protocol, lease, description)
+}
+
+// DeleteMapping removes the mapping of the port, or closes the pinhole of an IPv6 address
+func (c *upnpClient) DeleteMapping(address string, port int, protocol string) error {
+	return c.DeleteExternalMapping(address, port, port, protocol)
+}
+
+// AddExternalMapping forwards the external port to the port of the address, the port of an IPv6 address has a pinhole
+// opened instead as it isn't translated
+func (c *upnpClient) AddExternalMapping(address string, port, externalPort int, protocol string, lease time.Duration, description string) (time.Duration, error) {
+	if isIPv6(address) {
+		if c.firewall == nil {
+			return 0, fmt.Errorf("the gateway doesn't open pinholes for the IPv6 address [%s]", address)
+		}
+		if externalPort != port {
+			return 0, fmt.Errorf("the port of the IPv6 address [%s] isn't translated, the external port has to be [%d]", address, port)
+		}
+		return c.firewall.addPinhole(address, port, protocol, lease)
+	}
+	err := c.addPortMapping(address, port, externalPort, protocol, lease, description)
+	var soapErr *soapError
+	if lease != 0 && errors.As(err, &soapErr) && soapErr.code == upnpOnlyPermanentLeases {
+		// The gateway only has permanent leases
+		lease = 0
+		err = c.addPortMapping(address, port, externalPort, protocol, lease, description)
+	}
+	if err != nil {
+		return 0, err
+	}
+	// The external address of the gateway may have changed since the mapping was last renewed
+	if err := c.updateExternalIP(); err != nil {
+		log.Debugf("[UPNP] unable to update the external address of the gateway: %v", err)
+	}
+	return lease, nil
+}
+
+// DeleteExternalMapping removes the mapping of the external port, or closes the pinhole of an IPv6 address
+func (c *upnpClient) DeleteExternalMapping(address string, port, externalPort int, protocol string) error {
+	if isIPv6(address) {
+		if c.firewall == nil {
+			return nil
+		}
+		return c.firewall.deletePinhole(address, port, protocol)
+	}
+	_, err := c.connection.call("DeletePortMapping", [][2]string{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(externalPort)},
+		{"NewProtocol", protocol},
+	})
+	return err
+}
+
+func (c *upnpClient) addPortMapping(address string, port, externalPort int, protocol string, lease time.Duration, description string) error {
+	_, err := c.connection.call("AddPortMapping", [][2]string{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(externalPort)},
+		{"NewProtocol", protocol},
+		{"NewInternalPort", strconv.Itoa(port)},
+		{"NewInternalClient", address},
+		{"NewEnabled", "1"},
+		{"NewPortMappingDescription", description},
+		{"NewLeaseDuration", strconv.Itoa(int(lease / time.Second))},
+	})
+	return err
+}
+
+// updateExternalIP asks the gateway for its external address
+func (c *upnpClient) updateExternalIP() error {
+	response, err := c.connection.call("GetExternalIPAddress", nil)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.externalIP = response["NewExternalIPAddress"]
+	c.mu.Unlock()
+	return nil
+}
+
+// isIPv6 returns whether the address is an IPv6 address, which has a pinhole opened rather than a port mapped
+func isIPv6(address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && ip.To4() == nil
+}
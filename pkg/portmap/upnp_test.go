@@ -0,0 +1,107 @@
+package portmap
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIGD is an Internet Gateway Device that only has permanent leases, its mappings are recorded by their external
+// port and protocol
+func fakeIGD(t *testing.T, mappings map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(testDescription))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		value := func(name string) string {
+			_, after, _ := strings.Cut(string(body), "<"+name+">")
+			v, _, _ := strings.Cut(after, "</"+name+">")
+			return v
+		}
+		fault := func(code, description string) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = fmt.Fprintf(w, "<s:Envelope><s:Body><s:Fault><detail><UPnPError><errorCode>%s</errorCode><errorDescription>%s</errorDescription></UPnPError></detail></s:Fault></s:Body></s:Envelope>", code, description)
+		}
+		switch action := r.Header.Get("SOAPAction"); {
+		case r.URL.Path != "/ctl/IPConn":
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasSuffix(action, `#GetExternalIPAddress"`):
+			_, _ = w.Write([]byte("<s:Envelope><s:Body><u:GetExternalIPAddressResponse><NewExternalIPAddress>203.0.113.1</NewExternalIPAddress></u:GetExternalIPAddressResponse></s:Body></s:Envelope>"))
+		case strings.HasSuffix(action, `#AddPortMapping"`):
+			if value("NewLeaseDuration") != "0" {
+				fault(upnpOnlyPermanentLeases, "OnlyPermanentLeasesSupported")
+				return
+			}
+			mappings[value("NewExternalPort")+"/"+value("NewProtocol")] = value("NewInternalClient") + ":" + value("NewInternalPort")
+		case strings.HasSuffix(action, `#DeletePortMapping"`):
+			key := value("NewExternalPort") + "/" + value("NewProtocol")
+			if _, exists := mappings[key]; !exists {
+				fault("714", "NoSuchEntryInArray")
+				return
+			}
+			delete(mappings, key)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestUPNPGateway(t *testing.T) {
+	mappings := map[string]string{}
+	server := fakeIGD(t, mappings)
+
+	c, err := newUPNP(server.URL+"/rootDesc.xml", "")
+	if err != nil {
+		t.Fatalf("newUPNP() error = %v", err)
+	}
+	if c.Gateway() != "127.0.0.1" || c.ExternalIP() != "203.0.113.1" {
+		t.Errorf("newUPNP() gateway = %s, external address = %s, want 127.0.0.1 and 203.0.113.1", c.Gateway(), c.ExternalIP())
+	}
+
+	// The gateway only has permanent leases, the mapping is added again without one
+	lease, err := c.AddExternalMapping("192.168.0.10", 443, 8443, "TCP", time.Hour, "web")
+	if err != nil {
+		t.Fatalf("AddExternalMapping() error = %v", err)
+	}
+	if lease != 0 {
+		t.Errorf("AddExternalMapping() = %s, want a permanent mapping", lease)
+	}
+	if got := mappings["8443/TCP"]; got != "192.168.0.10:443" {
+		t.Errorf("the gateway maps port 8443 to [%s], want 192.168.0.10:443", got)
+	}
+
+	if err := c.DeleteExternalMapping("192.168.0.10", 443, 8443, "TCP"); err != nil {
+		t.Fatalf("DeleteExternalMapping() error = %v", err)
+	}
+	if len(mappings) != 0 {
+		t.Errorf("the gateway still has the mappings %v", mappings)
+	}
+	if err := c.DeleteExternalMapping("192.168.0.10", 443, 8443, "TCP"); err == nil || !strings.Contains(err.Error(), "NoSuchEntryInArray") {
+		t.Errorf("DeleteExternalMapping() error = %v, want the error of the gateway", err)
+	}
+}
+
+func TestFindServicesURLBase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(strings.Replace(testDescription, "<device>", "<URLBase>http://192.168.0.1:5000/</URLBase><device>", 1)))
+	}))
+	defer server.Close()
+
+	services, err := findServices(newHTTPClient(), server.URL+"/rootDesc.xml")
+	if err != nil {
+		t.Fatalf("findServices() error = %v", err)
+	}
+	if got, want := services[firewallServiceType], "http://192.168.0.1:5000/ctl/IP6FCtl"; got != want {
+		t.Errorf("findServices() control URL = %s, want %s relative to the base URL", got, want)
+	}
+	if got, want := services["urn:schemas-upnp-org:service:WANIPConnection:2"], "http://192.168.0.1:5000/ctl/IPConn"; got != want {
+		t.Errorf("findServices() control URL = %s, want %s relative to the base URL", got, want)
+	}
+}
@@ -0,0 +1,183 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/kube-vip/kube-vip/pkg/capabilities"
+	"github.com/kube-vip/kube-vip/pkg/k8s"
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+	"github.com/kube-vip/kube-vip/pkg/sysctl"
+	"github.com/kube-vip/kube-vip/pkg/utils"
+)
+
+// Check is a single preflight check
+type Check struct {
+	// Name describes what is being checked
+	Name string
+
+	// Remediation is a hint for how to resolve a failure
+	Remediation string
+
+	run func() error
+}
+
+// Result is the outcome of a preflight check
+type Result struct {
+	Check
+	Err error
+}
+
+// Passed returns true if the check succeeded
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+// Checks will build the list of preflight checks that are relevant to the configuration
+func Checks(c *kubevip.Config, kubeConfigPath string, inCluster bool) []Check {
+	checks := []Check{
+		capabilityCheck("NET_ADMIN"),
+		capabilityCheck("NET_RAW"),
+	}
+
+	if c.Interface != "" {
+		checks = append(checks, interfaceCheck(c.Interface))
+	}
+	if c.ServicesInterface != "" {
+		checks = append(checks, interfaceCheck(c.ServicesInterface))
+	}
+
+	if c.EnableLoadBalancer {
+		checks = append(checks, moduleCheck("ip_vs"), Check{
+			Name:        "net.ipv4.ip_forward is enabled",
+			Remediation: "run `sysctl -w net.ipv4.ip_forward=1`",
+			run:         func() error { return sysctlEquals("/proc/sys/net/ipv4/ip_forward", "1") },
+		})
+	}
+
+	if c.EnableWireguard {
+		checks = append(checks, moduleCheck("wireguard"))
+	}
+
+	if c.EnableARP {
+		checks = append(checks, Check{
+			Name:        "net.ipv4.conf.all.arp_ignore allows replies for the VIP",
+			Remediation: "run `sysctl -w net.ipv4.conf.all.arp_ignore=0` (or 1)",
+			run: func() error {
+				return sysctlAtMost("/proc/sys/net/ipv4/conf/all/arp_ignore", 1)
+			},
+		})
+	}
+
+	if c.LeaderElectionType != "etcd" {
+		checks = append(checks, Check{
+			Name:        "Kubernetes API is reachable",
+			Remediation: "check the kubeconfig (or incluster service account) and that the API server address is routable from this node",
+			run:         func() error { return apiReachable(kubeConfigPath, inCluster) },
+		})
+	}
+
+	return checks
+}
+
+// Run will run all of the checks and return their results
+func Run(checks []Check) []Result {
+	results := make([]Result, 0, len(checks))
+	for x := range checks {
+		results = append(results, Result{Check: checks[x], Err: checks[x].run()})
+	}
+	return results
+}
+
+func interfaceCheck(name string) Check {
+	return Check{
+		Name:        fmt.Sprintf("interface [%s] exists", name),
+		Remediation: "set --interface/--serviceInterface (vip_interface/vip_servicesinterface) to an interface listed by `ip link`",
+		run: func() error {
+			_, err := net.InterfaceByName(name)
+			return err
+		},
+	}
+}
+
+func moduleCheck(name string) Check {
+	return Check{
+		Name:        fmt.Sprintf("kernel module [%s] is loaded", name),
+		Remediation: fmt.Sprintf("run `modprobe %s` and add it to /etc/modules-load.d/", name),
+		run: func() error {
+			// A module that is built into the kernel will also be present in /sys/module
+			if !utils.FileExists("/sys/module/" + name) {
+				return fmt.Errorf("module [%s] not found in /sys/module", name)
+			}
+			return nil
+		},
+	}
+}
+
+func capabilityCheck(name string) Check {
+	return Check{
+		Name:        fmt.Sprintf("%s capability", name),
+		Remediation: fmt.Sprintf("add %s to the container securityContext capabilities", name),
+		run: func() error {
+			effective, err := capabilities.Effective()
+			if err != nil {
+				return err
+			}
+			missing, err := capabilities.Missing(effective, []string{name})
+			if err != nil {
+				return err
+			}
+			if len(missing) != 0 {
+				return fmt.Errorf("capability is not in the effective set [%016x]", effective)
+			}
+			return nil
+		},
+	}
+}
+
+func sysctlEquals(path, want string) error {
+	value, err := sysctl.ReadProcSys(path)
+	if err != nil {
+		return err
+	}
+	if value != want {
+		return fmt.Errorf("%s is [%s], expected [%s]", path, value, want)
+	}
+	return nil
+}
+
+func sysctlAtMost(path string, limit int) error {
+	value, err := sysctl.ReadProcSys(path)
+	if err != nil {
+		return err
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	if i > limit {
+		return fmt.Errorf("%s is [%d], expected %d or lower", path, i, limit)
+	}
+	return nil
+}
+
+func apiReachable(kubeConfigPath string, inCluster bool) error {
+	// The client will panic without a usable configuration, so check for one first
+	if inCluster && os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return fmt.Errorf("not running within a Kubernetes cluster")
+	}
+	if !inCluster && !utils.FileExists(kubeConfigPath) {
+		return fmt.Errorf("kubeconfig [%s] doesn't exist", kubeConfigPath)
+	}
+	clientset, err := k8s.NewClientset(kubeConfigPath, inCluster, "")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return clientset.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+}
@@ -0,0 +1,100 @@
+package preflight
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kube-vip/kube-vip/pkg/kubevip"
+)
+
+func TestChecks(t *testing.T) {
+	tests := []struct {
+		name string
+		c    *kubevip.Config
+		want []string
+	}{
+		{
+			name: "capabilities and the API",
+			c:    &kubevip.Config{},
+			want: []string{"NET_ADMIN capability", "NET_RAW capability", "Kubernetes API is reachable"},
+		},
+		{
+			name: "etcd leader election",
+			c:    &kubevip.Config{LeaderElectionType: "etcd"},
+			want: []string{"NET_ADMIN capability", "NET_RAW capability"},
+		},
+		{
+			name: "interfaces",
+			c:    &kubevip.Config{Interface: "eth0", ServicesInterface: "eth1", LeaderElectionType: "etcd"},
+			want: []string{"NET_ADMIN capability", "NET_RAW capability", "interface [eth0] exists", "interface [eth1] exists"},
+		},
+		{
+			name: "load balancer",
+			c:    &kubevip.Config{EnableLoadBalancer: true, LeaderElectionType: "etcd"},
+			want: []string{"NET_ADMIN capability", "NET_RAW capability", "kernel module [ip_vs] is loaded", "net.ipv4.ip_forward is enabled"},
+		},
+		{
+			name: "wireguard and arp",
+			c:    &kubevip.Config{EnableWireguard: true, EnableARP: true, LeaderElectionType: "etcd"},
+			want: []string{"NET_ADMIN capability", "NET_RAW capability", "kernel module [wireguard] is loaded", "net.ipv4.conf.all.arp_ignore allows replies for the VIP"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, check := range Checks(tt.c, "", false) {
+				if check.Remediation == "" {
+					t.Errorf("check [%s] has no remediation", check.Name)
+				}
+				names = append(names, check.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("Checks() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestSysctlChecks(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, value string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(value+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{name: "equal", err: sysctlEquals(write("ip_forward", "1"), "1")},
+		{name: "not equal", err: sysctlEquals(write("ip_forward_off", "0"), "1"), wantErr: true},
+		{name: "below the limit", err: sysctlAtMost(write("arp_ignore", "0"), 1)},
+		{name: "at the limit", err: sysctlAtMost(write("arp_ignore_one", "1"), 1)},
+		{name: "above the limit", err: sysctlAtMost(write("arp_ignore_two", "2"), 1), wantErr: true},
+		{name: "not a number", err: sysctlAtMost(write("arp_ignore_bad", "x"), 1), wantErr: true},
+		{name: "missing", err: sysctlEquals(filepath.Join(dir, "missing"), "1"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", tt.err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	results := Run([]Check{
+		interfaceCheck("lo"),
+		interfaceCheck("kube-vip-missing0"),
+	})
+	if len(results) != 2 || !results[0].Passed() || results[1].Passed() {
+		t.Errorf("Run() = %v, want the loopback interface to pass and the missing interface to fail", results)
+	}
+}
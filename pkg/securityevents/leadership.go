@@ -0,0 +1,94 @@
+package securityevents
+
+import (
+	"context"
+	"sync"
+)
+
+// LeaderObserver is told of the leaders of the leases that this node takes part in the election of
+type LeaderObserver interface {
+	// NewLeader is called when the leader of the lease changes, transition is false for the first leader observed
+	NewLeader(lease, leader string, transition bool)
+	// Stopped is called once this node no longer takes part in the election of the lease
+	Stopped(lease string)
+}
+
+var (
+	observerMutex sync.RWMutex
+	observer      LeaderObserver
+)
+
+// ObserveLeaders will tell the observer of the leaders of every lease that is tracked from now on
+func ObserveLeaders(o LeaderObserver) {
+	observerMutex.Lock()
+	defer observerMutex.Unlock()
+	observer = o
+}
+
+func leaderObserver() LeaderObserver {
+	observerMutex.RLock()
+	defer observerMutex.RUnlock()
+	return observer
+}
+
+// Leadership tracks a leader election, so that a lease that is taken by another node while this node still held it is
+// reported. Leadership that is given up by this node, by cancelling the election context, isn't an event.
+type Leadership struct {
+	mutex    sync.Mutex
+	ctx      context.Context
+	lease    string
+	identity string
+	leading  bool
+	// leader is the last leader that has been observed
+	leader   string
+	observer LeaderObserver
+}
+
+// NewLeadership tracks the lease for this identity, leadership that is taken by another node while it is still held here
+// is a security event. The context is the context of the leader election, the leader of the lease is no longer reported
+// once the context is cancelled.
+func NewLeadership(ctx context.Context, lease, identity string) *Leadership {
+	l := &Leadership{ctx: ctx, lease: lease, identity: identity, observer: leaderObserver()}
+	if l.observer != nil {
+		context.AfterFunc(ctx, func() {
+			l.mutex.Lock()
+			defer l.mutex.Unlock()
+			l.observer.Stopped(lease)
+		})
+	}
+	return l
+}
+
+// Started should be called when this node starts leading
+func (l *Leadership) Started() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.leading = true
+}
+
+// NewLeader should be called whenever a new leader is observed
+func (l *Leadership) NewLeader(identity string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if identity != l.leader && l.ctx.Err() == nil {
+		if l.observer != nil {
+			l.observer.NewLeader(l.lease, identity, l.leader != "")
+		}
+		l.leader = identity
+	}
+	if identity == l.identity {
+		l.leading = true
+		return
+	}
+	if !l.leading {
+		return
+	}
+	l.leading = false
+	if l.ctx.Err() != nil {
+		return
+	}
+	Emit(LeadershipTaken, "leadership was taken by another node before it was released", map[string]string{
+		"lease":  l.lease,
+		"leader": identity,
+	})
+}
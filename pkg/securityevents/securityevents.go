@@ -0,0 +1,169 @@
+// Package securityevents sends structured notifications of security relevant events to a webhook, e.g. so that they
+// can be collected by a SIEM
+package securityevents
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kube-vip/kube-vip/pkg/fips"
+)
+
+// Type is the type of a security event
+type Type string
+
+const (
+	// VIPConflict is sent when another MAC address claims a VIP that is held by this node
+	VIPConflict Type = "VIPConflict"
+	// BGPAuthFailure is sent when a BGP peer fails to authenticate
+	BGPAuthFailure Type = "BGPAuthFailure"
+	// ConfigChanged is sent when configuration that kube-vip is running with has been changed
+	ConfigChanged Type = "ConfigChanged"
+	// LeadershipTaken is sent when another node takes leadership that this node still held
+	LeadershipTaken Type = "LeadershipTaken"
+	// SplitBrain is sent when another node is also leading a lease that this node leads
+	SplitBrain Type = "SplitBrain"
+)
+
+// Event is the body that is posted to the webhook
+type Event struct {
+	Type    Type              `json:"type"`
+	Time    time.Time         `json:"time"`
+	Node    string            `json:"node"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+const (
+	// attempts is the number of times that an event is posted before it is dropped
+	attempts = 3
+	// flushTimeout is how long to wait for pending events before exiting
+	flushTimeout = 5 * time.Second
+)
+
+// Webhook posts events to a URL
+type Webhook struct {
+	url    string
+	node   string
+	client *http.Client
+
+	// pending are the events that are still being sent
+	pending sync.WaitGroup
+}
+
+// NewWebhook creates a webhook that posts events from the node to the URL
+func NewWebhook(url, node string) *Webhook {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	fips.RestrictTLS(transport.TLSClientConfig)
+
+	return &Webhook{
+		url:    url,
+		node:   node,
+		client: &http.Client{Timeout: 10 * time.Second, Transport: transport},
+	}
+}
+
+// Send will post the event in the background, failures are retried a few times before the event is dropped
+func (w *Webhook) Send(e Event) {
+	e.Node = w.node
+	w.pending.Add(1)
+	go func() {
+		defer w.pending.Done()
+		var err error
+		for attempt := 0; attempt < attempts; attempt++ {
+			if attempt != 0 {
+				time.Sleep(time.Duration(attempt) * time.Second)
+			}
+			if err = w.post(e); err == nil {
+				return
+			}
+		}
+		log.Errorf("(security) unable to send [%s] event to the webhook: %v", e.Type, err)
+	}()
+}
+
+func (w *Webhook) post(e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response [%s]", resp.Status)
+	}
+	return nil
+}
+
+// Flush waits for the pending events to be sent, up to the timeout
+func (w *Webhook) Flush(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		w.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+var (
+	mutex   sync.RWMutex
+	webhook *Webhook
+)
+
+// Configure will send every event to the webhook URL, an empty URL only logs the events
+func Configure(url, node string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if url == "" {
+		webhook = nil
+		return
+	}
+	webhook = NewWebhook(url, node)
+}
+
+// Emit will log a security event and send it to the webhook if one has been configured
+func Emit(t Type, message string, details map[string]string) {
+	log.Warnf("(security) [%s] %s %v", t, message, details)
+
+	mutex.RLock()
+	w := webhook
+	mutex.RUnlock()
+	if w != nil {
+		w.Send(Event{Type: t, Time: time.Now().UTC(), Message: message, Details: details})
+	}
+}
+
+// Flush waits for the pending events to be sent to the webhook, this should be called before exiting
+func Flush() {
+	mutex.RLock()
+	w := webhook
+	mutex.RUnlock()
+	if w != nil {
+		w.Flush(flushTimeout)
+	}
+}
+
+// BGPAuthFailed reports TCP MD5 authentication failures of BGP peers, it matches the callback of
+// bgp.Server.WatchAuthFailures
+func BGPAuthFailed(peers []string, failures uint64) {
+	Emit(BGPAuthFailure, "BGP peers are failing TCP MD5 authentication", map[string]string{
+		"peers":    strings.Join(peers, ","),
+		"failures": strconv.FormatUint(failures, 10),
+	})
+}
@@ -0,0 +1,159 @@
+package securityevents
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver records the events that are posted to it
+type receiver struct {
+	mutex  sync.Mutex
+	events []Event
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var e Event
+	if err := json.NewDecoder(req.Body).Decode(&e); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.mutex.Lock()
+	r.events = append(r.events, e)
+	r.mutex.Unlock()
+}
+
+func TestEmit(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	Configure(server.URL, "node-1")
+	defer Configure("", "")
+
+	Emit(ConfigChanged, "secret changed", map[string]string{"secret": "bgp"})
+	Flush()
+
+	if len(r.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(r.events))
+	}
+	e := r.events[0]
+	if e.Type != ConfigChanged || e.Node != "node-1" || e.Details["secret"] != "bgp" {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
+
+func TestLeadership(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	Configure(server.URL, "node-1")
+	defer Configure("", "")
+
+	tests := []struct {
+		name   string
+		steps  func(l *Leadership, cancel context.CancelFunc)
+		events int
+	}{
+		{
+			name: "another node was already leading",
+			steps: func(l *Leadership, cancel context.CancelFunc) {
+				l.NewLeader("node-2")
+			},
+		},
+		{
+			name: "leadership taken",
+			steps: func(l *Leadership, cancel context.CancelFunc) {
+				l.Started()
+				l.NewLeader("node-1")
+				l.NewLeader("node-2")
+			},
+			events: 1,
+		},
+		{
+			name: "leadership released",
+			steps: func(l *Leadership, cancel context.CancelFunc) {
+				l.Started()
+				cancel()
+				l.NewLeader("node-2")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.mutex.Lock()
+			r.events = nil
+			r.mutex.Unlock()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			tt.steps(NewLeadership(ctx, "plndr-cp-lock", "node-1"), cancel)
+			Flush()
+			if len(r.events) != tt.events {
+				t.Errorf("expected %d events, got %d", tt.events, len(r.events))
+			}
+		})
+	}
+}
+
+// recordingObserver records the leaders that it is told of
+type recordingObserver struct {
+	mutex       sync.Mutex
+	leaders     []string
+	transitions int
+	stopped     chan string
+}
+
+func (o *recordingObserver) NewLeader(_, leader string, transition bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.leaders = append(o.leaders, leader)
+	if transition {
+		o.transitions++
+	}
+}
+
+func (o *recordingObserver) Stopped(lease string) {
+	o.stopped <- lease
+}
+
+func TestLeaderObserver(t *testing.T) {
+	o := &recordingObserver{stopped: make(chan string, 1)}
+	ObserveLeaders(o)
+	defer ObserveLeaders(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l := NewLeadership(ctx, "kube-system/plndr-svcs-lock", "node-1")
+	l.NewLeader("node-2")
+	l.Started()
+	l.NewLeader("node-1")
+	l.NewLeader("node-1")
+
+	o.mutex.Lock()
+	if len(o.leaders) != 2 || o.leaders[0] != "node-2" || o.leaders[1] != "node-1" || o.transitions != 1 {
+		t.Errorf("expected node-2 then node-1 with 1 transition, got %v with %d", o.leaders, o.transitions)
+	}
+	o.mutex.Unlock()
+
+	// The observer is told once the election has stopped, and of no leaders after that
+	cancel()
+	select {
+	case lease := <-o.stopped:
+		if lease != "kube-system/plndr-svcs-lock" {
+			t.Errorf("expected the lease to be stopped, got %s", lease)
+		}
+	case <-time.After(time.Second):
+		t.Error("the observer wasn't told that the election stopped")
+	}
+	l.NewLeader("node-3")
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if len(o.leaders) != 2 {
+		t.Errorf("expected no leaders once the election stopped, got %v", o.leaders)
+	}
+}
@@ -0,0 +1,39 @@
+package servicepolicy
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SignatureAnnotation holds the signature of a policy, this is required when kube-vip has been given a public key
+const SignatureAnnotation = "kube-vip.io/signature"
+
+// SignaturePayload returns the content of a policy that is signed, the name is included so that the signature of one
+// policy can't be copied onto another. Metadata that the API server changes isn't part of the payload.
+func SignaturePayload(p *KubeVipServicePolicy) ([]byte, error) {
+	return json.Marshal(struct {
+		Name string `json:"name"`
+		Spec Spec   `json:"spec"`
+	}{
+		Name: p.Name,
+		Spec: p.Spec,
+	})
+}
+
+// Verifier checks the signature over a payload
+type Verifier interface {
+	Verify(content, signature []byte) error
+}
+
+// verify will check the signature annotation of a policy
+func verify(v Verifier, p *KubeVipServicePolicy) error {
+	signature, exists := p.Annotations[SignatureAnnotation]
+	if !exists {
+		return fmt.Errorf("no %s annotation", SignatureAnnotation)
+	}
+	payload, err := SignaturePayload(p)
+	if err != nil {
+		return err
+	}
+	return v.Verify(payload, []byte(signature))
+}
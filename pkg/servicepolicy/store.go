@@ -0,0 +1,145 @@
+package servicepolicy
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// policy is a KubeVipServicePolicy with its selector already parsed
+type policy struct {
+	name     string
+	spec     Spec
+	selector labels.Selector
+}
+
+// Store keeps the current set of policies and resolves the overrides for a service
+type Store struct {
+	mutex    sync.RWMutex
+	policies []policy
+
+	// verifier, when set, only allows policies with a valid signature
+	verifier Verifier
+}
+
+// NewStore creates an empty policy store
+func NewStore() *Store {
+	return &Store{}
+}
+
+// SetVerifier will require every policy to be signed, policies without a valid signature are ignored so that a
+// compromised API identity can't redirect services
+func (s *Store) SetVerifier(v Verifier) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.verifier = v
+}
+
+// Set will replace all of the policies in the store, policies that are invalid are skipped and returned as an error
+func (s *Store) Set(items []KubeVipServicePolicy) error {
+	s.mutex.RLock()
+	verifier := s.verifier
+	s.mutex.RUnlock()
+
+	var policies []policy
+	var invalid []string
+	for x := range items {
+		if verifier != nil {
+			if err := verify(verifier, &items[x]); err != nil {
+				invalid = append(invalid, fmt.Sprintf("%s: signature verification failed: %v", items[x].Name, err))
+				continue
+			}
+		}
+		// No selector selects every service
+		selector := labels.Everything()
+		if items[x].Spec.ServiceSelector != nil {
+			var err error
+			selector, err = metav1.LabelSelectorAsSelector(items[x].Spec.ServiceSelector)
+			if err != nil {
+				invalid = append(invalid, fmt.Sprintf("%s: %v", items[x].Name, err))
+				continue
+			}
+		}
+		policies = append(policies, policy{
+			name:     items[x].Name,
+			spec:     items[x].Spec,
+			selector: selector,
+		})
+	}
+
+	// Lowest priority first so that higher priorities are applied last, the name keeps the order stable
+	sort.SliceStable(policies, func(i, j int) bool {
+		if policies[i].spec.Priority != policies[j].spec.Priority {
+			return policies[i].spec.Priority < policies[j].spec.Priority
+		}
+		return policies[i].name < policies[j].name
+	})
+
+	s.mutex.Lock()
+	s.policies = policies
+	s.mutex.Unlock()
+
+	if len(invalid) != 0 {
+		return fmt.Errorf("invalid service policies %v", invalid)
+	}
+	return nil
+}
+
+// Resolve returns the merged overrides of every policy that selects the service, along with the names of those policies
+func (s *Store) Resolve(svc *v1.Service) (Overrides, []string) {
+	var o Overrides
+	var matched []string
+	if s == nil {
+		return o, matched
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for x := range s.policies {
+		p := &s.policies[x]
+		if len(p.spec.Namespaces) != 0 && !slices.Contains(p.spec.Namespaces, svc.Namespace) {
+			continue
+		}
+		if !p.selector.Matches(labels.Set(svc.Labels)) {
+			continue
+		}
+		matched = append(matched, p.name)
+		o.merge(&p.spec.Overrides)
+	}
+	return o, matched
+}
+
+// merge applies any values that are set in n on top of o
+func (o *Overrides) merge(n *Overrides) {
+	if n.Interface != "" {
+		o.Interface = n.Interface
+	}
+	if n.Engine != "" {
+		o.Engine = n.Engine
+	}
+	if n.VRF != "" {
+		o.VRF = n.VRF
+	}
+	if n.ArpBroadcastRate != 0 {
+		o.ArpBroadcastRate = n.ArpBroadcastRate
+	}
+	if n.BGP != nil {
+		bgp := &BGPOverrides{}
+		if o.BGP != nil {
+			*bgp = *o.BGP
+		}
+		if len(n.BGP.Communities) != 0 {
+			bgp.Communities = n.BGP.Communities
+		}
+		if n.BGP.LocalPref != 0 {
+			bgp.LocalPref = n.BGP.LocalPref
+		}
+		o.BGP = bgp
+	}
+}
@@ -0,0 +1,156 @@
+package servicepolicy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolve(t *testing.T) {
+	s := NewStore()
+	err := s.Set([]KubeVipServicePolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "defaults"},
+			Spec: Spec{
+				Overrides: Overrides{Interface: "eth1", ArpBroadcastRate: 1000},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge"},
+			Spec: Spec{
+				Priority:        10,
+				ServiceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "edge"}},
+				Overrides:       Overrides{Engine: "bgp", BGP: &BGPOverrides{Communities: []string{"65000:100"}}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+			Spec: Spec{
+				Priority:   20,
+				Namespaces: []string{"prod"},
+				Overrides:  Overrides{Interface: "bond0", VRF: "vrf-prod", BGP: &BGPOverrides{LocalPref: 200}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		svc     *v1.Service
+		want    Overrides
+		matched []string
+	}{
+		{
+			"defaults only",
+			&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}},
+			Overrides{Interface: "eth1", ArpBroadcastRate: 1000},
+			[]string{"defaults"},
+		},
+		{
+			"selected by labels",
+			&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default", Labels: map[string]string{"tier": "edge"}}},
+			Overrides{Interface: "eth1", Engine: "bgp", ArpBroadcastRate: 1000, BGP: &BGPOverrides{Communities: []string{"65000:100"}}},
+			[]string{"defaults", "edge"},
+		},
+		{
+			"higher priority wins",
+			&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "prod", Labels: map[string]string{"tier": "edge"}}},
+			Overrides{Interface: "bond0", Engine: "bgp", VRF: "vrf-prod", ArpBroadcastRate: 1000, BGP: &BGPOverrides{Communities: []string{"65000:100"}, LocalPref: 200}},
+			[]string{"defaults", "edge", "prod"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, matched := s.Resolve(tt.svc)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(matched, tt.matched) {
+				t.Errorf("Resolve() matched = %v, want %v", matched, tt.matched)
+			}
+		})
+	}
+}
+
+func TestSetInvalidSelector(t *testing.T) {
+	s := NewStore()
+	err := s.Set([]KubeVipServicePolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+			Spec: Spec{
+				ServiceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Bogus"}}},
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("Set() expected an error for an invalid selector")
+	}
+	if _, matched := s.Resolve(&v1.Service{}); len(matched) != 0 {
+		t.Errorf("Resolve() matched an invalid policy %v", matched)
+	}
+}
+
+// prefixVerifier accepts a signature that is "signed:" followed by the payload
+type prefixVerifier struct{}
+
+func (prefixVerifier) Verify(content, signature []byte) error {
+	if string(signature) != "signed:"+string(content) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+func TestSetVerifier(t *testing.T) {
+	signed := KubeVipServicePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "signed"},
+		Spec:       Spec{Overrides: Overrides{Interface: "eth1"}},
+	}
+	payload, err := SignaturePayload(&signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed.Annotations = map[string]string{SignatureAnnotation: "signed:" + string(payload)}
+
+	// The signature of one policy can't be used for another
+	copied := signed
+	copied.ObjectMeta = metav1.ObjectMeta{Name: "copied", Annotations: signed.Annotations}
+	unsigned := KubeVipServicePolicy{ObjectMeta: metav1.ObjectMeta{Name: "unsigned"}, Spec: Spec{Overrides: Overrides{Interface: "eth2"}}}
+
+	s := NewStore()
+	s.SetVerifier(prefixVerifier{})
+	if err := s.Set([]KubeVipServicePolicy{signed, copied, unsigned}); err == nil {
+		t.Errorf("Set() expected an error for the policies without a valid signature")
+	}
+
+	_, matched := s.Resolve(&v1.Service{})
+	if !reflect.DeepEqual(matched, []string{"signed"}) {
+		t.Errorf("Resolve() matched = %v, want only the signed policy", matched)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	policy := func(name, version string) KubeVipServicePolicy {
+		return KubeVipServicePolicy{ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: version}}
+	}
+
+	versions, changed := changes(nil, []KubeVipServicePolicy{policy("a", "1"), policy("b", "1")})
+	if len(changed) != 2 {
+		t.Errorf("changes() = %v, want every policy to be new", changed)
+	}
+
+	_, changed = changes(versions, []KubeVipServicePolicy{policy("a", "1"), policy("c", "1")})
+	if strings.Join(changed, ",") != "b,c" {
+		t.Errorf("changes() = %v, want [b c]", changed)
+	}
+
+	_, changed = changes(versions, []KubeVipServicePolicy{policy("a", "2"), policy("b", "1")})
+	if strings.Join(changed, ",") != "a" {
+		t.Errorf("changes() = %v, want [a]", changed)
+	}
+}
@@ -0,0 +1,132 @@
+package servicepolicy
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Group is the API group of the kube-vip custom resources
+	Group = "kube-vip.io"
+	// Version is the API version of the KubeVipServicePolicy resource
+	Version = "v1alpha1"
+	// Kind is the kind of the KubeVipServicePolicy resource
+	Kind = "KubeVipServicePolicy"
+	// Resource is the plural resource name used in the API path
+	Resource = "kubevipservicepolicies"
+)
+
+// KubeVipServicePolicy applies a set of overrides to all of the services that it selects, so that fleets of
+// services can share settings without annotating each one of them
+type KubeVipServicePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec Spec `json:"spec"`
+}
+
+// KubeVipServicePolicyList is a list of KubeVipServicePolicy resources
+type KubeVipServicePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []KubeVipServicePolicy `json:"items"`
+}
+
+// Spec defines which services a policy selects and the overrides that are applied to them
+type Spec struct {
+	// Priority, when several policies select a service they are applied from the lowest priority to the highest
+	Priority int `json:"priority,omitempty"`
+
+	// Namespaces limits the policy to services in these namespaces, all namespaces if empty
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// ServiceSelector selects services by their labels, all services if empty
+	ServiceSelector *metav1.LabelSelector `json:"serviceSelector,omitempty"`
+
+	// Overrides are applied to every selected service
+	Overrides Overrides `json:"overrides"`
+}
+
+// Overrides are the per-service settings that a policy can change, an empty value leaves the setting as it is
+type Overrides struct {
+	// Interface is the interface that the service VIPs are added to
+	Interface string `json:"interface,omitempty"`
+
+	// Engine is the engine (arp, bgp, wireguard, table) that advertises the service
+	Engine string `json:"engine,omitempty"`
+
+	// VRF is the VRF that the routes of the service VIPs are added to, in routing table mode
+	VRF string `json:"vrf,omitempty"`
+
+	// ArpBroadcastRate is how often (in milliseconds) gratuitous ARP is sent for the service VIPs
+	ArpBroadcastRate int64 `json:"arpBroadcastRate,omitempty"`
+
+	// BGP attributes that are added to the advertised routes
+	BGP *BGPOverrides `json:"bgp,omitempty"`
+}
+
+// BGPOverrides are the path attributes added to the routes of the selected services
+type BGPOverrides struct {
+	// Communities in the format "65000:100"
+	Communities []string `json:"communities,omitempty"`
+
+	// LocalPref is the local preference of the routes (iBGP only)
+	LocalPref uint32 `json:"localPref,omitempty"`
+}
+
+// CRD is the CustomResourceDefinition for the KubeVipServicePolicy resource
+const CRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: kubevipservicepolicies.kube-vip.io
+spec:
+  group: kube-vip.io
+  names:
+    kind: KubeVipServicePolicy
+    listKind: KubeVipServicePolicyList
+    plural: kubevipservicepolicies
+    singular: kubevipservicepolicy
+  scope: Cluster
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              priority:
+                type: integer
+              namespaces:
+                type: array
+                items:
+                  type: string
+              serviceSelector:
+                type: object
+                x-kubernetes-preserve-unknown-fields: true
+              overrides:
+                type: object
+                properties:
+                  interface:
+                    type: string
+                  engine:
+                    type: string
+                    enum: ["arp", "bgp", "wireguard", "table"]
+                  vrf:
+                    type: string
+                  arpBroadcastRate:
+                    type: integer
+                    minimum: 500
+                  bgp:
+                    type: object
+                    properties:
+                      communities:
+                        type: array
+                        items:
+                          type: string
+                      localPref:
+                        type: integer
+`
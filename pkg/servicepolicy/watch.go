@@ -0,0 +1,89 @@
+package servicepolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kube-vip/kube-vip/pkg/securityevents"
+)
+
+// List will retrieve all of the KubeVipServicePolicy resources from the cluster
+func List(ctx context.Context, clientSet *kubernetes.Clientset) ([]KubeVipServicePolicy, error) {
+	b, err := clientSet.CoreV1().RESTClient().Get().
+		AbsPath("/apis", Group, Version, Resource).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list KubeVipServicePolicyList
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, fmt.Errorf("unable to parse service policies: %v", err)
+	}
+	return list.Items, nil
+}
+
+// changes returns the resource versions of the policies along with the names of the policies that have been created,
+// modified or deleted since the previous resource versions
+func changes(previous map[string]string, items []KubeVipServicePolicy) (map[string]string, []string) {
+	current := map[string]string{}
+	var changed []string
+	for x := range items {
+		current[items[x].Name] = items[x].ResourceVersion
+		if version, exists := previous[items[x].Name]; !exists || version != items[x].ResourceVersion {
+			changed = append(changed, items[x].Name)
+		}
+	}
+	for name := range previous {
+		if _, exists := current[name]; !exists {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return current, changed
+}
+
+// Poll will refresh the store with the policies from the cluster every interval, until the context is cancelled
+func Poll(ctx context.Context, clientSet *kubernetes.Clientset, store *Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// versions are nil until the policies have been loaded once, so that the initial policies aren't a change
+	var versions map[string]string
+	for {
+		items, err := List(ctx, clientSet)
+		switch {
+		case errors.IsNotFound(err):
+			log.Warnf("(svc policy) the %s resource isn't installed, no service policies will be applied", Kind)
+		case err != nil:
+			log.Errorf("(svc policy) unable to list service policies: %v", err)
+		default:
+			var changed []string
+			loaded := versions != nil
+			versions, changed = changes(versions, items)
+			if loaded && len(changed) != 0 {
+				securityevents.Emit(securityevents.ConfigChanged, "service policies have been modified", map[string]string{
+					"policies": strings.Join(changed, ","),
+				})
+			}
+			if err := store.Set(items); err != nil {
+				log.Error(err)
+			}
+			log.Debugf("(svc policy) loaded [%d] service policies", len(items))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
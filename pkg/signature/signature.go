@@ -0,0 +1,83 @@
+// Package signature verifies detached signatures over kube-vip configuration, the signatures are compatible with
+// `cosign sign-blob --key` (a base64 encoded signature of the SHA256 digest of the content)
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Verifier verifies signatures with a public key
+type Verifier struct {
+	key crypto.PublicKey
+}
+
+// LoadVerifier will load a PEM encoded (ECDSA, Ed25519 or RSA) public key, e.g. cosign.pub
+func LoadVerifier(path string) (*Verifier, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded public key found in [%s]", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse public key [%s]: %v", path, err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey, *rsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T in [%s]", key, path)
+	}
+	return &Verifier{key: key}, nil
+}
+
+// Verify will check the signature of the content, the signature may be base64 encoded (as cosign writes it) or raw
+func (v *Verifier) Verify(content, signature []byte) error {
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil {
+		signature = decoded
+	}
+	digest := sha256.Sum256(content)
+
+	var valid bool
+	switch key := v.key.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, content, signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil ||
+			rsa.VerifyPSS(key, crypto.SHA256, digest[:], signature, nil) == nil
+	}
+	if !valid {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// VerifyFile will check the detached signature of a file
+func (v *Verifier) VerifyFile(path, signaturePath string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return fmt.Errorf("unable to read the signature of [%s]: %v", path, err)
+	}
+	if err := v.Verify(content, signature); err != nil {
+		return fmt.Errorf("[%s] failed verification with signature [%s]: %v", path, signaturePath, err)
+	}
+	return nil
+}
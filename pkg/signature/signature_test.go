@@ -0,0 +1,51 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "cosign.pub")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := LoadVerifier(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("apiVersion: kube-vip.io/v1alpha1\nkind: Config\n")
+	digest := sha256.Sum256(content)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifier.Verify(content, []byte(base64.StdEncoding.EncodeToString(signature)+"\n")); err != nil {
+		t.Errorf("Verify() base64 signature error = %v", err)
+	}
+	if err := verifier.Verify(content, signature); err != nil {
+		t.Errorf("Verify() raw signature error = %v", err)
+	}
+	if err := verifier.Verify([]byte("vip: 10.0.0.1\n"), signature); err == nil {
+		t.Errorf("Verify() expected an error for modified content")
+	}
+}
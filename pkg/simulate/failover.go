@@ -0,0 +1,324 @@
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// simulationIdentity is written as the lease holder to force the current leader to step down
+const simulationIdentity = "kube-vip-failover-simulation"
+
+// FailoverConfig defines the lease that will be handed off and the address that is probed
+type FailoverConfig struct {
+	// LeaseName and LeaseNamespace identify the lease that is held by the current leader
+	LeaseName      string
+	LeaseNamespace string
+
+	// Address is the host:port that is probed to measure downtime
+	Address string
+
+	// ProbeInterval is how often the address is probed
+	ProbeInterval time.Duration
+
+	// BGP withdraws the routes of the address by holding the lease, and releases it after Hold for them to be announced
+	// by a new leader, instead of leaving the lease to expire
+	BGP bool
+
+	// Timeout is how long to wait for a new leader and for the address to recover
+	Timeout time.Duration
+
+	// Hold is how long the routes of the address are kept withdrawn, once the address has stopped answering, before
+	// they are announced again (BGP cycle only)
+	Hold time.Duration
+
+	// dial connects to the address, it is replaced in the tests
+	dial func(address string, timeout time.Duration) error
+}
+
+// FailoverResult contains the measurements taken during a failover
+type FailoverResult struct {
+	PreviousLeader string
+	NewLeader      string
+
+	// LeaderElected is the time taken for a new leader to take the lease
+	LeaderElected time.Duration
+
+	// Downtime is the longest continuous period that the address could not be reached
+	Downtime time.Duration
+
+	// Withdrawn is the time taken for the address to stop answering once the lease has been taken, which is when the
+	// routes of the address have been withdrawn (BGP cycle only)
+	Withdrawn time.Duration
+
+	// Announced is the time taken for the address to answer again once the lease has been released, which is when
+	// the routes of the address have been announced by the new leader (BGP cycle only)
+	Announced time.Duration
+
+	// FailedProbes is the total number of probes that failed
+	FailedProbes int
+	TotalProbes  int
+}
+
+// prober keeps track of the longest run of failed connections to an address
+type prober struct {
+	dial func(address string, timeout time.Duration) error
+
+	mutex       sync.Mutex
+	failedSince time.Time
+	downtime    time.Duration
+	failed      int
+	total       int
+	lastSuccess time.Time
+	// lastFailure is the start of the last run of failed connections, and recovered is when it ended
+	lastFailure time.Time
+	recovered   time.Time
+}
+
+// newProber creates a prober that connects to the address over TCP, unless the dial function is replaced
+func newProber(dial func(address string, timeout time.Duration) error) *prober {
+	if dial == nil {
+		dial = dialTCP
+	}
+	return &prober{dial: dial}
+}
+
+func dialTCP(address string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (p *prober) probe(address string, timeout time.Duration) {
+	err := p.dial(address, timeout)
+	now := time.Now()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.total++
+	if err != nil {
+		p.failed++
+		if p.failedSince.IsZero() {
+			p.failedSince = now
+			p.lastFailure = now
+		}
+		return
+	}
+	if !p.failedSince.IsZero() {
+		if d := now.Sub(p.failedSince); d > p.downtime {
+			p.downtime = d
+		}
+		p.failedSince = time.Time{}
+		p.recovered = now
+	}
+	p.lastSuccess = now
+}
+
+// unreachableSince returns when the address stopped answering, if it has stopped answering after a point in time
+func (p *prober) unreachableSince(t time.Time) (time.Time, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.lastFailure, p.lastFailure.After(t)
+}
+
+// recoveredSince returns when the address answered again, if it has answered again after a point in time
+func (p *prober) recoveredSince(t time.Time) (time.Time, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.recovered, p.recovered.After(t)
+}
+
+// run probes the address until the context is cancelled
+func (p *prober) run(ctx context.Context, address string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.probe(address, interval)
+		}
+	}
+}
+
+// results adds the measurements of the probes to the result
+func (p *prober) results(result *FailoverResult) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	result.Downtime = p.downtime
+	result.FailedProbes = p.failed
+	result.TotalProbes = p.total
+}
+
+// reachableSince returns true if the address has been reached after a point in time
+func (p *prober) reachableSince(t time.Time) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.lastSuccess.After(t)
+}
+
+// Failover will force the holder of a lease to step down, and measure how long it takes for a new leader to be elected
+// and for the address to become reachable again. With the BGP cycle the lease is held until the routes of the address
+// have been withdrawn, and then released so that a new leader announces them again.
+func Failover(ctx context.Context, clientSet kubernetes.Interface, c *FailoverConfig) (*FailoverResult, error) {
+	leases := clientSet.CoordinationV1().Leases(c.LeaseNamespace)
+	lease, err := leases.Get(ctx, c.LeaseName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to find lease [%s/%s]: %v", c.LeaseNamespace, c.LeaseName, err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return nil, fmt.Errorf("lease [%s/%s] has no current holder", c.LeaseNamespace, c.LeaseName)
+	}
+	result := &FailoverResult{PreviousLeader: *lease.Spec.HolderIdentity}
+
+	// Ensure the address is reachable before we begin
+	p := newProber(c.dial)
+	before := time.Now()
+	p.probe(c.Address, c.ProbeInterval)
+	if !p.reachableSince(before) {
+		return nil, fmt.Errorf("address [%s] isn't reachable before the failover, unable to measure downtime", c.Address)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	go p.run(ctx, c.Address, c.ProbeInterval)
+
+	// Take the lease from the current leader, it will fail to renew and step down. Once this lease expires the
+	// remaining candidates will elect a new leader.
+	log.Infof("taking lease [%s/%s] from [%s]", c.LeaseNamespace, c.LeaseName, result.PreviousLeader)
+	start := time.Now()
+	if err = setHolder(ctx, leases, c.LeaseName, simulationIdentity); err != nil {
+		return nil, fmt.Errorf("unable to take lease [%s/%s]: %v", c.LeaseNamespace, c.LeaseName, err)
+	}
+
+	if c.BGP {
+		if err = withdrawAndRelease(ctx, leases, c, p, start, result); err != nil {
+			return result, err
+		}
+	}
+	released := time.Now()
+
+	// Wait for a new leader
+	for result.NewLeader == "" {
+		select {
+		case <-ctx.Done():
+			return result, fmt.Errorf("timed out waiting for a new leader of [%s/%s]", c.LeaseNamespace, c.LeaseName)
+		case <-time.After(c.ProbeInterval):
+		}
+		lease, err := leases.Get(ctx, c.LeaseName, metav1.GetOptions{})
+		if err != nil {
+			log.Warnf("unable to get lease [%s/%s]: %v", c.LeaseNamespace, c.LeaseName, err)
+			continue
+		}
+		if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != simulationIdentity && *lease.Spec.HolderIdentity != "" {
+			result.NewLeader = *lease.Spec.HolderIdentity
+			result.LeaderElected = time.Since(start)
+		}
+	}
+	log.Infof("new leader [%s] elected after [%s]", result.NewLeader, result.LeaderElected)
+
+	// Wait for the address to recover, a successful probe must happen after the new leader has been elected
+	elected := time.Now()
+	for !p.reachableSince(elected) {
+		select {
+		case <-ctx.Done():
+			return result, fmt.Errorf("timed out waiting for [%s] to become reachable", c.Address)
+		case <-time.After(c.ProbeInterval):
+		}
+	}
+
+	if c.BGP {
+		// The routes are announced when the address answers again after the lease was released
+		recovered, ok := p.recoveredSince(released)
+		if !ok {
+			return result, fmt.Errorf("address [%s] answered again before the lease was released", c.Address)
+		}
+		result.Announced = recovered.Sub(released)
+		log.Infof("routes of [%s] announced after [%s]", c.Address, result.Announced)
+	}
+
+	p.results(result)
+	return result, nil
+}
+
+// withdrawAndRelease keeps renewing the lease so that no candidate can lead and the routes of the address are
+// withdrawn, it then holds them withdrawn before releasing the lease to the remaining candidates
+func withdrawAndRelease(ctx context.Context, leases coordinationv1.LeaseInterface, c *FailoverConfig, p *prober, start time.Time, result *FailoverResult) error {
+	renewCtx, stopRenewing := context.WithCancel(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		for {
+			select {
+			case <-renewCtx.Done():
+				return
+			case <-time.After(c.ProbeInterval):
+			}
+			if err := setHolder(renewCtx, leases, c.LeaseName, simulationIdentity); err != nil && renewCtx.Err() == nil {
+				log.Warnf("unable to renew lease [%s/%s]: %v", c.LeaseNamespace, c.LeaseName, err)
+			}
+		}
+	}()
+	defer func() {
+		stopRenewing()
+		<-renewed
+	}()
+
+	// Wait for the routes to be withdrawn, the address stops answering once the leader has stepped down
+	for {
+		if withdrawn, ok := p.unreachableSince(start); ok {
+			result.Withdrawn = withdrawn.Sub(start)
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for the routes of [%s] to be withdrawn", c.Address)
+		case <-time.After(c.ProbeInterval):
+		}
+	}
+	log.Infof("routes of [%s] withdrawn after [%s], holding them for [%s]", c.Address, result.Withdrawn, c.Hold)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("timed out holding the routes of [%s]", c.Address)
+	case <-time.After(c.Hold):
+	}
+
+	// Release the lease, the candidates acquire a lease without a holder without waiting for it to expire
+	stopRenewing()
+	<-renewed
+	log.Infof("releasing lease [%s/%s]", c.LeaseNamespace, c.LeaseName)
+	if err := setHolder(ctx, leases, c.LeaseName, ""); err != nil {
+		return fmt.Errorf("unable to release lease [%s/%s]: %v", c.LeaseNamespace, c.LeaseName, err)
+	}
+	return nil
+}
+
+// setHolder writes the holder of a lease and renews it
+func setHolder(ctx context.Context, leases coordinationv1.LeaseInterface, name, holder string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		now := metav1.NewMicroTime(time.Now())
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != holder {
+			lease.Spec.AcquireTime = &now
+		}
+		lease.Spec.HolderIdentity = &holder
+		lease.Spec.RenewTime = &now
+		_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+		return err
+	})
+}
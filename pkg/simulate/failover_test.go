@@ -0,0 +1,161 @@
+package simulate
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	leaseDuration = 60 * time.Millisecond
+	announceDelay = 30 * time.Millisecond
+)
+
+// candidates acts as the remaining kube-vip candidates of a lease, the address answers while they hold it
+type candidates struct {
+	mutex    sync.Mutex
+	up       bool
+	upAt     time.Time
+	withdraw bool
+}
+
+func (k *candidates) dial(string, time.Duration) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if k.up || (!k.upAt.IsZero() && time.Now().After(k.upAt)) {
+		return nil
+	}
+	return errors.New("connection refused")
+}
+
+// run watches the lease, the leader steps down (and its routes are withdrawn) once the lease is taken, and a new
+// leader is elected when the lease is released or has expired
+func (k *candidates) run(ctx context.Context, t *testing.T, clientSet kubernetes.Interface) {
+	leases := clientSet.CoordinationV1().Leases("kube-system")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Millisecond):
+		}
+		lease, err := leases.Get(ctx, "plndr-svcs-lock", metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		holder := *lease.Spec.HolderIdentity
+		expired := time.Since(lease.Spec.RenewTime.Time) > leaseDuration
+		if holder != simulationIdentity && holder != "" {
+			continue
+		}
+
+		k.mutex.Lock()
+		if k.withdraw {
+			k.up = false
+		}
+		if holder == "" || expired {
+			k.upAt = time.Now().Add(announceDelay)
+			k.mutex.Unlock()
+			if err := setHolder(ctx, leases, "plndr-svcs-lock", "node-b"); err != nil && ctx.Err() == nil {
+				t.Errorf("unable to elect a new leader: %v", err)
+			}
+			continue
+		}
+		k.mutex.Unlock()
+	}
+}
+
+func newLease(holder string) *coordinationv1.Lease {
+	now := metav1.NewMicroTime(time.Now())
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "plndr-svcs-lock", Namespace: "kube-system"},
+		Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holder, RenewTime: &now},
+	}
+}
+
+func TestFailover(t *testing.T) {
+	tests := []struct {
+		name     string
+		holder   string
+		bgp      bool
+		withdraw bool
+		wantErr  bool
+	}{
+		{name: "lease takeover", holder: "node-a", withdraw: true},
+		{name: "bgp cycle", holder: "node-a", bgp: true, withdraw: true},
+		{name: "routes never withdrawn", holder: "node-a", bgp: true, wantErr: true},
+		{name: "no leader", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			clientSet := fake.NewSimpleClientset(newLease(tt.holder))
+			k := &candidates{up: true, withdraw: tt.withdraw}
+			if tt.holder != "" {
+				go k.run(ctx, t, clientSet)
+			}
+
+			c := &FailoverConfig{
+				LeaseName:      "plndr-svcs-lock",
+				LeaseNamespace: "kube-system",
+				Address:        "192.168.0.10:80",
+				ProbeInterval:  5 * time.Millisecond,
+				Timeout:        time.Second,
+				BGP:            tt.bgp,
+				Hold:           50 * time.Millisecond,
+				dial:           k.dial,
+			}
+			result, err := Failover(ctx, clientSet, c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Failover() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if result.PreviousLeader != "node-a" || result.NewLeader != "node-b" {
+				t.Errorf("leaders = %q then %q, want node-a then node-b", result.PreviousLeader, result.NewLeader)
+			}
+			if result.FailedProbes == 0 || result.FailedProbes >= result.TotalProbes {
+				t.Errorf("failed probes = %d/%d, want some of them to fail", result.FailedProbes, result.TotalProbes)
+			}
+			if result.Downtime < announceDelay {
+				t.Errorf("downtime = %s, want at least the time taken to announce the address [%s]", result.Downtime, announceDelay)
+			}
+
+			if !tt.bgp {
+				// The new leader is only elected once the taken lease has expired
+				if result.LeaderElected < leaseDuration {
+					t.Errorf("leader elected after %s, want at least the lease duration [%s]", result.LeaderElected, leaseDuration)
+				}
+				if result.Withdrawn != 0 || result.Announced != 0 {
+					t.Errorf("withdrawn = %s and announced = %s, want them only for the bgp cycle", result.Withdrawn, result.Announced)
+				}
+				return
+			}
+
+			// The routes are withdrawn first, held, and only then announced by the new leader which is elected without
+			// waiting for the lease to expire
+			if result.Withdrawn <= 0 || result.Withdrawn >= c.Hold {
+				t.Errorf("withdrawn after %s, want it before the routes were held for %s", result.Withdrawn, c.Hold)
+			}
+			if result.LeaderElected < result.Withdrawn+c.Hold {
+				t.Errorf("leader elected after %s, want it after the routes were withdrawn (%s) and held (%s)", result.LeaderElected, result.Withdrawn, c.Hold)
+			}
+			if result.Announced < announceDelay || result.Announced > result.Downtime {
+				t.Errorf("announced after %s, want between %s and the downtime [%s]", result.Announced, announceDelay, result.Downtime)
+			}
+			if result.Downtime < c.Hold+result.Announced {
+				t.Errorf("downtime = %s, want at least the hold [%s] and the announcement [%s]", result.Downtime, c.Hold, result.Announced)
+			}
+		})
+	}
+}
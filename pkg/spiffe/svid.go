@@ -0,0 +1,99 @@
+// Package spiffe fetches X.509 identities (SVIDs) from a SPIFFE Workload API socket, such as the one of a SPIRE
+// agent, so that kube-vip can authenticate with short lived certificates instead of static credentials.
+package spiffe
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// SVID is an X.509 SPIFFE identity, the certificate chain and key of the workload along with the trust bundle that
+// peers are verified with
+type SVID struct {
+	// ID is the SPIFFE ID of the workload, e.g. spiffe://example.org/kube-vip/node-1
+	ID string
+	// Certificates is the chain of the workload certificate, the leaf is first
+	Certificates []*x509.Certificate
+	// PrivateKey is the key of the leaf certificate
+	PrivateKey crypto.Signer
+	// Bundle is the CA certificates of the trust domain
+	Bundle []*x509.Certificate
+}
+
+// TLSCertificate returns the SVID as a certificate for TLS connections
+func (s *SVID) TLSCertificate() *tls.Certificate {
+	cert := &tls.Certificate{PrivateKey: s.PrivateKey, Leaf: s.Certificates[0]}
+	for _, c := range s.Certificates {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	return cert
+}
+
+// PEM returns the certificate chain, the key and the bundle of the SVID as PEM
+func (s *SVID) PEM() ([]byte, []byte, []byte, error) {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(s.PrivateKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return encodeCertificates(s.Certificates),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+		encodeCertificates(s.Bundle), nil
+}
+
+func encodeCertificates(certs []*x509.Certificate) []byte {
+	var b bytes.Buffer
+	for _, c := range certs {
+		_ = pem.Encode(&b, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+	}
+	return b.Bytes()
+}
+
+// ID returns the SPIFFE ID of a certificate, it is the only URI SAN with the spiffe scheme
+func ID(cert *x509.Certificate) string {
+	for _, u := range cert.URIs {
+		if u.Scheme == "spiffe" {
+			return u.String()
+		}
+	}
+	return ""
+}
+
+// ValidateTemplate checks that a template of per node SPIFFE IDs, e.g. spiffe://example.org/kube-vip/{node}, is a
+// SPIFFE ID with a single {node} placeholder
+func ValidateTemplate(template string) error {
+	if strings.Count(template, NodePlaceholder) != 1 {
+		return fmt.Errorf("SPIFFE ID template [%s] needs a single %s placeholder", template, NodePlaceholder)
+	}
+	u, err := url.Parse(strings.Replace(template, NodePlaceholder, "node", 1))
+	if err != nil || u.Scheme != "spiffe" || u.Host == "" {
+		return fmt.Errorf("SPIFFE ID template [%s] isn't a spiffe:// ID", template)
+	}
+	return nil
+}
+
+// NodePlaceholder is replaced by the node name in templates of SPIFFE IDs
+const NodePlaceholder = "{node}"
+
+// NodeID returns the SPIFFE ID of a node from the template
+func NodeID(template, node string) string {
+	return strings.Replace(template, NodePlaceholder, node, 1)
+}
+
+// Node returns the node name of a SPIFFE ID that matches the template, or an empty string if it doesn't match
+func Node(template, id string) string {
+	prefix, suffix, _ := strings.Cut(template, NodePlaceholder)
+	if len(id) <= len(prefix)+len(suffix) || !strings.HasPrefix(id, prefix) || !strings.HasSuffix(id, suffix) {
+		return ""
+	}
+	node := id[len(prefix) : len(id)-len(suffix)]
+	if strings.Contains(node, "/") {
+		return ""
+	}
+	return node
+}
@@ -0,0 +1,156 @@
+package spiffe
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+)
+
+// retryInterval is how long to wait before connecting to the Workload API again
+const retryInterval = 5 * time.Second
+
+// Source keeps the current SVID of the workload from the Workload API
+type Source struct {
+	socket string
+
+	mutex    sync.RWMutex
+	svid     *SVID
+	ready    chan struct{}
+	onUpdate []func(*SVID)
+
+	// callbacks keeps the update functions from being called out of order
+	callbacks sync.Mutex
+}
+
+// NewSource creates a source for the Workload API socket, e.g. unix:///run/spire/sockets/agent.sock. SVIDs aren't
+// fetched until the source is run.
+func NewSource(socket string) *Source {
+	return &Source{
+		socket: socket,
+		ready:  make(chan struct{}),
+	}
+}
+
+// OnUpdate registers a function that is called with every new SVID, it is called straight away if there is one
+func (s *Source) OnUpdate(f func(*SVID)) {
+	s.callbacks.Lock()
+	defer s.callbacks.Unlock()
+
+	s.mutex.Lock()
+	s.onUpdate = append(s.onUpdate, f)
+	svid := s.svid
+	s.mutex.Unlock()
+
+	if svid != nil {
+		f(svid)
+	}
+}
+
+// SVID returns the current SVID, nil if one hasn't been fetched yet
+func (s *Source) SVID() *SVID {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.svid
+}
+
+// WaitForSVID will wait until the first SVID has been fetched or the context is cancelled
+func (s *Source) WaitForSVID(ctx context.Context) (*SVID, error) {
+	select {
+	case <-s.ready:
+		return s.SVID(), nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no SVID was received from [%s]: %v", s.socket, ctx.Err())
+	}
+}
+
+// GetClientCertificate returns the current SVID as a TLS client certificate, so that connections always use the
+// latest rotation
+func (s *Source) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	svid := s.SVID()
+	if svid == nil {
+		return nil, fmt.Errorf("no SVID has been received from [%s]", s.socket)
+	}
+	return svid.TLSCertificate(), nil
+}
+
+// Run will watch the SVIDs of the Workload API until the context is cancelled. The X.509 source reconnects with a
+// backoff when the stream fails, it is only created again if it can't be created at all.
+func (s *Source) Run(ctx context.Context) {
+	for {
+		source, err := workloadapi.NewX509Source(ctx, workloadapi.WithClientOptions(workloadapi.WithAddr(s.socket)))
+		if err == nil {
+			s.watch(ctx, source)
+			_ = source.Close()
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Errorf("(spiffe) workload API [%s]: %v", s.socket, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// watch will update the SVID whenever the X.509 source has been updated, until the context is cancelled
+func (s *Source) watch(ctx context.Context, source *workloadapi.X509Source) {
+	for {
+		svid, err := newSVID(source)
+		if err != nil {
+			log.Errorf("(spiffe) %v", err)
+		} else {
+			s.update(svid)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-source.Updated():
+		}
+	}
+}
+
+// newSVID returns the default SVID of the X.509 source, along with the bundle of its trust domain
+func newSVID(source *workloadapi.X509Source) (*SVID, error) {
+	svid, err := source.GetX509SVID()
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := source.GetX509BundleForTrustDomain(svid.ID.TrustDomain())
+	if err != nil {
+		return nil, err
+	}
+	return &SVID{
+		ID:           svid.ID.String(),
+		Certificates: svid.Certificates,
+		PrivateKey:   svid.PrivateKey,
+		Bundle:       bundle.X509Authorities(),
+	}, nil
+}
+
+// update replaces the current SVID and calls the update functions
+func (s *Source) update(svid *SVID) {
+	s.callbacks.Lock()
+	defer s.callbacks.Unlock()
+
+	s.mutex.Lock()
+	first := s.svid == nil
+	s.svid = svid
+	onUpdate := s.onUpdate
+	s.mutex.Unlock()
+
+	log.Infof("(spiffe) received SVID [%s], it expires at [%s]", svid.ID, svid.Certificates[0].NotAfter)
+	if first {
+		close(s.ready)
+	}
+	for _, f := range onUpdate {
+		f(svid)
+	}
+}
@@ -0,0 +1,183 @@
+package spiffe
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// newX509SVID creates an SVID for the ID that is signed by a new CA, as it is returned by the Workload API
+func newX509SVID(t *testing.T, id string) *workload.X509SVID {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "spire"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri, err := url.Parse(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		URIs:         []*url.URL{uri},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}, caTemplate, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &workload.X509SVID{SpiffeId: id, X509Svid: der, X509SvidKey: keyDER, Bundle: caDER}
+}
+
+// workloadAPI sends an SVID to every stream, and a new one whenever it is rotated
+type workloadAPI struct {
+	workload.UnimplementedSpiffeWorkloadAPIServer
+	t      *testing.T
+	svids  []*workload.X509SVID
+	rotate chan struct{}
+}
+
+func (w *workloadAPI) FetchX509SVID(_ *workload.X509SVIDRequest, stream workload.SpiffeWorkloadAPI_FetchX509SVIDServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	if len(md.Get("workload.spiffe.io")) == 0 {
+		w.t.Errorf("request without the security header, metadata %v", md)
+	}
+	for x := range w.svids {
+		if x != 0 {
+			select {
+			case <-stream.Context().Done():
+				return nil
+			case <-w.rotate:
+			}
+		}
+		if err := stream.Send(&workload.X509SVIDResponse{Svids: w.svids[x : x+1]}); err != nil {
+			return err
+		}
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func TestSource(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := "spiffe://example.org/kube-vip/node-1"
+	api := &workloadAPI{t: t, svids: []*workload.X509SVID{newX509SVID(t, id), newX509SVID(t, id)}, rotate: make(chan struct{})}
+
+	server := grpc.NewServer()
+	workload.RegisterSpiffeWorkloadAPIServer(server, api)
+	go func() {
+		_ = server.Serve(l)
+	}()
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	source := NewSource("unix://" + socket)
+	updated := make(chan *SVID, 2)
+	source.OnUpdate(func(s *SVID) {
+		updated <- s
+	})
+	go source.Run(ctx)
+
+	svid, err := source.WaitForSVID(ctx)
+	if err != nil {
+		t.Fatalf("WaitForSVID() error = %v", err)
+	}
+	if svid.ID != id || ID(svid.Certificates[0]) != id || len(svid.Bundle) != 1 {
+		t.Errorf("WaitForSVID() = %s with %d bundle certificates, want %s", svid.ID, len(svid.Bundle), id)
+	}
+	if got := <-updated; got.ID != id {
+		t.Errorf("OnUpdate() called with %s, want %s", got.ID, id)
+	}
+	cert, err := source.GetClientCertificate(nil)
+	if err != nil || cert.Leaf != svid.Certificates[0] {
+		t.Errorf("GetClientCertificate() = %v, %v", cert, err)
+	}
+	if _, _, _, err := svid.PEM(); err != nil {
+		t.Errorf("PEM() error = %v", err)
+	}
+
+	// A rotated SVID replaces the current one
+	api.rotate <- struct{}{}
+	select {
+	case rotated := <-updated:
+		if rotated.Certificates[0].Equal(svid.Certificates[0]) {
+			t.Error("OnUpdate() called with the previous SVID, want the rotated SVID")
+		}
+		if source.SVID() != rotated {
+			t.Error("SVID() isn't the rotated SVID")
+		}
+	case <-ctx.Done():
+		t.Fatal("the rotated SVID wasn't received")
+	}
+}
+
+func TestNode(t *testing.T) {
+	template := "spiffe://example.org/kube-vip/{node}"
+	if err := ValidateTemplate(template); err != nil {
+		t.Fatalf("ValidateTemplate() error = %v", err)
+	}
+	for _, invalid := range []string{"spiffe://example.org/kube-vip", "https://example.org/{node}", "spiffe://{node}/{node}"} {
+		if err := ValidateTemplate(invalid); err == nil {
+			t.Errorf("ValidateTemplate(%s) expected an error", invalid)
+		}
+	}
+
+	id := NodeID(template, "node-1")
+	if id != "spiffe://example.org/kube-vip/node-1" {
+		t.Errorf("NodeID() = %s", id)
+	}
+	tests := map[string]string{
+		id:                                       "node-1",
+		"spiffe://example.org/kube-vip/":         "",
+		"spiffe://example.org/kube-vip/a/node-1": "",
+		"spiffe://other.org/kube-vip/node-1":     "",
+		"spiffe://example.org/kube-vip-x/node-1": "",
+	}
+	for id, want := range tests {
+		if got := Node(template, id); got != want {
+			t.Errorf("Node(%s) = %q, want %q", id, got, want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 )
 
 func WriteProcSys(path, value string) error {
@@ -27,3 +28,25 @@ func WriteProcSys(path, value string) error {
 
 	return nil
 }
+
+func ReadProcSys(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// EnsureProcSys will only write the value if it isn't already set, a container without privileges can't write to
+// /proc/sys but doesn't need to when the value has already been set on the node. It returns true if the value was
+// written.
+func EnsureProcSys(path, value string) (bool, error) {
+	current, err := ReadProcSys(path)
+	if err == nil && current == value {
+		return false, nil
+	}
+	if err := WriteProcSys(path, value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -1,6 +1,12 @@
 package utils
 
-import "os"
+import (
+	"context"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
 
 func FileExists(filename string) bool {
 	info, err := os.Stat(filename)
@@ -9,3 +15,42 @@ func FileExists(filename string) bool {
 	}
 	return !info.IsDir()
 }
+
+// WatchFiles will call reload whenever the modification time of one of the files changes, this is used to pick up
+// mounted secrets (which the kubelet updates in place) without a restart. A failed reload is retried on the next
+// interval, as the files may have been part way through being replaced.
+func WatchFiles(ctx context.Context, files []string, interval time.Duration, reload func() error) {
+	last := modTimes(files)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := modTimes(files)
+		if current == last {
+			continue
+		}
+		if err := reload(); err != nil {
+			log.Warnf("unable to reload %v: %v", files, err)
+			continue
+		}
+		last = current
+	}
+}
+
+// modTimes returns a string of the modification times of the files, so that any change can be detected
+func modTimes(files []string) string {
+	var times string
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			times += info.ModTime().String()
+		}
+		times += ","
+	}
+	return times
+}
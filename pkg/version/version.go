@@ -0,0 +1,129 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kube-vip/kube-vip/pkg/fips"
+)
+
+// Dependency is the version of a module that is built into kube-vip
+type Dependency struct {
+	Name    string
+	Path    string
+	Version string
+}
+
+// dependencies are the modules that are most useful when triaging an issue
+var dependencies = []Dependency{
+	{Name: "GoBGP", Path: "github.com/osrg/gobgp/v3"},
+	{Name: "wireguard-go", Path: "golang.zx2c4.com/wireguard"},
+	{Name: "wgctrl", Path: "golang.zx2c4.com/wireguard/wgctrl"},
+	{Name: "client-go", Path: "k8s.io/client-go"},
+}
+
+// buildSettings are the build settings that are reported, the rest are mostly noise
+var buildSettings = map[string]bool{
+	"-ldflags":     true,
+	"-tags":        true,
+	"CGO_ENABLED":  true,
+	"vcs.revision": true,
+	"vcs.time":     true,
+	"vcs.modified": true,
+}
+
+// Info is the version and build information of this kube-vip binary
+type Info struct {
+	Version   string
+	Build     string
+	GoVersion string
+	Platform  string
+
+	// FIPS is true when the binary has been built with a FIPS validated crypto module
+	FIPS bool
+
+	Dependencies  []Dependency
+	BuildSettings []debug.BuildSetting
+}
+
+// Get will return the version information, the version and build are set at link time by the Makefile
+func Get(version, build string) Info {
+	info := Info{
+		Version:   version,
+		Build:     build,
+		GoVersion: runtime.Version(),
+		Platform:  fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+		FIPS:      fips.Built(),
+	}
+
+	modules := map[string]string{}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range bi.Deps {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			modules[dep.Path] = dep.Version
+		}
+		for _, setting := range bi.Settings {
+			if buildSettings[setting.Key] {
+				info.BuildSettings = append(info.BuildSettings, setting)
+			}
+		}
+	}
+
+	for _, dep := range dependencies {
+		dep.Version = modules[dep.Path]
+		if dep.Version == "" {
+			// The module isn't used by any package that is linked into this binary
+			dep.Version = "not linked"
+		}
+		info.Dependencies = append(info.Dependencies, dep)
+	}
+	return info
+}
+
+// dependency returns the version of a dependency by name
+func (i Info) dependency(name string) string {
+	for _, dep := range i.Dependencies {
+		if dep.Name == name {
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
+
+// String returns the version information in a format suitable for pasting into an issue
+func (i Info) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Version:  %s\n", i.Version)
+	fmt.Fprintf(&b, "Build:    %s\n", i.Build)
+	fmt.Fprintf(&b, "Go:       %s\n", i.GoVersion)
+	fmt.Fprintf(&b, "Platform: %s\n", i.Platform)
+	fmt.Fprintf(&b, "FIPS:     %t\n", i.FIPS)
+	b.WriteString("Dependencies:\n")
+	for _, dep := range i.Dependencies {
+		fmt.Fprintf(&b, "  %-14s %s\n", dep.Name, dep.Version)
+	}
+	if len(i.BuildSettings) != 0 {
+		b.WriteString("Build flags:\n")
+		for _, setting := range i.BuildSettings {
+			fmt.Fprintf(&b, "  %-14s %s\n", setting.Key, setting.Value)
+		}
+	}
+	return b.String()
+}
+
+// BuildInfoCollector returns a gauge, that is always 1, labelled with the version information and enabled features
+func (i Info) BuildInfoCollector(features []string) prometheus.Collector {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "kube_vip",
+		Name:      "build_info",
+		Help:      "A metric with a constant '1' value labelled by the version, build, dependency versions and enabled features of kube-vip",
+	}, []string{"version", "build", "go_version", "gobgp_version", "client_go_version", "features"})
+	gauge.WithLabelValues(i.Version, i.Build, i.GoVersion, i.dependency("GoBGP"), i.dependency("client-go"), strings.Join(features, ",")).Set(1)
+	return gauge
+}
@@ -37,12 +37,16 @@ type Network interface {
 	PrepareRoute() *netlink.Route
 	SetIP(ip string) error
 	SetServicePorts(service *v1.Service)
+	SetNextHops(hops []NextHop) error
+	SetRuleTable(table int)
+	SetRouteAttributes(attributes RouteAttributes) error
 	Interface() string
 	IsDADFAIL() bool
 	IsDNS() bool
 	IsDDNS() bool
 	DDNSHostName() string
 	DNSName() string
+	SetValidLifetime(seconds int)
 }
 
 // network - This allows network configuration
@@ -57,13 +61,20 @@ type network struct {
 
 	dnsName string
 	isDDNS  bool
+	// The lifetime of an address that is resolved from the DNS name, it expires unless it is refreshed
+	validLft int
 
 	forwardMethod   string
 	iptablesBackend string
+	firewallBackend string
 
 	routeTable       int
 	routingTableType int
 	routingProtocol  int
+	routingMetric    int
+	nextHops         []*netlink.NexthopInfo
+	ruleTable        int
+	routeAttributes  RouteAttributes
 }
 
 func netlinkParse(addr string) (*netlink.Addr, error) {
@@ -75,7 +86,7 @@ func netlinkParse(addr string) (*netlink.Addr, error) {
 }
 
 // NewConfig will attempt to provide an interface to the kernel network configuration
-func NewConfig(address string, iface string, subnet string, isDDNS bool, tableID int, tableType int, routingProtocol int, dnsMode, forwardMethod, iptablesBackend string) ([]Network, error) {
+func NewConfig(address string, iface string, subnet string, isDDNS bool, tableID int, tableType int, routingProtocol int, routingMetric int, dnsMode, forwardMethod, iptablesBackend, firewallBackend string) ([]Network, error) {
 	networks := []Network{}
 
 	link, err := netlink.LinkByName(iface)
@@ -89,12 +100,14 @@ func NewConfig(address string, iface string, subnet string, isDDNS bool, tableID
 			routeTable:       tableID,
 			routingTableType: tableType,
 			routingProtocol:  routingProtocol,
+			routingMetric:    routingMetric,
 			forwardMethod:    forwardMethod,
 			iptablesBackend:  iptablesBackend,
+			firewallBackend:  firewallBackend,
 		}
 
 		// Check if the subnet needs overriding
-		if subnet != "" {
+		if subnet = SubnetForAddress(subnet, address); subnet != "" {
 			result.address, err = netlink.ParseAddr(address + subnet)
 			if err != nil {
 				return networks, errors.Wrapf(err, "could not parse address '%s'", address)
@@ -122,8 +135,10 @@ func NewConfig(address string, iface string, subnet string, isDDNS bool, tableID
 					routeTable:       tableID,
 					routingTableType: tableType,
 					routingProtocol:  routingProtocol,
+					routingMetric:    routingMetric,
 					forwardMethod:    forwardMethod,
 					iptablesBackend:  iptablesBackend,
+					firewallBackend:  firewallBackend,
 					isDDNS:           isDDNS,
 					dnsName:          address,
 				}
@@ -140,8 +155,10 @@ func NewConfig(address string, iface string, subnet string, isDDNS bool, tableID
 				routeTable:       tableID,
 				routingTableType: tableType,
 				routingProtocol:  routingProtocol,
+				routingMetric:    routingMetric,
 				forwardMethod:    forwardMethod,
 				iptablesBackend:  iptablesBackend,
+				firewallBackend:  firewallBackend,
 				isDDNS:           isDDNS,
 				dnsName:          address,
 			}
@@ -174,6 +191,25 @@ func ListRoutes(table, protocol int) ([]netlink.Route, error) {
 	return routes, nil
 }
 
+// VRF returns the routing table of a VRF and the interface that the VIP is bound to inside it. The interface is kept
+// if it is enslaved to the VRF, otherwise the VIP is bound to the VRF device itself.
+func VRF(name, iface string) (int, string, error) {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return 0, "", errors.Wrapf(err, "could not get VRF '%s'", name)
+	}
+	vrf, ok := link.(*netlink.Vrf)
+	if !ok {
+		return 0, "", fmt.Errorf("interface '%s' is a %s, not a VRF", name, link.Type())
+	}
+	if iface != "" && iface != name {
+		if l, err := netlink.LinkByName(iface); err == nil && l.Attrs().MasterIndex == vrf.Index {
+			return int(vrf.Table), iface, nil
+		}
+	}
+	return int(vrf.Table), name, nil
+}
+
 // ListRoutesByDst returns all routes from selected table with selected destination IP
 func ListRoutesByDst(table int, dst *net.IPNet) ([]netlink.Route, error) {
 	route := &netlink.Route{
@@ -199,19 +235,39 @@ func (configurator *network) PrepareRoute() *netlink.Route {
 		Table:     configurator.routeTable,
 		Type:      configurator.routingTableType,
 		Protocol:  netlink.RouteProtocol(configurator.routingProtocol),
+		Priority:  configurator.routingMetric,
 	}
+	if len(configurator.nextHops) != 0 {
+		// The next hops have their own interfaces
+		route.LinkIndex = 0
+		route.MultiPath = configurator.nextHops
+	}
+	configurator.applyRouteAttributes(route)
 	return route
 }
 
-// AddRoute - Add an IP address to a route table
+// AddRoute - Add an IP address to a route table, along with its rule
 func (configurator *network) AddRoute() error {
+	// The rule is added first, as an existing route is reported (and updated) by the callers
+	if err := configurator.addRule(); err != nil {
+		return err
+	}
 	route := configurator.PrepareRoute()
-	return netlink.RouteAdd(route)
+	err := netlink.RouteAdd(route)
+	if err == nil || errors.Is(err, unix.EEXIST) {
+		installedRoutes.Store(routeKey(route.Table, route.Dst), configurator)
+	}
+	return err
 }
 
-// DeleteRoute - Delete an IP address from a route table
+// DeleteRoute - Delete an IP address from a route table, along with its rule
 func (configurator *network) DeleteRoute() error {
+	if err := configurator.deleteRule(); err != nil {
+		return err
+	}
 	route := configurator.PrepareRoute()
+	// The route is forgotten first, so that it isn't re-installed when it is deleted
+	installedRoutes.Delete(routeKey(route.Table, route.Dst))
 	return netlink.RouteDel(route)
 }
 
@@ -246,18 +302,29 @@ func (configurator *network) UpdateRoutes() (bool, error) {
 
 // AddIP - Add an IP address to the interface
 func (configurator *network) AddIP() error {
-	if err := netlink.AddrReplace(configurator.link, configurator.address); err != nil {
+	if err := addressHandle.AddrReplace(configurator.link, configurator.address); err != nil {
 		return errors.Wrap(err, "could not add ip")
 	}
+	linkAddresses.added(configurator.link, *configurator.address)
+
+	security := os.Getenv("enable_service_security") == "true" && !configurator.ignoreSecurity
+	if !security && configurator.forwardMethod != "masquerade" {
+		return nil
+	}
+	rules, err := newServiceRules(configurator.firewallBackend)
+	if err != nil {
+		return err
+	}
+	vip := configurator.address.IP.String()
 
-	if os.Getenv("enable_service_security") == "true" && !configurator.ignoreSecurity {
-		if err := configurator.addIptablesRulesToLimitTrafficPorts(); err != nil {
+	if security {
+		if err := rules.limitPorts(vip, fmt.Sprintf(iptablesComment, configurator.serviceName), configurator.ports); err != nil {
 			return errors.Wrap(err, "could not add iptables rules to limit traffic ports")
 		}
 	}
 
 	if configurator.forwardMethod == "masquerade" {
-		if err := configurator.addIptablesRulesForMasquerade(); err != nil {
+		if err := rules.masquerade(vip, fmt.Sprintf(iptablesComment, vip)); err != nil {
 			return errors.Wrap(err, "could not add iptables rules for masquerade")
 		}
 	}
@@ -265,27 +332,28 @@ func (configurator *network) AddIP() error {
 	return nil
 }
 
-func (configurator *network) addIptablesRulesToLimitTrafficPorts() error {
+// iptablesServiceRules manages the rules of the VIPs of the services with the iptables binaries
+type iptablesServiceRules struct{}
+
+func (iptablesServiceRules) limitPorts(vip, comment string, ports []v1.ServicePort) error {
 	ipt, err := iptables.New()
 	if err != nil {
 		return errors.Wrap(err, "could not create iptables client")
 	}
 
-	vip := configurator.address.IP.String()
-	comment := fmt.Sprintf(iptablesComment, configurator.serviceName)
 	if err := insertCommonIPTablesRules(ipt, vip, comment); err != nil {
 		return fmt.Errorf("could not add common iptables rules: %w", err)
 	}
-	log.Debugf("add iptables rules, vip: %s, ports: %+v", vip, configurator.ports)
-	if err := configurator.insertIPTablesRulesForServicePorts(ipt, vip, comment); err != nil {
+	log.Debugf("add iptables rules, vip: %s, ports: %+v", vip, ports)
+	if err := insertIPTablesRulesForServicePorts(ipt, vip, comment, ports); err != nil {
 		return fmt.Errorf("could not add iptables rules for service ports: %v", err)
 	}
 
 	return nil
 }
 
-func (configurator *network) insertIPTablesRulesForServicePorts(ipt *iptables.IPTables, vip, comment string) error {
-	isPortsRuleExisting := make([]bool, len(configurator.ports))
+func insertIPTablesRulesForServicePorts(ipt *iptables.IPTables, vip, comment string, ports []v1.ServicePort) error {
+	isPortsRuleExisting := make([]bool, len(ports))
 
 	// delete rules of ports that are not in the service
 	rules, err := ipt.List(iptables.TableFilter, iptables.ChainInput)
@@ -312,7 +380,7 @@ func (configurator *network) insertIPTablesRulesForServicePorts(ipt *iptables.IP
 		}
 		// if the rule is for the vip, but its protocol and port are not in the service, delete it
 		toBeDeleted := true
-		for i, p := range configurator.ports {
+		for i, p := range ports {
 			if string(p.Protocol) == protocol && strconv.Itoa(int(p.Port)) == port {
 				// the rule is for the vip and its protocol and port are in the service, keep it and mark it as existing
 				toBeDeleted = false
@@ -330,10 +398,10 @@ func (configurator *network) insertIPTablesRulesForServicePorts(ipt *iptables.IP
 	for i, ok := range isPortsRuleExisting {
 		if !ok {
 			if err := ipt.InsertUnique(iptables.TableFilter, iptables.ChainInput, 1, "-d", vip, "-p",
-				string(configurator.ports[i].Protocol), "--dport", strconv.Itoa(int(configurator.ports[i].Port)),
+				string(ports[i].Protocol), "--dport", strconv.Itoa(int(ports[i].Port)),
 				"-m", "comment", "--comment", comment, "-j", "ACCEPT"); err != nil {
 				return fmt.Errorf("could not add iptables rule to accept the traffic to VIP %s for allowed "+
-					"port %d: %v", vip, configurator.ports[i].Port, err)
+					"port %d: %v", vip, ports[i].Port, err)
 			}
 		}
 	}
@@ -369,20 +437,18 @@ func deleteCommonIPTablesRules(ipt *iptables.IPTables, vip, comment string) erro
 	return nil
 }
 
-func (configurator *network) removeIptablesRuleToLimitTrafficPorts() error {
+func (iptablesServiceRules) removePortLimits(vip, comment string, ports []v1.ServicePort) error {
 	ipt, err := iptables.New()
 	if err != nil {
 		return errors.Wrap(err, "could not create iptables client")
 	}
-	vip := configurator.address.IP.String()
-	comment := fmt.Sprintf(iptablesComment, configurator.serviceName)
 
 	if err := deleteCommonIPTablesRules(ipt, vip, comment); err != nil {
 		return fmt.Errorf("could not delete common iptables rules: %w", err)
 	}
 
-	log.Debugf("remove iptables rules, vip: %s, ports: %+v", vip, configurator.ports)
-	for _, port := range configurator.ports {
+	log.Debugf("remove iptables rules, vip: %s, ports: %+v", vip, ports)
+	for _, port := range ports {
 		// iptables -D INPUT -d  <VIP> -p <protocol> --dport <port> -j ACCEPT
 		if err := ipt.DeleteIfExists(iptables.TableFilter, iptables.ChainInput, "-d", vip, "-p", string(port.Protocol),
 			"--dport", strconv.Itoa(int(port.Port)), "-m", "comment", "--comment", comment, "-j", "ACCEPT"); err != nil {
@@ -405,18 +471,29 @@ func (configurator *network) DeleteIP() error {
 		return nil
 	}
 
-	if err = netlink.AddrDel(configurator.link, configurator.address); err != nil {
+	if err = addressHandle.AddrDel(configurator.link, configurator.address); err != nil {
 		return errors.Wrap(err, "could not delete ip")
 	}
+	linkAddresses.deleted(configurator.link, *configurator.address)
 
-	if os.Getenv("enable_service_security") == "true" && !configurator.ignoreSecurity {
-		if err := configurator.removeIptablesRuleToLimitTrafficPorts(); err != nil {
+	security := os.Getenv("enable_service_security") == "true" && !configurator.ignoreSecurity
+	if !security && configurator.forwardMethod != "masquerade" {
+		return nil
+	}
+	rules, err := newServiceRules(configurator.firewallBackend)
+	if err != nil {
+		return err
+	}
+	vip := configurator.address.IP.String()
+
+	if security {
+		if err := rules.removePortLimits(vip, fmt.Sprintf(iptablesComment, configurator.serviceName), configurator.ports); err != nil {
 			return errors.Wrap(err, "could not remove iptables rules to limit traffic ports")
 		}
 	}
 
 	if configurator.forwardMethod == "masquerade" {
-		if err := configurator.removeIptablesRulesForMasquerade(); err != nil {
+		if err := rules.removeMasquerade(vip, fmt.Sprintf(iptablesComment, vip)); err != nil {
 			return errors.Wrap(err, "could not remove iptables masquerade rules ")
 		}
 	}
@@ -424,7 +501,7 @@ func (configurator *network) DeleteIP() error {
 	return nil
 }
 
-func (configurator *network) addIptablesRulesForMasquerade() error {
+func (iptablesServiceRules) masquerade(vip, comment string) error {
 	ver, err := iptables.GetVersion()
 	if err != nil {
 		return errors.Wrap(err, "could not get iptables version")
@@ -435,18 +512,10 @@ func (configurator *network) addIptablesRulesForMasquerade() error {
 		return errors.Wrap(err, "could not create iptables client")
 	}
 
-	vip := configurator.address.IP.String()
-	comment := fmt.Sprintf(iptablesComment, vip)
-	if err := addMasqueradeRuleForVIP(ipt, vip, comment); err != nil {
-		return err
-	}
-
-	return nil
+	return addMasqueradeRuleForVIP(ipt, vip, comment)
 }
 
-// addIptablesRulesForMasquerade add iptables rules for MASQUERADE
-// insert example
-func (configurator *network) removeIptablesRulesForMasquerade() error {
+func (iptablesServiceRules) removeMasquerade(vip, comment string) error {
 	ver, err := iptables.GetVersion()
 	if err != nil {
 		return errors.Wrap(err, "could not get iptables version")
@@ -455,15 +524,8 @@ func (configurator *network) removeIptablesRulesForMasquerade() error {
 	if err != nil {
 		return errors.Wrap(err, "could not create iptables client")
 	}
-	vip := configurator.address.IP.String()
-	comment := fmt.Sprintf(iptablesComment, vip)
 
-	err = delMasqueradeRuleForVIP(ipt, vip, comment)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return delMasqueradeRuleForVIP(ipt, vip, comment)
 }
 
 // TODO: investigate if adding "--vport <port>" would be better or not quite necessary
@@ -492,8 +554,8 @@ func (configurator *network) IsDADFAIL() bool {
 		return false
 	}
 
-	// Get all the address
-	addresses, err := netlink.AddrList(configurator.link, netlink.FAMILY_V6)
+	// The flags change after the address has been added, so they are read from the interface rather than a shared dump
+	addresses, err := addressHandle.AddrList(configurator.link, netlink.FAMILY_V6)
 	if err != nil {
 		return false
 	}
@@ -514,26 +576,15 @@ func addressHasDADFAILEDFlag(address netlink.Addr) bool {
 
 // IsSet - Check to see if VIP is set
 func (configurator *network) IsSet() (result bool, err error) {
-	var addresses []netlink.Addr
-
 	if configurator.address == nil {
 		return false, nil
 	}
 
-	addresses, err = netlink.AddrList(configurator.link, 0)
+	result, err = linkAddresses.contains(configurator.link, *configurator.address)
 	if err != nil {
 		err = errors.Wrap(err, "could not list addresses")
-
-		return
 	}
-
-	for _, address := range addresses {
-		if address.Equal(*configurator.address) {
-			return true, nil
-		}
-	}
-
-	return false, nil
+	return
 }
 
 // SetIP updates the IP that is used
@@ -547,6 +598,9 @@ func (configurator *network) SetIP(ip string) error {
 	}
 	if configurator.address != nil && configurator.IsDNS() {
 		addr.ValidLft = defaultValidLft
+		if configurator.validLft != 0 {
+			addr.ValidLft = configurator.validLft
+		}
 	}
 	configurator.address = addr
 	return nil
@@ -586,6 +640,16 @@ func (configurator *network) IsDDNS() bool {
 	return configurator.isDDNS
 }
 
+// SetValidLifetime - set the lifetime of the address that is resolved from the DNS name
+func (configurator *network) SetValidLifetime(seconds int) {
+	configurator.mu.Lock()
+	defer configurator.mu.Unlock()
+	configurator.validLft = seconds
+	if configurator.address != nil && configurator.IsDNS() {
+		configurator.address.ValidLft = seconds
+	}
+}
+
 // DDNSHostName - return the hostname for dynamic dns
 // when dDNSHostName is not empty, use DHCP to get IP for hostname: dDNSHostName
 // it's expected that dynamic DNS should be configured so
@@ -608,7 +672,7 @@ func GarbageCollect(adapter, address string) (found bool, err error) {
 	}
 
 	// Get addresses on adapter
-	addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+	addrs, err := addressHandle.AddrList(link, netlink.FAMILY_ALL)
 	if err != nil {
 		return false, err
 	}
@@ -620,9 +684,10 @@ func GarbageCollect(adapter, address string) (found bool, err error) {
 			found = true
 			// linting issue
 			existing := existing
-			if err = netlink.AddrDel(link, &existing); err != nil {
+			if err = addressHandle.AddrDel(link, &existing); err != nil {
 				return true, errors.Wrap(err, "could not delete ip")
 			}
+			linkAddresses.deleted(link, existing)
 		}
 	}
 	return // Didn't find the address on the adapter
@@ -0,0 +1,81 @@
+package vip
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+)
+
+// addressCacheTTL is how long a dump of the addresses of an interface is shared. The VIPs of all the services on an
+// interface are checked on the same interval, so one dump answers all of them rather than a dump for each VIP (that
+// would be quadratic in the number of services).
+var addressCacheTTL = time.Second
+
+type addressDump struct {
+	addresses map[string]bool
+	taken     time.Time
+}
+
+// addressCache shares the dumps of the addresses of each interface, the addresses that kube-vip adds or removes are
+// applied to the dump of their interface so that a node taking over thousands of VIPs doesn't dump the interface
+// again after each of them
+type addressCache struct {
+	mu    sync.Mutex
+	dumps map[int]addressDump
+	list  func(netlink.Link, int) ([]netlink.Addr, error)
+	now   func() time.Time
+}
+
+var linkAddresses = &addressCache{
+	dumps: make(map[int]addressDump),
+	list:  addressHandle.AddrList,
+	now:   time.Now,
+}
+
+// addressKey matches the addresses the way that netlink.Addr.Equal does, by the address and the prefix length
+func addressKey(address netlink.Addr) string {
+	ones, _ := address.Mask.Size()
+	return fmt.Sprintf("%s/%d", address.IP, ones)
+}
+
+// contains returns true if the address is on the link, from a dump of the addresses of all the families on the link.
+// The lock is held during the dump so that the checks that run at the same time wait for the one dump.
+func (c *addressCache) contains(link netlink.Link, address netlink.Addr) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index := link.Attrs().Index
+	dump, found := c.dumps[index]
+	if !found || c.now().Sub(dump.taken) >= addressCacheTTL {
+		addresses, err := c.list(link, netlink.FAMILY_ALL)
+		if err != nil {
+			return false, err
+		}
+		dump = addressDump{addresses: make(map[string]bool, len(addresses)), taken: c.now()}
+		for _, existing := range addresses {
+			dump.addresses[addressKey(existing)] = true
+		}
+		c.dumps[index] = dump
+	}
+	return dump.addresses[addressKey(address)], nil
+}
+
+// added records an address that kube-vip has added to the link
+func (c *addressCache) added(link netlink.Link, address netlink.Addr) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if dump, found := c.dumps[link.Attrs().Index]; found {
+		dump.addresses[addressKey(address)] = true
+	}
+}
+
+// deleted records an address that kube-vip has removed from the link
+func (c *addressCache) deleted(link netlink.Link, address netlink.Addr) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if dump, found := c.dumps[link.Attrs().Index]; found {
+		delete(dump.addresses, addressKey(address))
+	}
+}
@@ -0,0 +1,96 @@
+package vip
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+)
+
+func fakeAddressCache(addresses int) (*addressCache, *int, *time.Time) {
+	dumps := 0
+	now := time.Unix(0, 0)
+	list := make([]netlink.Addr, 0, addresses)
+	for n := 0; n < addresses; n++ {
+		list = append(list, netlink.Addr{IPNet: &net.IPNet{IP: net.ParseIP(fmt.Sprintf("10.%d.%d.%d", n>>16, (n>>8)&0xff, n&0xff)), Mask: net.CIDRMask(32, 32)}})
+	}
+	return &addressCache{
+		dumps: make(map[int]addressDump),
+		list: func(netlink.Link, int) ([]netlink.Addr, error) {
+			dumps++
+			return list, nil
+		},
+		now: func() time.Time { return now },
+	}, &dumps, &now
+}
+
+func TestAddressCache(t *testing.T) {
+	cache, dumps, now := fakeAddressCache(2)
+	eth0 := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 2}}
+	eth1 := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 3}}
+	address := func(ip string, prefix int) netlink.Addr {
+		return netlink.Addr{IPNet: &net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(prefix, 32)}}
+	}
+
+	for n := 0; n < 3; n++ {
+		if found, err := cache.contains(eth0, address("10.0.0.1", 32)); err != nil || !found {
+			t.Fatalf("contains() = %t, %v", found, err)
+		}
+	}
+	if *dumps != 1 {
+		t.Errorf("the addresses were dumped %d times, want 1", *dumps)
+	}
+	if found, err := cache.contains(eth0, address("10.0.0.1", 24)); err != nil || found {
+		t.Errorf("contains() = %t, %v for another prefix length", found, err)
+	}
+	if _, err := cache.contains(eth1, address("10.0.0.1", 32)); err != nil || *dumps != 2 {
+		t.Errorf("the addresses of another interface weren't dumped, %d dumps: %v", *dumps, err)
+	}
+
+	// The changes of kube-vip are applied to the dump, rather than the interface being dumped again
+	cache.added(eth0, address("10.0.0.10", 32))
+	cache.deleted(eth0, address("10.0.0.1", 32))
+	if found, err := cache.contains(eth0, address("10.0.0.10", 32)); err != nil || !found {
+		t.Errorf("contains() = %t, %v for an added address", found, err)
+	}
+	if found, err := cache.contains(eth0, address("10.0.0.1", 32)); err != nil || found {
+		t.Errorf("contains() = %t, %v for a deleted address", found, err)
+	}
+	if *dumps != 2 {
+		t.Errorf("the addresses were dumped %d times after a change, want 2", *dumps)
+	}
+
+	*now = now.Add(addressCacheTTL)
+	if found, err := cache.contains(eth0, address("10.0.0.1", 32)); err != nil || !found || *dumps != 3 {
+		t.Errorf("the addresses weren't dumped after the TTL, %d dumps: %t, %v", *dumps, found, err)
+	}
+}
+
+// BenchmarkIsSet checks the VIPs of 5000 services on the same interface, as the services do on every ARP interval
+func BenchmarkIsSet(b *testing.B) {
+	const services = 5000
+	cache, _, _ := fakeAddressCache(services)
+	saved := linkAddresses
+	linkAddresses = cache
+	defer func() { linkAddresses = saved }()
+
+	link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 2}}
+	configurators := make([]*network, 0, services)
+	for n := 0; n < services; n++ {
+		address, err := netlinkParse(fmt.Sprintf("10.%d.%d.%d", n>>16, (n>>8)&0xff, n&0xff))
+		if err != nil {
+			b.Fatal(err)
+		}
+		configurators = append(configurators, &network{link: link, address: address})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if set, err := configurators[n%services].IsSet(); err != nil || !set {
+			b.Fatalf("IsSet() = %t, %v", set, err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package vip
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// addressHandle is the netlink socket that the addresses of the VIPs are added, deleted and listed with. When a node
+// takes over thousands of VIPs at once the operations are sent one after another over this socket, rather than each
+// of them opening and closing a socket of its own.
+var addressHandle = newAddressHandle()
+
+func newAddressHandle() *netlink.Handle {
+	handle, err := netlink.NewHandle(unix.NETLINK_ROUTE)
+	if err != nil {
+		// A handle without a socket opens one for each operation
+		log.Warnf("unable to open a netlink socket for the VIP addresses: %v", err)
+		return &netlink.Handle{}
+	}
+	return handle
+}
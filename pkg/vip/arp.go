@@ -10,6 +10,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"net"
+	"sync"
 	"syscall"
 	"unsafe"
 )
@@ -115,8 +116,8 @@ func gratuitousARP(ip net.IP, mac net.HardwareAddr) (*arpMessage, error) {
 	return m, nil
 }
 
-// sendARP sends the given ARP message via the specified interface.
-func sendARP(iface *net.Interface, m *arpMessage) error {
+// sendARP sends the ARP messages via the specified interface, over one socket.
+func sendARP(iface *net.Interface, messages []*arpMessage) error {
 	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_DGRAM, int(htons(syscall.ETH_P_ARP)))
 	if err != nil {
 		return fmt.Errorf("failed to get raw socket: %v", err)
@@ -131,45 +132,120 @@ func sendARP(iface *net.Interface, m *arpMessage) error {
 		Protocol: htons(syscall.ETH_P_ARP),
 		Ifindex:  iface.Index,
 		Pkttype:  0, // syscall.PACKET_HOST
-		Hatype:   m.hardwareType,
-		Halen:    m.hardwareAddressLength,
+		Hatype:   1, // Ethernet
+		Halen:    hwLen,
 	}
 	target := ethernetBroadcast
 	for i := 0; i < len(target); i++ { //nolint
 		ll.Addr[i] = target[i]
 	}
 
-	b, err := m.bytes()
-	if err != nil {
-		return fmt.Errorf("failed to convert ARP message: %v", err)
-	}
-
 	if err := syscall.Bind(fd, &ll); err != nil {
 		return fmt.Errorf("failed to bind: %v", err)
 	}
-	if err := syscall.Sendto(fd, b, 0, &ll); err != nil {
-		return fmt.Errorf("failed to send: %v", err)
+	for _, m := range messages {
+		b, err := m.bytes()
+		if err != nil {
+			return fmt.Errorf("failed to convert ARP message: %v", err)
+		}
+		if err := syscall.Sendto(fd, b, 0, &ll); err != nil {
+			return fmt.Errorf("failed to send: %v", err)
+		}
 	}
 
 	return nil
 }
 
-// ARPSendGratuitous sends a gratuitous ARP message via the specified interface.
-func ARPSendGratuitous(address, ifaceName string) error {
+// arpBatch are the addresses of an interface that are announced together
+type arpBatch struct {
+	addresses []net.IP
+	done      chan struct{}
+	err       error
+}
+
+// arpBatcher coalesces the gratuitous ARPs of each interface. The VIPs that are announced while a batch of the
+// interface is being sent are sent together in the next batch, with one lookup of the interface and one socket, so that
+// a node taking over thousands of VIPs doesn't open a socket (and dump the links) for each of them.
+type arpBatcher struct {
+	mu      sync.Mutex
+	pending map[string]*arpBatch
+	sending map[string]bool
+	send    func(ifaceName string, addresses []net.IP) error
+}
+
+var arpBatches = &arpBatcher{
+	pending: map[string]*arpBatch{},
+	sending: map[string]bool{},
+	send:    sendGratuitousARPs,
+}
+
+// announce adds the address to the next batch of the interface and waits for it to be sent
+func (b *arpBatcher) announce(ifaceName string, ip net.IP) error {
+	b.mu.Lock()
+	batch := b.pending[ifaceName]
+	if batch == nil {
+		batch = &arpBatch{done: make(chan struct{})}
+		b.pending[ifaceName] = batch
+	}
+	batch.addresses = append(batch.addresses, ip)
+	if !b.sending[ifaceName] {
+		b.sending[ifaceName] = true
+		go b.flush(ifaceName)
+	}
+	b.mu.Unlock()
+
+	<-batch.done
+	return batch.err
+}
+
+// flush sends the batches of the interface until there are none left
+func (b *arpBatcher) flush(ifaceName string) {
+	for {
+		b.mu.Lock()
+		batch := b.pending[ifaceName]
+		if batch == nil {
+			delete(b.sending, ifaceName)
+			b.mu.Unlock()
+			return
+		}
+		delete(b.pending, ifaceName)
+		b.mu.Unlock()
+
+		batch.err = b.send(ifaceName, batch.addresses)
+		close(batch.done)
+	}
+}
+
+// sendGratuitousARPs sends a gratuitous ARP message for each of the addresses via the specified interface
+func sendGratuitousARPs(ifaceName string, addresses []net.IP) error {
 	iface, err := net.InterfaceByName(ifaceName)
 	if err != nil {
 		return fmt.Errorf("failed to get interface %q: %v", ifaceName, err)
 	}
 
+	messages := make([]*arpMessage, 0, len(addresses))
+	for _, ip := range addresses {
+		m, err := gratuitousARP(ip, iface.HardwareAddr)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, m)
+	}
+	return sendARP(iface, messages)
+}
+
+// ARPSendGratuitous sends a gratuitous ARP message via the specified interface.
+func ARPSendGratuitous(address, ifaceName string) (err error) {
+	defer func() { countGratuitous(ifaceName, "arp", err) }()
+
 	ip := net.ParseIP(address)
 	if ip == nil {
-		return fmt.Errorf("failed to parse address %s", ip)
+		return fmt.Errorf("failed to parse address %s", address)
 	}
-
-	// This is a debug message, enable debugging to ensure that the gratuitous arp is repeating
-	m, err := gratuitousARP(ip, iface.HardwareAddr)
-	if err != nil {
-		return err
+	// Checked before it is batched, so that it doesn't fail the other addresses of the interface
+	if ip.To4() == nil {
+		return fmt.Errorf("%q is not an IPv4 address", ip)
 	}
-	return sendARP(iface, m)
+
+	return arpBatches.announce(ifaceName, ip)
 }
@@ -0,0 +1,186 @@
+//go:build linux
+// +build linux
+
+package vip
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// arpSender returns the sender addresses of an ARP message, as it is received on a SOCK_DGRAM packet socket
+func arpSender(b []byte) (net.HardwareAddr, net.IP, bool) {
+	// The header is the hardware type, protocol type, address lengths and opcode
+	if len(b) < 8+hwLen+net.IPv4len {
+		return nil, nil, false
+	}
+	if binary.BigEndian.Uint16(b[2:4]) != 0x0800 || b[4] != hwLen || b[5] != net.IPv4len {
+		return nil, nil, false
+	}
+	return net.HardwareAddr(b[8 : 8+hwLen]), net.IP(b[8+hwLen : 8+hwLen+net.IPv4len]), true
+}
+
+// arpConflict is a VIP that is watched for conflicts, the pointer identifies the watch of the VIP
+type arpConflict struct {
+	conflict func(mac net.HardwareAddr)
+}
+
+// arpWatch receives the ARP messages of an interface on one socket, for all the VIPs on the interface that are
+// watched for conflicts
+type arpWatch struct {
+	iface     *net.Interface
+	conflicts map[string]map[*arpConflict]bool
+	stop      chan struct{}
+	done      chan struct{}
+	err       error
+}
+
+// arpWatches are the watches of the interfaces, by name
+type arpWatches struct {
+	mu      sync.Mutex
+	watches map[string]*arpWatch
+}
+
+var arpConflictWatches = &arpWatches{watches: map[string]*arpWatch{}}
+
+// add watches the address on the interface, the socket of the interface is opened for its first address
+func (w *arpWatches) add(ifaceName string, ip net.IP, c *arpConflict) (*arpWatch, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	watch := w.watches[ifaceName]
+	if watch == nil {
+		iface, err := net.InterfaceByName(ifaceName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get interface %q: %v", ifaceName, err)
+		}
+		fd, err := arpWatchSocket(iface)
+		if err != nil {
+			return nil, err
+		}
+		watch = &arpWatch{
+			iface:     iface,
+			conflicts: map[string]map[*arpConflict]bool{},
+			stop:      make(chan struct{}),
+			done:      make(chan struct{}),
+		}
+		w.watches[ifaceName] = watch
+		go w.receive(ifaceName, watch, fd)
+	}
+	if watch.conflicts[ip.String()] == nil {
+		watch.conflicts[ip.String()] = map[*arpConflict]bool{}
+	}
+	watch.conflicts[ip.String()][c] = true
+	return watch, nil
+}
+
+// remove stops watching the address, the socket of the interface is closed once none of its addresses are watched
+func (w *arpWatches) remove(ifaceName string, ip net.IP, c *arpConflict) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	watch := w.watches[ifaceName]
+	if watch == nil || !watch.conflicts[ip.String()][c] {
+		return
+	}
+	delete(watch.conflicts[ip.String()], c)
+	if len(watch.conflicts[ip.String()]) == 0 {
+		delete(watch.conflicts, ip.String())
+	}
+	if len(watch.conflicts) == 0 {
+		delete(w.watches, ifaceName)
+		close(watch.stop)
+	}
+}
+
+// receive reads the ARP messages of the interface until the watch is stopped or the socket fails
+func (w *arpWatches) receive(ifaceName string, watch *arpWatch, fd int) {
+	defer syscall.Close(fd)
+
+	b := make([]byte, 128)
+	for {
+		select {
+		case <-watch.stop:
+			return
+		default:
+		}
+		n, _, err := syscall.Recvfrom(fd, b, 0)
+		if err != nil {
+			if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR) {
+				continue
+			}
+			w.mu.Lock()
+			if w.watches[ifaceName] == watch {
+				delete(w.watches, ifaceName)
+			}
+			w.mu.Unlock()
+			watch.err = fmt.Errorf("failed to receive: %v", err)
+			close(watch.done)
+			return
+		}
+		mac, sender, ok := arpSender(b[:n])
+		if !ok || bytes.Equal(mac, watch.iface.HardwareAddr) {
+			continue
+		}
+
+		w.mu.Lock()
+		conflicts := make([]*arpConflict, 0, len(watch.conflicts[sender.String()]))
+		for c := range watch.conflicts[sender.String()] {
+			conflicts = append(conflicts, c)
+		}
+		w.mu.Unlock()
+		for _, c := range conflicts {
+			c.conflict(mac)
+		}
+	}
+}
+
+// arpWatchSocket opens a socket that receives the ARP messages of the interface
+func arpWatchSocket(iface *net.Interface) (int, error) {
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_DGRAM, int(htons(syscall.ETH_P_ARP)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get raw socket: %v", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrLinklayer{Protocol: htons(syscall.ETH_P_ARP), Ifindex: iface.Index}); err != nil {
+		syscall.Close(fd)
+		return 0, fmt.Errorf("failed to bind: %v", err)
+	}
+	// The timeout allows the watch to be stopped between messages
+	timeout := syscall.NsecToTimeval(time.Second.Nanoseconds())
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &timeout); err != nil {
+		syscall.Close(fd)
+		return 0, fmt.Errorf("failed to set timeout: %v", err)
+	}
+	return fd, nil
+}
+
+// ARPWatchConflicts will call conflict whenever an ARP message from another MAC address claims the address on the
+// interface, until the context is cancelled. The VIPs of an interface share one socket, so that a node with thousands
+// of VIPs doesn't receive each ARP message on thousands of sockets.
+func ARPWatchConflicts(ctx context.Context, address, ifaceName string, conflict func(mac net.HardwareAddr)) error {
+	ip := net.ParseIP(address).To4()
+	if ip == nil {
+		return fmt.Errorf("%q is not an IPv4 address", address)
+	}
+
+	c := &arpConflict{conflict: conflict}
+	watch, err := arpConflictWatches.add(ifaceName, ip, c)
+	if err != nil {
+		return err
+	}
+	defer arpConflictWatches.remove(ifaceName, ip, c)
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-watch.done:
+		return watch.err
+	}
+}
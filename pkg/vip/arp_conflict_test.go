@@ -0,0 +1,63 @@
+//go:build linux
+// +build linux
+
+package vip
+
+import (
+	"net"
+	"testing"
+)
+
+func TestARPSender(t *testing.T) {
+	mac := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+	m, err := gratuitousARP(net.ParseIP("192.168.0.10"), mac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := m.bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender, ip, ok := arpSender(b)
+	if !ok {
+		t.Fatal("expected the ARP message to be parsed")
+	}
+	if sender.String() != mac.String() || !ip.Equal(net.ParseIP("192.168.0.10")) {
+		t.Errorf("arpSender() = %s %s, want %s 192.168.0.10", sender, ip, mac)
+	}
+
+	if _, _, ok := arpSender(b[:10]); ok {
+		t.Error("expected a truncated ARP message to be ignored")
+	}
+}
+
+func TestARPWatches(t *testing.T) {
+	w := &arpWatches{watches: map[string]*arpWatch{}}
+	web, api := &arpConflict{}, &arpConflict{}
+	first, err := w.add("lo", net.ParseIP("192.168.0.10"), web)
+	if err != nil {
+		t.Skipf("the ARP messages of the loopback interface can't be received: %v", err)
+	}
+	second, err := w.add("lo", net.ParseIP("192.168.0.11"), api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("the addresses of an interface are watched on separate sockets, want one watch of the interface")
+	}
+
+	w.remove("lo", net.ParseIP("192.168.0.10"), web)
+	if w.watches["lo"] != first {
+		t.Error("the watch of the interface was stopped while one of its addresses is still watched")
+	}
+	w.remove("lo", net.ParseIP("192.168.0.11"), api)
+	if len(w.watches) != 0 {
+		t.Errorf("watches = %v once none of the addresses are watched", w.watches)
+	}
+	select {
+	case <-first.stop:
+	default:
+		t.Error("the socket of the interface wasn't stopped")
+	}
+}
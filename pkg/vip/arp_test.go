@@ -0,0 +1,82 @@
+//go:build linux
+// +build linux
+
+package vip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestARPBatcher(t *testing.T) {
+	release := make(chan struct{})
+	sent := make(chan []net.IP, 2)
+	b := &arpBatcher{
+		pending: map[string]*arpBatch{},
+		sending: map[string]bool{},
+		send: func(ifaceName string, addresses []net.IP) error {
+			sent <- addresses
+			<-release
+			if len(addresses) > 1 {
+				return errors.New("failed")
+			}
+			return nil
+		},
+	}
+
+	first := make(chan error, 1)
+	go func() {
+		first <- b.announce("eth0", net.ParseIP("192.168.0.1"))
+	}()
+	if batch := <-sent; len(batch) != 1 {
+		t.Fatalf("first batch = %v, want only 192.168.0.1", batch)
+	}
+
+	// The addresses that are announced while the first batch is being sent are sent together
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for n := 2; n <= 4; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- b.announce("eth0", net.ParseIP(fmt.Sprintf("192.168.0.%d", n)))
+		}()
+	}
+	for {
+		b.mu.Lock()
+		queued := 0
+		if batch := b.pending["eth0"]; batch != nil {
+			queued = len(batch.addresses)
+		}
+		b.mu.Unlock()
+		if queued == 3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if err := <-first; err != nil {
+		t.Errorf("announce() error = %v for the first batch", err)
+	}
+	if batch := <-sent; len(batch) != 3 {
+		t.Errorf("second batch = %v, want the three other addresses", batch)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err == nil {
+			t.Error("announce() error = nil, want the error of its batch")
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.pending) != 0 || len(b.sending) != 0 {
+		t.Errorf("pending = %v and sending = %v once every batch has been sent", b.pending, b.sending)
+	}
+}
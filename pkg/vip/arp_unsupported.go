@@ -3,9 +3,18 @@
 
 package vip
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"net"
+)
 
 // ARPSendGratuitous is only supported on Linux, so return an error
 func ARPSendGratuitous(address, ifaceName string) error {
 	return fmt.Errorf("Unsupported on this OS")
 }
+
+// ARPWatchConflicts is only supported on Linux, so return an error
+func ARPWatchConflicts(_ context.Context, _, _ string, _ func(mac net.HardwareAddr)) error {
+	return fmt.Errorf("Unsupported on this OS")
+}
@@ -0,0 +1,90 @@
+package vip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// UnallocatedNetworks returns the smallest set of networks that covers the pools without any of the allocated
+// addresses
+func UnallocatedNetworks(pools []*net.IPNet, allocated []net.IP) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, pool := range pools {
+		networks = append(networks, subtractAddresses(pool, allocated)...)
+	}
+	return networks
+}
+
+// subtractAddresses splits the network in half until the halves don't contain any of the addresses
+func subtractAddresses(network *net.IPNet, addresses []net.IP) []*net.IPNet {
+	var inside []net.IP
+	for _, address := range addresses {
+		if network.Contains(address) {
+			inside = append(inside, address)
+		}
+	}
+	if len(inside) == 0 {
+		return []*net.IPNet{network}
+	}
+	ones, bits := network.Mask.Size()
+	if ones == bits {
+		// The address itself is allocated
+		return nil
+	}
+
+	ip := network.IP.To4()
+	if ip == nil {
+		ip = network.IP.To16()
+	}
+	mask := net.CIDRMask(ones+1, bits)
+	lower := &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	upper := &net.IPNet{IP: append(net.IP{}, lower.IP...), Mask: mask}
+	upper.IP[ones/8] |= 0x80 >> (ones % 8)
+	return append(subtractAddresses(lower, inside), subtractAddresses(upper, inside)...)
+}
+
+// SyncBlackholeRoutes will make the blackhole routes with the protocol in the table match the networks, the number of
+// routes that were added and removed is returned
+func SyncBlackholeRoutes(table, protocol int, networks []*net.IPNet) (int, int, error) {
+	filter := &netlink.Route{Table: table, Protocol: netlink.RouteProtocol(protocol), Type: unix.RTN_BLACKHOLE}
+	existing, err := netlink.RouteListFiltered(nl.FAMILY_ALL, filter, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_PROTOCOL|netlink.RT_FILTER_TYPE)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error getting blackhole routes from table [%d]: %w", table, err)
+	}
+
+	desired := map[string]bool{}
+	for _, network := range networks {
+		desired[network.String()] = true
+	}
+	current := map[string]bool{}
+	removed := 0
+	for x := range existing {
+		if existing[x].Dst == nil {
+			continue
+		}
+		if desired[existing[x].Dst.String()] {
+			current[existing[x].Dst.String()] = true
+			continue
+		}
+		if err := netlink.RouteDel(&existing[x]); err != nil {
+			return 0, removed, fmt.Errorf("error deleting blackhole route [%s]: %w", existing[x].Dst, err)
+		}
+		removed++
+	}
+	added := 0
+	for _, network := range networks {
+		if current[network.String()] {
+			continue
+		}
+		route := &netlink.Route{Dst: network, Table: table, Protocol: netlink.RouteProtocol(protocol), Type: unix.RTN_BLACKHOLE}
+		if err := netlink.RouteReplace(route); err != nil {
+			return added, removed, fmt.Errorf("error adding blackhole route [%s]: %w", network, err)
+		}
+		added++
+	}
+	return added, removed, nil
+}
@@ -0,0 +1,49 @@
+package vip
+
+import (
+	"net"
+	"slices"
+	"testing"
+)
+
+func TestUnallocatedNetworks(t *testing.T) {
+	_, pool, _ := net.ParseCIDR("192.168.10.0/29")
+	_, pool6, _ := net.ParseCIDR("fd00::/126")
+	tests := []struct {
+		name      string
+		allocated []string
+		want      []string
+	}{
+		{name: "nothing allocated", want: []string{"192.168.10.0/29", "fd00::/126"}},
+		{
+			name:      "one address",
+			allocated: []string{"192.168.10.5"},
+			want:      []string{"192.168.10.0/30", "192.168.10.4/32", "192.168.10.6/31", "fd00::/126"},
+		},
+		{
+			name:      "outside the pools",
+			allocated: []string{"10.0.0.1"},
+			want:      []string{"192.168.10.0/29", "fd00::/126"},
+		},
+		{
+			name:      "ipv6",
+			allocated: []string{"fd00::3"},
+			want:      []string{"192.168.10.0/29", "fd00::/127", "fd00::2/128"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var allocated []net.IP
+			for _, a := range tt.allocated {
+				allocated = append(allocated, net.ParseIP(a))
+			}
+			var got []string
+			for _, n := range UnallocatedNetworks([]*net.IPNet{pool, pool6}, allocated) {
+				got = append(got, n.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("UnallocatedNetworks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -16,16 +16,28 @@ type DDNSManager interface {
 	Start() (string, error)
 }
 
+// DDNSPublisher publishes the records of the hostname and the address that was allocated from DHCP, for the DNS
+// providers other than the DHCP server itself
+type DDNSPublisher interface {
+	Name() string
+	Publish(ctx context.Context, hostname, address string) error
+}
+
 type ddnsManager struct {
-	ctx     context.Context
-	network Network
+	ctx         context.Context
+	network     Network
+	publisher   DDNSPublisher
+	requestedIP string
 }
 
-// NewDDNSManager returns a newly created Dynamic DNS manager
-func NewDDNSManager(ctx context.Context, network Network) DDNSManager {
+// NewDDNSManager returns a newly created Dynamic DNS manager, the requested IP (if any) is the address that was leased
+// before (e.g. by the kube-vip that this one replaces) and is requested again
+func NewDDNSManager(ctx context.Context, network Network, publisher DDNSPublisher, requestedIP string) DDNSManager {
 	return &ddnsManager{
-		ctx:     ctx,
-		network: network,
+		ctx:         ctx,
+		network:     network,
+		publisher:   publisher,
+		requestedIP: requestedIP,
 	}
 }
 
@@ -38,7 +50,7 @@ func (ddns *ddnsManager) Start() (string, error) {
 		return "", err
 	}
 
-	client := NewDHCPClient(iface, false, "")
+	client := NewDHCPClient(iface, ddns.requestedIP != "", ddns.requestedIP)
 
 	client.WithHostName(ddns.network.DDNSHostName())
 
@@ -62,6 +74,7 @@ func (ddns *ddnsManager) Start() (string, error) {
 	if ip == "<nil>" {
 		return "", errors.New("failed to get IP from dhcp for ddns, got ip as <nil>")
 	}
+	ddns.publish(ip)
 
 	// start a go routine to stop dhclient when lose leader election
 	// also to keep read the ip from channel
@@ -75,9 +88,20 @@ func (ddns *ddnsManager) Start() (string, error) {
 				return
 			case ip := <-client.IPChannel():
 				log.Info("got ip from dhcp: ", ip)
+				ddns.publish(ip)
 			}
 		}
 	}(ddns.ctx)
 
 	return ip, nil
 }
+
+// publish updates the record of the address with the provider, a failure is logged as the VIP works without it
+func (ddns *ddnsManager) publish(ip string) {
+	if ddns.publisher == nil || ip == "" || ip == "<nil>" {
+		return
+	}
+	if err := ddns.publisher.Publish(ddns.ctx, ddns.network.DNSName(), ip); err != nil {
+		log.Errorf("unable to publish the record of [%s] with [%s] through %s: %v", ddns.network.DNSName(), ip, ddns.publisher.Name(), err)
+	}
+}
@@ -2,6 +2,10 @@ package vip
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -12,19 +16,45 @@ type IPUpdater interface {
 	Run(ctx context.Context)
 }
 
+// DefaultDNSRefreshInterval is how often the name of a VIP is resolved again, unless it is configured
+const DefaultDNSRefreshInterval = 3 * time.Second
+
+// HealthCheck returns true if the address is healthy
+type HealthCheck func(ctx context.Context, address string) bool
+
 type ipUpdater struct {
-	vip Network
+	vip      Network
+	interval time.Duration
+	// healthCheck is used to prefer a healthy address when the name resolves to several, all are healthy without one
+	healthCheck HealthCheck
+	// onChange is called once the address of the VIP has been replaced, for it to be advertised again
+	onChange func(previous, current string)
 }
 
-// NewIPUpdater creates a DNSUpdater
-func NewIPUpdater(vip Network) IPUpdater {
+// NewIPUpdater creates a DNSUpdater, the name is resolved again at the interval (the default without one)
+func NewIPUpdater(vip Network, interval time.Duration) IPUpdater {
+	return NewHealthCheckedIPUpdater(vip, interval, nil, nil)
+}
+
+// NewHealthCheckedIPUpdater creates a DNSUpdater that health checks the addresses that the name resolves to, the
+// address of the VIP is kept while it is healthy and is otherwise replaced by the first healthy one. The onChange
+// function is called when the address has been replaced.
+func NewHealthCheckedIPUpdater(vip Network, interval time.Duration, healthCheck HealthCheck, onChange func(previous, current string)) IPUpdater {
+	if interval <= 0 {
+		interval = DefaultDNSRefreshInterval
+	}
 	return &ipUpdater{
-		vip: vip,
+		vip:         vip,
+		interval:    interval,
+		healthCheck: healthCheck,
+		onChange:    onChange,
 	}
 }
 
 // Run runs the IP updater
 func (d *ipUpdater) Run(ctx context.Context) {
+	// The address has to outlive the interval, or it would expire before it is refreshed
+	d.vip.SetValidLifetime(validLifetime(d.interval))
 	go func(ctx context.Context) {
 		for {
 			select {
@@ -32,20 +62,25 @@ func (d *ipUpdater) Run(ctx context.Context) {
 				log.Infof("stop ipUpdater")
 				return
 			default:
-				mode := "ipv4"
-				if IsIPv6(d.vip.IP()) {
-					mode = "ipv6"
-				}
-
-				ip, err := LookupHost(d.vip.DNSName(), mode)
+				current := d.vip.IP()
+				ips, err := lookupFamily(d.vip.DNSName(), IsIPv6(current))
 				if err != nil {
 					log.Warnf("cannot lookup %s: %v", d.vip.DNSName(), err)
 					// fallback to renewing the existing IP
-					ip = []string{d.vip.IP()}
+					ips = []string{current}
+				}
+				ip := chooseAddress(ctx, ips, current, d.healthCheck)
+
+				if ip != current {
+					log.Infof("the address of %s has changed from %s to %s", d.vip.DNSName(), current, ip)
+					// The previous address is removed straight away, rather than left to expire
+					if err := d.vip.DeleteIP(); err != nil {
+						log.Errorf("error deleting the previous virtual IP %s: %v", current, err)
+					}
 				}
 
 				log.Infof("setting %s as an IP", ip)
-				if err := d.vip.SetIP(ip[0]); err != nil {
+				if err := d.vip.SetIP(ip); err != nil {
 					log.Errorf("setting %s as an IP: %v", ip, err)
 				}
 
@@ -53,8 +88,77 @@ func (d *ipUpdater) Run(ctx context.Context) {
 					log.Errorf("error adding virtual IP: %v", err)
 				}
 
+				if ip != current && d.onChange != nil {
+					d.onChange(current, ip)
+				}
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(d.interval):
 			}
-			time.Sleep(3 * time.Second)
 		}
 	}(ctx)
 }
+
+// lookupFamily resolves the name to all of its addresses of the family
+func lookupFamily(dnsName string, ipv6 bool) ([]string, error) {
+	result, err := net.LookupHost(dnsName)
+	if err != nil {
+		return nil, err
+	}
+	var addresses []string
+	for _, address := range result {
+		if IsIP(address) && IsIPv6(address) == ipv6 {
+			addresses = append(addresses, address)
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no address of the family of the VIP for %s", dnsName)
+	}
+	return addresses, nil
+}
+
+// chooseAddress returns the address that the VIP should have, the current address is kept while the name still
+// resolves to it and it is healthy, so that the VIP doesn't move between equally healthy addresses. The current (or
+// first) address is kept if none of them are healthy.
+func chooseAddress(ctx context.Context, addresses []string, current string, healthCheck HealthCheck) string {
+	if healthCheck == nil {
+		return addresses[0]
+	}
+	resolved := slices.Contains(addresses, current)
+	if resolved && healthCheck(ctx, current) {
+		return current
+	}
+	for _, address := range addresses {
+		if address != current && healthCheck(ctx, address) {
+			return address
+		}
+	}
+	if resolved {
+		return current
+	}
+	return addresses[0]
+}
+
+// TCPHealthCheck returns a health check that connects to the port of the address
+func TCPHealthCheck(port int) HealthCheck {
+	return func(ctx context.Context, address string) bool {
+		dialer := &net.Dialer{Timeout: 2 * time.Second}
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+		if err != nil {
+			log.Debugf("health check of %s failed: %v", address, err)
+			return false
+		}
+		conn.Close()
+		return true
+	}
+}
+
+// validLifetime returns the lifetime (in seconds) of an address that is refreshed at the interval, which is long enough
+// to survive a couple of failed lookups
+func validLifetime(interval time.Duration) int {
+	if lifetime := int(3 * interval / time.Second); lifetime > defaultValidLft {
+		return lifetime
+	}
+	return defaultValidLft
+}
@@ -0,0 +1,54 @@
+package vip
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValidLifetime(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		want     int
+	}{
+		{interval: DefaultDNSRefreshInterval, want: defaultValidLft},
+		{interval: 20 * time.Second, want: defaultValidLft},
+		{interval: 30 * time.Second, want: 90},
+		{interval: 5 * time.Minute, want: 900},
+	}
+	for _, tt := range tests {
+		if got := validLifetime(tt.interval); got != tt.want {
+			t.Errorf("validLifetime(%s) = %d, want %d", tt.interval, got, tt.want)
+		}
+	}
+}
+
+func TestChooseAddress(t *testing.T) {
+	healthy := func(addresses ...string) HealthCheck {
+		return func(_ context.Context, address string) bool {
+			return slices.Contains(addresses, address)
+		}
+	}
+	addresses := []string{"192.168.0.10", "192.168.0.11", "192.168.0.12"}
+	tests := []struct {
+		name        string
+		current     string
+		healthCheck HealthCheck
+		want        string
+	}{
+		{name: "without health checks", current: "192.168.0.11", want: "192.168.0.10"},
+		{name: "current is healthy", current: "192.168.0.11", healthCheck: healthy("192.168.0.10", "192.168.0.11"), want: "192.168.0.11"},
+		{name: "current is unhealthy", current: "192.168.0.10", healthCheck: healthy("192.168.0.12"), want: "192.168.0.12"},
+		{name: "current isn't resolved", current: "192.168.0.20", healthCheck: healthy("192.168.0.11", "192.168.0.20"), want: "192.168.0.11"},
+		{name: "nothing is healthy", current: "192.168.0.12", healthCheck: healthy(), want: "192.168.0.12"},
+		{name: "nothing is healthy and current isn't resolved", current: "192.168.0.20", healthCheck: healthy(), want: "192.168.0.10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chooseAddress(context.Background(), addresses, tt.current, tt.healthCheck); got != tt.want {
+				t.Errorf("chooseAddress() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
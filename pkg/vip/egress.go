@@ -2,6 +2,7 @@ package vip
 
 import (
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 
@@ -32,6 +33,7 @@ const Comment = "a3ViZS12aXAK=kube-vip"
 type Egress struct {
 	ipTablesClient *iptables.IPTables
 	comment        string
+	sourcePorts    string
 }
 
 func CreateIptablesClient(nftables bool, namespace string, protocol iptables.Protocol) (*Egress, error) {
@@ -78,23 +80,42 @@ func (e *Egress) DeleteMangleMarking(podIP, name string) error {
 func (e *Egress) DeleteSourceNat(podIP, vip string) error {
 	log.Infof("[egress] Removing source nat from [%s] => [%s]", podIP, vip)
 
-	exists, _ := e.ipTablesClient.Exists("nat", "POSTROUTING", "-s", podIP+"/32", "-m", "mark", "--mark", "64/64", "-j", "SNAT", "--to-source", vip, "-m", "comment", "--comment", e.comment)
+	rules := e.sourceNatRules(vip, podIP)
+	exists, _ := e.ipTablesClient.Exists("nat", "POSTROUTING", rules[0]...)
 
 	if !exists {
 		return fmt.Errorf("unable to find source Nat rule for [%s]", podIP)
 	}
-	return e.ipTablesClient.Delete("nat", "POSTROUTING", "-s", podIP+"/32", "-m", "mark", "--mark", "64/64", "-j", "SNAT", "--to-source", vip, "-m", "comment", "--comment", e.comment)
+	for _, rule := range rules {
+		if exists, _ := e.ipTablesClient.Exists("nat", "POSTROUTING", rule...); !exists {
+			continue
+		}
+		if err := e.ipTablesClient.Delete("nat", "POSTROUTING", rule...); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (e *Egress) DeleteSourceNatForDestinationPort(podIP, vip, port, proto string) error {
-	log.Infof("[egress] Adding source nat from [%s] => [%s]", podIP, vip)
+func (e *Egress) DeleteSourceNatForDestinationPort(podIP, vip string, port EgressPort) error {
+	log.Infof("[egress] Removing source nat from [%s] => [%s]", podIP, vip)
 
-	exists, _ := e.ipTablesClient.Exists("nat", "POSTROUTING", "-s", podIP+"/32", "-m", "mark", "--mark", "64/64", "-j", "SNAT", "--to-source", vip, "-p", proto, "--dport", port, "-m", "comment", "--comment", e.comment)
+	rule := e.destinationPortRule(vip, podIP, port)
+	exists, _ := e.ipTablesClient.Exists("nat", "POSTROUTING", rule...)
 
 	if !exists {
-		return fmt.Errorf("unable to find source Nat rule for [%s], with destination port [%s]", podIP, port)
+		return fmt.Errorf("unable to find source Nat rule for [%s], with destination port [%s:%s]", podIP, port.Protocol, port.iptablesPort())
 	}
-	return e.ipTablesClient.Delete("nat", "POSTROUTING", "-s", podIP+"/32", "-m", "mark", "--mark", "64/64", "-j", "SNAT", "--to-source", vip, "-p", proto, "--dport", port, "-m", "comment", "--comment", e.comment)
+	return e.ipTablesClient.Delete("nat", "POSTROUTING", rule...)
+}
+
+// destinationPortRule returns the SNAT rule from the pod to the VIP for the protocol, and the ports if there are any
+func (e *Egress) destinationPortRule(vip, podIP string, port EgressPort) []string {
+	rule := []string{"-s", podIP + hostPrefix(podIP), "-m", "mark", "--mark", "64/64", "-j", "SNAT", "--to-source", e.sourceNatTarget(vip), "-p", port.Protocol}
+	if dport := port.iptablesPort(); dport != "" {
+		rule = append(rule, "--dport", dport)
+	}
+	return append(rule, "-m", "comment", "--comment", e.comment)
 }
 
 func (e *Egress) CreateMangleChain(name string) error {
@@ -113,6 +134,60 @@ func (e *Egress) AppendReturnRulesForDestinationSubnet(name, subnet string) erro
 	return nil
 }
 
+// InsertExclusionRule adds a rule ahead of the marking rules that returns the traffic of the pod to the destination,
+// so that it isn't SNATed to the VIP
+func (e *Egress) InsertExclusionRule(name, podIP, cidr string) error {
+	log.Infof("[egress] Excluding traffic from [%s] to [%s]", podIP, cidr)
+	rule := []string{"-s", podIP + hostPrefix(podIP), "-d", cidr, "-j", "RETURN", "-m", "comment", "--comment", e.comment}
+	exists, err := e.ipTablesClient.Exists("mangle", name, rule...)
+	if err != nil || exists {
+		return err
+	}
+	return e.ipTablesClient.Insert("mangle", name, 1, rule...)
+}
+
+// DeleteExclusionRule removes the rule that returns the traffic of the pod to the destination
+func (e *Egress) DeleteExclusionRule(name, podIP, cidr string) error {
+	rule := []string{"-s", podIP + hostPrefix(podIP), "-d", cidr, "-j", "RETURN", "-m", "comment", "--comment", e.comment}
+	exists, err := e.ipTablesClient.Exists("mangle", name, rule...)
+	if err != nil || !exists {
+		return err
+	}
+	return e.ipTablesClient.Delete("mangle", name, rule...)
+}
+
+// ExclusionRFC1918 is the keyword of the private ranges in the destinations that are excluded from the egress, the
+// RFC 1918 ranges for IPv4 and the unique local addresses for IPv6
+const ExclusionRFC1918 = "rfc1918"
+
+var privateRanges = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}
+
+// EgressExclusions returns the destination CIDRs (separated by commas) that are excluded from the egress of the pod,
+// those of the pod's family
+func EgressExclusions(exclusions, podIP string) ([]string, error) {
+	var cidrs []string
+	for _, exclusion := range strings.Split(exclusions, ",") {
+		exclusion = strings.TrimSpace(exclusion)
+		candidates := []string{exclusion}
+		switch exclusion {
+		case "":
+			continue
+		case ExclusionRFC1918:
+			candidates = privateRanges
+		}
+		for _, candidate := range candidates {
+			_, network, err := net.ParseCIDR(candidate)
+			if err != nil {
+				return nil, fmt.Errorf("excluded destination [%s] has to be a CIDR or %q", exclusion, ExclusionRFC1918)
+			}
+			if (network.IP.To4() != nil) == IsIPv4(podIP) {
+				cidrs = append(cidrs, network.String())
+			}
+		}
+	}
+	return cidrs, nil
+}
+
 func (e *Egress) AppendReturnRulesForMarking(name, subnet string) error {
 	log.Infof("[egress] Marking packets on network [%s]", subnet)
 	exists, _ := e.ipTablesClient.Exists("mangle", name, "-s", subnet, "-j", "MARK", "--set-mark", "64/64", "-m", "comment", "--comment", e.comment)
@@ -137,19 +212,87 @@ func (e *Egress) InsertMangeTableIntoPrerouting(name string) error {
 
 func (e *Egress) InsertSourceNat(vip, podIP string) error {
 	log.Infof("[egress] Adding source nat from [%s] => [%s]", podIP, vip)
-	if exists, err := e.ipTablesClient.Exists("nat", "POSTROUTING", "-s", podIP+"/32", "-m", "mark", "--mark", "64/64", "-j", "SNAT", "--to-source", vip, "-m", "comment", "--comment", e.comment); err != nil {
-		return err
-	} else if exists {
-		if err2 := e.ipTablesClient.Delete("nat", "POSTROUTING", "-s", podIP+"/32", "-m", "mark", "--mark", "64/64", "-j", "SNAT", "--to-source", vip, "-m", "comment", "--comment", e.comment); err2 != nil {
-			return err2
+	for _, rule := range e.sourceNatRules(vip, podIP) {
+		if exists, err := e.ipTablesClient.Exists("nat", "POSTROUTING", rule...); err != nil {
+			return err
+		} else if exists {
+			if err2 := e.ipTablesClient.Delete("nat", "POSTROUTING", rule...); err2 != nil {
+				return err2
+			}
 		}
+
+		if err := e.ipTablesClient.Insert("nat", "POSTROUTING", 1, rule...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sourceNatRules returns the SNAT rules from the pod to the VIP, with a source port range the TCP and UDP traffic have
+// rules of their own (ahead of the rule for the other protocols) as the ports can only be set for them
+func (e *Egress) sourceNatRules(vip, podIP string) [][]string {
+	rules := [][]string{{"-s", podIP + hostPrefix(podIP), "-m", "mark", "--mark", "64/64", "-j", "SNAT", "--to-source", vip, "-m", "comment", "--comment", e.comment}}
+	if e.sourcePorts == "" {
+		return rules
+	}
+	for _, proto := range []string{"udp", "tcp"} {
+		rules = append(rules, []string{"-s", podIP + hostPrefix(podIP), "-m", "mark", "--mark", "64/64", "-p", proto, "-j", "SNAT", "--to-source", e.sourceNatTarget(vip), "-m", "comment", "--comment", e.comment})
 	}
+	return rules
+}
+
+// SetSourceNatPorts sets the range of source ports (<first>-<last>) that the traffic is SNATed to, so that several
+// egress VIPs on one node don't contend for the same ports
+func (e *Egress) SetSourceNatPorts(ports string) error {
+	if err := ValidateSourceNatPorts(ports); err != nil {
+		return err
+	}
+	e.sourcePorts = ports
+	return nil
+}
+
+// ValidateSourceNatPorts will ensure that the range of source ports is valid, an empty range leaves the ports to the
+// kernel
+func ValidateSourceNatPorts(ports string) error {
+	if ports == "" {
+		return nil
+	}
+	first, last, found := strings.Cut(ports, "-")
+	if !found {
+		last = first
+	}
+	start, err := strconv.ParseUint(first, 10, 16)
+	if err != nil || start == 0 {
+		return fmt.Errorf("source nat ports [%s] have to be a range of ports (<first>-<last>)", ports)
+	}
+	end, err := strconv.ParseUint(last, 10, 16)
+	if err != nil || end < start {
+		return fmt.Errorf("source nat ports [%s] have to be a range of ports (<first>-<last>)", ports)
+	}
+	return nil
+}
+
+// hostPrefix returns the prefix length that matches only the address, for either family
+func hostPrefix(address string) string {
+	if IsIPv6(address) {
+		return "/128"
+	}
+	return "/32"
+}
 
-	return e.ipTablesClient.Insert("nat", "POSTROUTING", 1, "-s", podIP+"/32", "-m", "mark", "--mark", "64/64", "-j", "SNAT", "--to-source", vip, "-m", "comment", "--comment", e.comment)
+// sourceNatTarget returns the target of the SNAT rules, the VIP along with the range of source ports
+func (e *Egress) sourceNatTarget(vip string) string {
+	if e.sourcePorts == "" {
+		return vip
+	}
+	if IsIPv6(vip) {
+		return "[" + vip + "]:" + e.sourcePorts
+	}
+	return vip + ":" + e.sourcePorts
 }
 
-func (e *Egress) InsertSourceNatForDestinationPort(vip, podIP, port, proto string) error {
-	log.Infof("[egress] Adding source nat from [%s] => [%s], with destination port [%s]", podIP, vip, port)
+// CleanSourceNatForVIP removes the existing SNAT rules to the VIP, before the rules of its ports are added
+func (e *Egress) CleanSourceNatForVIP(vip string) error {
 	natRules, err := e.ipTablesClient.List("nat", "POSTROUTING")
 	if err != nil {
 		return err
@@ -162,16 +305,21 @@ func (e *Egress) InsertSourceNatForDestinationPort(vip, podIP, port, proto strin
 			log.Errorf("[egress] Error removing rule [%v]", err)
 		}
 	}
+	return nil
+}
 
-	if exists, err := e.ipTablesClient.Exists("nat", "POSTROUTING", "-s", podIP+"/32", "-m", "mark", "--mark", "64/64", "-j", "SNAT", "--to-source", vip, "-p", proto, "--dport", port, "-m", "comment", "--comment", e.comment); err != nil {
+func (e *Egress) InsertSourceNatForDestinationPort(vip, podIP string, port EgressPort) error {
+	log.Infof("[egress] Adding source nat from [%s] => [%s], with destination port [%s:%s]", podIP, vip, port.Protocol, port.iptablesPort())
+	rule := e.destinationPortRule(vip, podIP, port)
+	if exists, err := e.ipTablesClient.Exists("nat", "POSTROUTING", rule...); err != nil {
 		return err
 	} else if exists {
-		if err2 := e.ipTablesClient.Delete("nat", "POSTROUTING", "-s", podIP+"/32", "-m", "mark", "--mark", "64/64", "-j", "SNAT", "--to-source", vip, "-p", proto, "--dport", port, "-m", "comment", "--comment", e.comment); err2 != nil {
+		if err2 := e.ipTablesClient.Delete("nat", "POSTROUTING", rule...); err2 != nil {
 			return err2
 		}
 	}
 
-	return e.ipTablesClient.Insert("nat", "POSTROUTING", 1, "-s", podIP+"/32", "-m", "mark", "--mark", "64/64", "-j", "SNAT", "--to-source", vip, "-p", proto, "--dport", port, "-m", "comment", "--comment", e.comment)
+	return e.ipTablesClient.Insert("nat", "POSTROUTING", 1, rule...)
 }
 
 func DeleteExistingSessions(sessionIP string, destination bool, destinationPorts, srcPorts string) error {
@@ -182,64 +330,22 @@ func DeleteExistingSessions(sessionIP string, destination bool, destinationPorts
 		return err
 	}
 	defer nfct.Close()
-	sessions, err := nfct.Dump(ct.Conntrack, ct.IPv4)
+	family := ct.IPv4
+	if IsIPv6(sessionIP) {
+		family = ct.IPv6
+	}
+	sessions, err := nfct.Dump(ct.Conntrack, family)
 	if err != nil {
 		log.Errorf("could not dump sessions: %v", err)
 		return err
 	}
-	destPortProtocol := make(map[uint16]uint8)
-	srcPortProtocol := make(map[uint16]uint8)
-
-	if destinationPorts != "" {
-		fixedPorts := strings.Split(destinationPorts, ",")
-
-		for _, fixedPort := range fixedPorts {
-
-			data := strings.Split(fixedPort, ":")
-			if len(data) == 0 {
-				continue
-			}
-			port, err := strconv.ParseUint(data[1], 10, 16)
-			if err != nil {
-				return fmt.Errorf("[egress] error parsing annotaion [%s]", destinationPorts)
-			}
-			switch data[0] {
-			case strings.ToLower("udp"):
-				destPortProtocol[uint16(port)] = ProtocolUDP
-			case strings.ToLower("tcp"):
-				destPortProtocol[uint16(port)] = ProtocolTCP
-			case strings.ToLower("sctp"):
-				destPortProtocol[uint16(port)] = ProtocolSCTP
-			default:
-				log.Errorf("[egress] annotation protocol [%s] isn't supported", data[0])
-			}
-		}
+	destPortProtocol, err := ParseEgressPorts(destinationPorts)
+	if err != nil {
+		return fmt.Errorf("[egress] error parsing annotaion [%s]: %v", destinationPorts, err)
 	}
-
-	if srcPorts != "" {
-		fixedPorts := strings.Split(srcPorts, ",")
-
-		for _, fixedPort := range fixedPorts {
-
-			data := strings.Split(fixedPort, ":")
-			if len(data) == 0 {
-				continue
-			}
-			port, err := strconv.ParseUint(data[1], 10, 16)
-			if err != nil {
-				return fmt.Errorf("[egress] error parsing annotaion [%s]", srcPorts)
-			}
-			switch data[0] {
-			case strings.ToLower("udp"):
-				srcPortProtocol[uint16(port)] = ProtocolUDP
-			case strings.ToLower("tcp"):
-				srcPortProtocol[uint16(port)] = ProtocolTCP
-			case strings.ToLower("sctp"):
-				srcPortProtocol[uint16(port)] = ProtocolSCTP
-			default:
-				log.Errorf("[egress] annotation protocol [%s] isn't supported", data[0])
-			}
-		}
+	srcPortProtocol, err := ParseEgressPorts(srcPorts)
+	if err != nil {
+		return fmt.Errorf("[egress] error parsing annotaion [%s]: %v", srcPorts, err)
 	}
 
 	// by default we only clear source (i.e. connections going from the vip (egress))
@@ -248,13 +354,12 @@ func DeleteExistingSessions(sessionIP string, destination bool, destinationPorts
 			//session.Origin.Proto
 			if session.Origin.Src.String() == sessionIP /*&& *session.Origin.Proto.DstPort == uint16(destinationPort)*/ {
 				if destinationPorts != "" {
-					proto := destPortProtocol[*session.Origin.Proto.DstPort]
-					if proto == *session.Origin.Proto.Number {
+					if matchEgressPorts(destPortProtocol, *session.Origin.Proto.Number, *session.Origin.Proto.DstPort) {
 						log.Infof("[egress] cleaning existing connection Source [%s] -> [%s:%d] proto: [%d] ", session.Origin.Src.String(), session.Origin.Dst.String(), *session.Origin.Proto.DstPort, *session.Origin.Proto.Number)
-						err = nfct.Delete(ct.Conntrack, ct.IPv4, session)
+						err = nfct.Delete(ct.Conntrack, family, session)
 					}
 				} else {
-					err = nfct.Delete(ct.Conntrack, ct.IPv4, session)
+					err = nfct.Delete(ct.Conntrack, family, session)
 				}
 				if err != nil {
 					log.Errorf("could not delete sessions: %v", err)
@@ -268,13 +373,12 @@ func DeleteExistingSessions(sessionIP string, destination bool, destinationPorts
 
 			if session.Origin.Dst.String() == sessionIP /*&& *session.Origin.Proto.DstPort == uint16(destinationPort)*/ {
 				if srcPorts != "" {
-					proto := srcPortProtocol[*session.Origin.Proto.DstPort]
-					if proto == *session.Origin.Proto.Number {
+					if matchEgressPorts(srcPortProtocol, *session.Origin.Proto.Number, *session.Origin.Proto.DstPort) {
 						log.Infof("[egress] cleaning existing connection Source [%s] -> [%s:%d] proto: [%d] ", session.Origin.Src.String(), session.Origin.Dst.String(), *session.Origin.Proto.DstPort, *session.Origin.Proto.Number)
-						err = nfct.Delete(ct.Conntrack, ct.IPv4, session)
+						err = nfct.Delete(ct.Conntrack, family, session)
 					}
 				} else {
-					err = nfct.Delete(ct.Conntrack, ct.IPv4, session)
+					err = nfct.Delete(ct.Conntrack, family, session)
 				}
 				if err != nil {
 					log.Errorf("could not delete sessions: %v", err)
@@ -286,6 +390,102 @@ func DeleteExistingSessions(sessionIP string, destination bool, destinationPorts
 	return nil
 }
 
+// DeleteEgressSessions removes the connections that are SNATed to the VIP, once the VIP has moved to another node these
+// connections would keep leaving this node with the VIP as their source (where the replies never return to) until
+// their entries expire, removing them has the pods' next packets tracked and translated by the node that holds the VIP
+func DeleteEgressSessions(vip string) (int, error) {
+	address := net.ParseIP(vip)
+	if address == nil {
+		return 0, fmt.Errorf("invalid egress VIP [%s]", vip)
+	}
+	nfct, err := ct.Open(&ct.Config{})
+	if err != nil {
+		return 0, fmt.Errorf("could not create nfct: %v", err)
+	}
+	defer nfct.Close()
+	family := ct.IPv4
+	if IsIPv6(vip) {
+		family = ct.IPv6
+	}
+	sessions, err := nfct.Dump(ct.Conntrack, family)
+	if err != nil {
+		return 0, fmt.Errorf("could not dump sessions: %v", err)
+	}
+	var deleted int
+	for _, session := range sessions {
+		if !sourceNatTo(session, address) {
+			continue
+		}
+		if err = nfct.Delete(ct.Conntrack, family, session); err != nil {
+			log.Errorf("[egress] could not delete the connection from [%s] to [%s]: %v", session.Origin.Src, session.Origin.Dst, err)
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// sourceNatTo returns true if the connection is SNATed to the address, the replies come back to the address rather than
+// to the source of the connection
+func sourceNatTo(session ct.Con, address net.IP) bool {
+	if session.Origin == nil || session.Reply == nil || session.Origin.Src == nil || session.Reply.Dst == nil {
+		return false
+	}
+	return session.Reply.Dst.Equal(address) && !session.Origin.Src.Equal(address)
+}
+
+// EgressCounters are the packets and bytes of a pod that were marked to leave from its egress VIP
+type EgressCounters struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// MarkingCounters returns the counters of the marking rules in the mangle chain, keyed by the pod address
+func (e *Egress) MarkingCounters(name string) (map[string]EgressCounters, error) {
+	rules, err := e.ipTablesClient.ListWithCounters("mangle", name)
+	if err != nil {
+		return nil, err
+	}
+	return parseMarkingCounters(rules), nil
+}
+
+// parseMarkingCounters parses the marking rules of kube-vip (-A <chain> -s <pod> ... -j MARK ... -c <packets> <bytes>)
+func parseMarkingCounters(rules []string) map[string]EgressCounters {
+	counters := map[string]EgressCounters{}
+	for _, rule := range rules {
+		if !strings.Contains(rule, Comment) {
+			continue
+		}
+		r := strings.Fields(rule)
+		var source string
+		var marking bool
+		var counter EgressCounters
+		for x := range r {
+			switch r[x] {
+			case "MARK":
+				marking = true
+			case "-s":
+				if x+1 < len(r) {
+					source, _, _ = strings.Cut(r[x+1], "/")
+				}
+			case "-c":
+				if x+2 < len(r) {
+					counter.Packets, _ = strconv.ParseUint(r[x+1], 10, 64)
+					counter.Bytes, _ = strconv.ParseUint(r[x+2], 10, 64)
+				}
+			}
+		}
+		if !marking || source == "" {
+			continue
+		}
+		total := counters[source]
+		total.Packets += counter.Packets
+		total.Bytes += counter.Bytes
+		counters[source] = total
+	}
+	return counters
+}
+
 // Debug functions
 
 func (e *Egress) DumpChain(name string) error {
@@ -367,8 +567,8 @@ func (e *Egress) findExistingVIP(rules []string, vip string) [][]string {
 	for i := range rules {
 		r := strings.Split(rules[i], " ")
 		for x := range r {
-			// Look for a vip already in a post Routing rule
-			if r[x] == vip {
+			// Look for a vip already in a post Routing rule, it can be followed by the range of source ports
+			if r[x] == vip || strings.HasPrefix(r[x], vip+":") || strings.HasPrefix(r[x], "["+vip+"]:") {
 				foundRules = append(foundRules, r)
 			}
 		}
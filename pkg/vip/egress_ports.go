@@ -0,0 +1,85 @@
+package vip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EgressPort is a protocol, along with a destination port or range of ports, whose traffic is SNATed to the egress VIP.
+// Without a port all of the traffic of the protocol is SNATed.
+type EgressPort struct {
+	Protocol string
+	// First and Last are the range of ports, both are zero for all of the ports
+	First uint16
+	Last  uint16
+}
+
+// egressProtocols are the protocols that can be SNATed on their own, with their numbers in the connection tracking
+var egressProtocols = map[string]uint8{
+	"tcp":  ProtocolTCP,
+	"udp":  ProtocolUDP,
+	"sctp": ProtocolSCTP,
+}
+
+// ParseEgressPorts parses a list of ports separated by commas, each one in the format protocol, protocol:port,
+// protocol:first-last or port (a TCP port)
+func ParseEgressPorts(ports string) ([]EgressPort, error) {
+	var parsed []EgressPort
+	for _, p := range strings.Split(ports, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		protocol, portRange, found := strings.Cut(p, ":")
+		protocol = strings.ToLower(protocol)
+		if !found {
+			if _, known := egressProtocols[protocol]; known {
+				parsed = append(parsed, EgressPort{Protocol: protocol})
+				continue
+			}
+			protocol, portRange = "tcp", p
+		}
+		if _, known := egressProtocols[protocol]; !known {
+			return nil, fmt.Errorf("invalid port [%s], unknown protocol [%s]", p, protocol)
+		}
+		first, last, isRange := strings.Cut(portRange, "-")
+		if !isRange {
+			last = first
+		}
+		start, err := strconv.ParseUint(first, 10, 16)
+		if err != nil || start == 0 {
+			return nil, fmt.Errorf("invalid port [%s]", p)
+		}
+		end, err := strconv.ParseUint(last, 10, 16)
+		if err != nil || end < start {
+			return nil, fmt.Errorf("invalid port [%s]", p)
+		}
+		parsed = append(parsed, EgressPort{Protocol: protocol, First: uint16(start), Last: uint16(end)})
+	}
+	return parsed, nil
+}
+
+// iptablesPort returns the port in the format of the --dport of iptables, or nothing for all of the ports
+func (p EgressPort) iptablesPort() string {
+	switch {
+	case p.First == 0:
+		return ""
+	case p.First == p.Last:
+		return strconv.Itoa(int(p.First))
+	}
+	return fmt.Sprintf("%d:%d", p.First, p.Last)
+}
+
+// matchEgressPorts returns true if the protocol and port of a connection are in the ports
+func matchEgressPorts(ports []EgressPort, protocol uint8, port uint16) bool {
+	for _, p := range ports {
+		if egressProtocols[p.Protocol] != protocol {
+			continue
+		}
+		if p.First == 0 || (port >= p.First && port <= p.Last) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package vip
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEgressPorts(t *testing.T) {
+	tests := []struct {
+		ports   string
+		want    []EgressPort
+		invalid bool
+	}{
+		{"", nil, false},
+		{"tcp:80,udp:53", []EgressPort{{"tcp", 80, 80}, {"udp", 53, 53}}, false},
+		{"udp", []EgressPort{{Protocol: "udp"}}, false},
+		{"TCP:8000-9000, 443", []EgressPort{{"tcp", 8000, 9000}, {"tcp", 443, 443}}, false},
+		{"http:80", nil, true},
+		{"tcp:9000-8000", nil, true},
+		{"tcp:0", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ports, func(t *testing.T) {
+			got, err := ParseEgressPorts(tt.ports)
+			if (err != nil) != tt.invalid {
+				t.Fatalf("ParseEgressPorts() error = %v, invalid %v", err, tt.invalid)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEgressPorts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEgressPortRules(t *testing.T) {
+	e := Egress{comment: Comment + "-" + "default"}
+	ports, _ := ParseEgressPorts("udp,tcp:8000-9000")
+	if got := e.destinationPortRule("192.168.0.10", "10.0.0.5", ports[0]); len(got) != 16 {
+		t.Errorf("destinationPortRule() = %v, want no destination port for all of UDP", got)
+	}
+	if got := e.destinationPortRule("192.168.0.10", "10.0.0.5", ports[1]); got[13] != "8000:9000" {
+		t.Errorf("destinationPortRule() = %v, want the range of ports", got)
+	}
+	if !matchEgressPorts(ports, ProtocolUDP, 53) || !matchEgressPorts(ports, ProtocolTCP, 8080) {
+		t.Error("matchEgressPorts() = false, want UDP and the range of TCP ports to match")
+	}
+	if matchEgressPorts(ports, ProtocolTCP, 443) || matchEgressPorts(ports, ProtocolSCTP, 8080) {
+		t.Error("matchEgressPorts() = true, want other ports and protocols not to match")
+	}
+}
@@ -2,8 +2,11 @@ package vip
 
 import (
 	"fmt"
+	"net"
 	"reflect"
 	"testing"
+
+	ct "github.com/florianl/go-conntrack"
 )
 
 func Test_findRules(t *testing.T) {
@@ -34,3 +37,129 @@ func Test_findRules(t *testing.T) {
 		})
 	}
 }
+
+func TestSourceNatPorts(t *testing.T) {
+	tests := []struct {
+		ports   string
+		vip     string
+		target  string
+		invalid bool
+	}{
+		{"", "192.168.0.10", "192.168.0.10", false},
+		{"20000-29999", "192.168.0.10", "192.168.0.10:20000-29999", false},
+		{"30000", "fd00::10", "[fd00::10]:30000", false},
+		{"29999-20000", "192.168.0.10", "", true},
+		{"0-100", "192.168.0.10", "", true},
+		{"20000-70000", "192.168.0.10", "", true},
+		{"tcp:80", "192.168.0.10", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ports, func(t *testing.T) {
+			e := Egress{comment: Comment + "-" + "default"}
+			err := e.SetSourceNatPorts(tt.ports)
+			if (err != nil) != tt.invalid {
+				t.Fatalf("SetSourceNatPorts(%q) error = %v, invalid %v", tt.ports, err, tt.invalid)
+			}
+			if err != nil {
+				return
+			}
+			if got := e.sourceNatTarget(tt.vip); got != tt.target {
+				t.Errorf("sourceNatTarget() = %s, want %s", got, tt.target)
+			}
+			podIP, source := "10.0.0.5", "10.0.0.5/32"
+			if IsIPv6(tt.vip) {
+				podIP, source = "fd00:10::5", "fd00:10::5/128"
+			}
+			rules := e.sourceNatRules(tt.vip, podIP)
+			if rules[0][1] != source {
+				t.Errorf("sourceNatRules() source = %s, want %s", rules[0][1], source)
+			}
+			if want := map[bool]int{true: 1, false: 3}[tt.ports == ""]; len(rules) != want {
+				t.Errorf("sourceNatRules() = %d rules, want %d", len(rules), want)
+			}
+		})
+	}
+}
+
+func Test_findExistingVIP(t *testing.T) {
+	e := Egress{comment: Comment + "-" + "default"}
+	rules := []string{
+		"-A POSTROUTING -s 10.0.0.5/32 -m mark --mark 0x40/0x40 -j SNAT --to-source 192.168.0.10",
+		"-A POSTROUTING -s 10.0.0.5/32 -p tcp -m mark --mark 0x40/0x40 -j SNAT --to-source 192.168.0.10:20000-29999",
+		"-A POSTROUTING -s 10.0.0.6/32 -m mark --mark 0x40/0x40 -j SNAT --to-source 192.168.0.100",
+	}
+	if got := e.findExistingVIP(rules, "192.168.0.10"); len(got) != 2 {
+		t.Errorf("findExistingVIP() = %d rules, want 2", len(got))
+	}
+}
+
+func Test_sourceNatTo(t *testing.T) {
+	tuple := func(src, dst string) *ct.IPTuple {
+		s, d := net.ParseIP(src), net.ParseIP(dst)
+		return &ct.IPTuple{Src: &s, Dst: &d}
+	}
+	vip := net.ParseIP("192.168.0.10")
+	tests := []struct {
+		name    string
+		session ct.Con
+		want    bool
+	}{
+		{"snat to the vip", ct.Con{Origin: tuple("10.0.0.5", "1.1.1.1"), Reply: tuple("1.1.1.1", "192.168.0.10")}, true},
+		{"snat to the node", ct.Con{Origin: tuple("10.0.0.5", "1.1.1.1"), Reply: tuple("1.1.1.1", "192.168.0.2")}, false},
+		{"from the vip", ct.Con{Origin: tuple("192.168.0.10", "1.1.1.1"), Reply: tuple("1.1.1.1", "192.168.0.10")}, false},
+		{"to the vip", ct.Con{Origin: tuple("1.1.1.1", "192.168.0.10"), Reply: tuple("10.0.0.5", "1.1.1.1")}, false},
+		{"no reply", ct.Con{Origin: tuple("10.0.0.5", "1.1.1.1")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sourceNatTo(tt.session, vip); got != tt.want {
+				t.Errorf("sourceNatTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseMarkingCounters(t *testing.T) {
+	comment := Comment + "-" + "default"
+	rules := []string{
+		"-N KUBE-VIP-EGRESS",
+		fmt.Sprintf("-A KUBE-VIP-EGRESS -d 10.0.0.0/16 -m comment --comment \"%s\" -j RETURN -c 50 4000", comment),
+		fmt.Sprintf("-A KUBE-VIP-EGRESS -s 10.0.0.5/32 -m comment --comment \"%s\" -j MARK --set-xmark 0x40/0x40 -c 12 1008", comment),
+		fmt.Sprintf("-A KUBE-VIP-EGRESS -s fd00:10::5/128 -m comment --comment \"%s\" -j MARK --set-xmark 0x40/0x40 -c 3 240", comment),
+		"-A KUBE-VIP-EGRESS -s 10.0.0.6/32 -j MARK --set-xmark 0x40/0x40 -c 7 700",
+	}
+	want := map[string]EgressCounters{
+		"10.0.0.5":   {Packets: 12, Bytes: 1008},
+		"fd00:10::5": {Packets: 3, Bytes: 240},
+	}
+	if got := parseMarkingCounters(rules); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMarkingCounters() = %v, want %v", got, want)
+	}
+}
+
+func TestEgressExclusions(t *testing.T) {
+	tests := []struct {
+		name       string
+		exclusions string
+		podIP      string
+		want       []string
+		invalid    bool
+	}{
+		{"none", "", "10.0.0.5", nil, false},
+		{"private ranges", "rfc1918", "10.0.0.5", []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}, false},
+		{"private ranges of ipv6", "rfc1918", "fd00:10::5", []string{"fc00::/7"}, false},
+		{"partners", "203.0.113.0/24, 2001:db8::/32", "10.0.0.5", []string{"203.0.113.0/24"}, false},
+		{"address", "203.0.113.1", "10.0.0.5", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EgressExclusions(tt.exclusions, tt.podIP)
+			if (err != nil) != tt.invalid {
+				t.Fatalf("EgressExclusions() error = %v, invalid %v", err, tt.invalid)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EgressExclusions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package vip
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/google/nftables"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/iptables"
+)
+
+// The backends that manage the firewall rules of kube-vip
+const (
+	// FirewallIptables manages the rules with the iptables binaries, in their legacy or nft mode
+	FirewallIptables = "iptables"
+	// FirewallNftables manages the rules natively with nftables over netlink, without any binaries
+	FirewallNftables = "nftables"
+)
+
+// EgressRules manages the rules that mark the traffic of the pods and SNAT it to their egress VIPs
+type EgressRules interface {
+	SetSourceNatPorts(ports string) error
+
+	CheckMangleChain(name string) (bool, error)
+	CreateMangleChain(name string) error
+	DeleteMangleChain(name string) error
+	InsertMangleTableIntoPrerouting(name string) error
+	DeleteManglePrerouting(name string) error
+
+	AppendReturnRulesForDestinationSubnet(name, subnet string) error
+	InsertExclusionRule(name, podIP, cidr string) error
+	DeleteExclusionRule(name, podIP, cidr string) error
+	AppendReturnRulesForMarking(name, subnet string) error
+	DeleteMangleMarking(podIP, name string) error
+	MarkingCounters(name string) (map[string]EgressCounters, error)
+
+	InsertSourceNat(vip, podIP string) error
+	DeleteSourceNat(podIP, vip string) error
+	InsertSourceNatForDestinationPort(vip, podIP string, port EgressPort) error
+	DeleteSourceNatForDestinationPort(podIP, vip string, port EgressPort) error
+	CleanSourceNatForVIP(vip string) error
+
+	CleanRules() error
+}
+
+// serviceRules manages the rules of the VIP of a service, those that limit its traffic to the ports of the service and
+// those that masquerade the traffic that IPVS balances from it
+type serviceRules interface {
+	limitPorts(vip, comment string, ports []v1.ServicePort) error
+	removePortLimits(vip, comment string, ports []v1.ServicePort) error
+	masquerade(vip, comment string) error
+	removeMasquerade(vip, comment string) error
+}
+
+var (
+	detectFirewall   sync.Once
+	detectedFirewall string
+)
+
+// FirewallBackend returns the backend that manages the rules, the configured backend or (when it isn't set) the one
+// that the rules of the node are already in
+func FirewallBackend(backend string) string {
+	if backend != "" {
+		return backend
+	}
+	detectFirewall.Do(func() {
+		detectedFirewall = detectFirewallBackend(nftablesRuleset(), legacyIptablesRules())
+	})
+	return detectedFirewall
+}
+
+// detectFirewallBackend picks nftables when the node already has an nftables ruleset (of nft, or of iptables in its
+// nft mode) or when the legacy iptables tables are empty, and iptables when only the legacy tables have rules (e.g.
+// kube-proxy in the legacy mode), as the rules of kube-vip have to be in the same tables as those of the node
+func detectFirewallBackend(nftRuleset, legacyRules bool) string {
+	switch {
+	case nftRuleset && legacyRules:
+		log.Warnf("[firewall] the node has both an nftables ruleset and legacy iptables rules, the rules are managed with %s (set the firewall backend to %s for the legacy tables)", FirewallNftables, FirewallIptables)
+		return FirewallNftables
+	case nftRuleset:
+		log.Infof("[firewall] the node has an nftables ruleset, the rules are managed with %s", FirewallNftables)
+		return FirewallNftables
+	case legacyRules:
+		log.Infof("[firewall] the node has legacy iptables rules, the rules are managed with %s", FirewallIptables)
+		return FirewallIptables
+	}
+	log.Infof("[firewall] the node has no rules, the rules are managed with %s", FirewallNftables)
+	return FirewallNftables
+}
+
+// nftablesRuleset returns if the node has any nftables tables
+func nftablesRuleset() bool {
+	conn, err := nftables.New()
+	if err != nil {
+		return false
+	}
+	tables, err := conn.ListTables()
+	if err != nil {
+		log.Debugf("[firewall] unable to list the nftables tables: %v", err)
+		return false
+	}
+	return len(tables) != 0
+}
+
+// legacyIptablesRules returns if the legacy iptables tables of either family have any rules, they are empty when the
+// legacy binaries aren't installed
+func legacyIptablesRules() bool {
+	for _, binary := range []string{"iptables-legacy-save", "ip6tables-legacy-save"} {
+		out, err := exec.Command(binary).Output()
+		if err != nil {
+			continue
+		}
+		if hasRules(string(out)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRules returns if the output of iptables-save has any rules, rather than only the policies of the built-in chains
+func hasRules(save string) bool {
+	for _, line := range strings.Split(save, "\n") {
+		if strings.HasPrefix(line, "-A ") {
+			return true
+		}
+	}
+	return false
+}
+
+// NewEgressRules creates the egress rules of the namespace with the backend, nftables selects the nft mode of the
+// iptables backend
+func NewEgressRules(backend string, nftables bool, namespace string, protocol iptables.Protocol) (EgressRules, error) {
+	switch FirewallBackend(backend) {
+	case FirewallNftables:
+		e, err := newNftablesEgress(namespace, protocol)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	case FirewallIptables:
+		e, err := CreateIptablesClient(nftables, namespace, protocol)
+		if err != nil {
+			return nil, err
+		}
+		return iptablesEgress{e}, nil
+	}
+	return nil, fmt.Errorf("unknown firewall backend [%s]", backend)
+}
+
+// newServiceRules creates the rules of the VIPs of the services with the backend
+func newServiceRules(backend string) (serviceRules, error) {
+	switch FirewallBackend(backend) {
+	case FirewallNftables:
+		return nftablesServiceRules{}, nil
+	case FirewallIptables:
+		return iptablesServiceRules{}, nil
+	}
+	return nil, fmt.Errorf("unknown firewall backend [%s]", backend)
+}
@@ -0,0 +1,14 @@
+package vip
+
+// iptablesEgress manages the egress rules with the iptables client, whose methods keep the names they have always had
+type iptablesEgress struct {
+	*Egress
+}
+
+func (e iptablesEgress) InsertMangleTableIntoPrerouting(name string) error {
+	return e.InsertMangeTableIntoPrerouting(name)
+}
+
+func (e iptablesEgress) CleanRules() error {
+	return e.CleanIPtables()
+}
@@ -0,0 +1,776 @@
+package vip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/expr"
+	"github.com/google/nftables/userdata"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/iptables"
+)
+
+// nftablesTable is the table that holds all of the rules of kube-vip, there is one in the ip and one in the ip6 family
+const nftablesTable = "kube-vip"
+
+// The base chains of the table, the egress chain is a regular chain that the prerouting chain jumps to
+const (
+	nftablesPrerouting  = "prerouting"
+	nftablesOutput      = "output"
+	nftablesInput       = "input"
+	nftablesPostrouting = "postrouting"
+)
+
+const (
+	// egressMark marks the packets of the pods that are SNATed to their egress VIP, as with the iptables rules
+	egressMark = 0x40
+	// masqueradeMark marks the packets to a VIP that IPVS masquerades, nftables has no match of the virtual address of
+	// IPVS so the packets are marked before IPVS forwards them
+	masqueradeMark = 0x80
+)
+
+// nftablesRules manages the rules of one family in the table of kube-vip, every rule has a comment (the comment of
+// kube-vip and a key) that it is found by
+type nftablesRules struct {
+	conn    *nftables.Conn
+	table   *nftables.Table
+	ipv6    bool
+	comment string
+}
+
+func newNftablesRules(comment string, ipv6 bool, options ...nftables.ConnOption) (*nftablesRules, error) {
+	conn, err := nftables.New(options...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create nftables client: %w", err)
+	}
+	family := nftables.TableFamilyIPv4
+	if ipv6 {
+		family = nftables.TableFamilyIPv6
+	}
+	return &nftablesRules{
+		conn:    conn,
+		table:   &nftables.Table{Name: nftablesTable, Family: family},
+		ipv6:    ipv6,
+		comment: comment,
+	}, nil
+}
+
+// chain returns the chain of the table, the base chains are hooked as the tables and chains of iptables are
+func (n *nftablesRules) chain(name string) *nftables.Chain {
+	chain := &nftables.Chain{Name: name, Table: n.table}
+	switch name {
+	case nftablesPrerouting:
+		chain.Type, chain.Hooknum, chain.Priority = nftables.ChainTypeFilter, nftables.ChainHookPrerouting, nftables.ChainPriorityMangle
+	case nftablesOutput:
+		chain.Type, chain.Hooknum, chain.Priority = nftables.ChainTypeRoute, nftables.ChainHookOutput, nftables.ChainPriorityMangle
+	case nftablesInput:
+		chain.Type, chain.Hooknum, chain.Priority = nftables.ChainTypeFilter, nftables.ChainHookInput, nftables.ChainPriorityFilter
+	case nftablesPostrouting:
+		chain.Type, chain.Hooknum, chain.Priority = nftables.ChainTypeNAT, nftables.ChainHookPostrouting, nftables.ChainPriorityNATSource
+	}
+	return chain
+}
+
+// ensureChain adds the table and the chain, if they don't already exist
+func (n *nftablesRules) ensureChain(name string) (*nftables.Chain, error) {
+	n.conn.AddTable(n.table)
+	chain := n.conn.AddChain(n.chain(name))
+	if err := n.conn.Flush(); err != nil {
+		return nil, fmt.Errorf("could not add chain [%s]: %w", name, err)
+	}
+	return chain, nil
+}
+
+func (n *nftablesRules) chainExists(name string) (bool, error) {
+	chains, err := n.conn.ListChainsOfTableFamily(n.table.Family)
+	if err != nil {
+		return false, err
+	}
+	for _, chain := range chains {
+		if chain.Table.Name == nftablesTable && chain.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rules returns the rules of the chain, there are none if the chain doesn't exist
+func (n *nftablesRules) rules(name string) ([]*nftables.Rule, error) {
+	exists, err := n.chainExists(name)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return n.conn.GetRules(n.table, n.chain(name))
+}
+
+// ruleComment returns the comment of a rule, the comment of kube-vip is followed by the key of the rule
+func ruleComment(rule *nftables.Rule) string {
+	comment, _ := userdata.GetString(rule.UserData, userdata.TypeComment)
+	return comment
+}
+
+// find returns the rule of the chain with the key, nil if there isn't one
+func (n *nftablesRules) find(chain, key string) (*nftables.Rule, error) {
+	rules, err := n.rules(chain)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if ruleComment(rule) == n.comment+" "+key {
+			return rule, nil
+		}
+	}
+	return nil, nil
+}
+
+// add adds the rule with the key to the chain (at the top of the chain if first is set), unless the rule exists
+func (n *nftablesRules) add(chain, key string, first bool, exprs []expr.Any) error {
+	existing, err := n.find(chain, key)
+	if err != nil || existing != nil {
+		return err
+	}
+	c, err := n.ensureChain(chain)
+	if err != nil {
+		return err
+	}
+	rule := &nftables.Rule{
+		Table:    n.table,
+		Chain:    c,
+		Exprs:    exprs,
+		UserData: userdata.AppendString(nil, userdata.TypeComment, n.comment+" "+key),
+	}
+	if first {
+		n.conn.InsertRule(rule)
+	} else {
+		n.conn.AddRule(rule)
+	}
+	if err = n.conn.Flush(); err != nil {
+		return fmt.Errorf("could not add rule [%s] to chain [%s]: %w", key, chain, err)
+	}
+	return nil
+}
+
+// insert moves the rule with the key to the top of the chain, it is added if it doesn't exist
+func (n *nftablesRules) insert(chain, key string, exprs []expr.Any) error {
+	if _, err := n.remove(chain, key); err != nil {
+		return err
+	}
+	return n.add(chain, key, true, exprs)
+}
+
+// remove removes the rule with the key from the chain, returning false if there was no such rule
+func (n *nftablesRules) remove(chain, key string) (bool, error) {
+	rule, err := n.find(chain, key)
+	if err != nil || rule == nil {
+		return false, err
+	}
+	if err = n.deleteRules(rule); err != nil {
+		return false, fmt.Errorf("could not delete rule [%s] from chain [%s]: %w", key, chain, err)
+	}
+	return true, nil
+}
+
+func (n *nftablesRules) deleteRules(rules ...*nftables.Rule) error {
+	for _, rule := range rules {
+		if err := n.conn.DelRule(rule); err != nil {
+			return err
+		}
+	}
+	return n.conn.Flush()
+}
+
+// address matches the source (or the destination) of the packets against the prefix
+func (n *nftablesRules) address(source bool, prefix netip.Prefix) ([]expr.Any, error) {
+	if prefix.Addr().Is6() != n.ipv6 {
+		return nil, fmt.Errorf("address [%s] isn't of the family of the rules", prefix)
+	}
+	offset, length := uint32(16), uint32(4)
+	if source {
+		offset = 12
+	}
+	if n.ipv6 {
+		offset, length = 24, 16
+		if source {
+			offset = 8
+		}
+	}
+	exprs := []expr.Any{&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: offset, Len: length}}
+	if bits := prefix.Addr().BitLen(); prefix.Bits() < bits {
+		exprs = append(exprs, &expr.Bitwise{
+			SourceRegister: 1,
+			DestRegister:   1,
+			Len:            length,
+			Mask:           net.CIDRMask(prefix.Bits(), bits),
+			Xor:            make([]byte, length),
+		})
+	}
+	return append(exprs, &expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: prefix.Masked().Addr().AsSlice()}), nil
+}
+
+// parsePrefix parses a CIDR, or an address as the prefix of only that address
+func parsePrefix(cidr string) (netip.Prefix, error) {
+	if strings.Contains(cidr, "/") {
+		prefix, err := netip.ParsePrefix(cidr)
+		return prefix.Masked(), err
+	}
+	address, err := netip.ParseAddr(cidr)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(address, address.BitLen()), nil
+}
+
+// matchMark matches the packets that have the bits of the mark set
+func matchMark(mark uint32) []expr.Any {
+	return []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyMARK, Register: 1},
+		&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: 4, Mask: binary.NativeEndian.AppendUint32(nil, mark), Xor: make([]byte, 4)},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: binary.NativeEndian.AppendUint32(nil, mark)},
+	}
+}
+
+// setMark sets the bits of the mark, the other bits are left as they are (as with --set-mark <mark>/<mark>)
+func setMark(mark uint32) []expr.Any {
+	return []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyMARK, Register: 1},
+		&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: 4, Mask: binary.NativeEndian.AppendUint32(nil, ^mark), Xor: binary.NativeEndian.AppendUint32(nil, mark)},
+		&expr.Meta{Key: expr.MetaKeyMARK, SourceRegister: true, Register: 1},
+	}
+}
+
+// matchProtocol matches the packets of the transport protocol
+func matchProtocol(protocol string) ([]expr.Any, error) {
+	number, known := egressProtocols[strings.ToLower(protocol)]
+	if !known {
+		return nil, fmt.Errorf("unknown protocol [%s]", protocol)
+	}
+	return []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{number}},
+	}, nil
+}
+
+// matchDestinationPorts matches the packets to the range of ports, the protocol has to be matched ahead of it
+func matchDestinationPorts(first, last uint16) []expr.Any {
+	exprs := []expr.Any{&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: 2, Len: 2}}
+	if first == last {
+		return append(exprs, &expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: binary.BigEndian.AppendUint16(nil, first)})
+	}
+	return append(exprs, &expr.Range{Op: expr.CmpOpEq, Register: 1, FromData: binary.BigEndian.AppendUint16(nil, first), ToData: binary.BigEndian.AppendUint16(nil, last)})
+}
+
+// sourceNat SNATs the packets to the address, and to the range of source ports if there is one
+func (n *nftablesRules) sourceNat(address, ports string) ([]expr.Any, error) {
+	to, err := netip.ParseAddr(address)
+	if err != nil || to.Is6() != n.ipv6 {
+		return nil, fmt.Errorf("invalid source nat address [%s]", address)
+	}
+	nat := &expr.NAT{Type: expr.NATTypeSourceNAT, Family: uint32(n.table.Family), RegAddrMin: 1}
+	exprs := []expr.Any{&expr.Immediate{Register: 1, Data: to.AsSlice()}}
+	if ports != "" {
+		first, last, found := strings.Cut(ports, "-")
+		if !found {
+			last = first
+		}
+		start, _ := strconv.ParseUint(first, 10, 16)
+		end, _ := strconv.ParseUint(last, 10, 16)
+		exprs = append(exprs,
+			&expr.Immediate{Register: 2, Data: binary.BigEndian.AppendUint16(nil, uint16(start))},
+			&expr.Immediate{Register: 3, Data: binary.BigEndian.AppendUint16(nil, uint16(end))},
+		)
+		nat.RegProtoMin, nat.RegProtoMax = 2, 3
+	}
+	return append(exprs, nat), nil
+}
+
+// nftablesEgress manages the egress rules natively with nftables, the rules are those of the iptables egress
+type nftablesEgress struct {
+	*nftablesRules
+	sourcePorts string
+}
+
+func newNftablesEgress(namespace string, protocol iptables.Protocol, options ...nftables.ConnOption) (*nftablesEgress, error) {
+	log.Infof("[egress] Creating an nftables client, IPv6 [%t]", protocol == iptables.ProtocolIPv6)
+	comment := Comment
+	if namespace != "" {
+		comment += "-" + namespace
+	}
+	rules, err := newNftablesRules(comment, protocol == iptables.ProtocolIPv6, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &nftablesEgress{nftablesRules: rules}, nil
+}
+
+// SetSourceNatPorts sets the range of source ports (<first>-<last>) that the traffic is SNATed to
+func (e *nftablesEgress) SetSourceNatPorts(ports string) error {
+	if err := ValidateSourceNatPorts(ports); err != nil {
+		return err
+	}
+	e.sourcePorts = ports
+	return nil
+}
+
+func (e *nftablesEgress) CheckMangleChain(name string) (bool, error) {
+	log.Infof("[egress] Checking for Chain [%s]", name)
+	return e.chainExists(name)
+}
+
+func (e *nftablesEgress) CreateMangleChain(name string) error {
+	log.Infof("[egress] Creating Chain [%s]", name)
+	_, err := e.ensureChain(name)
+	return err
+}
+
+func (e *nftablesEgress) DeleteMangleChain(name string) error {
+	if err := e.DeleteManglePrerouting(name); err != nil {
+		return err
+	}
+	exists, err := e.chainExists(name)
+	if err != nil || !exists {
+		return err
+	}
+	chain := e.chain(name)
+	e.conn.FlushChain(chain)
+	e.conn.DelChain(chain)
+	return e.conn.Flush()
+}
+
+func (e *nftablesEgress) InsertMangleTableIntoPrerouting(name string) error {
+	log.Infof("[egress] Adding jump from mangle prerouting to [%s]", name)
+	return e.insert(nftablesPrerouting, "jump "+name, []expr.Any{&expr.Verdict{Kind: expr.VerdictJump, Chain: name}})
+}
+
+func (e *nftablesEgress) DeleteManglePrerouting(name string) error {
+	_, err := e.remove(nftablesPrerouting, "jump "+name)
+	return err
+}
+
+func (e *nftablesEgress) AppendReturnRulesForDestinationSubnet(name, subnet string) error {
+	log.Infof("[egress] Adding jump for subnet [%s] to RETURN to previous chain/rules", subnet)
+	prefix, err := parsePrefix(subnet)
+	if err != nil {
+		return err
+	}
+	exprs, err := e.address(false, prefix)
+	if err != nil {
+		return err
+	}
+	return e.add(name, "return daddr "+prefix.String(), false, append(exprs, &expr.Verdict{Kind: expr.VerdictReturn}))
+}
+
+// exclusionRule returns the key and the expressions of the rule that returns the traffic of the pod to the destination
+func (e *nftablesEgress) exclusionRule(podIP, cidr string) (string, []expr.Any, error) {
+	pod, err := parsePrefix(podIP)
+	if err != nil {
+		return "", nil, err
+	}
+	destination, err := parsePrefix(cidr)
+	if err != nil {
+		return "", nil, err
+	}
+	source, err := e.address(true, pod)
+	if err != nil {
+		return "", nil, err
+	}
+	dest, err := e.address(false, destination)
+	if err != nil {
+		return "", nil, err
+	}
+	exprs := append(append(source, dest...), &expr.Verdict{Kind: expr.VerdictReturn})
+	return fmt.Sprintf("exclude %s daddr %s", pod, destination), exprs, nil
+}
+
+func (e *nftablesEgress) InsertExclusionRule(name, podIP, cidr string) error {
+	log.Infof("[egress] Excluding traffic from [%s] to [%s]", podIP, cidr)
+	key, exprs, err := e.exclusionRule(podIP, cidr)
+	if err != nil {
+		return err
+	}
+	return e.add(name, key, true, exprs)
+}
+
+func (e *nftablesEgress) DeleteExclusionRule(name, podIP, cidr string) error {
+	key, _, err := e.exclusionRule(podIP, cidr)
+	if err != nil {
+		return err
+	}
+	_, err = e.remove(name, key)
+	return err
+}
+
+func (e *nftablesEgress) AppendReturnRulesForMarking(name, subnet string) error {
+	log.Infof("[egress] Marking packets on network [%s]", subnet)
+	prefix, err := parsePrefix(subnet)
+	if err != nil {
+		return err
+	}
+	exprs, err := e.address(true, prefix)
+	if err != nil {
+		return err
+	}
+	exprs = append(append(exprs, &expr.Counter{}), setMark(egressMark)...)
+	return e.add(name, "mark "+prefix.String(), false, exprs)
+}
+
+func (e *nftablesEgress) DeleteMangleMarking(podIP, name string) error {
+	log.Infof("[egress] Stopping marking packets on network [%s]", podIP)
+	prefix, err := parsePrefix(podIP)
+	if err != nil {
+		return err
+	}
+	removed, err := e.remove(name, "mark "+prefix.String())
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("unable to find source Mangle rule for [%s]", podIP)
+	}
+	return nil
+}
+
+// MarkingCounters returns the counters of the marking rules in the egress chain, keyed by the pod address
+func (e *nftablesEgress) MarkingCounters(name string) (map[string]EgressCounters, error) {
+	rules, err := e.rules(name)
+	if err != nil {
+		return nil, err
+	}
+	counters := map[string]EgressCounters{}
+	for _, rule := range rules {
+		comment := ruleComment(rule)
+		if !strings.HasPrefix(comment, Comment) {
+			continue
+		}
+		_, key, _ := strings.Cut(comment, " ")
+		source, marking := strings.CutPrefix(key, "mark ")
+		if !marking {
+			continue
+		}
+		source, _, _ = strings.Cut(source, "/")
+		for _, e := range rule.Exprs {
+			if counter, ok := e.(*expr.Counter); ok {
+				total := counters[source]
+				total.Packets += counter.Packets
+				total.Bytes += counter.Bytes
+				counters[source] = total
+			}
+		}
+	}
+	return counters, nil
+}
+
+// sourceNatRule returns the key and the expressions of a SNAT rule of the marked traffic of the pod, to the protocol and
+// ports of the egress port if it has them
+func (e *nftablesEgress) sourceNatRule(vip, podIP string, port *EgressPort) (string, []expr.Any, error) {
+	pod, err := parsePrefix(podIP)
+	if err != nil {
+		return "", nil, err
+	}
+	exprs, err := e.address(true, pod)
+	if err != nil {
+		return "", nil, err
+	}
+	exprs = append(exprs, matchMark(egressMark)...)
+	key := "snat " + pod.String()
+	ports := ""
+	if port != nil {
+		protocol, err := matchProtocol(port.Protocol)
+		if err != nil {
+			return "", nil, err
+		}
+		exprs = append(exprs, protocol...)
+		key += " " + port.Protocol
+		if port.First != 0 {
+			exprs = append(exprs, matchDestinationPorts(port.First, port.Last)...)
+			key += " dport " + port.iptablesPort()
+		}
+		// The range of source ports can only be set with a protocol that has ports
+		if port.Protocol != "sctp" {
+			ports = e.sourcePorts
+		}
+	}
+	nat, err := e.sourceNat(vip, ports)
+	if err != nil {
+		return "", nil, err
+	}
+	return key + " to " + e.sourceNatTarget(vip, ports), append(exprs, nat...), nil
+}
+
+// sourceNatTarget returns the target of a SNAT rule in its key, the VIP along with the range of source ports
+func (e *nftablesEgress) sourceNatTarget(vip, ports string) string {
+	if ports == "" {
+		return vip
+	}
+	if IsIPv6(vip) {
+		return "[" + vip + "]:" + ports
+	}
+	return vip + ":" + ports
+}
+
+// sourceNatRules returns the SNAT rules from the pod to the VIP, with a source port range the TCP and UDP traffic have
+// rules of their own (ahead of the rule for the other protocols) as the ports can only be set for them
+func (e *nftablesEgress) sourceNatRules(vip, podIP string) ([]string, [][]expr.Any, error) {
+	ports := []*EgressPort{nil}
+	if e.sourcePorts != "" {
+		ports = append(ports, &EgressPort{Protocol: "udp"}, &EgressPort{Protocol: "tcp"})
+	}
+	var keys []string
+	var rules [][]expr.Any
+	for _, port := range ports {
+		key, exprs, err := e.sourceNatRule(vip, podIP, port)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		rules = append(rules, exprs)
+	}
+	return keys, rules, nil
+}
+
+func (e *nftablesEgress) InsertSourceNat(vip, podIP string) error {
+	log.Infof("[egress] Adding source nat from [%s] => [%s]", podIP, vip)
+	keys, rules, err := e.sourceNatRules(vip, podIP)
+	if err != nil {
+		return err
+	}
+	for x := range keys {
+		if err = e.insert(nftablesPostrouting, keys[x], rules[x]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *nftablesEgress) DeleteSourceNat(podIP, vip string) error {
+	log.Infof("[egress] Removing source nat from [%s] => [%s]", podIP, vip)
+	keys, _, err := e.sourceNatRules(vip, podIP)
+	if err != nil {
+		return err
+	}
+	for x, key := range keys {
+		removed, err := e.remove(nftablesPostrouting, key)
+		if err != nil {
+			return err
+		}
+		if x == 0 && !removed {
+			return fmt.Errorf("unable to find source Nat rule for [%s]", podIP)
+		}
+	}
+	return nil
+}
+
+func (e *nftablesEgress) InsertSourceNatForDestinationPort(vip, podIP string, port EgressPort) error {
+	log.Infof("[egress] Adding source nat from [%s] => [%s], with destination port [%s:%s]", podIP, vip, port.Protocol, port.iptablesPort())
+	key, exprs, err := e.sourceNatRule(vip, podIP, &port)
+	if err != nil {
+		return err
+	}
+	return e.insert(nftablesPostrouting, key, exprs)
+}
+
+func (e *nftablesEgress) DeleteSourceNatForDestinationPort(podIP, vip string, port EgressPort) error {
+	log.Infof("[egress] Removing source nat from [%s] => [%s]", podIP, vip)
+	key, _, err := e.sourceNatRule(vip, podIP, &port)
+	if err != nil {
+		return err
+	}
+	removed, err := e.remove(nftablesPostrouting, key)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("unable to find source Nat rule for [%s], with destination port [%s:%s]", podIP, port.Protocol, port.iptablesPort())
+	}
+	return nil
+}
+
+// CleanSourceNatForVIP removes the existing SNAT rules to the VIP, before the rules of its ports are added
+func (e *nftablesEgress) CleanSourceNatForVIP(vip string) error {
+	rules, err := e.rules(nftablesPostrouting)
+	if err != nil {
+		return err
+	}
+	var found []*nftables.Rule
+	for _, rule := range rules {
+		comment := ruleComment(rule)
+		if !strings.HasPrefix(comment, Comment) {
+			continue
+		}
+		if strings.HasSuffix(comment, " to "+vip) || strings.Contains(comment, " to "+vip+":") || strings.Contains(comment, " to ["+vip+"]:") {
+			found = append(found, rule)
+		}
+	}
+	log.Warnf("[egress] Cleaning [%d] existing postrouting nat rules for vip [%s]", len(found), vip)
+	return e.deleteRules(found...)
+}
+
+// CleanRules removes the egress rules of the namespace
+func (e *nftablesEgress) CleanRules() error {
+	for _, chain := range []string{nftablesPostrouting, MangleChainName} {
+		rules, err := e.rules(chain)
+		if err != nil {
+			return err
+		}
+		var found []*nftables.Rule
+		for _, rule := range rules {
+			if strings.HasPrefix(ruleComment(rule), e.comment+" ") {
+				found = append(found, rule)
+			}
+		}
+		log.Warnf("[egress] Cleaning [%d] dangling rules of chain [%s]", len(found), chain)
+		if err = e.deleteRules(found...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nftablesServiceRules manages the rules of the VIPs of the services natively with nftables
+type nftablesServiceRules struct {
+	options []nftables.ConnOption
+}
+
+// rules returns the rules of the family of the VIP, with the comment of the VIP
+func (s nftablesServiceRules) rules(vip, comment string) (*nftablesRules, netip.Prefix, error) {
+	address, err := netip.ParseAddr(vip)
+	if err != nil {
+		return nil, netip.Prefix{}, fmt.Errorf("invalid VIP [%s]", vip)
+	}
+	rules, err := newNftablesRules(comment, address.Is6(), s.options...)
+	if err != nil {
+		return nil, netip.Prefix{}, err
+	}
+	return rules, netip.PrefixFrom(address, address.BitLen()), nil
+}
+
+// portRule returns the key and the expressions of the rule that accepts the traffic to a port of the VIP
+func (n *nftablesRules) portRule(vip netip.Prefix, protocol string, port uint16) (string, []expr.Any, error) {
+	exprs, err := n.address(false, vip)
+	if err != nil {
+		return "", nil, err
+	}
+	match, err := matchProtocol(protocol)
+	if err != nil {
+		return "", nil, err
+	}
+	exprs = append(append(exprs, match...), matchDestinationPorts(port, port)...)
+	key := fmt.Sprintf("accept %s %s:%d", vip.Addr(), strings.ToLower(protocol), port)
+	return key, append(exprs, &expr.Verdict{Kind: expr.VerdictAccept}), nil
+}
+
+// limitPorts drops the traffic to the VIP, other than that to the ports of the service and to the DHCP client
+func (s nftablesServiceRules) limitPorts(vip, comment string, ports []v1.ServicePort) error {
+	n, prefix, err := s.rules(vip, comment)
+	if err != nil {
+		return err
+	}
+	wanted := map[string][]expr.Any{}
+	dhcp, _ := strconv.ParseUint(dhcpClientPort, 10, 16)
+	for _, port := range append([]v1.ServicePort{{Protocol: v1.ProtocolUDP, Port: int32(dhcp)}}, ports...) {
+		key, exprs, err := n.portRule(prefix, string(port.Protocol), uint16(port.Port))
+		if err != nil {
+			return err
+		}
+		wanted[key] = exprs
+	}
+
+	// The rules of the ports that the service no longer has are removed
+	rules, err := n.rules(nftablesInput)
+	if err != nil {
+		return err
+	}
+	var stale []*nftables.Rule
+	for _, rule := range rules {
+		_, key, found := strings.Cut(ruleComment(rule), n.comment+" ")
+		if found && strings.HasPrefix(key, "accept ") && wanted[key] == nil {
+			stale = append(stale, rule)
+		}
+	}
+	if err = n.deleteRules(stale...); err != nil {
+		return fmt.Errorf("could not delete nftables rule: %w", err)
+	}
+
+	// The ports are accepted ahead of the drop of the rest of the traffic to the VIP
+	drop, err := n.address(false, prefix)
+	if err != nil {
+		return err
+	}
+	if err = n.add(nftablesInput, "drop "+prefix.Addr().String(), false, append(drop, &expr.Verdict{Kind: expr.VerdictDrop})); err != nil {
+		return fmt.Errorf("could not add nftables rule to drop the traffic to VIP %s: %w", vip, err)
+	}
+	for key, exprs := range wanted {
+		if err = n.add(nftablesInput, key, true, exprs); err != nil {
+			return fmt.Errorf("could not add nftables rule to accept the traffic to VIP %s: %w", vip, err)
+		}
+	}
+	return nil
+}
+
+func (s nftablesServiceRules) removePortLimits(vip, comment string, _ []v1.ServicePort) error {
+	n, prefix, err := s.rules(vip, comment)
+	if err != nil {
+		return err
+	}
+	rules, err := n.rules(nftablesInput)
+	if err != nil {
+		return err
+	}
+	var found []*nftables.Rule
+	for _, rule := range rules {
+		_, key, ok := strings.Cut(ruleComment(rule), n.comment+" ")
+		if ok && (key == "drop "+prefix.Addr().String() || strings.HasPrefix(key, "accept "+prefix.Addr().String()+" ")) {
+			found = append(found, rule)
+		}
+	}
+	if err = n.deleteRules(found...); err != nil {
+		return fmt.Errorf("could not delete nftables rules of VIP %s: %w", vip, err)
+	}
+	return nil
+}
+
+// masquerade marks the traffic to the VIP, both from other hosts and from this node, and masquerades the marked traffic
+// once IPVS has forwarded it to a backend
+func (s nftablesServiceRules) masquerade(vip, comment string) error {
+	n, prefix, err := s.rules(vip, comment)
+	if err != nil {
+		return err
+	}
+	mark, err := n.address(false, prefix)
+	if err != nil {
+		return err
+	}
+	mark = append(mark, setMark(masqueradeMark)...)
+	for _, chain := range []string{nftablesPrerouting, nftablesOutput} {
+		if err = n.add(chain, "mark "+prefix.Addr().String(), true, mark); err != nil {
+			return fmt.Errorf("could not add masquerade rule for VIP %s: %w", vip, err)
+		}
+	}
+	masquerade := append(matchMark(masqueradeMark), &expr.Masq{})
+	if err = n.add(nftablesPostrouting, "masquerade", true, masquerade); err != nil {
+		return fmt.Errorf("could not add masquerade rule for VIP %s: %w", vip, err)
+	}
+	return nil
+}
+
+func (s nftablesServiceRules) removeMasquerade(vip, comment string) error {
+	n, prefix, err := s.rules(vip, comment)
+	if err != nil {
+		return err
+	}
+	for _, chain := range []string{nftablesPrerouting, nftablesOutput} {
+		if _, err = n.remove(chain, "mark "+prefix.Addr().String()); err != nil {
+			return fmt.Errorf("could not del masquerade rule for VIP %s: %w", vip, err)
+		}
+	}
+	if _, err = n.remove(nftablesPostrouting, "masquerade"); err != nil {
+		return fmt.Errorf("could not del masquerade rule for VIP %s: %w", vip, err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package vip
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/nftables"
+	"github.com/vishvananda/netns"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kube-vip/kube-vip/pkg/iptables"
+)
+
+// testNamespace returns the option of a connection to a network namespace of its own, the test is skipped if one can't
+// be created
+func testNamespace(t *testing.T) nftables.ConnOption {
+	t.Helper()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	original, err := netns.Get()
+	if err != nil {
+		t.Skipf("network namespaces aren't available: %v", err)
+	}
+	defer original.Close()
+	ns, err := netns.New()
+	if err != nil {
+		t.Skipf("a network namespace can't be created: %v", err)
+	}
+	if err = netns.Set(original); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ns.Close() })
+	return nftables.WithNetNSFd(int(ns))
+}
+
+// ruleKeys returns the keys of the rules of the chain, in their order
+func ruleKeys(t *testing.T, n *nftablesRules, chain string) []string {
+	t.Helper()
+	rules, err := n.rules(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, rule := range rules {
+		keys = append(keys, strings.TrimPrefix(ruleComment(rule), n.comment+" "))
+	}
+	return keys
+}
+
+func equalKeys(got, want []string) bool {
+	return strings.Join(got, ",") == strings.Join(want, ",")
+}
+
+func TestNftablesEgress(t *testing.T) {
+	e, err := newNftablesEgress("default", iptables.ProtocolIPv4, testNamespace(t))
+	if err != nil {
+		t.Skipf("nftables isn't available: %v", err)
+	}
+
+	exists, err := e.CheckMangleChain(MangleChainName)
+	if err != nil {
+		t.Skipf("nftables isn't available: %v", err)
+	}
+	if exists {
+		t.Fatal("the egress chain exists before it is created")
+	}
+	if err = e.CreateMangleChain(MangleChainName); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ = e.CheckMangleChain(MangleChainName); !exists {
+		t.Fatal("the egress chain doesn't exist once it is created")
+	}
+	if err = e.InsertMangleTableIntoPrerouting(MangleChainName); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.AppendReturnRulesForDestinationSubnet(MangleChainName, "10.96.0.0/12"); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.AppendReturnRulesForMarking(MangleChainName, "10.0.0.5/32"); err != nil {
+		t.Fatal(err)
+	}
+	// Adding a rule again leaves the one rule
+	if err = e.AppendReturnRulesForMarking(MangleChainName, "10.0.0.5/32"); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.InsertExclusionRule(MangleChainName, "10.0.0.5", "192.168.1.0/24"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"exclude 10.0.0.5/32 daddr 192.168.1.0/24", "return daddr 10.96.0.0/12", "mark 10.0.0.5/32"}
+	if got := ruleKeys(t, e.nftablesRules, MangleChainName); !equalKeys(got, want) {
+		t.Errorf("egress chain = %v, want %v", got, want)
+	}
+
+	counters, err := e.MarkingCounters(MangleChainName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := counters["10.0.0.5"]; !found || len(counters) != 1 {
+		t.Errorf("MarkingCounters() = %v, want the counters of 10.0.0.5", counters)
+	}
+
+	if err = e.SetSourceNatPorts("30000-40000"); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.InsertSourceNat("192.168.0.100", "10.0.0.5"); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.InsertSourceNatForDestinationPort("192.168.0.100", "10.0.0.5", EgressPort{Protocol: "tcp", First: 443, Last: 443}); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		"snat 10.0.0.5/32 tcp dport 443 to 192.168.0.100:30000-40000",
+		"snat 10.0.0.5/32 tcp to 192.168.0.100:30000-40000",
+		"snat 10.0.0.5/32 udp to 192.168.0.100:30000-40000",
+		"snat 10.0.0.5/32 to 192.168.0.100",
+	}
+	if got := ruleKeys(t, e.nftablesRules, nftablesPostrouting); !equalKeys(got, want) {
+		t.Errorf("postrouting chain = %v, want %v", got, want)
+	}
+
+	if err = e.DeleteSourceNatForDestinationPort("10.0.0.5", "192.168.0.100", EgressPort{Protocol: "tcp", First: 443, Last: 443}); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.DeleteSourceNatForDestinationPort("10.0.0.5", "192.168.0.100", EgressPort{Protocol: "tcp", First: 443, Last: 443}); err == nil {
+		t.Error("DeleteSourceNatForDestinationPort() of a missing rule expected an error")
+	}
+	if err = e.CleanSourceNatForVIP("192.168.0.100"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ruleKeys(t, e.nftablesRules, nftablesPostrouting); len(got) != 0 {
+		t.Errorf("postrouting chain = %v once the VIP is cleaned, want no rules", got)
+	}
+
+	if err = e.DeleteMangleMarking("10.0.0.5/32", MangleChainName); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.DeleteMangleMarking("10.0.0.5/32", MangleChainName); err == nil {
+		t.Error("DeleteMangleMarking() of a missing rule expected an error")
+	}
+	if err = e.DeleteExclusionRule(MangleChainName, "10.0.0.5", "192.168.1.0/24"); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.CleanRules(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ruleKeys(t, e.nftablesRules, MangleChainName); len(got) != 0 {
+		t.Errorf("egress chain = %v once it is cleaned, want no rules", got)
+	}
+
+	if err = e.DeleteMangleChain(MangleChainName); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ = e.CheckMangleChain(MangleChainName); exists {
+		t.Error("the egress chain exists once it is deleted")
+	}
+	if got := ruleKeys(t, e.nftablesRules, nftablesPrerouting); len(got) != 0 {
+		t.Errorf("prerouting chain = %v once the egress chain is deleted, want no rules", got)
+	}
+}
+
+func TestNftablesEgressIPv6(t *testing.T) {
+	e, err := newNftablesEgress("default", iptables.ProtocolIPv6, testNamespace(t))
+	if err != nil {
+		t.Skipf("nftables isn't available: %v", err)
+	}
+	if err = e.CreateMangleChain(MangleChainName); err != nil {
+		t.Skipf("nftables isn't available: %v", err)
+	}
+	if err = e.AppendReturnRulesForMarking(MangleChainName, "fd00::5/128"); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.SetSourceNatPorts("30000-40000"); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.InsertSourceNat("fd00::100", "fd00::5"); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.InsertSourceNat("192.168.0.100", "10.0.0.5"); err == nil {
+		t.Error("InsertSourceNat() of IPv4 addresses to the IPv6 rules expected an error")
+	}
+	if got := ruleKeys(t, e.nftablesRules, nftablesPostrouting); len(got) != 3 || got[2] != "snat fd00::5/128 to fd00::100" {
+		t.Errorf("postrouting chain = %v, want the rules of fd00::5", got)
+	}
+	if err = e.CleanSourceNatForVIP("fd00::100"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ruleKeys(t, e.nftablesRules, nftablesPostrouting); len(got) != 0 {
+		t.Errorf("postrouting chain = %v once the VIP is cleaned, want no rules", got)
+	}
+}
+
+func TestNftablesServiceRules(t *testing.T) {
+	s := nftablesServiceRules{options: []nftables.ConnOption{testNamespace(t)}}
+	vip, comment := "192.168.0.100", "default/web kube-vip load balancer IP"
+	ports := []v1.ServicePort{{Protocol: v1.ProtocolTCP, Port: 80}, {Protocol: v1.ProtocolTCP, Port: 443}}
+	if err := s.limitPorts(vip, comment, ports); err != nil {
+		t.Skipf("nftables isn't available: %v", err)
+	}
+	n, _, err := s.rules(vip, comment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ruleKeys(t, n, nftablesInput)
+	if len(got) != 4 || got[3] != "drop 192.168.0.100" {
+		t.Errorf("input chain = %v, want the ports accepted ahead of the drop", got)
+	}
+
+	// The port that the service no longer has isn't accepted
+	if err = s.limitPorts(vip, comment, ports[:1]); err != nil {
+		t.Fatal(err)
+	}
+	got = ruleKeys(t, n, nftablesInput)
+	for _, key := range got {
+		if strings.HasSuffix(key, ":443") {
+			t.Errorf("input chain = %v, want the rule of port 443 removed", got)
+		}
+	}
+	if len(got) != 3 {
+		t.Errorf("input chain = %v, want the rules of udp 68 and tcp 80 and the drop", got)
+	}
+
+	if err = s.removePortLimits(vip, comment, ports[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if got = ruleKeys(t, n, nftablesInput); len(got) != 0 {
+		t.Errorf("input chain = %v once the limits are removed, want no rules", got)
+	}
+
+	if err = s.masquerade(vip, comment); err != nil {
+		t.Fatal(err)
+	}
+	for _, chain := range []string{nftablesPrerouting, nftablesOutput, nftablesPostrouting} {
+		if got = ruleKeys(t, n, chain); len(got) != 1 {
+			t.Errorf("%s chain = %v, want the masquerade rule", chain, got)
+		}
+	}
+	if err = s.removeMasquerade(vip, comment); err != nil {
+		t.Fatal(err)
+	}
+	for _, chain := range []string{nftablesPrerouting, nftablesOutput, nftablesPostrouting} {
+		if got = ruleKeys(t, n, chain); len(got) != 0 {
+			t.Errorf("%s chain = %v once the masquerade is removed, want no rules", chain, got)
+		}
+	}
+}
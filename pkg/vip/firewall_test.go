@@ -0,0 +1,43 @@
+package vip
+
+import "testing"
+
+func TestDetectFirewallBackend(t *testing.T) {
+	tests := []struct {
+		name        string
+		nftRuleset  bool
+		legacyRules bool
+		want        string
+	}{
+		{"no rules", false, false, FirewallNftables},
+		{"nftables ruleset", true, false, FirewallNftables},
+		{"legacy rules", false, true, FirewallIptables},
+		{"both", true, true, FirewallNftables},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectFirewallBackend(tt.nftRuleset, tt.legacyRules); got != tt.want {
+				t.Errorf("detectFirewallBackend() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasRules(t *testing.T) {
+	tests := []struct {
+		name string
+		save string
+		want bool
+	}{
+		{"not installed", "", false},
+		{"empty tables", "# Generated by iptables-save v1.8.7\n*filter\n:INPUT ACCEPT [0:0]\n:FORWARD ACCEPT [0:0]\n:OUTPUT ACCEPT [0:0]\nCOMMIT\n", false},
+		{"rules", "*nat\n:PREROUTING ACCEPT [0:0]\n:KUBE-SERVICES - [0:0]\n-A PREROUTING -j KUBE-SERVICES\nCOMMIT\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasRules(tt.save); got != tt.want {
+				t.Errorf("hasRules() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package vip
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// The gratuitous ARP and NDP updates of the VIPs, so that a node that is failing to announce its VIPs can be seen
+	gratuitousSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kube_vip",
+		Subsystem: "advertisement",
+		Name:      "gratuitous_sent_total",
+		Help:      "Count the gratuitous ARP and NDP updates that have been sent by the interface and the protocol",
+	}, []string{"interface", "protocol"})
+
+	gratuitousFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kube_vip",
+		Subsystem: "advertisement",
+		Name:      "gratuitous_failures_total",
+		Help:      "Count the gratuitous ARP and NDP updates that couldn't be sent by the interface and the protocol",
+	}, []string{"interface", "protocol"})
+)
+
+// countGratuitous counts a gratuitous update of the protocol (arp or ndp) that has been sent via the interface
+func countGratuitous(iface, protocol string, err error) {
+	if err != nil {
+		gratuitousFailures.WithLabelValues(iface, protocol).Inc()
+		return
+	}
+	gratuitousSent.WithLabelValues(iface, protocol).Inc()
+}
+
+// AdvertisementMetrics returns the collectors of the gratuitous ARP and NDP updates
+func AdvertisementMetrics() []prometheus.Collector {
+	return []prometheus.Collector{gratuitousSent, gratuitousFailures}
+}
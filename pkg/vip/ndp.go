@@ -44,7 +44,9 @@ func (n *NdpResponder) Close() error {
 }
 
 // SendGratuitous broadcasts an NDP update or returns error if encountered.
-func (n *NdpResponder) SendGratuitous(address string) error {
+func (n *NdpResponder) SendGratuitous(address string) (err error) {
+	defer func() { countGratuitous(n.intf, "ndp", err) }()
+
 	ip, err := netip.ParseAddr(address)
 	if err != nil {
 		return fmt.Errorf("failed to parse address %s", ip)
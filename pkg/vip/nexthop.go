@@ -0,0 +1,59 @@
+package vip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+)
+
+// NextHop is a gateway of a multipath route, the interface is only needed if the gateway isn't directly connected
+// to the interface of the VIP
+type NextHop struct {
+	Gateway   net.IP
+	Interface string
+}
+
+// ParseNextHops parses next hops in the format <gateway>[@<interface>]
+func ParseNextHops(hops []string) ([]NextHop, error) {
+	var nextHops []NextHop
+	for _, hop := range hops {
+		if hop = strings.TrimSpace(hop); hop == "" {
+			continue
+		}
+		gateway, iface, _ := strings.Cut(hop, "@")
+		ip := net.ParseIP(gateway)
+		if ip == nil {
+			return nil, fmt.Errorf("next hop [%s] does not have a valid gateway address", hop)
+		}
+		nextHops = append(nextHops, NextHop{Gateway: ip, Interface: iface})
+	}
+	return nextHops, nil
+}
+
+// SetNextHops will add the VIP route as a multipath route through the next hops (of the same family as the VIP), the
+// kernel then balances the flows to the VIP across them
+func (configurator *network) SetNextHops(hops []NextHop) error {
+	configurator.mu.Lock()
+	defer configurator.mu.Unlock()
+
+	ipv4 := configurator.address.IP.To4() != nil
+	var nexthops []*netlink.NexthopInfo
+	for _, hop := range hops {
+		if (hop.Gateway.To4() != nil) != ipv4 {
+			continue
+		}
+		info := &netlink.NexthopInfo{Gw: hop.Gateway}
+		if hop.Interface != "" {
+			link, err := netlink.LinkByName(hop.Interface)
+			if err != nil {
+				return fmt.Errorf("could not get link for next hop interface '%s': %w", hop.Interface, err)
+			}
+			info.LinkIndex = link.Attrs().Index
+		}
+		nexthops = append(nexthops, info)
+	}
+	configurator.nextHops = nexthops
+	return nil
+}
@@ -0,0 +1,39 @@
+package vip
+
+import (
+	"net"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+)
+
+func TestParseNextHops(t *testing.T) {
+	hops, err := ParseNextHops([]string{"10.0.0.1", " 10.0.1.1@eth1", "", "fd00::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hops) != 3 || hops[1].Interface != "eth1" || !hops[2].Gateway.Equal(net.ParseIP("fd00::1")) {
+		t.Errorf("ParseNextHops() = %+v", hops)
+	}
+	if _, err := ParseNextHops([]string{"eth1@10.0.0.1"}); err == nil {
+		t.Error("ParseNextHops() expected an error with an invalid gateway")
+	}
+}
+
+func TestPrepareMultipathRoute(t *testing.T) {
+	address, _ := netlink.ParseAddr("192.168.0.10/32")
+	n := &network{address: address, link: &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 4}}, routeTable: 198}
+	if route := n.PrepareRoute(); route.LinkIndex != 4 || len(route.MultiPath) != 0 {
+		t.Fatalf("PrepareRoute() = %v, want a route through the interface", route)
+	}
+
+	// Only the next hops of the same family as the VIP are used
+	hops, _ := ParseNextHops([]string{"10.0.0.1", "10.0.0.2", "fd00::1"})
+	if err := n.SetNextHops(hops); err != nil {
+		t.Fatal(err)
+	}
+	route := n.PrepareRoute()
+	if route.LinkIndex != 0 || len(route.MultiPath) != 2 || !route.MultiPath[1].Gw.Equal(net.ParseIP("10.0.0.2")) {
+		t.Errorf("PrepareRoute() = %v, want a multipath route through both IPv4 next hops", route)
+	}
+}
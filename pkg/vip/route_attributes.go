@@ -0,0 +1,80 @@
+package vip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+)
+
+// SourceVIP is the source of the route that is the VIP itself
+const SourceVIP = "vip"
+
+// routeScopes are the scopes that the VIP routes can be given
+var routeScopes = map[string]netlink.Scope{
+	"global": netlink.SCOPE_UNIVERSE,
+	"link":   netlink.SCOPE_LINK,
+	"host":   netlink.SCOPE_HOST,
+}
+
+// RouteAttributes are the attributes of the VIP routes that different upstream routers and CNIs need for the routes to
+// be accepted and used, the defaults are left to the kernel
+type RouteAttributes struct {
+	// Scope is global, link or host, the scope is derived from the type of the route if it is empty
+	Scope string
+	// OnLink makes the kernel treat the gateways as directly connected, even if they aren't in a subnet of the link
+	OnLink bool
+	// Source is the preferred source address of the route, either an address or "vip" for the VIP itself
+	Source string
+}
+
+// Validate will ensure that the scope and the source of the routes are valid
+func (a RouteAttributes) Validate() error {
+	if _, exists := routeScopes[a.Scope]; a.Scope != "" && !exists {
+		return fmt.Errorf("route scope [%s] has to be global, link or host", a.Scope)
+	}
+	if a.Source != "" && a.Source != SourceVIP && net.ParseIP(a.Source) == nil {
+		return fmt.Errorf("route source [%s] has to be an address or %q", a.Source, SourceVIP)
+	}
+	return nil
+}
+
+// SetRouteAttributes will set the scope, onlink flag and source of the VIP route, a source address of the other family
+// than the VIP is ignored
+func (configurator *network) SetRouteAttributes(attributes RouteAttributes) error {
+	if err := attributes.Validate(); err != nil {
+		return err
+	}
+	configurator.mu.Lock()
+	defer configurator.mu.Unlock()
+	configurator.routeAttributes = attributes
+	return nil
+}
+
+// applyRouteAttributes sets the attributes on the route of the VIP
+func (configurator *network) applyRouteAttributes(route *netlink.Route) {
+	attributes := configurator.routeAttributes
+	if scope, exists := routeScopes[attributes.Scope]; exists {
+		route.Scope = scope
+	}
+	if attributes.OnLink {
+		route.Flags |= int(netlink.FLAG_ONLINK)
+		// The next hops are copied, as they are shared with the configuration of the VIP
+		hops := make([]*netlink.NexthopInfo, len(route.MultiPath))
+		for i, hop := range route.MultiPath {
+			onlink := *hop
+			onlink.Flags |= int(netlink.FLAG_ONLINK)
+			hops[i] = &onlink
+		}
+		route.MultiPath = hops
+	}
+	switch attributes.Source {
+	case "":
+	case SourceVIP:
+		route.Src = configurator.address.IP
+	default:
+		if src := net.ParseIP(attributes.Source); (src.To4() != nil) == (configurator.address.IP.To4() != nil) {
+			route.Src = src
+		}
+	}
+}
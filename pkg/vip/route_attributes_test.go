@@ -0,0 +1,38 @@
+package vip
+
+import (
+	"net"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+)
+
+func TestRouteAttributes(t *testing.T) {
+	address, _ := netlink.ParseAddr("192.168.0.10/32")
+	n := &network{address: address, link: &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 4}}, routeTable: 198}
+	if err := n.SetRouteAttributes(RouteAttributes{Scope: "link", OnLink: true, Source: SourceVIP}); err != nil {
+		t.Fatal(err)
+	}
+	route := n.PrepareRoute()
+	if route.Scope != netlink.SCOPE_LINK || route.Flags&int(netlink.FLAG_ONLINK) == 0 || !route.Src.Equal(address.IP) {
+		t.Errorf("PrepareRoute() = %v, want a link scoped onlink route from the VIP", route)
+	}
+
+	// A source of the other family is ignored
+	if err := n.SetRouteAttributes(RouteAttributes{Source: "fd00::1"}); err != nil {
+		t.Fatal(err)
+	}
+	if route := n.PrepareRoute(); route.Src != nil {
+		t.Errorf("PrepareRoute() = %v, want no source", route)
+	}
+	if err := n.SetRouteAttributes(RouteAttributes{Source: "10.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if route := n.PrepareRoute(); !route.Src.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("PrepareRoute() = %v, want the source 10.0.0.1", route)
+	}
+
+	if err := n.SetRouteAttributes(RouteAttributes{Scope: "site"}); err == nil {
+		t.Error("SetRouteAttributes() expected an error with an invalid scope")
+	}
+}
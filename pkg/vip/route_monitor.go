@@ -0,0 +1,70 @@
+package vip
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// installedRoutes are the routes of the VIPs that have been added, keyed by their table and destination, these are
+// the routes that are re-installed when they are deleted or replaced by another process
+var installedRoutes sync.Map
+
+func routeKey(table int, dst *net.IPNet) string {
+	return fmt.Sprintf("%d/%s", table, dst)
+}
+
+// MonitorRoutes will watch the route updates of the kernel and re-install the routes of the VIPs if another process
+// deletes or replaces them, repaired is called for every route that is re-installed
+func MonitorRoutes(done <-chan struct{}, repaired func(route *netlink.Route)) error {
+	updates := make(chan netlink.RouteUpdate)
+	err := netlink.RouteSubscribeWithOptions(updates, done, netlink.RouteSubscribeOptions{
+		ErrorCallback: func(err error) {
+			log.Errorf("[route] error monitoring the routes: %v", err)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error subscribing to the route updates: %w", err)
+	}
+	go func() {
+		for update := range updates {
+			if update.Dst == nil {
+				continue
+			}
+			value, exists := installedRoutes.Load(routeKey(update.Table, update.Dst))
+			if !exists {
+				continue
+			}
+			desired := value.(*network).PrepareRoute()
+			if !repairNeeded(&update, desired) {
+				continue
+			}
+			if err := netlink.RouteReplace(desired); err != nil {
+				log.Errorf("[route] unable to re-install the route for [%s]: %v", desired.Dst, err)
+				continue
+			}
+			log.Warnf("[route] re-installed the route for [%s] in table [%d], it was changed by another process", desired.Dst, desired.Table)
+			repaired(desired)
+		}
+	}()
+	return nil
+}
+
+// repairNeeded returns true if the update removes our route, either because it was deleted or because a route of
+// another protocol has replaced it (a route with another metric is a different route, and is left alone)
+func repairNeeded(update *netlink.RouteUpdate, desired *netlink.Route) bool {
+	if update.Priority != desired.Priority {
+		return false
+	}
+	switch update.Type {
+	case unix.RTM_DELROUTE:
+		return update.Protocol == desired.Protocol
+	case unix.RTM_NEWROUTE:
+		return update.Protocol != desired.Protocol
+	}
+	return false
+}
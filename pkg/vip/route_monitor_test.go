@@ -0,0 +1,30 @@
+package vip
+
+import (
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestRepairNeeded(t *testing.T) {
+	desired := &netlink.Route{Table: 198, Protocol: 248, Priority: 100}
+	tests := []struct {
+		name   string
+		update netlink.RouteUpdate
+		want   bool
+	}{
+		{name: "deleted", update: netlink.RouteUpdate{Type: unix.RTM_DELROUTE, Route: netlink.Route{Protocol: 248, Priority: 100}}, want: true},
+		{name: "replaced", update: netlink.RouteUpdate{Type: unix.RTM_NEWROUTE, Route: netlink.Route{Protocol: unix.RTPROT_BOOT, Priority: 100}}, want: true},
+		{name: "re-installed", update: netlink.RouteUpdate{Type: unix.RTM_NEWROUTE, Route: netlink.Route{Protocol: 248, Priority: 100}}},
+		{name: "other metric deleted", update: netlink.RouteUpdate{Type: unix.RTM_DELROUTE, Route: netlink.Route{Protocol: 248, Priority: 50}}},
+		{name: "other metric added", update: netlink.RouteUpdate{Type: unix.RTM_NEWROUTE, Route: netlink.Route{Protocol: unix.RTPROT_BOOT, Priority: 50}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repairNeeded(&tt.update, desired); got != tt.want {
+				t.Errorf("repairNeeded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package vip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+)
+
+// RulePriority is the priority of the rules that steer the traffic from VIPs, it is ahead of the rules of the main
+// (32766) and default (32767) tables
+const RulePriority = 1000
+
+// SetRuleTable will add an ip rule (from <VIP> lookup <table>) alongside the route of the VIP, so that the replies from
+// the VIP are routed with that table (e.g. out of a specific uplink). The rule is removed with the route.
+func (configurator *network) SetRuleTable(table int) {
+	configurator.mu.Lock()
+	defer configurator.mu.Unlock()
+	configurator.ruleTable = table
+}
+
+// prepareRule returns the rule of the VIP, it is nil if the VIP doesn't have a rule table
+func (configurator *network) prepareRule() *netlink.Rule {
+	if configurator.ruleTable == 0 {
+		return nil
+	}
+	bits := 32
+	family := netlink.FAMILY_V4
+	if configurator.address.IP.To4() == nil {
+		bits = 128
+		family = netlink.FAMILY_V6
+	}
+	rule := netlink.NewRule()
+	rule.Family = family
+	rule.Src = &net.IPNet{IP: configurator.address.IP, Mask: net.CIDRMask(bits, bits)}
+	rule.Table = configurator.ruleTable
+	rule.Priority = RulePriority
+	return rule
+}
+
+// addRule adds the rule of the VIP, a rule that already exists is left alone
+func (configurator *network) addRule() error {
+	rule := configurator.prepareRule()
+	if rule == nil {
+		return nil
+	}
+	if err := netlink.RuleAdd(rule); err != nil && !errors.Is(err, syscall.EEXIST) {
+		return fmt.Errorf("error adding rule from [%s] to table [%d]: %w", rule.Src, rule.Table, err)
+	}
+	return nil
+}
+
+// deleteRule deletes the rule of the VIP, a rule that doesn't exist is ignored
+func (configurator *network) deleteRule() error {
+	rule := configurator.prepareRule()
+	if rule == nil {
+		return nil
+	}
+	if err := netlink.RuleDel(rule); err != nil && !errors.Is(err, syscall.ENOENT) && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("error deleting rule from [%s] to table [%d]: %w", rule.Src, rule.Table, err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package vip
+
+import (
+	"testing"
+
+	"github.com/vishvananda/netlink"
+)
+
+func TestPrepareRule(t *testing.T) {
+	address, _ := netlink.ParseAddr("192.168.0.10/32")
+	n := &network{address: address}
+	if rule := n.prepareRule(); rule != nil {
+		t.Fatalf("prepareRule() = %v, want no rule without a rule table", rule)
+	}
+
+	n.SetRuleTable(200)
+	rule := n.prepareRule()
+	if rule == nil || rule.Table != 200 || rule.Family != netlink.FAMILY_V4 || rule.Src.String() != "192.168.0.10/32" {
+		t.Errorf("prepareRule() = %v, want a rule from the VIP to table 200", rule)
+	}
+
+	address, _ = netlink.ParseAddr("fd00::10/128")
+	n = &network{address: address}
+	n.SetRuleTable(200)
+	if rule := n.prepareRule(); rule.Family != netlink.FAMILY_V6 || rule.Src.String() != "fd00::10/128" {
+		t.Errorf("prepareRule() = %v, want an IPv6 rule from the VIP", rule)
+	}
+}
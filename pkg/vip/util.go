@@ -115,6 +115,38 @@ func GetFullMask(address string) (string, error) {
 	return "", fmt.Errorf("failed to parse %s as either IPv4 or IPv6", address)
 }
 
+// SubnetForAddress returns the subnet of the address, the subnet can be an IPv4 and an IPv6 subnet (/32,/128) for
+// dual-stack services so that the IPv6 address isn't given the IPv4 subnet
+func SubnetForAddress(subnet, address string) string {
+	subnets := strings.Split(subnet, ",")
+	if len(subnets) != 2 {
+		return strings.TrimSpace(subnet)
+	}
+	if IsIPv6(address) {
+		return strings.TrimSpace(subnets[1])
+	}
+	return strings.TrimSpace(subnets[0])
+}
+
+// IsLocalAddress returns true if the address is configured on an interface of this node, e.g. because this node is
+// advertising it
+func IsLocalAddress(address string) (bool, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false, fmt.Errorf("failed to parse %s as either IPv4 or IPv6", address)
+	}
+	addresses, err := netlink.AddrList(nil, netlink.FAMILY_ALL)
+	if err != nil {
+		return false, err
+	}
+	for _, addr := range addresses {
+		if addr.IP.Equal(ip) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // GetDefaultGatewayInterface return default gateway interface link
 func GetDefaultGatewayInterface() (*net.Interface, error) {
 	routes, err := netlink.RouteList(nil, syscall.AF_INET)
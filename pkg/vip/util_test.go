@@ -0,0 +1,19 @@
+package vip
+
+import "testing"
+
+func TestSubnetForAddress(t *testing.T) {
+	tests := []struct {
+		subnet, address, want string
+	}{
+		{subnet: "", address: "192.168.0.10", want: ""},
+		{subnet: "/24", address: "192.168.0.10", want: "/24"},
+		{subnet: "/32,/128", address: "192.168.0.10", want: "/32"},
+		{subnet: "/32, /128", address: "fd00::10", want: "/128"},
+	}
+	for _, tt := range tests {
+		if got := SubnetForAddress(tt.subnet, tt.address); got != tt.want {
+			t.Errorf("SubnetForAddress(%q, %q) = %q, want %q", tt.subnet, tt.address, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package vrrp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	// Protocol is the IP protocol number of VRRP
+	Protocol = 112
+
+	typeAdvertisement = 1
+
+	// The authentication of VRRPv2, RFC 3768 only keeps the simple text password for compatibility with RFC 2338
+	authTypeNone   = 0
+	authTypeSimple = 1
+	passwordLength = 8
+)
+
+var (
+	// The advertisements are sent to these groups with a TTL (hop limit) of 255
+	groupIPv4 = net.IPv4(224, 0, 0, 18)
+	groupIPv6 = net.ParseIP("ff02::12")
+)
+
+// Advertisement is a VRRP advertisement, of version 3 (RFC 5798) or version 2 (RFC 3768) for the routers that
+// only speak the older version
+type Advertisement struct {
+	Version   int
+	RouterID  uint8
+	Priority  uint8
+	Interval  time.Duration
+	Addresses []net.IP
+
+	// Password is the simple text authentication of version 2, it is empty without authentication
+	Password string
+}
+
+// Marshal encodes the advertisement from the source to the destination, the checksum of version 3 covers the
+// pseudo-header of both
+func (a *Advertisement) Marshal(src, dst net.IP) ([]byte, error) {
+	ipv4 := dst.To4() != nil
+	addressLength := net.IPv4len
+	if !ipv4 {
+		addressLength = net.IPv6len
+	}
+
+	length := 8 + len(a.Addresses)*addressLength
+	switch a.Version {
+	case 2:
+		if !ipv4 {
+			return nil, fmt.Errorf("VRRPv2 only supports IPv4")
+		}
+		length += passwordLength
+	case 3:
+	default:
+		return nil, fmt.Errorf("VRRP version %d is not supported", a.Version)
+	}
+	if len(a.Addresses) > 255 {
+		return nil, fmt.Errorf("too many addresses (%d) for an advertisement", len(a.Addresses))
+	}
+
+	b := make([]byte, length)
+	b[0] = byte(a.Version)<<4 | typeAdvertisement
+	b[1] = a.RouterID
+	b[2] = a.Priority
+	b[3] = byte(len(a.Addresses))
+	if a.Version == 2 {
+		if a.Password != "" {
+			b[4] = authTypeSimple
+		}
+		b[5] = byte(a.Interval / time.Second)
+	} else {
+		binary.BigEndian.PutUint16(b[4:], uint16(a.Interval/(10*time.Millisecond))&0x0fff)
+	}
+	for i, address := range a.Addresses {
+		if ipv4 {
+			address = address.To4()
+		}
+		if len(address) != addressLength {
+			return nil, fmt.Errorf("the address [%s] isn't of the family of the advertisement", a.Addresses[i])
+		}
+		copy(b[8+i*addressLength:], address)
+	}
+	if a.Version == 2 {
+		copy(b[8+len(a.Addresses)*addressLength:], a.Password)
+	}
+	binary.BigEndian.PutUint16(b[6:], checksum(b, a.Version, src, dst))
+	return b, nil
+}
+
+// ParseAdvertisement decodes an advertisement that was received from the source on the destination, it fails if
+// the advertisement is malformed or its checksum doesn't match
+func ParseAdvertisement(b []byte, src, dst net.IP) (*Advertisement, error) {
+	if len(b) < 8 {
+		return nil, fmt.Errorf("the advertisement is too short (%d bytes)", len(b))
+	}
+	a := &Advertisement{
+		Version:  int(b[0] >> 4),
+		RouterID: b[1],
+		Priority: b[2],
+	}
+	if b[0]&0x0f != typeAdvertisement {
+		return nil, fmt.Errorf("unknown VRRP packet type %d", b[0]&0x0f)
+	}
+
+	addressLength := net.IPv4len
+	if src.To4() == nil {
+		addressLength = net.IPv6len
+	}
+	length := 8 + int(b[3])*addressLength
+	switch a.Version {
+	case 2:
+		length += passwordLength
+	case 3:
+	default:
+		return nil, fmt.Errorf("VRRP version %d is not supported", a.Version)
+	}
+	if len(b) < length {
+		return nil, fmt.Errorf("the advertisement is too short (%d bytes) for %d addresses", len(b), b[3])
+	}
+	b = b[:length]
+	if sum := checksum(b, a.Version, src, dst); sum != 0 {
+		return nil, fmt.Errorf("the checksum of the advertisement from [%s] doesn't match", src)
+	}
+
+	if a.Version == 2 {
+		a.Interval = time.Duration(b[5]) * time.Second
+		switch b[4] {
+		case authTypeNone:
+		case authTypeSimple:
+			a.Password = strings.TrimRight(string(b[length-passwordLength:]), "\x00")
+		default:
+			return nil, fmt.Errorf("VRRP authentication type %d is not supported", b[4])
+		}
+	} else {
+		a.Interval = time.Duration(binary.BigEndian.Uint16(b[4:])&0x0fff) * 10 * time.Millisecond
+	}
+	for i := 0; i < int(b[3]); i++ {
+		a.Addresses = append(a.Addresses, net.IP(append([]byte(nil), b[8+i*addressLength:8+(i+1)*addressLength]...)))
+	}
+	return a, nil
+}
+
+// checksum is the internet checksum of the advertisement, which is 0 over an advertisement whose checksum is
+// correct. The checksum of version 3 includes the pseudo-header of IPv4 or IPv6.
+func checksum(b []byte, version int, src, dst net.IP) uint16 {
+	var pseudo []byte
+	if version == 3 {
+		if src4, dst4 := src.To4(), dst.To4(); src4 != nil && dst4 != nil {
+			pseudo = append(append(pseudo, src4...), dst4...)
+			pseudo = append(pseudo, 0, Protocol, byte(len(b)>>8), byte(len(b)))
+		} else {
+			pseudo = append(append(pseudo, src.To16()...), dst.To16()...)
+			pseudo = binary.BigEndian.AppendUint32(pseudo, uint32(len(b)))
+			pseudo = append(pseudo, 0, 0, 0, Protocol)
+		}
+	}
+
+	var sum uint32
+	for _, data := range [][]byte{pseudo, b} {
+		for i := 0; i+1 < len(data); i += 2 {
+			sum += uint32(data[i])<<8 | uint32(data[i+1])
+		}
+		if len(data)%2 == 1 {
+			sum += uint32(data[len(data)-1]) << 8
+		}
+	}
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,84 @@
+package vrrp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAdvertisement(t *testing.T) {
+	src, src6 := net.ParseIP("192.168.0.2"), net.ParseIP("fe80::2")
+	tests := []struct {
+		name     string
+		a        *Advertisement
+		src, dst net.IP
+		length   int
+	}{
+		{
+			name:   "version 3",
+			a:      &Advertisement{Version: 3, RouterID: 51, Priority: 100, Interval: 250 * time.Millisecond, Addresses: []net.IP{net.ParseIP("192.168.0.10")}},
+			src:    src,
+			dst:    groupIPv4,
+			length: 12,
+		},
+		{
+			name:   "version 2 with a password",
+			a:      &Advertisement{Version: 2, RouterID: 51, Priority: 150, Interval: 2 * time.Second, Addresses: []net.IP{net.ParseIP("192.168.0.10"), net.ParseIP("192.168.0.11")}, Password: "secret"},
+			src:    src,
+			dst:    groupIPv4,
+			length: 24,
+		},
+		{
+			name:   "version 3 over IPv6",
+			a:      &Advertisement{Version: 3, RouterID: 7, Priority: 1, Interval: 40950 * time.Millisecond, Addresses: []net.IP{net.ParseIP("fd00::10")}},
+			src:    src6,
+			dst:    groupIPv6,
+			length: 24,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.a.Marshal(tt.src, tt.dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(b) != tt.length {
+				t.Errorf("Marshal() = %d bytes, want %d", len(b), tt.length)
+			}
+
+			got, err := ParseAdvertisement(b, tt.src, tt.dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Version != tt.a.Version || got.RouterID != tt.a.RouterID || got.Priority != tt.a.Priority ||
+				got.Interval != tt.a.Interval || got.Password != tt.a.Password || len(got.Addresses) != len(tt.a.Addresses) {
+				t.Fatalf("ParseAdvertisement() = %+v, want %+v", got, tt.a)
+			}
+			for i := range got.Addresses {
+				if !got.Addresses[i].Equal(tt.a.Addresses[i]) {
+					t.Errorf("address %d = %s, want %s", i, got.Addresses[i], tt.a.Addresses[i])
+				}
+			}
+
+			// A corrupted advertisement, or one from another source with version 3, is discarded
+			b[2]++
+			if _, err := ParseAdvertisement(b, tt.src, tt.dst); err == nil {
+				t.Error("a corrupted advertisement was parsed")
+			}
+		})
+	}
+}
+
+func TestAdvertisementErrors(t *testing.T) {
+	v6 := &Advertisement{Version: 2, RouterID: 1, Priority: 100, Interval: time.Second, Addresses: []net.IP{net.ParseIP("fd00::10")}}
+	if _, err := v6.Marshal(net.ParseIP("fe80::2"), groupIPv6); err == nil {
+		t.Error("VRRPv2 was marshalled over IPv6")
+	}
+	mixed := &Advertisement{Version: 3, RouterID: 1, Priority: 100, Interval: time.Second, Addresses: []net.IP{net.ParseIP("fd00::10")}}
+	if _, err := mixed.Marshal(net.ParseIP("192.168.0.2"), groupIPv4); err == nil {
+		t.Error("an IPv6 address was marshalled into an IPv4 advertisement")
+	}
+	if _, err := ParseAdvertisement([]byte{0x31, 1, 100, 2, 0, 100, 0, 0}, net.ParseIP("192.168.0.2"), groupIPv4); err == nil {
+		t.Error("an advertisement without its addresses was parsed")
+	}
+}
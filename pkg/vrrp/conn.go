@@ -0,0 +1,191 @@
+package vrrp
+
+import (
+	"fmt"
+	"net"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// The advertisements are only accepted with the TTL (hop limit) that they are sent with, so that they can't come
+// from another link
+const ttl = 255
+
+// ipv4Conn sends and receives the advertisements of IPv4 routers on the multicast group
+type ipv4Conn struct {
+	conn    *ipv4.PacketConn
+	ifi     *net.Interface
+	address net.IP
+}
+
+// ipv6Conn sends and receives the advertisements of IPv6 routers, from the link-local address of the interface
+type ipv6Conn struct {
+	conn    *ipv6.PacketConn
+	ifi     *net.Interface
+	address net.IP
+}
+
+// listen joins the multicast group of VRRP on the interface, for the family of the addresses of the router
+func listen(config *Config) (conn, error) {
+	if len(config.Addresses) == 0 {
+		return nil, fmt.Errorf("the virtual router has no addresses")
+	}
+	ifi, err := net.InterfaceByName(config.Interface)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the interface [%s]: %v", config.Interface, err)
+	}
+	v4 := config.Addresses[0].To4() != nil
+	primary, err := primaryAddress(ifi, v4, config.Addresses)
+	if err != nil {
+		return nil, err
+	}
+	if v4 {
+		return listenIPv4(ifi, primary)
+	}
+	return listenIPv6(ifi, primary)
+}
+
+// primaryAddress is the address of the interface that isn't one of the VIPs, it is the source of the advertisements
+// and breaks the ties between routers with the same priority
+func primaryAddress(ifi *net.Interface, v4 bool, vips []net.IP) (net.IP, error) {
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the addresses of [%s]: %v", ifi.Name, err)
+	}
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || (ipnet.IP.To4() != nil) != v4 {
+			continue
+		}
+		// IPv6 advertisements are sent from the link-local address
+		if !v4 && !ipnet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		vip := false
+		for _, address := range vips {
+			vip = vip || address.Equal(ipnet.IP)
+		}
+		if !vip {
+			return ipnet.IP, nil
+		}
+	}
+	return nil, fmt.Errorf("the interface [%s] has no address to send the advertisements from", ifi.Name)
+}
+
+func listenIPv4(ifi *net.Interface, address net.IP) (conn, error) {
+	c, err := net.ListenPacket(fmt.Sprintf("ip4:%d", Protocol), "0.0.0.0")
+	if err != nil {
+		return nil, fmt.Errorf("unable to open the VRRP socket: %v", err)
+	}
+	p := ipv4.NewPacketConn(c)
+	for _, set := range []func() error{
+		func() error { return p.JoinGroup(ifi, &net.IPAddr{IP: groupIPv4}) },
+		func() error { return p.SetMulticastInterface(ifi) },
+		func() error { return p.SetMulticastTTL(ttl) },
+		func() error { return p.SetMulticastLoopback(false) },
+		func() error { return p.SetControlMessage(ipv4.FlagTTL|ipv4.FlagDst|ipv4.FlagInterface, true) },
+	} {
+		if err := set(); err != nil {
+			p.Close()
+			return nil, fmt.Errorf("unable to join the VRRP group on [%s]: %v", ifi.Name, err)
+		}
+	}
+	return &ipv4Conn{conn: p, ifi: ifi, address: address}, nil
+}
+
+func (c *ipv4Conn) primary() net.IP {
+	return c.address
+}
+
+func (c *ipv4Conn) send(a *Advertisement) error {
+	b, err := a.Marshal(c.address, groupIPv4)
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.WriteTo(b, &ipv4.ControlMessage{IfIndex: c.ifi.Index, Src: c.address}, &net.IPAddr{IP: groupIPv4})
+	return err
+}
+
+func (c *ipv4Conn) receive() (*Advertisement, net.IP, error) {
+	buf := make([]byte, 1500)
+	for {
+		n, cm, from, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		src := from.(*net.IPAddr).IP
+		if cm == nil || cm.IfIndex != c.ifi.Index || cm.TTL != ttl {
+			continue
+		}
+		a, err := ParseAdvertisement(buf[:n], src, cm.Dst)
+		if err != nil {
+			log.Debugf("[vrrp] discarding an advertisement from [%s]: %v", src, err)
+			continue
+		}
+		return a, src, nil
+	}
+}
+
+func (c *ipv4Conn) close() error {
+	return c.conn.Close()
+}
+
+func listenIPv6(ifi *net.Interface, address net.IP) (conn, error) {
+	c, err := net.ListenPacket(fmt.Sprintf("ip6:%d", Protocol), "::")
+	if err != nil {
+		return nil, fmt.Errorf("unable to open the VRRP socket: %v", err)
+	}
+	p := ipv6.NewPacketConn(c)
+	for _, set := range []func() error{
+		func() error { return p.JoinGroup(ifi, &net.IPAddr{IP: groupIPv6}) },
+		func() error { return p.SetMulticastInterface(ifi) },
+		func() error { return p.SetMulticastHopLimit(ttl) },
+		func() error { return p.SetMulticastLoopback(false) },
+		func() error { return p.SetControlMessage(ipv6.FlagHopLimit|ipv6.FlagDst|ipv6.FlagInterface, true) },
+	} {
+		if err := set(); err != nil {
+			p.Close()
+			return nil, fmt.Errorf("unable to join the VRRP group on [%s]: %v", ifi.Name, err)
+		}
+	}
+	return &ipv6Conn{conn: p, ifi: ifi, address: address}, nil
+}
+
+func (c *ipv6Conn) primary() net.IP {
+	return c.address
+}
+
+func (c *ipv6Conn) send(a *Advertisement) error {
+	b, err := a.Marshal(c.address, groupIPv6)
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.WriteTo(b, &ipv6.ControlMessage{IfIndex: c.ifi.Index, Src: c.address, HopLimit: ttl}, &net.IPAddr{IP: groupIPv6, Zone: c.ifi.Name})
+	return err
+}
+
+func (c *ipv6Conn) receive() (*Advertisement, net.IP, error) {
+	buf := make([]byte, 1500)
+	for {
+		n, cm, from, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		src := from.(*net.IPAddr).IP
+		if cm == nil || cm.IfIndex != c.ifi.Index || cm.HopLimit != ttl {
+			continue
+		}
+		a, err := ParseAdvertisement(buf[:n], src, cm.Dst)
+		if err != nil {
+			log.Debugf("[vrrp] discarding an advertisement from [%s]: %v", src, err)
+			continue
+		}
+		return a, src, nil
+	}
+}
+
+func (c *ipv6Conn) close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,271 @@
+package vrrp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Config is a virtual router that the VIPs are elected with, the other routers (kube-vip, keepalived or network
+// devices) use the same router ID on the link
+type Config struct {
+	// Interface that the advertisements are sent and received on
+	Interface string
+
+	// RouterID identifies the virtual router on the link (1-255)
+	RouterID uint8
+
+	// Priority of this router (1-254), the router with the highest priority becomes the master
+	Priority uint8
+
+	// Interval between the advertisements of the master
+	Interval time.Duration
+
+	// Preempt lets this router take over from a master with a lower priority
+	Preempt bool
+
+	// Version of VRRP, 3 (RFC 5798) or 2 (RFC 3768)
+	Version int
+
+	// Password is the simple text authentication of version 2
+	Password string
+
+	// Addresses are the VIPs of the virtual router, all of the same family
+	Addresses []net.IP
+}
+
+// Callbacks are called as this router becomes the master and stops being the master, they are named after those of
+// the leader elections so that VRRP can take their place
+type Callbacks struct {
+	// OnStartedLeading is started in a goroutine when this router becomes the master, the context is cancelled when
+	// it stops
+	OnStartedLeading func(context.Context)
+	// OnStoppedLeading is called when this router stops being the master
+	OnStoppedLeading func()
+	// OnNewLeader is called with the address of the master when another router becomes the master
+	OnNewLeader func(identity string)
+}
+
+// State of a virtual router
+type State int
+
+const (
+	Backup State = iota
+	Master
+)
+
+func (s State) String() string {
+	if s == Master {
+		return "master"
+	}
+	return "backup"
+}
+
+// router is the state machine of RFC 5798 (section 6.4), it returns what has to happen after every event so that it
+// can be driven by the advertisements and timers of Run, or by a test
+type router struct {
+	config  *Config
+	primary net.IP
+	state   State
+
+	// The interval of the master, learned from its advertisements with version 3
+	masterInterval time.Duration
+}
+
+func newRouter(config *Config, primary net.IP) *router {
+	return &router{config: config, primary: primary, masterInterval: config.Interval}
+}
+
+func (r *router) skew() time.Duration {
+	return time.Duration(256-int(r.config.Priority)) * r.masterInterval / 256
+}
+
+func (r *router) masterDownInterval() time.Duration {
+	return 3*r.masterInterval + r.skew()
+}
+
+// start makes the router a backup, that becomes the master if it doesn't hear from one
+func (r *router) start() time.Duration {
+	r.state = Backup
+	return r.masterDownInterval()
+}
+
+// timeout is the expiry of the master down timer of a backup, or the advertisement timer of the master. It returns
+// the next timer, and the master advertises every time.
+func (r *router) timeout() time.Duration {
+	r.state = Master
+	return r.config.Interval
+}
+
+// receive handles an advertisement from another router, it returns the next timer if the timer is reset, and whether
+// the master has to advertise immediately
+func (r *router) receive(a *Advertisement, src net.IP) (time.Duration, bool) {
+	switch r.state {
+	case Backup:
+		if a.Priority == 0 {
+			// The master is shutting down, the backups take over after the skew so that the highest priority wins
+			return r.skew(), false
+		}
+		if !r.config.Preempt || a.Priority >= r.config.Priority {
+			if a.Version == 3 {
+				r.masterInterval = a.Interval
+			}
+			return r.masterDownInterval(), false
+		}
+		// A master with a lower priority is taken over once the timer expires
+		return 0, false
+	case Master:
+		if a.Priority == 0 {
+			return r.config.Interval, true
+		}
+		if a.Priority > r.config.Priority || (a.Priority == r.config.Priority && bytes.Compare(src.To16(), r.primary.To16()) > 0) {
+			r.state = Backup
+			if a.Version == 3 {
+				r.masterInterval = a.Interval
+			}
+			return r.masterDownInterval(), false
+		}
+	}
+	return 0, false
+}
+
+// advertisement is the advertisement of this router, with the priority 0 when it stops being the master
+func (r *router) advertisement(priority uint8) *Advertisement {
+	return &Advertisement{
+		Version:   r.config.Version,
+		RouterID:  r.config.RouterID,
+		Priority:  priority,
+		Interval:  r.config.Interval,
+		Addresses: r.config.Addresses,
+		Password:  r.config.Password,
+	}
+}
+
+// conn sends and receives the advertisements of the virtual router on its interface
+type conn interface {
+	// primary is the address of the interface that the advertisements are sent from
+	primary() net.IP
+	send(a *Advertisement) error
+	// receive blocks until an advertisement is received, the TTL and checksum have been checked
+	receive() (*Advertisement, net.IP, error)
+	close() error
+}
+
+type received struct {
+	advertisement *Advertisement
+	src           net.IP
+}
+
+// Run runs the virtual router until the context is cancelled, a master sends an advertisement with the priority 0 as
+// it stops so that a backup takes over straight away
+func Run(ctx context.Context, config *Config, callbacks Callbacks) error {
+	c, err := listen(config)
+	if err != nil {
+		return err
+	}
+	return run(ctx, config, c, callbacks)
+}
+
+func run(ctx context.Context, config *Config, c conn, callbacks Callbacks) error {
+	defer c.close()
+
+	adverts := make(chan received)
+	go func() {
+		for {
+			a, src, err := c.receive()
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Errorf("[vrrp] unable to receive the advertisements: %v", err)
+				}
+				return
+			}
+			select {
+			case adverts <- received{advertisement: a, src: src}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	r := newRouter(config, c.primary())
+	timer := time.NewTimer(r.start())
+	defer timer.Stop()
+	log.Infof("[vrrp] virtual router [%d] on [%s] is starting as a backup, priority [%d]", config.RouterID, config.Interface, config.Priority)
+
+	// The address of the current master, and the context of this router's time as the master
+	var master string
+	cancelMaster := func() {}
+	defer func() { cancelMaster() }()
+
+	transition := func(previous State) {
+		if r.state == previous {
+			return
+		}
+		log.Infof("[vrrp] virtual router [%d] is now the %s", config.RouterID, r.state)
+		if r.state == Master {
+			master = ""
+			var masterCtx context.Context
+			masterCtx, cancelMaster = context.WithCancel(ctx)
+			go callbacks.OnStartedLeading(masterCtx)
+		} else {
+			cancelMaster()
+			callbacks.OnStoppedLeading()
+		}
+	}
+
+	send := func(priority uint8) {
+		if err := c.send(r.advertisement(priority)); err != nil {
+			log.Warnf("[vrrp] unable to send the advertisement: %v", err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if r.state == Master {
+				send(0)
+				r.state = Backup
+				callbacks.OnStoppedLeading()
+			}
+			return nil
+		case <-timer.C:
+			previous := r.state
+			timer.Reset(r.timeout())
+			send(config.Priority)
+			transition(previous)
+		case rcv := <-adverts:
+			a := rcv.advertisement
+			if a.RouterID != config.RouterID {
+				continue
+			}
+			if a.Version != config.Version || a.Password != config.Password {
+				log.Warnf("[vrrp] ignoring an advertisement of virtual router [%d] from [%s] with another version or password", a.RouterID, rcv.src)
+				continue
+			}
+			previous := r.state
+			next, advertise := r.receive(a, rcv.src)
+			if next != 0 {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(next)
+			}
+			if advertise {
+				send(config.Priority)
+			}
+			transition(previous)
+			if r.state == Backup && a.Priority != 0 && rcv.src.String() != master {
+				master = rcv.src.String()
+				if callbacks.OnNewLeader != nil {
+					callbacks.OnNewLeader(master)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package vrrp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRouter(t *testing.T) {
+	config := &Config{RouterID: 51, Priority: 100, Interval: time.Second, Preempt: true, Version: 3}
+	primary := net.ParseIP("192.168.0.2")
+	lower, higher := net.ParseIP("192.168.0.1"), net.ParseIP("192.168.0.3")
+	advert := func(priority uint8) *Advertisement {
+		return &Advertisement{Version: 3, RouterID: 51, Priority: priority, Interval: 2 * time.Second}
+	}
+
+	tests := []struct {
+		name      string
+		state     State
+		preempt   bool
+		a         *Advertisement
+		src       net.IP
+		wantState State
+		wantTimer time.Duration
+		wantSend  bool
+	}{
+		{name: "backup hears the master", state: Backup, preempt: true, a: advert(150), src: higher, wantState: Backup, wantTimer: 6*time.Second + 2*time.Second*156/256},
+		{name: "backup preempts a lower priority", state: Backup, preempt: true, a: advert(50), src: higher, wantState: Backup},
+		{name: "backup without preemption", state: Backup, a: advert(50), src: higher, wantState: Backup, wantTimer: 6*time.Second + 2*time.Second*156/256},
+		{name: "master shuts down", state: Backup, preempt: true, a: advert(0), src: higher, wantState: Backup, wantTimer: time.Second * 156 / 256},
+		{name: "master hears a higher priority", state: Master, preempt: true, a: advert(150), src: lower, wantState: Backup, wantTimer: 6*time.Second + 2*time.Second*156/256},
+		{name: "master hears a lower priority", state: Master, preempt: true, a: advert(50), src: higher, wantState: Master},
+		{name: "master wins the tie", state: Master, preempt: true, a: advert(100), src: lower, wantState: Master},
+		{name: "master loses the tie", state: Master, preempt: true, a: advert(100), src: higher, wantState: Backup, wantTimer: 6*time.Second + 2*time.Second*156/256},
+		{name: "master answers a shutdown", state: Master, preempt: true, a: advert(0), src: higher, wantState: Master, wantTimer: time.Second, wantSend: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := *config
+			c.Preempt = tt.preempt
+			r := newRouter(&c, primary)
+			r.state = tt.state
+
+			timer, send := r.receive(tt.a, tt.src)
+			if r.state != tt.wantState || timer != tt.wantTimer || send != tt.wantSend {
+				t.Errorf("receive() = %s, %s, %t, want %s, %s, %t", r.state, timer, send, tt.wantState, tt.wantTimer, tt.wantSend)
+			}
+		})
+	}
+}
+
+func TestRouterStart(t *testing.T) {
+	r := newRouter(&Config{Priority: 200, Interval: time.Second}, net.ParseIP("192.168.0.2"))
+	// The higher the priority, the sooner a backup takes over
+	if timer := r.start(); r.state != Backup || timer != 3*time.Second+time.Second*56/256 {
+		t.Errorf("start() = %s, %s", r.state, timer)
+	}
+	if timer := r.timeout(); r.state != Master || timer != time.Second {
+		t.Errorf("timeout() = %s, %s", r.state, timer)
+	}
+}
+
+// fakeConn is the link, the advertisements of other routers are written to it and those of the router are read
+type fakeConn struct {
+	adverts chan received
+	sent    chan *Advertisement
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{adverts: make(chan received), sent: make(chan *Advertisement, 1000), closed: make(chan struct{})}
+}
+
+func (c *fakeConn) primary() net.IP {
+	return net.ParseIP("192.168.0.2")
+}
+
+func (c *fakeConn) send(a *Advertisement) error {
+	c.sent <- a
+	return nil
+}
+
+func (c *fakeConn) receive() (*Advertisement, net.IP, error) {
+	select {
+	case r := <-c.adverts:
+		return r.advertisement, r.src, nil
+	case <-c.closed:
+		return nil, nil, errors.New("closed")
+	}
+}
+
+func (c *fakeConn) close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	config := &Config{RouterID: 51, Priority: 100, Interval: 10 * time.Millisecond, Preempt: true, Version: 3, Addresses: []net.IP{net.ParseIP("192.168.0.10")}}
+	c := newFakeConn()
+	started, stopped, leaders := make(chan context.Context, 1), make(chan struct{}, 1), make(chan string, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- run(ctx, config, c, Callbacks{
+			OnStartedLeading: func(ctx context.Context) { started <- ctx },
+			OnStoppedLeading: func() { stopped <- struct{}{} },
+			OnNewLeader:      func(identity string) { leaders <- identity },
+		})
+	}()
+
+	// Without a master the router takes over
+	var masterCtx context.Context
+	select {
+	case masterCtx = <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the router didn't become the master")
+	}
+	if a := <-c.sent; a.Priority != 100 || a.RouterID != 51 {
+		t.Errorf("advertisement = %+v", a)
+	}
+
+	// Advertisements of other virtual routers are ignored, a higher priority takes over
+	c.adverts <- received{advertisement: &Advertisement{Version: 3, RouterID: 52, Priority: 255, Interval: 10 * time.Millisecond}, src: net.ParseIP("192.168.0.3")}
+	c.adverts <- received{advertisement: &Advertisement{Version: 3, RouterID: 51, Priority: 200, Interval: 10 * time.Millisecond}, src: net.ParseIP("192.168.0.3")}
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the router didn't step down")
+	}
+	if masterCtx.Err() == nil {
+		t.Error("the context of the master wasn't cancelled")
+	}
+	if leader := <-leaders; leader != "192.168.0.3" {
+		t.Errorf("OnNewLeader(%s), want 192.168.0.3", leader)
+	}
+
+	// The new master shuts down, this router takes over again and sends priority 0 as it stops
+	c.adverts <- received{advertisement: &Advertisement{Version: 3, RouterID: 51, Priority: 0, Interval: 10 * time.Millisecond}, src: net.ParseIP("192.168.0.3")}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the router didn't take over")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	<-stopped
+	var last *Advertisement
+	for len(c.sent) > 0 {
+		last = <-c.sent
+	}
+	if last == nil || last.Priority != 0 {
+		t.Errorf("the last advertisement = %+v, want priority 0", last)
+	}
+}
@@ -0,0 +1,271 @@
+package wireguard
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.zx2c4.com/wireguard/wgctrl"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// MeshDevice is the wireguard interface of the mesh between kube-vip nodes, it is separate from the tunnel to the
+	// external peer as every node has its own key in the mesh
+	MeshDevice = "kube-vip-mesh"
+
+	// DefaultMeshPort is the port that the mesh listens on (on every node), unless another one is configured
+	DefaultMeshPort = 51821
+
+	// PublicKeyAnnotation, EndpointAnnotation and AllowedIPsAnnotation are published on each node in the mesh, they
+	// are how the other nodes discover it
+	PublicKeyAnnotation  = "kube-vip.io/wireguard-public-key"
+	EndpointAnnotation   = "kube-vip.io/wireguard-endpoint"
+	AllowedIPsAnnotation = "kube-vip.io/wireguard-allowed-ips"
+)
+
+// MeshPeer is another node in the mesh
+type MeshPeer struct {
+	Node      string
+	PublicKey wgtypes.Key
+	Endpoint  *net.UDPAddr
+	// AllowedIPs are the addresses that are tunneled to the node, its pod CIDRs and its own address
+	AllowedIPs []net.IPNet
+}
+
+// PeerFromNode returns the mesh peer of a node from its annotations, false is returned if the node hasn't joined the
+// mesh
+func PeerFromNode(node *v1.Node) (MeshPeer, bool, error) {
+	key, exists := node.Annotations[PublicKeyAnnotation]
+	if !exists {
+		return MeshPeer{}, false, nil
+	}
+	peer := MeshPeer{Node: node.Name}
+	var err error
+	if peer.PublicKey, err = wgtypes.ParseKey(key); err != nil {
+		return MeshPeer{}, false, fmt.Errorf("node [%s] has an invalid wireguard public key: %v", node.Name, err)
+	}
+	if peer.Endpoint, err = net.ResolveUDPAddr("udp", node.Annotations[EndpointAnnotation]); err != nil || peer.Endpoint.IP == nil {
+		return MeshPeer{}, false, fmt.Errorf("node [%s] has an invalid wireguard endpoint [%s]", node.Name, node.Annotations[EndpointAnnotation])
+	}
+	for _, cidr := range strings.Split(node.Annotations[AllowedIPsAnnotation], ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return MeshPeer{}, false, fmt.Errorf("node [%s] has an invalid wireguard allowed IP [%s]", node.Name, cidr)
+		}
+		peer.AllowedIPs = append(peer.AllowedIPs, *network)
+	}
+	return peer, true, nil
+}
+
+// NodeAddress returns the internal address of a node, or its external address if it doesn't have one
+func NodeAddress(node *v1.Node) net.IP {
+	var external net.IP
+	for _, address := range node.Status.Addresses {
+		ip := net.ParseIP(address.Address)
+		if ip == nil {
+			continue
+		}
+		switch address.Type {
+		case v1.NodeInternalIP:
+			return ip
+		case v1.NodeExternalIP:
+			if external == nil {
+				external = ip
+			}
+		}
+	}
+	return external
+}
+
+// Mesh is the wireguard mesh between kube-vip nodes, every node generates its own key when it starts and publishes
+// the public key on its node
+type Mesh struct {
+	mutex      sync.Mutex
+	client     func() (deviceClient, func(), error)
+	setRoutes  func([]net.IPNet) error
+	privateKey wgtypes.Key
+	options    Options
+	// peers are the last peers that were reconciled, they're configured again when the preshared keys change
+	peers         []MeshPeer
+	presharedKeys PresharedKeys
+}
+
+// NewMesh creates the mesh with a new key
+func NewMesh(options Options) (*Mesh, error) {
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &Mesh{
+		client: func() (deviceClient, func(), error) {
+			c, err := wgctrl.New()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to open client: %v", err)
+			}
+			return c, func() { c.Close() }, nil
+		},
+		setRoutes:  setMeshRoutes,
+		privateKey: key,
+		options:    options,
+	}, nil
+}
+
+// Annotations returns the annotations that the node publishes so that the other nodes can peer with it
+func (m *Mesh) Annotations(address net.IP, podCIDRs []string) map[string]string {
+	bits := 32
+	if address.To4() == nil {
+		bits = 128
+	}
+	allowed := append([]string{(&net.IPNet{IP: address, Mask: net.CIDRMask(bits, bits)}).String()}, podCIDRs...)
+	return map[string]string{
+		PublicKeyAnnotation:  m.privateKey.PublicKey().String(),
+		EndpointAnnotation:   net.JoinHostPort(address.String(), strconv.Itoa(*m.options.port(DefaultMeshPort))),
+		AllowedIPsAnnotation: strings.Join(allowed, ","),
+	}
+}
+
+// Start creates the interface of the mesh and configures it with the key of this node
+func (m *Mesh) Start() error {
+	link, err := netlink.LinkByName(MeshDevice)
+	if err != nil {
+		if err = netlink.LinkAdd(&netlink.Wireguard{LinkAttrs: netlink.LinkAttrs{Name: MeshDevice}}); err != nil {
+			return fmt.Errorf("unable to create %s: %v", MeshDevice, err)
+		}
+		if link, err = netlink.LinkByName(MeshDevice); err != nil {
+			return err
+		}
+	}
+	if err := m.options.SetMTU(MeshDevice); err != nil {
+		return err
+	}
+	if err := netlink.LinkSetUp(link); err != nil {
+		return fmt.Errorf("unable to bring up %s: %v", MeshDevice, err)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	cfg := wgtypes.Config{PrivateKey: &m.privateKey, ListenPort: m.options.port(DefaultMeshPort), ReplacePeers: true}
+	if m.options.FirewallMark != 0 {
+		cfg.FirewallMark = &m.options.FirewallMark
+	}
+	return m.configure(cfg)
+}
+
+// configure applies the configuration to the mesh interface
+func (m *Mesh) configure(cfg wgtypes.Config) error {
+	client, done, err := m.client()
+	if err != nil {
+		return err
+	}
+	defer done()
+	if err := client.ConfigureDevice(MeshDevice, cfg); err != nil {
+		return fmt.Errorf("unable to configure %s: %v", MeshDevice, err)
+	}
+	return nil
+}
+
+// Reconcile will configure the peers of the mesh, peers that are no longer in the list are removed. The sessions with
+// peers that haven't changed are kept.
+func (m *Mesh) Reconcile(peers []MeshPeer) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.reconcile(peers)
+}
+
+// SetPresharedKeys replaces the preshared keys with the other nodes, the peers are configured with the new keys
+func (m *Mesh) SetPresharedKeys(keys PresharedKeys) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.presharedKeys = keys
+	return m.reconcile(m.peers)
+}
+
+// reconcile configures the peers, the mutex has to be held
+func (m *Mesh) reconcile(peers []MeshPeer) error {
+	client, done, err := m.client()
+	if err != nil {
+		return err
+	}
+	device, err := client.Device(MeshDevice)
+	done()
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %v", MeshDevice, err)
+	}
+
+	ka := 20 * time.Second
+	var configs []wgtypes.PeerConfig
+	var routes []net.IPNet
+	desired := map[wgtypes.Key]bool{}
+	for _, peer := range peers {
+		if peer.PublicKey == m.privateKey.PublicKey() {
+			// This node
+			continue
+		}
+		desired[peer.PublicKey] = true
+		psk := m.presharedKeys.For(peer.Node)
+		configs = append(configs, wgtypes.PeerConfig{
+			PublicKey:                   peer.PublicKey,
+			Endpoint:                    peer.Endpoint,
+			PresharedKey:                &psk,
+			PersistentKeepaliveInterval: &ka,
+			ReplaceAllowedIPs:           true,
+			AllowedIPs:                  peer.AllowedIPs,
+		})
+		for _, network := range peer.AllowedIPs {
+			// The encrypted packets to the peer can't be routed through the mesh
+			if !network.Contains(peer.Endpoint.IP) {
+				routes = append(routes, network)
+			}
+		}
+	}
+	for _, existing := range device.Peers {
+		if !desired[existing.PublicKey] {
+			configs = append(configs, wgtypes.PeerConfig{PublicKey: existing.PublicKey, Remove: true})
+		}
+	}
+
+	if err := m.configure(wgtypes.Config{Peers: configs}); err != nil {
+		return err
+	}
+	m.peers = peers
+	return m.setRoutes(routes)
+}
+
+// setMeshRoutes routes the networks through the mesh interface, routes to networks that are no longer in the mesh are
+// removed
+func setMeshRoutes(networks []net.IPNet) error {
+	link, err := netlink.LinkByName(MeshDevice)
+	if err != nil {
+		return err
+	}
+	existing, err := netlink.RouteList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		return err
+	}
+	var wanted []string
+	for x := range networks {
+		wanted = append(wanted, networks[x].String())
+		if err := netlink.RouteReplace(&netlink.Route{LinkIndex: link.Attrs().Index, Dst: &networks[x], Scope: netlink.SCOPE_LINK}); err != nil {
+			return fmt.Errorf("unable to route [%s] through %s: %v", networks[x].String(), MeshDevice, err)
+		}
+	}
+	for x := range existing {
+		if existing[x].Dst != nil && !slices.Contains(wanted, existing[x].Dst.String()) {
+			if err := netlink.RouteDel(&existing[x]); err != nil {
+				log.Warnf("(wireguard) unable to remove route to [%s] from %s: %v", existing[x].Dst, MeshDevice, err)
+			}
+		}
+	}
+	return nil
+}
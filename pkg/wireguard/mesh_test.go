@@ -0,0 +1,98 @@
+package wireguard
+
+import (
+	"net"
+	"testing"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newTestMesh creates a mesh on a fake device, the routes that are set are returned through the pointer
+func newTestMesh(t *testing.T, device *fakeDevice, routes *[]net.IPNet) *Mesh {
+	t.Helper()
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Mesh{
+		client: func() (deviceClient, func(), error) {
+			return device, func() {}, nil
+		},
+		setRoutes: func(networks []net.IPNet) error {
+			*routes = networks
+			return nil
+		},
+		privateKey: key,
+	}
+}
+
+// meshNode returns a node that has joined the mesh
+func meshNode(t *testing.T, name, address string, podCIDRs ...string) (*v1.Node, *Mesh) {
+	t.Helper()
+	mesh := newTestMesh(t, &fakeDevice{}, &[]net.IPNet{})
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: mesh.Annotations(net.ParseIP(address), podCIDRs)},
+	}, mesh
+}
+
+func TestPeerFromNode(t *testing.T) {
+	node, mesh := meshNode(t, "node-1", "192.168.0.10", "10.244.1.0/24")
+	peer, joined, err := PeerFromNode(node)
+	if err != nil || !joined {
+		t.Fatalf("PeerFromNode() = %v, %v", joined, err)
+	}
+	if peer.PublicKey != mesh.privateKey.PublicKey() || peer.Endpoint.String() != "192.168.0.10:51821" || len(peer.AllowedIPs) != 2 {
+		t.Errorf("PeerFromNode() = %+v", peer)
+	}
+
+	if _, joined, err := PeerFromNode(&v1.Node{}); joined || err != nil {
+		t.Errorf("PeerFromNode() of a node without annotations = %v, %v", joined, err)
+	}
+	node.Annotations[EndpointAnnotation] = "invalid"
+	if _, _, err := PeerFromNode(node); err == nil {
+		t.Error("PeerFromNode() expected an error with an invalid endpoint")
+	}
+}
+
+func TestMeshReconcile(t *testing.T) {
+	device := &fakeDevice{}
+	var routes []net.IPNet
+	mesh := newTestMesh(t, device, &routes)
+
+	self := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: mesh.Annotations(net.ParseIP("192.168.0.9"), []string{"10.244.0.0/24"})}}
+	node1, _ := meshNode(t, "node-1", "192.168.0.10", "10.244.1.0/24")
+	node2, _ := meshNode(t, "node-2", "192.168.0.11", "10.244.2.0/24")
+
+	var peers []MeshPeer
+	for _, node := range []*v1.Node{self, node1, node2} {
+		peer, _, err := PeerFromNode(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		peers = append(peers, peer)
+	}
+	if err := mesh.Reconcile(peers); err != nil {
+		t.Fatal(err)
+	}
+	// This node isn't a peer of itself
+	if len(device.peers) != 2 {
+		t.Fatalf("expected 2 peers, got %d", len(device.peers))
+	}
+	// The pod CIDRs are routed through the mesh, the node addresses are the endpoints and aren't
+	if len(routes) != 2 || routes[0].String() != "10.244.1.0/24" || routes[1].String() != "10.244.2.0/24" {
+		t.Errorf("unexpected routes %v", routes)
+	}
+
+	// A node that leaves the mesh is removed
+	if err := mesh.Reconcile(peers[:2]); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := device.peers[peers[2].PublicKey]; exists || len(device.peers) != 1 {
+		t.Errorf("expected node-2 to be removed, got %d peers", len(device.peers))
+	}
+	if len(routes) != 1 {
+		t.Errorf("unexpected routes %v", routes)
+	}
+}
@@ -0,0 +1,78 @@
+package wireguard
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"golang.zx2c4.com/wireguard/wgctrl"
+)
+
+var (
+	peerInfoDesc = prometheus.NewDesc("kube_vip_wireguard_peer_info",
+		"Peers of the wireguard interfaces, with their current endpoint", []string{"device", "public_key", "endpoint"}, nil)
+	peerHandshakeAgeDesc = prometheus.NewDesc("kube_vip_wireguard_peer_last_handshake_age_seconds",
+		"Seconds since the last handshake with the peer, peers that have never completed a handshake are left out", []string{"device", "public_key"}, nil)
+	peerReceiveBytesDesc = prometheus.NewDesc("kube_vip_wireguard_peer_receive_bytes_total",
+		"Bytes received from the peer", []string{"device", "public_key"}, nil)
+	peerTransmitBytesDesc = prometheus.NewDesc("kube_vip_wireguard_peer_transmit_bytes_total",
+		"Bytes transmitted to the peer", []string{"device", "public_key"}, nil)
+)
+
+// Collector exports the peers of the wireguard interfaces, they're read from the interfaces when they're collected
+type Collector struct {
+	client  func() (deviceClient, func(), error)
+	devices []string
+}
+
+// NewCollector creates a collector for the interfaces, interfaces that don't exist are skipped
+func NewCollector(devices ...string) *Collector {
+	return &Collector{
+		client: func() (deviceClient, func(), error) {
+			c, err := wgctrl.New()
+			if err != nil {
+				return nil, nil, err
+			}
+			return c, func() { c.Close() }, nil
+		},
+		devices: devices,
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- peerInfoDesc
+	ch <- peerHandshakeAgeDesc
+	ch <- peerReceiveBytesDesc
+	ch <- peerTransmitBytesDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	client, done, err := c.client()
+	if err != nil {
+		log.Debugf("(wireguard) unable to collect metrics: %v", err)
+		return
+	}
+	defer done()
+
+	for _, name := range c.devices {
+		device, err := client.Device(name)
+		if err != nil {
+			continue
+		}
+		for _, peer := range device.Peers {
+			key := peer.PublicKey.String()
+			endpoint := ""
+			if peer.Endpoint != nil {
+				endpoint = peer.Endpoint.String()
+			}
+			ch <- prometheus.MustNewConstMetric(peerInfoDesc, prometheus.GaugeValue, 1, name, key, endpoint)
+			if !peer.LastHandshakeTime.IsZero() {
+				ch <- prometheus.MustNewConstMetric(peerHandshakeAgeDesc, prometheus.GaugeValue, time.Since(peer.LastHandshakeTime).Seconds(), name, key)
+			}
+			ch <- prometheus.MustNewConstMetric(peerReceiveBytesDesc, prometheus.CounterValue, float64(peer.ReceiveBytes), name, key)
+			ch <- prometheus.MustNewConstMetric(peerTransmitBytesDesc, prometheus.CounterValue, float64(peer.TransmitBytes), name, key)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package wireguard
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func TestCollector(t *testing.T) {
+	handshake, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	never, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	device := &fakeDevice{peers: map[wgtypes.Key]*wgtypes.Peer{
+		handshake.PublicKey(): {
+			PublicKey:         handshake.PublicKey(),
+			Endpoint:          &net.UDPAddr{IP: net.ParseIP("192.168.0.1"), Port: 51820},
+			LastHandshakeTime: time.Now().Add(-time.Minute),
+			ReceiveBytes:      100,
+			TransmitBytes:     200,
+		},
+		never.PublicKey(): {PublicKey: never.PublicKey()},
+	}}
+	collector := &Collector{
+		client: func() (deviceClient, func(), error) {
+			return device, func() {}, nil
+		},
+		devices: []string{Device},
+	}
+
+	// Both peers have info and byte counters, only one has a handshake age
+	if got := testutil.CollectAndCount(collector); got != 7 {
+		t.Errorf("CollectAndCount() = %d, want 7", got)
+	}
+	if got := testutil.CollectAndCount(collector, "kube_vip_wireguard_peer_last_handshake_age_seconds"); got != 1 {
+		t.Errorf("handshake age metrics = %d, want 1", got)
+	}
+}
+
+func TestLastHandshake(t *testing.T) {
+	device := &fakeDevice{}
+	tunnel := &Tunnel{client: func() (deviceClient, func(), error) {
+		return device, func() {}, nil
+	}}
+	if _, err := tunnel.LastHandshake(); err == nil {
+		t.Error("LastHandshake() expected an error before the tunnel is configured")
+	}
+
+	private, _ := newKey(t)
+	_, peer := newKey(t)
+	if err := tunnel.Apply(Keys{PrivateKey: private, PeerPublicKey: peer, PeerEndpoint: "192.168.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	handshake := time.Now().Add(-time.Minute)
+	k, _ := wgtypes.ParseKey(peer)
+	device.peers[k].LastHandshakeTime = handshake
+	got, err := tunnel.LastHandshake()
+	if err != nil || !got.Equal(handshake) {
+		t.Errorf("LastHandshake() = %v, %v, want %v", got, err, handshake)
+	}
+}
@@ -0,0 +1,110 @@
+package wireguard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// ProbeFailures is how many probes in a row have to fail before the tunnel is down, so that a single lost packet
+	// doesn't move the VIPs
+	ProbeFailures = 3
+
+	// ProbeTimeout is how long a probe waits for the reply
+	ProbeTimeout = 2 * time.Second
+)
+
+// Prober pings an address through the tunnel, the handshakes only show that the peer can be reached and not that
+// traffic gets through it
+type Prober struct {
+	device   string
+	address  net.IP
+	ping     func(ctx context.Context, device string, address net.IP, id, seq int) error
+	id       int
+	seq      int
+	failures int
+}
+
+// NewProber creates a prober of the address through the interface
+func NewProber(device string, address net.IP) *Prober {
+	return &Prober{device: device, address: address, ping: ping, id: os.Getpid() & 0xffff}
+}
+
+// Healthy sends a probe and returns false once ProbeFailures probes in a row have failed, along with the error of
+// the last probe
+func (p *Prober) Healthy(ctx context.Context) (bool, error) {
+	p.seq = (p.seq + 1) & 0xffff
+	ctx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	defer cancel()
+	err := p.ping(ctx, p.device, p.address, p.id, p.seq)
+	if err == nil {
+		p.failures = 0
+		return true, nil
+	}
+	p.failures++
+	return p.failures < ProbeFailures, err
+}
+
+// ping sends an ICMP echo request through the interface and waits for the reply
+func ping(ctx context.Context, device string, address net.IP, id, seq int) error {
+	network, local, protocol := "ip4:icmp", "0.0.0.0", 1
+	var request, reply icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	if address.To4() == nil {
+		network, local, protocol = "ip6:ipv6-icmp", "::", 58
+		request, reply = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	}
+
+	// The socket is bound to the interface, so the probe can't take another route to the address
+	lc := net.ListenConfig{Control: func(_, _ string, c syscall.RawConn) error {
+		var err error
+		if cerr := c.Control(func(fd uintptr) { err = unix.BindToDevice(int(fd), device) }); cerr != nil {
+			return cerr
+		}
+		return err
+	}}
+	conn, err := lc.ListenPacket(ctx, network, local)
+	if err != nil {
+		return fmt.Errorf("unable to open the probe socket on %s: %v", device, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	// The kernel calculates the checksum of ICMPv6
+	msg := icmp.Message{Type: request, Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("kube-vip")}}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.WriteTo(b, &net.IPAddr{IP: address}); err != nil {
+		return fmt.Errorf("unable to probe [%s]: %v", address, err)
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return fmt.Errorf("no reply from [%s]: %v", address, err)
+		}
+		if addr, ok := from.(*net.IPAddr); !ok || !addr.IP.Equal(address) {
+			continue
+		}
+		m, err := icmp.ParseMessage(protocol, buf[:n])
+		if err != nil || m.Type != reply {
+			continue
+		}
+		if echo, ok := m.Body.(*icmp.Echo); ok && echo.ID == id && echo.Seq == seq {
+			return nil
+		}
+	}
+}
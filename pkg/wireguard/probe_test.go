@@ -0,0 +1,47 @@
+package wireguard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestProber(t *testing.T) {
+	var fail bool
+	prober := &Prober{ping: func(context.Context, string, net.IP, int, int) error {
+		if fail {
+			return fmt.Errorf("timeout")
+		}
+		return nil
+	}}
+	if healthy, err := prober.Healthy(context.Background()); !healthy || err != nil {
+		t.Fatalf("Healthy() = %v, %v", healthy, err)
+	}
+
+	// A few lost probes don't make the tunnel unhealthy
+	fail = true
+	for x := 1; x < ProbeFailures; x++ {
+		if healthy, err := prober.Healthy(context.Background()); !healthy || err == nil {
+			t.Fatalf("Healthy() after %d failures = %v, %v", x, healthy, err)
+		}
+	}
+	if healthy, _ := prober.Healthy(context.Background()); healthy {
+		t.Errorf("Healthy() after %d failures = true", ProbeFailures)
+	}
+
+	// A reply resets the failures
+	fail = false
+	if healthy, _ := prober.Healthy(context.Background()); !healthy {
+		t.Error("Healthy() = false after a reply")
+	}
+}
+
+func TestPing(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), ProbeTimeout)
+	defer cancel()
+	if err := ping(ctx, "lo", net.ParseIP("127.0.0.1"), 1, 1); err != nil {
+		// Raw sockets need NET_RAW
+		t.Skipf("unable to ping through the loopback interface: %v", err)
+	}
+}
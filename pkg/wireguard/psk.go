@@ -0,0 +1,59 @@
+package wireguard
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// PresharedKeyName is the name of the preshared key in a secret, in the secret of the mesh it is used between every
+// pair of nodes that doesn't have its own key
+const PresharedKeyName = "presharedKey"
+
+// PresharedKeys are the preshared keys of a node with the other nodes of the mesh
+type PresharedKeys struct {
+	// Default is used with the nodes that don't have their own key
+	Default *wgtypes.Key
+	// Nodes are the keys with specific nodes
+	Nodes map[string]wgtypes.Key
+}
+
+// For returns the preshared key with the node, the zero key (no preshared key) is returned if there isn't one
+func (p PresharedKeys) For(node string) wgtypes.Key {
+	if key, exists := p.Nodes[node]; exists {
+		return key
+	}
+	if p.Default != nil {
+		return *p.Default
+	}
+	return wgtypes.Key{}
+}
+
+// MeshPresharedKeys returns the preshared keys of a node from the data of a secret. A key named after two nodes
+// ("<node>_<node>", in either order) is used between them, as both nodes need the same key, and "presharedKey" is
+// used between every other pair of nodes.
+func MeshPresharedKeys(node string, data map[string][]byte) (PresharedKeys, error) {
+	keys := PresharedKeys{Nodes: map[string]wgtypes.Key{}}
+	for name, value := range data {
+		key, err := wgtypes.ParseKey(strings.TrimSpace(string(value)))
+		if err != nil {
+			return PresharedKeys{}, fmt.Errorf("failed to parse preshared key [%s]: %v", name, err)
+		}
+		if name == PresharedKeyName {
+			keys.Default = &key
+			continue
+		}
+		// Node names can't contain an underscore, so it can separate them
+		a, b, found := strings.Cut(name, "_")
+		switch {
+		case !found:
+			return PresharedKeys{}, fmt.Errorf("preshared key [%s] isn't named after two nodes (<node>_<node>)", name)
+		case a == node:
+			keys.Nodes[b] = key
+		case b == node:
+			keys.Nodes[a] = key
+		}
+	}
+	return keys, nil
+}
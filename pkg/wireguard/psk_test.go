@@ -0,0 +1,65 @@
+package wireguard
+
+import (
+	"net"
+	"testing"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func TestMeshPresharedKeys(t *testing.T) {
+	shared, _ := wgtypes.GenerateKey()
+	pair, _ := wgtypes.GenerateKey()
+	other, _ := wgtypes.GenerateKey()
+
+	keys, err := MeshPresharedKeys("node-1", map[string][]byte{
+		PresharedKeyName: []byte(shared.String()),
+		"node-2_node-1":  []byte(pair.String() + "\n"),
+		"node-2_node-3":  []byte(other.String()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.For("node-2") != pair {
+		t.Error("expected the key of the pair with node-2")
+	}
+	if keys.For("node-3") != shared {
+		t.Error("expected the shared key with node-3, the key between node-2 and node-3 isn't used by node-1")
+	}
+	if (PresharedKeys{}).For("node-2") != (wgtypes.Key{}) {
+		t.Error("expected no preshared key without a secret")
+	}
+
+	if _, err := MeshPresharedKeys("node-1", map[string][]byte{"node-2": []byte(pair.String())}); err == nil {
+		t.Error("MeshPresharedKeys() expected an error with a key that isn't named after two nodes")
+	}
+	if _, err := MeshPresharedKeys("node-1", map[string][]byte{PresharedKeyName: []byte("invalid")}); err == nil {
+		t.Error("MeshPresharedKeys() expected an error with an invalid key")
+	}
+}
+
+func TestMeshSetPresharedKeys(t *testing.T) {
+	device := &fakeDevice{}
+	var routes []net.IPNet
+	mesh := newTestMesh(t, device, &routes)
+	node, _ := meshNode(t, "node-1", "192.168.0.10")
+	peer, _, err := PeerFromNode(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mesh.Reconcile([]MeshPeer{peer}); err != nil {
+		t.Fatal(err)
+	}
+	if device.peers[peer.PublicKey].PresharedKey != (wgtypes.Key{}) {
+		t.Error("expected no preshared key")
+	}
+
+	// The peers that have been reconciled get the new key
+	psk, _ := wgtypes.GenerateKey()
+	if err := mesh.SetPresharedKeys(PresharedKeys{Nodes: map[string]wgtypes.Key{"node-1": psk}}); err != nil {
+		t.Fatal(err)
+	}
+	if device.peers[peer.PublicKey].PresharedKey != psk {
+		t.Error("expected the preshared key with node-1")
+	}
+}
@@ -0,0 +1,337 @@
+package wireguard
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.zx2c4.com/wireguard/wgctrl"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+const (
+	// Device is the wireguard interface that the tunnel is configured on
+	Device = "wg0"
+
+	// DefaultPort is the port of the tunnel, and of the peer if its endpoint doesn't have one
+	DefaultPort = 51820
+
+	// RotationOverlap is the longest that the previous key of a peer is kept when the key is rotated, the new key is
+	// used as soon as the peer has completed a handshake with it
+	RotationOverlap = 2 * time.Minute
+
+	// rotationPollInterval is how often the handshake of a new peer key is checked during a rotation
+	rotationPollInterval = 2 * time.Second
+)
+
+// Options are the settings of a wireguard interface, they have to fit around other tunnels on the node (e.g. the
+// wireguard encryption of the CNI)
+type Options struct {
+	// ListenPort is the UDP port of the interface, the default port of the interface is used if it is 0
+	ListenPort int
+	// MTU of the interface, it is left alone if it is 0
+	MTU int
+	// FirewallMark is set on the encrypted packets, they aren't marked if it is 0
+	FirewallMark int
+}
+
+// port returns the listen port, or the default port of the interface
+func (o Options) port(defaultPort int) *int {
+	if o.ListenPort != 0 {
+		return &o.ListenPort
+	}
+	return &defaultPort
+}
+
+// SetMTU sets the MTU of the interface, if one is configured
+func (o Options) SetMTU(device string) error {
+	if o.MTU == 0 {
+		return nil
+	}
+	link, err := netlink.LinkByName(device)
+	if err != nil {
+		return err
+	}
+	if link.Attrs().MTU == o.MTU {
+		return nil
+	}
+	if err := netlink.LinkSetMTU(link, o.MTU); err != nil {
+		return fmt.Errorf("unable to set the MTU of %s to %d: %v", device, o.MTU, err)
+	}
+	return nil
+}
+
+// Keys are the keys and the peer of the tunnel, as they're stored in the secret
+type Keys struct {
+	PrivateKey    string
+	PeerPublicKey string
+	PeerEndpoint  string
+	// PresharedKey is optional, it adds a symmetric key to the handshake as a hedge against quantum computers
+	PresharedKey string
+}
+
+// deviceClient configures wireguard devices, this is a wgctrl client outside of the tests
+type deviceClient interface {
+	Device(name string) (*wgtypes.Device, error)
+	ConfigureDevice(name string, cfg wgtypes.Config) error
+}
+
+// Tunnel keeps the wireguard interface in line with the keys, when the key of the peer is rotated the previous key
+// is kept until the peer has switched to the new one so that the tunnel isn't interrupted
+type Tunnel struct {
+	mutex   sync.Mutex
+	client  func() (deviceClient, func(), error)
+	options Options
+
+	privateKey   wgtypes.Key
+	endpoint     string
+	presharedKey wgtypes.Key
+	// active is the key of the peer that traffic is routed to
+	active *wgtypes.Key
+	// next is the new key of the peer during a rotation, it has no allowed IPs until the rotation is complete
+	next *wgtypes.Key
+	// rotation is incremented for every rotation, so that a superseded rotation doesn't complete
+	rotation int
+}
+
+// NewTunnel creates a tunnel on the wireguard interface
+func NewTunnel(options Options) *Tunnel {
+	return &Tunnel{
+		client: func() (deviceClient, func(), error) {
+			c, err := wgctrl.New()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to open client: %v", err)
+			}
+			return c, func() { c.Close() }, nil
+		},
+		options: options,
+	}
+}
+
+// GenerateKeys creates a new private key and returns it along with its public key
+func GenerateKeys() (string, string, error) {
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return "", "", err
+	}
+	return key.String(), key.PublicKey().String(), nil
+}
+
+// PublicKey returns the public key of a private key
+func PublicKey(privateKey string) (string, error) {
+	key, err := wgtypes.ParseKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	return key.PublicKey().String(), nil
+}
+
+// peerEndpoint returns the address of the peer, the default port is used if the endpoint doesn't have one
+func peerEndpoint(endpoint string) *net.UDPAddr {
+	if host, port, err := net.SplitHostPort(endpoint); err == nil {
+		if p, err := strconv.Atoi(port); err == nil {
+			return &net.UDPAddr{IP: net.ParseIP(host), Port: p}
+		}
+	}
+	return &net.UDPAddr{IP: net.ParseIP(endpoint), Port: DefaultPort}
+}
+
+// peerConfig returns the configuration of the peer, traffic is only routed to it with the allowed IPs. The zero
+// preshared key removes the preshared key of the peer.
+func peerConfig(key wgtypes.Key, endpoint string, psk wgtypes.Key, allowedIPs bool) wgtypes.PeerConfig {
+	ka := 20 * time.Second
+	peer := wgtypes.PeerConfig{
+		PublicKey:                   key,
+		Endpoint:                    peerEndpoint(endpoint),
+		PresharedKey:                &psk,
+		PersistentKeepaliveInterval: &ka,
+	}
+	if allowedIPs {
+		peer.ReplaceAllowedIPs = true
+		peer.AllowedIPs = []net.IPNet{{
+			IP:   net.ParseIP("10.0.0.0"),
+			Mask: net.ParseIP("0.0.0.0").DefaultMask(),
+		}}
+	}
+	return peer
+}
+
+// configure applies the configuration to the interface
+func (t *Tunnel) configure(cfg wgtypes.Config) error {
+	client, done, err := t.client()
+	if err != nil {
+		return err
+	}
+	defer done()
+	if err := client.ConfigureDevice(Device, cfg); err != nil {
+		return fmt.Errorf("unable to configure %s: %v", Device, err)
+	}
+	return nil
+}
+
+// Apply will configure the interface with the keys. A new private key replaces the whole configuration, as every
+// session has to be established again, whereas a new peer key is added next to the current one until the peer uses it.
+func (t *Tunnel) Apply(keys Keys) error {
+	pri, err := wgtypes.ParseKey(keys.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to parse private key: %v", err)
+	}
+	pub, err := wgtypes.ParseKey(keys.PeerPublicKey) // Should be generated by the remote peer
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %v", err)
+	}
+	var psk wgtypes.Key
+	if keys.PresharedKey != "" {
+		if psk, err = wgtypes.ParseKey(keys.PresharedKey); err != nil {
+			return fmt.Errorf("failed to parse preshared key: %v", err)
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	switch {
+	case t.active == nil || pri != t.privateKey:
+		// Nothing can be kept with a new private key
+		cfg := wgtypes.Config{
+			PrivateKey:   &pri,
+			ListenPort:   t.options.port(DefaultPort),
+			ReplacePeers: true,
+			Peers:        []wgtypes.PeerConfig{peerConfig(pub, keys.PeerEndpoint, psk, true)},
+		}
+		if t.options.FirewallMark != 0 {
+			cfg.FirewallMark = &t.options.FirewallMark
+		}
+		if err := t.configure(cfg); err != nil {
+			return err
+		}
+		if t.active != nil {
+			log.Infof("(wireguard) private key has been rotated, the public key is now [%s]", pri.PublicKey())
+		}
+		t.privateKey, t.active, t.next = pri, &pub, nil
+		t.rotation++
+
+	case pub == *t.active:
+		// A rotation that is in progress has been reverted
+		peers := []wgtypes.PeerConfig{peerConfig(pub, keys.PeerEndpoint, psk, true)}
+		if t.next != nil {
+			peers = append(peers, wgtypes.PeerConfig{PublicKey: *t.next, Remove: true})
+			log.Infof("(wireguard) rotation to peer key [%s] has been abandoned", t.next)
+		}
+		if err := t.configure(wgtypes.Config{Peers: peers}); err != nil {
+			return err
+		}
+		t.next = nil
+		t.rotation++
+
+	case t.next == nil || pub != *t.next:
+		// The peer key has been rotated, both keys are configured until the peer has switched
+		peers := []wgtypes.PeerConfig{
+			peerConfig(*t.active, keys.PeerEndpoint, psk, true),
+			peerConfig(pub, keys.PeerEndpoint, psk, false),
+		}
+		if t.next != nil {
+			peers = append(peers, wgtypes.PeerConfig{PublicKey: *t.next, Remove: true})
+		}
+		if err := t.configure(wgtypes.Config{Peers: peers}); err != nil {
+			return err
+		}
+		log.Infof("(wireguard) peer key is being rotated from [%s] to [%s]", t.active, pub)
+		t.next = &pub
+		t.rotation++
+		go t.completeRotation(t.rotation, time.Now())
+
+	default:
+		// Only the endpoint or the preshared key can have changed
+		if err := t.configure(wgtypes.Config{Peers: []wgtypes.PeerConfig{
+			peerConfig(*t.active, keys.PeerEndpoint, psk, true),
+			peerConfig(*t.next, keys.PeerEndpoint, psk, false),
+		}}); err != nil {
+			return err
+		}
+	}
+	t.endpoint, t.presharedKey = keys.PeerEndpoint, psk
+	return nil
+}
+
+// completeRotation waits until the new key of the peer has completed a handshake (or the overlap has passed) and then
+// routes traffic to it and removes the previous key
+func (t *Tunnel) completeRotation(rotation int, started time.Time) {
+	ticker := time.NewTicker(rotationPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if done := t.checkRotation(rotation, started); done {
+			return
+		}
+	}
+}
+
+// checkRotation completes the rotation if the peer has switched to the new key, true is returned when the rotation is
+// no longer in progress
+func (t *Tunnel) checkRotation(rotation int, started time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if rotation != t.rotation || t.next == nil {
+		return true
+	}
+
+	switched := false
+	if client, done, err := t.client(); err == nil {
+		device, err := client.Device(Device)
+		done()
+		if err == nil {
+			for _, peer := range device.Peers {
+				if peer.PublicKey == *t.next && peer.LastHandshakeTime.After(started) {
+					switched = true
+				}
+			}
+		}
+	}
+	if !switched && time.Since(started) < RotationOverlap {
+		return false
+	}
+	if !switched {
+		log.Warnf("(wireguard) peer hasn't used key [%s] after %s, switching to it anyway", t.next, RotationOverlap)
+	}
+
+	if err := t.configure(wgtypes.Config{Peers: []wgtypes.PeerConfig{
+		peerConfig(*t.next, t.endpoint, t.presharedKey, true),
+		{PublicKey: *t.active, Remove: true},
+	}}); err != nil {
+		log.Errorf("(wireguard) unable to complete the rotation of the peer key: %v", err)
+		return false
+	}
+	log.Infof("(wireguard) peer key rotation to [%s] is complete", t.next)
+	t.active, t.next = t.next, nil
+	return true
+}
+
+// LastHandshake returns the time of the last handshake with the peer that traffic is routed to, it is zero if there
+// hasn't been one
+func (t *Tunnel) LastHandshake() (time.Time, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.active == nil {
+		return time.Time{}, fmt.Errorf("the tunnel hasn't been configured")
+	}
+
+	client, done, err := t.client()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer done()
+	device, err := client.Device(Device)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, peer := range device.Peers {
+		if peer.PublicKey == *t.active {
+			return peer.LastHandshakeTime, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("peer [%s] isn't configured on %s", t.active, Device)
+}
@@ -0,0 +1,221 @@
+package wireguard
+
+import (
+	"testing"
+	"time"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// fakeDevice applies the configuration to the peers of a device in memory
+type fakeDevice struct {
+	privateKey   wgtypes.Key
+	listenPort   int
+	firewallMark int
+	peers        map[wgtypes.Key]*wgtypes.Peer
+}
+
+func (f *fakeDevice) Device(string) (*wgtypes.Device, error) {
+	d := &wgtypes.Device{Name: Device, PrivateKey: f.privateKey, ListenPort: f.listenPort, FirewallMark: f.firewallMark}
+	for _, peer := range f.peers {
+		d.Peers = append(d.Peers, *peer)
+	}
+	return d, nil
+}
+
+func (f *fakeDevice) ConfigureDevice(_ string, cfg wgtypes.Config) error {
+	if cfg.PrivateKey != nil {
+		f.privateKey = *cfg.PrivateKey
+	}
+	if cfg.ListenPort != nil {
+		f.listenPort = *cfg.ListenPort
+	}
+	if cfg.FirewallMark != nil {
+		f.firewallMark = *cfg.FirewallMark
+	}
+	if cfg.ReplacePeers || f.peers == nil {
+		f.peers = map[wgtypes.Key]*wgtypes.Peer{}
+	}
+	for _, pc := range cfg.Peers {
+		if pc.Remove {
+			delete(f.peers, pc.PublicKey)
+			continue
+		}
+		peer, exists := f.peers[pc.PublicKey]
+		if !exists {
+			peer = &wgtypes.Peer{PublicKey: pc.PublicKey}
+			f.peers[pc.PublicKey] = peer
+		}
+		if pc.Endpoint != nil {
+			peer.Endpoint = pc.Endpoint
+		}
+		if pc.PresharedKey != nil {
+			peer.PresharedKey = *pc.PresharedKey
+		}
+		if pc.ReplaceAllowedIPs {
+			peer.AllowedIPs = pc.AllowedIPs
+		}
+	}
+	return nil
+}
+
+func (f *fakeDevice) allowedIPs(key string) int {
+	k, _ := wgtypes.ParseKey(key)
+	peer, exists := f.peers[k]
+	if !exists {
+		return -1
+	}
+	return len(peer.AllowedIPs)
+}
+
+func newKey(t *testing.T) (string, string) {
+	t.Helper()
+	private, public, err := GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private, public
+}
+
+func TestTunnelRotation(t *testing.T) {
+	device := &fakeDevice{}
+	tunnel := &Tunnel{client: func() (deviceClient, func(), error) {
+		return device, func() {}, nil
+	}}
+
+	private, _ := newKey(t)
+	_, oldPeer := newKey(t)
+	if err := tunnel.Apply(Keys{PrivateKey: private, PeerPublicKey: oldPeer, PeerEndpoint: "192.168.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if device.allowedIPs(oldPeer) != 1 {
+		t.Fatalf("peer should have the allowed IPs, got %d", device.allowedIPs(oldPeer))
+	}
+
+	// Both keys are configured while the peer switches, the traffic stays with the old key
+	_, newPeer := newKey(t)
+	if err := tunnel.Apply(Keys{PrivateKey: private, PeerPublicKey: newPeer, PeerEndpoint: "192.168.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if device.allowedIPs(oldPeer) != 1 || device.allowedIPs(newPeer) != 0 {
+		t.Fatalf("expected both keys during the rotation, got %d and %d allowed IPs", device.allowedIPs(oldPeer), device.allowedIPs(newPeer))
+	}
+
+	started := time.Now()
+	if done := tunnel.checkRotation(tunnel.rotation, started); done {
+		t.Fatal("rotation shouldn't complete before the peer has used the new key")
+	}
+
+	// The peer completes a handshake with the new key
+	k, _ := wgtypes.ParseKey(newPeer)
+	device.peers[k].LastHandshakeTime = started.Add(time.Second)
+	if done := tunnel.checkRotation(tunnel.rotation, started); !done {
+		t.Fatal("rotation should complete after a handshake with the new key")
+	}
+	if device.allowedIPs(oldPeer) != -1 || device.allowedIPs(newPeer) != 1 {
+		t.Fatalf("expected only the new key after the rotation, got %d and %d allowed IPs", device.allowedIPs(oldPeer), device.allowedIPs(newPeer))
+	}
+
+	// A new private key replaces all peers
+	newPrivate, newPublic := newKey(t)
+	if err := tunnel.Apply(Keys{PrivateKey: newPrivate, PeerPublicKey: newPeer, PeerEndpoint: "192.168.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if device.privateKey.PublicKey().String() != newPublic || device.allowedIPs(newPeer) != 1 {
+		t.Fatal("expected the new private key to be configured")
+	}
+}
+
+func TestTunnelRotationReverted(t *testing.T) {
+	device := &fakeDevice{}
+	tunnel := &Tunnel{client: func() (deviceClient, func(), error) {
+		return device, func() {}, nil
+	}}
+
+	private, _ := newKey(t)
+	_, oldPeer := newKey(t)
+	_, newPeer := newKey(t)
+	for _, peer := range []string{oldPeer, newPeer, oldPeer} {
+		if err := tunnel.Apply(Keys{PrivateKey: private, PeerPublicKey: peer, PeerEndpoint: "192.168.0.1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if device.allowedIPs(oldPeer) != 1 || device.allowedIPs(newPeer) != -1 {
+		t.Fatalf("expected only the old key after reverting, got %d and %d allowed IPs", device.allowedIPs(oldPeer), device.allowedIPs(newPeer))
+	}
+	if tunnel.next != nil {
+		t.Error("rotation should no longer be in progress")
+	}
+}
+
+func TestTunnelOptions(t *testing.T) {
+	private, _ := newKey(t)
+	_, peer := newKey(t)
+	k, _ := wgtypes.ParseKey(peer)
+	tests := []struct {
+		name         string
+		options      Options
+		endpoint     string
+		wantPort     int
+		wantMark     int
+		wantEndpoint string
+	}{
+		{name: "defaults", endpoint: "192.168.0.1", wantPort: DefaultPort, wantEndpoint: "192.168.0.1:51820"},
+		{name: "custom", options: Options{ListenPort: 51830, FirewallMark: 0x4000}, endpoint: "192.168.0.1:51840", wantPort: 51830, wantMark: 0x4000, wantEndpoint: "192.168.0.1:51840"},
+		{name: "ipv6 peer", endpoint: "[fd00::1]:51850", wantPort: DefaultPort, wantEndpoint: "[fd00::1]:51850"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := &fakeDevice{}
+			tunnel := NewTunnel(tt.options)
+			tunnel.client = func() (deviceClient, func(), error) {
+				return device, func() {}, nil
+			}
+			if err := tunnel.Apply(Keys{PrivateKey: private, PeerPublicKey: peer, PeerEndpoint: tt.endpoint}); err != nil {
+				t.Fatal(err)
+			}
+			if device.listenPort != tt.wantPort || device.firewallMark != tt.wantMark {
+				t.Errorf("port, mark = %d, %d, want %d, %d", device.listenPort, device.firewallMark, tt.wantPort, tt.wantMark)
+			}
+			if got := device.peers[k].Endpoint.String(); got != tt.wantEndpoint {
+				t.Errorf("endpoint = %s, want %s", got, tt.wantEndpoint)
+			}
+		})
+	}
+}
+
+func TestTunnelPresharedKey(t *testing.T) {
+	device := &fakeDevice{}
+	tunnel := &Tunnel{client: func() (deviceClient, func(), error) {
+		return device, func() {}, nil
+	}}
+	private, _ := newKey(t)
+	_, peer := newKey(t)
+	k, _ := wgtypes.ParseKey(peer)
+	psk, err := wgtypes.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := Keys{PrivateKey: private, PeerPublicKey: peer, PeerEndpoint: "192.168.0.1", PresharedKey: psk.String()}
+	if err := tunnel.Apply(keys); err != nil {
+		t.Fatal(err)
+	}
+	if device.peers[k].PresharedKey != psk {
+		t.Error("expected the peer to have the preshared key")
+	}
+
+	// Removing the preshared key from the secret removes it from the peer
+	keys.PresharedKey = ""
+	if err := tunnel.Apply(keys); err != nil {
+		t.Fatal(err)
+	}
+	if device.peers[k].PresharedKey != (wgtypes.Key{}) {
+		t.Error("expected the preshared key to be removed")
+	}
+
+	keys.PresharedKey = "invalid"
+	if err := tunnel.Apply(keys); err == nil {
+		t.Error("Apply() expected an error with an invalid preshared key")
+	}
+}
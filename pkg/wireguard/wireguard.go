@@ -2,9 +2,7 @@ package wireguard
 
 import (
 	"fmt"
-	"net"
 	"os"
-	"time"
 
 	"golang.zx2c4.com/wireguard/wgctrl"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
@@ -30,31 +28,15 @@ func ConfigureInterface(priKey, peerPublicKey, endpoint string) error {
 
 	//log.Printf("Public Key [%s]", pri.PublicKey())
 
-	port := 51820
-	ka := 20 * time.Second
-
+	port := DefaultPort
 	conf := wgtypes.Config{
 		PrivateKey:   &pri,
 		ListenPort:   &port,
 		ReplacePeers: true,
-		Peers: []wgtypes.PeerConfig{{
-			PublicKey:  pub,
-			Remove:     false,
-			UpdateOnly: false,
-			Endpoint: &net.UDPAddr{
-				IP:   net.ParseIP(endpoint),
-				Port: 51820,
-			},
-			PersistentKeepaliveInterval: &ka,
-			ReplaceAllowedIPs:           true,
-			AllowedIPs: []net.IPNet{{
-				IP:   net.ParseIP("10.0.0.0"),
-				Mask: net.ParseIP("0.0.0.0").DefaultMask(),
-			}},
-		}},
+		Peers:        []wgtypes.PeerConfig{peerConfig(pub, endpoint, wgtypes.Key{}, true)},
 	}
 
-	if err := client.ConfigureDevice("wg0", conf); err != nil {
+	if err := client.ConfigureDevice(Device, conf); err != nil {
 		if os.IsNotExist(err) {
 			return fmt.Errorf("wg0 doesn't exist [%s]", err)
 		}
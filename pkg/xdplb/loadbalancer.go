@@ -0,0 +1,301 @@
+package xdplb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/rlimit"
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kube-vip/kube-vip/pkg/healthcheck"
+)
+
+/*
+XDP Load Balancer
+
+The connections to the port of the VIP are balanced across the backends by an XDP program on the interface of the VIP,
+before the packets reach the network stack. The backend of every connection is tracked in a map, new connections are
+hashed onto a ring of the healthy backends.
+
+The packets are forwarded by rewriting their MAC addresses and sending them back out of the interface, the destination
+is still the VIP so (as with the IPVS directroute forwarding method) the backends have to accept the VIP locally, for
+instance on lo with arp_ignore set, and they reply to the clients directly. The backends have to be on the network of
+the interface or reachable through a gateway on it, and only IPv4 TCP is balanced.
+*/
+
+// LoadBalancer balances the connections to a port of the VIP with an XDP program
+type LoadBalancer struct {
+	vip   netip.Addr
+	port  int
+	iface *net.Interface
+
+	maps    *maps
+	program *ebpf.Program
+	xdp     link.Link
+	checker *healthcheck.Checker
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mutex    sync.Mutex
+	backends map[netip.Addr]bool
+	// forwarding is the backends that have been written to the maps, they are the healthy backends that can be reached
+	forwarding map[netip.Addr]backendValue
+}
+
+// New loads the program onto the interface, the backends are probed on the port every interval seconds
+func New(iface, address string, port, interval int) (*LoadBalancer, error) {
+	vip, err := netip.ParseAddr(address)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the VIP [%s]: %w", address, err)
+	}
+	if !vip.Is4() {
+		return nil, fmt.Errorf("the XDP load balancer only balances IPv4, the VIP is [%s]", address)
+	}
+	if port < 1 || port > 65535 {
+		return nil, fmt.Errorf("the port [%d] of the XDP load balancer is invalid", port)
+	}
+	i, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the interface [%s]: %w", iface, err)
+	}
+	if interval <= 0 {
+		interval = 5
+	}
+	probe := healthcheck.Probe{Type: healthcheck.TCP, Port: port, Interval: time.Duration(interval) * time.Second}
+	if err = probe.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err = rlimit.RemoveMemlock(); err != nil {
+		return nil, fmt.Errorf("unable to remove the memlock limit: %w", err)
+	}
+	lb := &LoadBalancer{
+		vip:        vip,
+		port:       port,
+		iface:      i,
+		checker:    healthcheck.NewChecker(probe),
+		done:       make(chan struct{}),
+		backends:   map[netip.Addr]bool{},
+		forwarding: map[netip.Addr]backendValue{},
+	}
+	if lb.maps, err = newMaps(); err != nil {
+		return nil, err
+	}
+	if lb.program, err = loadProgram(vip, uint16(port), lb.maps); err != nil {
+		lb.maps.Close()
+		return nil, err
+	}
+	if lb.xdp, err = link.AttachXDP(link.XDPOptions{Program: lb.program, Interface: i.Index}); err != nil {
+		lb.program.Close()
+		lb.maps.Close()
+		return nil, fmt.Errorf("unable to attach the XDP program to [%s]: %w", iface, err)
+	}
+	log.Infof("XDP LoadBalancer enabled for [%s:%d] on [%s]", vip, port, iface)
+
+	lb.ctx, lb.cancel = context.WithCancel(context.Background())
+	go lb.run(time.Duration(interval) * time.Second)
+	register(lb)
+	return lb, nil
+}
+
+// loadProgram builds the program for the VIP and loads it into the kernel
+func loadProgram(vip netip.Addr, port uint16, m *maps) (*ebpf.Program, error) {
+	insns, err := program(vip, port, m.fds())
+	if err != nil {
+		return nil, err
+	}
+	prog, err := ebpf.NewProgram(&ebpf.ProgramSpec{
+		Name:         "kube_vip_lb",
+		Type:         ebpf.XDP,
+		Instructions: insns,
+		License:      "GPL",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the XDP program: %w", err)
+	}
+	return prog, nil
+}
+
+// AddBackend adds a backend, it is balanced to once it is healthy
+func (lb *LoadBalancer) AddBackend(address string, port int) error {
+	backend, err := lb.backend(address, port)
+	if err != nil {
+		return err
+	}
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+	if lb.backends[backend] {
+		return nil
+	}
+	lb.backends[backend] = true
+	lb.probe()
+	log.Infof("Added backend for [%s:%d] on [%s:%d]", lb.vip, lb.port, address, port)
+	return nil
+}
+
+// RemoveBackend removes a backend, its connections are balanced to the other backends
+func (lb *LoadBalancer) RemoveBackend(address string, port int) error {
+	backend, err := lb.backend(address, port)
+	if err != nil {
+		return err
+	}
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+	if !lb.backends[backend] {
+		return nil
+	}
+	delete(lb.backends, backend)
+	lb.probe()
+	return lb.sync()
+}
+
+// backend parses the address of a backend, the packets aren't translated so it has to be on the port of the VIP
+func (lb *LoadBalancer) backend(address string, port int) (netip.Addr, error) {
+	if port != lb.port {
+		return netip.Addr{}, fmt.Errorf("the backend [%s:%d] has to be on the port [%d] of the XDP load balancer", address, port, lb.port)
+	}
+	backend, err := netip.ParseAddr(address)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("unable to parse the backend [%s]: %w", address, err)
+	}
+	if !backend.Is4() {
+		return netip.Addr{}, fmt.Errorf("the XDP load balancer only balances IPv4, the backend is [%s]", address)
+	}
+	return backend, nil
+}
+
+// probe sets the backends that are health checked, the mutex has to be held
+func (lb *LoadBalancer) probe() {
+	addresses := make([]string, 0, len(lb.backends))
+	for backend := range lb.backends {
+		addresses = append(addresses, backend.String())
+	}
+	lb.checker.Set(lb.ctx, addresses)
+}
+
+// run updates the maps whenever the health of a backend changes, and every interval in case the neighbours have changed
+func (lb *LoadBalancer) run(interval time.Duration) {
+	defer close(lb.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lb.ctx.Done():
+			return
+		case <-lb.checker.Changed():
+		case <-ticker.C:
+		}
+		lb.mutex.Lock()
+		if err := lb.sync(); err != nil {
+			log.Errorf("(xdp) unable to update the backends of [%s:%d]: %v", lb.vip, lb.port, err)
+		}
+		lb.mutex.Unlock()
+	}
+}
+
+// sync writes the healthy backends that can be reached to the maps, the mutex has to be held
+func (lb *LoadBalancer) sync() error {
+	local, err := localAddresses()
+	if err != nil {
+		return err
+	}
+	forwarding := map[netip.Addr]backendValue{}
+	for backend := range lb.backends {
+		if !lb.checker.Healthy(backend.String()) {
+			continue
+		}
+		if local[backend] {
+			forwarding[backend] = newBackendValue(nil, nil, true)
+			continue
+		}
+		mac, err := lb.neighbour(backend)
+		if err != nil {
+			log.Warnf("(xdp) backend [%s] can't be balanced to: %v", backend, err)
+			continue
+		}
+		forwarding[backend] = newBackendValue(lb.iface.HardwareAddr, mac, false)
+	}
+	if err = lb.maps.setBackends(forwarding); err != nil {
+		return err
+	}
+	for backend := range forwarding {
+		if _, exists := lb.forwarding[backend]; !exists {
+			log.Infof("(xdp) balancing [%s:%d] to [%s]", lb.vip, lb.port, backend)
+		}
+	}
+	for backend := range lb.forwarding {
+		if _, exists := forwarding[backend]; !exists {
+			log.Infof("(xdp) no longer balancing [%s:%d] to [%s]", lb.vip, lb.port, backend)
+		}
+	}
+	lb.forwarding = forwarding
+	return nil
+}
+
+// neighbour returns the MAC address that the packets to the backend are sent to, which is the backend itself or the
+// gateway to it. The health checks of the backend keep the neighbour resolved.
+func (lb *LoadBalancer) neighbour(backend netip.Addr) (net.HardwareAddr, error) {
+	routes, err := netlink.RouteGet(backend.AsSlice())
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the route: %w", err)
+	}
+	if len(routes) == 0 || routes[0].LinkIndex != lb.iface.Index {
+		return nil, fmt.Errorf("it isn't reached through [%s]", lb.iface.Name)
+	}
+	next := backend.AsSlice()
+	if routes[0].Gw != nil {
+		next = routes[0].Gw
+	}
+
+	neighbours, err := netlink.NeighList(lb.iface.Index, netlink.FAMILY_V4)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the neighbours: %w", err)
+	}
+	for _, n := range neighbours {
+		if n.IP.Equal(next) && len(n.HardwareAddr) == 6 && n.State&(netlink.NUD_INCOMPLETE|netlink.NUD_FAILED) == 0 {
+			return n.HardwareAddr, nil
+		}
+	}
+	return nil, fmt.Errorf("the MAC address of [%s] isn't known", next)
+}
+
+// localAddresses returns the IPv4 addresses of this node
+func localAddresses() (map[netip.Addr]bool, error) {
+	addresses, err := netlink.AddrList(nil, netlink.FAMILY_V4)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the addresses of the node: %w", err)
+	}
+	local := map[netip.Addr]bool{}
+	for _, address := range addresses {
+		if a, ok := netip.AddrFromSlice(address.IP.To4()); ok {
+			local[a] = true
+		}
+	}
+	return local, nil
+}
+
+// Close stops the health checks and removes the program from the interface
+func (lb *LoadBalancer) Close() error {
+	unregister(lb)
+	lb.cancel()
+	<-lb.done
+	lb.checker.Stop()
+
+	err := lb.xdp.Close()
+	lb.program.Close()
+	lb.maps.Close()
+	if err != nil {
+		return fmt.Errorf("unable to detach the XDP program from [%s]: %w", lb.iface.Name, err)
+	}
+	log.Infof("XDP LoadBalancer stopped for [%s:%d]", lb.vip, lb.port)
+	return nil
+}
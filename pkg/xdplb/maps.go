@@ -0,0 +1,194 @@
+package xdplb
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+
+	"github.com/cilium/ebpf"
+)
+
+const (
+	// ringBits is the size of the ring as a power of two, the top bits of the hash of a connection are its slot
+	ringBits = 8
+	ringSize = 1 << ringBits
+	// maxConnections is how many connections are tracked, the least recently used ones are forgotten first
+	maxConnections = 65536
+	// maxBackends is how many backends the VIP can be balanced across
+	maxBackends = 64
+)
+
+// flagLocal marks a backend on this node, its packets are passed to the kernel rather than forwarded
+const flagLocal = 1
+
+// connectionKey is the address and the port of a client, the port is padded to keep the key aligned
+type connectionKey struct {
+	Address [4]byte
+	Port    [2]byte
+	_       [2]byte
+}
+
+// backendValue is what the program needs to forward a packet to a backend
+type backendValue struct {
+	Destination [6]byte
+	Source      [6]byte
+	Flags       uint32
+}
+
+// maps are the maps shared between the program and the load balancer
+type maps struct {
+	// connections is the backend of every connection that has been seen
+	connections *ebpf.Map
+	// ring is the slots that the new connections are hashed to, every slot holds the address of a healthy backend
+	ring *ebpf.Map
+	// backends is the forwarding of every healthy backend by its address
+	backends *ebpf.Map
+	// stats is the count of the packets that have been balanced to every backend, the unspecified address counts
+	// the packets that couldn't be balanced
+	stats *ebpf.Map
+}
+
+func newMaps() (*maps, error) {
+	m := &maps{}
+	var err error
+	if m.connections, err = ebpf.NewMap(&ebpf.MapSpec{Name: "kv_conns", Type: ebpf.LRUHash, KeySize: 8, ValueSize: 4, MaxEntries: maxConnections}); err != nil {
+		return nil, fmt.Errorf("unable to create the connections map: %w", err)
+	}
+	if m.ring, err = ebpf.NewMap(&ebpf.MapSpec{Name: "kv_ring", Type: ebpf.Array, KeySize: 4, ValueSize: 4, MaxEntries: ringSize}); err != nil {
+		m.Close()
+		return nil, fmt.Errorf("unable to create the ring map: %w", err)
+	}
+	if m.backends, err = ebpf.NewMap(&ebpf.MapSpec{Name: "kv_backends", Type: ebpf.Hash, KeySize: 4, ValueSize: 16, MaxEntries: maxBackends}); err != nil {
+		m.Close()
+		return nil, fmt.Errorf("unable to create the backends map: %w", err)
+	}
+	// The stats of the backends outlive them, so that their counters don't go backwards when they come back
+	if m.stats, err = ebpf.NewMap(&ebpf.MapSpec{Name: "kv_stats", Type: ebpf.Hash, KeySize: 4, ValueSize: 8, MaxEntries: 4 * maxBackends}); err != nil {
+		m.Close()
+		return nil, fmt.Errorf("unable to create the stats map: %w", err)
+	}
+	if err = m.count(netip.IPv4Unspecified()); err != nil {
+		m.Close()
+		return nil, fmt.Errorf("unable to add the counter of the unbalanced packets: %w", err)
+	}
+	return m, nil
+}
+
+// fds returns the file descriptors of the maps for the program
+func (m *maps) fds() programMaps {
+	return programMaps{connections: m.connections.FD(), ring: m.ring.FD(), backends: m.backends.FD(), stats: m.stats.FD()}
+}
+
+// Close closes every map that has been created
+func (m *maps) Close() {
+	for _, bpfMap := range []*ebpf.Map{m.connections, m.ring, m.backends, m.stats} {
+		if bpfMap != nil {
+			bpfMap.Close()
+		}
+	}
+}
+
+// count ensures that the backend has a counter for the program to add to
+func (m *maps) count(address netip.Addr) error {
+	err := m.stats.Update(address.As4(), uint64(0), ebpf.UpdateNoExist)
+	if err != nil && !errors.Is(err, ebpf.ErrKeyExist) {
+		return err
+	}
+	return nil
+}
+
+// packets returns the packets that have been balanced to every backend
+func (m *maps) packets() (map[netip.Addr]uint64, error) {
+	packets := map[netip.Addr]uint64{}
+	var key [4]byte
+	var value uint64
+	iterator := m.stats.Iterate()
+	for iterator.Next(&key, &value) {
+		packets[netip.AddrFrom4(key)] = value
+	}
+	return packets, iterator.Err()
+}
+
+// connectionCount returns how many connections are being tracked
+func (m *maps) connectionCount() (int, error) {
+	var key connectionKey
+	var value [4]byte
+	count := 0
+	iterator := m.connections.Iterate()
+	for iterator.Next(&key, &value) {
+		count++
+	}
+	return count, iterator.Err()
+}
+
+// setBackends replaces the backends with the forwarding of the healthy backends, and the ring with their slots
+func (m *maps) setBackends(forwarding map[netip.Addr]backendValue) error {
+	addresses := make([]netip.Addr, 0, len(forwarding))
+	for address, value := range forwarding {
+		if err := m.count(address); err != nil {
+			return fmt.Errorf("unable to add the counter of [%s]: %w", address, err)
+		}
+		if err := m.backends.Put(address.As4(), value); err != nil {
+			return fmt.Errorf("unable to add the backend [%s]: %w", address, err)
+		}
+		addresses = append(addresses, address)
+	}
+
+	// The ring is updated before the backends that have gone are removed, so a new connection is never hashed to one
+	for slot, address := range slots(addresses, ringSize) {
+		var value [4]byte
+		if address.IsValid() {
+			value = address.As4()
+		}
+		if err := m.ring.Put(uint32(slot), value); err != nil {
+			return fmt.Errorf("unable to update the slot [%d]: %w", slot, err)
+		}
+	}
+
+	var key [4]byte
+	var value backendValue
+	var gone [][4]byte
+	iterator := m.backends.Iterate()
+	for iterator.Next(&key, &value) {
+		if _, exists := forwarding[netip.AddrFrom4(key)]; !exists {
+			gone = append(gone, key)
+		}
+	}
+	if err := iterator.Err(); err != nil {
+		return err
+	}
+	for _, key := range gone {
+		if err := m.backends.Delete(key); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return fmt.Errorf("unable to remove the backend [%s]: %w", netip.AddrFrom4(key), err)
+		}
+	}
+	return nil
+}
+
+// newBackendValue returns the forwarding to a backend, from the MAC address of the interface to that of the backend
+func newBackendValue(source, destination net.HardwareAddr, local bool) backendValue {
+	var value backendValue
+	copy(value.Source[:], source)
+	copy(value.Destination[:], destination)
+	if local {
+		value.Flags = flagLocal
+	}
+	return value
+}
+
+// slots returns the backend of every slot of the ring, the backends are spread evenly in the order of their addresses
+// so that every node balancing the VIP agrees on the ring. The slots are empty if there are no backends.
+func slots(backends []netip.Addr, size int) []netip.Addr {
+	ring := make([]netip.Addr, size)
+	if len(backends) == 0 {
+		return ring
+	}
+	sorted := slices.Clone(backends)
+	slices.SortFunc(sorted, netip.Addr.Compare)
+	for slot := range ring {
+		ring[slot] = sorted[slot%len(sorted)]
+	}
+	return ring
+}
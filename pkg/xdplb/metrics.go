@@ -0,0 +1,96 @@
+package xdplb
+
+import (
+	"net/netip"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	backendPacketsDesc = prometheus.NewDesc("kube_vip_xdp_backend_packets_total",
+		"Packets to the VIP that the XDP program has balanced to the backend", []string{"vip", "backend"}, nil)
+	unbalancedPacketsDesc = prometheus.NewDesc("kube_vip_xdp_unbalanced_packets_total",
+		"Packets to the VIP that the XDP program passed to the kernel as there was no healthy backend", []string{"vip"}, nil)
+	connectionsDesc = prometheus.NewDesc("kube_vip_xdp_connections",
+		"Connections to the VIP that are tracked by the XDP program", []string{"vip"}, nil)
+	backendHealthyDesc = prometheus.NewDesc("kube_vip_xdp_backend_healthy",
+		"Whether the backend is healthy and reachable, and so is balanced to", []string{"vip", "backend"}, nil)
+)
+
+var (
+	runningMutex sync.Mutex
+	// running is the load balancers that are collected
+	running = map[*LoadBalancer]struct{}{}
+)
+
+func register(lb *LoadBalancer) {
+	runningMutex.Lock()
+	defer runningMutex.Unlock()
+	running[lb] = struct{}{}
+}
+
+func unregister(lb *LoadBalancer) {
+	runningMutex.Lock()
+	defer runningMutex.Unlock()
+	delete(running, lb)
+}
+
+// Collector exports the backends and the counters of the XDP load balancers that are running
+type Collector struct{}
+
+// NewCollector creates a collector of the XDP load balancers
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- backendPacketsDesc
+	ch <- unbalancedPacketsDesc
+	ch <- connectionsDesc
+	ch <- backendHealthyDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	runningMutex.Lock()
+	defer runningMutex.Unlock()
+	for lb := range running {
+		lb.collect(ch)
+	}
+}
+
+func (lb *LoadBalancer) collect(ch chan<- prometheus.Metric) {
+	vip := lb.vip.String()
+
+	lb.mutex.Lock()
+	for backend := range lb.backends {
+		healthy := 0.0
+		if _, exists := lb.forwarding[backend]; exists {
+			healthy = 1
+		}
+		ch <- prometheus.MustNewConstMetric(backendHealthyDesc, prometheus.GaugeValue, healthy, vip, backend.String())
+	}
+	lb.mutex.Unlock()
+
+	packets, err := lb.maps.packets()
+	if err != nil {
+		log.Debugf("(xdp) unable to collect the packets of [%s]: %v", vip, err)
+	}
+	for backend, count := range packets {
+		if backend == netip.IPv4Unspecified() {
+			ch <- prometheus.MustNewConstMetric(unbalancedPacketsDesc, prometheus.CounterValue, float64(count), vip)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(backendPacketsDesc, prometheus.CounterValue, float64(count), vip, backend.String())
+	}
+
+	connections, err := lb.maps.connectionCount()
+	if err != nil {
+		log.Debugf("(xdp) unable to collect the connections of [%s]: %v", vip, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(connectionsDesc, prometheus.GaugeValue, float64(connections), vip)
+}
@@ -0,0 +1,188 @@
+package xdplb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+
+	"github.com/cilium/ebpf/asm"
+)
+
+// The verdicts of an XDP program
+const (
+	xdpPass = 2
+	xdpTX   = 3
+)
+
+// The offsets of the headers of an IPv4 TCP packet without IP options
+const (
+	offsetEtherType = 12
+	offsetIP        = 14
+	offsetFragment  = offsetIP + 6
+	offsetProtocol  = offsetIP + 9
+	offsetSource    = offsetIP + 12
+	offsetDest      = offsetIP + 16
+	offsetTCP       = offsetIP + 20
+	// headersLength is what has to be in the packet for the ports to be read
+	headersLength = offsetTCP + 4
+)
+
+// The slots of the stack of the program, relative to the frame pointer
+const (
+	// stackConnection is the connectionKey of the packet
+	stackConnection = -8
+	// stackSlot is the slot of the ring that a new connection is hashed to
+	stackSlot = -12
+	// stackBackend is the address of the backend of the packet
+	stackBackend = -16
+)
+
+// ringHash is the golden ratio multiplier 0x9E3779B1 as a 32 bit immediate, it spreads the addresses and ports of the
+// clients across the ring
+const ringHash = -0x61C88647
+
+// programMaps are the file descriptors of the maps that the program uses
+type programMaps struct {
+	connections int
+	ring        int
+	backends    int
+	stats       int
+}
+
+// network16 returns a value in network order as the program loads it from the packet, so that it can be compared with
+// what was loaded
+func network16(value uint16) int32 {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], value)
+	return int32(binary.NativeEndian.Uint16(b[:]))
+}
+
+// program builds the XDP program that balances the TCP connections to the port of the VIP. The backend of a connection
+// is remembered, a new connection is hashed to a slot of the ring that holds one of the healthy backends. The packets
+// are sent back out of the interface to the MAC address of the backend (as with IPVS direct routing), the packets for
+// a local backend and all of the other packets are passed to the kernel.
+func program(vip netip.Addr, port uint16, maps programMaps) (asm.Instructions, error) {
+	if !vip.Is4() {
+		return nil, fmt.Errorf("the VIP [%s] isn't an IPv4 address", vip)
+	}
+	address := vip.As4()
+
+	insns := asm.Instructions{
+		// r6 = ctx, r7 = data, r8 = data_end
+		asm.Mov.Reg(asm.R6, asm.R1),
+		asm.LoadMem(asm.R7, asm.R6, 0, asm.Word),
+		asm.LoadMem(asm.R8, asm.R6, 4, asm.Word),
+		asm.Mov.Reg(asm.R2, asm.R7),
+		asm.Add.Imm(asm.R2, headersLength),
+		asm.JGT.Reg(asm.R2, asm.R8, "pass"),
+
+		// Only IPv4 TCP packets for the port of the VIP, without IP options and that aren't fragments
+		asm.LoadMem(asm.R2, asm.R7, offsetEtherType, asm.Half),
+		asm.JNE.Imm(asm.R2, network16(0x0800), "pass"),
+		asm.LoadMem(asm.R2, asm.R7, offsetIP, asm.Byte),
+		asm.JNE.Imm(asm.R2, 0x45, "pass"),
+		asm.LoadMem(asm.R2, asm.R7, offsetProtocol, asm.Byte),
+		asm.JNE.Imm(asm.R2, 6, "pass"),
+		asm.LoadMem(asm.R2, asm.R7, offsetFragment, asm.Half),
+		asm.JSet.Imm(asm.R2, network16(0x3fff), "pass"),
+		asm.LoadMem(asm.R2, asm.R7, offsetDest, asm.Word),
+		asm.LoadImm(asm.R3, int64(binary.NativeEndian.Uint32(address[:])), asm.DWord),
+		asm.JNE.Reg(asm.R2, asm.R3, "pass"),
+		asm.LoadMem(asm.R2, asm.R7, offsetTCP+2, asm.Half),
+		asm.JNE.Imm(asm.R2, network16(port), "pass"),
+
+		// The connection is the address and the port of the client
+		asm.LoadMem(asm.R2, asm.R7, offsetSource, asm.Word),
+		asm.StoreMem(asm.RFP, stackConnection, asm.R2, asm.Word),
+		asm.LoadMem(asm.R2, asm.R7, offsetTCP, asm.Half),
+		asm.StoreMem(asm.RFP, stackConnection+4, asm.R2, asm.Half),
+		asm.StoreImm(asm.RFP, stackConnection+6, 0, asm.Half),
+
+		// A known connection stays with its backend while it is healthy
+		asm.LoadMapPtr(asm.R1, maps.connections),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, stackConnection),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, "new"),
+		asm.LoadMem(asm.R2, asm.R0, 0, asm.Word),
+		asm.StoreMem(asm.RFP, stackBackend, asm.R2, asm.Word),
+		asm.LoadMapPtr(asm.R1, maps.backends),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, stackBackend),
+		asm.FnMapLookupElem.Call(),
+		asm.JNE.Imm(asm.R0, 0, "forward"),
+
+		// A new connection is hashed to a slot of the ring
+		asm.LoadMem(asm.R2, asm.RFP, stackConnection, asm.Word).WithSymbol("new"),
+		asm.LoadMem(asm.R3, asm.RFP, stackConnection+4, asm.Half),
+		asm.LSh.Imm(asm.R3, 16),
+		asm.Xor.Reg(asm.R2, asm.R3),
+		asm.Mul.Imm32(asm.R2, ringHash),
+		asm.RSh.Imm32(asm.R2, 32-ringBits),
+		asm.StoreMem(asm.RFP, stackSlot, asm.R2, asm.Word),
+		asm.LoadMapPtr(asm.R1, maps.ring),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, stackSlot),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, "unbalanced"),
+		asm.LoadMem(asm.R2, asm.R0, 0, asm.Word),
+		asm.JEq.Imm(asm.R2, 0, "unbalanced"),
+		asm.StoreMem(asm.RFP, stackBackend, asm.R2, asm.Word),
+		asm.LoadMapPtr(asm.R1, maps.backends),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, stackBackend),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, "unbalanced"),
+		asm.Mov.Reg(asm.R9, asm.R0),
+		asm.LoadMapPtr(asm.R1, maps.connections),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, stackConnection),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, stackBackend),
+		asm.Mov.Imm(asm.R4, 0),
+		asm.FnMapUpdateElem.Call(),
+		asm.Mov.Reg(asm.R0, asm.R9),
+
+		// r9 = the backend
+		asm.Mov.Reg(asm.R9, asm.R0).WithSymbol("forward"),
+	}
+	insns = append(insns, count(maps.stats, "rewrite")...)
+	insns = append(insns,
+		asm.LoadMem(asm.R2, asm.R9, 12, asm.Word).WithSymbol("rewrite"),
+		asm.JSet.Imm(asm.R2, flagLocal, "pass"),
+	)
+	// The destination and the source MAC addresses are replaced with those of the backend
+	for x := int16(0); x < 12; x++ {
+		insns = append(insns,
+			asm.LoadMem(asm.R2, asm.R9, x, asm.Byte),
+			asm.StoreMem(asm.R7, x, asm.R2, asm.Byte),
+		)
+	}
+	insns = append(insns,
+		asm.Mov.Imm(asm.R0, xdpTX),
+		asm.Return(),
+
+		// The packets without a backend are counted against the unspecified address
+		asm.StoreImm(asm.RFP, stackBackend, 0, asm.Word).WithSymbol("unbalanced"),
+	)
+	insns = append(insns, count(maps.stats, "pass")...)
+	insns = append(insns,
+		asm.Mov.Imm(asm.R0, xdpPass).WithSymbol("pass"),
+		asm.Return(),
+	)
+	return insns, nil
+}
+
+// count adds the packet to the counter of the backend on the stack, and then jumps to the label
+func count(stats int, label string) asm.Instructions {
+	return asm.Instructions{
+		asm.LoadMapPtr(asm.R1, stats),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, stackBackend),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, label),
+		asm.Mov.Imm(asm.R1, 1),
+		asm.StoreXAdd(asm.R0, asm.R1, asm.DWord),
+		asm.Ja.Label(label),
+	}
+}
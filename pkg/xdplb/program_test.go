@@ -0,0 +1,240 @@
+package xdplb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/rlimit"
+)
+
+var (
+	testVIP       = netip.MustParseAddr("192.168.0.100")
+	testInterface = net.HardwareAddr{0x02, 0, 0, 0, 0, 0x01}
+)
+
+// loadTestProgram loads the program for port 6443 of the VIP, the test is skipped if eBPF programs can't be loaded
+func loadTestProgram(t *testing.T) (*ebpf.Program, *maps) {
+	t.Helper()
+	if err := rlimit.RemoveMemlock(); err != nil {
+		t.Skipf("eBPF isn't available: %v", err)
+	}
+	m, err := newMaps()
+	if err != nil {
+		t.Skipf("eBPF maps can't be created: %v", err)
+	}
+	t.Cleanup(m.Close)
+	prog, err := loadProgram(testVIP, 6443, m)
+	if err != nil {
+		t.Skipf("eBPF programs can't be loaded: %v", err)
+	}
+	t.Cleanup(func() { prog.Close() })
+	return prog, m
+}
+
+// tcpPacket returns an ethernet frame of an IPv4 TCP packet from the client to the destination
+func tcpPacket(client netip.AddrPort, destination netip.AddrPort) []byte {
+	packet := make([]byte, 14+20+20)
+	copy(packet[0:6], testInterface)
+	copy(packet[6:12], net.HardwareAddr{0x02, 0, 0, 0, 0, 0xcc})
+	binary.BigEndian.PutUint16(packet[12:], 0x0800)
+	ip := packet[14:]
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:], 40)
+	ip[8] = 64
+	ip[9] = 6
+	source, dest := client.Addr().As4(), destination.Addr().As4()
+	copy(ip[12:16], source[:])
+	copy(ip[16:20], dest[:])
+	tcp := ip[20:]
+	binary.BigEndian.PutUint16(tcp[0:], client.Port())
+	binary.BigEndian.PutUint16(tcp[2:], destination.Port())
+	tcp[12] = 5 << 4
+	return packet
+}
+
+func TestProgramPasses(t *testing.T) {
+	prog, m := loadTestProgram(t)
+	backend := netip.MustParseAddr("192.168.0.10")
+	if err := m.setBackends(map[netip.Addr]backendValue{backend: newBackendValue(testInterface, net.HardwareAddr{0x02, 0, 0, 0, 0, 0x10}, false)}); err != nil {
+		t.Fatal(err)
+	}
+	client := netip.MustParseAddrPort("10.0.0.1:40000")
+
+	arp := make([]byte, 60)
+	binary.BigEndian.PutUint16(arp[12:], 0x0806)
+	otherAddress := tcpPacket(client, netip.MustParseAddrPort("192.168.0.101:6443"))
+	otherPort := tcpPacket(client, netip.AddrPortFrom(testVIP, 22))
+	fragment := tcpPacket(client, netip.AddrPortFrom(testVIP, 6443))
+	binary.BigEndian.PutUint16(fragment[14+6:], 0x2000)
+	options := tcpPacket(client, netip.AddrPortFrom(testVIP, 6443))
+	options[14] = 0x46
+
+	for name, packet := range map[string][]byte{
+		"arp":           arp,
+		"other address": otherAddress,
+		"other port":    otherPort,
+		"fragment":      fragment,
+		"ip options":    options,
+		"truncated":     otherPort[:14+20],
+	} {
+		verdict, _, err := prog.Test(packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verdict != xdpPass {
+			t.Errorf("%s: verdict = %d, want %d", name, verdict, xdpPass)
+		}
+	}
+	packets, err := m.packets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packets[backend] != 0 {
+		t.Errorf("packets balanced to the backend = %d, want 0", packets[backend])
+	}
+}
+
+func TestProgramBalances(t *testing.T) {
+	prog, m := loadTestProgram(t)
+	client := netip.MustParseAddrPort("10.0.0.1:40000")
+	packet := tcpPacket(client, netip.AddrPortFrom(testVIP, 6443))
+
+	// Without a backend the packets are passed to the kernel and counted as unbalanced
+	verdict, _, err := prog.Test(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict != xdpPass {
+		t.Errorf("verdict without backends = %d, want %d", verdict, xdpPass)
+	}
+
+	first, second := netip.MustParseAddr("192.168.0.10"), netip.MustParseAddr("192.168.0.11")
+	macs := map[netip.Addr]net.HardwareAddr{
+		first:  {0x02, 0, 0, 0, 0, 0x10},
+		second: {0x02, 0, 0, 0, 0, 0x11},
+	}
+	if err = m.setBackends(map[netip.Addr]backendValue{
+		first:  newBackendValue(testInterface, macs[first], false),
+		second: newBackendValue(testInterface, macs[second], false),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	verdict, out, err := prog.Test(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict != xdpTX {
+		t.Fatalf("verdict = %d, want %d", verdict, xdpTX)
+	}
+	if !bytes.Equal(out[6:12], testInterface) {
+		t.Errorf("source MAC = %s, want %s", net.HardwareAddr(out[6:12]), testInterface)
+	}
+	if !bytes.Equal(out[12:], packet[12:]) {
+		t.Error("the packet was changed beyond its MAC addresses")
+	}
+	var chosen netip.Addr
+	for backend, mac := range macs {
+		if bytes.Equal(out[0:6], mac) {
+			chosen = backend
+		}
+	}
+	if !chosen.IsValid() {
+		t.Fatalf("destination MAC = %s, which isn't a backend", net.HardwareAddr(out[0:6]))
+	}
+
+	// The connection stays with its backend
+	for x := 0; x < 3; x++ {
+		_, out, err = prog.Test(packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out[0:6], macs[chosen]) {
+			t.Fatalf("destination MAC = %s, want the tracked backend %s", net.HardwareAddr(out[0:6]), macs[chosen])
+		}
+	}
+	connections, err := m.connectionCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connections != 1 {
+		t.Errorf("connections = %d, want 1", connections)
+	}
+
+	// Once its backend has gone the connection moves to the other backend
+	other := first
+	if chosen == first {
+		other = second
+	}
+	if err = m.setBackends(map[netip.Addr]backendValue{other: newBackendValue(testInterface, macs[other], false)}); err != nil {
+		t.Fatal(err)
+	}
+	_, out, err = prog.Test(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out[0:6], macs[other]) {
+		t.Errorf("destination MAC = %s, want %s", net.HardwareAddr(out[0:6]), macs[other])
+	}
+
+	packets, err := m.packets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packets[netip.IPv4Unspecified()] != 1 || packets[chosen] != 4 || packets[other] != 1 {
+		t.Errorf("packets = %v, want 1 unbalanced, 4 to %s and 1 to %s", packets, chosen, other)
+	}
+}
+
+func TestProgramLocalBackend(t *testing.T) {
+	prog, m := loadTestProgram(t)
+	local := netip.MustParseAddr("192.168.0.10")
+	if err := m.setBackends(map[netip.Addr]backendValue{local: newBackendValue(nil, nil, true)}); err != nil {
+		t.Fatal(err)
+	}
+	packet := tcpPacket(netip.MustParseAddrPort("10.0.0.1:40000"), netip.AddrPortFrom(testVIP, 6443))
+	verdict, out, err := prog.Test(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict != xdpPass || !bytes.Equal(out, packet) {
+		t.Errorf("verdict = %d, want the packet passed to the kernel unchanged", verdict)
+	}
+	packets, err := m.packets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packets[local] != 1 {
+		t.Errorf("packets balanced to the local backend = %d, want 1", packets[local])
+	}
+}
+
+func TestSlots(t *testing.T) {
+	a, b, c := netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("10.0.0.2"), netip.MustParseAddr("10.0.0.3")
+
+	for _, slot := range slots(nil, 8) {
+		if slot.IsValid() {
+			t.Fatalf("slots(nil) has the backend %s", slot)
+		}
+	}
+
+	ring := slots([]netip.Addr{c, a, b}, 8)
+	want := []netip.Addr{a, b, c, a, b, c, a, b}
+	for x := range want {
+		if ring[x] != want[x] {
+			t.Errorf("slot %d = %s, want %s", x, ring[x], want[x])
+		}
+	}
+
+	// Every node agrees on the ring, whatever the order it learned the backends in
+	again := slots([]netip.Addr{b, c, a}, 8)
+	for x := range ring {
+		if ring[x] != again[x] {
+			t.Errorf("slot %d = %s with the backends in another order, want %s", x, again[x], ring[x])
+		}
+	}
+}